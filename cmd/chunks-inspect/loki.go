@@ -50,8 +50,10 @@ var (
 		}
 		return r, nil
 	}}
+	// blocks of zstd-dict chunks compressed with a trained dictionary can't be decompressed without it.
+	encZstdDict = Encoding{code: 10, name: "zstd-dict", readerFn: encZstd.readerFn}
 
-	Encodings = []Encoding{encNone, encGZIP, encDumb, encLZ4, encSnappy, enclz4_256k, enclz4_1M, enclz4_4M, encFlate, encZstd}
+	Encodings = []Encoding{encNone, encGZIP, encDumb, encLZ4, encSnappy, enclz4_256k, enclz4_1M, enclz4_4M, encFlate, encZstd, encZstdDict}
 )
 
 const (
@@ -59,6 +61,19 @@ const (
 	chunkFormatV1
 	chunkFormatV2
 	chunkFormatV3
+	chunkFormatV4
+	chunkFormatV5
+)
+
+const (
+	chunkMetasSectionIdx              = 1
+	chunkStructuredMetadataSectionIdx = 2
+	chunkDictSectionIdx               = 3
+
+	// columnarBlockFlagDict is set on columnar blocks which lines are compressed with a dictionary of the chunk.
+	columnarBlockFlagDict = 1
+	// columnarDictID is the ID the dictionaries of the chunks are registered with.
+	columnarDictID = 1
 )
 
 type LokiChunk struct {
@@ -67,6 +82,11 @@ type LokiChunk struct {
 
 	blocks []LokiBlock
 
+	symbols []string // structured metadata names and values (V4 chunks and greater only)
+	dicts   [][]byte // zstd dictionaries of the lines (V5 chunks only)
+
+	zstdDictID uint32 // ID of the trained dictionary of zstd-dict chunks, 0 when there is none
+
 	metadataChecksum         uint32
 	computedMetadataChecksum uint32
 }
@@ -90,8 +110,9 @@ type LokiBlock struct {
 }
 
 type LokiEntry struct {
-	timestamp int64
-	line      string
+	timestamp          int64
+	line               string
+	structuredMetadata []string // name=value pairs
 }
 
 func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
@@ -101,6 +122,11 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 	4B magic number
 	1B version
 	1B encoding
+	4B zstd dictionary ID (zstd-dict chunks only)
+	Structured metadata (V4 chunks and greater only) <----C
+	Structured metadata Checksum
+	Dictionaries (V5 chunks only) <----------------------D
+	Dictionaries Checksum
 	Block 1 <------------------------------------B
 	Block 1 Checksum
 	...
@@ -113,7 +139,13 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 	Block1 Uvarint length
 	Block1 Meta Checksum
 	...
-	4B Meta offset ----------------------------> A
+	8B Dictionaries length, 8B offset ----------> D (V5 chunks only)
+	8B Structured metadata length, 8B offset ---> C (V4 chunks and greater only)
+	8B Meta length (V4 chunks and greater only)
+	8B Meta offset ----------------------------> A
+
+	Starting from V5, blocks are stored as separate compressed columns:
+	timestamps, structured metadata symbols per name, then lines.
 	*/
 
 	// Loki chunks need to be loaded into memory, because some offsets are actually stored at the end.
@@ -134,15 +166,47 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		return nil, fmt.Errorf("failed to read compression: %w", err)
 	}
 
-	// return &LokiChunk{encoding: compression}, nil
+	var zstdDictID uint32
+	if compression.code == encZstdDict.code {
+		zstdDictID = binary.BigEndian.Uint32(data[6:10])
+	}
 
-	metasOffset := binary.BigEndian.Uint64(data[len(data)-8:])
+	// sectionLenAndOffset reads the length and offset of a section from the end of V4+ chunks.
+	sectionLenAndOffset := func(idx int) (uint64, uint64) {
+		pos := len(data) - idx*16
+		return binary.BigEndian.Uint64(data[pos : pos+8]), binary.BigEndian.Uint64(data[pos+8 : pos+16])
+	}
 
-	metadata := data[metasOffset : len(data)-(8+4)]
+	var metasOffset, metasLen uint64
+	if f >= chunkFormatV4 {
+		metasLen, metasOffset = sectionLenAndOffset(chunkMetasSectionIdx)
+	} else {
+		metasOffset = binary.BigEndian.Uint64(data[len(data)-8:])
+		metasLen = uint64(len(data)-(8+4)) - metasOffset
+	}
+	metadata := data[metasOffset : metasOffset+metasLen]
 
-	metaChecksum := binary.BigEndian.Uint32(data[len(data)-12 : len(data)-8])
+	metaChecksum := binary.BigEndian.Uint32(data[metasOffset+metasLen:])
 	computedMetaChecksum := crc32.Checksum(metadata, castagnoliTable)
 
+	var symbols []string
+	if f >= chunkFormatV4 {
+		l, o := sectionLenAndOffset(chunkStructuredMetadataSectionIdx)
+		symbols, err = parseSymbols(compression, data[o:o+l])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read structured metadata: %w", err)
+		}
+	}
+
+	var dicts [][]byte
+	if f >= chunkFormatV5 {
+		l, o := sectionLenAndOffset(chunkDictSectionIdx)
+		dicts, err = parseDicts(data[o : o+l])
+		if err != nil {
+			return nil, fmt.Errorf("failed to read dictionaries: %w", err)
+		}
+	}
+
 	blocks, n := binary.Uvarint(metadata)
 	if n <= 0 {
 		return nil, fmt.Errorf("failed to read number of blocks")
@@ -152,6 +216,9 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 	lokiChunk := &LokiChunk{
 		format:                   f,
 		encoding:                 compression,
+		symbols:                  symbols,
+		dicts:                    dicts,
+		zstdDictID:               zstdDictID,
 		metadataChecksum:         metaChecksum,
 		computedMetadataChecksum: computedMetaChecksum,
 	}
@@ -175,14 +242,18 @@ func parseLokiChunk(chunkHeader *ChunkHeader, r io.Reader) (*LokiChunk, error) {
 		block.rawData = data[block.dataOffset : block.dataOffset+dataLength]
 		block.storedChecksum = binary.BigEndian.Uint32(data[block.dataOffset+dataLength : block.dataOffset+dataLength+4])
 		block.computedChecksum = crc32.Checksum(block.rawData, castagnoliTable)
-		block.originalData, block.entries, err = parseLokiBlock(compression, block.rawData)
+		if f >= chunkFormatV5 {
+			block.originalData, block.entries, err = parseColumnarBlock(compression, block.rawData, symbols, dicts)
+		} else {
+			block.originalData, block.entries, err = parseLokiBlock(f, compression, block.rawData, symbols)
+		}
 		lokiChunk.blocks = append(lokiChunk.blocks, block)
 	}
 
 	return lokiChunk, nil
 }
 
-func parseLokiBlock(compression Encoding, data []byte) ([]byte, []LokiEntry, error) {
+func parseLokiBlock(format byte, compression Encoding, data []byte, symbols []string) ([]byte, []LokiEntry, error) {
 	r, err := compression.readerFn(bytes.NewReader(data))
 	if err != nil {
 		return nil, nil, err
@@ -209,17 +280,218 @@ func parseLokiBlock(compression Encoding, data []byte) ([]byte, []LokiEntry, err
 			return origDecompressed, nil, fmt.Errorf("not enough line data, need %d, got %d", lineLength, len(decompressed))
 		}
 
-		entries = append(entries, LokiEntry{
+		entry := LokiEntry{
 			timestamp: timestamp,
 			line:      string(decompressed[0:lineLength]),
-		})
-
+		}
 		decompressed = decompressed[lineLength:]
+
+		if format >= chunkFormatV4 {
+			var numSymbols, name, value uint64
+			// skip the length of the symbols section
+			_, decompressed, err = readUvarint(err, decompressed)
+			numSymbols, decompressed, err = readUvarint(err, decompressed)
+			for i := uint64(0); i < numSymbols; i++ {
+				name, decompressed, err = readUvarint(err, decompressed)
+				value, decompressed, err = readUvarint(err, decompressed)
+				entry.structuredMetadata = append(entry.structuredMetadata, symbolPair(symbols, name, value))
+			}
+			if err != nil {
+				return origDecompressed, nil, err
+			}
+		}
+
+		entries = append(entries, entry)
 	}
 
 	return origDecompressed, entries, nil
 }
 
+// parseColumnarBlock parses the timestamps, structured metadata and lines columns of V5 blocks.
+func parseColumnarBlock(compression Encoding, data []byte, symbols []string, dicts [][]byte) ([]byte, []LokiEntry, error) {
+	var err error
+	var numEntries, numColumns, name, dictIdx uint64
+	numEntries, data, err = readUvarint(err, data)
+	if err != nil || len(data) == 0 {
+		return nil, nil, fmt.Errorf("failed to read block header: %v", err)
+	}
+	flags := data[0]
+	data = data[1:]
+	if flags&columnarBlockFlagDict != 0 {
+		dictIdx, data, err = readUvarint(err, data)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read block header: %v", err)
+		}
+		if dictIdx >= uint64(len(dicts)) {
+			return nil, nil, fmt.Errorf("block compressed with dictionary %d but the chunk has %d", dictIdx, len(dicts))
+		}
+	}
+
+	var origDecompressed []byte
+	readColumn := func() ([]byte, error) {
+		var l uint64
+		l, data, err = readUvarint(err, data)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(data)) < l {
+			return nil, fmt.Errorf("not enough column data, need %d, got %d", l, len(data))
+		}
+		column := data[:l]
+		data = data[l:]
+		r, err := compression.readerFn(bytes.NewReader(column))
+		if err != nil {
+			return nil, err
+		}
+		decompressed, err := io.ReadAll(r)
+		origDecompressed = append(origDecompressed, decompressed...)
+		return decompressed, err
+	}
+
+	timestamps, err := readColumn()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read timestamps column: %w", err)
+	}
+	entries := make([]LokiEntry, numEntries)
+	var ts, delta int64
+	for i := range entries {
+		delta, timestamps, err = readVarint(err, timestamps)
+		ts += delta
+		entries[i].timestamp = ts
+	}
+	if err != nil {
+		return origDecompressed, nil, err
+	}
+
+	numColumns, data, err = readUvarint(err, data)
+	for c := uint64(0); c < numColumns && err == nil; c++ {
+		name, data, err = readUvarint(err, data)
+		var values []byte
+		if values, err = readColumn(); err != nil {
+			return origDecompressed, nil, fmt.Errorf("failed to read structured metadata column: %w", err)
+		}
+		var value uint64
+		for i := range entries {
+			value, values, err = readUvarint(err, values)
+			if err == nil && value != 0 {
+				entries[i].structuredMetadata = append(entries[i].structuredMetadata, symbolPair(symbols, name, value-1))
+			}
+		}
+	}
+	if err != nil {
+		return origDecompressed, nil, err
+	}
+
+	var lines []byte
+	if flags&columnarBlockFlagDict == 0 {
+		lines, err = readColumn()
+	} else {
+		lines, err = decompressWithDict(data, dicts[dictIdx])
+		origDecompressed = append(origDecompressed, lines...)
+	}
+	if err != nil {
+		return origDecompressed, nil, fmt.Errorf("failed to read lines column: %w", err)
+	}
+	for i := range entries {
+		var lineLength uint64
+		lineLength, lines, err = readUvarint(err, lines)
+		if err != nil {
+			return origDecompressed, nil, err
+		}
+		if uint64(len(lines)) < lineLength {
+			return origDecompressed, nil, fmt.Errorf("not enough line data, need %d, got %d", lineLength, len(lines))
+		}
+		entries[i].line = string(lines[:lineLength])
+		lines = lines[lineLength:]
+	}
+
+	return origDecompressed, entries, nil
+}
+
+func decompressWithDict(data, dict []byte) ([]byte, error) {
+	var err error
+	var l uint64
+	l, data, err = readUvarint(err, data)
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(data)) < l {
+		return nil, fmt.Errorf("not enough column data, need %d, got %d", l, len(data))
+	}
+
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderDictRaw(columnarDictID, dict), zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+	return dec.DecodeAll(data[:l], nil)
+}
+
+// parseDicts parses the dictionaries section: the number of dictionaries followed by the length and content of each.
+func parseDicts(data []byte) ([][]byte, error) {
+	var err error
+	var num, l uint64
+	num, data, err = readUvarint(err, data)
+	dicts := make([][]byte, 0, num)
+	for i := uint64(0); i < num && err == nil; i++ {
+		l, data, err = readUvarint(err, data)
+		if err == nil && uint64(len(data)) < l {
+			return nil, fmt.Errorf("not enough dictionary data, need %d, got %d", l, len(data))
+		}
+		if err == nil {
+			dicts = append(dicts, data[:l])
+			data = data[l:]
+		}
+	}
+	return dicts, err
+}
+
+// parseSymbols parses the structured metadata section: the number of symbols followed by the compressed symbols.
+func parseSymbols(compression Encoding, data []byte) ([]string, error) {
+	var err error
+	var numSymbols, l uint64
+	numSymbols, data, err = readUvarint(err, data)
+	if err != nil {
+		return nil, err
+	}
+	if numSymbols == 0 {
+		return nil, nil
+	}
+
+	r, err := compression.readerFn(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	decompressed, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	symbols := make([]string, 0, numSymbols)
+	for i := uint64(0); i < numSymbols; i++ {
+		l, decompressed, err = readUvarint(err, decompressed)
+		if err != nil {
+			return nil, err
+		}
+		if uint64(len(decompressed)) < l {
+			return nil, fmt.Errorf("not enough symbol data, need %d, got %d", l, len(decompressed))
+		}
+		symbols = append(symbols, string(decompressed[:l]))
+		decompressed = decompressed[l:]
+	}
+	return symbols, nil
+}
+
+func symbolPair(symbols []string, name, value uint64) string {
+	lookup := func(idx uint64) string {
+		if idx >= uint64(len(symbols)) {
+			return fmt.Sprintf("<unknown symbol %d>", idx)
+		}
+		return symbols[idx]
+	}
+	return fmt.Sprintf("%s=%s", lookup(name), lookup(value))
+}
+
 func readVarint(prevErr error, buf []byte) (int64, []byte, error) {
 	if prevErr != nil {
 		return 0, buf, prevErr
@@ -12,14 +12,20 @@ import (
 )
 
 var (
-	ruleCommand  commands.RuleCommand
-	auditCommand commands.AuditCommand
+	ruleCommand   commands.RuleCommand
+	auditCommand  commands.AuditCommand
+	exportCommand commands.ExportCommand
+	importCommand commands.ImportCommand
+	tenantCommand commands.TenantCommand
 )
 
 func main() {
 	app := kingpin.New("lokitool", "A command-line tool to manage Loki.")
 	ruleCommand.Register(app)
 	auditCommand.Register(app)
+	exportCommand.Register(app)
+	importCommand.Register(app)
+	tenantCommand.Register(app)
 
 	app.Command("version", "Get the version of the lokitool CLI").Action(func(_ *kingpin.ParseContext) error {
 		fmt.Println(version.Print("loki"))
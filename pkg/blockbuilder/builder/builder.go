@@ -4,7 +4,6 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"math"
 	"sync"
 	"time"
 
@@ -23,13 +22,9 @@ import (
 	"github.com/grafana/loki/v3/pkg/kafka"
 	"github.com/grafana/loki/v3/pkg/kafka/client"
 	"github.com/grafana/loki/v3/pkg/logproto"
-	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/storage/stores"
-	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
-	storagetypes "github.com/grafana/loki/v3/pkg/storage/types"
 	"github.com/grafana/loki/v3/pkg/util/flagext"
-	util_log "github.com/grafana/loki/v3/pkg/util/log"
 )
 
 type Config struct {
@@ -306,199 +301,20 @@ func (i *BlockBuilder) processJob(ctx context.Context, c *kgo.Client, job *types
 	level.Debug(logger).Log("msg", "beginning job")
 	start := time.Now()
 
-	indexer := newTsdbCreator()
-	appender := newAppender(i.id,
-		i.cfg,
-		i.periodicConfigs,
-		i.store,
-		i.objStore,
-		logger,
-		i.metrics,
-	)
-
 	var lastOffset int64
-	p := newPipeline(ctx)
-
-	// Pipeline stage 1: Process the job offsets and write records to inputCh
-	// This stage reads from the partition and feeds records into the input channel
-	// When complete, it stores the last processed offset and closes the channel
-	inputCh := make(chan []AppendInput)
-	p.AddStageWithCleanup(
-		"load records",
-		1,
-		func(ctx context.Context) error {
-			lastOffset, err = i.loadRecords(ctx, c, job.Partition(), job.Offsets(), inputCh)
-			return errors.Wrap(err, "loading records")
-		},
-		func(ctx context.Context) error {
-			level.Debug(logger).Log(
-				"msg", "finished loading records",
-				"ctx_error", ctx.Err(),
-				"last_offset", lastOffset,
-				"total_records", lastOffset-job.Offsets().Min,
-			)
-			close(inputCh)
-			return nil
-		},
-	)
-
-	// Stage 2: Process input records and generate chunks
-	// This stage receives AppendInput batches, appends them to appropriate instances,
-	// and forwards any cut chunks to the chunks channel for flushing.
-	// ConcurrentWriters workers process inputs in parallel to maximize throughput.
-	flush := make(chan *chunk.Chunk)
-	p.AddStageWithCleanup(
-		"appender",
-		i.cfg.ConcurrentWriters,
-		func(ctx context.Context) error {
-
-			for {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case inputs, ok := <-inputCh:
-					// inputs are finished; we're done
-					if !ok {
-						return nil
-					}
-
-					for _, input := range inputs {
-						cut, err := appender.Append(ctx, input)
-						if err != nil {
-							level.Error(logger).Log("msg", "failed to append records", "err", err)
-							return errors.Wrap(err, "appending records")
-						}
-
-						for _, chk := range cut {
-							select {
-							case <-ctx.Done():
-								return ctx.Err()
-							case flush <- chk:
-							}
-						}
-					}
-				}
-			}
-		},
-		func(ctx context.Context) (err error) {
-			defer func() {
-				level.Debug(logger).Log(
-					"msg", "finished appender",
-					"err", err,
-					"ctx_error", ctx.Err(),
-				)
-			}()
-			defer close(flush)
-
-			// once we're done appending, cut all remaining chunks.
-			chks, err := appender.CutRemainingChunks(ctx)
-			if err != nil {
-				return errors.Wrap(err, "cutting remaining chunks")
-			}
-
-			for _, chk := range chks {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case flush <- chk:
-				}
-			}
-			return nil
-		},
-	)
-
-	// Stage 3: Flush chunks to storage
-	// This stage receives chunks from the chunks channel and flushes them to storage
-	// using ConcurrentFlushes workers for parallel processing
-	p.AddStage(
-		"flusher",
-		i.cfg.ConcurrentFlushes,
-		func(ctx context.Context) error {
-			for {
-				select {
-				case <-ctx.Done():
-					return ctx.Err()
-				case chk, ok := <-flush:
-					if !ok {
-						return nil
-					}
-					if _, err := withBackoff(
-						ctx,
-						i.cfg.Backoff, // retry forever
-						func() (res struct{}, err error) {
-							err = i.store.PutOne(ctx, chk.From, chk.Through, *chk)
-							if err != nil {
-								level.Error(logger).Log("msg", "failed to flush chunk", "err", err)
-								i.metrics.chunksFlushFailures.Inc()
-								return res, errors.Wrap(err, "flushing chunk")
-							}
-							appender.reportFlushedChunkStatistics(chk)
-
-							// write flushed chunk to index
-							approxKB := math.Round(float64(chk.Data.UncompressedSize()) / float64(1<<10))
-							meta := index.ChunkMeta{
-								Checksum: chk.ChunkRef.Checksum,
-								MinTime:  int64(chk.ChunkRef.From),
-								MaxTime:  int64(chk.ChunkRef.Through),
-								KB:       uint32(approxKB),
-								Entries:  uint32(chk.Data.Entries()),
-							}
-							err = indexer.Append(chk.UserID, chk.Metric, chk.ChunkRef.Fingerprint, index.ChunkMetas{meta})
-							if err != nil {
-								level.Error(logger).Log("msg", "failed to append chunk to index", "err", err)
-								return res, errors.Wrap(err, "appending chunk to index")
-							}
-
-							return
-						},
-					); err != nil {
-						return err
-					}
-				}
-			}
-		},
-	)
-
-	err = p.Run()
-	level.Debug(logger).Log(
-		"msg", "finished chunk creation",
-		"err", err,
-	)
-	if err != nil {
-		return errors.Wrap(err, "running pipeline")
-	}
-
-	var (
-		nodeName    = i.id
-		tableRanges = config.GetIndexStoreTableRanges(storagetypes.TSDBType, i.periodicConfigs)
-	)
-
-	built, err := indexer.create(ctx, nodeName, tableRanges)
+	w := newWriter(i.id, i.cfg, i.periodicConfigs, i.store, i.objStore, i.metrics, logger)
+	err = w.write(ctx, func(ctx context.Context, ch chan<- []AppendInput) (err error) {
+		lastOffset, err = i.loadRecords(ctx, c, job.Partition(), job.Offsets(), ch)
+		level.Debug(logger).Log(
+			"msg", "finished loading records",
+			"ctx_error", ctx.Err(),
+			"last_offset", lastOffset,
+			"total_records", lastOffset-job.Offsets().Min,
+		)
+		return errors.Wrap(err, "loading records")
+	})
 	if err != nil {
-		level.Error(logger).Log("msg", "failed to build index", "err", err)
-		return errors.Wrap(err, "building index")
-	}
-
-	u := newUploader(i.objStore)
-	for _, db := range built {
-		if _, err := withBackoff(ctx, i.cfg.Backoff, func() (res struct{}, err error) {
-			err = u.Put(ctx, db)
-			if err != nil {
-				level.Error(util_log.Logger).Log(
-					"msg", "failed to upload tsdb",
-					"path", db.id.Path(),
-				)
-				return res, errors.Wrap(err, "uploading tsdb")
-			}
-
-			level.Debug(logger).Log(
-				"msg", "uploaded tsdb",
-				"name", db.id.Name(),
-			)
-			return
-		}); err != nil {
-			return errors.Wrap(err, "running pipeline")
-		}
+		return err
 	}
 
 	// log success
@@ -0,0 +1,285 @@
+package builder
+
+import (
+	"context"
+	"math"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+	storagetypes "github.com/grafana/loki/v3/pkg/storage/types"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+
+	"github.com/grafana/loki/pkg/push"
+)
+
+// NewAppendInput creates an AppendInput adding the entries to the stream of the tenant.
+func NewAppendInput(tenant string, ls labels.Labels, entries []push.Entry) AppendInput {
+	return AppendInput{
+		tenant:    tenant,
+		labels:    ls,
+		labelsStr: ls.String(),
+		entries:   entries,
+	}
+}
+
+// LoadFunc sends the inputs to write to the channel, which is closed once it returns.
+type LoadFunc func(ctx context.Context, ch chan<- []AppendInput) error
+
+// Writer builds chunks and TSDB indexes from the loaded inputs and flushes them to storage,
+// outside of the block builder jobs, e.g. to import historical data.
+type Writer struct {
+	writer *writer
+}
+
+// NewWriter creates a new Writer.
+func NewWriter(
+	id string,
+	cfg Config,
+	periodicConfigs []config.PeriodConfig,
+	store stores.ChunkWriter,
+	objStore *MultiStore,
+	logger log.Logger,
+	registerer prometheus.Registerer,
+) *Writer {
+	return &Writer{
+		writer: newWriter(id, cfg, periodicConfigs, store, objStore, newBuilderMetrics(registerer), logger),
+	}
+}
+
+// Write writes the inputs loaded by load to storage. The chunks and indexes are
+// only complete once Write returns without error.
+func (w *Writer) Write(ctx context.Context, load LoadFunc) error {
+	return w.writer.write(ctx, load)
+}
+
+// writer is a single use construct writing the chunks and indexes of a batch of inputs.
+type writer struct {
+	id              string
+	cfg             Config
+	periodicConfigs []config.PeriodConfig
+
+	store    stores.ChunkWriter
+	objStore *MultiStore
+
+	metrics *builderMetrics
+	logger  log.Logger
+}
+
+func newWriter(
+	id string,
+	cfg Config,
+	periodicConfigs []config.PeriodConfig,
+	store stores.ChunkWriter,
+	objStore *MultiStore,
+	metrics *builderMetrics,
+	logger log.Logger,
+) *writer {
+	return &writer{
+		id:              id,
+		cfg:             cfg,
+		periodicConfigs: periodicConfigs,
+		store:           store,
+		objStore:        objStore,
+		metrics:         metrics,
+		logger:          logger,
+	}
+}
+
+func (w *writer) write(ctx context.Context, load LoadFunc) error {
+	logger := w.logger
+	indexer := newTsdbCreator()
+	appender := newAppender(w.id,
+		w.cfg,
+		w.periodicConfigs,
+		w.store,
+		w.objStore,
+		logger,
+		w.metrics,
+	)
+
+	p := newPipeline(ctx)
+
+	// Pipeline stage 1: Load the inputs and write them to inputCh
+	// When complete, it closes the channel
+	inputCh := make(chan []AppendInput)
+	p.AddStageWithCleanup(
+		"load records",
+		1,
+		func(ctx context.Context) error {
+			return load(ctx, inputCh)
+		},
+		func(_ context.Context) error {
+			close(inputCh)
+			return nil
+		},
+	)
+
+	// Stage 2: Process input records and generate chunks
+	// This stage receives AppendInput batches, appends them to appropriate instances,
+	// and forwards any cut chunks to the chunks channel for flushing.
+	// ConcurrentWriters workers process inputs in parallel to maximize throughput.
+	flush := make(chan *chunk.Chunk)
+	p.AddStageWithCleanup(
+		"appender",
+		w.cfg.ConcurrentWriters,
+		func(ctx context.Context) error {
+
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case inputs, ok := <-inputCh:
+					// inputs are finished; we're done
+					if !ok {
+						return nil
+					}
+
+					for _, input := range inputs {
+						cut, err := appender.Append(ctx, input)
+						if err != nil {
+							level.Error(logger).Log("msg", "failed to append records", "err", err)
+							return errors.Wrap(err, "appending records")
+						}
+
+						for _, chk := range cut {
+							select {
+							case <-ctx.Done():
+								return ctx.Err()
+							case flush <- chk:
+							}
+						}
+					}
+				}
+			}
+		},
+		func(ctx context.Context) (err error) {
+			defer func() {
+				level.Debug(logger).Log(
+					"msg", "finished appender",
+					"err", err,
+					"ctx_error", ctx.Err(),
+				)
+			}()
+			defer close(flush)
+
+			// once we're done appending, cut all remaining chunks.
+			chks, err := appender.CutRemainingChunks(ctx)
+			if err != nil {
+				return errors.Wrap(err, "cutting remaining chunks")
+			}
+
+			for _, chk := range chks {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case flush <- chk:
+				}
+			}
+			return nil
+		},
+	)
+
+	// Stage 3: Flush chunks to storage
+	// This stage receives chunks from the chunks channel and flushes them to storage
+	// using ConcurrentFlushes workers for parallel processing
+	p.AddStage(
+		"flusher",
+		w.cfg.ConcurrentFlushes,
+		func(ctx context.Context) error {
+			for {
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case chk, ok := <-flush:
+					if !ok {
+						return nil
+					}
+					if _, err := withBackoff(
+						ctx,
+						w.cfg.Backoff, // retry forever
+						func() (res struct{}, err error) {
+							err = w.store.PutOne(ctx, chk.From, chk.Through, *chk)
+							if err != nil {
+								level.Error(logger).Log("msg", "failed to flush chunk", "err", err)
+								w.metrics.chunksFlushFailures.Inc()
+								return res, errors.Wrap(err, "flushing chunk")
+							}
+							appender.reportFlushedChunkStatistics(chk)
+
+							// write flushed chunk to index
+							approxKB := math.Round(float64(chk.Data.UncompressedSize()) / float64(1<<10))
+							meta := index.ChunkMeta{
+								Checksum: chk.ChunkRef.Checksum,
+								MinTime:  int64(chk.ChunkRef.From),
+								MaxTime:  int64(chk.ChunkRef.Through),
+								KB:       uint32(approxKB),
+								Entries:  uint32(chk.Data.Entries()),
+							}
+							err = indexer.Append(chk.UserID, chk.Metric, chk.ChunkRef.Fingerprint, index.ChunkMetas{meta})
+							if err != nil {
+								level.Error(logger).Log("msg", "failed to append chunk to index", "err", err)
+								return res, errors.Wrap(err, "appending chunk to index")
+							}
+
+							return
+						},
+					); err != nil {
+						return err
+					}
+				}
+			}
+		},
+	)
+
+	err := p.Run()
+	level.Debug(logger).Log(
+		"msg", "finished chunk creation",
+		"err", err,
+	)
+	if err != nil {
+		return errors.Wrap(err, "running pipeline")
+	}
+
+	var (
+		nodeName    = w.id
+		tableRanges = config.GetIndexStoreTableRanges(storagetypes.TSDBType, w.periodicConfigs)
+	)
+
+	built, err := indexer.create(ctx, nodeName, tableRanges)
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to build index", "err", err)
+		return errors.Wrap(err, "building index")
+	}
+
+	u := newUploader(w.objStore)
+	for _, db := range built {
+		if _, err := withBackoff(ctx, w.cfg.Backoff, func() (res struct{}, err error) {
+			err = u.Put(ctx, db)
+			if err != nil {
+				level.Error(util_log.Logger).Log(
+					"msg", "failed to upload tsdb",
+					"path", db.id.Path(),
+				)
+				return res, errors.Wrap(err, "uploading tsdb")
+			}
+
+			level.Debug(logger).Log(
+				"msg", "uploaded tsdb",
+				"name", db.id.Name(),
+			)
+			return
+		}); err != nil {
+			return errors.Wrap(err, "running pipeline")
+		}
+	}
+
+	return nil
+}
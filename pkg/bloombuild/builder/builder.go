@@ -388,6 +388,16 @@ func (b *Builder) processTask(
 		return nil, fmt.Errorf("failed to parse block encoding: %w", err)
 	}
 
+	lineTokenizerMode, err := v1.ParseLineTokenizerMode(b.limits.BloomLineTokenizer(tenant))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse line tokenizer: %w", err)
+	}
+	lineTokenizer := v1.LineTokenizerConfig{
+		Mode:        lineTokenizerMode,
+		NGramLength: b.limits.BloomNGramLength(tenant),
+		NGramSkip:   b.limits.BloomNGramSkip(tenant),
+	}
+
 	var (
 		blockCt      int
 		maxBlockSize = uint64(b.limits.BloomMaxBlockSize(tenant))
@@ -397,6 +407,7 @@ func (b *Builder) processTask(
 		totalSeries  int
 		bytesAdded   int
 	)
+	blockOpts.Schema = blockOpts.Schema.WithLineTokenizer(lineTokenizer)
 
 	for i := range task.Gaps {
 		if ctx.Err() != nil {
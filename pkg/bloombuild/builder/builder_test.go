@@ -335,6 +335,18 @@ func (f fakeLimits) BloomMaxBloomSize(_ string) int {
 	return 0
 }
 
+func (f fakeLimits) BloomLineTokenizer(_ string) string {
+	return "none"
+}
+
+func (f fakeLimits) BloomNGramLength(_ string) int {
+	return 0
+}
+
+func (f fakeLimits) BloomNGramSkip(_ string) int {
+	return 0
+}
+
 func (f fakeLimits) BuilderResponseTimeout(_ string) time.Duration {
 	return f.taskTimout
 }
@@ -41,6 +41,9 @@ type Limits interface {
 	BloomBlockEncoding(tenantID string) string
 	BloomMaxBlockSize(tenantID string) int
 	BloomMaxBloomSize(tenantID string) int
+	BloomLineTokenizer(tenantID string) string
+	BloomNGramLength(tenantID string) int
+	BloomNGramSkip(tenantID string) int
 	BuilderResponseTimeout(tenantID string) time.Duration
 	PrefetchBloomBlocks(tenantID string) bool
 }
@@ -69,6 +69,7 @@ func NewSimpleBloomGenerator(
 
 		tokenizer: v1.NewBloomTokenizer(
 			int(opts.UnencodedBlockOptions.MaxBloomSizeBytes),
+			opts.Schema.LineTokenizer(),
 			metrics,
 			log.With(
 				logger,
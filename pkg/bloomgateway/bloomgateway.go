@@ -231,7 +231,7 @@ func (g *Gateway) FilterChunkRefs(ctx context.Context, req *logproto.FilterChunk
 		return nil, errors.New("from time must not be after through time")
 	}
 
-	matchers := v1.ExtractTestableLabelMatchers(req.Plan.AST)
+	matchers := v1.ExtractTestableMatchers(req.Plan.AST)
 	stats.NumMatchers = len(matchers)
 	g.metrics.receivedMatchers.Observe(float64(len(matchers)))
 
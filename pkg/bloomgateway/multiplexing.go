@@ -134,11 +134,13 @@ func (t Task) Copy(series []*logproto.GroupedChunkRefs) Task {
 	}
 }
 
-func (t Task) RequestIter() iter.Iterator[v1.Request] {
+// RequestIter returns the requests of the task against a block whose log lines
+// were tokenized with the given line tokenizer.
+func (t Task) RequestIter(lineTokenizer v1.LineTokenizerConfig) iter.Iterator[v1.Request] {
 	return &requestIterator{
 		recorder: t.recorder,
 		series:   iter.NewSliceIter(t.series),
-		search:   v1.LabelMatchersToBloomTest(t.matchers...),
+		search:   v1.LabelMatchersToBloomTest(lineTokenizer, t.matchers...),
 		channel:  t.resCh,
 		curr:     v1.Request{},
 	}
@@ -62,7 +62,7 @@ func TestTask_RequestIterator(t *testing.T) {
 			series:   []*logproto.GroupedChunkRefs{},
 		}
 		task := newTask(context.Background(), tenant, swb, nil, nil)
-		it := task.RequestIter()
+		it := task.RequestIter(v1.LineTokenizerConfig{})
 		// nothing to iterate over
 		require.False(t, it.Next())
 	})
@@ -113,7 +113,7 @@ func TestTask_RequestIterator(t *testing.T) {
 
 		iters := make([]v2.PeekIterator[v1.Request], 0, len(tasks))
 		for _, task := range tasks {
-			iters = append(iters, v2.NewPeekIter(task.RequestIter()))
+			iters = append(iters, v2.NewPeekIter(task.RequestIter(v1.LineTokenizerConfig{})))
 		}
 
 		// merge the request iterators using the heap sort iterator
@@ -168,7 +168,7 @@ func (p *processor) processBlock(_ context.Context, bq *bloomshipper.CloseableBl
 		// 	sp.LogKV("process block", blockID, "series", len(task.series))
 		// }
 
-		it := iter.NewPeekIter(task.RequestIter())
+		it := iter.NewPeekIter(task.RequestIter(schema.LineTokenizer()))
 		iters = append(iters, it)
 	}
 
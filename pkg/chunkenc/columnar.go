@@ -0,0 +1,474 @@
+package chunkenc
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"sort"
+	"sync"
+
+	"github.com/cespare/xxhash/v2"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+/*
+Starting from ChunkFormatV5, blocks are columnar: instead of interleaving the entries, each block stores
+its timestamps, structured metadata and lines as separate, independently compressed columns.
+
+	Uvarint # entries
+	1B flags
+	Uvarint dictionary index (only when the block flags say so)
+	Uvarint length | timestamps column: Varint delta with the previous timestamp per entry
+	Uvarint # structured metadata columns
+	  Uvarint name symbol
+	  Uvarint length | column: Uvarint value symbol + 1 per entry, 0 when the entry does not have the name
+	Uvarint length | lines column: Uvarint length + line per entry
+
+The lines column of a block of a zstd chunk can be compressed with one of the dictionaries of the chunk, which are
+stored in their own section:
+
+	Uvarint # dictionaries
+	  Uvarint length | raw zstd dictionary
+
+Dictionaries are trained on the lines of the stream: the lines of a cut block give the dictionary of the next
+blocks, which is only used by the blocks it makes smaller and stored once by the chunk when a block uses it.
+A new dictionary is trained from each block which didn't use the current one, so the dictionaries follow the
+changes of the lines of the stream.
+*/
+
+const (
+	// columnarBlockFlagDict is set when the lines column is compressed with a dictionary of the chunk.
+	columnarBlockFlagDict byte = 1 << iota
+)
+
+const (
+	// columnarDictID is the ID of the raw zstd dictionaries of the chunks, it is never stored in the frames
+	// compressed with them and only needs to match between the encoder and the decoder.
+	columnarDictID = 1
+	// maxColumnarDictSize is the maximum size of the dictionary trained on the lines of a block.
+	maxColumnarDictSize = 32 << 10
+	// maxColumnarDicts is the maximum number of dictionaries of a chunk.
+	maxColumnarDicts = 4
+	// columnarDictCacheSize is the number of dictionaries, and their pooled codecs, kept in memory.
+	columnarDictCacheSize = 128
+)
+
+var columnarDicts = newColumnarDictCache(columnarDictCacheSize)
+
+// usesColumnarDict tells if the blocks of chunks of the given format and encoding are compressed with a dictionary.
+func usesColumnarDict(format byte, enc compression.Codec) bool {
+	return format >= ChunkFormatV5 && enc == compression.Zstd
+}
+
+// trainColumnarDict builds a raw zstd dictionary out of the lines of a block.
+// Lines are sampled evenly when they don't fit in the dictionary.
+func trainColumnarDict(lines []string) []byte {
+	size := 0
+	for _, l := range lines {
+		size += len(l)
+	}
+	if size == 0 {
+		return nil
+	}
+
+	step := 1
+	if size > maxColumnarDictSize {
+		step = int(math.Ceil(float64(size) / maxColumnarDictSize))
+	}
+
+	dict := make([]byte, 0, min(size, maxColumnarDictSize))
+	for i := 0; i < len(lines); i += step {
+		if len(dict)+len(lines[i]) > maxColumnarDictSize {
+			break
+		}
+		dict = append(dict, lines[i]...)
+	}
+	return dict
+}
+
+// columnarDict is a raw zstd dictionary of the lines of columnar blocks with its pooled encoders and decoders.
+type columnarDict struct {
+	raw      []byte
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+// encode compresses src with the dictionary.
+func (d *columnarDict) encode(src []byte) ([]byte, error) {
+	var enc *zstd.Encoder
+	if e := d.encoders.Get(); e != nil {
+		enc = e.(*zstd.Encoder)
+	} else {
+		var err error
+		enc, err = zstd.NewWriter(nil, zstd.WithEncoderDictRaw(columnarDictID, d.raw), zstd.WithEncoderConcurrency(1))
+		if err != nil {
+			return nil, errors.Wrap(err, "creating dictionary encoder")
+		}
+	}
+	defer d.encoders.Put(enc)
+	return enc.EncodeAll(src, nil), nil
+}
+
+// decode decompresses src with the dictionary.
+func (d *columnarDict) decode(src []byte) ([]byte, error) {
+	var dec *zstd.Decoder
+	if r := d.decoders.Get(); r != nil {
+		dec = r.(*zstd.Decoder)
+	} else {
+		var err error
+		dec, err = zstd.NewReader(nil, zstd.WithDecoderDictRaw(columnarDictID, d.raw), zstd.WithDecoderConcurrency(1), zstd.WithDecoderLowmem(true))
+		if err != nil {
+			return nil, errors.Wrap(err, "creating dictionary decoder")
+		}
+		runtime.SetFinalizer(dec, (*zstd.Decoder).Close)
+	}
+	defer d.decoders.Put(dec)
+	return dec.DecodeAll(src, nil)
+}
+
+// columnarDictCache keeps the recently used dictionaries, so that their codecs are shared by the chunks
+// compressed with the same dictionary, and by the successive reads of a chunk.
+type columnarDictCache struct {
+	dicts *lru.Cache[uint64, *columnarDict]
+}
+
+func newColumnarDictCache(size int) *columnarDictCache {
+	dicts, err := lru.New[uint64, *columnarDict](size)
+	if err != nil {
+		panic(err) // never happens, error is only returned on a non-positive size.
+	}
+	return &columnarDictCache{dicts: dicts}
+}
+
+// get returns the cached dictionary with the given content, or caches a copy of it.
+func (c *columnarDictCache) get(raw []byte) *columnarDict {
+	key := xxhash.Sum64(raw)
+	if d, ok := c.dicts.Get(key); ok && bytes.Equal(d.raw, raw) {
+		return d
+	}
+	d := &columnarDict{raw: bytes.Clone(raw)}
+	c.dicts.Add(key, d)
+	return d
+}
+
+// encodeColumnarDicts encodes the dictionary section of a chunk.
+func encodeColumnarDicts(dicts []*columnarDict) []byte {
+	eb := &encbuf{}
+	eb.putUvarint(len(dicts))
+	for _, d := range dicts {
+		eb.putUvarint(len(d.raw))
+		eb.b = append(eb.b, d.raw...)
+	}
+	return eb.get()
+}
+
+// decodeColumnarDicts decodes the dictionary section of a chunk.
+func decodeColumnarDicts(b []byte) ([]*columnarDict, error) {
+	db := decbuf{b: b}
+	num := db.uvarint()
+	if num == 0 {
+		return nil, db.err()
+	}
+	dicts := make([]*columnarDict, num)
+	for i := range dicts {
+		raw := db.bytes(db.uvarint())
+		if db.err() != nil {
+			return nil, errors.Wrap(db.err(), "reading dictionary")
+		}
+		dicts[i] = columnarDicts.get(raw)
+	}
+	return dicts, db.err()
+}
+
+// serialiseColumnar writes the entries of the head block as a columnar block and returns the lines of the block.
+// The lines column is compressed by compressLines, which returns the index of the dictionary it used plus one,
+// or 0 when it compressed the column without dictionary.
+func (hb *unorderedHeadBlock) serialiseColumnar(pool compression.WriterPool, compressLines func([]byte) (int, []byte, error)) ([]byte, []string, error) {
+	encBuf := make([]byte, binary.MaxVarintLen64)
+	tsColumn := &bytes.Buffer{}
+	linesColumn := &bytes.Buffer{}
+	metadataColumns := map[uint32]*bytes.Buffer{}
+	lines := make([]string, 0, hb.lines)
+
+	var prevTs int64
+	_ = hb.forEntries(
+		context.Background(),
+		logproto.FORWARD,
+		0,
+		math.MaxInt64,
+		func(_ *stats.Context, ts int64, line string, structuredMetadataSymbols symbols) error {
+			n := binary.PutVarint(encBuf, ts-prevTs)
+			tsColumn.Write(encBuf[:n])
+			prevTs = ts
+
+			n = binary.PutUvarint(encBuf, uint64(len(line)))
+			linesColumn.Write(encBuf[:n])
+			linesColumn.WriteString(line)
+
+			// columns of names which the previous entries didn't have are backfilled with zeroes.
+			for _, s := range structuredMetadataSymbols {
+				if _, ok := metadataColumns[s.Name]; !ok {
+					metadataColumns[s.Name] = bytes.NewBuffer(make([]byte, len(lines)))
+				}
+			}
+			for name, column := range metadataColumns {
+				value := uint64(0)
+				for _, s := range structuredMetadataSymbols {
+					if s.Name == name {
+						value = uint64(s.Value) + 1
+						break
+					}
+				}
+				n = binary.PutUvarint(encBuf, value)
+				column.Write(encBuf[:n])
+			}
+
+			lines = append(lines, line)
+			return nil
+		},
+	)
+
+	names := make([]uint32, 0, len(metadataColumns))
+	for name := range metadataColumns {
+		names = append(names, name)
+	}
+	// sort the columns by name so that the structured metadata is read back sorted.
+	sort.Slice(names, func(i, j int) bool {
+		return hb.symbolizer.lookup(names[i]) < hb.symbolizer.lookup(names[j])
+	})
+
+	dict, compressedLines, err := compressLines(linesColumn.Bytes())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "compressing lines column")
+	}
+
+	out := &bytes.Buffer{}
+	n := binary.PutUvarint(encBuf, uint64(len(lines)))
+	out.Write(encBuf[:n])
+
+	if dict > 0 {
+		out.WriteByte(columnarBlockFlagDict)
+		n = binary.PutUvarint(encBuf, uint64(dict-1))
+		out.Write(encBuf[:n])
+	} else {
+		out.WriteByte(0)
+	}
+
+	if err := writeColumn(out, pool, tsColumn.Bytes()); err != nil {
+		return nil, nil, errors.Wrap(err, "writing timestamps column")
+	}
+
+	n = binary.PutUvarint(encBuf, uint64(len(names)))
+	out.Write(encBuf[:n])
+	for _, name := range names {
+		n = binary.PutUvarint(encBuf, uint64(name))
+		out.Write(encBuf[:n])
+		if err := writeColumn(out, pool, metadataColumns[name].Bytes()); err != nil {
+			return nil, nil, errors.Wrap(err, "writing structured metadata column")
+		}
+	}
+
+	n = binary.PutUvarint(encBuf, uint64(len(compressedLines)))
+	out.Write(encBuf[:n])
+	out.Write(compressedLines)
+
+	return out.Bytes(), lines, nil
+}
+
+// writeColumn compresses the column and writes it prefixed by its compressed length.
+func writeColumn(w *bytes.Buffer, pool compression.WriterPool, column []byte) error {
+	compressed, err := compressColumn(pool, column)
+	if err != nil {
+		return err
+	}
+
+	encBuf := make([]byte, binary.MaxVarintLen64)
+	n := binary.PutUvarint(encBuf, uint64(len(compressed)))
+	w.Write(encBuf[:n])
+	w.Write(compressed)
+	return nil
+}
+
+func compressColumn(pool compression.WriterPool, column []byte) ([]byte, error) {
+	compressed := &bytes.Buffer{}
+	compressedWriter := pool.GetWriter(compressed)
+	defer pool.PutWriter(compressedWriter)
+
+	if _, err := compressedWriter.Write(column); err != nil {
+		return nil, err
+	}
+	if err := compressedWriter.Close(); err != nil {
+		return nil, err
+	}
+	return compressed.Bytes(), nil
+}
+
+// columnarBlock is a decoded columnar block. The lines column is only decompressed when a line is read,
+// so that reading the timestamps and the structured metadata doesn't pay for it.
+type columnarBlock struct {
+	timestamps []int64
+	names      []uint32
+	values     [][]uint32 // values[column][entry], 0 when the entry does not have the name of the column.
+
+	pool            compression.ReaderPool
+	dict            *columnarDict // The dictionary of the lines column, nil when it is compressed without dictionary.
+	compressedLines []byte
+	lines           []byte
+	linesRead       bool
+}
+
+// decodeColumnarBlock decompresses the timestamps and structured metadata columns of a block.
+func decodeColumnarBlock(b []byte, pool compression.ReaderPool, dicts []*columnarDict) (*columnarBlock, error) {
+	db := decbuf{b: b}
+	numEntries := db.uvarint()
+	flags := db.byte()
+	blk := &columnarBlock{timestamps: make([]int64, 0, numEntries), pool: pool}
+	if flags&columnarBlockFlagDict != 0 {
+		idx := db.uvarint()
+		if db.err() == nil && idx >= len(dicts) {
+			return nil, fmt.Errorf("block compressed with dictionary %d but the chunk has %d", idx, len(dicts))
+		}
+		if db.err() == nil {
+			blk.dict = dicts[idx]
+		}
+	}
+	if db.err() != nil {
+		return nil, errors.Wrap(db.err(), "reading block header")
+	}
+
+	readColumn := func() ([]byte, error) {
+		l := db.uvarint()
+		column := db.bytes(l)
+		if db.err() != nil {
+			return nil, db.err()
+		}
+		return decompressColumn(pool, column)
+	}
+
+	tsColumn, err := readColumn()
+	if err != nil {
+		return nil, errors.Wrap(err, "reading timestamps column")
+	}
+	tsBuf := decbuf{b: tsColumn}
+	var ts int64
+	for i := 0; i < numEntries; i++ {
+		ts += tsBuf.varint64()
+		blk.timestamps = append(blk.timestamps, ts)
+	}
+	if tsBuf.err() != nil {
+		return nil, errors.Wrap(tsBuf.err(), "decoding timestamps column")
+	}
+
+	numColumns := db.uvarint()
+	for i := 0; i < numColumns; i++ {
+		name := uint32(db.uvarint())
+		column, err := readColumn()
+		if err != nil {
+			return nil, errors.Wrap(err, "reading structured metadata column")
+		}
+		valuesBuf := decbuf{b: column}
+		values := make([]uint32, numEntries)
+		for j := range values {
+			values[j] = uint32(valuesBuf.uvarint())
+		}
+		if valuesBuf.err() != nil {
+			return nil, errors.Wrap(valuesBuf.err(), "decoding structured metadata column")
+		}
+		blk.names = append(blk.names, name)
+		blk.values = append(blk.values, values)
+	}
+
+	l := db.uvarint()
+	blk.compressedLines = db.bytes(l)
+	if db.err() != nil {
+		return nil, errors.Wrap(db.err(), "reading lines column")
+	}
+	return blk, nil
+}
+
+// readLines decompresses the lines column if it isn't yet.
+func (blk *columnarBlock) readLines() error {
+	if blk.linesRead {
+		return nil
+	}
+
+	var err error
+	if blk.dict != nil {
+		blk.lines, err = blk.dict.decode(blk.compressedLines)
+	} else {
+		blk.lines, err = decompressColumn(blk.pool, blk.compressedLines)
+	}
+	if err != nil {
+		return errors.Wrap(err, "decompressing lines column")
+	}
+	blk.linesRead = true
+	return nil
+}
+
+func decompressColumn(pool compression.ReaderPool, column []byte) ([]byte, error) {
+	r, err := pool.GetReader(bytes.NewReader(column))
+	if err != nil {
+		return nil, err
+	}
+	defer pool.PutReader(r)
+	return io.ReadAll(r)
+}
+
+// moveNextColumnar moves the iterator to the next entry of a columnar block.
+// The returned line is nil when the iterator skips the lines.
+func (si *bufferedIterator) moveNextColumnar() (int64, []byte, bool) {
+	if si.columnar == nil {
+		blk, err := decodeColumnarBlock(si.origBytes, si.pool, si.dicts)
+		if err != nil {
+			si.err = err
+			return 0, nil, false
+		}
+		si.columnar = blk
+	}
+
+	blk := si.columnar
+	if si.columnarEntry >= len(blk.timestamps) {
+		return 0, nil, false
+	}
+	entry := si.columnarEntry
+	si.columnarEntry++
+
+	var line []byte
+	if !si.skipLines {
+		if err := blk.readLines(); err != nil {
+			si.err = err
+			return 0, nil, false
+		}
+		lineSize, w := binary.Uvarint(blk.lines)
+		if w <= 0 || int(lineSize) > len(blk.lines)-w {
+			si.err = fmt.Errorf("invalid data in chunk")
+			return 0, nil, false
+		}
+		line = blk.lines[w : w+int(lineSize)]
+		blk.lines = blk.lines[w+int(lineSize):]
+	}
+
+	si.symbolsBuf = si.symbolsBuf[:0]
+	for i, name := range blk.names {
+		if v := blk.values[i][entry]; v != 0 {
+			si.symbolsBuf = append(si.symbolsBuf, symbol{Name: name, Value: v - 1})
+		}
+	}
+
+	decompressedStructuredMetadataBytes := int64(binary.MaxVarintLen64 + len(si.symbolsBuf)*2*binary.MaxVarintLen64)
+	si.stats.AddDecompressedLines(1)
+	si.stats.AddDecompressedStructuredMetadataBytes(decompressedStructuredMetadataBytes)
+	si.stats.AddDecompressedBytes(2*binary.MaxVarintLen64 + int64(len(line)) + decompressedStructuredMetadataBytes)
+
+	return blk.timestamps[entry], line, true
+}
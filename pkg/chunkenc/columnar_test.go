@@ -0,0 +1,156 @@
+package chunkenc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/pkg/push"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+func TestColumnarChunk(t *testing.T) {
+	for _, enc := range []compression.Codec{compression.Zstd, compression.Snappy, compression.None} {
+		t.Run(enc.String(), func(t *testing.T) {
+			c := NewMemChunk(ChunkFormatV5, enc, UnorderedWithStructuredMetadataHeadBlockFmt, 1024, 0)
+
+			var expected []logproto.Entry
+			for i := 0; i < 100; i++ {
+				entry := logproto.Entry{
+					Timestamp: time.Unix(0, int64(i)),
+					Line:      fmt.Sprintf("level=info msg=\"request served\" path=/api/v1/items/%d duration=%dms", i, i%7),
+				}
+				// the entries don't all have the same structured metadata.
+				if i%2 == 0 {
+					entry.StructuredMetadata = append(entry.StructuredMetadata, push.LabelAdapter{Name: "trace_id", Value: fmt.Sprintf("trace-%d", i)})
+				}
+				if i%3 == 0 {
+					entry.StructuredMetadata = append(entry.StructuredMetadata, push.LabelAdapter{Name: "user", Value: "alice"})
+				}
+				_, err := c.Append(&entry)
+				require.NoError(t, err)
+				expected = append(expected, entry)
+			}
+			require.NoError(t, c.Close())
+			require.Greater(t, len(c.blocks), 1)
+
+			if enc == compression.Zstd {
+				// the first block has no dictionary yet, the next ones are compressed with the one trained on it.
+				require.Len(t, c.dicts, 1)
+				require.Zero(t, c.blocks[0].b[1]&columnarBlockFlagDict)
+				require.NotZero(t, c.blocks[1].b[1]&columnarBlockFlagDict)
+			} else {
+				require.Empty(t, c.dicts)
+			}
+
+			b, err := c.Bytes()
+			require.NoError(t, err)
+			decoded, err := NewByteChunk(b, 1024, 0)
+			require.NoError(t, err)
+			require.Equal(t, ChunkFormatV5, decoded.format)
+			require.Equal(t, len(c.dicts), len(decoded.dicts))
+			for i := range c.dicts {
+				require.Equal(t, c.dicts[i].raw, decoded.dicts[i].raw)
+			}
+
+			var chk, head bytes.Buffer
+			require.NoError(t, c.SerializeForCheckpointTo(&chk, &head))
+			fromCheckpoint, err := MemchunkFromCheckpoint("", chk.Bytes(), head.Bytes(), UnorderedWithStructuredMetadataHeadBlockFmt, 1024, 0)
+			require.NoError(t, err)
+
+			for _, chunk := range []*MemChunk{c, decoded, fromCheckpoint} {
+				it, err := chunk.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, log.NewNoopPipeline().ForStream(labels.EmptyLabels()))
+				require.NoError(t, err)
+
+				var actual []logproto.Entry
+				for it.Next() {
+					actual = append(actual, it.At())
+				}
+				require.NoError(t, it.Err())
+				require.NoError(t, it.Close())
+				require.Len(t, actual, len(expected))
+				for i := range expected {
+					require.Equal(t, expected[i].Timestamp, actual[i].Timestamp)
+					require.Equal(t, expected[i].Line, actual[i].Line)
+					require.Equal(t, logproto.FromLabelAdaptersToLabels(expected[i].StructuredMetadata), logproto.FromLabelAdaptersToLabels(actual[i].StructuredMetadata))
+				}
+
+				sampleIt := chunk.SampleIterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), countExtractor)
+				samples := 0
+				for sampleIt.Next() {
+					samples++
+				}
+				require.NoError(t, sampleIt.Close())
+				require.Equal(t, len(expected), samples)
+			}
+		})
+	}
+}
+
+func TestColumnarChunk_ForEachStructuredMetadata(t *testing.T) {
+	c := NewMemChunk(ChunkFormatV5, compression.Zstd, UnorderedWithStructuredMetadataHeadBlockFmt, 1024, 0)
+	for i := 0; i < 100; i++ {
+		_, err := c.Append(&logproto.Entry{
+			Timestamp:          time.Unix(0, int64(i)),
+			Line:               fmt.Sprintf("level=info msg=\"request served\" path=/api/v1/items/%d", i),
+			StructuredMetadata: push.LabelsAdapter{{Name: "trace_id", Value: fmt.Sprintf("trace-%d", i)}},
+		})
+		require.NoError(t, err)
+	}
+	require.Greater(t, len(c.blocks), 1)
+
+	// corrupt the checksum ending the lines column of a block compressed with the dictionary, which is only read along with the lines.
+	require.NotZero(t, c.blocks[1].b[1]&columnarBlockFlagDict)
+	c.blocks[1].b[len(c.blocks[1].b)-1] ^= 0xff
+
+	it := c.Blocks(time.Unix(0, 0), time.Unix(0, math.MaxInt64))[1].Iterator(context.Background(), log.NewNoopPipeline().ForStream(labels.EmptyLabels()))
+	require.False(t, it.Next())
+	require.ErrorContains(t, it.Err(), "decompressing lines column")
+
+	var timestamps []int64
+	err := c.ForEachStructuredMetadata(context.Background(), time.Unix(0, 10), time.Unix(0, 90), func(ts int64, structuredMetadata labels.Labels) error {
+		require.Equal(t, fmt.Sprintf("trace-%d", ts), structuredMetadata.Get("trace_id"))
+		timestamps = append(timestamps, ts)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, timestamps, 80)
+}
+
+func TestColumnarDictCache(t *testing.T) {
+	cache := newColumnarDictCache(1)
+	dict := cache.get([]byte("foobar"))
+	require.Same(t, dict, cache.get([]byte("foobar")))
+
+	compressed, err := dict.encode([]byte("foobarfoobar"))
+	require.NoError(t, err)
+	decompressed, err := cache.get([]byte("foobar")).decode(compressed)
+	require.NoError(t, err)
+	require.Equal(t, "foobarfoobar", string(decompressed))
+
+	require.NotSame(t, dict, cache.get([]byte("barfoo")))
+	require.NotSame(t, dict, cache.get([]byte("foobar")))
+}
+
+func TestTrainColumnarDict(t *testing.T) {
+	require.Nil(t, trainColumnarDict(nil))
+	require.Equal(t, []byte("foobar"), trainColumnarDict([]string{"foo", "bar"}))
+
+	lines := make([]string, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		lines = append(lines, fmt.Sprintf("%0100d", i))
+	}
+	dict := trainColumnarDict(lines)
+	require.LessOrEqual(t, len(dict), maxColumnarDictSize)
+	// lines are sampled across the whole block.
+	require.Contains(t, string(dict), lines[len(lines)-4])
+}
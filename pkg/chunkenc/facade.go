@@ -1,11 +1,18 @@
 package chunkenc
 
 import (
+	"context"
+	"encoding/binary"
+	"hash/crc32"
 	"io"
+	"math"
 	"time"
 
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/util/filter"
 )
@@ -31,6 +38,8 @@ type Facade struct {
 	c          Chunk
 	blockSize  int
 	targetSize int
+	// userID is the tenant of the decoded chunk, whose zstd dictionaries the chunk may be compressed with.
+	userID string
 	chunk.Data
 }
 
@@ -58,13 +67,57 @@ func (f Facade) Marshal(w io.Writer) error {
 	return nil
 }
 
+// SetUserID implements chunk.TenantData.
+func (f *Facade) SetUserID(userID string) {
+	f.userID = userID
+}
+
 // UnmarshalFromBuf implements chunk.Chunk.
 func (f *Facade) UnmarshalFromBuf(buf []byte) error {
 	var err error
-	f.c, err = NewByteChunk(buf, f.blockSize, f.targetSize)
+	f.c, err = NewTenantByteChunk(f.userID, buf, f.blockSize, f.targetSize)
+	return err
+}
+
+// UnmarshalRange implements chunk.RangeData.
+func (f *Facade) UnmarshalRange(size int, read func(offset, length int) ([]byte, error), from, through model.Time) error {
+	var err error
+	// through is rounded up to include the entries of its last millisecond.
+	f.c, err = NewByteChunkInRange(f.userID, size, read, from.UnixNano(), through.Add(time.Millisecond).UnixNano()-1, f.blockSize, f.targetSize)
 	return err
 }
 
+// ContentHash implements chunk.ContentHasher by hashing the timestamp, line and structured metadata of the entries
+// of the chunk in order.
+func (f Facade) ContentHash() (uint32, error) {
+	h := crc32.New(castagnoliTable)
+	if f.c == nil {
+		return h.Sum32(), nil
+	}
+	it, err := f.c.Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, log.NewNoopPipeline().ForStream(labels.EmptyLabels()))
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+
+	var buf [binary.MaxVarintLen64]byte
+	writeString := func(s string) {
+		_, _ = h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(s)))])
+		_, _ = io.WriteString(h, s)
+	}
+	for it.Next() {
+		e := it.At()
+		_, _ = h.Write(buf[:binary.PutVarint(buf[:], e.Timestamp.UnixNano())])
+		writeString(e.Line)
+		_, _ = h.Write(buf[:binary.PutUvarint(buf[:], uint64(len(e.StructuredMetadata)))])
+		for _, l := range e.StructuredMetadata {
+			writeString(l.Name)
+			writeString(l.Value)
+		}
+	}
+	return h.Sum32(), it.Err()
+}
+
 // Encoding implements chunk.Chunk.
 func (Facade) Encoding() chunk.Encoding {
 	return LogChunk
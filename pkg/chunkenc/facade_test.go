@@ -0,0 +1,56 @@
+package chunkenc
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+)
+
+func TestFacade_ContentHash(t *testing.T) {
+	lbs := labels.FromStrings("app", "foo")
+	newChunk := func(blockSize int, codec compression.Codec, entries int) chunk.Chunk {
+		mc := NewMemChunk(ChunkFormatV4, codec, UnorderedWithStructuredMetadataHeadBlockFmt, blockSize, 0)
+		for i := int64(0); i < int64(entries); i++ {
+			_, err := mc.Append(logprotoEntryWithStructuredMetadata(i, fmt.Sprintf("line %d", i), []logproto.LabelAdapter{{Name: "i", Value: fmt.Sprint(i % 10)}}))
+			require.NoError(t, err)
+		}
+		require.NoError(t, mc.Close())
+		return chunk.NewChunk("fake", model.Fingerprint(lbs.Hash()), lbs, NewFacade(mc, blockSize, 0), 0, model.Time(entries))
+	}
+
+	// chunks holding the same entries but encoded differently share their content key.
+	a, b := newChunk(1<<10, compression.Snappy, 1000), newChunk(16<<10, compression.GZIP, 1000)
+	require.NoError(t, a.EncodeContentAddressed())
+	require.NoError(t, b.EncodeContentAddressed())
+	require.Equal(t, a.Checksum, b.Checksum)
+
+	encodedA, err := a.Encoded()
+	require.NoError(t, err)
+	encodedB, err := b.Encoded()
+	require.NoError(t, err)
+	require.NotEqual(t, encodedA, encodedB)
+
+	other := newChunk(1<<10, compression.Snappy, 999)
+	require.NoError(t, other.EncodeContentAddressed())
+	require.NotEqual(t, a.Checksum, other.Checksum)
+
+	// content addressed chunks decode like any other chunk.
+	decoded := chunk.Chunk{ChunkRef: b.ChunkRef}
+	require.NoError(t, decoded.Decode(chunk.NewDecodeContext(), encodedB))
+	require.True(t, decoded.ContentAddressed)
+	require.Equal(t, lbs, decoded.Metric)
+	require.Equal(t, 1000, decoded.Data.Entries())
+
+	// a corrupted chunk is still rejected by the checksum of its data, before the data is decoded.
+	require.NotZero(t, decoded.DataChecksum)
+	encodedB[len(encodedB)-1] ^= 0xff
+	decoded = chunk.Chunk{ChunkRef: b.ChunkRef}
+	require.ErrorIs(t, decoded.Decode(chunk.NewDecodeContext(), encodedB), chunk.ErrInvalidChecksum)
+}
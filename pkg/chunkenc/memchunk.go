@@ -8,6 +8,7 @@ import (
 	"hash"
 	"hash/crc32"
 	"io"
+	"slices"
 	"time"
 	"unsafe"
 
@@ -32,6 +33,8 @@ const (
 	ChunkFormatV2
 	ChunkFormatV3
 	ChunkFormatV4
+	// ChunkFormatV5 stores the blocks as columns, see columnar.go.
+	ChunkFormatV5
 
 	blocksPerChunk = 10
 	maxLineLength  = 1024 * 1024 * 1024
@@ -43,6 +46,7 @@ const (
 
 	chunkMetasSectionIdx              = 1
 	chunkStructuredMetadataSectionIdx = 2
+	chunkDictSectionIdx               = 3
 )
 
 var HeadBlockFmts = []HeadBlockFmt{OrderedHeadBlockFmt, UnorderedHeadBlockFmt, UnorderedWithStructuredMetadataHeadBlockFmt}
@@ -123,6 +127,11 @@ type MemChunk struct {
 	targetSize int
 
 	symbolizer *symbolizer
+	// dicts are the zstd dictionaries the lines of the columnar blocks are compressed with, see columnar.go.
+	dicts []*columnarDict
+	// nextDict is the dictionary trained on the lines of the stream for the next block, it is added to dicts
+	// once a block is compressed with it.
+	nextDict *columnarDict
 	// The finished blocks.
 	blocks []block
 	// The compressed size of all the blocks
@@ -135,6 +144,11 @@ type MemChunk struct {
 	encoding compression.Codec
 	headFmt  HeadBlockFmt
 
+	// zstdDictID is the ID of the trained dictionary the blocks of ZstdDict chunks are compressed with, 0 when there is none.
+	zstdDictID uint32
+	// pool overrides the pool of the encoding, it is set for ZstdDict chunks having a dictionary.
+	pool compression.ReaderWriterPool
+
 	// compressed size of chunk. Set when chunk is cut or while decoding chunk from storage.
 	compressedSize int
 }
@@ -359,13 +373,25 @@ func NewMemChunk(chunkFormat byte, enc compression.Codec, head HeadBlockFmt, blo
 	return newMemChunkWithFormat(chunkFormat, enc, head, blockSize, targetSize)
 }
 
+// NewMemChunkWithZstdDictionary returns a new in-mem ZstdDict chunk whose blocks are compressed with the given dictionary.
+// The ID of the dictionary is stored in the chunk header, readers resolve it with compression.GetZstdDictPool
+// among the dictionaries of the tenant of the chunk, see NewTenantByteChunk.
+// A nil dictionary gives a ZstdDict chunk compressed without dictionary.
+func NewMemChunkWithZstdDictionary(chunkFormat byte, dict *compression.ZstdDictPool, head HeadBlockFmt, blockSize, targetSize int) *MemChunk {
+	c := newMemChunkWithFormat(chunkFormat, compression.ZstdDict, head, blockSize, targetSize)
+	if dict != nil {
+		c.zstdDictID, c.pool = dict.ID(), dict
+	}
+	return c
+}
+
 func panicIfInvalidFormat(chunkFmt byte, head HeadBlockFmt) {
 	if chunkFmt == ChunkFormatV2 && head != OrderedHeadBlockFmt {
 		panic("only OrderedHeadBlockFmt is supported for V2 chunks")
 	}
-	if chunkFmt == ChunkFormatV4 && head != UnorderedWithStructuredMetadataHeadBlockFmt {
+	if chunkFmt >= ChunkFormatV4 && head != UnorderedWithStructuredMetadataHeadBlockFmt {
 		fmt.Println("received head fmt", head.String())
-		panic("only UnorderedWithStructuredMetadataHeadBlockFmt is supported for V4 chunks")
+		panic("only UnorderedWithStructuredMetadataHeadBlockFmt is supported for V4+ chunks")
 	}
 }
 
@@ -389,11 +415,19 @@ func newMemChunkWithFormat(format byte, enc compression.Codec, head HeadBlockFmt
 }
 
 // NewByteChunk returns a MemChunk on the passed bytes.
+// Chunks compressed with a trained zstd dictionary can only be decoded with NewTenantByteChunk.
 func NewByteChunk(b []byte, blockSize, targetSize int) (*MemChunk, error) {
-	return newByteChunk(b, blockSize, targetSize, false)
+	return newByteChunk("", b, blockSize, targetSize, false, nil)
 }
 
-func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*MemChunk, error) {
+// NewTenantByteChunk returns a MemChunk on the passed bytes of a chunk of the tenant.
+func NewTenantByteChunk(tenant string, b []byte, blockSize, targetSize int) (*MemChunk, error) {
+	return newByteChunk(tenant, b, blockSize, targetSize, false, nil)
+}
+
+// newByteChunk decodes the encoded chunk of the tenant. If keep is not nil, the blocks for which it returns false
+// are neither validated nor decoded.
+func newByteChunk(tenant string, b []byte, blockSize, targetSize int, fromCheckpoint bool, keep func(mint, maxt int64) bool) (*MemChunk, error) {
 	bc := &MemChunk{
 		head:           &headBlock{}, // Dummy, empty headblock.
 		blockSize:      blockSize,
@@ -415,13 +449,27 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 	switch version {
 	case ChunkFormatV1:
 		bc.encoding = compression.GZIP
-	case ChunkFormatV2, ChunkFormatV3, ChunkFormatV4:
+	case ChunkFormatV2, ChunkFormatV3, ChunkFormatV4, ChunkFormatV5:
 		// format v2+ has a byte for block encoding.
 		enc := compression.Codec(db.byte())
 		if db.err() != nil {
 			return nil, errors.Wrap(db.err(), "verifying encoding")
 		}
 		bc.encoding = enc
+		if enc == compression.ZstdDict {
+			// ZstdDict chunks have the ID of their dictionary after the encoding.
+			bc.zstdDictID = db.be32()
+			if db.err() != nil {
+				return nil, errors.Wrap(db.err(), "reading zstd dictionary ID")
+			}
+			if bc.zstdDictID != 0 {
+				pool, err := compression.GetZstdDictPool(context.Background(), tenant, bc.zstdDictID)
+				if err != nil {
+					return nil, errors.Wrap(err, "getting zstd dictionary")
+				}
+				bc.pool = pool
+			}
+		}
 	default:
 		return nil, errors.Errorf("invalid version %d", version)
 	}
@@ -450,6 +498,11 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 		metasLen, metasOffset = readSectionLenAndOffset(chunkMetasSectionIdx)
 		structuredMetadataLength, structuredMetadataOffset := readSectionLenAndOffset(chunkStructuredMetadataSectionIdx)
 		expectedBlockOffset = int(structuredMetadataLength + structuredMetadataOffset + 4)
+		if version >= ChunkFormatV5 {
+			// version >= 5 writes the dictionary section between the structured metadata and the blocks
+			dictLength, dictOffset := readSectionLenAndOffset(chunkDictSectionIdx)
+			expectedBlockOffset = int(dictLength + dictOffset + 4)
+		}
 	} else {
 		// version <= 3 does not store length of metas. metas are followed by metasOffset + hash and then the chunk ends
 		metasOffset = binary.BigEndian.Uint64(b[len(b)-8:])
@@ -485,6 +538,14 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 		}
 		l := db.uvarint()
 
+		if keep != nil && !keep(blk.mint, blk.maxt) {
+			if db.err() != nil {
+				return nil, errors.Wrap(db.err(), "decoding block meta")
+			}
+			expectedBlockOffset = blk.offset + l + 4
+			continue
+		}
+
 		invalidBlockErr := validateBlock(b, blk.offset, l)
 		if invalidBlockErr != nil {
 			level.Error(util_log.Logger).Log("msg", "invalid block found", "err", invalidBlockErr)
@@ -536,7 +597,7 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 		if fromCheckpoint {
 			bc.symbolizer = symbolizerFromCheckpoint(lb)
 		} else {
-			symbolizer, err := symbolizerFromEnc(lb, compression.GetReaderPool(bc.encoding))
+			symbolizer, err := symbolizerFromEnc(lb, bc.compressionPool())
 			if err != nil {
 				return nil, err
 			}
@@ -544,6 +605,21 @@ func newByteChunk(b []byte, blockSize, targetSize int, fromCheckpoint bool) (*Me
 		}
 	}
 
+	if version >= ChunkFormatV5 {
+		dictLength, dictOffset := readSectionLenAndOffset(chunkDictSectionIdx)
+		db = decbuf{b: b[dictOffset : dictOffset+dictLength]}
+
+		expCRC := binary.BigEndian.Uint32(b[dictOffset+dictLength:])
+		if expCRC != db.crc32() {
+			return nil, ErrInvalidChecksum
+		}
+		dicts, err := decodeColumnarDicts(b[dictOffset : dictOffset+dictLength])
+		if err != nil {
+			return nil, err
+		}
+		bc.dicts = dicts
+	}
+
 	return bc, nil
 }
 
@@ -571,6 +647,9 @@ func (c *MemChunk) BytesSize() int {
 	if c.format > ChunkFormatV1 {
 		size++ // chunk format v2+ has a byte for encoding.
 	}
+	if c.encoding == compression.ZstdDict {
+		size += 4 // zstd dictionary ID
+	}
 
 	// blocks
 	for _, b := range c.blocks {
@@ -600,6 +679,11 @@ func (c *MemChunk) BytesSize() int {
 
 		size += 8 + 8 // structured metadata offset and length
 	}
+
+	if c.format >= ChunkFormatV5 {
+		size += len(encodeColumnarDicts(c.dicts)) + crc32.Size // dictionary block + crc
+		size += 8 + 8                                          // dictionary offset and length
+	}
 	return size
 }
 
@@ -631,6 +715,9 @@ func (c *MemChunk) writeTo(w io.Writer, forCheckpoint bool) (int64, error) {
 	if c.format > ChunkFormatV1 {
 		// chunk format v2+ has a byte for encoding.
 		eb.putByte(byte(c.encoding))
+		if c.encoding == compression.ZstdDict {
+			eb.putBE32(c.zstdDictID)
+		}
 	}
 
 	n, err := w.Write(eb.get())
@@ -654,7 +741,7 @@ func (c *MemChunk) writeTo(w io.Writer, forCheckpoint bool) (int64, error) {
 			}
 		} else {
 			var err error
-			n, crcHash, err = c.symbolizer.SerializeTo(w, compression.GetWriterPool(c.encoding))
+			n, crcHash, err = c.symbolizer.SerializeTo(w, c.compressionPool())
 			if err != nil {
 				return offset, errors.Wrap(err, "write structured metadata")
 			}
@@ -669,6 +756,21 @@ func (c *MemChunk) writeTo(w io.Writer, forCheckpoint bool) (int64, error) {
 		offset += int64(n)
 	}
 
+	dictOffset := offset
+	var dicts []byte
+	if c.format >= ChunkFormatV5 {
+		dicts = encodeColumnarDicts(c.dicts)
+		crc32Hash.Reset()
+		if _, err := crc32Hash.Write(dicts); err != nil {
+			return offset, errors.Wrap(err, "write dictionary")
+		}
+		n, err := w.Write(crc32Hash.Sum(dicts))
+		if err != nil {
+			return offset, errors.Wrap(err, "write dictionary")
+		}
+		offset += int64(n)
+	}
+
 	// Write Blocks.
 	for i, b := range c.blocks {
 		c.blocks[i].offset = int(offset)
@@ -711,6 +813,18 @@ func (c *MemChunk) writeTo(w io.Writer, forCheckpoint bool) (int64, error) {
 	}
 	offset += int64(n)
 
+	if c.format >= ChunkFormatV5 {
+		// Write dictionary offset and length
+		eb.reset()
+		eb.putBE64int(len(dicts))
+		eb.putBE64int(int(dictOffset))
+		n, err = w.Write(eb.get())
+		if err != nil {
+			return offset, errors.Wrap(err, "write dictionary offset and length")
+		}
+		offset += int64(n)
+	}
+
 	if c.format >= ChunkFormatV4 {
 		// Write structured metadata offset and length
 		eb.reset()
@@ -761,8 +875,8 @@ func (c *MemChunk) CheckpointSize() (chunk, head int) {
 	return c.BytesSize(), c.head.CheckpointSize()
 }
 
-func MemchunkFromCheckpoint(chk, head []byte, desiredIfNotUnordered HeadBlockFmt, blockSize int, targetSize int) (*MemChunk, error) {
-	mc, err := newByteChunk(chk, blockSize, targetSize, true)
+func MemchunkFromCheckpoint(tenant string, chk, head []byte, desiredIfNotUnordered HeadBlockFmt, blockSize int, targetSize int) (*MemChunk, error) {
+	mc, err := newByteChunk(tenant, chk, blockSize, targetSize, true, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -942,7 +1056,15 @@ func (c *MemChunk) cut() error {
 		return nil
 	}
 
-	b, err := c.head.Serialise(compression.GetWriterPool(c.encoding))
+	var (
+		b   []byte
+		err error
+	)
+	if c.format >= ChunkFormatV5 {
+		b, err = c.cutColumnar()
+	} else {
+		b, err = c.head.Serialise(c.compressionPool())
+	}
 	if err != nil {
 		return err
 	}
@@ -962,6 +1084,67 @@ func (c *MemChunk) cut() error {
 	return nil
 }
 
+// cutColumnar serialises the head as a columnar block. A new dictionary is trained on the lines
+// of the block for the next blocks when the block wasn't compressed with the current one, until
+// the chunk has maxColumnarDicts dictionaries.
+func (c *MemChunk) cutColumnar() ([]byte, error) {
+	hb, ok := c.head.(*unorderedHeadBlock)
+	if !ok {
+		return nil, fmt.Errorf("unsupported head block format %s for chunk format %d", c.head.Format(), c.format)
+	}
+
+	var usedDict bool
+	b, lines, err := hb.serialiseColumnar(c.compressionPool(), func(column []byte) (int, []byte, error) {
+		dict, compressed, err := c.compressLines(column)
+		usedDict = dict > 0
+		return dict, compressed, err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !usedDict && usesColumnarDict(c.format, c.encoding) && len(c.dicts) < maxColumnarDicts {
+		if dict := trainColumnarDict(lines); len(dict) > 0 {
+			c.nextDict = columnarDicts.get(dict)
+		}
+	}
+	return b, nil
+}
+
+// compressLines compresses the lines column of a columnar block with the dictionary trained on the stream when it makes
+// the column smaller, and with the pool of the chunk otherwise. It returns the index of the dictionary in the dictionaries
+// of the chunk plus one, or 0 when no dictionary was used.
+func (c *MemChunk) compressLines(column []byte) (int, []byte, error) {
+	compressed, err := compressColumn(c.compressionPool(), column)
+	if err != nil || c.nextDict == nil {
+		return 0, compressed, err
+	}
+
+	idx := slices.Index(c.dicts, c.nextDict)
+	if idx < 0 && len(c.dicts) >= maxColumnarDicts {
+		return 0, compressed, nil
+	}
+	withDict, err := c.nextDict.encode(column)
+	if err != nil {
+		return 0, nil, err
+	}
+	if len(withDict) >= len(compressed) {
+		return 0, compressed, nil
+	}
+	if idx < 0 {
+		c.dicts = append(c.dicts, c.nextDict)
+		idx = len(c.dicts) - 1
+	}
+	return idx + 1, withDict, nil
+}
+
+// compressionPool returns the pool the blocks of the chunk are compressed with.
+func (c *MemChunk) compressionPool() compression.ReaderWriterPool {
+	if c.pool != nil {
+		return c.pool
+	}
+	return compression.GetPool(c.encoding)
+}
+
 // Bounds implements Chunk.
 func (c *MemChunk) Bounds() (fromT, toT time.Time) {
 	from, to := c.head.Bounds()
@@ -1007,7 +1190,7 @@ func (c *MemChunk) Iterator(ctx context.Context, mintT, maxtT time.Time, directi
 		}
 		lastMax = b.maxt
 
-		blockItrs = append(blockItrs, encBlock{c.encoding, c.format, c.symbolizer, b}.Iterator(ctx, pipeline))
+		blockItrs = append(blockItrs, encBlock{c.compressionPool(), c.format, c.symbolizer, c.dicts, b}.Iterator(ctx, pipeline))
 	}
 
 	if !c.head.IsEmpty() {
@@ -1098,7 +1281,7 @@ func (c *MemChunk) SampleIterator(
 		lastMax = b.maxt
 		its = append(
 			its,
-			encBlock{c.encoding, c.format, c.symbolizer, b}.SampleIterator(ctx, extractors...),
+			encBlock{c.compressionPool(), c.format, c.symbolizer, c.dicts, b}.SampleIterator(ctx, extractors...),
 		)
 	}
 
@@ -1124,6 +1307,58 @@ func (c *MemChunk) SampleIterator(
 	)
 }
 
+// ForEachStructuredMetadata calls f with the timestamp and the structured metadata of each entry of the chunk between
+// mint and maxt, in no particular order. Unlike Iterator, it doesn't decompress the lines of columnar blocks.
+// The structured metadata passed to f is only valid until f returns.
+func (c *MemChunk) ForEachStructuredMetadata(ctx context.Context, mintT, maxtT time.Time, f func(ts int64, structuredMetadata labels.Labels) error) error {
+	mint, maxt := mintT.UnixNano(), maxtT.UnixNano()
+	pipeline := log.NewNoopPipeline().ForStream(labels.EmptyLabels())
+
+	forEachEntry := func(it iter.EntryIterator) error {
+		defer it.Close()
+		for it.Next() {
+			entry := it.At()
+			if err := f(entry.Timestamp.UnixNano(), logproto.FromLabelAdaptersToLabels(entry.StructuredMetadata)); err != nil {
+				return err
+			}
+		}
+		return it.Err()
+	}
+
+	for _, b := range c.blocks {
+		if maxt < b.mint || b.maxt < mint || len(b.b) == 0 {
+			continue
+		}
+		if c.format < ChunkFormatV5 {
+			it := encBlock{c.compressionPool(), c.format, c.symbolizer, c.dicts, b}.Iterator(ctx, pipeline)
+			if err := forEachEntry(iter.NewTimeRangedIterator(it, time.Unix(0, mint), time.Unix(0, maxt))); err != nil {
+				return err
+			}
+			continue
+		}
+
+		it := newBufferedIterator(ctx, c.compressionPool(), b.b, c.format, c.symbolizer, c.dicts)
+		it.skipLines = true
+		for it.Next() {
+			if it.currTs < mint || it.currTs >= maxt {
+				continue
+			}
+			if err := f(it.currTs, it.currStructuredMetadata); err != nil {
+				it.Close()
+				return err
+			}
+		}
+		if err := it.Close(); err != nil {
+			return err
+		}
+	}
+
+	if c.head.IsEmpty() {
+		return nil
+	}
+	return forEachEntry(c.head.Iterator(ctx, logproto.FORWARD, mint, maxt, pipeline))
+}
+
 // Blocks implements Chunk
 func (c *MemChunk) Blocks(mintT, maxtT time.Time) []Block {
 	mint, maxt := mintT.UnixNano(), maxtT.UnixNano()
@@ -1131,7 +1366,7 @@ func (c *MemChunk) Blocks(mintT, maxtT time.Time) []Block {
 
 	for _, b := range c.blocks {
 		if maxt >= b.mint && b.maxt >= mint {
-			blocks = append(blocks, encBlock{c.encoding, c.format, c.symbolizer, b})
+			blocks = append(blocks, encBlock{c.compressionPool(), c.format, c.symbolizer, c.dicts, b})
 		}
 	}
 	return blocks
@@ -1156,6 +1391,8 @@ func (c *MemChunk) Rebound(start, end time.Time, filter filter.Func) (Chunk, err
 		// For target chunk size I am using compressed size of original chunk since the newChunk should anyways be lower in size than that.
 		newChunk = NewMemChunk(c.format, c.Encoding(), c.headFmt, defaultBlockSize, c.CompressedSize())
 	}
+	// the new chunk is compressed with the same dictionary.
+	newChunk.zstdDictID, newChunk.pool = c.zstdDictID, c.pool
 
 	for itr.Next() {
 		entry := itr.At()
@@ -1183,9 +1420,10 @@ func (c *MemChunk) Rebound(start, end time.Time, filter filter.Func) (Chunk, err
 // then allows us to bind a decoding context to a block when requested, but otherwise helps reduce the
 // chances of chunk<>block encoding drift in the codebase as the latter is parameterized by the former.
 type encBlock struct {
-	enc        compression.Codec
+	pool       compression.ReaderPool
 	format     byte
 	symbolizer *symbolizer
+	dicts      []*columnarDict
 	block
 }
 
@@ -1193,7 +1431,7 @@ func (b encBlock) Iterator(ctx context.Context, pipeline log.StreamPipeline) ite
 	if len(b.b) == 0 {
 		return iter.NoopEntryIterator
 	}
-	return newEntryIterator(ctx, compression.GetReaderPool(b.enc), b.b, pipeline, b.format, b.symbolizer)
+	return newEntryIterator(ctx, b.pool, b.b, pipeline, b.format, b.symbolizer, b.dicts)
 }
 
 func (b encBlock) SampleIterator(
@@ -1205,10 +1443,11 @@ func (b encBlock) SampleIterator(
 	}
 	return newSampleIterator(
 		ctx,
-		compression.GetReaderPool(b.enc),
+		b.pool,
 		b.b,
 		b.format,
 		b.symbolizer,
+		b.dicts,
 		extractors...,
 	)
 }
@@ -1388,10 +1627,15 @@ type bufferedIterator struct {
 	symbolsBuf             []symbol      // The buffer for a single entry's symbols.
 	currStructuredMetadata labels.Labels // The current labels.
 
+	dicts         []*columnarDict // The dictionaries of the chunk for columnar blocks.
+	columnar      *columnarBlock  // The decoded columnar block.
+	columnarEntry int             // The index of the next entry of the columnar block.
+	skipLines     bool            // Set when only the timestamps and structured metadata of columnar blocks are read.
+
 	closed bool
 }
 
-func newBufferedIterator(ctx context.Context, pool compression.ReaderPool, b []byte, format byte, symbolizer *symbolizer) *bufferedIterator {
+func newBufferedIterator(ctx context.Context, pool compression.ReaderPool, b []byte, format byte, symbolizer *symbolizer, dicts []*columnarDict) *bufferedIterator {
 	stats := stats.FromContext(ctx)
 	stats.AddCompressedBytes(int64(len(b)))
 	return &bufferedIterator{
@@ -1401,6 +1645,7 @@ func newBufferedIterator(ctx context.Context, pool compression.ReaderPool, b []b
 		pool:       pool,
 		format:     format,
 		symbolizer: symbolizer,
+		dicts:      dicts,
 	}
 }
 
@@ -1409,7 +1654,7 @@ func (si *bufferedIterator) Next() bool {
 		return false
 	}
 
-	if !si.closed && si.reader == nil {
+	if !si.closed && si.reader == nil && si.format < ChunkFormatV5 {
 		// initialize reader now, hopefully reusing one of the previous readers
 		var err error
 		si.reader, err = si.pool.GetReader(bytes.NewBuffer(si.origBytes))
@@ -1433,6 +1678,14 @@ func (si *bufferedIterator) Next() bool {
 
 // moveNext moves the buffer to the next entry
 func (si *bufferedIterator) moveNext() (int64, []byte, labels.Labels, bool) {
+	if si.format >= ChunkFormatV5 {
+		ts, line, ok := si.moveNextColumnar()
+		if !ok {
+			return 0, nil, nil, false
+		}
+		return ts, line, si.symbolizer.Lookup(si.symbolsBuf, si.currStructuredMetadata), true
+	}
+
 	var decompressedBytes int64
 	var decompressedStructuredMetadataBytes int64
 	var ts int64
@@ -1649,12 +1902,13 @@ func (si *bufferedIterator) close() {
 		si.currStructuredMetadata = nil
 	}
 
+	si.columnar = nil
 	si.origBytes = nil
 }
 
-func newEntryIterator(ctx context.Context, pool compression.ReaderPool, b []byte, pipeline log.StreamPipeline, format byte, symbolizer *symbolizer) iter.EntryIterator {
+func newEntryIterator(ctx context.Context, pool compression.ReaderPool, b []byte, pipeline log.StreamPipeline, format byte, symbolizer *symbolizer, dicts []*columnarDict) iter.EntryIterator {
 	return &entryBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer),
+		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer, dicts),
 		pipeline:         pipeline,
 		stats:            stats.FromContext(ctx),
 	}
@@ -1710,6 +1964,7 @@ func newSampleIterator(
 	b []byte,
 	format byte,
 	symbolizer *symbolizer,
+	dicts []*columnarDict,
 	extractors ...log.StreamSampleExtractor,
 ) iter.SampleIterator {
 	if len(extractors) == 0 {
@@ -1717,11 +1972,11 @@ func newSampleIterator(
 	}
 
 	if len(extractors) > 1 {
-		return newMultiExtractorSampleIterator(ctx, pool, b, format, symbolizer, extractors...)
+		return newMultiExtractorSampleIterator(ctx, pool, b, format, symbolizer, dicts, extractors...)
 	}
 
 	return &sampleBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer),
+		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer, dicts),
 		extractor:        extractors[0],
 		stats:            stats.FromContext(ctx),
 	}
@@ -82,6 +82,10 @@ var (
 			headBlockFmt: UnorderedWithStructuredMetadataHeadBlockFmt,
 			chunkFormat:  ChunkFormatV4,
 		},
+		{
+			headBlockFmt: UnorderedWithStructuredMetadataHeadBlockFmt,
+			chunkFormat:  ChunkFormatV5,
+		},
 	}
 )
 
@@ -1322,7 +1326,7 @@ func TestCheckpointEncoding(t *testing.T) {
 			err = c.SerializeForCheckpointTo(&chk, &head)
 			require.Nil(t, err)
 
-			cpy, err = MemchunkFromCheckpoint(chk.Bytes(), head.Bytes(), f.headBlockFmt, blockSize, targetSize)
+			cpy, err = MemchunkFromCheckpoint("", chk.Bytes(), head.Bytes(), f.headBlockFmt, blockSize, targetSize)
 			require.Nil(t, err)
 
 			if f.chunkFormat <= ChunkFormatV2 {
@@ -1353,7 +1357,7 @@ func TestCheckpointEncoding(t *testing.T) {
 			err = c.SerializeForCheckpointTo(&chk, &head)
 			require.Nil(t, err)
 
-			cpy, err = MemchunkFromCheckpoint(chk.Bytes(), head.Bytes(), f.headBlockFmt, blockSize, targetSize)
+			cpy, err = MemchunkFromCheckpoint("", chk.Bytes(), head.Bytes(), f.headBlockFmt, blockSize, targetSize)
 			require.Nil(t, err)
 
 			if f.chunkFormat <= ChunkFormatV2 {
@@ -2244,6 +2248,16 @@ func TestDecodeChunkIncorrectBlockOffset(t *testing.T) {
 					_, err = w.Write(eb.get())
 					require.NoError(t, err)
 
+					if chk.format >= ChunkFormatV5 {
+						// Write dictionary offset and length
+						eb.reset()
+
+						eb.putBE64int(int(binary.BigEndian.Uint64(b[len(b)-48:])))
+						eb.putBE64int(int(binary.BigEndian.Uint64(b[len(b)-40:])))
+						_, err = w.Write(eb.get())
+						require.NoError(t, err)
+					}
+
 					if chk.format >= ChunkFormatV4 {
 						// Write structured metadata offset and length
 						eb.reset()
@@ -2270,7 +2284,7 @@ func TestDecodeChunkIncorrectBlockOffset(t *testing.T) {
 					copy(chkWithIncorrectOffset[metasOffset:], w.Bytes())
 
 					// decoding the problematic chunk should succeed
-					decodedChkWithIncorrectOffset, err := newByteChunk(chkWithIncorrectOffset, blockSize, testTargetSize, false)
+					decodedChkWithIncorrectOffset, err := newByteChunk("", chkWithIncorrectOffset, blockSize, testTargetSize, false, nil)
 					require.NoError(t, err)
 
 					require.Len(t, decodedChkWithIncorrectOffset.blocks, len(chk.blocks))
@@ -2296,3 +2310,71 @@ func TestDecodeChunkIncorrectBlockOffset(t *testing.T) {
 		})
 	}
 }
+
+func TestMemChunk_ZstdDictionary(t *testing.T) {
+	samples := make([][]byte, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		samples = append(samples, []byte(fmt.Sprintf("level=info msg=\"request served\" path=/api/v1/items/%d duration=%dms", i, i%7)))
+	}
+	dict, err := compression.TrainZstdDictionary(samples, 4<<10)
+	require.NoError(t, err)
+	pool, err := compression.RegisterZstdDictionary("fake", dict)
+	require.NoError(t, err)
+
+	for _, format := range []byte{ChunkFormatV4, ChunkFormatV5} {
+		t.Run(fmt.Sprintf("v%d", format), func(t *testing.T) {
+			c := NewMemChunkWithZstdDictionary(format, pool, UnorderedWithStructuredMetadataHeadBlockFmt, 1024, 0)
+			for i := 0; i < 100; i++ {
+				_, err := c.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: string(samples[i])})
+				require.NoError(t, err)
+			}
+			require.NoError(t, c.Close())
+
+			b, err := c.Bytes()
+			require.NoError(t, err)
+			decoded, err := NewTenantByteChunk("fake", b, 1024, 0)
+			require.NoError(t, err)
+			require.Equal(t, compression.ZstdDict, decoded.Encoding())
+			require.Equal(t, pool.ID(), decoded.zstdDictID)
+
+			rebound, err := decoded.Rebound(time.Unix(0, 10), time.Unix(0, 19), nil)
+			require.NoError(t, err)
+			require.Equal(t, pool.ID(), rebound.(*MemChunk).zstdDictID)
+
+			for _, chk := range []Chunk{decoded, rebound} {
+				it, err := chk.Iterator(context.Background(), time.Unix(0, 10), time.Unix(0, 20), logproto.FORWARD, noopStreamPipeline)
+				require.NoError(t, err)
+				i := 10
+				for it.Next() {
+					require.Equal(t, string(samples[i]), it.At().Line)
+					i++
+				}
+				require.NoError(t, it.Close())
+				require.Equal(t, 20, i)
+			}
+
+			// the dictionaries are resolved among the dictionaries of the tenant of the chunk.
+			_, err = NewTenantByteChunk("other", b, 1024, 0)
+			require.Error(t, err)
+			_, err = NewByteChunk(b, 1024, 0)
+			require.Error(t, err)
+
+			// the ID of an unknown dictionary can't be resolved.
+			binary.BigEndian.PutUint32(b[6:], pool.ID()+1)
+			_, err = NewTenantByteChunk("fake", b, 1024, 0)
+			require.Error(t, err)
+		})
+	}
+
+	// chunks without dictionary are plain zstd.
+	c := NewMemChunkWithZstdDictionary(ChunkFormatV4, nil, UnorderedWithStructuredMetadataHeadBlockFmt, 1024, 0)
+	_, err = c.Append(&logproto.Entry{Timestamp: time.Unix(0, 1), Line: "foo"})
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+	b, err := c.Bytes()
+	require.NoError(t, err)
+	decoded, err := NewByteChunk(b, 1024, 0)
+	require.NoError(t, err)
+	require.Zero(t, decoded.zstdDictID)
+	require.Equal(t, 1, decoded.Size())
+}
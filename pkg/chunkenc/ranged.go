@@ -0,0 +1,142 @@
+package chunkenc
+
+import (
+	"encoding/binary"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// chunkHeaderMaxSize is the size of the largest chunk header: magic number, version, encoding and dictionary ID.
+	chunkHeaderMaxSize = 10
+	// chunkTailReadSize is the size of the end of a chunk read first, which usually holds its block metas and the
+	// offsets and lengths of its sections.
+	chunkTailReadSize = 16 << 10
+)
+
+// RangeReader reads length bytes of an encoded chunk at the given offset.
+type RangeReader func(offset, length int) ([]byte, error)
+
+// NewByteChunkInRange decodes the blocks overlapping [mint, maxt] of an encoded chunk of the tenant of the given size with ranged
+// reads: it reads the end of the chunk holding its block metas, then its header and sections and finally the range
+// of the blocks to decode. The other blocks are left out of the returned chunk, which can only be iterated within
+// [mint, maxt].
+func NewByteChunkInRange(tenant string, size int, read RangeReader, mint, maxt int64, blockSize, targetSize int) (*MemChunk, error) {
+	b := make([]byte, size)
+	fill := func(offset, end int) error {
+		if offset >= end {
+			return nil
+		}
+		if offset < 0 || end > size {
+			return errors.Errorf("range [%d, %d) out of chunk of %d bytes", offset, end, size)
+		}
+		r, err := read(offset, end-offset)
+		if err != nil {
+			return err
+		}
+		if len(r) != end-offset {
+			return errors.Errorf("read %d bytes of chunk range [%d, %d)", len(r), offset, end)
+		}
+		copy(b[offset:end], r)
+		return nil
+	}
+	keep := func(blkMint, blkMaxt int64) bool {
+		return maxt >= blkMint && blkMaxt >= mint
+	}
+
+	tailOffset := max(size-chunkTailReadSize, 0)
+	if err := fill(tailOffset, size); err != nil {
+		return nil, errors.Wrap(err, "reading chunk tail")
+	}
+	if err := fill(0, min(chunkHeaderMaxSize, tailOffset)); err != nil {
+		return nil, errors.Wrap(err, "reading chunk header")
+	}
+	if tailOffset == 0 {
+		// the whole chunk is read already.
+		return newByteChunk(tenant, b, blockSize, targetSize, false, keep)
+	}
+
+	if size < 5 {
+		return nil, errors.New("chunk too small")
+	}
+	version := b[4]
+
+	// readSectionLenAndOffset reads the length and offset of the section at the given index from the end of the chunk,
+	// as in newByteChunk.
+	readSectionLenAndOffset := func(idx int) (int, int, error) {
+		pos := size - (idx * 16)
+		if pos < tailOffset {
+			return 0, 0, errors.Errorf("invalid section %d", idx)
+		}
+		l, o := binary.BigEndian.Uint64(b[pos:pos+8]), binary.BigEndian.Uint64(b[pos+8:pos+16])
+		if o+l+4 > uint64(size) {
+			return 0, 0, errors.Errorf("section %d at offset %d of length %d out of chunk of %d bytes", idx, o, l, size)
+		}
+		return int(l), int(o), nil
+	}
+
+	var metasLen, metasOffset, sectionsOffset, sectionsEnd int
+	if version >= ChunkFormatV4 {
+		var err error
+		if metasLen, metasOffset, err = readSectionLenAndOffset(chunkMetasSectionIdx); err != nil {
+			return nil, err
+		}
+		structuredMetadataLength, structuredMetadataOffset, err := readSectionLenAndOffset(chunkStructuredMetadataSectionIdx)
+		if err != nil {
+			return nil, err
+		}
+		sectionsOffset, sectionsEnd = structuredMetadataOffset, structuredMetadataOffset+structuredMetadataLength+4
+		if version >= ChunkFormatV5 {
+			dictLength, dictOffset, err := readSectionLenAndOffset(chunkDictSectionIdx)
+			if err != nil {
+				return nil, err
+			}
+			sectionsEnd = dictOffset + dictLength + 4
+		}
+	} else {
+		metasOffset = int(binary.BigEndian.Uint64(b[size-8:]))
+		metasLen = size - (8 + 4) - metasOffset
+		if metasOffset < 0 || metasLen < 0 {
+			return nil, errors.Errorf("invalid metas offset %d", metasOffset)
+		}
+	}
+
+	if err := fill(metasOffset, tailOffset); err != nil {
+		return nil, errors.Wrap(err, "reading chunk block metas")
+	}
+	if err := fill(sectionsOffset, min(sectionsEnd, tailOffset)); err != nil {
+		return nil, errors.Wrap(err, "reading chunk sections")
+	}
+
+	// read the range of the blocks to decode.
+	db := decbuf{b: b[metasOffset : metasOffset+metasLen]}
+	blocksOffset, blocksEnd := -1, 0
+	num := db.uvarint()
+	for i := 0; i < num; i++ {
+		db.uvarint() // #entries
+		blkMint, blkMaxt := db.varint64(), db.varint64()
+		offset := db.uvarint()
+		if version >= ChunkFormatV3 {
+			db.uvarint() // uncompressed size
+		}
+		l := db.uvarint()
+		if db.err() != nil {
+			return nil, errors.Wrap(db.err(), "decoding block meta")
+		}
+		if !keep(blkMint, blkMaxt) {
+			continue
+		}
+		if blocksOffset < 0 || offset < blocksOffset {
+			blocksOffset = offset
+		}
+		// each block is followed by its checksum.
+		blocksEnd = max(blocksEnd, offset+l+4)
+	}
+	if blocksOffset >= 0 {
+		if err := fill(blocksOffset, min(blocksEnd, tailOffset)); err != nil {
+			return nil, errors.Wrap(err, "reading chunk blocks")
+		}
+	}
+
+	return newByteChunk(tenant, b, blockSize, targetSize, false, keep)
+}
@@ -0,0 +1,96 @@
+package chunkenc
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+)
+
+func TestNewByteChunkInRange(t *testing.T) {
+	const (
+		blockSize = 4 << 10
+		entries   = 10000
+	)
+	for _, enc := range []compression.Codec{compression.None, compression.Snappy} {
+		for _, format := range allPossibleFormats {
+			t.Run(fmt.Sprintf("encoding:%v chunkFormat:%v headBlockFmt:%v", enc, format.chunkFormat, format.headBlockFmt), func(t *testing.T) {
+				rnd := rand.New(rand.NewSource(1))
+				chk := NewMemChunk(format.chunkFormat, enc, format.headBlockFmt, blockSize, 0)
+				for i := int64(0); i < entries; i++ {
+					line := fmt.Sprintf("line %d %x", i, rnd.Int63())
+					_, err := chk.Append(logprotoEntryWithStructuredMetadata(i, line, []logproto.LabelAdapter{{Name: "i", Value: fmt.Sprint(i % 10)}}))
+					require.NoError(t, err)
+				}
+				require.NoError(t, chk.Close())
+				b, err := chk.Bytes()
+				require.NoError(t, err)
+				require.Greater(t, len(b), 4*chunkTailReadSize)
+
+				for _, tc := range []struct {
+					name       string
+					mint, maxt int64
+				}{
+					{name: "start", mint: 0, maxt: 100},
+					{name: "middle", mint: 4000, maxt: 4500},
+					{name: "end", mint: entries - 100, maxt: entries},
+					{name: "none", mint: 2 * entries, maxt: 3 * entries},
+				} {
+					t.Run(tc.name, func(t *testing.T) {
+						var read int
+						c, err := NewByteChunkInRange("", len(b), func(offset, length int) ([]byte, error) {
+							read += length
+							return b[offset : offset+length], nil
+						}, tc.mint, tc.maxt, blockSize, 0)
+						require.NoError(t, err)
+						require.Less(t, read, len(b)/2)
+
+						it, err := c.Iterator(context.Background(), time.Unix(0, tc.mint), time.Unix(0, tc.maxt+1), logproto.FORWARD, log.NewNoopPipeline().ForStream(labels.Labels{}))
+						require.NoError(t, err)
+						expected := tc.mint
+						for it.Next() {
+							e := it.At()
+							require.Equal(t, expected, e.Timestamp.UnixNano())
+							require.Contains(t, e.Line, fmt.Sprintf("line %d ", expected))
+							if format.chunkFormat >= ChunkFormatV4 {
+								require.Equal(t, fmt.Sprint(expected%10), logproto.FromLabelAdaptersToLabels(e.StructuredMetadata).Get("i"))
+							}
+							expected++
+						}
+						require.NoError(t, it.Err())
+						require.NoError(t, it.Close())
+						require.Equal(t, max(min(tc.maxt+1, entries), tc.mint), expected)
+					})
+				}
+			})
+		}
+	}
+}
+
+func TestNewByteChunkInRange_SmallChunk(t *testing.T) {
+	chk := NewMemChunk(ChunkFormatV5, compression.Snappy, DefaultTestHeadBlockFmt, testBlockSize, testTargetSize)
+	for i := int64(0); i < 10; i++ {
+		_, err := chk.Append(logprotoEntry(i, fmt.Sprint(i)))
+		require.NoError(t, err)
+	}
+	require.NoError(t, chk.Close())
+	b, err := chk.Bytes()
+	require.NoError(t, err)
+
+	var reads int
+	c, err := NewByteChunkInRange("", len(b), func(offset, length int) ([]byte, error) {
+		reads++
+		return b[offset : offset+length], nil
+	}, 2, 4, testBlockSize, testTargetSize)
+	require.NoError(t, err)
+	require.Equal(t, 1, reads)
+	require.Equal(t, 10, c.Size())
+}
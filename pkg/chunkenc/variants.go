@@ -20,10 +20,11 @@ func newMultiExtractorSampleIterator(
 	b []byte,
 	format byte,
 	symbolizer *symbolizer,
+	dicts []*columnarDict,
 	extractors ...log.StreamSampleExtractor,
 ) iter.SampleIterator {
 	return &multiExtractorSampleBufferedIterator{
-		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer),
+		bufferedIterator: newBufferedIterator(ctx, pool, b, format, symbolizer, dicts),
 		extractors:       extractors,
 		stats:            stats.FromContext(ctx),
 	}
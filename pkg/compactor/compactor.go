@@ -16,6 +16,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/dskit/backoff"
 	"github.com/grafana/dskit/kv"
@@ -24,10 +25,14 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/analytics"
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/export"
 	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/compactor/tokenindex"
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
 	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
 	"github.com/grafana/loki/v3/pkg/util/filter"
@@ -73,27 +78,35 @@ var (
 )
 
 type Config struct {
-	WorkingDirectory               string              `yaml:"working_directory"`
-	CompactionInterval             time.Duration       `yaml:"compaction_interval"`
-	ApplyRetentionInterval         time.Duration       `yaml:"apply_retention_interval"`
-	RetentionEnabled               bool                `yaml:"retention_enabled"`
-	RetentionDeleteDelay           time.Duration       `yaml:"retention_delete_delay"`
-	RetentionDeleteWorkCount       int                 `yaml:"retention_delete_worker_count"`
-	RetentionTableTimeout          time.Duration       `yaml:"retention_table_timeout"`
-	RetentionBackoffConfig         backoff.Config      `yaml:"retention_backoff_config"`
-	DeleteRequestStore             string              `yaml:"delete_request_store"`
-	DeleteRequestStoreKeyPrefix    string              `yaml:"delete_request_store_key_prefix"`
-	DeleteRequestStoreDBType       string              `yaml:"delete_request_store_db_type"`
-	BackupDeleteRequestStoreDBType string              `yaml:"backup_delete_request_store_db_type"`
-	DeleteBatchSize                int                 `yaml:"delete_batch_size"`
-	DeleteRequestCancelPeriod      time.Duration       `yaml:"delete_request_cancel_period"`
-	DeleteMaxInterval              time.Duration       `yaml:"delete_max_interval"`
-	MaxCompactionParallelism       int                 `yaml:"max_compaction_parallelism"`
-	UploadParallelism              int                 `yaml:"upload_parallelism"`
-	CompactorRing                  lokiring.RingConfig `yaml:"compactor_ring,omitempty" doc:"description=The hash ring configuration used by compactors to elect a single instance for running compactions. The CLI flags prefix for this block config is: compactor.ring"`
-	RunOnce                        bool                `yaml:"_" doc:"hidden"`
-	TablesToCompact                int                 `yaml:"tables_to_compact"`
-	SkipLatestNTables              int                 `yaml:"skip_latest_n_tables"`
+	WorkingDirectory               string                 `yaml:"working_directory"`
+	CompactionInterval             time.Duration          `yaml:"compaction_interval"`
+	ApplyRetentionInterval         time.Duration          `yaml:"apply_retention_interval"`
+	RetentionEnabled               bool                   `yaml:"retention_enabled"`
+	RetentionDeleteDelay           time.Duration          `yaml:"retention_delete_delay"`
+	RetentionDeleteWorkCount       int                    `yaml:"retention_delete_worker_count"`
+	RetentionTableTimeout          time.Duration          `yaml:"retention_table_timeout"`
+	RetentionBackoffConfig         backoff.Config         `yaml:"retention_backoff_config"`
+	DeleteRequestStore             string                 `yaml:"delete_request_store"`
+	DeleteRequestStoreKeyPrefix    string                 `yaml:"delete_request_store_key_prefix"`
+	DeleteRequestStoreDBType       string                 `yaml:"delete_request_store_db_type"`
+	BackupDeleteRequestStoreDBType string                 `yaml:"backup_delete_request_store_db_type"`
+	DeleteBatchSize                int                    `yaml:"delete_batch_size"`
+	DeleteRequestCancelPeriod      time.Duration          `yaml:"delete_request_cancel_period"`
+	DeleteMaxInterval              time.Duration          `yaml:"delete_max_interval"`
+	DeleteRequestPreview           deletion.PreviewConfig `yaml:"delete_request_preview" doc:"description=Configures the previews of delete requests and their approval by a second user. The CLI flags prefix for this block config is: compactor.delete-request-preview"`
+	LegalHoldUserHeader            string                 `yaml:"legal_hold_user_header"`
+	MaxCompactionParallelism       int                    `yaml:"max_compaction_parallelism"`
+	UploadParallelism              int                    `yaml:"upload_parallelism"`
+	StorageTierMoveParallelism     int                    `yaml:"storage_tier_move_parallelism"`
+	Export                         export.Config          `yaml:"export" doc:"description=Configures the export of tenant data to Parquet files. The CLI flags prefix for this block config is: compactor.export"`
+	TenantMove                     TenantMoveConfig       `yaml:"tenant_move" doc:"description=Configures the moves of the data of a tenant to another tenant. The CLI flags prefix for this block config is: compactor.tenant-move"`
+	TokenIndex                     tokenindex.Config      `yaml:"token_index" doc:"description=Configures the token index, an exact index of the values of structured metadata keys used to skip the chunks which can't match the label filters of queries. The CLI flags prefix for this block config is: compactor.token-index"`
+	Tombstones                     tombstones.Config      `yaml:"tombstones" doc:"description=Configures the tombstones of the series fully removed by retention and delete requests, applied by the index gateways and the queriers before the compactor rebuilds the index. The CLI flags prefix for this block config is: compactor.tombstones"`
+	MergeReplicaChunks             bool                   `yaml:"merge_replica_chunks" category:"experimental"`
+	CompactorRing                  lokiring.RingConfig    `yaml:"compactor_ring,omitempty" doc:"description=The hash ring configuration used by compactors to elect a single instance for running compactions. The CLI flags prefix for this block config is: compactor.ring"`
+	RunOnce                        bool                   `yaml:"_" doc:"hidden"`
+	TablesToCompact                int                    `yaml:"tables_to_compact"`
+	SkipLatestNTables              int                    `yaml:"skip_latest_n_tables"`
 }
 
 // RegisterFlags registers flags.
@@ -111,14 +124,22 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.DeleteBatchSize, "compactor.delete-batch-size", 70, "The max number of delete requests to run per compaction cycle.")
 	f.DurationVar(&cfg.DeleteRequestCancelPeriod, "compactor.delete-request-cancel-period", 24*time.Hour, "Allow cancellation of delete request until duration after they are created. Data would be deleted only after delete requests have been older than this duration. Ideally this should be set to at least 24h.")
 	f.DurationVar(&cfg.DeleteMaxInterval, "compactor.delete-max-interval", 24*time.Hour, "Constrain the size of any single delete request with line filters. When a delete request > delete_max_interval is input, the request is sharded into smaller requests of no more than delete_max_interval")
+	f.StringVar(&cfg.LegalHoldUserHeader, "compactor.legal-hold-user-header", "X-Grafana-User", "HTTP header identifying the user who creates or releases a legal hold in the audit trail of the legal holds.")
 	f.DurationVar(&cfg.RetentionTableTimeout, "compactor.retention-table-timeout", 0, "The maximum amount of time to spend running retention and deletion on any given table in the index.")
 	f.IntVar(&cfg.MaxCompactionParallelism, "compactor.max-compaction-parallelism", 1, "Maximum number of tables to compact in parallel. While increasing this value, please make sure compactor has enough disk space allocated to be able to store and compact as many tables.")
 	f.IntVar(&cfg.UploadParallelism, "compactor.upload-parallelism", 10, "Number of upload/remove operations to execute in parallel when finalizing a compaction. NOTE: This setting is per compaction operation, which can be executed in parallel. The upper bound on the number of concurrent uploads is upload_parallelism * max_compaction_parallelism.")
+	f.BoolVar(&cfg.MergeReplicaChunks, "compactor.merge-replica-chunks", false, "Experimental. Merge the overlapping chunks of a series, typically flushed by the replicas of a stream, into chunks without their duplicated entries while retention is applied. The overlapping chunks are marked for deletion once the merged chunks are uploaded and indexed. Requires retention to be enabled.")
+	f.IntVar(&cfg.StorageTierMoveParallelism, "compactor.storage-tier-move-parallelism", 10, "Number of chunks to move in parallel to the storage tiers of a table. Storage tiers are configured per period in the schema config.")
 	f.BoolVar(&cfg.RunOnce, "compactor.run-once", false, "Run the compactor one time to cleanup and compact index files only (no retention applied)")
 	f.IntVar(&cfg.TablesToCompact, "compactor.tables-to-compact", 0, "Number of tables that compactor will try to compact. Newer tables are chosen when this is less than the number of tables available.")
 	f.IntVar(&cfg.SkipLatestNTables, "compactor.skip-latest-n-tables", 0, "Do not compact N latest tables. Together with -compactor.run-once and -compactor.tables-to-compact, this is useful when clearing compactor backlogs.")
 
 	cfg.RetentionBackoffConfig.RegisterFlagsWithPrefix("compactor.retention-backoff-config", f)
+	cfg.DeleteRequestPreview.RegisterFlagsWithPrefix("compactor.delete-request-preview.", f)
+	cfg.Export.RegisterFlagsWithPrefix("compactor.export.", f)
+	cfg.TenantMove.RegisterFlagsWithPrefix("compactor.tenant-move.", f)
+	cfg.TokenIndex.RegisterFlagsWithPrefix("compactor.token-index.", f)
+	cfg.Tombstones.RegisterFlagsWithPrefix("compactor.tombstones.", f)
 	// Ring
 	skipFlags := []string{
 		"compactor.ring.num-tokens",
@@ -143,6 +164,38 @@ func (cfg *Config) Validate() error {
 		return errors.New("Replication factor must not be changed as it will not take effect")
 	}
 
+	if err := cfg.DeleteRequestPreview.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Export.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.TenantMove.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.TokenIndex.Validate(); err != nil {
+		return err
+	}
+
+	if err := cfg.Tombstones.Validate(); err != nil {
+		return err
+	}
+
+	if cfg.Tombstones.Enabled && !cfg.RetentionEnabled {
+		return fmt.Errorf("compactor.retention-enabled should be set when tombstones are enabled")
+	}
+
+	if cfg.MergeReplicaChunks && !cfg.RetentionEnabled {
+		return fmt.Errorf("compactor.retention-enabled should be set when merging replica chunks")
+	}
+
+	if cfg.TenantMove.Enabled && !cfg.RetentionEnabled {
+		return fmt.Errorf("compactor.retention-enabled should be set when tenant moves are enabled")
+	}
+
 	if cfg.RetentionEnabled {
 		if cfg.DeleteRequestStore == "" {
 			return fmt.Errorf("compactor.delete-request-store should be configured when retention is enabled")
@@ -175,6 +228,7 @@ type Compactor struct {
 	deleteRequestsStore       deletion.DeleteRequestsStore
 	DeleteRequestsHandler     *deletion.DeleteRequestHandler
 	DeleteRequestsGRPCHandler *deletion.GRPCRequestHandler
+	LegalHoldsHandler         *deletion.LegalHoldHandler
 	deleteRequestsManager     *deletion.DeleteRequestsManager
 	expirationChecker         retention.ExpirationChecker
 	metrics                   *metrics
@@ -184,6 +238,10 @@ type Compactor struct {
 	schemaConfig              config.SchemaConfig
 	tableLocker               *tableLocker
 	limits                    Limits
+	exporter                  *export.Exporter
+	tokenIndexBuilder         *TokenIndexBuilder
+	TenantMover               *TenantMover
+	tombstoner                *tombstoner
 
 	// Ring used for running a single compactor
 	ringLifecycler *ring.BasicLifecycler
@@ -199,20 +257,29 @@ type Compactor struct {
 }
 
 type storeContainer struct {
-	tableMarker        retention.TableMarker
-	sweeper            *retention.Sweeper
+	tableMarker      retention.TableMarker
+	sweeper          *retention.Sweeper
+	tierMover        *storageTierMover
+	tombstonesFolder *tombstonesFolder
+	replicaMerger    *replicaMerger
+	// retentionWorkDir holds the markers of the chunks to delete by the sweeper, set when retention is enabled.
+	retentionWorkDir   string
 	indexStorageClient storage.Client
+	// locations[0] is the object_store of the period and locations[i+1] the storage tier i.
+	locations []tierLocation
 }
 
 type Limits interface {
 	deletion.Limits
 	retention.Limits
+	fetcher.TierLimits
 	DefaultLimits() *validation.Limits
 }
 
 func NewCompactor(
 	cfg Config,
 	objectStoreClients map[config.DayTime]client.ObjectClient,
+	tierStoreClients map[string]client.ObjectClient,
 	deleteStoreClient client.ObjectClient,
 	schemaConfig config.SchemaConfig,
 	limits Limits,
@@ -276,7 +343,7 @@ func NewCompactor(
 	compactor.subservicesWatcher = services.NewFailureWatcher()
 	compactor.subservicesWatcher.WatchManager(compactor.subservices)
 
-	if err := compactor.init(objectStoreClients, deleteStoreClient, schemaConfig, indexUpdatePropagationMaxDelay, limits, r); err != nil {
+	if err := compactor.init(objectStoreClients, tierStoreClients, deleteStoreClient, schemaConfig, indexUpdatePropagationMaxDelay, limits, r); err != nil {
 		return nil, fmt.Errorf("init compactor: %w", err)
 	}
 
@@ -286,6 +353,7 @@ func NewCompactor(
 
 func (c *Compactor) init(
 	objectStoreClients map[config.DayTime]client.ObjectClient,
+	tierStoreClients map[string]client.ObjectClient,
 	deleteStoreClient client.ObjectClient,
 	schemaConfig config.SchemaConfig,
 	indexUpdatePropagationMaxDelay time.Duration,
@@ -309,23 +377,52 @@ func (c *Compactor) init(
 
 	legacyMarkerDirs := make(map[string]struct{})
 	c.storeContainers = make(map[config.DayTime]storeContainer, len(objectStoreClients))
+	if c.cfg.Tombstones.Enabled {
+		c.tombstoner, err = newTombstoner(c.cfg.Tombstones, c, indexUpdatePropagationMaxDelay, r)
+		if err != nil {
+			return fmt.Errorf("failed to init tombstoner: %w", err)
+		}
+	}
+
 	for from, objectClient := range objectStoreClients {
 		period, err := schemaConfig.SchemaForTime(from.Time)
 		if err != nil {
 			return err
 		}
 
-		var sc storeContainer
+		var (
+			sc      storeContainer
+			name    = fmt.Sprintf("%s_%s", period.ObjectType, period.From.String())
+			r       = prometheus.WrapRegistererWith(prometheus.Labels{"from": name}, r)
+			primary = newTierLocation(period.ObjectType, objectClient, schemaConfig)
+		)
 		sc.indexStorageClient = storage.NewIndexStorageClient(objectClient, period.IndexTables.PathPrefix)
 
+		chunkClient := primary.chunkClient
+		sc.locations = []tierLocation{primary}
+		if len(period.StorageTiers) > 0 {
+			tiers := make([]client.Client, 0, len(period.StorageTiers))
+			for _, tier := range period.StorageTiers {
+				tierStoreClient, ok := tierStoreClients[tier.ObjectType]
+				if !ok {
+					return fmt.Errorf("object client not found for storage tier %s of period %s", tier.ObjectType, period.From.String())
+				}
+				location := newTierLocation(tier.ObjectType, tierStoreClient, schemaConfig)
+				sc.locations = append(sc.locations, location)
+				tiers = append(tiers, location.chunkClient)
+			}
+			// retention has to read and delete chunks wherever they are.
+			chunkClient = fetcher.NewTieredClient(schemaConfig, period, primary.chunkClient, tiers, limits)
+
+			sc.tierMover, err = newStorageTierMover(filepath.Join(c.cfg.WorkingDirectory, "tiers", name), schemaConfig, period, sc.locations, limits, c.cfg.StorageTierMoveParallelism, r)
+			if err != nil {
+				return fmt.Errorf("failed to init storage tier mover: %w", err)
+			}
+		}
+
 		if c.cfg.RetentionEnabled {
-			var (
-				raw              client.ObjectClient
-				encoder          client.KeyEncoder
-				name             = fmt.Sprintf("%s_%s", period.ObjectType, period.From.String())
-				retentionWorkDir = filepath.Join(c.cfg.WorkingDirectory, "retention", name)
-				r                = prometheus.WrapRegistererWith(prometheus.Labels{"from": name}, r)
-			)
+			retentionWorkDir := filepath.Join(c.cfg.WorkingDirectory, "retention", name)
+			sc.retentionWorkDir = retentionWorkDir
 
 			// given that compaction can now run on multiple periods, marker files are stored under /retention/{objectStoreType}_{periodFrom}/markers/
 			// if any markers are found in the common markers dir (/retention/markers/) or store specific markers dir (/retention/{objectStoreType}/markers/), copy them to the period specific dirs
@@ -340,16 +437,6 @@ func (c *Compactor) init(
 			// remove markers from the store dir after copying them to period specific dirs.
 			legacyMarkerDirs[period.ObjectType] = struct{}{}
 
-			if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
-				raw = casted.GetDownstream()
-			} else {
-				raw = objectClient
-			}
-			if _, ok := raw.(*local.FSObjectClient); ok {
-				encoder = client.FSEncoder
-			}
-			chunkClient := client.NewClient(objectClient, encoder, schemaConfig)
-
 			sc.sweeper, err = retention.NewSweeper(retentionWorkDir, chunkClient, c.cfg.RetentionDeleteWorkCount, c.cfg.RetentionDeleteDelay, c.cfg.RetentionBackoffConfig, r)
 			if err != nil {
 				return fmt.Errorf("failed to init sweeper: %w", err)
@@ -359,6 +446,14 @@ func (c *Compactor) init(
 			if err != nil {
 				return fmt.Errorf("failed to init table marker: %w", err)
 			}
+
+			if c.tombstoner != nil {
+				sc.tombstonesFolder = c.tombstoner.newFolder(retentionWorkDir)
+			}
+
+			if c.cfg.MergeReplicaChunks {
+				sc.replicaMerger = newReplicaMerger(retentionWorkDir, schemaConfig, period, chunkClient, r)
+			}
 		}
 
 		c.storeContainers[from] = sc
@@ -377,6 +472,17 @@ func (c *Compactor) init(
 		}
 	}
 
+	if c.cfg.TenantMove.Enabled {
+		if deleteStoreClient == nil {
+			return fmt.Errorf("delete store client not initialised when tenant moves are enabled")
+		}
+
+		c.TenantMover, err = newTenantMover(c.cfg.TenantMove, deleteStoreClient, c, r)
+		if err != nil {
+			return fmt.Errorf("failed to init tenant mover: %w", err)
+		}
+	}
+
 	c.metrics = newMetrics(r)
 	return nil
 }
@@ -399,6 +505,7 @@ func (c *Compactor) initDeletes(objectClient client.ObjectClient, indexUpdatePro
 
 	c.DeleteRequestsHandler = deletion.NewDeleteRequestHandler(
 		c.deleteRequestsStore,
+		limits,
 		c.cfg.DeleteMaxInterval,
 		c.cfg.DeleteRequestCancelPeriod,
 		r,
@@ -414,7 +521,13 @@ func (c *Compactor) initDeletes(objectClient client.ObjectClient, indexUpdatePro
 		r,
 	)
 
-	c.expirationChecker = newExpirationChecker(retention.NewExpirationChecker(limits), c.deleteRequestsManager)
+	c.LegalHoldsHandler = deletion.NewLegalHoldHandler(c.deleteRequestsStore, c.cfg.LegalHoldUserHeader)
+
+	c.expirationChecker = newExpirationChecker(
+		retention.NewExpirationChecker(limits, r),
+		c.deleteRequestsManager,
+		deletion.NewLegalHoldsChecker(c.deleteRequestsStore, r),
+	)
 	return nil
 }
 
@@ -621,6 +734,86 @@ func (c *Compactor) runCompactions(ctx context.Context) {
 			}(container)
 		}
 	}
+	if c.exporter != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			ticker := time.NewTicker(c.exporter.Interval())
+			defer ticker.Stop()
+
+			for {
+				if err := c.exporter.Run(ctx); err != nil {
+					level.Error(util_log.Logger).Log("msg", "failed to run exports", "err", err)
+				}
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	if c.tokenIndexBuilder != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			ticker := time.NewTicker(c.tokenIndexBuilder.Interval())
+			defer ticker.Stop()
+
+			for {
+				if err := c.tokenIndexBuilder.Run(ctx); err != nil {
+					level.Error(util_log.Logger).Log("msg", "failed to update token index", "err", err)
+				}
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	if c.TenantMover != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			ticker := time.NewTicker(c.TenantMover.Interval())
+			defer ticker.Stop()
+
+			for {
+				if err := c.TenantMover.Run(ctx); err != nil {
+					level.Error(util_log.Logger).Log("msg", "failed to run tenant moves", "err", err)
+				}
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	if c.tombstoner != nil {
+		c.wg.Add(1)
+		go func() {
+			defer c.wg.Done()
+
+			ticker := time.NewTicker(c.tombstoner.Interval())
+			defer ticker.Stop()
+
+			for {
+				if err := c.tombstoner.Run(ctx); err != nil {
+					level.Error(util_log.Logger).Log("msg", "failed to update tombstones", "err", err)
+				}
+				select {
+				case <-ticker.C:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
 	level.Info(util_log.Logger).Log("msg", "compactor started")
 }
 
@@ -682,6 +875,9 @@ func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRet
 		level.Error(util_log.Logger).Log("msg", "failed to initialize table for compaction", "table", tableName, "err", err)
 		return err
 	}
+	table.tierMover = sc.tierMover
+	table.tombstonesFolder = sc.tombstonesFolder
+	table.replicaMerger = sc.replicaMerger
 
 	interval := retention.ExtractIntervalFromTableName(tableName)
 	intervalMayHaveExpiredChunks := false
@@ -701,10 +897,42 @@ func (c *Compactor) CompactTable(ctx context.Context, tableName string, applyRet
 	return nil
 }
 
+// newTierLocation returns the location of the chunks stored in the object client.
+func newTierLocation(objectType string, objectClient client.ObjectClient, schemaConfig config.SchemaConfig) tierLocation {
+	var (
+		raw     client.ObjectClient
+		encoder client.KeyEncoder
+	)
+	if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
+		raw = casted.GetDownstream()
+	} else {
+		raw = objectClient
+	}
+	if _, ok := raw.(*local.FSObjectClient); ok {
+		encoder = client.FSEncoder
+	}
+	return tierLocation{
+		objectType:   objectType,
+		objectClient: objectClient,
+		chunkClient:  client.NewClient(objectClient, encoder, schemaConfig),
+		encoder:      encoder,
+	}
+}
+
 func (c *Compactor) RegisterIndexCompactor(indexType string, indexCompactor IndexCompactor) {
 	c.indexCompactors[indexType] = indexCompactor
 }
 
+// RegisterTokenIndexBuilder registers the builder updating the token index while this instance runs the compactor.
+func (c *Compactor) RegisterTokenIndexBuilder(builder *TokenIndexBuilder) {
+	c.tokenIndexBuilder = builder
+}
+
+// RegisterExporter registers the exporter processing the pending exports while this instance runs the compactor.
+func (c *Compactor) RegisterExporter(exporter *export.Exporter) {
+	c.exporter = exporter
+}
+
 func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) (err error) {
 	status := statusSuccess
 	start := time.Now()
@@ -813,8 +1041,8 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) (err
 
 	go func() {
 		for _, tableName := range tables {
-			if tableName == deletion.DeleteRequestsTableName {
-				// we do not want to compact or apply retention on delete requests table
+			if tableName == deletion.DeleteRequestsTableName || tableName == tombstones.TableName {
+				// we do not want to compact or apply retention on delete requests and tombstones tables
 				continue
 			}
 
@@ -841,27 +1069,84 @@ func (c *Compactor) RunCompaction(ctx context.Context, applyRetention bool) (err
 		return firstErr
 	}
 
+	if applyRetention {
+		if err := c.expireZstdDictionaries(ctx); err != nil {
+			return fmt.Errorf("failed to expire zstd dictionaries: %w", err)
+		}
+	}
+
 	return ctx.Err()
 }
 
+// expireZstdDictionaries deletes the expired zstd dictionaries from the object store of each period.
+func (c *Compactor) expireZstdDictionaries(ctx context.Context) error {
+	if c.limits == nil {
+		return nil
+	}
+
+	seen := map[string]struct{}{}
+	for _, sc := range c.storeContainers {
+		primary := sc.locations[0]
+		if _, ok := seen[primary.objectType]; ok {
+			continue
+		}
+		seen[primary.objectType] = struct{}{}
+
+		if _, err := expireZstdDictionaries(ctx, primary.objectClient, c.limits, time.Now(), util_log.Logger); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 type expirationChecker struct {
 	retentionExpiryChecker retention.ExpirationChecker
 	deletionExpiryChecker  retention.ExpirationChecker
+	legalHolds             legalHoldsChecker
+}
+
+// legalHoldsChecker tells if a chunk is under a legal hold.
+type legalHoldsChecker interface {
+	Load(ctx context.Context, now model.Time)
+	Held(ref retention.ChunkEntry, now model.Time) bool
 }
 
-func newExpirationChecker(retentionExpiryChecker, deletionExpiryChecker retention.ExpirationChecker) retention.ExpirationChecker {
-	return &expirationChecker{retentionExpiryChecker, deletionExpiryChecker}
+func newExpirationChecker(retentionExpiryChecker, deletionExpiryChecker retention.ExpirationChecker, legalHolds legalHoldsChecker) retention.ExpirationChecker {
+	return &expirationChecker{retentionExpiryChecker, deletionExpiryChecker, legalHolds}
 }
 
+// Expired tells if the chunk is expired by the retention or deleted by delete requests.
+// The chunks under a legal hold are never expired nor deleted.
 func (e *expirationChecker) Expired(ref retention.ChunkEntry, now model.Time) (bool, filter.Func) {
-	if expired, nonDeletedIntervals := e.retentionExpiryChecker.Expired(ref, now); expired {
-		return expired, nonDeletedIntervals
+	expired, filterFunc := e.expired(ref, now)
+	if expired && e.legalHolds.Held(ref, now) {
+		return false, nil
 	}
+	return expired, filterFunc
+}
 
-	return e.deletionExpiryChecker.Expired(ref, now)
+func (e *expirationChecker) expired(ref retention.ChunkEntry, now model.Time) (bool, filter.Func) {
+	expired, retentionFilter := e.retentionExpiryChecker.Expired(ref, now)
+	if expired && retentionFilter == nil {
+		return true, nil
+	}
+
+	// lines expired by the retention rules and lines deleted by delete requests are removed by the same chunk rewrite.
+	deleted, deletionFilter := e.deletionExpiryChecker.Expired(ref, now)
+	switch {
+	case !deleted:
+		return expired, retentionFilter
+	case deletionFilter == nil || !expired:
+		return true, deletionFilter
+	}
+
+	return true, func(ts time.Time, s string, structuredMetadata ...labels.Label) bool {
+		return retentionFilter(ts, s, structuredMetadata...) || deletionFilter(ts, s, structuredMetadata...)
+	}
 }
 
 func (e *expirationChecker) MarkPhaseStarted() {
+	e.legalHolds.Load(context.Background(), model.Now())
 	e.retentionExpiryChecker.MarkPhaseStarted()
 	e.deletionExpiryChecker.MarkPhaseStarted()
 }
@@ -886,7 +1171,8 @@ func (e *expirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval
 }
 
 func (e *expirationChecker) DropFromIndex(ref retention.ChunkEntry, tableEndTime model.Time, now model.Time) bool {
-	return e.retentionExpiryChecker.DropFromIndex(ref, tableEndTime, now) || e.deletionExpiryChecker.DropFromIndex(ref, tableEndTime, now)
+	drop := e.retentionExpiryChecker.DropFromIndex(ref, tableEndTime, now) || e.deletionExpiryChecker.DropFromIndex(ref, tableEndTime, now)
+	return drop && !e.legalHolds.Held(ref, now)
 }
 
 func (c *Compactor) OnRingInstanceRegister(_ *ring.BasicLifecycler, ringDesc ring.Desc, instanceExists bool, _ string, instanceDesc ring.InstanceDesc) (ring.InstanceState, ring.Tokens) {
@@ -13,12 +13,15 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/util/constants"
+	"github.com/grafana/loki/v3/pkg/util/filter"
 	loki_net "github.com/grafana/loki/v3/pkg/util/net"
 	"github.com/grafana/loki/v3/pkg/validation"
 )
@@ -61,7 +64,7 @@ func setupTestCompactor(t *testing.T, objectClients map[config.DayTime]client.Ob
 	overrides, err := validation.NewOverrides(defaultLimits, nil)
 	require.NoError(t, err)
 
-	c, err := NewCompactor(cfg, objectClients, objectClients[periodConfigs[len(periodConfigs)-1].From], config.SchemaConfig{
+	c, err := NewCompactor(cfg, objectClients, nil, objectClients[periodConfigs[len(periodConfigs)-1].From], config.SchemaConfig{
 		Configs: periodConfigs,
 	}, overrides, 0, prometheus.NewPedanticRegistry(), constants.Loki)
 	require.NoError(t, err)
@@ -449,3 +452,95 @@ func TestCompactor_TableLocking(t *testing.T) {
 		})
 	}
 }
+
+type fakeExpirationChecker struct {
+	retention.ExpirationChecker
+	expired    bool
+	filterFunc filter.Func
+}
+
+func (f fakeExpirationChecker) Expired(_ retention.ChunkEntry, _ model.Time) (bool, filter.Func) {
+	return f.expired, f.filterFunc
+}
+
+type fakeLegalHoldsChecker bool
+
+func (f fakeLegalHoldsChecker) Load(_ context.Context, _ model.Time) {}
+
+func (f fakeLegalHoldsChecker) Held(_ retention.ChunkEntry, _ model.Time) bool {
+	return bool(f)
+}
+
+func Test_expirationChecker_Expired(t *testing.T) {
+	lineFilter := func(line string) filter.Func {
+		return func(_ time.Time, s string, _ ...labels.Label) bool {
+			return s == line
+		}
+	}
+
+	for _, tc := range []struct {
+		name               string
+		retention, deletes fakeExpirationChecker
+		held               bool
+		expired            bool
+		deletedLines       []string
+	}{
+		{
+			name: "nothing expired",
+		},
+		{
+			name:      "chunk expired by retention",
+			retention: fakeExpirationChecker{expired: true},
+			deletes:   fakeExpirationChecker{expired: true, filterFunc: lineFilter("a")},
+			expired:   true,
+		},
+		{
+			name:         "lines expired by retention rules",
+			retention:    fakeExpirationChecker{expired: true, filterFunc: lineFilter("a")},
+			expired:      true,
+			deletedLines: []string{"a"},
+		},
+		{
+			name:         "lines deleted by delete requests",
+			deletes:      fakeExpirationChecker{expired: true, filterFunc: lineFilter("b")},
+			expired:      true,
+			deletedLines: []string{"b"},
+		},
+		{
+			name:      "chunk deleted by delete requests",
+			retention: fakeExpirationChecker{expired: true, filterFunc: lineFilter("a")},
+			deletes:   fakeExpirationChecker{expired: true},
+			expired:   true,
+		},
+		{
+			name:         "lines expired by retention rules and deleted by delete requests",
+			retention:    fakeExpirationChecker{expired: true, filterFunc: lineFilter("a")},
+			deletes:      fakeExpirationChecker{expired: true, filterFunc: lineFilter("b")},
+			expired:      true,
+			deletedLines: []string{"a", "b"},
+		},
+		{
+			name:      "chunk under a legal hold",
+			retention: fakeExpirationChecker{expired: true},
+			deletes:   fakeExpirationChecker{expired: true, filterFunc: lineFilter("b")},
+			held:      true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			expired, filterFunc := newExpirationChecker(tc.retention, tc.deletes, fakeLegalHoldsChecker(tc.held)).Expired(retention.ChunkEntry{}, model.Now())
+			require.Equal(t, tc.expired, expired)
+			if tc.deletedLines == nil {
+				require.Nil(t, filterFunc)
+				return
+			}
+
+			var deleted []string
+			for _, line := range []string{"a", "b", "c"} {
+				if filterFunc(time.Now(), line) {
+					deleted = append(deleted, line)
+				}
+			}
+			require.Equal(t, tc.deletedLines, deleted)
+		})
+	}
+}
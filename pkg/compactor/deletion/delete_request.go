@@ -89,7 +89,10 @@ func (d *DeleteRequest) FilterFunction(lbls labels.Labels) (filter.Func, error)
 
 		result, _, skip := f(0, s, structuredMetadata...)
 		if len(result) != 0 || skip {
-			d.Metrics.deletedLinesTotal.WithLabelValues(d.UserID).Inc()
+			// previews of delete requests don't count as deleted lines.
+			if d.Metrics != nil {
+				d.Metrics.deletedLinesTotal.WithLabelValues(d.UserID).Inc()
+			}
 			d.DeletedLines++
 			return true
 		}
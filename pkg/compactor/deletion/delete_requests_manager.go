@@ -202,6 +202,12 @@ func (d *DeleteRequestsManager) loadDeleteRequestsToProcess() error {
 	return nil
 }
 
+// PendingRequests returns the delete requests which can't be canceled anymore and are not processed yet,
+// without loading them for processing.
+func (d *DeleteRequestsManager) PendingRequests() ([]DeleteRequest, error) {
+	return d.filteredSortedDeleteRequests()
+}
+
 func (d *DeleteRequestsManager) filteredSortedDeleteRequests() ([]DeleteRequest, error) {
 	deleteRequests, err := d.deleteRequestsStore.GetUnprocessedShards(context.Background())
 	if err != nil {
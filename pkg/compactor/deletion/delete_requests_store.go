@@ -30,12 +30,16 @@ type DeleteRequestsStore interface {
 	RemoveDeleteRequest(ctx context.Context, userID string, requestID string) error
 	GetDeleteRequest(ctx context.Context, userID, requestID string) (DeleteRequest, error)
 	GetCacheGenerationNumber(ctx context.Context, userID string) (string, error)
+	// UpdateCacheGenerationNumber invalidates the results cache of the user after its data was removed outside of a delete request.
+	UpdateCacheGenerationNumber(ctx context.Context, userID string) error
 	MergeShardedRequests(ctx context.Context) error
 
 	// ToDo(Sandeep): To keep changeset smaller, below 2 methods treat a single shard as individual request. This can be refactored later in a separate PR.
 	MarkShardAsProcessed(ctx context.Context, req DeleteRequest) error
 	GetUnprocessedShards(ctx context.Context) ([]DeleteRequest, error)
 
+	LegalHoldsStore
+
 	Stop()
 }
 
@@ -74,23 +78,49 @@ func newDeleteRequestsStore(
 		if err != nil {
 			return nil, err
 		}
+		// the legal holds are counted on their own, so that they are copied even when the delete requests
+		// were copied by a version without legal holds.
+		noLegalHolds, err := deleteRequestsStoreSQLite.legalHoldsIsEmpty(context.Background())
+		if err != nil {
+			return nil, err
+		}
 
-		// copy data from boltdb to sqlite only if the sqlite store is empty
-		if sqliteStoreIsEmpty {
+		// copy data from boltdb to sqlite only if the sqlite store has none of it
+		if sqliteStoreIsEmpty || noLegalHolds {
 			boltdbStore, err := newDeleteRequestsStoreBoltDB(workingDirectory, indexStorageClient)
 			if err != nil {
 				return nil, err
 			}
 
-			shards, cacheGen, err := boltdbStore.getAllData(context.Background())
-			if err != nil {
-				return nil, err
+			var (
+				shards          []DeleteRequest
+				cacheGen        []userCacheGen
+				legalHolds      []LegalHold
+				legalHoldEvents []LegalHoldEvent
+			)
+			if sqliteStoreIsEmpty {
+				shards, cacheGen, err = boltdbStore.getAllData(context.Background())
+				if err != nil {
+					return nil, err
+				}
+			}
+			if noLegalHolds {
+				legalHolds, legalHoldEvents, err = boltdbStore.getAllLegalHoldsData(context.Background())
+				if err != nil {
+					return nil, err
+				}
 			}
 			boltdbStore.Stop()
 
-			err = deleteRequestsStoreSQLite.copyData(context.Background(), shards, cacheGen)
-			if err != nil {
-				return nil, err
+			if sqliteStoreIsEmpty {
+				if err := deleteRequestsStoreSQLite.copyData(context.Background(), shards, cacheGen); err != nil {
+					return nil, err
+				}
+			}
+			if noLegalHolds {
+				if err := deleteRequestsStoreSQLite.copyLegalHolds(context.Background(), legalHolds, legalHoldEvents); err != nil {
+					return nil, err
+				}
 			}
 		}
 	} else {
@@ -187,6 +217,14 @@ func (d deleteRequestsStoreTee) GetCacheGenerationNumber(ctx context.Context, us
 	return d.primaryStore.GetCacheGenerationNumber(ctx, userID)
 }
 
+func (d deleteRequestsStoreTee) UpdateCacheGenerationNumber(ctx context.Context, userID string) error {
+	if err := d.primaryStore.UpdateCacheGenerationNumber(ctx, userID); err != nil {
+		return err
+	}
+
+	return d.backupStore.UpdateCacheGenerationNumber(ctx, userID)
+}
+
 func (d deleteRequestsStoreTee) MergeShardedRequests(ctx context.Context) error {
 	if err := d.primaryStore.MergeShardedRequests(ctx); err != nil {
 		return err
@@ -207,6 +245,52 @@ func (d deleteRequestsStoreTee) GetUnprocessedShards(ctx context.Context) ([]Del
 	return d.primaryStore.GetUnprocessedShards(ctx)
 }
 
+func (d deleteRequestsStoreTee) AddLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error) {
+	hold, err := d.primaryStore.AddLegalHold(ctx, hold)
+	if err != nil {
+		return LegalHold{}, err
+	}
+
+	// Use hold ID from primary store to have hold with same ID in backup store.
+	if err := d.backupStore.addLegalHold(ctx, hold); err != nil {
+		return LegalHold{}, err
+	}
+
+	return hold, nil
+}
+
+func (d deleteRequestsStoreTee) addLegalHold(ctx context.Context, hold LegalHold) error {
+	if err := d.primaryStore.addLegalHold(ctx, hold); err != nil {
+		return err
+	}
+
+	return d.backupStore.addLegalHold(ctx, hold)
+}
+
+func (d deleteRequestsStoreTee) ReleaseLegalHold(ctx context.Context, userID, holdID, releasedBy, reason string, releasedAt model.Time) error {
+	if err := d.primaryStore.ReleaseLegalHold(ctx, userID, holdID, releasedBy, reason, releasedAt); err != nil {
+		return err
+	}
+
+	return d.backupStore.ReleaseLegalHold(ctx, userID, holdID, releasedBy, reason, releasedAt)
+}
+
+func (d deleteRequestsStoreTee) GetLegalHold(ctx context.Context, userID, holdID string) (LegalHold, error) {
+	return d.primaryStore.GetLegalHold(ctx, userID, holdID)
+}
+
+func (d deleteRequestsStoreTee) GetLegalHoldsForUser(ctx context.Context, userID string) ([]LegalHold, error) {
+	return d.primaryStore.GetLegalHoldsForUser(ctx, userID)
+}
+
+func (d deleteRequestsStoreTee) GetAllLegalHolds(ctx context.Context) ([]LegalHold, error) {
+	return d.primaryStore.GetAllLegalHolds(ctx)
+}
+
+func (d deleteRequestsStoreTee) GetLegalHoldEventsForUser(ctx context.Context, userID string) ([]LegalHoldEvent, error) {
+	return d.primaryStore.GetLegalHoldEventsForUser(ctx, userID)
+}
+
 func (d deleteRequestsStoreTee) Stop() {
 	d.primaryStore.Stop()
 	d.backupStore.Stop()
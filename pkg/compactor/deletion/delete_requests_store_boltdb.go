@@ -285,6 +285,13 @@ func (ds *deleteRequestsStoreBoltDB) GetCacheGenerationNumber(ctx context.Contex
 	return genNumber, nil
 }
 
+func (ds *deleteRequestsStoreBoltDB) UpdateCacheGenerationNumber(ctx context.Context, userID string) error {
+	writeBatch := ds.indexClient.NewWriteBatch()
+	ds.updateCacheGen(userID, writeBatch)
+
+	return ds.indexClient.BatchWrite(ctx, writeBatch)
+}
+
 func (ds *deleteRequestsStoreBoltDB) queryDeleteRequests(ctx context.Context, deleteQuery index.Query) ([]DeleteRequest, error) {
 	var deleteRequests []DeleteRequest
 	var err error
@@ -107,6 +107,9 @@ func newDeleteRequestsStoreSQLite(workingDirectory string, indexStorageClient st
 		sqlQuery{query: sqlCreateShardsTableIndex},
 		sqlQuery{query: sqlCreateCacheGenTable},
 		sqlQuery{query: sqlCreateCacheTableIndex},
+		sqlQuery{query: sqlCreateLegalHoldsTable},
+		sqlQuery{query: sqlCreateLegalHoldEventsTable},
+		sqlQuery{query: sqlCreateLegalHoldEventsTableIndex},
 	)
 	if err != nil {
 		return nil, err
@@ -210,9 +213,14 @@ func (ds *deleteRequestsStoreSQLite) buildAddDeleteRequestQueries(req DeleteRequ
 }
 
 func (ds *deleteRequestsStoreSQLite) isEmpty(ctx context.Context) (bool, error) {
+	return ds.countIsZero(ctx, sqlCountDeleteRequests)
+}
+
+// countIsZero returns whether the count returned by the query is zero.
+func (ds *deleteRequestsStoreSQLite) countIsZero(ctx context.Context, query string) (bool, error) {
 	isEmpty := true
 	err := ds.sqliteStore.Exec(ctx, false, sqlQuery{
-		query: sqlCountDeleteRequests,
+		query: query,
 		execOpts: &sqlitex.ExecOptions{
 			ResultFunc: func(stmt *sqlite.Stmt) error {
 				if stmt.ColumnInt(0) != 0 {
@@ -432,6 +440,18 @@ func (ds *deleteRequestsStoreSQLite) GetCacheGenerationNumber(ctx context.Contex
 	return genNumber, nil
 }
 
+func (ds *deleteRequestsStoreSQLite) UpdateCacheGenerationNumber(ctx context.Context, userID string) error {
+	return ds.sqliteStore.Exec(ctx, true, sqlQuery{
+		query: sqlUpdateCacheGen,
+		execOpts: &sqlitex.ExecOptions{
+			Args: []any{
+				userID,
+				time.Now().UnixNano(),
+			},
+		},
+	})
+}
+
 func (ds *deleteRequestsStoreSQLite) queryDeleteRequests(ctx context.Context, query string, args []any) ([]DeleteRequest, error) {
 	var requests []DeleteRequest
 	if err := ds.sqliteStore.Exec(ctx, false, sqlQuery{
@@ -0,0 +1,181 @@
+package deletion
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+type LegalHoldAction string
+
+const (
+	LegalHoldActionCreated  LegalHoldAction = "created"
+	LegalHoldActionReleased LegalHoldAction = "released"
+)
+
+var (
+	ErrLegalHoldNotFound   = errors.New("could not find matching legal hold")
+	errLegalHoldReleased   = errors.New("legal hold is already released")
+	errLegalHoldNoReason   = errors.New("reason not set")
+	errLegalHoldNoSelector = errors.New("query of a legal hold must be a stream selector without filters")
+)
+
+// LegalHold freezes the chunks of the streams matching its selector and overlapping its time range:
+// they are neither expired by the retention nor deleted by delete requests until the hold is released or expires.
+type LegalHold struct {
+	HoldID     string     `json:"hold_id"`
+	Query      string     `json:"query"`
+	StartTime  model.Time `json:"start_time"`
+	EndTime    model.Time `json:"end_time"`
+	Reason     string     `json:"reason"`
+	ExpiresAt  model.Time `json:"expires_at,omitempty"`
+	CreatedAt  model.Time `json:"created_at"`
+	CreatedBy  string     `json:"created_by,omitempty"`
+	ReleasedAt model.Time `json:"released_at,omitempty"`
+	ReleasedBy string     `json:"released_by,omitempty"`
+
+	UserID   string            `json:"-"`
+	matchers []*labels.Matcher `json:"-"`
+}
+
+// SetQuery sets the stream selector of the hold.
+func (h *LegalHold) SetQuery(query string) error {
+	matchers, err := syntax.ParseMatchers(query, true)
+	if err != nil {
+		return errLegalHoldNoSelector
+	}
+	h.Query = query
+	h.matchers = matchers
+	return nil
+}
+
+// IsActive tells if the hold is neither released nor expired.
+func (h *LegalHold) IsActive(now model.Time) bool {
+	return h.ReleasedAt == 0 && (h.ExpiresAt == 0 || now.Before(h.ExpiresAt))
+}
+
+// Holds tells if the hold covers the chunk.
+func (h *LegalHold) Holds(ref retention.ChunkEntry) bool {
+	return intervalsOverlap(model.Interval{Start: h.StartTime, End: h.EndTime}, model.Interval{Start: ref.From, End: ref.Through}) &&
+		allMatch(h.matchers, ref.Labels)
+}
+
+// LegalHoldEvent is an entry of the audit trail of the legal holds.
+type LegalHoldEvent struct {
+	HoldID    string          `json:"hold_id"`
+	Action    LegalHoldAction `json:"action"`
+	User      string          `json:"user,omitempty"`
+	Reason    string          `json:"reason,omitempty"`
+	Timestamp model.Time      `json:"timestamp"`
+
+	UserID string `json:"-"`
+}
+
+// LegalHoldsStore persists the legal holds and their audit trail next to the delete requests.
+type LegalHoldsStore interface {
+	// AddLegalHold assigns an ID to the hold, stores it and records its creation.
+	AddLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error)
+	addLegalHold(ctx context.Context, hold LegalHold) error
+	// ReleaseLegalHold releases the hold and records its release.
+	ReleaseLegalHold(ctx context.Context, userID, holdID, releasedBy, reason string, releasedAt model.Time) error
+	GetLegalHold(ctx context.Context, userID, holdID string) (LegalHold, error)
+	GetLegalHoldsForUser(ctx context.Context, userID string) ([]LegalHold, error)
+	GetAllLegalHolds(ctx context.Context) ([]LegalHold, error)
+	GetLegalHoldEventsForUser(ctx context.Context, userID string) ([]LegalHoldEvent, error)
+}
+
+func newLegalHold(hold LegalHold) LegalHold {
+	hold.HoldID = generateUniqueID(hold.UserID, hold.Query)
+	hold.CreatedAt = model.Now()
+	return hold
+}
+
+func legalHoldCreatedEvent(hold LegalHold) LegalHoldEvent {
+	return LegalHoldEvent{
+		HoldID:    hold.HoldID,
+		UserID:    hold.UserID,
+		Action:    LegalHoldActionCreated,
+		User:      hold.CreatedBy,
+		Reason:    hold.Reason,
+		Timestamp: hold.CreatedAt,
+	}
+}
+
+// LegalHoldsChecker keeps the active legal holds loaded for the duration of a compaction
+// to tell which chunks must be kept.
+type LegalHoldsChecker struct {
+	store   LegalHoldsStore
+	metrics *legalHoldsMetrics
+
+	holdsMtx sync.RWMutex
+	holds    map[string][]LegalHold
+	// loadFailed makes every chunk held when the holds could not be loaded,
+	// so that the compaction never deletes data that may be under a hold.
+	loadFailed bool
+}
+
+// NewLegalHoldsChecker creates a LegalHoldsChecker.
+func NewLegalHoldsChecker(store LegalHoldsStore, r prometheus.Registerer) *LegalHoldsChecker {
+	return &LegalHoldsChecker{
+		store:   store,
+		metrics: newLegalHoldsMetrics(r),
+		holds:   map[string][]LegalHold{},
+	}
+}
+
+// Load loads the holds which are active at the given time.
+func (c *LegalHoldsChecker) Load(ctx context.Context, now model.Time) {
+	holds, err := c.store.GetAllLegalHolds(ctx)
+
+	c.holdsMtx.Lock()
+	defer c.holdsMtx.Unlock()
+
+	c.holds = map[string][]LegalHold{}
+	c.loadFailed = err != nil
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "failed to load legal holds, holding all the chunks", "err", err)
+		return
+	}
+
+	active := 0
+	for _, hold := range holds {
+		if !hold.IsActive(now) {
+			continue
+		}
+		if err := hold.SetQuery(hold.Query); err != nil {
+			level.Error(util_log.Logger).Log("msg", "failed to parse legal hold query, holding all the chunks of the user in its time range", "hold_id", hold.HoldID, "user", hold.UserID, "err", err)
+			hold.matchers = nil
+		}
+		c.holds[hold.UserID] = append(c.holds[hold.UserID], hold)
+		active++
+	}
+	c.metrics.activeLegalHolds.Set(float64(active))
+}
+
+// Held tells if a hold active at the given time covers the chunk.
+func (c *LegalHoldsChecker) Held(ref retention.ChunkEntry, now model.Time) bool {
+	c.holdsMtx.RLock()
+	defer c.holdsMtx.RUnlock()
+
+	if c.loadFailed {
+		return true
+	}
+
+	userID := unsafeGetString(ref.UserID)
+	for _, hold := range c.holds[userID] {
+		if hold.IsActive(now) && hold.Holds(ref) {
+			c.metrics.legalHoldChunksRetainedTotal.WithLabelValues(string(ref.UserID)).Inc()
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package deletion
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/common/model"
+
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+// LegalHoldHandler provides handlers for legal holds
+type LegalHoldHandler struct {
+	legalHoldsStore LegalHoldsStore
+	userHeader      string
+}
+
+// NewLegalHoldHandler creates a LegalHoldHandler. The user identified by the userHeader of the requests
+// is recorded in the audit trail of the holds.
+func NewLegalHoldHandler(store LegalHoldsStore, userHeader string) *LegalHoldHandler {
+	return &LegalHoldHandler{
+		legalHoldsStore: store,
+		userHeader:      userHeader,
+	}
+}
+
+// AddLegalHoldHandler handles addition of a new legal hold
+func (h *LegalHoldHandler) AddLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	if h == nil {
+		http.Error(w, "Retention is not enabled", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	hold, err := legalHoldFromParams(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hold.UserID = userID
+	hold.CreatedBy = r.Header.Get(h.userHeader)
+
+	hold, err = h.legalHoldsStore.AddLegalHold(ctx, hold)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error adding legal hold to the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(util_log.Logger).Log(
+		"msg", "legal hold for user created",
+		"hold_id", hold.HoldID,
+		"user", userID,
+		"query", hold.Query,
+		"start_time", hold.StartTime.Unix(),
+		"end_time", hold.EndTime.Unix(),
+		"expires_at", hold.ExpiresAt.Unix(),
+		"created_by", hold.CreatedBy,
+		"reason", hold.Reason,
+	)
+
+	writeJSON(w, hold)
+}
+
+func legalHoldFromParams(params url.Values) (LegalHold, error) {
+	var hold LegalHold
+	if params.Get("query") == "" {
+		return hold, errors.New("query not set")
+	}
+	if err := hold.SetQuery(params.Get("query")); err != nil {
+		return hold, err
+	}
+
+	start, err := startTime(params)
+	if err != nil {
+		return hold, err
+	}
+	// unlike delete requests, a hold can cover data which is yet to be ingested.
+	end, err := parseTime(params.Get("end"))
+	if err != nil {
+		return hold, errors.New("invalid end time: require unix seconds or RFC3339 format")
+	}
+	if int64(start) >= end {
+		return hold, errors.New("start time can't be greater than or equal to end time")
+	}
+
+	if expires := params.Get("expires"); expires != "" {
+		expiresAt, err := parseTime(expires)
+		if err != nil {
+			return hold, errors.New("invalid expiry time: require unix seconds or RFC3339 format")
+		}
+		if expiresAt <= int64(model.Now()) {
+			return hold, errors.New("expiry time must be in the future")
+		}
+		hold.ExpiresAt = model.Time(expiresAt)
+	}
+
+	hold.Reason = params.Get("reason")
+	if hold.Reason == "" {
+		return hold, errLegalHoldNoReason
+	}
+	hold.StartTime = start
+	hold.EndTime = model.Time(end)
+	return hold, nil
+}
+
+// GetLegalHoldsHandler handles listing the legal holds of the tenant
+func (h *LegalHoldHandler) GetLegalHoldsHandler(w http.ResponseWriter, r *http.Request) {
+	if h == nil {
+		http.Error(w, "Retention is not enabled", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	holds, err := h.legalHoldsStore.GetLegalHoldsForUser(ctx, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting legal holds from the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if holds == nil {
+		holds = []LegalHold{}
+	}
+
+	writeJSON(w, holds)
+}
+
+// ReleaseLegalHoldHandler handles the release of a legal hold
+func (h *LegalHoldHandler) ReleaseLegalHoldHandler(w http.ResponseWriter, r *http.Request) {
+	if h == nil {
+		http.Error(w, "Retention is not enabled", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	holdID := params.Get("hold_id")
+	if holdID == "" {
+		http.Error(w, "hold_id not set", http.StatusBadRequest)
+		return
+	}
+	reason := params.Get("reason")
+	if reason == "" {
+		http.Error(w, errLegalHoldNoReason.Error(), http.StatusBadRequest)
+		return
+	}
+
+	releasedBy := r.Header.Get(h.userHeader)
+	err = h.legalHoldsStore.ReleaseLegalHold(ctx, userID, holdID, releasedBy, reason, model.Now())
+	switch {
+	case errors.Is(err, ErrLegalHoldNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errLegalHoldReleased):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		level.Error(util_log.Logger).Log("msg", "error releasing legal hold", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(util_log.Logger).Log(
+		"msg", "legal hold for user released",
+		"hold_id", holdID,
+		"user", userID,
+		"released_by", releasedBy,
+		"reason", reason,
+	)
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLegalHoldEventsHandler handles listing the audit trail of the legal holds of the tenant
+func (h *LegalHoldHandler) GetLegalHoldEventsHandler(w http.ResponseWriter, r *http.Request) {
+	if h == nil {
+		http.Error(w, "Retention is not enabled", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.legalHoldsStore.GetLegalHoldEventsForUser(ctx, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error getting legal hold events from the store", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if events == nil {
+		events = []LegalHoldEvent{}
+	}
+
+	writeJSON(w, events)
+}
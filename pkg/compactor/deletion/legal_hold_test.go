@@ -0,0 +1,244 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+func TestLegalHoldsAllStoreTypes(t *testing.T) {
+	for _, storeType := range []DeleteRequestsStoreDBType{DeleteRequestsStoreDBTypeBoltDB, DeleteRequestsStoreDBTypeSQLite} {
+		t.Run(string(storeType), func(t *testing.T) {
+			tc := setupStoreType(t, storeType)
+			defer tc.store.Stop()
+			ctx := context.Background()
+
+			hold1, err := tc.store.AddLegalHold(ctx, LegalHold{UserID: user1, Query: `{app="foo"}`, StartTime: now.Add(-time.Hour), EndTime: now, Reason: "case 1", CreatedBy: "alice"})
+			require.NoError(t, err)
+			require.NotEmpty(t, hold1.HoldID)
+			hold2, err := tc.store.AddLegalHold(ctx, LegalHold{UserID: user1, Query: `{app="bar"}`, StartTime: now.Add(-time.Hour), EndTime: now, Reason: "case 2", ExpiresAt: now.Add(time.Hour)})
+			require.NoError(t, err)
+			hold3, err := tc.store.AddLegalHold(ctx, LegalHold{UserID: user2, Query: `{app="foo"}`, StartTime: now.Add(-time.Hour), EndTime: now, Reason: "case 3"})
+			require.NoError(t, err)
+
+			holds, err := tc.store.GetLegalHoldsForUser(ctx, user1)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []LegalHold{hold1, hold2}, holds)
+
+			holds, err = tc.store.GetAllLegalHolds(ctx)
+			require.NoError(t, err)
+			require.ElementsMatch(t, []LegalHold{hold1, hold2, hold3}, holds)
+
+			require.NoError(t, tc.store.ReleaseLegalHold(ctx, user1, hold1.HoldID, "bob", "case closed", now.Add(time.Minute)))
+			require.ErrorIs(t, tc.store.ReleaseLegalHold(ctx, user1, hold1.HoldID, "bob", "case closed", now.Add(time.Minute)), errLegalHoldReleased)
+			require.ErrorIs(t, tc.store.ReleaseLegalHold(ctx, user2, hold1.HoldID, "bob", "case closed", now.Add(time.Minute)), ErrLegalHoldNotFound)
+
+			released, err := tc.store.GetLegalHold(ctx, user1, hold1.HoldID)
+			require.NoError(t, err)
+			require.Equal(t, now.Add(time.Minute), released.ReleasedAt)
+			require.Equal(t, "bob", released.ReleasedBy)
+			require.False(t, released.IsActive(now.Add(time.Minute)))
+
+			events, err := tc.store.GetLegalHoldEventsForUser(ctx, user1)
+			require.NoError(t, err)
+			require.Equal(t, []LegalHoldEvent{
+				{HoldID: hold1.HoldID, UserID: user1, Action: LegalHoldActionCreated, User: "alice", Reason: "case 1", Timestamp: hold1.CreatedAt},
+				{HoldID: hold2.HoldID, UserID: user1, Action: LegalHoldActionCreated, Reason: "case 2", Timestamp: hold2.CreatedAt},
+				{HoldID: hold1.HoldID, UserID: user1, Action: LegalHoldActionReleased, User: "bob", Reason: "case closed", Timestamp: now.Add(time.Minute)},
+			}, events)
+		})
+	}
+}
+
+func TestCopyLegalHolds(t *testing.T) {
+	tempDir := t.TempDir()
+	workingDir := filepath.Join(tempDir, "working-dir")
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{
+		Directory: filepath.Join(tempDir, "object-store"),
+	})
+	require.NoError(t, err)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, "")
+
+	boltdbStore, err := NewDeleteRequestsStore(DeleteRequestsStoreDBTypeBoltDB, workingDir, indexStorageClient, "", time.Hour)
+	require.NoError(t, err)
+
+	hold, err := boltdbStore.AddLegalHold(context.Background(), LegalHold{UserID: user1, Query: `{app="foo"}`, StartTime: now.Add(-time.Hour), EndTime: now, Reason: "case"})
+	require.NoError(t, err)
+	require.NoError(t, boltdbStore.ReleaseLegalHold(context.Background(), user1, hold.HoldID, "bob", "case closed", now))
+
+	boltdbHolds, err := boltdbStore.GetAllLegalHolds(context.Background())
+	require.NoError(t, err)
+	boltdbEvents, err := boltdbStore.GetLegalHoldEventsForUser(context.Background(), user1)
+	require.NoError(t, err)
+	boltdbStore.Stop()
+
+	// the holds are copied to sqlite even without any delete request.
+	sqliteStore, err := NewDeleteRequestsStore(DeleteRequestsStoreDBTypeSQLite, workingDir, indexStorageClient, "", time.Hour)
+	require.NoError(t, err)
+	defer sqliteStore.Stop()
+
+	sqliteHolds, err := sqliteStore.GetAllLegalHolds(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, boltdbHolds, sqliteHolds)
+
+	sqliteEvents, err := sqliteStore.GetLegalHoldEventsForUser(context.Background(), user1)
+	require.NoError(t, err)
+	require.Equal(t, boltdbEvents, sqliteEvents)
+
+	// the legal holds don't count as delete requests, which are copied on their own.
+	isEmpty, err := sqliteStore.(*deleteRequestsStoreSQLite).isEmpty(context.Background())
+	require.NoError(t, err)
+	require.True(t, isEmpty)
+	noLegalHolds, err := sqliteStore.(*deleteRequestsStoreSQLite).legalHoldsIsEmpty(context.Background())
+	require.NoError(t, err)
+	require.False(t, noLegalHolds)
+}
+
+type mockLegalHoldsStore struct {
+	LegalHoldsStore
+	holds []LegalHold
+	err   error
+}
+
+func (m *mockLegalHoldsStore) GetAllLegalHolds(_ context.Context) ([]LegalHold, error) {
+	return m.holds, m.err
+}
+
+func TestLegalHoldsChecker_Held(t *testing.T) {
+	chunk := func(userID string, lbls labels.Labels, from, through model.Time) retention.ChunkEntry {
+		return retention.ChunkEntry{
+			ChunkRef: retention.ChunkRef{UserID: []byte(userID), From: from, Through: through},
+			Labels:   lbls,
+		}
+	}
+
+	store := &mockLegalHoldsStore{holds: []LegalHold{
+		{HoldID: "1", UserID: user1, Query: `{app="foo"}`, StartTime: now.Add(-2 * time.Hour), EndTime: now.Add(-time.Hour)},
+		{HoldID: "2", UserID: user1, Query: `{app="bar"}`, StartTime: now.Add(-2 * time.Hour), EndTime: now, ReleasedAt: now.Add(-time.Minute)},
+		{HoldID: "3", UserID: user1, Query: `{app="baz"}`, StartTime: now.Add(-2 * time.Hour), EndTime: now, ExpiresAt: now.Add(time.Minute)},
+	}}
+	checker := NewLegalHoldsChecker(store, nil)
+	checker.Load(context.Background(), now)
+
+	for _, tc := range []struct {
+		name  string
+		chunk retention.ChunkEntry
+		now   model.Time
+		held  bool
+	}{
+		{
+			name:  "matching stream and time range",
+			chunk: chunk(user1, labels.FromStrings("app", "foo"), now.Add(-3*time.Hour), now.Add(-90*time.Minute)),
+			now:   now,
+			held:  true,
+		},
+		{
+			name:  "other stream",
+			chunk: chunk(user1, labels.FromStrings("app", "other"), now.Add(-3*time.Hour), now.Add(-90*time.Minute)),
+			now:   now,
+		},
+		{
+			name:  "other user",
+			chunk: chunk(user2, labels.FromStrings("app", "foo"), now.Add(-3*time.Hour), now.Add(-90*time.Minute)),
+			now:   now,
+		},
+		{
+			name:  "outside of the time range",
+			chunk: chunk(user1, labels.FromStrings("app", "foo"), now.Add(-30*time.Minute), now),
+			now:   now,
+		},
+		{
+			name:  "released hold",
+			chunk: chunk(user1, labels.FromStrings("app", "bar"), now.Add(-30*time.Minute), now),
+			now:   now,
+		},
+		{
+			name:  "hold not expired yet",
+			chunk: chunk(user1, labels.FromStrings("app", "baz"), now.Add(-30*time.Minute), now),
+			now:   now,
+			held:  true,
+		},
+		{
+			name:  "expired hold",
+			chunk: chunk(user1, labels.FromStrings("app", "baz"), now.Add(-30*time.Minute), now),
+			now:   now.Add(time.Minute),
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.held, checker.Held(tc.chunk, tc.now))
+		})
+	}
+
+	// all the chunks are held when the holds can't be loaded.
+	store.err = context.DeadlineExceeded
+	checker.Load(context.Background(), now)
+	require.True(t, checker.Held(chunk(user2, labels.FromStrings("app", "other"), now, now), now))
+}
+
+func TestLegalHoldHandler(t *testing.T) {
+	tc := setupStoreType(t, DeleteRequestsStoreDBTypeSQLite)
+	defer tc.store.Stop()
+	h := NewLegalHoldHandler(tc.store, "X-Grafana-User")
+
+	do := func(handler http.HandlerFunc, method string, params url.Values) *httptest.ResponseRecorder {
+		req, err := http.NewRequestWithContext(user.InjectOrgID(context.Background(), user1), method, "http://localhost:3100/loki/api/v1/legal_hold?"+params.Encode(), nil)
+		require.NoError(t, err)
+		req.Header.Set("X-Grafana-User", "alice")
+
+		w := httptest.NewRecorder()
+		handler(w, req)
+		return w
+	}
+
+	start := unixString(now.Add(-time.Hour))
+	for _, params := range []url.Values{
+		{"start": {start}, "reason": {"case"}},
+		{"query": {`{app="foo"} |= "error"`}, "start": {start}, "reason": {"case"}},
+		{"query": {`{app="foo"}`}, "reason": {"case"}},
+		{"query": {`{app="foo"}`}, "start": {start}},
+		{"query": {`{app="foo"}`}, "start": {start}, "reason": {"case"}, "expires": {unixString(now.Add(-time.Minute))}},
+	} {
+		require.Equal(t, http.StatusBadRequest, do(h.AddLegalHoldHandler, http.MethodPost, params).Code, params.Encode())
+	}
+
+	w := do(h.AddLegalHoldHandler, http.MethodPost, url.Values{"query": {`{app="foo"}`}, "start": {start}, "end": {unixString(now.Add(time.Hour))}, "reason": {"case"}})
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var hold LegalHold
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&hold))
+	require.Equal(t, "alice", hold.CreatedBy)
+
+	w = do(h.GetLegalHoldsHandler, http.MethodGet, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var holds []LegalHold
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&holds))
+	require.Len(t, holds, 1)
+	require.Equal(t, hold.HoldID, holds[0].HoldID)
+
+	require.Equal(t, http.StatusBadRequest, do(h.ReleaseLegalHoldHandler, http.MethodDelete, url.Values{"hold_id": {hold.HoldID}}).Code)
+	require.Equal(t, http.StatusNotFound, do(h.ReleaseLegalHoldHandler, http.MethodDelete, url.Values{"hold_id": {"unknown"}, "reason": {"case closed"}}).Code)
+	require.Equal(t, http.StatusNoContent, do(h.ReleaseLegalHoldHandler, http.MethodDelete, url.Values{"hold_id": {hold.HoldID}, "reason": {"case closed"}}).Code)
+	require.Equal(t, http.StatusBadRequest, do(h.ReleaseLegalHoldHandler, http.MethodDelete, url.Values{"hold_id": {hold.HoldID}, "reason": {"case closed"}}).Code)
+
+	w = do(h.GetLegalHoldEventsHandler, http.MethodGet, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+	var events []LegalHoldEvent
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&events))
+	require.Len(t, events, 2)
+	require.Equal(t, LegalHoldActionReleased, events[1].Action)
+	require.Equal(t, "case closed", events[1].Reason)
+}
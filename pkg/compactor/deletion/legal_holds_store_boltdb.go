@@ -0,0 +1,223 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/storage/stores/series/index"
+)
+
+const (
+	legalHold       indexType = "4"
+	legalHoldEvents indexType = "5"
+)
+
+// AddLegalHold assigns an ID to the hold, stores it and records its creation.
+func (ds *deleteRequestsStoreBoltDB) AddLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error) {
+	hold = newLegalHold(hold)
+	return hold, ds.addLegalHold(ctx, hold)
+}
+
+func (ds *deleteRequestsStoreBoltDB) addLegalHold(ctx context.Context, hold LegalHold) error {
+	writeBatch := ds.indexClient.NewWriteBatch()
+	if err := ds.writeLegalHold(hold, writeBatch); err != nil {
+		return err
+	}
+	if err := ds.writeLegalHoldEvent(legalHoldCreatedEvent(hold), writeBatch); err != nil {
+		return err
+	}
+
+	return ds.indexClient.BatchWrite(ctx, writeBatch)
+}
+
+// writeLegalHold adds an entry with userID and holdID as range key and the hold as value.
+// We don't want to set anything in hash key here since we would want to find the holds of all the users.
+func (ds *deleteRequestsStoreBoltDB) writeLegalHold(hold LegalHold, writeBatch index.WriteBatch) error {
+	value, err := json.Marshal(hold)
+	if err != nil {
+		return err
+	}
+
+	writeBatch.Add(DeleteRequestsTableName, string(legalHold), []byte(fmt.Sprintf("%s:%s", hold.UserID, hold.HoldID)), value)
+	return nil
+}
+
+// legalHoldEventSeq is the last sequence number of the legal hold events written by this process.
+var legalHoldEventSeq atomic.Int64
+
+// nextLegalHoldEventSeq returns an increasing sequence number ordering the events with the same timestamp,
+// in nanoseconds so that it keeps increasing across restarts.
+func nextLegalHoldEventSeq() int64 {
+	for {
+		last := legalHoldEventSeq.Load()
+		next := max(time.Now().UnixNano(), last+1)
+		if legalHoldEventSeq.CompareAndSwap(last, next) {
+			return next
+		}
+	}
+}
+
+// writeLegalHoldEvent adds an entry per user with the hex encoded timestamp and sequence number as range key prefix
+// to list the events in order.
+func (ds *deleteRequestsStoreBoltDB) writeLegalHoldEvent(event LegalHoldEvent, writeBatch index.WriteBatch) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	rangeValue := fmt.Sprintf("%016x:%016x:%s:%s", int64(event.Timestamp), nextLegalHoldEventSeq(), event.HoldID, event.Action)
+	writeBatch.Add(DeleteRequestsTableName, fmt.Sprintf("%s:%s", legalHoldEvents, event.UserID), []byte(rangeValue), value)
+	return nil
+}
+
+// ReleaseLegalHold releases the hold and records its release.
+func (ds *deleteRequestsStoreBoltDB) ReleaseLegalHold(ctx context.Context, userID, holdID, releasedBy, reason string, releasedAt model.Time) error {
+	hold, err := ds.GetLegalHold(ctx, userID, holdID)
+	if err != nil {
+		return err
+	}
+	if hold.ReleasedAt != 0 {
+		return errLegalHoldReleased
+	}
+
+	hold.ReleasedAt = releasedAt
+	hold.ReleasedBy = releasedBy
+
+	writeBatch := ds.indexClient.NewWriteBatch()
+	if err := ds.writeLegalHold(hold, writeBatch); err != nil {
+		return err
+	}
+	if err := ds.writeLegalHoldEvent(LegalHoldEvent{
+		HoldID:    holdID,
+		UserID:    userID,
+		Action:    LegalHoldActionReleased,
+		User:      releasedBy,
+		Reason:    reason,
+		Timestamp: releasedAt,
+	}, writeBatch); err != nil {
+		return err
+	}
+
+	return ds.indexClient.BatchWrite(ctx, writeBatch)
+}
+
+// GetLegalHold finds and returns the hold with given ID.
+func (ds *deleteRequestsStoreBoltDB) GetLegalHold(ctx context.Context, userID, holdID string) (LegalHold, error) {
+	holds, err := ds.queryLegalHolds(ctx, []byte(fmt.Sprintf("%s:%s", userID, holdID)))
+	if err != nil {
+		return LegalHold{}, err
+	}
+
+	for _, hold := range holds {
+		if hold.UserID == userID && hold.HoldID == holdID {
+			return hold, nil
+		}
+	}
+	return LegalHold{}, ErrLegalHoldNotFound
+}
+
+// GetLegalHoldsForUser returns all the holds of a user.
+func (ds *deleteRequestsStoreBoltDB) GetLegalHoldsForUser(ctx context.Context, userID string) ([]LegalHold, error) {
+	return ds.queryLegalHolds(ctx, []byte(userID+":"))
+}
+
+// GetAllLegalHolds returns the holds of all the users.
+func (ds *deleteRequestsStoreBoltDB) GetAllLegalHolds(ctx context.Context) ([]LegalHold, error) {
+	return ds.queryLegalHolds(ctx, nil)
+}
+
+func (ds *deleteRequestsStoreBoltDB) queryLegalHolds(ctx context.Context, rangeValuePrefix []byte) ([]LegalHold, error) {
+	var holds []LegalHold
+	var unmarshalErr error
+	err := ds.indexClient.QueryPages(ctx, []index.Query{{
+		TableName:        DeleteRequestsTableName,
+		HashValue:        string(legalHold),
+		RangeValuePrefix: rangeValuePrefix,
+	}}, func(_ index.Query, batch index.ReadBatchResult) (shouldContinue bool) {
+		itr := batch.Iterator()
+		for itr.Next() {
+			var hold LegalHold
+			if unmarshalErr = json.Unmarshal(itr.Value(), &hold); unmarshalErr != nil {
+				return false
+			}
+
+			rangeValue := string(itr.RangeValue())
+			hold.UserID = rangeValue[:strings.LastIndex(rangeValue, ":")]
+			holds = append(holds, hold)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	sort.Slice(holds, func(i, j int) bool {
+		return holds[i].CreatedAt < holds[j].CreatedAt
+	})
+	return holds, nil
+}
+
+// GetLegalHoldEventsForUser returns the audit trail of the holds of a user, oldest first.
+func (ds *deleteRequestsStoreBoltDB) GetLegalHoldEventsForUser(ctx context.Context, userID string) ([]LegalHoldEvent, error) {
+	var events []LegalHoldEvent
+	var unmarshalErr error
+	err := ds.indexClient.QueryPages(ctx, []index.Query{{
+		TableName: DeleteRequestsTableName,
+		HashValue: fmt.Sprintf("%s:%s", legalHoldEvents, userID),
+	}}, func(_ index.Query, batch index.ReadBatchResult) (shouldContinue bool) {
+		itr := batch.Iterator()
+		for itr.Next() {
+			var event LegalHoldEvent
+			if unmarshalErr = json.Unmarshal(itr.Value(), &event); unmarshalErr != nil {
+				return false
+			}
+
+			event.UserID = userID
+			events = append(events, event)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if unmarshalErr != nil {
+		return nil, unmarshalErr
+	}
+
+	return events, nil
+}
+
+// getAllLegalHoldsData returns all the holds and their audit trail for migrating them to another store.
+func (ds *deleteRequestsStoreBoltDB) getAllLegalHoldsData(ctx context.Context) ([]LegalHold, []LegalHoldEvent, error) {
+	holds, err := ds.GetAllLegalHolds(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var events []LegalHoldEvent
+	seenUsers := map[string]struct{}{}
+	for _, hold := range holds {
+		if _, ok := seenUsers[hold.UserID]; ok {
+			continue
+		}
+		seenUsers[hold.UserID] = struct{}{}
+
+		userEvents, err := ds.GetLegalHoldEventsForUser(ctx, hold.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		events = append(events, userEvents...)
+	}
+
+	return holds, events, nil
+}
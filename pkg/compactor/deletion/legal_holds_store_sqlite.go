@@ -0,0 +1,237 @@
+package deletion
+
+import (
+	"context"
+
+	"github.com/prometheus/common/model"
+	"zombiezen.com/go/sqlite"
+	"zombiezen.com/go/sqlite/sqlitex"
+)
+
+const (
+	columnNameReason     = "reason"
+	columnNameExpiresAt  = "expires_at"
+	columnNameCreatedBy  = "created_by"
+	columnNameReleasedAt = "released_at"
+	columnNameReleasedBy = "released_by"
+	columnNameAction     = "action"
+	columnNameActor      = "actor"
+	columnNameTimestamp  = "timestamp"
+)
+
+const (
+	sqlCreateLegalHoldsTable = `CREATE TABLE IF NOT EXISTS legal_holds (
+       id TEXT NOT NULL,
+       user_id TEXT NOT NULL,
+       query TEXT NOT NULL,
+       start_time INT NOT NULL,
+       end_time INT NOT NULL,
+       reason TEXT NOT NULL,
+       expires_at INT NOT NULL DEFAULT 0,
+       created_at INT NOT NULL,
+       created_by TEXT NOT NULL DEFAULT '',
+       released_at INT NOT NULL DEFAULT 0,
+       released_by TEXT NOT NULL DEFAULT '',
+       PRIMARY KEY (id, user_id)
+    );`
+	sqlCreateLegalHoldEventsTable = `CREATE TABLE IF NOT EXISTS legal_hold_events (
+       id TEXT NOT NULL,
+       user_id TEXT NOT NULL,
+       action TEXT NOT NULL,
+       actor TEXT NOT NULL,
+       reason TEXT NOT NULL,
+       timestamp INT NOT NULL
+    );`
+	sqlCreateLegalHoldEventsTableIndex = `CREATE INDEX IF NOT EXISTS idx_legal_hold_events_user_id ON legal_hold_events(user_id);`
+
+	sqlInsertLegalHold = `INSERT INTO legal_holds (id, user_id, query, start_time, end_time, reason, expires_at, created_at, created_by)
+                          VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?);`
+	sqlInsertLegalHoldEvent         = `INSERT INTO legal_hold_events VALUES (?, ?, ?, ?, ?, ?);`
+	sqlReleaseLegalHold             = `UPDATE legal_holds SET released_at=?, released_by=? WHERE id=? AND user_id=?;`
+	sqlSelectLegalHoldByID          = `SELECT * FROM legal_holds WHERE id = ? AND user_id = ?;`
+	sqlSelectLegalHolds             = `SELECT * FROM legal_holds ORDER BY created_at;`
+	sqlSelectLegalHoldsForUser      = `SELECT * FROM legal_holds WHERE user_id = ? ORDER BY created_at;`
+	sqlSelectLegalHoldEventsForUser = `SELECT * FROM legal_hold_events WHERE user_id = ? ORDER BY timestamp, rowid;`
+	sqlCountLegalHolds              = `SELECT COUNT(*) FROM legal_holds;`
+)
+
+// AddLegalHold assigns an ID to the hold, stores it and records its creation.
+func (ds *deleteRequestsStoreSQLite) AddLegalHold(ctx context.Context, hold LegalHold) (LegalHold, error) {
+	hold = newLegalHold(hold)
+	return hold, ds.addLegalHold(ctx, hold)
+}
+
+func (ds *deleteRequestsStoreSQLite) addLegalHold(ctx context.Context, hold LegalHold) error {
+	return ds.sqliteStore.Exec(ctx, true, buildInsertLegalHoldQuery(hold), buildInsertLegalHoldEventQuery(legalHoldCreatedEvent(hold)))
+}
+
+func buildInsertLegalHoldQuery(hold LegalHold) sqlQuery {
+	return sqlQuery{
+		query: sqlInsertLegalHold,
+		execOpts: &sqlitex.ExecOptions{
+			Args: []any{
+				hold.HoldID,
+				hold.UserID,
+				hold.Query,
+				hold.StartTime,
+				hold.EndTime,
+				hold.Reason,
+				hold.ExpiresAt,
+				hold.CreatedAt,
+				hold.CreatedBy,
+			},
+		},
+	}
+}
+
+func buildInsertLegalHoldEventQuery(event LegalHoldEvent) sqlQuery {
+	return sqlQuery{
+		query: sqlInsertLegalHoldEvent,
+		execOpts: &sqlitex.ExecOptions{
+			Args: []any{
+				event.HoldID,
+				event.UserID,
+				string(event.Action),
+				event.User,
+				event.Reason,
+				event.Timestamp,
+			},
+		},
+	}
+}
+
+// ReleaseLegalHold releases the hold and records its release.
+func (ds *deleteRequestsStoreSQLite) ReleaseLegalHold(ctx context.Context, userID, holdID, releasedBy, reason string, releasedAt model.Time) error {
+	hold, err := ds.GetLegalHold(ctx, userID, holdID)
+	if err != nil {
+		return err
+	}
+	if hold.ReleasedAt != 0 {
+		return errLegalHoldReleased
+	}
+
+	return ds.sqliteStore.Exec(ctx, true, sqlQuery{
+		query: sqlReleaseLegalHold,
+		execOpts: &sqlitex.ExecOptions{
+			Args: []any{
+				releasedAt,
+				releasedBy,
+				holdID,
+				userID,
+			},
+		},
+	}, buildInsertLegalHoldEventQuery(LegalHoldEvent{
+		HoldID:    holdID,
+		UserID:    userID,
+		Action:    LegalHoldActionReleased,
+		User:      releasedBy,
+		Reason:    reason,
+		Timestamp: releasedAt,
+	}))
+}
+
+func (ds *deleteRequestsStoreSQLite) GetLegalHold(ctx context.Context, userID, holdID string) (LegalHold, error) {
+	holds, err := ds.queryLegalHolds(ctx, sqlSelectLegalHoldByID, []any{holdID, userID})
+	if err != nil {
+		return LegalHold{}, err
+	}
+	if len(holds) == 0 {
+		return LegalHold{}, ErrLegalHoldNotFound
+	}
+
+	return holds[0], nil
+}
+
+func (ds *deleteRequestsStoreSQLite) GetLegalHoldsForUser(ctx context.Context, userID string) ([]LegalHold, error) {
+	return ds.queryLegalHolds(ctx, sqlSelectLegalHoldsForUser, []any{userID})
+}
+
+func (ds *deleteRequestsStoreSQLite) GetAllLegalHolds(ctx context.Context) ([]LegalHold, error) {
+	return ds.queryLegalHolds(ctx, sqlSelectLegalHolds, nil)
+}
+
+func (ds *deleteRequestsStoreSQLite) GetLegalHoldEventsForUser(ctx context.Context, userID string) ([]LegalHoldEvent, error) {
+	var events []LegalHoldEvent
+	if err := ds.sqliteStore.Exec(ctx, false, sqlQuery{
+		query: sqlSelectLegalHoldEventsForUser,
+		execOpts: &sqlitex.ExecOptions{
+			Args: []any{userID},
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				events = append(events, LegalHoldEvent{
+					HoldID:    stmt.GetText(columnNameID),
+					UserID:    stmt.GetText(columnNameUserID),
+					Action:    LegalHoldAction(stmt.GetText(columnNameAction)),
+					User:      stmt.GetText(columnNameActor),
+					Reason:    stmt.GetText(columnNameReason),
+					Timestamp: model.Time(stmt.GetInt64(columnNameTimestamp)),
+				})
+				return nil
+			},
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+func (ds *deleteRequestsStoreSQLite) queryLegalHolds(ctx context.Context, query string, args []any) ([]LegalHold, error) {
+	var holds []LegalHold
+	if err := ds.sqliteStore.Exec(ctx, false, sqlQuery{
+		query: query,
+		execOpts: &sqlitex.ExecOptions{
+			Args: args,
+			ResultFunc: func(stmt *sqlite.Stmt) error {
+				holds = append(holds, LegalHold{
+					HoldID:     stmt.GetText(columnNameID),
+					UserID:     stmt.GetText(columnNameUserID),
+					Query:      stmt.GetText(columnNameQuery),
+					StartTime:  model.Time(stmt.GetInt64(columnNameStartTime)),
+					EndTime:    model.Time(stmt.GetInt64(columnNameEndTime)),
+					Reason:     stmt.GetText(columnNameReason),
+					ExpiresAt:  model.Time(stmt.GetInt64(columnNameExpiresAt)),
+					CreatedAt:  model.Time(stmt.GetInt64(columnNameCreatedAt)),
+					CreatedBy:  stmt.GetText(columnNameCreatedBy),
+					ReleasedAt: model.Time(stmt.GetInt64(columnNameReleasedAt)),
+					ReleasedBy: stmt.GetText(columnNameReleasedBy),
+				})
+				return nil
+			},
+		},
+	}); err != nil {
+		return nil, err
+	}
+
+	return holds, nil
+}
+
+// legalHoldsIsEmpty returns whether the store holds no legal hold.
+func (ds *deleteRequestsStoreSQLite) legalHoldsIsEmpty(ctx context.Context) (bool, error) {
+	return ds.countIsZero(ctx, sqlCountLegalHolds)
+}
+
+// copyLegalHolds copies the holds and their audit trail migrated from another store.
+func (ds *deleteRequestsStoreSQLite) copyLegalHolds(ctx context.Context, holds []LegalHold, events []LegalHoldEvent) error {
+	var sqlQueries []sqlQuery
+	for _, hold := range holds {
+		sqlQueries = append(sqlQueries, buildInsertLegalHoldQuery(hold))
+		if hold.ReleasedAt != 0 {
+			sqlQueries = append(sqlQueries, sqlQuery{
+				query: sqlReleaseLegalHold,
+				execOpts: &sqlitex.ExecOptions{
+					Args: []any{
+						hold.ReleasedAt,
+						hold.ReleasedBy,
+						hold.HoldID,
+						hold.UserID,
+					},
+				},
+			})
+		}
+	}
+	for _, event := range events {
+		sqlQueries = append(sqlQueries, buildInsertLegalHoldEventQuery(event))
+	}
+
+	return ds.sqliteStore.Exec(ctx, true, sqlQueries...)
+}
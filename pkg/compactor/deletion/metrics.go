@@ -37,6 +37,7 @@ func NewDeleteRequestClientMetrics(r prometheus.Registerer) *DeleteRequestClient
 
 type deleteRequestHandlerMetrics struct {
 	deleteRequestsReceivedTotal *prometheus.CounterVec
+	deleteRequestPreviewsTotal  *prometheus.CounterVec
 }
 
 func newDeleteRequestHandlerMetrics(r prometheus.Registerer) *deleteRequestHandlerMetrics {
@@ -47,6 +48,11 @@ func newDeleteRequestHandlerMetrics(r prometheus.Registerer) *deleteRequestHandl
 		Name:      "compactor_delete_requests_received_total",
 		Help:      "Number of delete requests received per user",
 	}, []string{"user"})
+	m.deleteRequestPreviewsTotal = promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "compactor_delete_request_previews_total",
+		Help:      "Number of delete requests previewed per user",
+	}, []string{"user"})
 
 	return &m
 }
@@ -102,3 +108,25 @@ func newDeleteRequestsManagerMetrics(r prometheus.Registerer) *deleteRequestsMan
 
 	return &m
 }
+
+type legalHoldsMetrics struct {
+	activeLegalHolds             prometheus.Gauge
+	legalHoldChunksRetainedTotal *prometheus.CounterVec
+}
+
+func newLegalHoldsMetrics(r prometheus.Registerer) *legalHoldsMetrics {
+	m := legalHoldsMetrics{}
+
+	m.activeLegalHolds = promauto.With(r).NewGauge(prometheus.GaugeOpts{
+		Namespace: constants.Loki,
+		Name:      "compactor_active_legal_holds",
+		Help:      "Number of legal holds active at the start of the last compaction",
+	})
+	m.legalHoldChunksRetainedTotal = promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "compactor_legal_hold_chunks_retained_total",
+		Help:      "Number of chunks kept because of a legal hold while they were selected for retention or deletion per user",
+	}, []string{"user"})
+
+	return &m
+}
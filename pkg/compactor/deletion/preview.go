@@ -0,0 +1,351 @@
+package deletion
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
+)
+
+var (
+	ErrPreviewNotFound         = errors.New("could not find delete request preview")
+	errPreviewNotPending       = errors.New("delete request preview is not pending approval")
+	errPreviewExpired          = errors.New("delete request preview expired, preview the delete request again")
+	errApproverNotIdentified   = errors.New("approving a delete request requires the user header to be set")
+	errPreviewNotIdentified    = errors.New("delete request preview was created without the user header and can't be approved")
+	errApproverSameAsPreviewer = errors.New("a delete request must be approved by another user than the one who previewed it")
+)
+
+// PreviewConfig configures the previews of delete requests.
+type PreviewConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	PathPrefix string        `yaml:"path_prefix"`
+	UserHeader string        `yaml:"user_header"`
+	SampleSize int           `yaml:"sample_size"`
+	MaxChunks  int           `yaml:"max_chunks"`
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the preview config.
+func (cfg *PreviewConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable previews of delete requests with the dry_run parameter of the delete API and their approval by a second user. Requires the compactor to read the chunks through the store.")
+	f.StringVar(&cfg.PathPrefix, prefix+"path-prefix", "delete_previews/", "Path prefix of the previews in the delete request store.")
+	f.StringVar(&cfg.UserHeader, prefix+"user-header", "X-Grafana-User", "HTTP header identifying the user who previews or approves a delete request.")
+	f.IntVar(&cfg.SampleSize, prefix+"sample-size", 10, "Maximum number of matching log lines returned as a sample by a preview.")
+	f.IntVar(&cfg.MaxChunks, prefix+"max-chunks", 10000, "Maximum number of chunks read by a preview. The counts of a preview reading more chunks are lower bounds. 0 means no limit.")
+	f.DurationVar(&cfg.TTL, prefix+"ttl", 7*24*time.Hour, "How long the previews are kept. Older previews can't be approved anymore and are deleted when the previews of their tenant are created or listed. 0 means the previews are kept forever.")
+}
+
+// Validate validates the preview config.
+func (cfg *PreviewConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.PathPrefix == "" || !strings.HasSuffix(cfg.PathPrefix, "/") {
+		return errors.New("delete request preview path_prefix must end with a path separator i.e '/'")
+	}
+	if cfg.UserHeader == "" {
+		return errors.New("delete request preview user_header must be set")
+	}
+	if cfg.TTL < 0 {
+		return errors.New("delete request preview ttl must not be negative")
+	}
+	return nil
+}
+
+type PreviewStatus string
+
+const (
+	PreviewStatusPendingApproval PreviewStatus = "pending_approval"
+	PreviewStatusApproved        PreviewStatus = "approved"
+)
+
+// PreviewLine is a log line matched by a preview.
+type PreviewLine struct {
+	Timestamp time.Time `json:"timestamp"`
+	Labels    string    `json:"labels"`
+	Line      string    `json:"line"`
+}
+
+// DeletePreview is what a delete request would delete if it was processed now.
+// A delete request is created from the preview once it is approved.
+type DeletePreview struct {
+	PreviewID   string        `json:"preview_id"`
+	Query       string        `json:"query"`
+	StartTime   model.Time    `json:"start_time"`
+	EndTime     model.Time    `json:"end_time"`
+	MaxInterval time.Duration `json:"max_interval,omitempty"`
+	CreatedAt   model.Time    `json:"created_at"`
+	CreatedBy   string        `json:"created_by"`
+
+	Streams int64 `json:"streams"`
+	Chunks  int64 `json:"chunks"`
+	Lines   int64 `json:"lines"`
+	// Truncated is set when the preview stopped reading chunks after the configured maximum.
+	Truncated bool          `json:"truncated"`
+	Sample    []PreviewLine `json:"sample"`
+
+	Status     PreviewStatus `json:"status"`
+	ApprovedBy string        `json:"approved_by,omitempty"`
+	ApprovedAt model.Time    `json:"approved_at,omitempty"`
+	RequestID  string        `json:"request_id,omitempty"`
+}
+
+// ChunkStore looks up and fetches the chunks of a tenant.
+type ChunkStore interface {
+	GetChunks(ctx context.Context, userID string, from, through model.Time, predicate chunk.Predicate, storeChunksOverride *logproto.ChunkRefGroup) ([][]chunk.Chunk, []*fetcher.Fetcher, error)
+}
+
+// Previewer computes the previews of delete requests with the same filter function used by the compaction
+// and stores them in object storage until they expire:
+//
+//	<path_prefix>/<tenant>/<preview_id>.json
+type Previewer struct {
+	cfg          PreviewConfig
+	objectClient client.ObjectClient
+	store        ChunkStore
+
+	// approveMtx serializes the approvals, so that a preview approved concurrently only adds one delete request.
+	approveMtx sync.Mutex
+}
+
+// NewPreviewer creates a new Previewer.
+func NewPreviewer(cfg PreviewConfig, objectClient client.ObjectClient, store ChunkStore) *Previewer {
+	return &Previewer{
+		cfg:          cfg,
+		objectClient: objectClient,
+		store:        store,
+	}
+}
+
+// Preview computes and stores the preview of a delete request.
+func (p *Previewer) Preview(ctx context.Context, userID, createdBy, query string, startTime, endTime model.Time, maxInterval time.Duration) (DeletePreview, error) {
+	preview := DeletePreview{
+		PreviewID:   generateUniqueID(userID, query),
+		Query:       query,
+		StartTime:   startTime,
+		EndTime:     endTime,
+		MaxInterval: maxInterval,
+		CreatedAt:   model.Now(),
+		CreatedBy:   createdBy,
+		Sample:      []PreviewLine{},
+		Status:      PreviewStatusPendingApproval,
+	}
+
+	req := DeleteRequest{UserID: userID, StartTime: startTime, EndTime: endTime}
+	if err := req.SetQuery(query); err != nil {
+		return preview, err
+	}
+	if err := p.count(ctx, &req, &preview); err != nil {
+		return preview, err
+	}
+	// listing the previews of the tenant deletes the expired ones.
+	if _, err := p.List(ctx, userID); err != nil {
+		return preview, err
+	}
+
+	return preview, p.write(ctx, userID, preview)
+}
+
+// count counts the streams, chunks and lines deleted by the request.
+func (p *Previewer) count(ctx context.Context, req *DeleteRequest, preview *DeletePreview) error {
+	ctx = user.InjectOrgID(ctx, req.UserID)
+	chunks, fetchers, err := p.store.GetChunks(ctx, req.UserID, req.StartTime, req.EndTime, chunk.NewPredicate(req.matchers, nil), nil)
+	if err != nil {
+		return err
+	}
+
+	streams := map[string]struct{}{}
+	read := 0
+	for i, group := range chunks {
+		if p.cfg.MaxChunks > 0 && read+len(group) > p.cfg.MaxChunks {
+			group = group[:p.cfg.MaxChunks-read]
+			preview.Truncated = true
+		}
+		read += len(group)
+		if len(group) == 0 {
+			continue
+		}
+
+		fetched, err := fetchers[i].FetchChunks(ctx, group)
+		if err != nil {
+			return err
+		}
+		// the chunks are fetched concurrently, sort them so that the sample doesn't depend on the fetch order.
+		sort.Slice(fetched, func(i, j int) bool {
+			if c := labels.Compare(fetched[i].Metric, fetched[j].Metric); c != 0 {
+				return c < 0
+			}
+			return fetched[i].From < fetched[j].From
+		})
+		for _, c := range fetched {
+			lbls := labels.NewBuilder(c.Metric).Del(labels.MetricName).Labels()
+			lines, err := p.countChunk(ctx, req, c, lbls, preview)
+			if err != nil {
+				return err
+			}
+			if lines == 0 {
+				continue
+			}
+			preview.Chunks++
+			preview.Lines += lines
+			streams[lbls.String()] = struct{}{}
+		}
+	}
+	preview.Streams = int64(len(streams))
+	return nil
+}
+
+func (p *Previewer) countChunk(ctx context.Context, req *DeleteRequest, c chunk.Chunk, lbls labels.Labels, preview *DeletePreview) (int64, error) {
+	facade, ok := c.Data.(*chunkenc.Facade)
+	if !ok {
+		return 0, errors.New("invalid chunk type")
+	}
+
+	filterFunc, err := req.FilterFunction(lbls)
+	if err != nil {
+		return 0, err
+	}
+
+	// the end time of delete requests is inclusive.
+	it, err := facade.LokiChunk().Iterator(ctx, req.StartTime.Time(), req.EndTime.Time().Add(time.Nanosecond), logproto.FORWARD, log.NewNoopPipeline().ForStream(lbls))
+	if err != nil {
+		return 0, err
+	}
+	defer it.Close()
+
+	var lines int64
+	for it.Next() {
+		entry := it.At()
+		if !filterFunc(entry.Timestamp, entry.Line, logproto.FromLabelAdaptersToLabels(entry.StructuredMetadata)...) {
+			continue
+		}
+		lines++
+		if len(preview.Sample) < p.cfg.SampleSize {
+			preview.Sample = append(preview.Sample, PreviewLine{Timestamp: entry.Timestamp.UTC(), Labels: lbls.String(), Line: entry.Line})
+		}
+	}
+	return lines, it.Err()
+}
+
+// Approve approves the pending preview on behalf of approvedBy. The delete request is added to the store
+// before the preview is marked as approved.
+func (p *Previewer) Approve(ctx context.Context, store DeleteRequestsStore, userID, previewID, approvedBy string) (DeletePreview, error) {
+	p.approveMtx.Lock()
+	defer p.approveMtx.Unlock()
+
+	preview, err := p.Get(ctx, userID, previewID)
+	if err != nil {
+		return preview, err
+	}
+
+	switch {
+	case preview.Status != PreviewStatusPendingApproval:
+		return preview, errPreviewNotPending
+	case p.expired(preview, model.Now()):
+		return preview, errPreviewExpired
+	case approvedBy == "":
+		return preview, errApproverNotIdentified
+	case preview.CreatedBy == "":
+		return preview, errPreviewNotIdentified
+	case preview.CreatedBy == approvedBy:
+		return preview, errApproverSameAsPreviewer
+	}
+
+	requestID, err := store.AddDeleteRequest(ctx, userID, preview.Query, preview.StartTime, preview.EndTime, preview.MaxInterval)
+	if err != nil {
+		return preview, err
+	}
+
+	preview.Status = PreviewStatusApproved
+	preview.ApprovedBy = approvedBy
+	preview.ApprovedAt = model.Now()
+	preview.RequestID = requestID
+	return preview, p.write(ctx, userID, preview)
+}
+
+// Get returns the preview of the tenant.
+func (p *Previewer) Get(ctx context.Context, userID, previewID string) (DeletePreview, error) {
+	var preview DeletePreview
+	reader, _, err := p.objectClient.GetObject(ctx, p.key(userID, previewID))
+	if err != nil {
+		if p.objectClient.IsObjectNotFoundErr(err) {
+			return preview, ErrPreviewNotFound
+		}
+		return preview, err
+	}
+	defer reader.Close()
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return preview, err
+	}
+	return preview, json.Unmarshal(b, &preview)
+}
+
+// List returns the previews of the tenant, oldest first. The expired previews are deleted.
+func (p *Previewer) List(ctx context.Context, userID string) ([]DeletePreview, error) {
+	objects, _, err := p.objectClient.List(ctx, p.cfg.PathPrefix+userID+"/", "")
+	if err != nil {
+		return nil, err
+	}
+
+	now := model.Now()
+	previews := make([]DeletePreview, 0, len(objects))
+	for _, object := range objects {
+		previewID := strings.TrimSuffix(object.Key[strings.LastIndex(object.Key, "/")+1:], ".json")
+		preview, err := p.Get(ctx, userID, previewID)
+		if errors.Is(err, ErrPreviewNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if p.expired(preview, now) {
+			if err := p.objectClient.DeleteObject(ctx, object.Key); err != nil && !p.objectClient.IsObjectNotFoundErr(err) {
+				return nil, err
+			}
+			continue
+		}
+		previews = append(previews, preview)
+	}
+	sort.Slice(previews, func(i, j int) bool {
+		return previews[i].CreatedAt < previews[j].CreatedAt
+	})
+	return previews, nil
+}
+
+// expired returns true if the preview is older than the TTL.
+func (p *Previewer) expired(preview DeletePreview, now model.Time) bool {
+	return p.cfg.TTL > 0 && now.Sub(preview.CreatedAt) > p.cfg.TTL
+}
+
+func (p *Previewer) write(ctx context.Context, userID string, preview DeletePreview) error {
+	b, err := json.Marshal(preview)
+	if err != nil {
+		return err
+	}
+	return p.objectClient.PutObject(ctx, p.key(userID, preview.PreviewID), bytes.NewReader(b))
+}
+
+func (p *Previewer) key(userID, previewID string) string {
+	return fmt.Sprintf("%s%s/%s.json", p.cfg.PathPrefix, userID, previewID)
+}
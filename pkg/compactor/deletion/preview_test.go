@@ -0,0 +1,303 @@
+package deletion
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
+	ingesterclient "github.com/grafana/loki/v3/pkg/ingester/client"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+type mockChunkStore struct {
+	chunks  []chunk.Chunk
+	fetcher *fetcher.Fetcher
+}
+
+func (m *mockChunkStore) GetChunks(_ context.Context, _ string, _, _ model.Time, predicate chunk.Predicate, _ *logproto.ChunkRefGroup) ([][]chunk.Chunk, []*fetcher.Fetcher, error) {
+	var chunks []chunk.Chunk
+	for _, c := range m.chunks {
+		if allMatch(predicate.Matchers, c.Metric) {
+			chunks = append(chunks, c)
+		}
+	}
+	return [][]chunk.Chunk{chunks}, []*fetcher.Fetcher{m.fetcher}, nil
+}
+
+func newTestPreviewer(t *testing.T, cfg PreviewConfig, chunks ...chunk.Chunk) *Previewer {
+	sc := config.SchemaConfig{Configs: testutils.NewMockStorage().GetSchemaConfigs()}
+	chunkClient := client.NewClient(testutils.NewInMemoryObjectClient(), nil, sc)
+	require.NoError(t, chunkClient.PutChunks(context.Background(), chunks))
+
+	f, err := fetcher.New(cache.NewNoopCache(), nil, false, sc, chunkClient, 0, 0)
+	require.NoError(t, err)
+	return NewPreviewer(cfg, testutils.NewInMemoryObjectClient(), &mockChunkStore{chunks: chunks, fetcher: f})
+}
+
+func createPreviewChunk(t *testing.T, userID string, lbs labels.Labels, from model.Time, lines ...string) chunk.Chunk {
+	metric := labels.NewBuilder(lbs).Set(labels.MetricName, "logs").Labels()
+	chunkEnc := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256*1024, 0)
+	for i, line := range lines {
+		dup, err := chunkEnc.Append(&logproto.Entry{Timestamp: from.Add(time.Duration(i) * time.Minute).Time(), Line: line})
+		require.False(t, dup)
+		require.NoError(t, err)
+	}
+	require.NoError(t, chunkEnc.Close())
+
+	through := from.Add(time.Duration(len(lines)-1) * time.Minute)
+	c := chunk.NewChunk(userID, ingesterclient.Fingerprint(lbs), metric, chunkenc.NewFacade(chunkEnc, 256*1024, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func TestPreviewer_Preview(t *testing.T) {
+	from := model.TimeFromUnix(1000)
+	previewer := newTestPreviewer(t, PreviewConfig{PathPrefix: "delete_previews/", SampleSize: 2},
+		createPreviewChunk(t, "user", labels.FromStrings("app", "foo", "pod", "a"), from, "error 1", "info 2", "error 3"),
+		createPreviewChunk(t, "user", labels.FromStrings("app", "foo", "pod", "a"), from.Add(time.Hour), "info 4"),
+		createPreviewChunk(t, "user", labels.FromStrings("app", "foo", "pod", "b"), from, "error 5", "error 6"),
+		createPreviewChunk(t, "user", labels.FromStrings("app", "bar"), from, "error 7"),
+	)
+
+	preview, err := previewer.Preview(context.Background(), "user", "alice", `{app="foo"} |= "error"`, from, from.Add(2*time.Hour), 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), preview.Streams)
+	require.Equal(t, int64(2), preview.Chunks)
+	require.Equal(t, int64(4), preview.Lines)
+	require.False(t, preview.Truncated)
+	require.Len(t, preview.Sample, 2)
+	require.Equal(t, "error 1", preview.Sample[0].Line)
+	require.Equal(t, `{app="foo", pod="a"}`, preview.Sample[0].Labels)
+	require.Equal(t, PreviewStatusPendingApproval, preview.Status)
+	require.Equal(t, "alice", preview.CreatedBy)
+
+	// lines outside the interval of the delete request are not counted.
+	preview, err = previewer.Preview(context.Background(), "user", "alice", `{app="foo"}`, from, from.Add(time.Minute), 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), preview.Streams)
+	require.Equal(t, int64(4), preview.Lines)
+
+	// the previews are stored.
+	stored, err := previewer.Get(context.Background(), "user", preview.PreviewID)
+	require.NoError(t, err)
+	require.Equal(t, preview, stored)
+
+	previews, err := previewer.List(context.Background(), "user")
+	require.NoError(t, err)
+	require.Len(t, previews, 2)
+
+	_, err = previewer.Get(context.Background(), "other", preview.PreviewID)
+	require.ErrorIs(t, err, ErrPreviewNotFound)
+}
+
+func TestPreviewer_MaxChunks(t *testing.T) {
+	from := model.TimeFromUnix(1000)
+	var chunks []chunk.Chunk
+	for i := 0; i < 5; i++ {
+		chunks = append(chunks, createPreviewChunk(t, "user", labels.FromStrings("app", fmt.Sprint(i)), from, "line"))
+	}
+	previewer := newTestPreviewer(t, PreviewConfig{PathPrefix: "delete_previews/", MaxChunks: 3}, chunks...)
+
+	preview, err := previewer.Preview(context.Background(), "user", "alice", `{app=~".+"}`, from, from.Add(time.Hour), 0)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), preview.Chunks)
+	require.True(t, preview.Truncated)
+}
+
+func TestPreviewer_Approve(t *testing.T) {
+	from := model.TimeFromUnix(1000)
+	previewer := newTestPreviewer(t, PreviewConfig{PathPrefix: "delete_previews/"},
+		createPreviewChunk(t, "user", labels.FromStrings("app", "foo"), from, "error 1"),
+	)
+	preview, err := previewer.Preview(context.Background(), "user", "alice", `{app="foo"}`, from, from.Add(time.Hour), time.Hour)
+	require.NoError(t, err)
+
+	store := &mockDeleteRequestsStore{}
+	for _, tc := range []struct {
+		approvedBy string
+		err        error
+	}{
+		{"", errApproverNotIdentified},
+		{"alice", errApproverSameAsPreviewer},
+	} {
+		_, err := previewer.Approve(context.Background(), store, "user", preview.PreviewID, tc.approvedBy)
+		require.ErrorIs(t, err, tc.err)
+		require.Empty(t, store.addReq.userID)
+	}
+
+	approved, err := previewer.Approve(context.Background(), store, "user", preview.PreviewID, "bob")
+	require.NoError(t, err)
+	require.Equal(t, PreviewStatusApproved, approved.Status)
+	require.Equal(t, "bob", approved.ApprovedBy)
+	require.Equal(t, storeAddReqDetails{
+		userID:          "user",
+		query:           `{app="foo"}`,
+		startTime:       from,
+		endTime:         from.Add(time.Hour),
+		shardByInterval: time.Hour,
+	}, store.addReq)
+
+	_, err = previewer.Approve(context.Background(), store, "user", preview.PreviewID, "carol")
+	require.ErrorIs(t, err, errPreviewNotPending)
+
+	// previews created without the user header can't be approved.
+	preview, err = previewer.Preview(context.Background(), "user", "", `{app="foo"}`, from, from.Add(time.Hour), 0)
+	require.NoError(t, err)
+	_, err = previewer.Approve(context.Background(), store, "user", preview.PreviewID, "bob")
+	require.ErrorIs(t, err, errPreviewNotIdentified)
+}
+
+// countingDeleteRequestsStore counts the delete requests added to it.
+type countingDeleteRequestsStore struct {
+	DeleteRequestsStore
+	added atomic.Int32
+}
+
+func (s *countingDeleteRequestsStore) AddDeleteRequest(_ context.Context, _, _ string, _, _ model.Time, _ time.Duration) (string, error) {
+	// leave time for concurrent approvals to read the pending preview.
+	time.Sleep(10 * time.Millisecond)
+	return fmt.Sprint(s.added.Add(1)), nil
+}
+
+func TestPreviewer_ApproveConcurrently(t *testing.T) {
+	from := model.TimeFromUnix(1000)
+	previewer := newTestPreviewer(t, PreviewConfig{PathPrefix: "delete_previews/"},
+		createPreviewChunk(t, "user", labels.FromStrings("app", "foo"), from, "error 1"),
+	)
+	preview, err := previewer.Preview(context.Background(), "user", "alice", `{app="foo"}`, from, from.Add(time.Hour), 0)
+	require.NoError(t, err)
+
+	store := &countingDeleteRequestsStore{}
+	var (
+		wg       sync.WaitGroup
+		approved atomic.Int32
+	)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := previewer.Approve(context.Background(), store, "user", preview.PreviewID, fmt.Sprintf("approver-%d", i))
+			if err == nil {
+				approved.Add(1)
+				return
+			}
+			require.ErrorIs(t, err, errPreviewNotPending)
+		}(i)
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), approved.Load())
+	require.Equal(t, int32(1), store.added.Load())
+}
+
+func TestPreviewer_TTL(t *testing.T) {
+	from := model.TimeFromUnix(1000)
+	previewer := newTestPreviewer(t, PreviewConfig{PathPrefix: "delete_previews/", TTL: time.Hour},
+		createPreviewChunk(t, "user", labels.FromStrings("app", "foo"), from, "error 1"),
+	)
+	preview, err := previewer.Preview(context.Background(), "user", "alice", `{app="foo"}`, from, from.Add(time.Hour), 0)
+	require.NoError(t, err)
+
+	// make the preview older than the TTL.
+	preview.CreatedAt = preview.CreatedAt.Add(-2 * time.Hour)
+	require.NoError(t, previewer.write(context.Background(), "user", preview))
+
+	store := &mockDeleteRequestsStore{}
+	_, err = previewer.Approve(context.Background(), store, "user", preview.PreviewID, "bob")
+	require.ErrorIs(t, err, errPreviewExpired)
+	require.Empty(t, store.addReq.userID)
+
+	// expired previews are deleted when the previews of the tenant are listed.
+	previews, err := previewer.List(context.Background(), "user")
+	require.NoError(t, err)
+	require.Empty(t, previews)
+	_, err = previewer.Get(context.Background(), "user", preview.PreviewID)
+	require.ErrorIs(t, err, ErrPreviewNotFound)
+}
+
+func TestDeleteRequestHandler_Previews(t *testing.T) {
+	from := model.TimeFromUnix(1600000000)
+	limits := &fakeLimits{tenantLimits: map[string]limit{"org-id": {deletionRequiresApproval: true}}}
+
+	t.Run("dry run requires the previews to be enabled", func(t *testing.T) {
+		h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, limits, 0, 0, nil)
+		req := buildRequest("org-id", `{app="foo"}`, unixString(from), unixString(from.Add(time.Hour)), false)
+		params := req.URL.Query()
+		params.Set("dry_run", "true")
+		req.URL.RawQuery = params.Encode()
+
+		w := httptest.NewRecorder()
+		h.AddDeleteRequestHandler(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("delete requests of tenants requiring approval are previewed and approved", func(t *testing.T) {
+		store := &mockDeleteRequestsStore{}
+		h := NewDeleteRequestHandler(store, limits, 0, 0, nil)
+		h.RegisterPreviewer(newTestPreviewer(t, PreviewConfig{PathPrefix: "delete_previews/", UserHeader: "X-Grafana-User", SampleSize: 10},
+			createPreviewChunk(t, "org-id", labels.FromStrings("app", "foo"), from, "line 1", "line 2"),
+		))
+
+		req := buildRequest("org-id", `{app="foo"}`, unixString(from), unixString(from.Add(time.Hour)), false)
+		w := httptest.NewRecorder()
+		h.AddDeleteRequestHandler(w, req)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+		require.Empty(t, store.addReq.userID)
+
+		params := req.URL.Query()
+		params.Set("dry_run", "true")
+		req.URL.RawQuery = params.Encode()
+		req.Header.Set("X-Grafana-User", "alice")
+		w = httptest.NewRecorder()
+		h.AddDeleteRequestHandler(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+		require.Empty(t, store.addReq.userID)
+
+		var preview DeletePreview
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&preview))
+		require.Equal(t, int64(2), preview.Lines)
+
+		approve := func(approvedBy string) *httptest.ResponseRecorder {
+			req := buildRequest("org-id", "", "", "", false)
+			req.URL.RawQuery = "preview_id=" + preview.PreviewID
+			req.Header.Set("X-Grafana-User", approvedBy)
+			w := httptest.NewRecorder()
+			h.ApproveDeletePreviewHandler(w, req)
+			return w
+		}
+		require.Equal(t, http.StatusForbidden, approve("alice").Code)
+		require.Equal(t, http.StatusOK, approve("bob").Code)
+		require.Equal(t, `{app="foo"}`, store.addReq.query)
+		require.Equal(t, http.StatusBadRequest, approve("carol").Code)
+
+		req = buildRequest("org-id", "", "", "", false)
+		req.URL.RawQuery = ""
+		w = httptest.NewRecorder()
+		h.GetDeletePreviewsHandler(w, req)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var previews []DeletePreview
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&previews))
+		require.Len(t, previews, 1)
+		require.Equal(t, PreviewStatusApproved, previews[0].Status)
+		require.Equal(t, "bob", previews[0].ApprovedBy)
+	})
+}
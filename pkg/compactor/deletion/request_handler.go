@@ -27,6 +27,8 @@ const ForQuerytimeFilteringQueryParam = "for_querytime_filtering"
 // DeleteRequestHandler provides handlers for delete requests
 type DeleteRequestHandler struct {
 	deleteRequestsStore DeleteRequestsStore
+	previewer           *Previewer
+	limits              Limits
 	metrics             *deleteRequestHandlerMetrics
 	maxInterval         time.Duration
 
@@ -34,9 +36,10 @@ type DeleteRequestHandler struct {
 }
 
 // NewDeleteRequestHandler creates a DeleteRequestHandler
-func NewDeleteRequestHandler(deleteStore DeleteRequestsStore, maxInterval, deleteRequestCancelPeriod time.Duration, registerer prometheus.Registerer) *DeleteRequestHandler {
+func NewDeleteRequestHandler(deleteStore DeleteRequestsStore, limits Limits, maxInterval, deleteRequestCancelPeriod time.Duration, registerer prometheus.Registerer) *DeleteRequestHandler {
 	deleteMgr := DeleteRequestHandler{
 		deleteRequestsStore:       deleteStore,
+		limits:                    limits,
 		maxInterval:               maxInterval,
 		deleteRequestCancelPeriod: deleteRequestCancelPeriod,
 		metrics:                   newDeleteRequestHandlerMetrics(registerer),
@@ -45,6 +48,11 @@ func NewDeleteRequestHandler(deleteStore DeleteRequestsStore, maxInterval, delet
 	return &deleteMgr
 }
 
+// RegisterPreviewer enables the previews of delete requests and their approval.
+func (dm *DeleteRequestHandler) RegisterPreviewer(previewer *Previewer) {
+	dm.previewer = previewer
+}
+
 // AddDeleteRequestHandler handles addition of a new delete request
 func (dm *DeleteRequestHandler) AddDeleteRequestHandler(w http.ResponseWriter, r *http.Request) {
 	if dm == nil {
@@ -88,6 +96,16 @@ func (dm *DeleteRequestHandler) AddDeleteRequestHandler(w http.ResponseWriter, r
 		}
 	}
 
+	if params.Get("dry_run") == "true" {
+		dm.previewDeleteRequest(w, r, userID, query, startTime, endTime, shardByInterval)
+		return
+	}
+
+	if dm.limits.DeletionRequiresApproval(userID) {
+		http.Error(w, "delete requests of this tenant require approval: preview the request with dry_run=true and have another user approve the preview", http.StatusBadRequest)
+		return
+	}
+
 	requestID, err := dm.deleteRequestsStore.AddDeleteRequest(ctx, userID, query, startTime, endTime, shardByInterval)
 	if err != nil {
 		level.Error(util_log.Logger).Log("msg", "error adding delete request to the store", "err", err)
@@ -107,6 +125,127 @@ func (dm *DeleteRequestHandler) AddDeleteRequestHandler(w http.ResponseWriter, r
 	w.WriteHeader(http.StatusNoContent)
 }
 
+func (dm *DeleteRequestHandler) previewDeleteRequest(w http.ResponseWriter, r *http.Request, userID, query string, startTime, endTime model.Time, shardByInterval time.Duration) {
+	if dm.previewer == nil {
+		http.Error(w, "Delete request previews are not enabled", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := dm.previewer.Preview(r.Context(), userID, r.Header.Get(dm.previewer.cfg.UserHeader), query, startTime, endTime, shardByInterval)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error previewing delete request", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(util_log.Logger).Log(
+		"msg", "delete request for user previewed",
+		"preview_id", preview.PreviewID,
+		"user", userID,
+		"query", query,
+		"streams", preview.Streams,
+		"chunks", preview.Chunks,
+		"lines", preview.Lines,
+	)
+
+	dm.metrics.deleteRequestPreviewsTotal.WithLabelValues(userID).Inc()
+	writeJSON(w, preview)
+}
+
+// GetDeletePreviewsHandler handles get of the delete request previews, or of a single one with the preview_id parameter.
+func (dm *DeleteRequestHandler) GetDeletePreviewsHandler(w http.ResponseWriter, r *http.Request) {
+	if dm == nil || dm.previewer == nil {
+		http.Error(w, "Delete request previews are not enabled", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if previewID := r.URL.Query().Get("preview_id"); previewID != "" {
+		preview, err := dm.previewer.Get(ctx, userID, previewID)
+		if err != nil {
+			if errors.Is(err, ErrPreviewNotFound) {
+				http.Error(w, err.Error(), http.StatusNotFound)
+				return
+			}
+			level.Error(util_log.Logger).Log("msg", "error getting delete request preview", "err", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, preview)
+		return
+	}
+
+	previews, err := dm.previewer.List(ctx, userID)
+	if err != nil {
+		level.Error(util_log.Logger).Log("msg", "error listing delete request previews", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, previews)
+}
+
+// ApproveDeletePreviewHandler handles the approval of a delete request preview by a second user, which adds the delete request.
+func (dm *DeleteRequestHandler) ApproveDeletePreviewHandler(w http.ResponseWriter, r *http.Request) {
+	if dm == nil || dm.previewer == nil {
+		http.Error(w, "Delete request previews are not enabled", http.StatusBadRequest)
+		return
+	}
+	ctx := r.Context()
+	userID, err := tenant.TenantID(ctx)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	previewID := r.URL.Query().Get("preview_id")
+	if previewID == "" {
+		http.Error(w, "preview_id not set", http.StatusBadRequest)
+		return
+	}
+
+	preview, err := dm.previewer.Approve(ctx, dm.deleteRequestsStore, userID, previewID, r.Header.Get(dm.previewer.cfg.UserHeader))
+	switch {
+	case errors.Is(err, ErrPreviewNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	case errors.Is(err, errApproverSameAsPreviewer):
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	case errors.Is(err, errPreviewNotPending), errors.Is(err, errPreviewExpired), errors.Is(err, errApproverNotIdentified), errors.Is(err, errPreviewNotIdentified):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case err != nil:
+		level.Error(util_log.Logger).Log("msg", "error approving delete request preview", "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	level.Info(util_log.Logger).Log(
+		"msg", "delete request for user approved",
+		"preview_id", preview.PreviewID,
+		"delete_request_id", preview.RequestID,
+		"user", userID,
+		"previewed_by", preview.CreatedBy,
+		"approved_by", preview.ApprovedBy,
+	)
+
+	dm.metrics.deleteRequestsReceivedTotal.WithLabelValues(userID).Inc()
+	writeJSON(w, preview)
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		level.Error(util_log.Logger).Log("msg", "error marshalling response", "err", err)
+		http.Error(w, fmt.Sprintf("Error marshalling response: %v", err), http.StatusInternalServerError)
+	}
+}
+
 func (dm *DeleteRequestHandler) interval(params url.Values, startTime, endTime model.Time) (time.Duration, error) {
 	qr := params.Get("max_interval")
 	if qr == "" {
@@ -21,7 +21,7 @@ import (
 func TestAddDeleteRequestHandler(t *testing.T) {
 	t.Run("it adds the delete request to the store", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", `{foo="bar"}`, "0000000000", "0000000001", false)
 
@@ -38,7 +38,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 
 	t.Run("it only shards deletes with line filter based on a query param", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		now := model.Now()
 		from := model.TimeFromUnix(now.Add(-3 * time.Hour).Unix())
@@ -59,7 +59,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 
 	t.Run("it uses the default for sharding when the query param isn't present", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
-		h := NewDeleteRequestHandler(store, time.Hour, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, time.Hour, 0, nil)
 
 		now := model.Now()
 		from := model.TimeFromUnix(now.Add(-3 * time.Hour).Unix())
@@ -76,7 +76,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 
 	t.Run("it does not shard deletes without line filter", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		from := model.TimeFromUnix(model.Now().Add(-3 * time.Hour).Unix())
 		to := model.TimeFromUnix(from.Add(3 * time.Hour).Unix())
@@ -95,7 +95,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 
 	t.Run("it works with RFC3339", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", `{foo="bar"}`, "2006-01-02T15:04:05Z", "2006-01-03T15:04:05Z", false)
 
@@ -112,7 +112,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 
 	t.Run("it fills in end time if blank", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", `{foo="bar"}`, "0000000000", "", false)
 
@@ -129,7 +129,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 
 	t.Run("it returns 500 when the delete store errors", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{addErr: errors.New("something bad")}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", `{foo="bar"}`, "0000000000", "0000000001", false)
 
@@ -139,7 +139,7 @@ func TestAddDeleteRequestHandler(t *testing.T) {
 	})
 
 	t.Run("Validation", func(t *testing.T) {
-		h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, time.Minute, 0, nil)
+		h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, &fakeLimits{}, time.Minute, 0, nil)
 
 		for _, tc := range []struct {
 			orgID, query, startTime, endTime, interval, error string
@@ -183,7 +183,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
 		store.getResult = stored
 
-		h := NewDeleteRequestHandler(store, 0, time.Hour, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, time.Hour, nil)
 
 		req := buildRequest("org-id", ``, "", "", false)
 		params := req.URL.Query()
@@ -213,7 +213,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
 		store.getResult = stored
 
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", ``, "", "", false)
 		params := req.URL.Query()
@@ -231,7 +231,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 	t.Run("error getting from store", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
 		store.getErr = errors.New("something bad")
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("orgid", ``, "", "", false)
 		params := req.URL.Query()
@@ -251,7 +251,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 		store.getResult = stored
 		store.removeErr = errors.New("something bad")
 
-		h := NewDeleteRequestHandler(store, 0, time.Hour, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, time.Hour, nil)
 
 		req := buildRequest("org-id", ``, "", "", false)
 		params := req.URL.Query()
@@ -267,7 +267,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 
 	t.Run("Validation", func(t *testing.T) {
 		t.Run("no org id", func(t *testing.T) {
-			h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, 0, 0, nil)
+			h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, &fakeLimits{}, 0, 0, nil)
 
 			req := buildRequest("", ``, "", "", false)
 			params := req.URL.Query()
@@ -282,7 +282,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 		})
 
 		t.Run("request not found", func(t *testing.T) {
-			h := NewDeleteRequestHandler(&mockDeleteRequestsStore{getErr: ErrDeleteRequestNotFound}, 0, 0, nil)
+			h := NewDeleteRequestHandler(&mockDeleteRequestsStore{getErr: ErrDeleteRequestNotFound}, &fakeLimits{}, 0, 0, nil)
 
 			req := buildRequest("org-id", ``, "", "", false)
 			params := req.URL.Query()
@@ -301,7 +301,7 @@ func TestCancelDeleteRequestHandler(t *testing.T) {
 			store := &mockDeleteRequestsStore{}
 			store.getResult = stored
 
-			h := NewDeleteRequestHandler(store, 0, 0, nil)
+			h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 			req := buildRequest("org-id", ``, "", "", false)
 			params := req.URL.Query()
@@ -321,7 +321,7 @@ func TestGetAllDeleteRequestsHandler(t *testing.T) {
 	t.Run("it gets all the delete requests for the user", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
 		store.getAllResult = []DeleteRequest{{RequestID: "test-request-1", Status: StatusReceived}, {RequestID: "test-request-2", Status: StatusReceived}}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		for _, forQuerytimeFiltering := range []bool{false, true} {
 			req := buildRequest("org-id", ``, "", "", forQuerytimeFiltering)
@@ -347,7 +347,7 @@ func TestGetAllDeleteRequestsHandler(t *testing.T) {
 			{RequestID: "test-request-2", CreatedAt: now.Add(time.Minute), StartTime: now.Add(30 * time.Minute), EndTime: now.Add(90 * time.Minute)},
 			{RequestID: "test-request-1", CreatedAt: now, StartTime: now.Add(time.Hour), EndTime: now.Add(2 * time.Hour)},
 		}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", ``, "", "", false)
 
@@ -377,7 +377,7 @@ func TestGetAllDeleteRequestsHandler(t *testing.T) {
 			{RequestID: "test-request-2", CreatedAt: now.Add(time.Minute), Status: StatusProcessed},
 			{RequestID: "test-request-3", CreatedAt: now.Add(2 * time.Minute), Status: StatusReceived},
 		}
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("org-id", ``, "", "", false)
 
@@ -400,7 +400,7 @@ func TestGetAllDeleteRequestsHandler(t *testing.T) {
 	t.Run("error getting from store", func(t *testing.T) {
 		store := &mockDeleteRequestsStore{}
 		store.getAllErr = errors.New("something bad")
-		h := NewDeleteRequestHandler(store, 0, 0, nil)
+		h := NewDeleteRequestHandler(store, &fakeLimits{}, 0, 0, nil)
 
 		req := buildRequest("orgid", ``, "", "", false)
 		params := req.URL.Query()
@@ -416,7 +416,7 @@ func TestGetAllDeleteRequestsHandler(t *testing.T) {
 
 	t.Run("validation", func(t *testing.T) {
 		t.Run("no org id", func(t *testing.T) {
-			h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, 0, 0, nil)
+			h := NewDeleteRequestHandler(&mockDeleteRequestsStore{}, &fakeLimits{}, 0, 0, nil)
 
 			req := buildRequest("", ``, "", "", false)
 
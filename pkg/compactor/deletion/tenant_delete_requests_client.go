@@ -11,6 +11,7 @@ const deletionNotAvailableMsg = "deletion is not available for this tenant"
 
 type Limits interface {
 	DeletionMode(userID string) string
+	DeletionRequiresApproval(userID string) bool
 	RetentionPeriod(userID string) time.Duration
 	StreamRetention(userID string) []validation.StreamRetention
 }
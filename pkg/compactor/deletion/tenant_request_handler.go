@@ -11,21 +11,24 @@ func TenantMiddleware(limits Limits) middleware.Interface {
 	return middleware.Func(func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
-			userID, err := tenant.TenantID(ctx)
+			// requests of several tenants, like tenant moves, need deletion enabled for all of them.
+			userIDs, err := tenant.TenantIDs(ctx)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
 				return
 			}
 
-			hasDelete, err := validDeletionLimit(limits, userID)
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusBadRequest)
-				return
-			}
+			for _, userID := range userIDs {
+				hasDelete, err := validDeletionLimit(limits, userID)
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
 
-			if !hasDelete {
-				http.Error(w, deletionNotAvailableMsg, http.StatusForbidden)
-				return
+				if !hasDelete {
+					http.Error(w, deletionNotAvailableMsg, http.StatusForbidden)
+					return
+				}
 			}
 
 			next.ServeHTTP(w, r)
@@ -42,6 +42,15 @@ func TestDeleteRequestHandlerDeletionMiddleware(t *testing.T) {
 
 	require.Equal(t, http.StatusForbidden, res.Result().StatusCode)
 
+	// Requests of several tenants need deletion enabled for all of them
+	req = httptest.NewRequest(http.MethodGet, "http://www.your-domain.com", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "1|2"))
+
+	res = httptest.NewRecorder()
+	middle.ServeHTTP(res, req)
+
+	require.Equal(t, http.StatusForbidden, res.Result().StatusCode)
+
 	// User header is not given
 	req = httptest.NewRequest(http.MethodGet, "http://www.your-domain.com", nil)
 
@@ -52,9 +61,10 @@ func TestDeleteRequestHandlerDeletionMiddleware(t *testing.T) {
 }
 
 type limit struct {
-	deletionMode    string
-	retentionPeriod time.Duration
-	streamRetention []validation.StreamRetention
+	deletionMode             string
+	deletionRequiresApproval bool
+	retentionPeriod          time.Duration
+	streamRetention          []validation.StreamRetention
 }
 
 type fakeLimits struct {
@@ -75,6 +85,10 @@ func (f *fakeLimits) DeletionMode(userID string) string {
 	return f.getLimitForUser(userID).deletionMode
 }
 
+func (f *fakeLimits) DeletionRequiresApproval(userID string) bool {
+	return f.getLimitForUser(userID).deletionRequiresApproval
+}
+
 func (f *fakeLimits) RetentionPeriod(userID string) time.Duration {
 	return f.getLimitForUser(userID).retentionPeriod
 }
@@ -0,0 +1,364 @@
+// Package export exports the logs of tenants to Parquet files in object storage,
+// partitioned by tenant and day.
+package export
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/user"
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+)
+
+// Config configures the export of tenant data to Parquet.
+type Config struct {
+	Enabled        bool          `yaml:"enabled"`
+	ObjectStore    string        `yaml:"object_store"`
+	PathPrefix     string        `yaml:"path_prefix"`
+	Interval       time.Duration `yaml:"interval"`
+	MaxRowsPerFile int           `yaml:"max_rows_per_file"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the export config.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable the export of tenant data to Parquet files requested through the export API.")
+	f.StringVar(&cfg.ObjectStore, prefix+"object-store", "", "Store the Parquet files and the export manifests are written to. Either a storage type or a named store.")
+	f.StringVar(&cfg.PathPrefix, prefix+"path-prefix", "export/", "Path prefix of the Parquet files and the export manifests.")
+	f.DurationVar(&cfg.Interval, prefix+"interval", 10*time.Minute, "Interval at which the pending exports are processed.")
+	f.IntVar(&cfg.MaxRowsPerFile, prefix+"max-rows-per-file", 1_000_000, "Maximum number of log lines per Parquet file.")
+}
+
+// Validate validates the export config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.ObjectStore == "" {
+		return errors.New("export object_store must be set when the export is enabled")
+	}
+	if cfg.PathPrefix == "" || !strings.HasSuffix(cfg.PathPrefix, "/") {
+		return errors.New("export path_prefix must end with a path separator i.e '/'")
+	}
+	if cfg.MaxRowsPerFile <= 0 {
+		return errors.New("export max_rows_per_file must be positive")
+	}
+	return nil
+}
+
+// Row is a log line of the Parquet files.
+type Row struct {
+	Timestamp          int64             `parquet:"timestamp,timestamp(nanosecond),delta"`
+	Labels             map[string]string `parquet:"labels"`
+	StructuredMetadata map[string]string `parquet:"structured_metadata"`
+	Line               string            `parquet:"line,zstd"`
+}
+
+// Store reads the logs of a tenant.
+type Store interface {
+	SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error)
+}
+
+type metrics struct {
+	exportedDays *prometheus.CounterVec
+	exportedRows prometheus.Counter
+}
+
+func newMetrics(r prometheus.Registerer) *metrics {
+	return &metrics{
+		exportedDays: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "export_days_total",
+			Help:      "Total number of tenant days exported to Parquet by status.",
+		}, []string{"status"}),
+		exportedRows: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "export_rows_total",
+			Help:      "Total number of log lines exported to Parquet.",
+		}),
+	}
+}
+
+// Exporter writes the logs of the requested tenant days to Parquet files and tracks
+// the progress in a manifest per tenant, next to the files:
+//
+//	<path_prefix>/<tenant>/manifest.json
+//	<path_prefix>/<tenant>/day=<YYYY-MM-DD>/part-<n>.parquet
+type Exporter struct {
+	cfg              Config
+	objectClient     client.ObjectClient
+	store            Store
+	completionDelay  time.Duration
+	workingDirectory string
+	metrics          *metrics
+	logger           log.Logger
+
+	// manifestMtx serializes the updates of the manifests.
+	manifestMtx sync.Mutex
+	now         func() time.Time
+}
+
+// NewExporter creates a new Exporter. Days can only be exported once the completion delay elapsed after their end,
+// so that the logs of the day still held by the ingesters or missing from the index of the readers aren't left out.
+func NewExporter(cfg Config, objectClient client.ObjectClient, store Store, completionDelay time.Duration, workingDirectory string, r prometheus.Registerer, logger log.Logger) (*Exporter, error) {
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+	return &Exporter{
+		cfg:              cfg,
+		objectClient:     objectClient,
+		store:            store,
+		completionDelay:  completionDelay,
+		workingDirectory: workingDirectory,
+		metrics:          newMetrics(r),
+		logger:           logger,
+		now:              time.Now,
+	}, nil
+}
+
+// Interval returns the interval at which the pending exports are processed.
+func (e *Exporter) Interval() time.Duration {
+	return e.cfg.Interval
+}
+
+// Request requests the export of the days of the tenant from the day of from to the day of through.
+// Days which were already exported are exported again.
+func (e *Exporter) Request(ctx context.Context, tenant string, from, through time.Time) (Manifest, error) {
+	e.manifestMtx.Lock()
+	defer e.manifestMtx.Unlock()
+
+	m, err := readManifest(ctx, e.objectClient, e.cfg.PathPrefix, tenant)
+	if err != nil {
+		return m, err
+	}
+	m.request(from, through, e.now().UTC())
+	return m, writeManifest(ctx, e.objectClient, e.cfg.PathPrefix, tenant, m)
+}
+
+// Manifest returns the manifest of the tenant.
+func (e *Exporter) Manifest(ctx context.Context, tenant string) (Manifest, error) {
+	return readManifest(ctx, e.objectClient, e.cfg.PathPrefix, tenant)
+}
+
+// Run exports the pending days of all the tenants.
+func (e *Exporter) Run(ctx context.Context) error {
+	_, tenants, err := e.objectClient.List(ctx, e.cfg.PathPrefix, "/")
+	if err != nil {
+		return err
+	}
+
+	for _, prefix := range tenants {
+		tenant := strings.TrimSuffix(strings.TrimPrefix(string(prefix), e.cfg.PathPrefix), "/")
+		m, err := e.Manifest(ctx, tenant)
+		if err != nil {
+			return err
+		}
+
+		for _, day := range m.pending() {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			e.exportDay(ctx, tenant, day)
+		}
+	}
+	return nil
+}
+
+func (e *Exporter) exportDay(ctx context.Context, tenant string, day Day) {
+	logger := log.With(e.logger, "tenant", tenant, "day", day.Day)
+	start := time.Now()
+
+	files, rows, err := e.writeDay(ctx, tenant, day.Day)
+	day.Files, day.Rows, day.CompletedAt = files, rows, e.now().UTC()
+	if err != nil {
+		level.Error(logger).Log("msg", "failed to export day", "err", err)
+		day.Status, day.Error = StatusFailed, err.Error()
+	} else {
+		level.Info(logger).Log("msg", "exported day", "files", len(files), "rows", rows, "duration", time.Since(start))
+		day.Status, day.Error = StatusDone, ""
+	}
+	e.metrics.exportedDays.WithLabelValues(day.Status).Inc()
+
+	if err := e.updateDay(ctx, tenant, day); err != nil {
+		level.Error(logger).Log("msg", "failed to update export manifest", "err", err)
+	}
+}
+
+// updateDay sets the day in the manifest unless it was requested again meanwhile.
+func (e *Exporter) updateDay(ctx context.Context, tenant string, day Day) error {
+	e.manifestMtx.Lock()
+	defer e.manifestMtx.Unlock()
+
+	m, err := readManifest(ctx, e.objectClient, e.cfg.PathPrefix, tenant)
+	if err != nil {
+		return err
+	}
+	for _, d := range m.Days {
+		if d.Day == day.Day && d.RequestedAt.After(day.RequestedAt) {
+			return nil
+		}
+	}
+	m.set(day)
+	return writeManifest(ctx, e.objectClient, e.cfg.PathPrefix, tenant, m)
+}
+
+func (e *Exporter) dayPrefix(tenant, day string) string {
+	return fmt.Sprintf("%s%s/day=%s/", e.cfg.PathPrefix, tenant, day)
+}
+
+// writeDay writes the logs of the day of the tenant to Parquet files, replacing the files of a previous export.
+func (e *Exporter) writeDay(ctx context.Context, tenant, day string) ([]string, int64, error) {
+	from, err := time.Parse(dayFormat, day)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	previous, _, err := e.objectClient.List(ctx, e.dayPrefix(tenant, day), "")
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, object := range previous {
+		if err := e.objectClient.DeleteObject(ctx, object.Key); err != nil && !e.objectClient.IsObjectNotFoundErr(err) {
+			return nil, 0, err
+		}
+	}
+
+	it, err := e.store.SelectLogs(user.InjectOrgID(ctx, tenant), selectAllLogs(from, from.Add(24*time.Hour)))
+	if err != nil {
+		return nil, 0, err
+	}
+	defer it.Close()
+
+	var (
+		files  []string
+		rows   int64
+		part   *partWriter
+		lbls   string
+		lblMap map[string]string
+	)
+	for it.Next() {
+		if part == nil {
+			if part, err = newPartWriter(e.workingDirectory); err != nil {
+				return files, rows, err
+			}
+		}
+
+		if it.Labels() != lbls {
+			lbls = it.Labels()
+			parsed, err := syntax.ParseLabels(lbls)
+			if err != nil {
+				part.abort()
+				return files, rows, err
+			}
+			lblMap = parsed.Map()
+		}
+
+		entry := it.At()
+		row := Row{
+			Timestamp:          entry.Timestamp.UnixNano(),
+			Labels:             lblMap,
+			StructuredMetadata: logproto.FromLabelAdaptersToLabels(entry.StructuredMetadata).Map(),
+			Line:               entry.Line,
+		}
+		if err := part.write(row); err != nil {
+			part.abort()
+			return files, rows, err
+		}
+		rows++
+		e.metrics.exportedRows.Inc()
+
+		if part.rows >= e.cfg.MaxRowsPerFile {
+			key := fmt.Sprintf("%spart-%05d.parquet", e.dayPrefix(tenant, day), len(files))
+			if err := part.upload(ctx, e.objectClient, key); err != nil {
+				return files, rows, err
+			}
+			files = append(files, key)
+			part = nil
+		}
+	}
+	if err := it.Err(); err != nil {
+		if part != nil {
+			part.abort()
+		}
+		return files, rows, err
+	}
+
+	if part != nil {
+		key := fmt.Sprintf("%spart-%05d.parquet", e.dayPrefix(tenant, day), len(files))
+		if err := part.upload(ctx, e.objectClient, key); err != nil {
+			return files, rows, err
+		}
+		files = append(files, key)
+	}
+	return files, rows, nil
+}
+
+// selectAllLogs selects all the streams of the tenant, every stream having the metric name label in storage.
+func selectAllLogs(from, through time.Time) logql.SelectLogParams {
+	expr := &syntax.MatchersExpr{Mts: []*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, labels.MetricName, "logs")}}
+	return logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  expr.String(),
+		Start:     from,
+		End:       through,
+		Direction: logproto.FORWARD,
+		Plan:      &plan.QueryPlan{AST: expr},
+	}}
+}
+
+// partWriter writes a Parquet file to disk before uploading it.
+type partWriter struct {
+	file   *os.File
+	writer *parquet.GenericWriter[Row]
+	rows   int
+}
+
+func newPartWriter(dir string) (*partWriter, error) {
+	f, err := os.CreateTemp(dir, "part-*.parquet")
+	if err != nil {
+		return nil, err
+	}
+	return &partWriter{file: f, writer: parquet.NewGenericWriter[Row](f)}, nil
+}
+
+func (p *partWriter) write(row Row) error {
+	if _, err := p.writer.Write([]Row{row}); err != nil {
+		return err
+	}
+	p.rows++
+	return nil
+}
+
+func (p *partWriter) upload(ctx context.Context, objectClient client.ObjectClient, key string) error {
+	defer p.abort()
+
+	if err := p.writer.Close(); err != nil {
+		return err
+	}
+	if _, err := p.file.Seek(0, 0); err != nil {
+		return err
+	}
+	return objectClient.PutObject(ctx, key, p.file)
+}
+
+func (p *partWriter) abort() {
+	_ = p.file.Close()
+	_ = os.Remove(p.file.Name())
+}
@@ -0,0 +1,181 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+)
+
+type mockStore struct {
+	streams map[string][]logproto.Stream
+}
+
+func (s *mockStore) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error) {
+	tenant, err := user.ExtractOrgID(ctx)
+	if err != nil {
+		return nil, err
+	}
+	it := iter.NewStreamsIterator(s.streams[tenant], req.Direction)
+	return iter.NewTimeRangedIterator(it, req.Start, req.End), nil
+}
+
+func newTestExporter(t *testing.T, objectClient client.ObjectClient, store Store) *Exporter {
+	cfg := Config{Enabled: true, ObjectStore: "inmemory", PathPrefix: "export/", Interval: time.Minute, MaxRowsPerFile: 2}
+	e, err := NewExporter(cfg, objectClient, store, 3*time.Hour, t.TempDir(), prometheus.NewRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+	return e
+}
+
+func readRows(t *testing.T, objectClient client.ObjectClient, key string) []Row {
+	rc, _, err := objectClient.GetObject(context.Background(), key)
+	require.NoError(t, err)
+	defer rc.Close()
+	b, err := io.ReadAll(rc)
+	require.NoError(t, err)
+
+	rows, err := parquet.Read[Row](bytes.NewReader(b), int64(len(b)))
+	require.NoError(t, err)
+	return rows
+}
+
+func TestExporter(t *testing.T) {
+	day := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	store := &mockStore{streams: map[string][]logproto.Stream{
+		"tenant-a": {
+			{
+				Labels: `{app="foo"}`,
+				Entries: []logproto.Entry{
+					{Timestamp: day.Add(-time.Minute), Line: "previous day"},
+					{Timestamp: day.Add(time.Minute), Line: "1", StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.FromStrings("trace_id", "abc"))},
+					{Timestamp: day.Add(2 * time.Minute), Line: "2"},
+				},
+			},
+			{
+				Labels:  `{app="bar"}`,
+				Entries: []logproto.Entry{{Timestamp: day.Add(3 * time.Minute), Line: "3"}},
+			},
+		},
+	}}
+	objectClient := testutils.NewInMemoryObjectClient()
+	e := newTestExporter(t, objectClient, store)
+	e.now = func() time.Time { return day.Add(48 * time.Hour) }
+	ctx := context.Background()
+
+	m, err := e.Request(ctx, "tenant-a", day, day.Add(24*time.Hour))
+	require.NoError(t, err)
+	require.Len(t, m.Days, 2)
+	require.Equal(t, StatusPending, m.Days[0].Status)
+
+	require.NoError(t, e.Run(ctx))
+
+	m, err = e.Manifest(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Len(t, m.Days, 2)
+	require.Equal(t, Day{
+		Day:         "2024-03-04",
+		Status:      StatusDone,
+		RequestedAt: day.Add(48 * time.Hour),
+		CompletedAt: day.Add(48 * time.Hour),
+		Files:       []string{"export/tenant-a/day=2024-03-04/part-00000.parquet", "export/tenant-a/day=2024-03-04/part-00001.parquet"},
+		Rows:        3,
+	}, m.Days[0])
+	require.Equal(t, StatusDone, m.Days[1].Status)
+	require.Empty(t, m.Days[1].Files)
+	require.Empty(t, m.pending())
+
+	rows := append(readRows(t, objectClient, m.Days[0].Files[0]), readRows(t, objectClient, m.Days[0].Files[1])...)
+	require.Len(t, rows, 3)
+	require.Equal(t, Row{
+		Timestamp:          day.Add(time.Minute).UnixNano(),
+		Labels:             map[string]string{"app": "foo"},
+		StructuredMetadata: map[string]string{"trace_id": "abc"},
+		Line:               "1",
+	}, rows[0])
+	require.Equal(t, map[string]string{"app": "bar"}, rows[2].Labels)
+	require.Equal(t, "3", rows[2].Line)
+
+	// exporting a day again replaces its files.
+	store.streams["tenant-a"] = store.streams["tenant-a"][1:]
+	_, err = e.Request(ctx, "tenant-a", day, day)
+	require.NoError(t, err)
+	require.NoError(t, e.Run(ctx))
+
+	m, err = e.Manifest(ctx, "tenant-a")
+	require.NoError(t, err)
+	require.Equal(t, []string{"export/tenant-a/day=2024-03-04/part-00000.parquet"}, m.Days[0].Files)
+	objects, _, err := objectClient.List(ctx, "export/tenant-a/day=2024-03-04/", "")
+	require.NoError(t, err)
+	require.Len(t, objects, 1)
+}
+
+func TestRequestExportHandler(t *testing.T) {
+	now := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+	e := newTestExporter(t, testutils.NewInMemoryObjectClient(), &mockStore{})
+	e.now = func() time.Time { return now }
+
+	for _, tc := range []struct {
+		name       string
+		query      string
+		expectCode int
+		expectDays []string
+	}{
+		{name: "single day", query: "start=2024-03-01", expectCode: http.StatusOK, expectDays: []string{"2024-03-01"}},
+		{name: "range", query: "start=2024-03-08&end=2024-03-09", expectCode: http.StatusOK, expectDays: []string{"2024-03-01", "2024-03-08", "2024-03-09"}},
+		{name: "invalid start", query: "start=yesterday", expectCode: http.StatusBadRequest},
+		{name: "end before start", query: "start=2024-03-05&end=2024-03-04", expectCode: http.StatusBadRequest},
+		{name: "current day", query: "start=2024-03-09&end=2024-03-10", expectCode: http.StatusBadRequest},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/export?"+tc.query, nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), "tenant-a"))
+			w := httptest.NewRecorder()
+			e.RequestExportHandler(w, req)
+			require.Equal(t, tc.expectCode, w.Code, w.Body.String())
+			if tc.expectCode != http.StatusOK {
+				return
+			}
+
+			var m Manifest
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &m))
+			var days []string
+			for _, d := range m.Days {
+				require.Equal(t, StatusPending, d.Status)
+				days = append(days, d.Day)
+			}
+			require.Equal(t, tc.expectDays, days)
+		})
+	}
+
+	// the logs of the previous day may still be held by the ingesters or missing from the index.
+	e.now = func() time.Time { return time.Date(2024, 3, 10, 2, 0, 0, 0, time.UTC) }
+	req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/export?start=2024-03-09", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "tenant-a"))
+	w := httptest.NewRecorder()
+	e.RequestExportHandler(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/loki/api/v1/export", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "tenant-b"))
+	w = httptest.NewRecorder()
+	e.GetExportHandler(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.JSONEq(t, `{"days":[]}`, w.Body.String())
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+)
+
+// RequestExportHandler requests the export of the days of the tenant between the
+// start and end query parameters, both inclusive and in YYYY-MM-DD format.
+func (e *Exporter) RequestExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	start, err := time.Parse(dayFormat, params.Get("start"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid start day, expected YYYY-MM-DD: %v", err), http.StatusBadRequest)
+		return
+	}
+	end := start
+	if params.Get("end") != "" {
+		end, err = time.Parse(dayFormat, params.Get("end"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid end day, expected YYYY-MM-DD: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if end.Before(start) {
+		http.Error(w, "end day must not be before start day", http.StatusBadRequest)
+		return
+	}
+	if completeAt := end.Add(24*time.Hour + e.completionDelay); e.now().Before(completeAt) {
+		http.Error(w, fmt.Sprintf("only days whose logs were all flushed and indexed can be exported, the end day can be exported after %s", completeAt.Format(time.RFC3339)), http.StatusBadRequest)
+		return
+	}
+
+	m, err := e.Request(r.Context(), userID, start, end)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "error requesting export", "tenant", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	level.Info(e.logger).Log("msg", "export requested", "tenant", userID, "start", start.Format(dayFormat), "end", end.Format(dayFormat))
+
+	writeManifestResponse(w, m)
+}
+
+// GetExportHandler returns the export manifest of the tenant.
+func (e *Exporter) GetExportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	m, err := e.Manifest(r.Context(), userID)
+	if err != nil {
+		level.Error(e.logger).Log("msg", "error reading export manifest", "tenant", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeManifestResponse(w, m)
+}
+
+func writeManifestResponse(w http.ResponseWriter, m Manifest) {
+	if m.Days == nil {
+		m.Days = []Day{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(m); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
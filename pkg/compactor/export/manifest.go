@@ -0,0 +1,95 @@
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+)
+
+const (
+	manifestFile = "manifest.json"
+	dayFormat    = "2006-01-02"
+
+	StatusPending = "pending"
+	StatusDone    = "done"
+	StatusFailed  = "failed"
+)
+
+// Manifest tracks the export of the days of a tenant.
+type Manifest struct {
+	Days []Day `json:"days"`
+}
+
+// Day is the export of a single day of a tenant.
+type Day struct {
+	Day         string    `json:"day"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requested_at"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	Files       []string  `json:"files,omitempty"`
+	Rows        int64     `json:"rows"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// request marks the days from the day of from to the day of through as pending.
+func (m *Manifest) request(from, through, now time.Time) {
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(through); day = day.Add(24 * time.Hour) {
+		m.set(Day{Day: day.Format(dayFormat), Status: StatusPending, RequestedAt: now})
+	}
+}
+
+func (m *Manifest) set(day Day) {
+	for i := range m.Days {
+		if m.Days[i].Day == day.Day {
+			m.Days[i] = day
+			return
+		}
+	}
+	m.Days = append(m.Days, day)
+	sort.Slice(m.Days, func(i, j int) bool { return m.Days[i].Day < m.Days[j].Day })
+}
+
+func (m *Manifest) pending() []Day {
+	var pending []Day
+	for _, d := range m.Days {
+		if d.Status == StatusPending {
+			pending = append(pending, d)
+		}
+	}
+	return pending
+}
+
+func manifestPath(prefix, tenant string) string {
+	return prefix + tenant + "/" + manifestFile
+}
+
+func readManifest(ctx context.Context, objectClient client.ObjectClient, prefix, tenant string) (Manifest, error) {
+	var m Manifest
+	rc, _, err := objectClient.GetObject(ctx, manifestPath(prefix, tenant))
+	if err != nil {
+		if objectClient.IsObjectNotFoundErr(err) {
+			return m, nil
+		}
+		return m, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return m, err
+	}
+	return m, json.Unmarshal(b, &m)
+}
+
+func writeManifest(ctx context.Context, objectClient client.ObjectClient, prefix, tenant string, m Manifest) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return objectClient.PutObject(ctx, manifestPath(prefix, tenant), bytes.NewReader(b))
+}
@@ -194,29 +194,38 @@ func (is *indexSet) upload() error {
 		return err
 	}
 
+	_, err = uploadIndexFile(is.ctx, idx, is.logger, func(ctx context.Context, fileName string, file io.ReadSeeker) error {
+		return is.baseIndexSet.PutFile(ctx, is.tableName, is.userID, fileName, file)
+	})
+	return err
+}
+
+// uploadIndexFile uploads the index file in compressed format with put and removes it from disk.
+// It returns the name of the uploaded file.
+func uploadIndexFile(ctx context.Context, idx index.Index, logger log.Logger, put func(ctx context.Context, fileName string, file io.ReadSeeker) error) (string, error) {
 	defer func() {
 		filePath := idx.Path()
 
 		if err := idx.Close(); err != nil {
-			level.Error(is.logger).Log("msg", "failed to close indexFile", "err", err)
+			level.Error(logger).Log("msg", "failed to close indexFile", "err", err)
 			return
 		}
 
 		if err := os.Remove(filePath); err != nil {
-			level.Error(is.logger).Log("msg", "failed to remove indexFile", "err", err)
+			level.Error(logger).Log("msg", "failed to remove indexFile", "err", err)
 			return
 		}
 	}()
 
 	fileName := idx.Name()
-	level.Debug(is.logger).Log("msg", fmt.Sprintf("uploading index %s", fileName))
+	level.Debug(logger).Log("msg", fmt.Sprintf("uploading index %s", fileName))
 
 	idxPath := idx.Path()
 
 	filePath := fmt.Sprintf("%s%s", idxPath, ".temp")
 	f, err := os.Create(filePath)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	defer func() {
@@ -235,34 +244,35 @@ func (is *indexSet) upload() error {
 
 	idxReader, err := idx.Reader()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	_, err = idxReader.Seek(0, 0)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	_, err = io.Copy(compressedWriter, idxReader)
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	err = compressedWriter.Close()
 	if err != nil {
-		return err
+		return "", err
 	}
 
 	// flush the file to disk and seek the file to the beginning.
 	if err := f.Sync(); err != nil {
-		return err
+		return "", err
 	}
 
 	if _, err := f.Seek(0, 0); err != nil {
-		return err
+		return "", err
 	}
 
-	return is.baseIndexSet.PutFile(is.ctx, is.tableName, is.userID, fmt.Sprintf("%s.gz", fileName), f)
+	uploadedName := fmt.Sprintf("%s.gz", fileName)
+	return uploadedName, put(ctx, uploadedName, f)
 }
 
 // removeFilesFromStorage deletes source objects from storage.
@@ -0,0 +1,359 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	lokilog "github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/util"
+)
+
+const (
+	// the merged chunks are cut like the ingesters do by default.
+	mergedChunkBlockSize  = 256 << 10
+	mergedChunkTargetSize = 1536 << 10
+)
+
+type replicaMergeMetrics struct {
+	mergedChunks  prometheus.Counter
+	writtenChunks prometheus.Counter
+	savedBytes    prometheus.Counter
+}
+
+func newReplicaMergeMetrics(r prometheus.Registerer) *replicaMergeMetrics {
+	return &replicaMergeMetrics{
+		mergedChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "replica_chunks_merged_total",
+			Help:      "Total number of overlapping chunks replaced by merged chunks and marked for deletion.",
+		}),
+		writtenChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "replica_chunks_written_total",
+			Help:      "Total number of merged chunks written in place of overlapping chunks.",
+		}),
+		savedBytes: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "replica_chunks_saved_bytes_total",
+			Help:      "Total number of bytes of object storage saved by merging overlapping chunks.",
+		}),
+	}
+}
+
+// replicaMerger merges the overlapping chunks of the series of a table, typically flushed by the replicas of a
+// stream which cut their chunks at different times, into chunks without the duplicated entries.
+//
+// The overlapping chunks are read in order of their start, with only the chunks overlapping the next entry open
+// at a time, and the entries found in several of them are written once into chunks cut at the target size of the
+// ingesters. The merged chunks are uploaded and indexed before the overlapping ones are removed from the index and
+// marked for deletion, so the entries can always be read from either of them.
+//
+// Only the chunks within the interval of the table are merged, as the others are indexed by several tables.
+type replicaMerger struct {
+	schemaConfig config.SchemaConfig
+	period       config.PeriodConfig
+	chunkClient  client.Client
+	// retentionWorkDir is the working directory of the retention of the period, holding the markers
+	// of the chunks deleted by the sweeper.
+	retentionWorkDir string
+	metrics          *replicaMergeMetrics
+}
+
+func newReplicaMerger(retentionWorkDir string, schemaConfig config.SchemaConfig, period config.PeriodConfig, chunkClient client.Client, r prometheus.Registerer) *replicaMerger {
+	return &replicaMerger{
+		schemaConfig:     schemaConfig,
+		period:           period,
+		chunkClient:      chunkClient,
+		retentionWorkDir: retentionWorkDir,
+		metrics:          newReplicaMergeMetrics(r),
+	}
+}
+
+// replicaChunk is a chunk of a series which may overlap others.
+type replicaChunk struct {
+	userID, chunkID string
+	from, through   model.Time
+}
+
+// merge merges the overlapping chunks of the index and returns whether the index is modified.
+func (m *replicaMerger) merge(ctx context.Context, tableName string, compactedIndex CompactedIndex, logger log.Logger) (bool, error) {
+	tableInterval := retention.ExtractIntervalFromTableName(tableName)
+
+	series := map[string][]replicaChunk{}
+	err := compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		if ce.From < tableInterval.Start || ce.Through > tableInterval.End {
+			return false, nil
+		}
+		key := string(ce.UserID) + "/" + string(ce.SeriesID)
+		series[key] = append(series[key], replicaChunk{
+			userID:  string(ce.UserID),
+			chunkID: string(ce.ChunkID),
+			from:    ce.From,
+			through: ce.Through,
+		})
+		return false, nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	merged := map[string]struct{}{}
+	var written, savedBytes int
+	for _, chunks := range series {
+		for _, overlapping := range overlappingChunks(chunks) {
+			writtenIDs, saved, err := m.mergeChunks(ctx, overlapping, compactedIndex)
+			if err != nil {
+				return false, fmt.Errorf("merging %d overlapping chunks from %s: %w", len(overlapping), overlapping[0].chunkID, err)
+			}
+			for _, c := range overlapping {
+				merged[c.chunkID] = struct{}{}
+			}
+			// the overlapping chunk a merged chunk is encoded like is kept.
+			for _, id := range writtenIDs {
+				delete(merged, id)
+			}
+			written += len(writtenIDs)
+			savedBytes += saved
+		}
+	}
+	if len(merged) == 0 {
+		return false, nil
+	}
+
+	markerWriter, err := retention.NewMarkerStorageWriter(m.retentionWorkDir)
+	if err != nil {
+		return false, fmt.Errorf("failed to create marker writer: %w", err)
+	}
+	err = compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		if _, ok := merged[string(ce.ChunkID)]; !ok {
+			return false, nil
+		}
+		if err := markerWriter.Put(ce.ChunkID); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+	if closeErr := markerWriter.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close marker writer: %w", closeErr)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	m.metrics.mergedChunks.Add(float64(len(merged)))
+	m.metrics.writtenChunks.Add(float64(written))
+	m.metrics.savedBytes.Add(float64(max(savedBytes, 0)))
+	level.Info(logger).Log("msg", "merged overlapping chunks", "chunks", len(merged), "merged_chunks", written, "saved_bytes", savedBytes)
+	return true, nil
+}
+
+// overlappingChunks returns the groups of chunks overlapping each other, in order of their start.
+func overlappingChunks(chunks []replicaChunk) [][]replicaChunk {
+	sort.Slice(chunks, func(i, j int) bool {
+		if chunks[i].from != chunks[j].from {
+			return chunks[i].from < chunks[j].from
+		}
+		return chunks[i].through < chunks[j].through
+	})
+
+	var groups [][]replicaChunk
+	for start := 0; start < len(chunks); {
+		end, through := start+1, chunks[start].through
+		// the merged chunks don't overlap as they end and start in different milliseconds.
+		for end < len(chunks) && chunks[end].from < through {
+			through = max(through, chunks[end].through)
+			end++
+		}
+		if end-start > 1 {
+			groups = append(groups, chunks[start:end])
+		}
+		start = end
+	}
+	return groups
+}
+
+// mergeChunks writes the entries of the overlapping chunks into new chunks, and returns the IDs of the chunks written
+// and the number of bytes saved.
+func (m *replicaMerger) mergeChunks(ctx context.Context, overlapping []replicaChunk, compactedIndex CompactedIndex) ([]string, int, error) {
+	chunkFormat, headBlockFmt, err := m.period.ChunkFormat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var (
+		open    []iter.EntryIterator
+		next    int
+		src     chunk.Chunk
+		srcSize int
+		written []string
+		dstSize int
+		dst     *chunkenc.MemChunk
+		seen    = map[string]struct{}{}
+		lastTs  int64
+	)
+	defer func() {
+		for _, it := range open {
+			_ = it.Close()
+		}
+	}()
+
+	flush := func() error {
+		if dst == nil || dst.Size() == 0 {
+			return nil
+		}
+		if err := dst.Close(); err != nil {
+			return err
+		}
+		from, through := util.RoundToMilliseconds(dst.Bounds())
+		c := chunk.NewChunk(src.UserID, src.FingerprintModel(), src.Metric, chunkenc.NewFacade(dst, mergedChunkBlockSize, mergedChunkTargetSize), from, through)
+		if err := c.Encode(); err != nil {
+			return err
+		}
+		key := m.schemaConfig.ExternalKey(c.ChunkRef)
+		// a merged chunk encoded like one of the overlapping chunks is already stored and indexed.
+		if !slices.ContainsFunc(overlapping, func(rc replicaChunk) bool { return rc.chunkID == key }) {
+			if err := m.chunkClient.PutChunks(ctx, []chunk.Chunk{c}); err != nil {
+				return err
+			}
+			if _, err := compactedIndex.IndexChunk(c); err != nil {
+				return err
+			}
+		}
+		encoded, err := c.Encoded()
+		if err != nil {
+			return err
+		}
+		written = append(written, key)
+		dstSize += len(encoded)
+		dst = nil
+		return nil
+	}
+
+	// headTs returns the timestamp of the earliest entry of the open chunks.
+	headTs := func() int64 {
+		ts := int64(math.MaxInt64)
+		for _, it := range open {
+			ts = min(ts, it.At().Timestamp.UnixNano())
+		}
+		return ts
+	}
+
+	for {
+		// open the chunks which may hold entries before the earliest entry of the open ones.
+		for next < len(overlapping) && (len(open) == 0 || overlapping[next].from <= model.TimeFromUnixNano(headTs())) {
+			c, it, size, err := m.open(ctx, overlapping[next])
+			if err != nil {
+				return nil, 0, err
+			}
+			next++
+			srcSize += size
+			src = c
+			if it.Next() {
+				open = append(open, it)
+			} else if err := closeIterator(it); err != nil {
+				return nil, 0, err
+			}
+		}
+		if len(open) == 0 {
+			break
+		}
+
+		// pick the earliest entry, the entries of the same timestamp being ordered by line.
+		i := 0
+		for j := 1; j < len(open); j++ {
+			a, b := open[j].At(), open[i].At()
+			if a.Timestamp.Before(b.Timestamp) || (a.Timestamp.Equal(b.Timestamp) && a.Line < b.Line) {
+				i = j
+			}
+		}
+		e := open[i].At()
+		ts := e.Timestamp.UnixNano()
+		if ts != lastTs {
+			clear(seen)
+		}
+		key := e.Line + "\xff" + logproto.FromLabelAdaptersToLabels(e.StructuredMetadata).String()
+		if _, ok := seen[key]; !ok {
+			seen[key] = struct{}{}
+			// chunks are only cut between milliseconds, so that the merged chunks don't overlap.
+			if dst != nil && !dst.SpaceFor(&e) && ts/int64(time.Millisecond) != lastTs/int64(time.Millisecond) {
+				if err := flush(); err != nil {
+					return nil, 0, err
+				}
+			}
+			if dst == nil {
+				dst = chunkenc.NewMemChunk(chunkFormat, src.Data.(*chunkenc.Facade).LokiChunk().Encoding(), headBlockFmt, mergedChunkBlockSize, mergedChunkTargetSize)
+			}
+			if _, err := dst.Append(&e); err != nil {
+				return nil, 0, err
+			}
+		}
+		lastTs = ts
+
+		if !open[i].Next() {
+			err := closeIterator(open[i])
+			open = append(open[:i], open[i+1:]...)
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	if err := flush(); err != nil {
+		return nil, 0, err
+	}
+	return written, srcSize - dstSize, nil
+}
+
+// open fetches the chunk and returns an iterator over its entries along with its encoded size.
+func (m *replicaMerger) open(ctx context.Context, rc replicaChunk) (chunk.Chunk, iter.EntryIterator, int, error) {
+	chk, err := chunk.ParseExternalKey(rc.userID, rc.chunkID)
+	if err != nil {
+		return chunk.Chunk{}, nil, 0, err
+	}
+	chks, err := m.chunkClient.GetChunks(ctx, []chunk.Chunk{chk})
+	if err != nil {
+		return chunk.Chunk{}, nil, 0, err
+	}
+	if len(chks) != 1 {
+		return chunk.Chunk{}, nil, 0, fmt.Errorf("expected 1 entry for chunk %s but found %d in storage", rc.chunkID, len(chks))
+	}
+	facade, ok := chks[0].Data.(*chunkenc.Facade)
+	if !ok {
+		return chunk.Chunk{}, nil, 0, fmt.Errorf("invalid chunk type %T", chks[0].Data)
+	}
+	encoded, err := chks[0].Encoded()
+	if err != nil {
+		return chunk.Chunk{}, nil, 0, err
+	}
+	it, err := facade.LokiChunk().Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, lokilog.NewNoopPipeline().ForStream(labels.EmptyLabels()))
+	if err != nil {
+		return chunk.Chunk{}, nil, 0, err
+	}
+	return chks[0], it, len(encoded), nil
+}
+
+func closeIterator(it iter.EntryIterator) error {
+	err := it.Err()
+	if closeErr := it.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,142 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/util"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+func TestReplicaMerger(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	start := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	lbs := labels.FromStrings("app", "foo")
+
+	// newChunk stores a chunk of the entries of the seconds [from, to) after start.
+	newChunk := func(from, to, blockSize int) string {
+		mc := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, blockSize, mergedChunkTargetSize)
+		for i := from; i < to; i++ {
+			_, err := mc.Append(&logproto.Entry{Timestamp: start.Add(time.Duration(i) * time.Second), Line: fmt.Sprintf("line %d", i)})
+			require.NoError(t, err)
+		}
+		require.NoError(t, mc.Close())
+		chkFrom, chkThrough := util.RoundToMilliseconds(mc.Bounds())
+		chk := chunk.NewChunk(moveSource, model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(mc, 0, 0), chkFrom, chkThrough)
+		require.NoError(t, chk.Encode())
+		require.NoError(t, f.location.chunkClient.PutChunks(context.Background(), []chunk.Chunk{chk}))
+		return f.schemaConfig.ExternalKey(chk.ChunkRef)
+	}
+
+	// three replicas of a stream which cut their chunks at different times, one of them missing the first entries.
+	replicas := []string{
+		newChunk(0, 300, mergedChunkBlockSize),
+		newChunk(300, 600, mergedChunkBlockSize),
+		newChunk(0, 600, 1024),
+		newChunk(100, 600, mergedChunkBlockSize),
+	}
+	// a later chunk which overlaps none of them.
+	later := newChunk(3600, 3700, mergedChunkBlockSize)
+
+	metrics := prometheus.NewRegistry()
+	merger := newReplicaMerger(t.TempDir(), f.schemaConfig, f.period, f.location.chunkClient, metrics)
+	idx := &chunkListIndex{schemaConfig: f.schemaConfig, userID: moveSource, keys: append(replicas, later)}
+
+	modified, err := merger.merge(context.Background(), f.table, idx, util_log.Logger)
+	require.NoError(t, err)
+	require.True(t, modified)
+	require.Len(t, idx.keys, 2)
+	require.Equal(t, later, idx.keys[0])
+	require.NotContains(t, replicas, idx.keys[1])
+	require.Equal(t, float64(len(replicas)), testutil.ToFloat64(merger.metrics.mergedChunks))
+	require.Equal(t, float64(1), testutil.ToFloat64(merger.metrics.writtenChunks))
+	require.Greater(t, testutil.ToFloat64(merger.metrics.savedBytes), float64(0))
+
+	// the merged chunk holds every entry once.
+	chk, err := chunk.ParseExternalKey(moveSource, idx.keys[1])
+	require.NoError(t, err)
+	chks, err := f.location.chunkClient.GetChunks(context.Background(), []chunk.Chunk{chk})
+	require.NoError(t, err)
+	require.Len(t, chks, 1)
+	require.Equal(t, lbs, chks[0].Metric)
+	it, err := chks[0].Data.(*chunkenc.Facade).LokiChunk().Iterator(context.Background(), time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, log.NewNoopPipeline().ForStream(labels.EmptyLabels()))
+	require.NoError(t, err)
+	var i int
+	for ; it.Next(); i++ {
+		require.Equal(t, start.Add(time.Duration(i)*time.Second).UnixNano(), it.At().Timestamp.UnixNano())
+		require.Equal(t, fmt.Sprintf("line %d", i), it.At().Line)
+	}
+	require.NoError(t, it.Close())
+	require.Equal(t, 600, i)
+
+	// the merged chunks overlap none of the others anymore.
+	modified, err = merger.merge(context.Background(), f.table, idx, util_log.Logger)
+	require.NoError(t, err)
+	require.False(t, modified)
+	require.Len(t, idx.keys, 2)
+}
+
+func TestOverlappingChunks(t *testing.T) {
+	chunks := []replicaChunk{
+		{chunkID: "c", from: 5, through: 8},
+		{chunkID: "a", from: 0, through: 3},
+		{chunkID: "b", from: 2, through: 5},
+		{chunkID: "d", from: 8, through: 9},
+		{chunkID: "e", from: 10, through: 20},
+		{chunkID: "f", from: 12, through: 14},
+	}
+	var ids [][]string
+	for _, group := range overlappingChunks(chunks) {
+		var g []string
+		for _, c := range group {
+			g = append(g, c.chunkID)
+		}
+		ids = append(ids, g)
+	}
+	// chunks ending in the millisecond the next one starts don't overlap.
+	require.Equal(t, [][]string{{"a", "b"}, {"e", "f"}}, ids)
+}
+
+func TestReplicaMerger_KeepsIdenticalChunk(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	start := time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)
+	lbs := labels.FromStrings("app", "foo")
+
+	var keys []string
+	for _, bounds := range [][2]int{{0, 600}, {100, 200}} {
+		mc := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, mergedChunkBlockSize, mergedChunkTargetSize)
+		for i := bounds[0]; i < bounds[1]; i++ {
+			_, err := mc.Append(&logproto.Entry{Timestamp: start.Add(time.Duration(i) * time.Second), Line: fmt.Sprintf("line %d", i)})
+			require.NoError(t, err)
+		}
+		require.NoError(t, mc.Close())
+		chkFrom, chkThrough := util.RoundToMilliseconds(mc.Bounds())
+		chk := chunk.NewChunk(moveSource, model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(mc, 0, 0), chkFrom, chkThrough)
+		require.NoError(t, chk.Encode())
+		require.NoError(t, f.location.chunkClient.PutChunks(context.Background(), []chunk.Chunk{chk}))
+		keys = append(keys, f.schemaConfig.ExternalKey(chk.ChunkRef))
+	}
+
+	// the merged chunk is encoded like the chunk holding all the entries, which is kept.
+	merger := newReplicaMerger(t.TempDir(), f.schemaConfig, f.period, f.location.chunkClient, prometheus.NewRegistry())
+	idx := &chunkListIndex{schemaConfig: f.schemaConfig, userID: moveSource, keys: keys}
+	modified, err := merger.merge(context.Background(), f.table, idx, util_log.Logger)
+	require.NoError(t, err)
+	require.True(t, modified)
+	require.Equal(t, keys[:1], idx.keys)
+	require.Equal(t, float64(1), testutil.ToFloat64(merger.metrics.mergedChunks))
+}
@@ -2,12 +2,17 @@ package retention
 
 import (
 	"fmt"
+	"strconv"
+	"sync"
 	"time"
 
+	"github.com/cespare/xxhash/v2"
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/filter"
 	util_log "github.com/grafana/loki/v3/pkg/util/log"
@@ -35,31 +40,189 @@ type ExpirationChecker interface {
 type expirationChecker struct {
 	tenantsRetention         *TenantsRetention
 	latestRetentionStartTime latestRetentionStartTime
+	processedChunks          *processedChunks
+	metrics                  *retentionRulesMetrics
 }
 
 type Limits interface {
 	RetentionPeriod(userID string) time.Duration
 	StreamRetention(userID string) []validation.StreamRetention
+	RetentionRules(userID string) []validation.RetentionRule
 	AllByUserID() map[string]*validation.Limits
 	DefaultLimits() *validation.Limits
 	PoliciesStreamMapping(userID string) validation.PolicyStreamMapping
 }
 
-func NewExpirationChecker(limits Limits) ExpirationChecker {
+func NewExpirationChecker(limits Limits, r prometheus.Registerer) ExpirationChecker {
 	return &expirationChecker{
 		tenantsRetention: NewTenantsRetention(limits),
+		processedChunks:  newProcessedChunks(),
+		metrics:          newRetentionRulesMetrics(r),
 	}
 }
 
 // Expired tells if a ref chunk is expired based on retention rules.
+// Chunks with lines older than the period of a retention rule with line filters are expired
+// with a filter.Func returning the lines to delete.
 func (e *expirationChecker) Expired(ref ChunkEntry, now model.Time) (bool, filter.Func) {
 	userID := unsafeGetString(ref.UserID)
 	period := e.tenantsRetention.RetentionPeriodFor(userID, ref.Labels)
 	// The 0 value should disable retention
-	if period <= 0 {
-		return false, nil
+	if period > 0 && now.Sub(ref.Through) > period {
+		return true, nil
+	}
+
+	filterFunc := e.retentionRulesFilter(userID, ref, now)
+	return filterFunc != nil, filterFunc
+}
+
+// retentionRulesFilter returns a filter.Func deleting the lines of the chunk expired by the retention rules
+// of the tenant, nil when no line of the chunk can be expired by the rules.
+// The rules which already filtered all the lines of the chunk in the last finished retention phase are skipped,
+// so the chunk is not downloaded again while these rules are unchanged.
+func (e *expirationChecker) retentionRulesFilter(userID string, ref ChunkEntry, now model.Time) filter.Func {
+	type ruleFilter struct {
+		name   string
+		before model.Time
+		chunk  processedChunk
+		stream log.StreamPipeline
+	}
+
+	var filters []ruleFilter
+	for _, rule := range e.tenantsRetention.limits.RetentionRules(userID) {
+		period := time.Duration(rule.Period)
+		if period <= 0 || rule.Expr == nil || now.Sub(ref.From) <= period {
+			continue
+		}
+		if !labels.Selector(rule.Expr.Matchers()).Matches(ref.Labels) {
+			continue
+		}
+
+		chunk := processedChunk{rule: retentionRuleHash(userID, rule), chunk: xxhash.Sum64(ref.ChunkID)}
+		if e.processedChunks.filtered(chunk) {
+			continue
+		}
+
+		p, err := rule.Expr.Pipeline()
+		if err != nil {
+			// The query of the rule is checked when the limits are validated.
+			// So this error should not occur.
+			level.Error(util_log.Logger).Log("msg", "unexpected error getting the pipeline of a retention rule", "user", userID, "rule", rule.Name, "err", err)
+			continue
+		}
+		filters = append(filters, ruleFilter{
+			name:   rule.Name,
+			before: now.Add(-period),
+			chunk:  chunk,
+			stream: p.ForStream(ref.Labels),
+		})
+	}
+	if len(filters) == 0 {
+		return nil
+	}
+
+	// counted tells, per rule, if the deleted lines are counted in the metrics. They are not when the chunk was
+	// already filtered by the rule in this phase, from the index of another table.
+	var counted []bool
+	return func(ts time.Time, line string, structuredMetadata ...labels.Label) bool {
+		if counted == nil {
+			counted = make([]bool, len(filters))
+			for i, f := range filters {
+				counted[i] = e.processedChunks.add(f.chunk, ref.Through.Before(f.before))
+			}
+		}
+
+		for i, f := range filters {
+			if !ts.Before(f.before.Time()) {
+				continue
+			}
+			if result, _, matches := f.stream.ProcessString(ts.UnixNano(), line, structuredMetadata...); len(result) != 0 || matches {
+				if counted[i] {
+					e.metrics.reclaimedBytesTotal.WithLabelValues(userID, f.name).Add(float64(len(line)))
+					e.metrics.deletedLinesTotal.WithLabelValues(userID, f.name).Inc()
+				}
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// retentionRuleHash identifies a retention rule of a tenant, so the chunks are filtered again when the rule changes.
+func retentionRuleHash(userID string, rule validation.RetentionRule) uint64 {
+	h := xxhash.New()
+	for _, s := range []string{userID, rule.Name, rule.Query, strconv.FormatInt(int64(rule.Period), 10)} {
+		_, _ = h.WriteString(s)
+		_, _ = h.Write([]byte{0})
+	}
+	return h.Sum64()
+}
+
+// processedChunk identifies a chunk filtered by a retention rule.
+type processedChunk struct {
+	rule, chunk uint64
+}
+
+// processedChunks records the chunks filtered by the retention rules during a retention phase.
+// The chunks whose lines were all filtered by a rule in the last finished phase have nothing left to delete for
+// this rule, the chunks which were rewritten have a new ID. The records are kept in memory, so the chunks are
+// filtered once again after a restart of the compactor.
+type processedChunks struct {
+	mtx      sync.Mutex
+	previous map[processedChunk]struct{}
+	current  map[processedChunk]bool
+}
+
+func newProcessedChunks() *processedChunks {
+	return &processedChunks{
+		previous: map[processedChunk]struct{}{},
+		current:  map[processedChunk]bool{},
 	}
-	return now.Sub(ref.Through) > period, nil
+}
+
+// filtered tells if all the lines of the chunk were filtered by the rule in the last finished phase.
+func (p *processedChunks) filtered(chunk processedChunk) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if _, ok := p.previous[chunk]; !ok {
+		return false
+	}
+	p.current[chunk] = true
+	return true
+}
+
+// add records that the chunk is filtered by the rule in this phase, all of its lines when whole is true.
+// It returns false when the chunk was already filtered by the rule in this phase.
+func (p *processedChunks) add(chunk processedChunk, whole bool) bool {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	_, ok := p.current[chunk]
+	p.current[chunk] = p.current[chunk] || whole
+	return !ok
+}
+
+// finish keeps the chunks whose lines were all filtered in this phase for the next phase.
+func (p *processedChunks) finish() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.previous = make(map[processedChunk]struct{}, len(p.current))
+	for chunk, whole := range p.current {
+		if whole {
+			p.previous[chunk] = struct{}{}
+		}
+	}
+	p.current = map[processedChunk]bool{}
+}
+
+// reset drops the chunks filtered in this phase, they are filtered again in the next phase.
+func (p *processedChunks) reset() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.current = map[processedChunk]bool{}
 }
 
 // DropFromIndex tells if it is okay to drop the chunk entry from index table.
@@ -79,11 +242,14 @@ func (e *expirationChecker) MarkPhaseStarted() {
 	e.latestRetentionStartTime = findLatestRetentionStartTime(model.Now(), e.tenantsRetention.limits)
 	level.Info(util_log.Logger).Log("msg", fmt.Sprintf("overall smallest retention period %v, default smallest retention period %v",
 		e.latestRetentionStartTime.overall, e.latestRetentionStartTime.defaults))
+	e.processedChunks.reset()
 }
 
-func (e *expirationChecker) MarkPhaseFailed()   {}
-func (e *expirationChecker) MarkPhaseTimedOut() {}
-func (e *expirationChecker) MarkPhaseFinished() {}
+func (e *expirationChecker) MarkPhaseFailed() { e.processedChunks.reset() }
+
+// MarkPhaseTimedOut drops the chunks filtered in this phase, as the rewrite of the last of them could have been interrupted.
+func (e *expirationChecker) MarkPhaseTimedOut() { e.processedChunks.reset() }
+func (e *expirationChecker) MarkPhaseFinished() { e.processedChunks.finish() }
 
 func (e *expirationChecker) IntervalMayHaveExpiredChunks(interval model.Interval, userID string) bool {
 	// when userID is empty, it means we are checking for common index table. In this case we use e.overallLatestRetentionStartTime.
@@ -195,6 +361,22 @@ Outer:
 	return r.globalRetention
 }
 
+// MaxRetentionPeriod returns the longest retention period of the tenant across its stream retention rules and
+// global retention, or 0 when some of its streams are retained forever.
+func (r *TenantRetentionSnapshot) MaxRetentionPeriod() time.Duration {
+	if r.globalRetention == 0 {
+		return 0
+	}
+	maxPeriod := r.globalRetention
+	for _, streamRetention := range r.streamRetentions {
+		if streamRetention.Period == 0 {
+			return 0
+		}
+		maxPeriod = max(maxPeriod, time.Duration(streamRetention.Period))
+	}
+	return maxPeriod
+}
+
 type latestRetentionStartTime struct {
 	// defaults holds latest retention start time considering only default retention config.
 	// It is used to determine if user index table may have any expired chunks when the user does not have any custom retention config set.
@@ -211,12 +393,7 @@ type latestRetentionStartTime struct {
 func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetentionStartTime {
 	// find the smallest retention period from default limits
 	defaultLimits := limits.DefaultLimits()
-	smallestDefaultRetentionPeriod := defaultLimits.RetentionPeriod
-	for _, streamRetention := range defaultLimits.StreamRetention {
-		if streamRetention.Period < smallestDefaultRetentionPeriod {
-			smallestDefaultRetentionPeriod = streamRetention.Period
-		}
-	}
+	smallestDefaultRetentionPeriod := smallestRetentionPeriod(defaultLimits)
 
 	overallSmallestRetentionPeriod := smallestDefaultRetentionPeriod
 
@@ -224,12 +401,7 @@ func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetention
 	limitsByUserID := limits.AllByUserID()
 	smallestRetentionPeriodByUser := make(map[string]model.Time, len(limitsByUserID))
 	for userID, limit := range limitsByUserID {
-		smallestRetentionPeriodForUser := limit.RetentionPeriod
-		for _, streamRetention := range limit.StreamRetention {
-			if streamRetention.Period < smallestRetentionPeriodForUser {
-				smallestRetentionPeriodForUser = streamRetention.Period
-			}
-		}
+		smallestRetentionPeriodForUser := smallestRetentionPeriod(limit)
 
 		// update the overallSmallestRetentionPeriod if this user has smaller value
 		smallestRetentionPeriodByUser[userID] = now.Add(time.Duration(-smallestRetentionPeriodForUser))
@@ -244,3 +416,20 @@ func findLatestRetentionStartTime(now model.Time, limits Limits) latestRetention
 		byUser:   smallestRetentionPeriodByUser,
 	}
 }
+
+// smallestRetentionPeriod returns the smallest retention period of the limits, considering the
+// stream retention and the retention rules.
+func smallestRetentionPeriod(limits *validation.Limits) model.Duration {
+	smallest := limits.RetentionPeriod
+	for _, streamRetention := range limits.StreamRetention {
+		if streamRetention.Period < smallest {
+			smallest = streamRetention.Period
+		}
+	}
+	for _, rule := range limits.RetentionRules {
+		if rule.Period > 0 && rule.Period < smallest {
+			smallest = rule.Period
+		}
+	}
+	return smallest
+}
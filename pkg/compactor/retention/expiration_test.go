@@ -5,16 +5,20 @@ import (
 	"time"
 
 	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/require"
 
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/validation"
 )
 
 type retentionLimit struct {
 	retentionPeriod     time.Duration
 	streamRetention     []validation.StreamRetention
+	retentionRules      []validation.RetentionRule
 	policyStreamMapping validation.PolicyStreamMapping
 }
 
@@ -22,6 +26,7 @@ func (r retentionLimit) convertToValidationLimit() *validation.Limits {
 	return &validation.Limits{
 		RetentionPeriod: model.Duration(r.retentionPeriod),
 		StreamRetention: r.streamRetention,
+		RetentionRules:  r.retentionRules,
 	}
 }
 
@@ -42,6 +47,10 @@ func (f fakeLimits) StreamRetention(userID string) []validation.StreamRetention
 	return f.perTenant[userID].streamRetention
 }
 
+func (f fakeLimits) RetentionRules(userID string) []validation.RetentionRule {
+	return f.perTenant[userID].retentionRules
+}
+
 func (f fakeLimits) DefaultLimits() *validation.Limits {
 	return f.defaultLimit.convertToValidationLimit()
 }
@@ -106,7 +115,7 @@ func Test_expirationChecker_Expired(t *testing.T) {
 	o, err := overridesTestConfig(d, f)
 	require.NoError(t, err)
 
-	e := NewExpirationChecker(o)
+	e := NewExpirationChecker(o, nil)
 	tests := []struct {
 		name string
 		ref  ChunkEntry
@@ -128,6 +137,97 @@ func Test_expirationChecker_Expired(t *testing.T) {
 	}
 }
 
+func Test_expirationChecker_Expired_retentionRules(t *testing.T) {
+	now := model.Now()
+	expr, err := syntax.ParseLogSelector(`{app="foo"} | level="debug"`, true)
+	require.NoError(t, err)
+
+	reg := prometheus.NewRegistry()
+	e := NewExpirationChecker(fakeLimits{perTenant: map[string]retentionLimit{
+		"1": {
+			retentionPeriod: 90 * 24 * time.Hour,
+			retentionRules:  []validation.RetentionRule{{Name: "debug", Period: model.Duration(7 * 24 * time.Hour), Expr: expr}},
+		},
+	}}, reg)
+
+	// chunks of other streams or newer than the period of the rule are not expired.
+	expired, filterFunc := e.Expired(newChunkEntry("1", `{app="bar"}`, now.Add(-10*24*time.Hour), now.Add(-9*24*time.Hour)), now)
+	require.False(t, expired)
+	require.Nil(t, filterFunc)
+	expired, _ = e.Expired(newChunkEntry("1", `{app="foo"}`, now.Add(-6*24*time.Hour), now.Add(-5*24*time.Hour)), now)
+	require.False(t, expired)
+
+	// chunks past the retention of the tenant are deleted entirely.
+	expired, filterFunc = e.Expired(newChunkEntry("1", `{app="foo"}`, now.Add(-92*24*time.Hour), now.Add(-91*24*time.Hour)), now)
+	require.True(t, expired)
+	require.Nil(t, filterFunc)
+
+	// only the debug lines older than the period of the rule are deleted from chunks spanning the period.
+	expired, filterFunc = e.Expired(newChunkEntry("1", `{app="foo"}`, now.Add(-8*24*time.Hour), now.Add(-6*24*time.Hour)), now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+
+	debug := labels.FromStrings("level", "debug")
+	old := now.Add(-7*24*time.Hour - time.Minute).Time()
+	require.True(t, filterFunc(old, "debug line", debug...))
+	require.False(t, filterFunc(old, "info line", labels.FromStrings("level", "info")...))
+	require.False(t, filterFunc(old, "line without level"))
+	require.False(t, filterFunc(now.Add(-7*24*time.Hour+time.Minute).Time(), "recent debug line", debug...))
+
+	require.Equal(t, float64(len("debug line")), testutil.ToFloat64(e.(*expirationChecker).metrics.reclaimedBytesTotal.WithLabelValues("1", "debug")))
+	require.Equal(t, float64(1), testutil.ToFloat64(e.(*expirationChecker).metrics.deletedLinesTotal.WithLabelValues("1", "debug")))
+}
+
+func Test_expirationChecker_Expired_retentionRulesProcessedChunks(t *testing.T) {
+	now := model.Now()
+	expr, err := syntax.ParseLogSelector(`{app="foo"} | level="debug"`, true)
+	require.NoError(t, err)
+
+	rules := []validation.RetentionRule{{Name: "debug", Period: model.Duration(7 * 24 * time.Hour), Query: `{app="foo"} | level="debug"`, Expr: expr}}
+	limits := fakeLimits{perTenant: map[string]retentionLimit{"1": {retentionPeriod: 90 * 24 * time.Hour, retentionRules: rules}}}
+	e := NewExpirationChecker(limits, prometheus.NewRegistry())
+	deletedLines := e.(*expirationChecker).metrics.deletedLinesTotal.WithLabelValues("1", "debug")
+
+	old := newChunkEntry("1", `{app="foo"}`, now.Add(-9*24*time.Hour), now.Add(-8*24*time.Hour))
+	old.ChunkID = []byte("old")
+	spanning := newChunkEntry("1", `{app="foo"}`, now.Add(-8*24*time.Hour), now.Add(-6*24*time.Hour))
+	spanning.ChunkID = []byte("spanning")
+	debug := labels.FromStrings("level", "debug")
+
+	e.MarkPhaseStarted()
+	for i := 0; i < 2; i++ {
+		// the chunks indexed in two tables are filtered twice, but their deleted lines are counted once.
+		for _, ref := range []ChunkEntry{old, spanning} {
+			expired, filterFunc := e.Expired(ref, now)
+			require.True(t, expired)
+			require.True(t, filterFunc(ref.From.Time(), "debug line", debug...))
+		}
+	}
+	require.Equal(t, float64(2), testutil.ToFloat64(deletedLines))
+	e.MarkPhaseFinished()
+
+	// the chunk whose lines were all filtered is not read again, unlike the chunk with lines newer than the period.
+	e.MarkPhaseStarted()
+	expired, filterFunc := e.Expired(old, now)
+	require.False(t, expired)
+	require.Nil(t, filterFunc)
+	expired, _ = e.Expired(spanning, now)
+	require.True(t, expired)
+	e.MarkPhaseFinished()
+
+	e.MarkPhaseStarted()
+	expired, _ = e.Expired(old, now)
+	require.False(t, expired)
+	e.MarkPhaseFailed()
+
+	// the chunks are filtered again when the rule changes.
+	rules[0].Period = model.Duration(8 * 24 * time.Hour)
+	e.MarkPhaseStarted()
+	expired, filterFunc = e.Expired(old, now)
+	require.True(t, expired)
+	require.NotNil(t, filterFunc)
+}
+
 func TestTenantsRetention_RetentionPeriodFor(t *testing.T) {
 	sevenDays, err := model.ParseDuration("720h")
 	require.NoError(t, err)
@@ -181,7 +281,7 @@ func Test_expirationChecker_Expired_zeroValue(t *testing.T) {
 	}
 	o, err := overridesTestConfig(d, f)
 	require.NoError(t, err)
-	e := NewExpirationChecker(o)
+	e := NewExpirationChecker(o, nil)
 	tests := []struct {
 		name string
 		ref  ChunkEntry
@@ -227,7 +327,7 @@ func Test_expirationChecker_Expired_zeroValueOverride(t *testing.T) {
 	o, err := overridesTestConfig(d, f)
 	require.NoError(t, err)
 
-	e := NewExpirationChecker(o)
+	e := NewExpirationChecker(o, nil)
 	tests := []struct {
 		name string
 		ref  ChunkEntry
@@ -261,7 +361,7 @@ func Test_expirationChecker_DropFromIndex_zeroValue(t *testing.T) {
 	}
 	o, err := overridesTestConfig(d, f)
 	require.NoError(t, err)
-	e := NewExpirationChecker(o)
+	e := NewExpirationChecker(o, nil)
 
 	chunkFrom := model.Now().Add(-3 * time.Hour)
 	chunkThrough := model.Now().Add(-2 * time.Hour)
@@ -354,6 +454,29 @@ func TestFindLatestRetentionStartTime(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "user retention rule period smallest",
+			limit: fakeLimits{
+				defaultLimit: retentionLimit{
+					retentionPeriod: 7 * dayDuration,
+				},
+				perTenant: map[string]retentionLimit{
+					"0": {
+						retentionPeriod: 90 * dayDuration,
+						retentionRules: []validation.RetentionRule{
+							{Period: model.Duration(2 * dayDuration)},
+						},
+					},
+				},
+			},
+			expectedLatestRetentionStartTime: latestRetentionStartTime{
+				overall:  now.Add(-2 * dayDuration),
+				defaults: now.Add(-7 * dayDuration),
+				byUser: map[string]model.Time{
+					"0": now.Add(-2 * dayDuration),
+				},
+			},
+		},
 		{
 			name: "user retention retention period smallest",
 			limit: fakeLimits{
@@ -545,3 +668,17 @@ func TestExpirationChecker_IntervalMayHaveExpiredChunks(t *testing.T) {
 		})
 	}
 }
+
+func TestTenantRetentionSnapshot_MaxRetentionPeriod(t *testing.T) {
+	limits := fakeLimits{perTenant: map[string]retentionLimit{
+		"global":  {retentionPeriod: 24 * time.Hour},
+		"streams": {retentionPeriod: 24 * time.Hour, streamRetention: []validation.StreamRetention{{Period: model.Duration(48 * time.Hour)}}},
+		"forever": {retentionPeriod: 24 * time.Hour, streamRetention: []validation.StreamRetention{{Period: 0}}},
+		"none":    {},
+	}}
+
+	require.Equal(t, 24*time.Hour, NewTenantRetentionSnapshot(limits, "global").MaxRetentionPeriod())
+	require.Equal(t, 48*time.Hour, NewTenantRetentionSnapshot(limits, "streams").MaxRetentionPeriod())
+	require.Equal(t, time.Duration(0), NewTenantRetentionSnapshot(limits, "forever").MaxRetentionPeriod())
+	require.Equal(t, time.Duration(0), NewTenantRetentionSnapshot(limits, "none").MaxRetentionPeriod())
+}
@@ -74,3 +74,23 @@ func newMarkerMetrics(r prometheus.Registerer) *markerMetrics {
 		}, []string{"table", "status"}),
 	}
 }
+
+type retentionRulesMetrics struct {
+	reclaimedBytesTotal *prometheus.CounterVec
+	deletedLinesTotal   *prometheus.CounterVec
+}
+
+func newRetentionRulesMetrics(r prometheus.Registerer) *retentionRulesMetrics {
+	return &retentionRulesMetrics{
+		reclaimedBytesTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "retention_rule_reclaimed_bytes_total",
+			Help:      "Total bytes of log lines deleted by the retention rules per user and rule.",
+		}, []string{"user", "rule"}),
+		deletedLinesTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "retention_rule_deleted_lines_total",
+			Help:      "Total number of log lines deleted by the retention rules per user and rule.",
+		}, []string{"user", "rule"}),
+	}
+}
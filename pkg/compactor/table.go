@@ -6,6 +6,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"time"
 
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
@@ -13,6 +14,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
 	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
@@ -76,6 +78,9 @@ type table struct {
 	tableMarker        retention.TableMarker
 	expirationChecker  tableExpirationChecker
 	periodConfig       config.PeriodConfig
+	tierMover          *storageTierMover
+	tombstonesFolder   *tombstonesFolder
+	replicaMerger      *replicaMerger
 
 	baseUserIndexSet, baseCommonIndexSet storage.IndexSet
 
@@ -174,14 +179,51 @@ func (t *table) compact(applyRetention bool) error {
 		return err
 	}
 
+	var folded map[string]*tombstones.Tombstones
+	var propagated []string
 	if applyRetention {
 		err := t.applyRetention()
 		if err != nil {
 			return err
 		}
+
+		if t.tombstonesFolder != nil {
+			folded, propagated, err = t.foldTombstones()
+			if err != nil {
+				return err
+			}
+		}
+
+		if t.replicaMerger != nil {
+			if err := t.mergeReplicaChunks(); err != nil {
+				return err
+			}
+		}
 	}
 
-	return t.done()
+	if t.tierMover != nil {
+		if err := t.moveToStorageTiers(); err != nil {
+			return err
+		}
+	}
+
+	if err := t.done(); err != nil {
+		return err
+	}
+
+	// the tombstones folded into the uploaded index are rewritten so that their modification time tells when
+	// the index without the tombstoned chunks was uploaded, and deleted by a later run once it has propagated.
+	for userID, ts := range folded {
+		if err := tombstones.Write(t.ctx, t.indexStorageClient, t.name, userID, ts); err != nil {
+			return fmt.Errorf("writing folded tombstones: %w", err)
+		}
+	}
+	for _, userID := range propagated {
+		if err := tombstones.Delete(t.ctx, t.indexStorageClient, t.name, userID); err != nil {
+			return fmt.Errorf("deleting folded tombstones: %w", err)
+		}
+	}
+	return nil
 }
 
 func (t *table) done() error {
@@ -245,6 +287,129 @@ func (t *table) applyRetention() error {
 	return nil
 }
 
+// foldTombstones removes the tombstoned chunks from the index of the tenants having tombstones in the table.
+// It returns the tombstones folded into the index by this run, and the tenants whose tombstones were folded
+// for longer than the index update propagation delay, which can be deleted.
+func (t *table) foldTombstones() (map[string]*tombstones.Tombstones, []string, error) {
+	files, err := tombstones.Tenants(t.ctx, t.indexStorageClient, t.name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("listing tombstones: %w", err)
+	}
+
+	folded := map[string]*tombstones.Tombstones{}
+	var propagated []string
+	for _, file := range files {
+		userID := file.Name
+		sinceFolded := time.Since(file.ModifiedAt)
+		is, ok := t.indexSets[userID]
+		if !ok {
+			// the tenant has no index left in the table.
+			if sinceFolded >= t.tombstonesFolder.indexUpdatePropagationMaxDelay {
+				propagated = append(propagated, userID)
+			}
+			continue
+		}
+
+		if is.compactedIndex == nil {
+			if len(is.ListSourceFiles()) != 1 {
+				continue
+			}
+			if err := t.openCompactedIndexForRetention(is); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		ts, err := tombstones.Read(t.ctx, t.indexStorageClient, t.name, userID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading tombstones: %w", err)
+		}
+		var empty, modified bool
+		if ts.Len() > 0 {
+			empty, modified, err = t.tombstonesFolder.fold(t.ctx, userID, ts, is.compactedIndex)
+			if err != nil {
+				return nil, nil, fmt.Errorf("folding tombstones: %w", err)
+			}
+			if empty {
+				is.uploadCompactedDB = false
+				is.removeSourceObjects = true
+			} else if modified {
+				is.uploadCompactedDB = true
+				is.removeSourceObjects = true
+			}
+			level.Info(is.logger).Log("msg", "folded tombstones", "series", ts.Len(), "empty", empty, "modified", modified)
+		}
+
+		switch {
+		case modified:
+			folded[userID] = ts
+		case sinceFolded >= t.tombstonesFolder.indexUpdatePropagationMaxDelay:
+			// the index had none of the tombstoned chunks left since the tombstones were last written.
+			propagated = append(propagated, userID)
+		}
+	}
+
+	return folded, propagated, nil
+}
+
+// mergeReplicaChunks merges the overlapping chunks of the index sets.
+func (t *table) mergeReplicaChunks() error {
+	for userID, is := range t.indexSets {
+		// make sure we do not merge chunks of the common index set which got compacted away to per-user index
+		if userID == "" && is.compactedIndex == nil && is.removeSourceObjects && !is.uploadCompactedDB {
+			continue
+		}
+
+		if is.compactedIndex == nil {
+			if len(is.ListSourceFiles()) != 1 {
+				continue
+			}
+			if err := t.openCompactedIndexForRetention(is); err != nil {
+				return err
+			}
+		}
+
+		modified, err := t.replicaMerger.merge(t.ctx, t.name, is.compactedIndex, is.logger)
+		if err != nil {
+			return fmt.Errorf("merging replica chunks: %w", err)
+		}
+		if modified {
+			is.uploadCompactedDB = true
+			is.removeSourceObjects = true
+		}
+	}
+
+	return nil
+}
+
+// moveToStorageTiers moves the chunks of the index sets to the storage tiers of the period.
+func (t *table) moveToStorageTiers() error {
+	for userID, is := range t.indexSets {
+		// make sure we do not move chunks of the common index set which got compacted away to per-user index
+		if userID == "" && is.compactedIndex == nil && is.removeSourceObjects && !is.uploadCompactedDB {
+			continue
+		}
+
+		if !t.tierMover.pending(t.name, userID) {
+			continue
+		}
+
+		if is.compactedIndex == nil {
+			if len(is.ListSourceFiles()) != 1 {
+				continue
+			}
+			if err := t.openCompactedIndexForRetention(is); err != nil {
+				return err
+			}
+		}
+
+		if err := t.tierMover.moveChunks(t.ctx, t.name, userID, is.compactedIndex, is.logger); err != nil {
+			return fmt.Errorf("moving chunks to storage tiers: %w", err)
+		}
+	}
+
+	return nil
+}
+
 func (t *table) openCompactedIndexForRetention(idxSet *indexSet) error {
 	sourceFiles := idxSet.ListSourceFiles()
 	if len(sourceFiles) != 1 {
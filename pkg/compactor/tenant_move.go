@@ -0,0 +1,653 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+const (
+	// TenantMoveModeCopy copies the data of the source tenant to the destination tenant.
+	TenantMoveModeCopy = "copy"
+	// TenantMoveModeMove copies the data of the source tenant to the destination tenant and then deletes it from the source tenant.
+	TenantMoveModeMove = "move"
+
+	TenantMoveStatusPending = "pending"
+	// TenantMoveStatusCopied is the status of the tables of a move whose data was copied but not deleted from the source tenant yet.
+	TenantMoveStatusCopied = "copied"
+	TenantMoveStatusDone   = "done"
+	TenantMoveStatusFailed = "failed"
+)
+
+var (
+	errTableNotCompacted    = errors.New("table has index files which were not compacted yet")
+	errTenantMoveInProgress = errors.New("a move of the tenant is already in progress")
+)
+
+// TenantMoveConfig configures the moves of the data of a tenant to another tenant.
+type TenantMoveConfig struct {
+	Enabled            bool          `yaml:"enabled"`
+	PathPrefix         string        `yaml:"path_prefix"`
+	Interval           time.Duration `yaml:"interval"`
+	Parallelism        int           `yaml:"parallelism"`
+	MaxChunksPerSecond int           `yaml:"max_chunks_per_second"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the tenant move config.
+func (cfg *TenantMoveConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable the moves of the data of a tenant to another tenant requested through the tenant move API. The progress of the moves is stored in the delete request store. Requires retention to be enabled.")
+	f.StringVar(&cfg.PathPrefix, prefix+"path-prefix", "tenant_moves/", "Path prefix of the progress of the moves in the delete request store.")
+	f.DurationVar(&cfg.Interval, prefix+"interval", 10*time.Minute, "Interval at which the pending moves are processed.")
+	f.IntVar(&cfg.Parallelism, prefix+"parallelism", 10, "Number of chunks copied in parallel.")
+	f.IntVar(&cfg.MaxChunksPerSecond, prefix+"max-chunks-per-second", 0, "Maximum number of chunks copied per second. 0 means no limit.")
+}
+
+// Validate validates the tenant move config.
+func (cfg *TenantMoveConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.PathPrefix == "" || !strings.HasSuffix(cfg.PathPrefix, "/") {
+		return errors.New("tenant move path_prefix must end with a path separator i.e '/'")
+	}
+	if cfg.Parallelism <= 0 {
+		return errors.New("tenant move parallelism must be positive")
+	}
+	if cfg.MaxChunksPerSecond < 0 {
+		return errors.New("tenant move max_chunks_per_second must not be negative")
+	}
+	return nil
+}
+
+// TenantMove is the move of the data of a source tenant to a destination tenant.
+// It is also the checkpoint of the move, updated after each table.
+type TenantMove struct {
+	Source      string            `json:"source"`
+	Destination string            `json:"destination"`
+	Mode        string            `json:"mode"`
+	Status      string            `json:"status"`
+	RequestedAt time.Time         `json:"requested_at"`
+	CompletedAt time.Time         `json:"completed_at,omitempty"`
+	Tables      []TenantMoveTable `json:"tables"`
+}
+
+// TenantMoveTable is the move of the data of a single index table.
+type TenantMoveTable struct {
+	Table  string `json:"table"`
+	Status string `json:"status"`
+	Chunks int    `json:"chunks"`
+	// IndexFiles are the index files of the destination tenant written by the move, replaced when the table is retried.
+	IndexFiles []string `json:"index_files,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+func (m *TenantMove) hasTable(table string) bool {
+	for _, t := range m.Tables {
+		if t.Table == table {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStatus sets the status of the move from the status of its tables.
+func (m *TenantMove) updateStatus(now time.Time) {
+	status := TenantMoveStatusDone
+	for _, t := range m.Tables {
+		switch t.Status {
+		case TenantMoveStatusPending, TenantMoveStatusCopied:
+			return
+		case TenantMoveStatusFailed:
+			status = TenantMoveStatusFailed
+		}
+	}
+	m.Status, m.CompletedAt = status, now
+}
+
+type tenantMoveMetrics struct {
+	movedTables *prometheus.CounterVec
+	movedChunks prometheus.Counter
+}
+
+func newTenantMoveMetrics(r prometheus.Registerer) *tenantMoveMetrics {
+	return &tenantMoveMetrics{
+		movedTables: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "tenant_move_tables_total",
+			Help:      "Total number of tables of tenant moves processed by status.",
+		}, []string{"status"}),
+		movedChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "tenant_move_chunks_total",
+			Help:      "Total number of chunks copied to the destination tenant of tenant moves.",
+		}),
+	}
+}
+
+// TenantMover moves the data of tenants to other tenants, table by table.
+//
+// The chunks of the source tenant are re-keyed, which re-encodes them with the destination tenant,
+// written next to the source chunks and read back to verify them. The index of the destination tenant
+// is then rebuilt from the index of the source tenant with the new chunks and uploaded as a new per tenant
+// index file, merged with the other files of the destination tenant by the next compaction.
+// The zstd dictionaries of the source tenant are copied along, as the re-keyed chunks keep their compressed blocks.
+// Moves then remove the source chunks from the index of the source tenant and mark them for deletion by the
+// retention sweeper, which deletes them after the retention delete delay. Chunks under a legal hold are kept.
+//
+// Only compacted tables of periods using the tsdb index are moved, tables having multi-tenant index
+// files are retried once compacted. The tables are locked while they are moved, so that they are not
+// compacted or processed by retention meanwhile.
+type TenantMover struct {
+	cfg              TenantMoveConfig
+	objectClient     client.ObjectClient
+	schemaConfig     config.SchemaConfig
+	storeContainers  map[config.DayTime]storeContainer
+	indexCompactors  map[string]IndexCompactor
+	tableLocker      *tableLocker
+	workingDirectory string
+	limiter          *rate.Limiter
+	legalHolds       legalHoldsChecker
+	cacheGenerations cacheGenerationsUpdater
+	metrics          *tenantMoveMetrics
+	logger           log.Logger
+
+	// manifestMtx serializes the updates of the moves.
+	manifestMtx sync.Mutex
+	now         func() time.Time
+}
+
+// cacheGenerationsUpdater invalidates the results cache of the tenants whose data is removed.
+type cacheGenerationsUpdater interface {
+	UpdateCacheGenerationNumber(ctx context.Context, userID string) error
+}
+
+func newTenantMover(cfg TenantMoveConfig, objectClient client.ObjectClient, c *Compactor, r prometheus.Registerer) (*TenantMover, error) {
+	checker, ok := c.expirationChecker.(*expirationChecker)
+	if !ok || c.deleteRequestsStore == nil {
+		return nil, errors.New("tenant moves require retention to be enabled")
+	}
+
+	workingDirectory := filepath.Join(c.cfg.WorkingDirectory, "tenant_moves")
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+
+	limiter := rate.NewLimiter(rate.Inf, 1)
+	if cfg.MaxChunksPerSecond > 0 {
+		limiter = rate.NewLimiter(rate.Limit(cfg.MaxChunksPerSecond), 1)
+	}
+
+	return &TenantMover{
+		cfg:              cfg,
+		objectClient:     objectClient,
+		schemaConfig:     c.schemaConfig,
+		storeContainers:  c.storeContainers,
+		indexCompactors:  c.indexCompactors,
+		tableLocker:      c.tableLocker,
+		workingDirectory: workingDirectory,
+		limiter:          limiter,
+		legalHolds:       checker.legalHolds,
+		cacheGenerations: c.deleteRequestsStore,
+		metrics:          newTenantMoveMetrics(r),
+		logger:           log.With(util_log.Logger, "component", "tenant-mover"),
+		now:              time.Now,
+	}, nil
+}
+
+// Tables returns the tables to move for the days from the day of from to the day of through.
+// All the days must belong to periods using the tsdb index.
+func (m *TenantMover) Tables(from, through time.Time) ([]TenantMoveTable, error) {
+	var tables []TenantMoveTable
+	for day := from.UTC().Truncate(24 * time.Hour); !day.After(through); day = day.Add(24 * time.Hour) {
+		ts := model.TimeFromUnix(day.Unix())
+		period, err := m.schemaConfig.SchemaForTime(ts)
+		if err != nil {
+			return nil, err
+		}
+		if period.IndexType != types.TSDBType {
+			return nil, fmt.Errorf("day %s uses the %s index, only the %s index is supported", day.Format(dayFormat), period.IndexType, types.TSDBType)
+		}
+		if _, ok := m.storeContainers[period.From]; !ok {
+			return nil, fmt.Errorf("no object store for the period of day %s", day.Format(dayFormat))
+		}
+		table := period.IndexTables.TableFor(ts)
+		if len(tables) == 0 || tables[len(tables)-1].Table != table {
+			tables = append(tables, TenantMoveTable{Table: table, Status: TenantMoveStatusPending})
+		}
+	}
+	return tables, nil
+}
+
+// Request requests the move of the tables of the source tenant, see Tables.
+// It fails if the previous move of the source tenant is still pending.
+func (m *TenantMover) Request(ctx context.Context, source, destination, mode string, tables []TenantMoveTable) (TenantMove, error) {
+	move := TenantMove{
+		Source:      source,
+		Destination: destination,
+		Mode:        mode,
+		Status:      TenantMoveStatusPending,
+		RequestedAt: m.now().UTC(),
+		Tables:      tables,
+	}
+
+	m.manifestMtx.Lock()
+	defer m.manifestMtx.Unlock()
+
+	previous, err := m.readMove(ctx, source)
+	if err != nil {
+		return move, err
+	}
+	if previous != nil && previous.Status == TenantMoveStatusPending {
+		return *previous, errTenantMoveInProgress
+	}
+	return move, m.writeMove(ctx, move)
+}
+
+// Move returns the last move of the source tenant, or nil if none.
+func (m *TenantMover) Move(ctx context.Context, source string) (*TenantMove, error) {
+	return m.readMove(ctx, source)
+}
+
+// Interval returns the interval at which the pending moves are processed.
+func (m *TenantMover) Interval() time.Duration {
+	return m.cfg.Interval
+}
+
+// Run processes the pending tables of the pending moves.
+func (m *TenantMover) Run(ctx context.Context) error {
+	objects, _, err := m.objectClient.List(ctx, m.cfg.PathPrefix, "")
+	if err != nil {
+		return err
+	}
+	m.legalHolds.Load(ctx, model.Now())
+
+	for _, object := range objects {
+		source, ok := strings.CutSuffix(strings.TrimPrefix(object.Key, m.cfg.PathPrefix), ".json")
+		if !ok {
+			continue
+		}
+		move, err := m.readMove(ctx, source)
+		if err != nil {
+			return err
+		}
+		if move == nil || move.Status != TenantMoveStatusPending {
+			continue
+		}
+
+		for _, t := range move.Tables {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if t.Status == TenantMoveStatusPending || t.Status == TenantMoveStatusCopied {
+				m.processTable(ctx, move, t)
+			}
+		}
+	}
+	return nil
+}
+
+func (m *TenantMover) processTable(ctx context.Context, move *TenantMove, t TenantMoveTable) {
+	logger := log.With(m.logger, "source", move.Source, "destination", move.Destination, "table", t.Table)
+	start := time.Now()
+
+	for {
+		locked, lockWaiterChan := m.tableLocker.lockTable(t.Table)
+		if locked {
+			break
+		}
+		select {
+		case <-lockWaiterChan:
+		case <-ctx.Done():
+			return
+		}
+	}
+	defer m.tableLocker.unlockTable(t.Table)
+
+	err := m.moveTable(ctx, move, &t, logger)
+	switch {
+	case errors.Is(err, errTableNotCompacted):
+		level.Info(logger).Log("msg", "waiting for the compaction of the table before moving it")
+		return
+	case ctx.Err() != nil:
+		return
+	case err != nil:
+		level.Error(logger).Log("msg", "failed to move table", "err", err)
+		t.Status, t.Error = TenantMoveStatusFailed, err.Error()
+	default:
+		level.Info(logger).Log("msg", "moved table", "chunks", t.Chunks, "duration", time.Since(start))
+		t.Status, t.Error = TenantMoveStatusDone, ""
+	}
+	m.metrics.movedTables.WithLabelValues(t.Status).Inc()
+
+	if err := m.updateTable(ctx, move, t); err != nil {
+		level.Error(logger).Log("msg", "failed to update tenant move", "err", err)
+	}
+}
+
+// moveTable copies the chunks and index of the table to the destination tenant, checkpointing the move
+// before deleting the source data of moves.
+func (m *TenantMover) moveTable(ctx context.Context, move *TenantMove, t *TenantMoveTable, logger log.Logger) error {
+	period, ok := SchemaPeriodForTable(m.schemaConfig, t.Table)
+	if !ok {
+		return fmt.Errorf("no schema period for table %s", t.Table)
+	}
+	sc, ok := m.storeContainers[period.From]
+	if !ok {
+		return fmt.Errorf("no object store for the period of table %s", t.Table)
+	}
+	indexCompactor, ok := m.indexCompactors[period.IndexType]
+	if !ok {
+		return fmt.Errorf("index processor not found for index type %s", period.IndexType)
+	}
+
+	commonFiles, _, err := sc.indexStorageClient.ListFiles(ctx, t.Table, true)
+	if err != nil {
+		return err
+	}
+	if len(commonFiles) > 0 {
+		return errTableNotCompacted
+	}
+	sourceFiles, err := sc.indexStorageClient.ListUserFiles(ctx, t.Table, move.Source, true)
+	if err != nil {
+		return err
+	}
+
+	workingDir := filepath.Join(m.workingDirectory, t.Table, move.Source)
+	if err := chunk_util.EnsureDirectory(workingDir); err != nil {
+		return err
+	}
+	defer os.RemoveAll(workingDir)
+
+	if t.Status == TenantMoveStatusPending {
+		// replace the index files written by a previous attempt.
+		for _, fileName := range t.IndexFiles {
+			if err := sc.indexStorageClient.DeleteUserFile(ctx, t.Table, move.Destination, fileName); err != nil && !sc.indexStorageClient.IsFileNotFoundErr(err) {
+				return err
+			}
+		}
+		t.IndexFiles, t.Chunks = nil, 0
+
+		// the copied chunks are still compressed with the zstd dictionaries of the source tenant.
+		if err := copyZstdDictionaries(ctx, sc.locations[0].objectClient, move.Source, move.Destination); err != nil {
+			return err
+		}
+
+		for _, file := range sourceFiles {
+			chunks, fileName, err := m.copyIndexFile(ctx, move, period, sc, indexCompactor, t.Table, file.Name, workingDir, logger)
+			if err != nil {
+				return err
+			}
+			t.IndexFiles = append(t.IndexFiles, fileName)
+			t.Chunks += len(chunks)
+		}
+
+		if move.Mode == TenantMoveModeCopy {
+			return nil
+		}
+		t.Status = TenantMoveStatusCopied
+		if err := m.updateTable(ctx, move, *t); err != nil {
+			return err
+		}
+	}
+
+	// the table was copied, possibly before the previous attempt stopped, only the source data is left to remove.
+	return m.removeSourceChunks(ctx, move, period, sc, indexCompactor, t.Table, sourceFiles, workingDir, logger)
+}
+
+// chunksOfIndexFile downloads and opens the index file of the tenant and returns the chunks it references.
+// The opened index is passed to f, if not nil, before being cleaned up.
+func chunksOfIndexFile(ctx context.Context, logger log.Logger, tenant string, period config.PeriodConfig, sc storeContainer, indexCompactor IndexCompactor, table, fileName, workingDir string, f func(CompactedIndex, []chunk.Chunk) error) ([]chunk.Chunk, error) {
+	localPath := filepath.Join(workingDir, strings.TrimSuffix(fileName, ".gz"))
+	if err := storage.DownloadFileFromStorage(localPath, storage.IsCompressedFile(fileName), false, logger, func() (io.ReadCloser, error) {
+		return sc.indexStorageClient.GetUserFile(ctx, table, tenant, fileName)
+	}); err != nil {
+		return nil, err
+	}
+	defer os.Remove(localPath)
+
+	compactedIndex, err := indexCompactor.OpenCompactedIndexFile(ctx, localPath, table, tenant, workingDir, period, logger)
+	if err != nil {
+		return nil, err
+	}
+	defer compactedIndex.Cleanup()
+
+	var chunks []chunk.Chunk
+	err = compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		chk, err := chunk.ParseExternalKey(tenant, string(ce.ChunkID))
+		if err != nil {
+			return false, err
+		}
+		chunks = append(chunks, chk)
+		return false, nil
+	})
+	if err != nil || f == nil {
+		return chunks, err
+	}
+	return chunks, f(compactedIndex, chunks)
+}
+
+// copyIndexFile copies the chunks referenced by the index file of the source tenant and uploads
+// the index of the copies to the destination tenant. It returns the source chunks and the name of the uploaded file.
+func (m *TenantMover) copyIndexFile(ctx context.Context, move *TenantMove, period config.PeriodConfig, sc storeContainer, indexCompactor IndexCompactor, table, fileName, workingDir string, logger log.Logger) ([]chunk.Chunk, string, error) {
+	var uploaded string
+	chunks, err := chunksOfIndexFile(ctx, m.logger, move.Source, period, sc, indexCompactor, table, fileName, workingDir, func(compactedIndex CompactedIndex, chunks []chunk.Chunk) error {
+		copies := make([]chunk.Chunk, len(chunks))
+		err := concurrency.ForEachJob(ctx, len(chunks), m.cfg.Parallelism, func(ctx context.Context, idx int) error {
+			if err := m.limiter.Wait(ctx); err != nil {
+				return err
+			}
+			copied, err := m.copyChunk(ctx, sc.locations, chunks[idx], move.Destination)
+			if err != nil {
+				return err
+			}
+			copies[idx] = copied
+			m.metrics.movedChunks.Inc()
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		// replace the source chunks with their copies, which only differ by their checksum in the index.
+		if err := compactedIndex.ForEachChunk(ctx, func(retention.ChunkEntry) (bool, error) {
+			return true, nil
+		}); err != nil {
+			return err
+		}
+		for _, c := range copies {
+			indexed, err := compactedIndex.IndexChunk(c)
+			if err != nil {
+				return err
+			}
+			if !indexed {
+				return fmt.Errorf("chunk %s does not belong to table %s", m.schemaConfig.ExternalKey(c.ChunkRef), table)
+			}
+		}
+
+		idx, err := compactedIndex.ToIndexFile()
+		if err != nil {
+			return err
+		}
+		uploaded, err = uploadIndexFile(ctx, idx, logger, func(ctx context.Context, fileName string, file io.ReadSeeker) error {
+			return sc.indexStorageClient.PutUserFile(ctx, table, move.Destination, fileName, file)
+		})
+		return err
+	})
+	return chunks, uploaded, err
+}
+
+// copyChunk copies the chunk to the destination tenant in the location holding it
+// and verifies the copy can be read back.
+func (m *TenantMover) copyChunk(ctx context.Context, locations []tierLocation, src chunk.Chunk, destination string) (chunk.Chunk, error) {
+	for _, loc := range locations {
+		chunks, err := loc.chunkClient.GetChunks(ctx, []chunk.Chunk{src})
+		if err != nil {
+			if loc.chunkClient.IsChunkNotFoundErr(err) {
+				continue
+			}
+			return chunk.Chunk{}, err
+		}
+
+		c := chunks[0]
+		dst := chunk.NewChunk(destination, model.Fingerprint(c.Fingerprint), c.Metric, c.Data, c.From, c.Through)
+		if err := dst.Encode(); err != nil {
+			return chunk.Chunk{}, err
+		}
+		if err := loc.chunkClient.PutChunks(ctx, []chunk.Chunk{dst}); err != nil {
+			return chunk.Chunk{}, err
+		}
+
+		// fetching the copy decodes it, which verifies its checksum.
+		key := m.schemaConfig.ExternalKey(dst.ChunkRef)
+		copies, err := loc.chunkClient.GetChunks(ctx, []chunk.Chunk{{ChunkRef: dst.ChunkRef}})
+		if err != nil {
+			return chunk.Chunk{}, fmt.Errorf("verifying the copy %s: %w", key, err)
+		}
+		if copies[0].Data.Entries() != c.Data.Entries() {
+			return chunk.Chunk{}, fmt.Errorf("verifying the copy %s: %d entries instead of %d", key, copies[0].Data.Entries(), c.Data.Entries())
+		}
+		return dst, nil
+	}
+	return chunk.Chunk{}, fmt.Errorf("chunk %s not found", m.schemaConfig.ExternalKey(src.ChunkRef))
+}
+
+// removeSourceChunks removes the source chunks from the index files of the source tenant and marks them
+// for deletion by the sweeper, like retention does. Chunks spanning later tables are only marked with the last
+// table they are referenced from, and chunks spanning earlier tables which are not moved are not marked.
+// Chunks under a legal hold are kept in the index of the source tenant.
+func (m *TenantMover) removeSourceChunks(ctx context.Context, move *TenantMove, period config.PeriodConfig, sc storeContainer, indexCompactor IndexCompactor, table string, sourceFiles []storage.IndexFile, workingDir string, logger log.Logger) error {
+	markerWriter, err := retention.NewMarkerStorageWriter(sc.retentionWorkDir)
+	if err != nil {
+		return fmt.Errorf("failed to create marker writer: %w", err)
+	}
+
+	now := model.Now()
+	var held int
+	var replaced []string
+	for _, file := range sourceFiles {
+		var uploaded string
+		_, err = chunksOfIndexFile(ctx, m.logger, move.Source, period, sc, indexCompactor, table, file.Name, workingDir, func(compactedIndex CompactedIndex, _ []chunk.Chunk) error {
+			var kept int
+			err := compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+				if m.legalHolds.Held(ce, now) {
+					kept++
+					return false, nil
+				}
+				if period.IndexTables.TableFor(ce.Through) == table && move.hasTable(period.IndexTables.TableFor(ce.From)) {
+					if err := markerWriter.Put(ce.ChunkID); err != nil {
+						return false, err
+					}
+				}
+				return true, nil
+			})
+			if err != nil || kept == 0 {
+				return err
+			}
+			held += kept
+
+			idx, err := compactedIndex.ToIndexFile()
+			if err != nil {
+				return err
+			}
+			uploaded, err = uploadIndexFile(ctx, idx, logger, func(ctx context.Context, fileName string, file io.ReadSeeker) error {
+				return sc.indexStorageClient.PutUserFile(ctx, table, move.Source, fileName, file)
+			})
+			return err
+		})
+		if err != nil {
+			break
+		}
+		if uploaded != file.Name {
+			replaced = append(replaced, file.Name)
+		}
+	}
+	if closeErr := markerWriter.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close marker writer: %w", closeErr)
+	}
+	if err != nil {
+		return err
+	}
+	if held > 0 {
+		level.Info(logger).Log("msg", "kept source chunks under a legal hold", "chunks", held)
+	}
+
+	// the index files are only deleted once the markers of their chunks are stored.
+	for _, fileName := range replaced {
+		if err := sc.indexStorageClient.DeleteUserFile(ctx, table, move.Source, fileName); err != nil && !sc.indexStorageClient.IsFileNotFoundErr(err) {
+			return err
+		}
+	}
+	return m.cacheGenerations.UpdateCacheGenerationNumber(ctx, move.Source)
+}
+
+func (m *TenantMover) movePath(source string) string {
+	return m.cfg.PathPrefix + source + ".json"
+}
+
+func (m *TenantMover) readMove(ctx context.Context, source string) (*TenantMove, error) {
+	rc, _, err := m.objectClient.GetObject(ctx, m.movePath(source))
+	if err != nil {
+		if m.objectClient.IsObjectNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	var move TenantMove
+	return &move, json.Unmarshal(b, &move)
+}
+
+func (m *TenantMover) writeMove(ctx context.Context, move TenantMove) error {
+	b, err := json.Marshal(move)
+	if err != nil {
+		return err
+	}
+	return m.objectClient.PutObject(ctx, m.movePath(move.Source), bytes.NewReader(b))
+}
+
+// updateTable checkpoints the progress of the table in the move and in its stored copy.
+func (m *TenantMover) updateTable(ctx context.Context, move *TenantMove, t TenantMoveTable) error {
+	m.manifestMtx.Lock()
+	defer m.manifestMtx.Unlock()
+
+	for i := range move.Tables {
+		if move.Tables[i].Table == t.Table {
+			move.Tables[i] = t
+		}
+	}
+	move.updateStatus(m.now().UTC())
+	return m.writeMove(ctx, *move)
+}
+
+const dayFormat = "2006-01-02"
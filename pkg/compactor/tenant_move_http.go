@@ -0,0 +1,133 @@
+package compactor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/tenant"
+)
+
+// RequestTenantMoveHandler requests the move of the data of the source tenant to the destination tenant
+// for the days between the start and end query parameters, both inclusive and in YYYY-MM-DD format.
+// The mode query parameter is either copy, the default, or move.
+//
+// As the move writes into the destination tenant, the caller must be authorized for both tenants: the
+// org ID of the request holds the source and the destination tenants, like source|destination.
+func (m *TenantMover) RequestTenantMoveHandler(w http.ResponseWriter, r *http.Request) {
+	tenants, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	params := r.URL.Query()
+	destination := params.Get("destination")
+	if err := tenant.ValidTenantID(destination); err != nil {
+		http.Error(w, fmt.Sprintf("invalid destination tenant: %v", err), http.StatusBadRequest)
+		return
+	}
+	userID, err := moveSourceTenant(tenants, destination)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	mode := params.Get("mode")
+	switch mode {
+	case "":
+		mode = TenantMoveModeCopy
+	case TenantMoveModeCopy, TenantMoveModeMove:
+	default:
+		http.Error(w, fmt.Sprintf("invalid mode %q, expected %s or %s", mode, TenantMoveModeCopy, TenantMoveModeMove), http.StatusBadRequest)
+		return
+	}
+
+	start, err := time.Parse(dayFormat, params.Get("start"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("invalid start day, expected YYYY-MM-DD: %v", err), http.StatusBadRequest)
+		return
+	}
+	end := start
+	if params.Get("end") != "" {
+		end, err = time.Parse(dayFormat, params.Get("end"))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid end day, expected YYYY-MM-DD: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if end.Before(start) {
+		http.Error(w, "end day must not be before start day", http.StatusBadRequest)
+		return
+	}
+	if !end.Before(m.now().UTC().Truncate(24 * time.Hour)) {
+		http.Error(w, "only past days can be moved", http.StatusBadRequest)
+		return
+	}
+
+	tables, err := m.Tables(start, end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	move, err := m.Request(r.Context(), userID, destination, mode, tables)
+	if err != nil {
+		if errors.Is(err, errTenantMoveInProgress) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		level.Error(m.logger).Log("msg", "error requesting tenant move", "source", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	level.Info(m.logger).Log("msg", "tenant move requested", "source", userID, "destination", destination, "mode", mode, "start", start.Format(dayFormat), "end", end.Format(dayFormat))
+
+	writeTenantMoveResponse(w, &move)
+}
+
+// moveSourceTenant returns the source tenant of a move to the destination from the tenants of the org ID.
+func moveSourceTenant(tenants []string, destination string) (string, error) {
+	if len(tenants) != 2 || !slices.Contains(tenants, destination) {
+		return "", fmt.Errorf("the org ID must hold the source and the destination tenants, like <source>|%s", destination)
+	}
+	if tenants[0] == destination {
+		return tenants[1], nil
+	}
+	return tenants[0], nil
+}
+
+// GetTenantMoveHandler returns the last move of the tenant.
+func (m *TenantMover) GetTenantMoveHandler(w http.ResponseWriter, r *http.Request) {
+	userID, err := tenant.TenantID(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	move, err := m.Move(r.Context(), userID)
+	if err != nil {
+		level.Error(m.logger).Log("msg", "error reading tenant move", "source", userID, "err", err)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if move == nil {
+		http.Error(w, "no move of the tenant was requested", http.StatusNotFound)
+		return
+	}
+	writeTenantMoveResponse(w, move)
+}
+
+func writeTenantMoveResponse(w http.ResponseWriter, move *TenantMove) {
+	if move.Tables == nil {
+		move.Tables = []TenantMoveTable{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(move); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,426 @@
+package compactor
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/backoff"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/index"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+)
+
+// moveSource is the tenant of the chunks built by testutils.DummyChunkFor.
+const moveSource = "userID"
+
+// chunkListIndexCompactor opens index files listing the external keys of their chunks, one per line.
+type chunkListIndexCompactor struct {
+	testIndexCompactor
+	schemaConfig config.SchemaConfig
+}
+
+func (i chunkListIndexCompactor) OpenCompactedIndexFile(_ context.Context, path, _, userID, workingDir string, _ config.PeriodConfig, _ log.Logger) (CompactedIndex, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return &chunkListIndex{schemaConfig: i.schemaConfig, userID: userID, workingDir: workingDir, keys: strings.Fields(string(b))}, nil
+}
+
+type chunkListIndex struct {
+	schemaConfig config.SchemaConfig
+	userID       string
+	workingDir   string
+	keys         []string
+}
+
+func (c *chunkListIndex) ForEachChunk(_ context.Context, callback retention.ChunkEntryCallback) error {
+	var keys []string
+	for _, key := range c.keys {
+		chk, err := chunk.ParseExternalKey(c.userID, key)
+		if err != nil {
+			return err
+		}
+		deleteChunk, err := callback(retention.ChunkEntry{ChunkRef: retention.ChunkRef{
+			UserID:  []byte(c.userID),
+			ChunkID: []byte(key),
+			From:    chk.From,
+			Through: chk.Through,
+		}})
+		if err != nil {
+			return err
+		}
+		if !deleteChunk {
+			keys = append(keys, key)
+		}
+	}
+	c.keys = keys
+	return nil
+}
+
+func (c *chunkListIndex) IndexChunk(chk chunk.Chunk) (bool, error) {
+	c.keys = append(c.keys, c.schemaConfig.ExternalKey(chk.ChunkRef))
+	return true, nil
+}
+
+func (c *chunkListIndex) CleanupSeries(_ []byte, _ labels.Labels) error {
+	return nil
+}
+
+func (c *chunkListIndex) Cleanup() {}
+
+func (c *chunkListIndex) ToIndexFile() (index.Index, error) {
+	path := filepath.Join(c.workingDir, fmt.Sprintf("chunks-%d", time.Now().UnixNano()))
+	if err := os.WriteFile(path, []byte(strings.Join(c.keys, "\n")), 0o640); err != nil {
+		return nil, err
+	}
+	return openCompactedIndex(path)
+}
+
+// heldChunks holds the chunks with the given IDs.
+type heldChunks map[string]struct{}
+
+func (h heldChunks) Load(_ context.Context, _ model.Time) {}
+
+func (h heldChunks) Held(ref retention.ChunkEntry, _ model.Time) bool {
+	_, ok := h[string(ref.ChunkID)]
+	return ok
+}
+
+type cacheGenerationsStore struct {
+	deletion.DeleteRequestsStore
+	updated []string
+}
+
+func (s *cacheGenerationsStore) UpdateCacheGenerationNumber(_ context.Context, userID string) error {
+	s.updated = append(s.updated, userID)
+	return nil
+}
+
+type tenantMoveFixture struct {
+	compactor          *Compactor
+	objectClient       client.ObjectClient
+	schemaConfig       config.SchemaConfig
+	period             config.PeriodConfig
+	table              string
+	mover              *TenantMover
+	location           tierLocation
+	indexStorageClient storage.Client
+	retentionWorkDir   string
+	legalHolds         heldChunks
+	cacheGenerations   *cacheGenerationsStore
+}
+
+func newTenantMoveFixture(t *testing.T) *tenantMoveFixture {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	period := config.PeriodConfig{
+		From:       config.DayTime{Time: model.Time(0)},
+		IndexType:  types.TSDBType,
+		ObjectType: types.StorageTypeFileSystem,
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PathPrefix:          "index/",
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: 24 * time.Hour},
+		},
+	}
+	schemaConfig := config.SchemaConfig{Configs: []config.PeriodConfig{period}}
+	location := newTierLocation(period.ObjectType, objectClient, schemaConfig)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, period.IndexTables.PathPrefix)
+	retentionWorkDir := t.TempDir()
+	legalHolds := heldChunks{}
+	cacheGenerations := &cacheGenerationsStore{}
+
+	c := &Compactor{
+		cfg:                 Config{WorkingDirectory: t.TempDir()},
+		schemaConfig:        schemaConfig,
+		tableLocker:         newTableLocker(),
+		indexCompactors:     map[string]IndexCompactor{types.TSDBType: chunkListIndexCompactor{schemaConfig: schemaConfig}},
+		expirationChecker:   newExpirationChecker(nil, nil, legalHolds),
+		deleteRequestsStore: cacheGenerations,
+		storeContainers: map[config.DayTime]storeContainer{
+			period.From: {indexStorageClient: indexStorageClient, locations: []tierLocation{location}, retentionWorkDir: retentionWorkDir},
+		},
+	}
+	mover, err := newTenantMover(TenantMoveConfig{Enabled: true, PathPrefix: "tenant_moves/", Parallelism: 2}, objectClient, c, prometheus.NewRegistry())
+	require.NoError(t, err)
+
+	return &tenantMoveFixture{
+		compactor:          c,
+		objectClient:       objectClient,
+		schemaConfig:       schemaConfig,
+		period:             period,
+		table:              period.IndexTables.TableFor(model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())),
+		mover:              mover,
+		location:           location,
+		indexStorageClient: indexStorageClient,
+		retentionWorkDir:   retentionWorkDir,
+		legalHolds:         legalHolds,
+		cacheGenerations:   cacheGenerations,
+	}
+}
+
+// putChunks stores chunks of the source tenant and the index file referencing them.
+func (f *tenantMoveFixture) putChunks(t *testing.T, n int) []chunk.Chunk {
+	from := model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	var chunks []chunk.Chunk
+	for i := 0; i < n; i++ {
+		chunks = append(chunks, testutils.DummyChunkFor(from.Add(time.Duration(i)*time.Hour), from.Add(time.Duration(i)*time.Hour+time.Minute), labels.FromStrings("app", fmt.Sprint(i))))
+	}
+	f.putIndexedChunks(t, "chunks", chunks)
+	return chunks
+}
+
+// putIndexedChunks stores the chunks of the source tenant and the index file referencing them.
+func (f *tenantMoveFixture) putIndexedChunks(t *testing.T, fileName string, chunks []chunk.Chunk) {
+	keys := make([]string, 0, len(chunks))
+	for _, chk := range chunks {
+		keys = append(keys, f.schemaConfig.ExternalKey(chk.ChunkRef))
+	}
+	require.NoError(t, f.location.chunkClient.PutChunks(context.Background(), chunks))
+	require.NoError(t, f.indexStorageClient.PutUserFile(context.Background(), f.table, moveSource, fileName, bytes.NewReader([]byte(strings.Join(keys, "\n")))))
+}
+
+// indexedChunks returns the chunks referenced by the index files of the tenant.
+func (f *tenantMoveFixture) indexedChunks(t *testing.T, tenant string) []chunk.Chunk {
+	files, err := f.indexStorageClient.ListUserFiles(context.Background(), f.table, tenant, true)
+	require.NoError(t, err)
+
+	var chunks []chunk.Chunk
+	for _, file := range files {
+		rc, err := f.indexStorageClient.GetUserFile(context.Background(), f.table, tenant, file.Name)
+		require.NoError(t, err)
+		var r io.Reader = rc
+		if storage.IsCompressedFile(file.Name) {
+			r, err = gzip.NewReader(rc)
+			require.NoError(t, err)
+		}
+		b, err := io.ReadAll(r)
+		require.NoError(t, err)
+		require.NoError(t, rc.Close())
+
+		for _, key := range strings.Fields(string(b)) {
+			chk, err := chunk.ParseExternalKey(tenant, key)
+			require.NoError(t, err)
+			chunks = append(chunks, chk)
+		}
+	}
+	sort.Slice(chunks, func(i, j int) bool { return chunks[i].From < chunks[j].From })
+	return chunks
+}
+
+func (f *tenantMoveFixture) chunkExists(t *testing.T, chk chunk.Chunk) bool {
+	_, err := f.location.chunkClient.GetChunks(context.Background(), []chunk.Chunk{chk})
+	if f.location.chunkClient.IsChunkNotFoundErr(err) {
+		return false
+	}
+	require.NoError(t, err)
+	return true
+}
+
+// sweep runs the sweeper until the chunks marked for deletion are deleted.
+func (f *tenantMoveFixture) sweep(t *testing.T) {
+	sweeper, err := retention.NewSweeper(f.retentionWorkDir, f.location.chunkClient, 1, 0, backoff.Config{MaxRetries: 1}, prometheus.NewRegistry())
+	require.NoError(t, err)
+	sweeper.Start()
+	defer sweeper.Stop()
+
+	require.Eventually(t, func() bool {
+		markers, err := os.ReadDir(filepath.Join(f.retentionWorkDir, retention.MarkersFolder))
+		return err == nil && len(markers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func (f *tenantMoveFixture) request(t *testing.T, mode string) {
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tables, err := f.mover.Tables(day, day)
+	require.NoError(t, err)
+	require.Equal(t, []TenantMoveTable{{Table: f.table, Status: TenantMoveStatusPending}}, tables)
+
+	_, err = f.mover.Request(context.Background(), moveSource, "other", mode, tables)
+	require.NoError(t, err)
+}
+
+func TestTenantMover(t *testing.T) {
+	for _, mode := range []string{TenantMoveModeCopy, TenantMoveModeMove} {
+		t.Run(mode, func(t *testing.T) {
+			f := newTenantMoveFixture(t)
+			sources := f.putChunks(t, 3)
+			f.request(t, mode)
+
+			// a second move can't be requested while the first one is pending.
+			_, err := f.mover.Request(context.Background(), moveSource, "other", mode, nil)
+			require.ErrorIs(t, err, errTenantMoveInProgress)
+
+			require.NoError(t, f.mover.Run(context.Background()))
+
+			move, err := f.mover.Move(context.Background(), moveSource)
+			require.NoError(t, err)
+			require.Equal(t, TenantMoveStatusDone, move.Status)
+			require.Len(t, move.Tables, 1)
+			require.Equal(t, TenantMoveStatusDone, move.Tables[0].Status)
+			require.Equal(t, 3, move.Tables[0].Chunks)
+			require.Len(t, move.Tables[0].IndexFiles, 1)
+
+			copies := f.indexedChunks(t, "other")
+			require.Len(t, copies, len(sources))
+			for i, c := range copies {
+				require.Equal(t, "other", c.UserID)
+				require.Equal(t, sources[i].Fingerprint, c.Fingerprint)
+				require.Equal(t, sources[i].From, c.From)
+				require.Equal(t, sources[i].Through, c.Through)
+				require.True(t, f.chunkExists(t, c))
+			}
+
+			if mode == TenantMoveModeCopy {
+				require.Len(t, f.indexedChunks(t, moveSource), len(sources))
+				for _, c := range sources {
+					require.True(t, f.chunkExists(t, c))
+				}
+				require.Empty(t, f.cacheGenerations.updated)
+			} else {
+				require.Empty(t, f.indexedChunks(t, moveSource))
+				require.Equal(t, []string{moveSource}, f.cacheGenerations.updated)
+				// the source chunks are only deleted by the sweeper.
+				for _, c := range sources {
+					require.True(t, f.chunkExists(t, c))
+				}
+				f.sweep(t)
+				for _, c := range sources {
+					require.False(t, f.chunkExists(t, c))
+				}
+			}
+		})
+	}
+}
+
+func TestTenantMover_ResumesCopiedTables(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	sources := f.putChunks(t, 2)
+	f.request(t, TenantMoveModeMove)
+
+	// the previous attempt copied the table but stopped before deleting the source data.
+	move, err := f.mover.Move(context.Background(), moveSource)
+	require.NoError(t, err)
+	for _, c := range sources {
+		_, err := f.mover.copyChunk(context.Background(), []tierLocation{f.location}, c, "other")
+		require.NoError(t, err)
+	}
+	move.Tables[0].Status = TenantMoveStatusCopied
+	require.NoError(t, f.mover.writeMove(context.Background(), *move))
+
+	require.NoError(t, f.mover.Run(context.Background()))
+	move, err = f.mover.Move(context.Background(), moveSource)
+	require.NoError(t, err)
+	require.Equal(t, TenantMoveStatusDone, move.Status)
+	require.Empty(t, f.indexedChunks(t, moveSource))
+	f.sweep(t)
+	for _, c := range sources {
+		require.False(t, f.chunkExists(t, c))
+	}
+}
+
+func TestTenantMover_KeepsHeldChunks(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	sources := f.putChunks(t, 3)
+	f.legalHolds[f.schemaConfig.ExternalKey(sources[1].ChunkRef)] = struct{}{}
+	f.request(t, TenantMoveModeMove)
+
+	require.NoError(t, f.mover.Run(context.Background()))
+	move, err := f.mover.Move(context.Background(), moveSource)
+	require.NoError(t, err)
+	require.Equal(t, TenantMoveStatusDone, move.Status)
+	require.Len(t, f.indexedChunks(t, "other"), len(sources))
+
+	held := f.indexedChunks(t, moveSource)
+	require.Len(t, held, 1)
+	require.Equal(t, sources[1].From, held[0].From)
+
+	f.sweep(t)
+	require.False(t, f.chunkExists(t, sources[0]))
+	require.True(t, f.chunkExists(t, sources[1]))
+	require.False(t, f.chunkExists(t, sources[2]))
+}
+
+func TestTenantMover_WaitsForCompaction(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	sources := f.putChunks(t, 1)
+	require.NoError(t, f.indexStorageClient.PutFile(context.Background(), f.table, "multi-tenant", bytes.NewReader([]byte("index"))))
+	f.request(t, TenantMoveModeMove)
+
+	require.NoError(t, f.mover.Run(context.Background()))
+	move, err := f.mover.Move(context.Background(), moveSource)
+	require.NoError(t, err)
+	require.Equal(t, TenantMoveStatusPending, move.Status)
+	require.Equal(t, TenantMoveStatusPending, move.Tables[0].Status)
+	require.Empty(t, f.indexedChunks(t, "other"))
+	require.True(t, f.chunkExists(t, sources[0]))
+}
+
+func TestRequestTenantMoveHandler_Authorization(t *testing.T) {
+	for name, tc := range map[string]struct {
+		orgID          string
+		expectedStatus int
+	}{
+		"source only": {
+			orgID:          moveSource,
+			expectedStatus: http.StatusForbidden,
+		},
+		"destination not in org ID": {
+			orgID:          moveSource + "|third",
+			expectedStatus: http.StatusForbidden,
+		},
+		"more tenants": {
+			orgID:          moveSource + "|other|third",
+			expectedStatus: http.StatusForbidden,
+		},
+		"both tenants": {
+			orgID:          "other|" + moveSource,
+			expectedStatus: http.StatusOK,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			f := newTenantMoveFixture(t)
+			req := httptest.NewRequest(http.MethodPost, "/loki/api/v1/tenant/move?destination=other&start=2024-01-01", nil)
+			req = req.WithContext(user.InjectOrgID(req.Context(), tc.orgID))
+			res := httptest.NewRecorder()
+			f.mover.RequestTenantMoveHandler(res, req)
+			require.Equal(t, tc.expectedStatus, res.Code, res.Body.String())
+
+			move, err := f.mover.Move(context.Background(), moveSource)
+			require.NoError(t, err)
+			if tc.expectedStatus != http.StatusOK {
+				require.Nil(t, move)
+				return
+			}
+			require.Equal(t, moveSource, move.Source)
+			require.Equal(t, "other", move.Destination)
+		})
+	}
+}
@@ -0,0 +1,214 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+const commonIndexSetMarker = "_common"
+
+// tierLocation is an object store holding chunks of a period.
+type tierLocation struct {
+	objectType   string
+	objectClient client.ObjectClient
+	chunkClient  client.Client
+	encoder      client.KeyEncoder
+}
+
+// storageTierMover moves the chunks of a period to its storage tiers once they are
+// older than the delay of the tier. Chunks are copied to the tier before being
+// deleted from the lower ones, so they can always be read from one of them.
+//
+// The index does not record the location of chunks: readers resolve it from the
+// age of the chunks and fall back to the other tiers, see fetcher.NewTieredClient.
+type storageTierMover struct {
+	schemaConfig config.SchemaConfig
+	period       config.PeriodConfig
+	limits       fetcher.TierLimits
+	parallelism  int
+
+	// locations[0] is the object_store of the period and locations[i+1] the storage tier i.
+	locations []tierLocation
+
+	// markersDir holds per table and index set the highest tier all its chunks were moved to.
+	markersDir string
+
+	movedChunks *prometheus.CounterVec
+	now         func() time.Time
+}
+
+func newStorageTierMover(workingDirectory string, schemaConfig config.SchemaConfig, period config.PeriodConfig, locations []tierLocation, limits fetcher.TierLimits, parallelism int, r prometheus.Registerer) (*storageTierMover, error) {
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+
+	return &storageTierMover{
+		schemaConfig: schemaConfig,
+		period:       period,
+		limits:       limits,
+		parallelism:  parallelism,
+		locations:    locations,
+		markersDir:   workingDirectory,
+		movedChunks: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "storage_tier_moved_chunks_total",
+			Help:      "Total number of chunks moved to a storage tier.",
+		}, []string{"object_store"}),
+		now: time.Now,
+	}, nil
+}
+
+// pending returns true if chunks of the index set of the table may have to be moved.
+func (m *storageTierMover) pending(tableName, userID string) bool {
+	done := m.doneTier(tableName, userID)
+	if done == len(m.period.StorageTiers)-1 {
+		return false
+	}
+
+	// chunks of a table end after its start, so the newest chunks of a table can only be moved to the tiers
+	// whose delay elapsed since the start of the table.
+	interval := retention.ExtractIntervalFromTableName(tableName)
+	return fetcher.StorageTierFor(m.period, m.limits, userID, interval.Start, m.now()) > done
+}
+
+type chunkMove struct {
+	userID  string
+	chunkID string
+	tier    int
+}
+
+// moveChunks moves the chunks of the index set of the table to their storage tier.
+func (m *storageTierMover) moveChunks(ctx context.Context, tableName, userID string, chunks retention.ChunkIterator, logger log.Logger) error {
+	var (
+		now     = m.now()
+		moves   []chunkMove
+		minTier = len(m.period.StorageTiers)
+	)
+	err := chunks.ForEachChunk(ctx, func(c retention.ChunkEntry) (bool, error) {
+		tier := fetcher.StorageTierFor(m.period, m.limits, string(c.UserID), c.Through, now)
+		minTier = min(minTier, tier)
+		if tier >= 0 {
+			moves = append(moves, chunkMove{userID: string(c.UserID), chunkID: string(c.ChunkID), tier: tier})
+		}
+		return false, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = concurrency.ForEachJob(ctx, len(moves), m.parallelism, func(ctx context.Context, idx int) error {
+		return m.move(ctx, moves[idx])
+	})
+	if err != nil {
+		return err
+	}
+
+	level.Info(logger).Log("msg", "moved chunks to storage tiers", "chunks", len(moves))
+	if minTier < 0 || minTier == len(m.period.StorageTiers) {
+		return nil
+	}
+	return m.setDoneTier(tableName, userID, minTier)
+}
+
+func (m *storageTierMover) move(ctx context.Context, mv chunkMove) error {
+	chk, err := chunk.ParseExternalKey(mv.userID, mv.chunkID)
+	if err != nil {
+		return err
+	}
+
+	dst := m.locations[mv.tier+1]
+	exists, err := dst.objectClient.ObjectExists(ctx, m.objectKey(dst, chk))
+	if err != nil {
+		return err
+	}
+	if !exists {
+		copied, err := m.copyFromLowerTiers(ctx, chk, mv.tier)
+		if err != nil || !copied {
+			// a chunk missing from all the lower tiers was deleted or already moved to a higher tier.
+			return err
+		}
+		m.movedChunks.WithLabelValues(dst.objectType).Inc()
+	}
+
+	for _, src := range m.locations[:mv.tier+1] {
+		if err := src.chunkClient.DeleteChunk(ctx, mv.userID, mv.chunkID); err != nil && !src.chunkClient.IsChunkNotFoundErr(err) {
+			return fmt.Errorf("deleting chunk %s moved to %s from %s: %w", mv.chunkID, dst.objectType, src.objectType, err)
+		}
+	}
+	return nil
+}
+
+// copyFromLowerTiers copies the chunk from the highest tier below the given one holding it.
+func (m *storageTierMover) copyFromLowerTiers(ctx context.Context, chk chunk.Chunk, tier int) (bool, error) {
+	dst := m.locations[tier+1]
+	for i := tier; i >= 0; i-- {
+		src := m.locations[i]
+		chunks, err := src.chunkClient.GetChunks(ctx, []chunk.Chunk{chk})
+		if err != nil {
+			if src.chunkClient.IsChunkNotFoundErr(err) {
+				continue
+			}
+			return false, err
+		}
+		if err := dst.chunkClient.PutChunks(ctx, chunks); err != nil {
+			return false, fmt.Errorf("copying chunk to %s: %w", dst.objectType, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (m *storageTierMover) objectKey(loc tierLocation, chk chunk.Chunk) string {
+	if loc.encoder != nil {
+		return loc.encoder(m.schemaConfig, chk)
+	}
+	return m.schemaConfig.ExternalKey(chk.ChunkRef)
+}
+
+func (m *storageTierMover) markerPath(tableName, userID string) string {
+	if userID == "" {
+		userID = commonIndexSetMarker
+	}
+	return filepath.Join(m.markersDir, tableName, userID)
+}
+
+// doneTier returns the highest tier all the chunks of the index set of the table were moved to,
+// or -1 if none.
+func (m *storageTierMover) doneTier(tableName, userID string) int {
+	b, err := os.ReadFile(m.markerPath(tableName, userID))
+	if err != nil {
+		return -1
+	}
+	objectType := strings.TrimSpace(string(b))
+	for i, t := range m.period.StorageTiers {
+		if t.ObjectType == objectType {
+			return i
+		}
+	}
+	return -1
+}
+
+func (m *storageTierMover) setDoneTier(tableName, userID string, tier int) error {
+	path := m.markerPath(tableName, userID)
+	if err := chunk_util.EnsureDirectory(filepath.Dir(path)); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(m.period.StorageTiers[tier].ObjectType), 0o640)
+}
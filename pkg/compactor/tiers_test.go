@@ -0,0 +1,84 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+type chunkEntries []retention.ChunkEntry
+
+func (c chunkEntries) ForEachChunk(_ context.Context, callback retention.ChunkEntryCallback) error {
+	for _, entry := range c {
+		if _, err := callback(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestStorageTierMover(t *testing.T) {
+	now := time.Now()
+	schemaCfg := testutils.DefaultSchemaConfig("inmemory")
+	period := schemaCfg.Configs[0]
+	period.StorageTiers = []config.StorageTier{{After: model.Duration(24 * time.Hour), ObjectType: "cold"}}
+
+	hot := newTierLocation("inmemory", testutils.NewInMemoryObjectClient(), schemaCfg)
+	cold := newTierLocation("cold", testutils.NewInMemoryObjectClient(), schemaCfg)
+	mover, err := newStorageTierMover(t.TempDir(), schemaCfg, period, []tierLocation{hot, cold}, nil, 2, prometheus.NewRegistry())
+	require.NoError(t, err)
+	mover.now = func() time.Time { return now }
+
+	putChunk := func(through time.Time) retention.ChunkEntry {
+		chk := testutils.DummyChunkFor(model.TimeFromUnixNano(through.Add(-time.Hour).UnixNano()), model.TimeFromUnixNano(through.UnixNano()), labels.FromStrings("foo", "bar"))
+		require.NoError(t, hot.chunkClient.PutChunks(context.Background(), []chunk.Chunk{chk}))
+		return retention.ChunkEntry{ChunkRef: retention.ChunkRef{
+			UserID:  []byte(chk.UserID),
+			ChunkID: []byte(schemaCfg.ExternalKey(chk.ChunkRef)),
+			From:    chk.From,
+			Through: chk.Through,
+		}}
+	}
+	inTier := func(loc tierLocation, entry retention.ChunkEntry) bool {
+		chk, err := chunk.ParseExternalKey(string(entry.UserID), string(entry.ChunkID))
+		require.NoError(t, err)
+		_, err = loc.chunkClient.GetChunks(context.Background(), []chunk.Chunk{chk})
+		if loc.chunkClient.IsChunkNotFoundErr(err) {
+			return false
+		}
+		require.NoError(t, err)
+		return true
+	}
+
+	tableName := fmt.Sprintf("index_%d", now.Add(-72*time.Hour).Unix()/86400)
+	require.True(t, mover.pending(tableName, "fake"))
+
+	old, recent := putChunk(now.Add(-48*time.Hour)), putChunk(now.Add(-time.Hour))
+	require.NoError(t, mover.moveChunks(context.Background(), tableName, "fake", chunkEntries{old, recent}, util_log.Logger))
+	require.True(t, inTier(cold, old))
+	require.False(t, inTier(hot, old))
+	require.True(t, inTier(hot, recent))
+	require.False(t, inTier(cold, recent))
+	// the recent chunk still has to be moved.
+	require.True(t, mover.pending(tableName, "fake"))
+
+	// moving chunks again is a no-op.
+	require.NoError(t, mover.moveChunks(context.Background(), tableName, "fake", chunkEntries{old}, util_log.Logger))
+	require.True(t, inTier(cold, old))
+	require.False(t, mover.pending(tableName, "fake"))
+
+	// tables without chunks old enough to be moved are skipped.
+	require.False(t, mover.pending(fmt.Sprintf("index_%d", now.Unix()/86400), "fake"))
+}
@@ -0,0 +1,502 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compactor/tokenindex"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+const tokenIndexMetaFile = "meta.json"
+
+// tokenIndexMeta describes the token index of a tenant in a table. It is written after the shards,
+// once the token index of the tenant is complete.
+type tokenIndexMeta struct {
+	// Sources are the index files of the tenant the token index was built from.
+	Sources []string  `json:"sources"`
+	Version int       `json:"version"`
+	Keys    []string  `json:"keys"`
+	Shards  int       `json:"shards"`
+	Chunks  int       `json:"chunks"`
+	BuiltAt time.Time `json:"built_at"`
+}
+
+func (m *tokenIndexMeta) upToDate(sources, keys []string, shards int) bool {
+	return m != nil && slices.Equal(m.Sources, sources) && m.reusable(keys, shards)
+}
+
+// reusable returns whether the tokens of the shards of the token index can be reused with the keys and number of shards.
+// The shards of another encoding version are rebuilt.
+func (m *tokenIndexMeta) reusable(keys []string, shards int) bool {
+	return m != nil && m.Version == tokenindex.ShardVersion && slices.Equal(m.Keys, keys) && m.Shards == shards
+}
+
+type tokenIndexMetrics struct {
+	updatedTenants *prometheus.CounterVec
+	indexedChunks  prometheus.Counter
+}
+
+func newTokenIndexMetrics(r prometheus.Registerer) *tokenIndexMetrics {
+	return &tokenIndexMetrics{
+		updatedTenants: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "token_index_updates_total",
+			Help:      "Total number of updates of the token index of a tenant in a table by status.",
+		}, []string{"status"}),
+		indexedChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "token_index_chunks_read_total",
+			Help:      "Total number of chunks read to update the token index.",
+		}),
+	}
+}
+
+// TokenIndexBuilder builds the token index of the compacted tables of the periods using the tsdb index.
+//
+// The token index of each tenant in a table is updated when its index files changed since the last update.
+// Only the chunks which were not indexed yet are read, the tokens of the other chunks are copied from the
+// previous shards. Chunks are indexed in the table of their start, which is the table the token index is
+// looked up in.
+type TokenIndexBuilder struct {
+	cfg              tokenindex.Config
+	objectClient     client.ObjectClient
+	schemaConfig     config.SchemaConfig
+	storeContainers  map[config.DayTime]storeContainer
+	indexCompactors  map[string]IndexCompactor
+	tableLocker      *tableLocker
+	workingDirectory string
+	metrics          *tokenIndexMetrics
+	logger           log.Logger
+
+	now func() time.Time
+}
+
+// NewTokenIndexBuilder creates a new TokenIndexBuilder writing the token index with the object client.
+func NewTokenIndexBuilder(cfg tokenindex.Config, objectClient client.ObjectClient, c *Compactor, r prometheus.Registerer) (*TokenIndexBuilder, error) {
+	workingDirectory := filepath.Join(c.cfg.WorkingDirectory, "token_index")
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+
+	return &TokenIndexBuilder{
+		cfg:              cfg,
+		objectClient:     objectClient,
+		schemaConfig:     c.schemaConfig,
+		storeContainers:  c.storeContainers,
+		indexCompactors:  c.indexCompactors,
+		tableLocker:      c.tableLocker,
+		workingDirectory: workingDirectory,
+		metrics:          newTokenIndexMetrics(r),
+		logger:           log.With(util_log.Logger, "component", "token-index-builder"),
+		now:              time.Now,
+	}, nil
+}
+
+// Interval returns the interval at which the token index is updated.
+func (b *TokenIndexBuilder) Interval() time.Duration {
+	return b.cfg.Interval
+}
+
+// Run updates the token index of the tables compacted since the last run.
+func (b *TokenIndexBuilder) Run(ctx context.Context) error {
+	seen := map[string]struct{}{}
+	for _, sc := range b.storeContainers {
+		tables, err := sc.indexStorageClient.ListTables(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list tables: %w", err)
+		}
+		SortTablesByRange(tables)
+
+		for _, table := range tables {
+			if _, ok := seen[table]; ok {
+				continue
+			}
+			seen[table] = struct{}{}
+
+			period, ok := SchemaPeriodForTable(b.schemaConfig, table)
+			if !ok || period.IndexType != types.TSDBType {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := b.updateTable(ctx, period, table); err != nil {
+				level.Error(b.logger).Log("msg", "failed to update token index of table", "table", table, "err", err)
+			}
+		}
+	}
+	return b.deleteRemovedTables(ctx, seen)
+}
+
+// updateTable updates the token index of the tenants of the table, unless the table was not compacted yet.
+func (b *TokenIndexBuilder) updateTable(ctx context.Context, period config.PeriodConfig, table string) error {
+	sc, ok := b.storeContainers[period.From]
+	if !ok {
+		return fmt.Errorf("no object store for the period of table %s", table)
+	}
+	indexCompactor, ok := b.indexCompactors[period.IndexType]
+	if !ok {
+		return fmt.Errorf("index processor not found for index type %s", period.IndexType)
+	}
+
+	for {
+		locked, lockWaiterChan := b.tableLocker.lockTable(table)
+		if locked {
+			break
+		}
+		select {
+		case <-lockWaiterChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer b.tableLocker.unlockTable(table)
+
+	commonFiles, tenants, err := sc.indexStorageClient.ListFiles(ctx, table, true)
+	if err != nil {
+		return err
+	}
+	if len(commonFiles) > 0 {
+		return nil
+	}
+
+	for _, tenant := range tenants {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger := log.With(b.logger, "table", table, "tenant", tenant)
+		start := time.Now()
+
+		chunks, updated, err := b.updateTenant(ctx, period, sc, indexCompactor, table, tenant, logger)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			level.Error(logger).Log("msg", "failed to update token index", "err", err)
+			b.metrics.updatedTenants.WithLabelValues("failure").Inc()
+		case updated:
+			level.Info(logger).Log("msg", "updated token index", "chunks", chunks, "duration", time.Since(start))
+			b.metrics.updatedTenants.WithLabelValues("success").Inc()
+		}
+	}
+	return b.deleteRemovedTenants(ctx, table, tenants)
+}
+
+// updateTenant updates the token index of the tenant in the table if its index files changed since the last update.
+// It returns the number of indexed chunks and whether the token index was updated.
+func (b *TokenIndexBuilder) updateTenant(ctx context.Context, period config.PeriodConfig, sc storeContainer, indexCompactor IndexCompactor, table, tenant string, logger log.Logger) (int, bool, error) {
+	files, err := sc.indexStorageClient.ListUserFiles(ctx, table, tenant, true)
+	if err != nil {
+		return 0, false, err
+	}
+	sources := make([]string, 0, len(files))
+	for _, file := range files {
+		sources = append(sources, file.Name)
+	}
+	sort.Strings(sources)
+
+	prefix := tokenindex.TenantPrefix(b.cfg.PathPrefix, table, tenant)
+	meta, err := b.readMeta(ctx, prefix)
+	if err != nil {
+		return 0, false, err
+	}
+	if meta.upToDate(sources, b.cfg.Keys, b.cfg.Shards) {
+		return 0, false, nil
+	}
+
+	workingDir := filepath.Join(b.workingDirectory, table, tenant)
+	if err := chunk_util.EnsureDirectory(workingDir); err != nil {
+		return 0, false, err
+	}
+	defer os.RemoveAll(workingDir)
+
+	var chunks []chunk.Chunk
+	seen := map[tokenindex.ChunkRef]struct{}{}
+	for _, fileName := range sources {
+		fileChunks, err := chunksOfIndexFile(ctx, logger, tenant, period, sc, indexCompactor, table, fileName, workingDir, nil)
+		if err != nil {
+			return 0, false, err
+		}
+		for _, c := range fileChunks {
+			ref := tokenindex.RefOf(&c.ChunkRef)
+			if _, ok := seen[ref]; ok || b.lookupTable(c) != table {
+				continue
+			}
+			seen[ref] = struct{}{}
+			chunks = append(chunks, c)
+		}
+	}
+
+	shards := make([]*tokenindex.Shard, b.cfg.Shards)
+	for i := range shards {
+		shards[i] = tokenindex.NewShard(b.cfg.Keys)
+	}
+
+	// copy the tokens of the chunks indexed by the previous update.
+	var previous []*tokenindex.Shard
+	if meta.reusable(b.cfg.Keys, b.cfg.Shards) {
+		if previous, err = b.readShards(ctx, table, tenant); err != nil {
+			return 0, false, err
+		}
+	}
+	previousTokens := make([]map[tokenindex.ChunkRef][]uint64, len(previous))
+	for i, shard := range previous {
+		if shard != nil {
+			previousTokens[i] = shard.ChunkTokens()
+		}
+	}
+	var pending []chunk.Chunk
+	for _, c := range chunks {
+		ref := tokenindex.RefOf(&c.ChunkRef)
+		shard := tokenindex.ShardOf(ref.Fingerprint, b.cfg.Shards)
+		if previous != nil {
+			if tokens, ok := previousTokens[shard][ref]; ok {
+				shards[shard].Add(ref, tokens)
+				continue
+			}
+		}
+		pending = append(pending, c)
+	}
+
+	tokens := make([][]uint64, len(pending))
+	found := make([]bool, len(pending))
+	err = concurrency.ForEachJob(ctx, len(pending), b.cfg.Parallelism, func(ctx context.Context, idx int) error {
+		var err error
+		tokens[idx], found[idx], err = b.chunkTokens(ctx, sc.locations, pending[idx])
+		return err
+	})
+	if err != nil {
+		return 0, false, err
+	}
+
+	indexed := len(chunks) - len(pending)
+	for i, c := range pending {
+		// missing chunks are not covered, so that they are never filtered.
+		if !found[i] {
+			level.Warn(logger).Log("msg", "chunk not found, not indexing it", "chunk", b.schemaConfig.ExternalKey(c.ChunkRef))
+			continue
+		}
+		ref := tokenindex.RefOf(&c.ChunkRef)
+		shards[tokenindex.ShardOf(ref.Fingerprint, b.cfg.Shards)].Add(ref, tokens[i])
+		indexed++
+	}
+
+	if err := b.writeShards(ctx, table, tenant, shards); err != nil {
+		return 0, false, err
+	}
+	return indexed, true, b.writeMeta(ctx, prefix, tokenIndexMeta{
+		Sources: sources,
+		Version: tokenindex.ShardVersion,
+		Keys:    b.cfg.Keys,
+		Shards:  b.cfg.Shards,
+		Chunks:  indexed,
+		BuiltAt: b.now().UTC(),
+	})
+}
+
+// lookupTable returns the table the token index of the chunk is looked up in.
+func (b *TokenIndexBuilder) lookupTable(c chunk.Chunk) string {
+	period, err := b.schemaConfig.SchemaForTime(c.From)
+	if err != nil {
+		return ""
+	}
+	return period.IndexTables.TableFor(c.From)
+}
+
+// chunkTokens returns the tokens of the indexed keys of the chunk, from its labels and the structured metadata
+// of its entries, and whether the chunk was found in the location holding it.
+func (b *TokenIndexBuilder) chunkTokens(ctx context.Context, locations []tierLocation, c chunk.Chunk) ([]uint64, bool, error) {
+	for _, loc := range locations {
+		chunks, err := loc.chunkClient.GetChunks(ctx, []chunk.Chunk{c})
+		if err != nil {
+			if loc.chunkClient.IsChunkNotFoundErr(err) {
+				continue
+			}
+			return nil, false, err
+		}
+		b.metrics.indexedChunks.Inc()
+
+		fetched := chunks[0]
+		tokens := map[uint64]struct{}{}
+		for _, key := range b.cfg.Keys {
+			if value := fetched.Metric.Get(key); value != "" {
+				tokens[tokenindex.Token(key, value)] = struct{}{}
+			}
+		}
+
+		memChunk, ok := fetched.Data.(*chunkenc.Facade).LokiChunk().(*chunkenc.MemChunk)
+		if !ok {
+			return nil, false, fmt.Errorf("unexpected chunk type %T", fetched.Data.(*chunkenc.Facade).LokiChunk())
+		}
+		// only the structured metadata is read, without decompressing the lines of columnar chunks.
+		err = memChunk.ForEachStructuredMetadata(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), func(_ int64, structuredMetadata labels.Labels) error {
+			structuredMetadata.Range(func(l labels.Label) {
+				if slices.Contains(b.cfg.Keys, l.Name) && l.Value != "" {
+					tokens[tokenindex.Token(l.Name, l.Value)] = struct{}{}
+				}
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, false, err
+		}
+
+		result := make([]uint64, 0, len(tokens))
+		for token := range tokens {
+			result = append(result, token)
+		}
+		return result, true, nil
+	}
+	return nil, false, nil
+}
+
+// readShards reads the shards of the token index of the tenant in the table. Missing shards are nil.
+func (b *TokenIndexBuilder) readShards(ctx context.Context, table, tenant string) ([]*tokenindex.Shard, error) {
+	shards := make([]*tokenindex.Shard, b.cfg.Shards)
+	err := concurrency.ForEachJob(ctx, len(shards), b.cfg.Parallelism, func(ctx context.Context, idx int) error {
+		rc, _, err := b.objectClient.GetObject(ctx, tokenindex.ShardPath(b.cfg.PathPrefix, table, tenant, idx, b.cfg.Shards))
+		if err != nil {
+			if b.objectClient.IsObjectNotFoundErr(err) {
+				return nil
+			}
+			return err
+		}
+		defer rc.Close()
+
+		buf, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		shards[idx], err = tokenindex.DecodeShard(buf)
+		return err
+	})
+	return shards, err
+}
+
+// writeShards writes the shards of the token index of the tenant in the table and deletes the shards of
+// a previous number of shards.
+func (b *TokenIndexBuilder) writeShards(ctx context.Context, table, tenant string, shards []*tokenindex.Shard) error {
+	paths := map[string]struct{}{}
+	for i, shard := range shards {
+		path := tokenindex.ShardPath(b.cfg.PathPrefix, table, tenant, i, len(shards))
+		paths[path] = struct{}{}
+		buf := shard.Encode()
+		if err := b.objectClient.PutObject(ctx, path, bytes.NewReader(buf)); err != nil {
+			return err
+		}
+	}
+
+	prefix := tokenindex.TenantPrefix(b.cfg.PathPrefix, table, tenant)
+	objects, _, err := b.objectClient.List(ctx, prefix, "")
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if _, ok := paths[object.Key]; ok || object.Key == prefix+tokenIndexMetaFile {
+			continue
+		}
+		if err := b.objectClient.DeleteObject(ctx, object.Key); err != nil && !b.objectClient.IsObjectNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRemovedTables deletes the token index of the tables which were deleted, for example by retention.
+func (b *TokenIndexBuilder) deleteRemovedTables(ctx context.Context, tables map[string]struct{}) error {
+	_, prefixes, err := b.objectClient.List(ctx, b.cfg.PathPrefix, "/")
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		table := strings.TrimSuffix(strings.TrimPrefix(string(prefix), b.cfg.PathPrefix), "/")
+		if _, ok := tables[table]; ok {
+			continue
+		}
+		if err := b.deletePrefix(ctx, string(prefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteRemovedTenants deletes the token index of the tenants which have no index in the table anymore.
+func (b *TokenIndexBuilder) deleteRemovedTenants(ctx context.Context, table string, tenants []string) error {
+	tablePrefix := b.cfg.PathPrefix + table + "/"
+	_, prefixes, err := b.objectClient.List(ctx, tablePrefix, "/")
+	if err != nil {
+		return err
+	}
+	for _, prefix := range prefixes {
+		tenant := strings.TrimSuffix(strings.TrimPrefix(string(prefix), tablePrefix), "/")
+		if slices.Contains(tenants, tenant) {
+			continue
+		}
+		if err := b.deletePrefix(ctx, string(prefix)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *TokenIndexBuilder) deletePrefix(ctx context.Context, prefix string) error {
+	objects, _, err := b.objectClient.List(ctx, prefix, "")
+	if err != nil {
+		return err
+	}
+	for _, object := range objects {
+		if err := b.objectClient.DeleteObject(ctx, object.Key); err != nil && !b.objectClient.IsObjectNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *TokenIndexBuilder) readMeta(ctx context.Context, prefix string) (*tokenIndexMeta, error) {
+	rc, _, err := b.objectClient.GetObject(ctx, prefix+tokenIndexMetaFile)
+	if err != nil {
+		if b.objectClient.IsObjectNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	var meta tokenIndexMeta
+	if err := json.NewDecoder(rc).Decode(&meta); err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func (b *TokenIndexBuilder) writeMeta(ctx context.Context, prefix string, meta tokenIndexMeta) error {
+	buf, err := json.Marshal(meta)
+	if err != nil {
+		return err
+	}
+	return b.objectClient.PutObject(ctx, prefix+tokenIndexMetaFile, bytes.NewReader(buf))
+}
@@ -0,0 +1,114 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compactor/tokenindex"
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/ingester/client"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+)
+
+// traceChunk returns a chunk of the source tenant with an entry per trace id in its structured metadata.
+func traceChunk(t *testing.T, from model.Time, lbs labels.Labels, traceIDs ...string) chunk.Chunk {
+	memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256*1024, 0)
+	for i, traceID := range traceIDs {
+		_, err := memChunk.Append(&logproto.Entry{
+			Timestamp:          from.Add(time.Duration(i) * time.Second).Time(),
+			Line:               "line",
+			StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.FromStrings("trace_id", traceID)),
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, memChunk.Close())
+	through := from.Add(time.Duration(len(traceIDs)) * time.Second)
+	c := chunk.NewChunk(moveSource, client.Fingerprint(lbs), lbs, chunkenc.NewFacade(memChunk, 256*1024, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func TestTokenIndexBuilder(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	from := model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	chunks := []chunk.Chunk{
+		traceChunk(t, from, labels.FromStrings("app", "a"), "1", "2"),
+		traceChunk(t, from.Add(time.Hour), labels.FromStrings("app", "b"), "3"),
+		traceChunk(t, from.Add(2*time.Hour), labels.FromStrings("app", "c", "trace_id", "4")),
+	}
+	f.putIndexedChunks(t, "chunks-1", chunks)
+
+	cfg := tokenindex.Config{
+		Enabled:     true,
+		PathPrefix:  "token_index/",
+		Keys:        []string{"trace_id"},
+		Shards:      4,
+		Parallelism: 2,
+		CacheSize:   10,
+		CacheTTL:    time.Minute,
+	}
+	builder, err := NewTokenIndexBuilder(cfg, f.objectClient, f.compactor, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, builder.Run(context.Background()))
+	require.Equal(t, float64(3), testutil.ToFloat64(builder.metrics.indexedChunks))
+
+	filter := func(query string, chunks []chunk.Chunk) []chunk.Chunk {
+		// a new querier does not cache the shards of the previous updates.
+		querier, err := tokenindex.NewQuerier(cfg, f.objectClient, f.schemaConfig, prometheus.NewRegistry(), log.NewNopLogger())
+		require.NoError(t, err)
+
+		expr, err := syntax.ParseExpr(query)
+		require.NoError(t, err)
+		filtered, err := querier.FilterChunks(context.Background(), moveSource, expr, append([]chunk.Chunk(nil), chunks...))
+		require.NoError(t, err)
+		return filtered
+	}
+	require.Equal(t, chunks[:1], filter(`{app=~".+"} | trace_id="2"`, chunks))
+	require.Equal(t, chunks[1:2], filter(`{app=~".+"} | trace_id="3"`, chunks))
+	require.Equal(t, chunks[2:], filter(`{app=~".+"} | trace_id="4"`, chunks))
+	require.Empty(t, filter(`{app=~".+"} | trace_id="5"`, chunks))
+	require.Equal(t, chunks, filter(`{app=~".+"} | span_id="5"`, chunks))
+
+	// the chunks which are not indexed yet are kept.
+	added := traceChunk(t, from.Add(3*time.Hour), labels.FromStrings("app", "d"), "5")
+	require.Equal(t, []chunk.Chunk{added}, filter(`{app=~".+"} | trace_id="3"`, []chunk.Chunk{added}))
+
+	// the next compaction replaces the index file, only the new chunk is read by the update.
+	require.NoError(t, f.indexStorageClient.DeleteUserFile(context.Background(), f.table, moveSource, "chunks-1"))
+	f.putIndexedChunks(t, "chunks-2", append(chunks[1:], added))
+	require.NoError(t, builder.Run(context.Background()))
+	require.Equal(t, float64(4), testutil.ToFloat64(builder.metrics.indexedChunks))
+
+	require.Equal(t, []chunk.Chunk{added}, filter(`{app=~".+"} | trace_id="5"`, append(chunks[1:], added)))
+	require.Empty(t, filter(`{app=~".+"} | trace_id="3"`, []chunk.Chunk{added}))
+
+	// nothing changed since the last update.
+	require.NoError(t, builder.Run(context.Background()))
+	require.Equal(t, float64(2), testutil.ToFloat64(builder.metrics.updatedTenants.WithLabelValues("success")))
+}
+
+func TestTokenIndexBuilder_SkipsUncompactedTables(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	f.putChunks(t, 1)
+	require.NoError(t, f.indexStorageClient.PutFile(context.Background(), f.table, "multi-tenant", bytes.NewReader([]byte("index"))))
+
+	builder, err := NewTokenIndexBuilder(tokenindex.Config{Enabled: true, PathPrefix: "token_index/", Keys: []string{"trace_id"}, Shards: 1, Parallelism: 1}, f.objectClient, f.compactor, prometheus.NewRegistry())
+	require.NoError(t, err)
+	require.NoError(t, builder.Run(context.Background()))
+
+	objects, _, err := f.objectClient.List(context.Background(), "token_index/", "")
+	require.NoError(t, err)
+	require.Empty(t, objects)
+}
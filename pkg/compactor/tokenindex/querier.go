@@ -0,0 +1,350 @@
+package tokenindex
+
+import (
+	"context"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/concurrency"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	v1 "github.com/grafana/loki/v3/pkg/storage/bloom/v1"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+)
+
+const (
+	resultKept       = "kept"
+	resultFiltered   = "filtered"
+	resultNotIndexed = "not_indexed"
+)
+
+type querierMetrics struct {
+	chunkRefs   *prometheus.CounterVec
+	shardLoads  *prometheus.CounterVec
+	shardsBytes prometheus.Counter
+}
+
+func newQuerierMetrics(r prometheus.Registerer) *querierMetrics {
+	return &querierMetrics{
+		chunkRefs: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "token_index",
+			Name:      "chunk_refs_total",
+			Help:      "Total number of chunk refs tested against the token index by result.",
+		}, []string{"result"}),
+		shardLoads: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "token_index",
+			Name:      "shard_loads_total",
+			Help:      "Total number of shards of the token index read from the object store by status.",
+		}, []string{"status"}),
+		shardsBytes: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki",
+			Subsystem: "token_index",
+			Name:      "shard_bytes_total",
+			Help:      "Total number of bytes of the shards of the token index read from the object store.",
+		}),
+	}
+}
+
+type cachedShard struct {
+	// shard is nil if the token index has no such shard.
+	shard    *Shard
+	loadedAt time.Time
+}
+
+// Querier filters chunk refs with the token index. Chunks are only dropped when they are covered by
+// the token index and do not contain the values of the label filters of the query, so that the chunks
+// which are not indexed yet are always kept.
+type Querier struct {
+	cfg          Config
+	objectClient client.ObjectClient
+	schemaConfig config.SchemaConfig
+	cache        *lru.Cache[string, cachedShard]
+	metrics      *querierMetrics
+	logger       log.Logger
+
+	now func() time.Time
+}
+
+// NewQuerier creates a new Querier.
+func NewQuerier(cfg Config, objectClient client.ObjectClient, schemaConfig config.SchemaConfig, r prometheus.Registerer, logger log.Logger) (*Querier, error) {
+	cache, err := lru.New[string, cachedShard](cfg.CacheSize)
+	if err != nil {
+		return nil, err
+	}
+	return &Querier{
+		cfg:          cfg,
+		objectClient: objectClient,
+		schemaConfig: schemaConfig,
+		cache:        cache,
+		metrics:      newQuerierMetrics(r),
+		logger:       logger,
+		now:          time.Now,
+	}, nil
+}
+
+// FilterChunkRefs returns the chunk refs which may contain the values of the label filters of the expression.
+func (q *Querier) FilterChunkRefs(ctx context.Context, tenant string, expr syntax.Expr, refs []*logproto.ChunkRef) ([]*logproto.ChunkRef, error) {
+	keep, err := q.filter(ctx, tenant, expr, len(refs), func(i int) *logproto.ChunkRef { return refs[i] })
+	if err != nil || keep == nil {
+		return refs, err
+	}
+
+	filtered := refs[:0]
+	for i, ref := range refs {
+		if keep[i] {
+			filtered = append(filtered, ref)
+		}
+	}
+	return filtered, nil
+}
+
+// FilterChunks returns the chunks which may contain the values of the label filters of the expression.
+func (q *Querier) FilterChunks(ctx context.Context, tenant string, expr syntax.Expr, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	keep, err := q.filter(ctx, tenant, expr, len(chunks), func(i int) *logproto.ChunkRef { return &chunks[i].ChunkRef })
+	if err != nil || keep == nil {
+		return chunks, err
+	}
+
+	filtered := chunks[:0]
+	for i, c := range chunks {
+		if keep[i] {
+			filtered = append(filtered, c)
+		}
+	}
+	return filtered, nil
+}
+
+// filter returns whether each of the n chunk refs must be kept, or nil if the expression has no label filter of the indexed keys.
+func (q *Querier) filter(ctx context.Context, tenant string, expr syntax.Expr, n int, ref func(int) *logproto.ChunkRef) ([]bool, error) {
+	var matchers []v1.LabelMatcher
+	for _, m := range v1.ExtractTestableLabelMatchers(expr) {
+		if q.indexed(m) {
+			matchers = append(matchers, m)
+		}
+	}
+	if len(matchers) == 0 || n == 0 {
+		return nil, nil
+	}
+
+	paths := make([]string, n)
+	var missing []string
+	for i := 0; i < n; i++ {
+		r := ref(i)
+		period, err := q.schemaConfig.SchemaForTime(r.From)
+		if err != nil || period.IndexType != types.TSDBType {
+			continue
+		}
+		paths[i] = ShardPath(q.cfg.PathPrefix, period.IndexTables.TableFor(r.From), tenant, ShardOf(r.FingerprintModel(), q.cfg.Shards), q.cfg.Shards)
+		if _, ok := q.cached(paths[i]); !ok {
+			missing = append(missing, paths[i])
+		}
+	}
+	if err := q.load(ctx, missing); err != nil {
+		return nil, err
+	}
+
+	// the chunks matching the matchers are looked up once per shard.
+	matching := map[*Shard][]postings{}
+	keep := make([]bool, n)
+	var kept, notIndexed int
+	for i := range keep {
+		var shard *Shard
+		if paths[i] != "" {
+			shard, _ = q.cached(paths[i])
+		}
+		var id uint32
+		var covered bool
+		if shard != nil {
+			id, covered = shard.ID(RefOf(ref(i)))
+		}
+		if !covered {
+			keep[i] = true
+			notIndexed++
+			continue
+		}
+
+		p, ok := matching[shard]
+		if !ok {
+			p = make([]postings, len(matchers))
+			for j, m := range matchers {
+				p[j] = matchingPostings(m, shard)
+			}
+			matching[shard] = p
+		}
+		keep[i] = true
+		for _, m := range p {
+			if !m.contains(id) {
+				keep[i] = false
+				break
+			}
+		}
+		if keep[i] {
+			kept++
+		}
+	}
+	q.metrics.chunkRefs.WithLabelValues(resultKept).Add(float64(kept))
+	q.metrics.chunkRefs.WithLabelValues(resultNotIndexed).Add(float64(notIndexed))
+	q.metrics.chunkRefs.WithLabelValues(resultFiltered).Add(float64(n - kept - notIndexed))
+	return keep, nil
+}
+
+// indexed returns whether the matcher can drop chunks.
+func (q *Querier) indexed(m v1.LabelMatcher) bool {
+	switch m := m.(type) {
+	case v1.KeyValueMatcher:
+		// an empty value also matches the entries without the key.
+		return m.Value != "" && indexesKey(q.cfg.Keys, m.Key)
+	case v1.OrLabelMatcher:
+		return q.indexed(m.Left) && q.indexed(m.Right)
+	case v1.AndLabelMatcher:
+		return q.indexed(m.Left) || q.indexed(m.Right)
+	default:
+		return false
+	}
+}
+
+// postings are the sorted IDs of the chunks of a shard which may match a matcher, or all the chunks of the shard.
+type postings struct {
+	all bool
+	ids []uint32
+}
+
+func (p postings) contains(id uint32) bool {
+	if p.all {
+		return true
+	}
+	_, found := slices.BinarySearch(p.ids, id)
+	return found
+}
+
+// matchingPostings returns the chunks of the shard which may match the matcher.
+func matchingPostings(m v1.LabelMatcher, shard *Shard) postings {
+	switch m := m.(type) {
+	case v1.KeyValueMatcher:
+		if m.Value == "" || !indexesKey(shard.Keys, m.Key) {
+			return postings{all: true}
+		}
+		return postings{ids: shard.Postings(Token(m.Key, m.Value))}
+	case v1.OrLabelMatcher:
+		left, right := matchingPostings(m.Left, shard), matchingPostings(m.Right, shard)
+		if left.all || right.all {
+			return postings{all: true}
+		}
+		return postings{ids: union(left.ids, right.ids)}
+	case v1.AndLabelMatcher:
+		left, right := matchingPostings(m.Left, shard), matchingPostings(m.Right, shard)
+		switch {
+		case left.all:
+			return right
+		case right.all:
+			return left
+		}
+		return postings{ids: intersect(left.ids, right.ids)}
+	default:
+		return postings{all: true}
+	}
+}
+
+// union returns the sorted IDs of both sorted lists.
+func union(a, b []uint32) []uint32 {
+	res := make([]uint32, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			res, a = append(res, a[0]), a[1:]
+		case a[0] > b[0]:
+			res, b = append(res, b[0]), b[1:]
+		default:
+			res, a, b = append(res, a[0]), a[1:], b[1:]
+		}
+	}
+	return append(append(res, a...), b...)
+}
+
+// intersect returns the sorted IDs present in both sorted lists.
+func intersect(a, b []uint32) []uint32 {
+	var res []uint32
+	for len(a) > 0 && len(b) > 0 {
+		switch {
+		case a[0] < b[0]:
+			a = a[1:]
+		case a[0] > b[0]:
+			b = b[1:]
+		default:
+			res, a, b = append(res, a[0]), a[1:], b[1:]
+		}
+	}
+	return res
+}
+
+// cached returns the shard of the path from the cache, which is nil if the token index has no such shard.
+func (q *Querier) cached(path string) (*Shard, bool) {
+	s, ok := q.cache.Get(path)
+	if !ok || q.now().Sub(s.loadedAt) > q.cfg.CacheTTL {
+		return nil, false
+	}
+	return s.shard, true
+}
+
+// load reads the shards of the paths from the object store into the cache. Shards which can't be read
+// are logged and not cached, so that their chunks are kept and the queries do not fail.
+func (q *Querier) load(ctx context.Context, paths []string) error {
+	seen := make(map[string]struct{}, len(paths))
+	var pending []string
+	for _, path := range paths {
+		if _, ok := seen[path]; !ok {
+			seen[path] = struct{}{}
+			pending = append(pending, path)
+		}
+	}
+
+	return concurrency.ForEachJob(ctx, len(pending), q.cfg.Parallelism, func(ctx context.Context, idx int) error {
+		shard, err := q.readShard(ctx, pending[idx])
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			level.Warn(q.logger).Log("msg", "failed to read token index shard", "path", pending[idx], "err", err)
+			q.metrics.shardLoads.WithLabelValues("failure").Inc()
+			return nil
+		}
+
+		status := "success"
+		if shard == nil {
+			status = "not_found"
+		}
+		q.metrics.shardLoads.WithLabelValues(status).Inc()
+		q.cache.Add(pending[idx], cachedShard{shard: shard, loadedAt: q.now()})
+		return nil
+	})
+}
+
+func (q *Querier) readShard(ctx context.Context, path string) (*Shard, error) {
+	rc, _, err := q.objectClient.GetObject(ctx, path)
+	if err != nil {
+		if q.objectClient.IsObjectNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	q.metrics.shardsBytes.Add(float64(len(b)))
+	return DecodeShard(b)
+}
@@ -0,0 +1,76 @@
+package tokenindex
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+)
+
+func TestQuerier_FilterChunkRefs(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	period := config.PeriodConfig{
+		From:      config.DayTime{Time: model.Time(0)},
+		IndexType: types.TSDBType,
+		Schema:    "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: 24 * time.Hour},
+		},
+	}
+	cfg := Config{PathPrefix: "token_index/", Keys: []string{"trace_id"}, Shards: 1, Parallelism: 1, CacheSize: 10, CacheTTL: time.Minute}
+	from := model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+
+	var (
+		withX      = &logproto.ChunkRef{Fingerprint: 1, UserID: "fake", From: from, Through: from.Add(time.Hour), Checksum: 1}
+		withY      = &logproto.ChunkRef{Fingerprint: 2, UserID: "fake", From: from, Through: from.Add(time.Hour), Checksum: 2}
+		notInShard = &logproto.ChunkRef{Fingerprint: 3, UserID: "fake", From: from, Through: from.Add(time.Hour), Checksum: 3}
+		nextDay    = &logproto.ChunkRef{Fingerprint: 1, UserID: "fake", From: from.Add(24 * time.Hour), Through: from.Add(25 * time.Hour), Checksum: 4}
+	)
+	shard := NewShard(cfg.Keys)
+	shard.Add(RefOf(withX), []uint64{Token("trace_id", "x")})
+	shard.Add(RefOf(withY), []uint64{Token("trace_id", "y")})
+	require.NoError(t, objectClient.PutObject(context.Background(), ShardPath(cfg.PathPrefix, period.IndexTables.TableFor(from), "fake", 0, 1), bytes.NewReader(shard.Encode())))
+
+	querier, err := NewQuerier(cfg, objectClient, config.SchemaConfig{Configs: []config.PeriodConfig{period}}, prometheus.NewRegistry(), log.NewNopLogger())
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		query    string
+		expected []*logproto.ChunkRef
+	}{
+		{query: `{app="foo"} | trace_id="x"`, expected: []*logproto.ChunkRef{withX, notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id="z"`, expected: []*logproto.ChunkRef{notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id="x" or trace_id="y"`, expected: []*logproto.ChunkRef{withX, withY, notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id="x" | level="error"`, expected: []*logproto.ChunkRef{withX, notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id="x" | trace_id="y"`, expected: []*logproto.ChunkRef{notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id="x" or trace_id="z" | trace_id="x"`, expected: []*logproto.ChunkRef{withX, notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id=~"x|y"`, expected: []*logproto.ChunkRef{withX, withY, notInShard, nextDay}},
+		// filters which can't be tested keep all the chunks.
+		{query: `{app="foo"} | trace_id=""`, expected: []*logproto.ChunkRef{withX, withY, notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id!="x"`, expected: []*logproto.ChunkRef{withX, withY, notInShard, nextDay}},
+		{query: `{app="foo"} | trace_id="x" or level="error"`, expected: []*logproto.ChunkRef{withX, withY, notInShard, nextDay}},
+		{query: `{app="foo"} | json | trace_id="x"`, expected: []*logproto.ChunkRef{withX, withY, notInShard, nextDay}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			require.NoError(t, err)
+
+			refs, err := querier.FilterChunkRefs(context.Background(), "fake", expr, []*logproto.ChunkRef{withX, withY, notInShard, nextDay})
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, refs)
+		})
+	}
+}
@@ -0,0 +1,234 @@
+package tokenindex
+
+import (
+	"fmt"
+	"hash/crc32"
+	"slices"
+	"sort"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/util/encoding"
+)
+
+const (
+	shardMagic = 0x544B4E49 // "TKNI"
+	// ShardVersion is the version of the encoding of the shards.
+	ShardVersion = 2
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// ChunkRef identifies a chunk of a tenant.
+type ChunkRef struct {
+	Fingerprint model.Fingerprint
+	From        model.Time
+	Through     model.Time
+	Checksum    uint32
+}
+
+// RefOf returns the ChunkRef of the chunk ref.
+func RefOf(ref *logproto.ChunkRef) ChunkRef {
+	return ChunkRef{Fingerprint: model.Fingerprint(ref.Fingerprint), From: ref.From, Through: ref.Through, Checksum: ref.Checksum}
+}
+
+func (r ChunkRef) less(other ChunkRef) bool {
+	if r.Fingerprint != other.Fingerprint {
+		return r.Fingerprint < other.Fingerprint
+	}
+	if r.From != other.From {
+		return r.From < other.From
+	}
+	if r.Through != other.Through {
+		return r.Through < other.Through
+	}
+	return r.Checksum < other.Checksum
+}
+
+// Shard is a shard of the token index of a tenant in a table. It maps the tokens of the values of the
+// indexed keys to the chunks covered by the shard containing them. The chunks which are not covered
+// can't be filtered.
+//
+// Shards are encoded as:
+//
+//	magic (4 bytes) | version (1 byte) | #keys | keys... | #chunks | chunks... | #tokens | tokens... | crc32 (4 bytes)
+//
+// where each chunk is its fingerprint, from, through and checksum, sorted, and each token is delta encoded
+// from the previous one, sorted, followed by the positions of the chunks containing it, delta encoded.
+type Shard struct {
+	// Keys are the keys indexed by the shard.
+	Keys []string
+	// refs are the chunks covered by the shard, their position in refs is their ID in the postings.
+	refs []ChunkRef
+	ids  map[ChunkRef]uint32
+	// postings are the sorted IDs of the chunks containing each token.
+	postings map[uint64][]uint32
+}
+
+// NewShard returns an empty shard indexing the keys.
+func NewShard(keys []string) *Shard {
+	return &Shard{Keys: keys, ids: map[ChunkRef]uint32{}, postings: map[uint64][]uint32{}}
+}
+
+// Add adds the chunk and its tokens to the shard. Chunks added again are ignored.
+func (s *Shard) Add(ref ChunkRef, tokens []uint64) {
+	if _, ok := s.ids[ref]; ok {
+		return
+	}
+	id := uint32(len(s.refs))
+	s.refs = append(s.refs, ref)
+	s.ids[ref] = id
+
+	tokens = slices.Clone(tokens)
+	slices.Sort(tokens)
+	for _, token := range slices.Compact(tokens) {
+		s.postings[token] = append(s.postings[token], id)
+	}
+}
+
+// ID returns the ID of the chunk in the postings and whether the shard covers it.
+func (s *Shard) ID(ref ChunkRef) (uint32, bool) {
+	id, ok := s.ids[ref]
+	return id, ok
+}
+
+// Covers returns whether the shard covers the chunk.
+func (s *Shard) Covers(ref ChunkRef) bool {
+	_, ok := s.ids[ref]
+	return ok
+}
+
+// Len returns the number of chunks covered by the shard.
+func (s *Shard) Len() int {
+	return len(s.refs)
+}
+
+// Postings returns the sorted IDs of the chunks containing the token.
+func (s *Shard) Postings(token uint64) []uint32 {
+	return s.postings[token]
+}
+
+// ChunkTokens returns the tokens of each chunk covered by the shard.
+func (s *Shard) ChunkTokens() map[ChunkRef][]uint64 {
+	tokens := make(map[ChunkRef][]uint64, len(s.refs))
+	for _, ref := range s.refs {
+		tokens[ref] = nil
+	}
+	for token, ids := range s.postings {
+		for _, id := range ids {
+			tokens[s.refs[id]] = append(tokens[s.refs[id]], token)
+		}
+	}
+	return tokens
+}
+
+// Encode encodes the shard.
+func (s *Shard) Encode() []byte {
+	// the chunks are encoded sorted, so their IDs are remapped to their position.
+	order := make([]uint32, len(s.refs))
+	for i := range order {
+		order[i] = uint32(i)
+	}
+	sort.Slice(order, func(i, j int) bool { return s.refs[order[i]].less(s.refs[order[j]]) })
+	remapped := make([]uint32, len(s.refs))
+	for id, old := range order {
+		remapped[old] = uint32(id)
+	}
+
+	tokens := make([]uint64, 0, len(s.postings))
+	for token := range s.postings {
+		tokens = append(tokens, token)
+	}
+	slices.Sort(tokens)
+
+	var enc encoding.Encbuf
+	enc.PutBE32(shardMagic)
+	enc.PutByte(ShardVersion)
+	enc.PutUvarint(len(s.Keys))
+	for _, key := range s.Keys {
+		enc.PutUvarintStr(key)
+	}
+	enc.PutUvarint(len(order))
+	for _, old := range order {
+		ref := s.refs[old]
+		enc.PutBE64(uint64(ref.Fingerprint))
+		enc.PutVarint64(int64(ref.From))
+		enc.PutVarint64(int64(ref.Through))
+		enc.PutBE32(ref.Checksum)
+	}
+
+	enc.PutUvarint(len(tokens))
+	var prevToken uint64
+	ids := make([]uint32, 0, len(order))
+	for _, token := range tokens {
+		enc.PutUvarint64(token - prevToken)
+		prevToken = token
+
+		ids = ids[:0]
+		for _, id := range s.postings[token] {
+			ids = append(ids, remapped[id])
+		}
+		slices.Sort(ids)
+		enc.PutUvarint(len(ids))
+		var prevID uint32
+		for _, id := range ids {
+			enc.PutUvarint32(id - prevID)
+			prevID = id
+		}
+	}
+	enc.PutHash(crc32.New(castagnoliTable))
+	return enc.Get()
+}
+
+// DecodeShard decodes a shard encoded by Encode.
+func DecodeShard(b []byte) (*Shard, error) {
+	dec := encoding.DecWith(b)
+	if err := dec.CheckCrc(castagnoliTable); err != nil {
+		return nil, fmt.Errorf("checking shard checksum: %w", err)
+	}
+	if magic := dec.Be32(); magic != shardMagic {
+		return nil, fmt.Errorf("invalid shard magic number %x", magic)
+	}
+	if version := dec.Byte(); version != ShardVersion {
+		return nil, fmt.Errorf("unsupported shard version %d", version)
+	}
+
+	keys := make([]string, dec.Uvarint())
+	for i := range keys {
+		keys[i] = dec.UvarintStr()
+	}
+	s := NewShard(keys)
+
+	n := dec.Uvarint()
+	for i := 0; i < n && dec.Err() == nil; i++ {
+		ref := ChunkRef{
+			Fingerprint: model.Fingerprint(dec.Be64()),
+			From:        model.Time(dec.Varint64()),
+			Through:     model.Time(dec.Varint64()),
+			Checksum:    dec.Be32(),
+		}
+		s.ids[ref] = uint32(len(s.refs))
+		s.refs = append(s.refs, ref)
+	}
+
+	tokens := dec.Uvarint()
+	var token uint64
+	for i := 0; i < tokens && dec.Err() == nil; i++ {
+		token += dec.Uvarint64()
+		ids := make([]uint32, dec.Uvarint())
+		var id uint32
+		for j := range ids {
+			id += dec.Uvarint32()
+			if int(id) >= len(s.refs) {
+				return nil, fmt.Errorf("decoding shard: chunk ID %d out of range", id)
+			}
+			ids[j] = id
+		}
+		s.postings[token] = ids
+	}
+	if err := dec.Err(); err != nil {
+		return nil, fmt.Errorf("decoding shard: %w", err)
+	}
+	return s, nil
+}
@@ -0,0 +1,81 @@
+package tokenindex
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/sharding"
+)
+
+func TestShard_EncodeDecode(t *testing.T) {
+	shard := NewShard([]string{"trace_id", "request_id"})
+	a := ChunkRef{Fingerprint: 1, From: 1000, Through: 2000, Checksum: 42}
+	b := ChunkRef{Fingerprint: math.MaxUint64, From: -1, Through: 0, Checksum: 7}
+	c := ChunkRef{Fingerprint: 1, From: 500, Through: 600, Checksum: 1}
+	empty := ChunkRef{Fingerprint: 2, From: 3000, Through: 4000}
+	shard.Add(a, []uint64{Token("trace_id", "x"), Token("trace_id", "y"), Token("trace_id", "x")})
+	shard.Add(b, []uint64{math.MaxUint64, 0})
+	shard.Add(c, []uint64{Token("trace_id", "x")})
+	shard.Add(empty, nil)
+
+	decoded, err := DecodeShard(shard.Encode())
+	require.NoError(t, err)
+	require.Equal(t, shard.Keys, decoded.Keys)
+	require.Equal(t, 4, decoded.Len())
+
+	ids := func(refs ...ChunkRef) []uint32 {
+		var res []uint32
+		for _, ref := range refs {
+			id, ok := decoded.ID(ref)
+			require.True(t, ok)
+			res = append(res, id)
+		}
+		slices.Sort(res)
+		return res
+	}
+	require.Equal(t, ids(a, c), decoded.Postings(Token("trace_id", "x")))
+	require.Equal(t, ids(a), decoded.Postings(Token("trace_id", "y")))
+	require.Empty(t, decoded.Postings(Token("trace_id", "z")))
+	require.Empty(t, decoded.Postings(Token("request_id", "x")))
+	require.Equal(t, ids(b), decoded.Postings(0))
+	require.Equal(t, ids(b), decoded.Postings(math.MaxUint64))
+
+	tokens := decoded.ChunkTokens()
+	require.ElementsMatch(t, []uint64{Token("trace_id", "x"), Token("trace_id", "y")}, tokens[a])
+	require.ElementsMatch(t, []uint64{0, math.MaxUint64}, tokens[b])
+	require.Contains(t, tokens, empty)
+	require.Empty(t, tokens[empty])
+
+	require.True(t, decoded.Covers(empty))
+	require.False(t, decoded.Covers(ChunkRef{Fingerprint: 1, From: 1000, Through: 2000, Checksum: 43}))
+}
+
+func TestDecodeShard_Corrupted(t *testing.T) {
+	shard := NewShard([]string{"trace_id"})
+	shard.Add(ChunkRef{Fingerprint: 1}, []uint64{1, 2, 3})
+	b := shard.Encode()
+
+	b[len(b)/2]++
+	_, err := DecodeShard(b)
+	require.Error(t, err)
+
+	_, err = DecodeShard(b[:3])
+	require.Error(t, err)
+}
+
+func TestShardOf(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 16} {
+		shards := sharding.LinearShards(n, 0)
+		for i, shard := range shards {
+			require.Equal(t, i, ShardOf(shard.Bounds.Min, n))
+			if i < n-1 {
+				require.Equal(t, i, ShardOf(shard.Bounds.Max-1, n))
+			}
+		}
+		require.Equal(t, n-1, ShardOf(model.Fingerprint(math.MaxUint64), n))
+	}
+}
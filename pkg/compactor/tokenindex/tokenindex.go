@@ -0,0 +1,109 @@
+// Package tokenindex implements an exact secondary index of the values of structured metadata keys,
+// mapping the hash of each value to the chunks of the series containing it.
+//
+// The index is built by the compactor for each table and tenant, and split in shards by series
+// fingerprint. It is used by the index gateway and the queriers to drop the chunks which can't match
+// the label filters of a query, for example `| trace_id="x"`, before fetching them.
+package tokenindex
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+)
+
+// Config configures the token index.
+type Config struct {
+	Enabled     bool                   `yaml:"enabled"`
+	ObjectStore string                 `yaml:"object_store"`
+	PathPrefix  string                 `yaml:"path_prefix"`
+	Keys        flagext.StringSliceCSV `yaml:"keys"`
+	Shards      int                    `yaml:"shards"`
+	Interval    time.Duration          `yaml:"interval"`
+	Parallelism int                    `yaml:"parallelism"`
+	CacheSize   int                    `yaml:"cache_size"`
+	CacheTTL    time.Duration          `yaml:"cache_ttl"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the token index config.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable the token index. The compactor builds it for the compacted tables of the periods using the tsdb index, and the index gateways and the queriers use it to skip the chunks which can't match the label filters of the indexed keys.")
+	f.StringVar(&cfg.ObjectStore, prefix+"object-store", "", "Store the token index is written to and read from. Either a storage type or a named store.")
+	f.StringVar(&cfg.PathPrefix, prefix+"path-prefix", "token_index/", "Path prefix of the token index.")
+	f.Var(&cfg.Keys, prefix+"keys", "Comma separated list of the structured metadata keys to index, for example trace_id. Stream labels with the same names are indexed too.")
+	f.IntVar(&cfg.Shards, prefix+"shards", 16, "Number of shards of the token index of each table and tenant, by series fingerprint.")
+	f.DurationVar(&cfg.Interval, prefix+"interval", time.Hour, "Interval at which the compactor updates the token index of the tables compacted since the last update.")
+	f.IntVar(&cfg.Parallelism, prefix+"parallelism", 10, "Number of chunks read in parallel by the compactor to update the token index, and of shards read in parallel by the index gateways and the queriers.")
+	f.IntVar(&cfg.CacheSize, prefix+"cache-size", 1000, "Maximum number of shards of the token index kept in memory by the index gateways and the queriers.")
+	f.DurationVar(&cfg.CacheTTL, prefix+"cache-ttl", 10*time.Minute, "Duration after which the shards kept in memory are read again from the object store.")
+}
+
+// Validate validates the token index config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.ObjectStore == "" {
+		return errors.New("token index object_store must be set when the token index is enabled")
+	}
+	if cfg.PathPrefix == "" || !strings.HasSuffix(cfg.PathPrefix, "/") {
+		return errors.New("token index path_prefix must end with a path separator i.e '/'")
+	}
+	if len(cfg.Keys) == 0 {
+		return errors.New("token index keys must be set when the token index is enabled")
+	}
+	if cfg.Shards <= 0 {
+		return errors.New("token index shards must be positive")
+	}
+	if cfg.Parallelism <= 0 {
+		return errors.New("token index parallelism must be positive")
+	}
+	if cfg.CacheSize <= 0 {
+		return errors.New("token index cache_size must be positive")
+	}
+	if cfg.CacheTTL <= 0 {
+		return errors.New("token index cache_ttl must be positive")
+	}
+	return nil
+}
+
+// Token returns the token of the value of the key.
+func Token(key, value string) uint64 {
+	h := xxhash.New()
+	_, _ = h.WriteString(key)
+	_, _ = h.Write([]byte{0xff})
+	_, _ = h.WriteString(value)
+	return h.Sum64()
+}
+
+// ShardOf returns the shard of the series among the given number of shards. The shards split
+// the fingerprint space in equal ranges, like the bounds of sharding.LinearShards.
+func ShardOf(fp model.Fingerprint, shards int) int {
+	if shards < 2 {
+		return 0
+	}
+	return min(int(fp/(model.Fingerprint(math.MaxUint64)/model.Fingerprint(shards))), shards-1)
+}
+
+// TenantPrefix returns the prefix of the objects of the token index of the tenant in the table.
+func TenantPrefix(pathPrefix, table, tenant string) string {
+	return pathPrefix + table + "/" + tenant + "/"
+}
+
+// ShardPath returns the path of the shard of the token index of the tenant in the table.
+func ShardPath(pathPrefix, table, tenant string, shard, shards int) string {
+	return fmt.Sprintf("%sshard-%d-of-%d", TenantPrefix(pathPrefix, table, tenant), shard, shards)
+}
+
+// indexesKey returns whether the keys contain the key.
+func indexesKey(keys []string, key string) bool {
+	return slices.Contains(keys, key)
+}
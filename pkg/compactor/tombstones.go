@@ -0,0 +1,407 @@
+package compactor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+type tombstonesMetrics struct {
+	updatedTenants *prometheus.CounterVec
+	foldedChunks   prometheus.Counter
+}
+
+func newTombstonesMetrics(r prometheus.Registerer) *tombstonesMetrics {
+	return &tombstonesMetrics{
+		updatedTenants: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "tombstones_updates_total",
+			Help:      "Total number of updates of the tombstones of a tenant in a table by status.",
+		}, []string{"status"}),
+		foldedChunks: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: "loki_compactor",
+			Name:      "tombstones_folded_chunks_total",
+			Help:      "Total number of tombstoned chunks removed from the index and marked for deletion.",
+		}),
+	}
+}
+
+// pendingDeleteRequests returns the delete requests to be processed by the next retention.
+type pendingDeleteRequests interface {
+	PendingRequests() ([]deletion.DeleteRequest, error)
+}
+
+// tombstoner writes the tombstones of the series whose chunks in a compacted table are all expired by the
+// retention or fully deleted by delete requests, so that the index gateways and the queriers skip them
+// before the index is rebuilt.
+//
+// Only the compacted tables of the periods using the tsdb index are considered. The tombstones of a tenant
+// in a table are rewritten when they changed, and deleted when the tenant has no removed series anymore.
+// The tombstones of the series which were folded into the index are kept until the index without them
+// reached the index gateways and the queriers, see tombstonesFolder.
+type tombstoner struct {
+	cfg                            tombstones.Config
+	indexUpdatePropagationMaxDelay time.Duration
+	schemaConfig                   config.SchemaConfig
+	storeContainers                map[config.DayTime]storeContainer
+	indexCompactors                map[string]IndexCompactor
+	tableLocker                    *tableLocker
+	retention                      retention.ExpirationChecker
+	deleteRequests                 pendingDeleteRequests
+	legalHolds                     legalHoldsChecker
+	workingDirectory               string
+	metrics                        *tombstonesMetrics
+	logger                         log.Logger
+
+	now func() model.Time
+}
+
+func newTombstoner(cfg tombstones.Config, c *Compactor, indexUpdatePropagationMaxDelay time.Duration, r prometheus.Registerer) (*tombstoner, error) {
+	checker, ok := c.expirationChecker.(*expirationChecker)
+	if !ok {
+		return nil, fmt.Errorf("tombstones require retention to be enabled")
+	}
+	workingDirectory := filepath.Join(c.cfg.WorkingDirectory, "tombstones")
+	if err := chunk_util.EnsureDirectory(workingDirectory); err != nil {
+		return nil, err
+	}
+
+	return &tombstoner{
+		cfg:                            cfg,
+		indexUpdatePropagationMaxDelay: indexUpdatePropagationMaxDelay,
+		schemaConfig:                   c.schemaConfig,
+		storeContainers:                c.storeContainers,
+		indexCompactors:                c.indexCompactors,
+		tableLocker:                    c.tableLocker,
+		retention:                      checker.retentionExpiryChecker,
+		deleteRequests:                 c.deleteRequestsManager,
+		legalHolds:                     checker.legalHolds,
+		workingDirectory:               workingDirectory,
+		metrics:                        newTombstonesMetrics(r),
+		logger:                         log.With(util_log.Logger, "component", "tombstoner"),
+		now:                            model.Now,
+	}, nil
+}
+
+// Interval returns the interval at which the tombstones are updated.
+func (t *tombstoner) Interval() time.Duration {
+	return t.cfg.Interval
+}
+
+// Run updates the tombstones of the compacted tables and deletes the tombstones of the tables which were deleted.
+func (t *tombstoner) Run(ctx context.Context) error {
+	deleteRequests, err := t.deleteRequests.PendingRequests()
+	if err != nil {
+		return fmt.Errorf("failed to get pending delete requests: %w", err)
+	}
+	now := t.now()
+	t.legalHolds.Load(ctx, now)
+
+	for from, sc := range t.storeContainers {
+		tables, err := sc.indexStorageClient.ListTables(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list tables: %w", err)
+		}
+		SortTablesByRange(tables)
+
+		for _, table := range tables {
+			period, ok := SchemaPeriodForTable(t.schemaConfig, table)
+			if !ok || period.From != from || period.IndexType != types.TSDBType {
+				continue
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if err := t.updateTable(ctx, period, sc, table, deleteRequests, now); err != nil {
+				level.Error(t.logger).Log("msg", "failed to update tombstones of table", "table", table, "err", err)
+			}
+		}
+
+		if err := t.deleteRemovedTables(ctx, sc, tables); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateTable updates the tombstones of the tenants of the table, unless the table was not compacted yet.
+func (t *tombstoner) updateTable(ctx context.Context, period config.PeriodConfig, sc storeContainer, table string, deleteRequests []deletion.DeleteRequest, now model.Time) error {
+	indexCompactor, ok := t.indexCompactors[period.IndexType]
+	if !ok {
+		return fmt.Errorf("index processor not found for index type %s", period.IndexType)
+	}
+
+	for {
+		locked, lockWaiterChan := t.tableLocker.lockTable(table)
+		if locked {
+			break
+		}
+		select {
+		case <-lockWaiterChan:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	defer t.tableLocker.unlockTable(table)
+
+	commonFiles, tenants, err := sc.indexStorageClient.ListFiles(ctx, table, true)
+	if err != nil {
+		return err
+	}
+	if len(commonFiles) > 0 {
+		return nil
+	}
+
+	for _, tenant := range tenants {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		logger := log.With(t.logger, "table", table, "tenant", tenant)
+
+		series, updated, err := t.updateTenant(ctx, period, sc, indexCompactor, table, tenant, deleteRequests, now, logger)
+		switch {
+		case ctx.Err() != nil:
+			return ctx.Err()
+		case err != nil:
+			level.Error(logger).Log("msg", "failed to update tombstones", "err", err)
+			t.metrics.updatedTenants.WithLabelValues("failure").Inc()
+		case updated:
+			level.Info(logger).Log("msg", "updated tombstones", "series", series)
+			t.metrics.updatedTenants.WithLabelValues("success").Inc()
+		}
+	}
+
+	// the tenants without index in the table can't have tombstoned series, unless their index was just removed
+	// by folding their tombstones.
+	existing, err := tombstones.Tenants(ctx, sc.indexStorageClient, table)
+	if err != nil {
+		return err
+	}
+	for _, file := range existing {
+		if slices.Contains(tenants, file.Name) || now.Time().Sub(file.ModifiedAt) < t.indexUpdatePropagationMaxDelay {
+			continue
+		}
+		if err := tombstones.Delete(ctx, sc.indexStorageClient, table, file.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateTenant writes the tombstones of the series of the tenant whose chunks in the table are all removed.
+// It returns the number of tombstoned series and whether the tombstones of the tenant were updated.
+func (t *tombstoner) updateTenant(ctx context.Context, period config.PeriodConfig, sc storeContainer, indexCompactor IndexCompactor, table, tenant string, deleteRequests []deletion.DeleteRequest, now model.Time, logger log.Logger) (int, bool, error) {
+	files, err := sc.indexStorageClient.ListUserFiles(ctx, table, tenant, true)
+	if err != nil {
+		return 0, false, err
+	}
+
+	workingDir := filepath.Join(t.workingDirectory, table, tenant)
+	if err := chunk_util.EnsureDirectory(workingDir); err != nil {
+		return 0, false, err
+	}
+	defer os.RemoveAll(workingDir)
+
+	type seriesChunks struct {
+		chunks []tombstones.Chunk
+		live   bool
+	}
+	series := map[model.Fingerprint]*seriesChunks{}
+	for _, file := range files {
+		_, err := chunksOfIndexFile(ctx, logger, tenant, period, sc, indexCompactor, table, file.Name, workingDir, func(compactedIndex CompactedIndex, _ []chunk.Chunk) error {
+			return compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+				chk, err := chunk.ParseExternalKey(tenant, string(ce.ChunkID))
+				if err != nil {
+					return false, err
+				}
+				fp := model.Fingerprint(chk.Fingerprint)
+				s, ok := series[fp]
+				if !ok {
+					s = &seriesChunks{}
+					series[fp] = s
+				}
+				if s.live {
+					return false, nil
+				}
+				if !t.removed(ce, deleteRequests, now) {
+					s.live = true
+					return false, nil
+				}
+				s.chunks = append(s.chunks, tombstones.Chunk{From: chk.From, Through: chk.Through, Checksum: chk.Checksum})
+				return false, nil
+			})
+		})
+		if err != nil {
+			return 0, false, err
+		}
+	}
+
+	updated := tombstones.New()
+	for fp, s := range series {
+		if !s.live && len(s.chunks) > 0 {
+			updated.Add(fp, s.chunks...)
+		}
+	}
+
+	existing, err := tombstones.Read(ctx, sc.indexStorageClient, table, tenant)
+	if err != nil {
+		return 0, false, err
+	}
+	// the series missing from the index were folded, their tombstones are deleted by the compactor
+	// once the index without them has propagated.
+	existing.ForEachSeries(func(fp model.Fingerprint, chunks []tombstones.Chunk) {
+		if _, ok := series[fp]; !ok {
+			updated.Add(fp, chunks...)
+		}
+	})
+	switch {
+	case updated.Len() == 0 && existing == nil:
+		return 0, false, nil
+	case updated.Len() == 0:
+		return 0, true, tombstones.Delete(ctx, sc.indexStorageClient, table, tenant)
+	case existing != nil && existing.Equal(updated):
+		return updated.Len(), false, nil
+	}
+	return updated.Len(), true, tombstones.Write(ctx, sc.indexStorageClient, table, tenant, updated)
+}
+
+// removed returns whether the whole chunk is expired by the retention or deleted by a delete request,
+// and not under a legal hold.
+func (t *tombstoner) removed(ce retention.ChunkEntry, deleteRequests []deletion.DeleteRequest, now model.Time) bool {
+	expired, filterFunc := t.retention.Expired(ce, now)
+	removed := expired && filterFunc == nil
+	for i := 0; !removed && i < len(deleteRequests); i++ {
+		deleted, filterFunc := deleteRequests[i].IsDeleted(ce)
+		removed = deleted && filterFunc == nil
+	}
+	return removed && !t.legalHolds.Held(ce, now)
+}
+
+// deleteRemovedTables deletes the tombstones of the tables which were deleted, for example by retention.
+func (t *tombstoner) deleteRemovedTables(ctx context.Context, sc storeContainer, tables []string) error {
+	tombstonedTables, err := tombstones.Tables(ctx, sc.indexStorageClient)
+	if err != nil {
+		return err
+	}
+	for _, table := range tombstonedTables {
+		if slices.Contains(tables, table) {
+			continue
+		}
+		tenants, err := tombstones.Tenants(ctx, sc.indexStorageClient, table)
+		if err != nil {
+			return err
+		}
+		for _, file := range tenants {
+			if err := tombstones.Delete(ctx, sc.indexStorageClient, table, file.Name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// newFolder returns the folder of the tombstones of the tables of a period whose retention markers
+// are written in retentionWorkDir.
+func (t *tombstoner) newFolder(retentionWorkDir string) *tombstonesFolder {
+	return &tombstonesFolder{
+		retentionWorkDir:               retentionWorkDir,
+		indexUpdatePropagationMaxDelay: t.indexUpdatePropagationMaxDelay,
+		legalHolds:                     t.legalHolds,
+		metrics:                        t.metrics,
+	}
+}
+
+// tombstonesFolder folds the tombstones of the tenants of a table into their compacted index while
+// retention is applied on the table: the tombstoned chunks are removed from the index and marked for deletion.
+//
+// The index gateways and the queriers keep serving the previous index files until they resync, so the
+// tombstones are only deleted once they were folded for longer than the index update propagation delay.
+type tombstonesFolder struct {
+	// retentionWorkDir is the working directory of the retention of the period, holding the markers
+	// of the chunks deleted by the sweeper.
+	retentionWorkDir               string
+	indexUpdatePropagationMaxDelay time.Duration
+	legalHolds                     legalHoldsChecker
+	metrics                        *tombstonesMetrics
+}
+
+// fold removes the tombstoned chunks from the index and returns whether the index is empty or modified,
+// like retention.TableMarker. The chunks put under a legal hold after they were tombstoned are kept.
+func (f *tombstonesFolder) fold(ctx context.Context, userID string, t *tombstones.Tombstones, compactedIndex CompactedIndex) (bool, bool, error) {
+	now := model.Now()
+	markerWriter, err := retention.NewMarkerStorageWriter(f.retentionWorkDir)
+	if err != nil {
+		return false, false, fmt.Errorf("failed to create marker writer: %w", err)
+	}
+
+	type foldedSeries struct {
+		lbls labels.Labels
+		live bool
+	}
+	series := map[string]*foldedSeries{}
+	empty, modified := true, false
+	err = compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		s, ok := series[string(ce.SeriesID)]
+		if !ok {
+			s = &foldedSeries{lbls: ce.Labels.Copy()}
+			series[string(ce.SeriesID)] = s
+		}
+
+		chk, err := chunk.ParseExternalKey(userID, string(ce.ChunkID))
+		if err != nil {
+			return false, err
+		}
+		covered := t.Covers(model.Fingerprint(chk.Fingerprint), tombstones.Chunk{From: chk.From, Through: chk.Through, Checksum: chk.Checksum})
+		if !covered || f.legalHolds.Held(ce, now) {
+			s.live = true
+			empty = false
+			return false, nil
+		}
+
+		// tombstoned chunks are fully removed, they can be deleted right away.
+		if err := markerWriter.Put(ce.ChunkID); err != nil {
+			return false, err
+		}
+		modified = true
+		return true, nil
+	})
+	f.metrics.foldedChunks.Add(float64(markerWriter.Count()))
+	if closeErr := markerWriter.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close marker writer: %w", closeErr)
+	}
+	if err != nil || !modified {
+		return false, false, err
+	}
+	if empty {
+		return true, true, nil
+	}
+
+	for _, s := range series {
+		if s.live {
+			continue
+		}
+		if err := compactedIndex.CleanupSeries([]byte(userID), s.lbls); err != nil {
+			return false, false, err
+		}
+	}
+	return false, true, nil
+}
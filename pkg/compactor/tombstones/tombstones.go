@@ -0,0 +1,269 @@
+// Package tombstones implements the tombstones of the series removed from the TSDB index by retention
+// and delete requests.
+//
+// When all the chunks of a series in a table are expired or deleted, the compactor writes them in the
+// tombstone file of the tenant in the table instead of waiting for the next retention to rebuild the
+// index. The index gateways and the queriers read the tombstone files and skip the tombstoned chunks
+// right away, and the compactor later folds the tombstones into the index files it rebuilds.
+package tombstones
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/util/encoding"
+)
+
+// TableName is the name of the pseudo table holding the tombstone files in the index store.
+// The tombstone file of a tenant in a table is stored at <TableName>/<table>/<tenant>.
+const TableName = "tombstones"
+
+const (
+	tombstonesMagic   = 0x544F4D42 // "TOMB"
+	tombstonesVersion = 1
+)
+
+var castagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+
+// Config configures the tombstones written by the compactor.
+type Config struct {
+	Enabled         bool          `yaml:"enabled"`
+	Interval        time.Duration `yaml:"interval"`
+	RefreshInterval time.Duration `yaml:"refresh_interval"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the tombstones config.
+func (cfg *Config) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Enable the tombstones of the series removed by retention and delete requests from the periods using the tsdb index. The compactor writes the chunks of the series whose chunks in a table are all expired or deleted in tombstone files, which the index gateways and the queriers apply right away, and folds them into the index files it rebuilds. Requires retention to be enabled.")
+	f.DurationVar(&cfg.Interval, prefix+"interval", 5*time.Minute, "Interval at which the compactor updates the tombstones of the compacted tables.")
+	f.DurationVar(&cfg.RefreshInterval, prefix+"refresh-interval", time.Minute, "Interval at which the index gateways and the queriers read again the tombstones of the tables they query.")
+}
+
+// Validate validates the tombstones config.
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.Interval <= 0 {
+		return errors.New("tombstones interval must be positive")
+	}
+	if cfg.RefreshInterval <= 0 {
+		return errors.New("tombstones refresh_interval must be positive")
+	}
+	return nil
+}
+
+// Chunk identifies a tombstoned chunk of a series.
+type Chunk struct {
+	From     model.Time
+	Through  model.Time
+	Checksum uint32
+}
+
+func (c Chunk) compare(other Chunk) int {
+	if c.From != other.From {
+		return cmp.Compare(c.From, other.From)
+	}
+	if c.Through != other.Through {
+		return cmp.Compare(c.Through, other.Through)
+	}
+	return cmp.Compare(c.Checksum, other.Checksum)
+}
+
+// Tombstones are the tombstoned chunks of the series of a tenant in a table.
+//
+// Tombstones are encoded as:
+//
+//	magic (4 bytes) | version (1 byte) | #series | series... | crc32 (4 bytes)
+//
+// where each series is its fingerprint followed by its sorted chunks.
+type Tombstones struct {
+	series map[model.Fingerprint][]Chunk
+}
+
+// New returns empty tombstones.
+func New() *Tombstones {
+	return &Tombstones{series: map[model.Fingerprint][]Chunk{}}
+}
+
+// Add tombstones the chunks of the series.
+func (t *Tombstones) Add(fp model.Fingerprint, chunks ...Chunk) {
+	merged := append(t.series[fp], chunks...)
+	slices.SortFunc(merged, Chunk.compare)
+	t.series[fp] = slices.Compact(merged)
+}
+
+// Covers returns whether the chunk of the series is tombstoned.
+func (t *Tombstones) Covers(fp model.Fingerprint, chk Chunk) bool {
+	if t == nil {
+		return false
+	}
+	_, found := slices.BinarySearchFunc(t.series[fp], chk, Chunk.compare)
+	return found
+}
+
+// HasSeries returns whether some chunks of the series are tombstoned.
+func (t *Tombstones) HasSeries(fp model.Fingerprint) bool {
+	if t == nil {
+		return false
+	}
+	_, ok := t.series[fp]
+	return ok
+}
+
+// ForEachSeries calls f with the fingerprint and the tombstoned chunks of each series.
+func (t *Tombstones) ForEachSeries(f func(fp model.Fingerprint, chunks []Chunk)) {
+	if t == nil {
+		return
+	}
+	for fp, chunks := range t.series {
+		f(fp, chunks)
+	}
+}
+
+// Len returns the number of tombstoned series.
+func (t *Tombstones) Len() int {
+	if t == nil {
+		return 0
+	}
+	return len(t.series)
+}
+
+// Chunks returns the number of tombstoned chunks.
+func (t *Tombstones) Chunks() int {
+	if t == nil {
+		return 0
+	}
+	n := 0
+	for _, chunks := range t.series {
+		n += len(chunks)
+	}
+	return n
+}
+
+// Equal returns whether both tombstones cover the same chunks.
+func (t *Tombstones) Equal(other *Tombstones) bool {
+	if t.Len() != other.Len() {
+		return false
+	}
+	for fp, chunks := range t.series {
+		if !slices.Equal(chunks, other.series[fp]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Encode encodes the tombstones.
+func (t *Tombstones) Encode() []byte {
+	fps := make([]model.Fingerprint, 0, len(t.series))
+	for fp := range t.series {
+		fps = append(fps, fp)
+	}
+	sort.Slice(fps, func(i, j int) bool { return fps[i] < fps[j] })
+
+	var enc encoding.Encbuf
+	enc.PutBE32(tombstonesMagic)
+	enc.PutByte(tombstonesVersion)
+	enc.PutUvarint(len(fps))
+	for _, fp := range fps {
+		enc.PutBE64(uint64(fp))
+		chunks := t.series[fp]
+		enc.PutUvarint(len(chunks))
+		for _, chk := range chunks {
+			enc.PutVarint64(int64(chk.From))
+			enc.PutVarint64(int64(chk.Through))
+			enc.PutBE32(chk.Checksum)
+		}
+	}
+	enc.PutHash(crc32.New(castagnoliTable))
+	return enc.Get()
+}
+
+// Decode decodes tombstones encoded by Encode.
+func Decode(b []byte) (*Tombstones, error) {
+	dec := encoding.DecWith(b)
+	if err := dec.CheckCrc(castagnoliTable); err != nil {
+		return nil, fmt.Errorf("checking tombstones checksum: %w", err)
+	}
+	if magic := dec.Be32(); magic != tombstonesMagic {
+		return nil, fmt.Errorf("invalid tombstones magic number %x", magic)
+	}
+	if version := dec.Byte(); version != tombstonesVersion {
+		return nil, fmt.Errorf("unsupported tombstones version %d", version)
+	}
+
+	t := New()
+	n := dec.Uvarint()
+	for i := 0; i < n && dec.Err() == nil; i++ {
+		fp := model.Fingerprint(dec.Be64())
+		chunks := make([]Chunk, dec.Uvarint())
+		for j := range chunks {
+			chunks[j] = Chunk{
+				From:     model.Time(dec.Varint64()),
+				Through:  model.Time(dec.Varint64()),
+				Checksum: dec.Be32(),
+			}
+		}
+		t.series[fp] = chunks
+	}
+	if err := dec.Err(); err != nil {
+		return nil, fmt.Errorf("decoding tombstones: %w", err)
+	}
+	return t, nil
+}
+
+// Read reads the tombstones of the tenant in the table, nil if it has none.
+func Read(ctx context.Context, client storage.Client, table, tenant string) (*Tombstones, error) {
+	rc, err := client.GetUserFile(ctx, TableName, table, tenant)
+	if err != nil {
+		if client.IsFileNotFoundErr(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer rc.Close()
+
+	b, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, err
+	}
+	return Decode(b)
+}
+
+// Write writes the tombstones of the tenant in the table.
+func Write(ctx context.Context, client storage.Client, table, tenant string, t *Tombstones) error {
+	return client.PutUserFile(ctx, TableName, table, tenant, bytes.NewReader(t.Encode()))
+}
+
+// Delete deletes the tombstones of the tenant in the table.
+func Delete(ctx context.Context, client storage.Client, table, tenant string) error {
+	err := client.DeleteUserFile(ctx, TableName, table, tenant)
+	if err != nil && client.IsFileNotFoundErr(err) {
+		return nil
+	}
+	return err
+}
+
+// Tenants returns the tenants having tombstones in the table, with the last modification time of their tombstones.
+func Tenants(ctx context.Context, client storage.Client, table string) ([]storage.IndexFile, error) {
+	return client.ListUserFiles(ctx, TableName, table, true)
+}
+
+// Tables returns the tables having tombstones.
+func Tables(ctx context.Context, client storage.Client) ([]string, error) {
+	_, tables, err := client.ListFiles(ctx, TableName, true)
+	return tables, err
+}
@@ -0,0 +1,48 @@
+package tombstones
+
+import (
+	"testing"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTombstones(t *testing.T) {
+	ts := New()
+	ts.Add(1, Chunk{From: 10, Through: 20, Checksum: 2}, Chunk{From: 0, Through: 10, Checksum: 1})
+	ts.Add(1, Chunk{From: 0, Through: 10, Checksum: 1})
+	ts.Add(2, Chunk{From: -5, Through: 5, Checksum: 3})
+
+	require.Equal(t, 2, ts.Len())
+	require.Equal(t, 3, ts.Chunks())
+	require.True(t, ts.HasSeries(1))
+	require.False(t, ts.HasSeries(3))
+	require.True(t, ts.Covers(1, Chunk{From: 10, Through: 20, Checksum: 2}))
+	require.False(t, ts.Covers(1, Chunk{From: 10, Through: 20, Checksum: 3}))
+	require.False(t, ts.Covers(2, Chunk{From: 0, Through: 10, Checksum: 1}))
+
+	decoded, err := Decode(ts.Encode())
+	require.NoError(t, err)
+	require.True(t, ts.Equal(decoded))
+	require.True(t, decoded.Covers(2, Chunk{From: -5, Through: 5, Checksum: 3}))
+
+	// tombstones of other chunks differ.
+	other := New()
+	other.Add(1, Chunk{From: 0, Through: 10, Checksum: 1})
+	other.Add(2, Chunk{From: -5, Through: 5, Checksum: 3})
+	require.False(t, ts.Equal(other))
+
+	var none *Tombstones
+	require.False(t, none.Covers(model.Fingerprint(1), Chunk{}))
+	require.Equal(t, 0, none.Len())
+}
+
+func TestDecode_Corrupted(t *testing.T) {
+	ts := New()
+	ts.Add(1, Chunk{From: 0, Through: 10, Checksum: 1})
+	b := ts.Encode()
+	b[6] ^= 0xff
+
+	_, err := Decode(b)
+	require.Error(t, err)
+}
@@ -0,0 +1,198 @@
+package compactor
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/v3/pkg/util/filter"
+)
+
+// expiredBeforeChecker expires the chunks ending before a time.
+type expiredBeforeChecker struct {
+	retention.ExpirationChecker
+	before model.Time
+}
+
+func (e expiredBeforeChecker) Expired(ref retention.ChunkEntry, _ model.Time) (bool, filter.Func) {
+	return ref.Through < e.before, nil
+}
+
+type fakePendingDeleteRequests []deletion.DeleteRequest
+
+func (f fakePendingDeleteRequests) PendingRequests() ([]deletion.DeleteRequest, error) {
+	return f, nil
+}
+
+func newTestTombstoner(t *testing.T, f *tenantMoveFixture, before model.Time, held bool) *tombstoner {
+	return newTestTombstonerWithDelay(t, f, before, held, 0)
+}
+
+func newTestTombstonerWithDelay(t *testing.T, f *tenantMoveFixture, before model.Time, held bool, indexUpdatePropagationMaxDelay time.Duration) *tombstoner {
+	return &tombstoner{
+		cfg:                            tombstones.Config{Enabled: true, Interval: time.Minute, RefreshInterval: time.Minute},
+		indexUpdatePropagationMaxDelay: indexUpdatePropagationMaxDelay,
+		schemaConfig:                   f.schemaConfig,
+		storeContainers:                f.compactor.storeContainers,
+		indexCompactors:                f.compactor.indexCompactors,
+		tableLocker:                    f.compactor.tableLocker,
+		retention:                      expiredBeforeChecker{before: before},
+		deleteRequests:                 fakePendingDeleteRequests(nil),
+		legalHolds:                     fakeLegalHoldsChecker(held),
+		workingDirectory:               t.TempDir(),
+		metrics:                        newTombstonesMetrics(prometheus.NewRegistry()),
+		logger:                         log.NewNopLogger(),
+		now:                            model.Now,
+	}
+}
+
+func tombstoneOf(chk chunk.Chunk) tombstones.Chunk {
+	return tombstones.Chunk{From: chk.From, Through: chk.Through, Checksum: chk.Checksum}
+}
+
+func TestTombstoner(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	chunks := f.putChunks(t, 3)
+	// the second series has a live chunk in another index file.
+	from := model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	live := testutils.DummyChunkFor(from.Add(5*time.Hour), from.Add(5*time.Hour+time.Minute), labels.FromStrings("app", "1"))
+	f.putIndexedChunks(t, "live", []chunk.Chunk{live})
+
+	// the chunks of the first two series are expired.
+	before := chunks[1].Through.Add(time.Second)
+	require.NoError(t, newTestTombstoner(t, f, before, false).Run(context.Background()))
+
+	ts, err := tombstones.Read(context.Background(), f.indexStorageClient, f.table, moveSource)
+	require.NoError(t, err)
+	require.Equal(t, 1, ts.Len())
+	require.True(t, ts.Covers(model.Fingerprint(chunks[0].Fingerprint), tombstoneOf(chunks[0])))
+	require.False(t, ts.HasSeries(model.Fingerprint(chunks[1].Fingerprint)))
+	require.False(t, ts.HasSeries(model.Fingerprint(chunks[2].Fingerprint)))
+
+	// the chunks under a legal hold are never tombstoned.
+	require.NoError(t, newTestTombstoner(t, f, before, true).Run(context.Background()))
+	ts, err = tombstones.Read(context.Background(), f.indexStorageClient, f.table, moveSource)
+	require.NoError(t, err)
+	require.Nil(t, ts)
+
+	// the tombstones of deleted tables are deleted.
+	require.NoError(t, newTestTombstoner(t, f, before, false).Run(context.Background()))
+	tables, err := tombstones.Tables(context.Background(), f.indexStorageClient)
+	require.NoError(t, err)
+	require.Equal(t, []string{f.table}, tables)
+
+	// the tombstones of the series missing from the index were folded, they are kept for the compactor.
+	require.NoError(t, f.indexStorageClient.DeleteUserFile(context.Background(), f.table, moveSource, "chunks"))
+	require.NoError(t, newTestTombstoner(t, f, before, false).Run(context.Background()))
+	ts, err = tombstones.Read(context.Background(), f.indexStorageClient, f.table, moveSource)
+	require.NoError(t, err)
+	require.True(t, ts.Covers(model.Fingerprint(chunks[0].Fingerprint), tombstoneOf(chunks[0])))
+
+	// the tombstones of tenants without index are kept until the index update propagation delay has passed.
+	require.NoError(t, f.indexStorageClient.DeleteUserFile(context.Background(), f.table, moveSource, "live"))
+	require.NoError(t, newTestTombstonerWithDelay(t, f, before, false, time.Hour).Run(context.Background()))
+	tenants, err := tombstones.Tenants(context.Background(), f.indexStorageClient, f.table)
+	require.NoError(t, err)
+	require.Len(t, tenants, 1)
+
+	require.NoError(t, newTestTombstoner(t, f, before, false).Run(context.Background()))
+	tenants, err = tombstones.Tenants(context.Background(), f.indexStorageClient, f.table)
+	require.NoError(t, err)
+	require.Empty(t, tenants)
+}
+
+func TestTable_FoldTombstones_KeepsTombstonesUntilPropagated(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	ts := tombstones.New()
+	ts.Add(1, tombstones.Chunk{From: 0, Through: 1, Checksum: 1})
+	require.NoError(t, tombstones.Write(context.Background(), f.indexStorageClient, f.table, moveSource, ts))
+
+	// the tenant has no index left in the table, as its last series were folded.
+	tbl := &table{
+		ctx:                context.Background(),
+		name:               f.table,
+		indexStorageClient: f.indexStorageClient,
+		indexSets:          map[string]*indexSet{},
+		tombstonesFolder:   &tombstonesFolder{indexUpdatePropagationMaxDelay: time.Hour},
+	}
+	folded, propagated, err := tbl.foldTombstones()
+	require.NoError(t, err)
+	require.Empty(t, folded)
+	require.Empty(t, propagated)
+
+	tbl.tombstonesFolder.indexUpdatePropagationMaxDelay = 0
+	_, propagated, err = tbl.foldTombstones()
+	require.NoError(t, err)
+	require.Equal(t, []string{moveSource}, propagated)
+}
+
+func TestTombstonesFolder(t *testing.T) {
+	f := newTenantMoveFixture(t)
+	chunks := f.putChunks(t, 3)
+	keys := make([]string, 0, len(chunks))
+	for _, chk := range chunks {
+		keys = append(keys, f.schemaConfig.ExternalKey(chk.ChunkRef))
+	}
+	ts := tombstones.New()
+	ts.Add(model.Fingerprint(chunks[0].Fingerprint), tombstoneOf(chunks[0]))
+
+	for _, tc := range []struct {
+		name       string
+		tombstones *tombstones.Tombstones
+		held       bool
+		empty      bool
+		modified   bool
+		keys       []string
+	}{
+		{
+			name:       "tombstoned chunks are removed",
+			tombstones: ts,
+			modified:   true,
+			keys:       keys[1:],
+		},
+		{
+			name:       "chunks under a legal hold are kept",
+			tombstones: ts,
+			held:       true,
+			keys:       keys,
+		},
+		{
+			name: "all chunks tombstoned",
+			tombstones: func() *tombstones.Tombstones {
+				ts := tombstones.New()
+				for _, chk := range chunks {
+					ts.Add(model.Fingerprint(chk.Fingerprint), tombstoneOf(chk))
+				}
+				return ts
+			}(),
+			empty:    true,
+			modified: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			metrics := newTombstonesMetrics(prometheus.NewRegistry())
+			folder := &tombstonesFolder{retentionWorkDir: t.TempDir(), legalHolds: fakeLegalHoldsChecker(tc.held), metrics: metrics}
+			idx := &chunkListIndex{schemaConfig: f.schemaConfig, userID: moveSource, keys: slices.Clone(keys)}
+
+			empty, modified, err := folder.fold(context.Background(), moveSource, tc.tombstones, idx)
+			require.NoError(t, err)
+			require.Equal(t, tc.empty, empty)
+			require.Equal(t, tc.modified, modified)
+			require.Equal(t, tc.keys, idx.keys)
+			require.Equal(t, float64(len(keys)-len(tc.keys)), testutil.ToFloat64(metrics.foldedChunks))
+		})
+	}
+}
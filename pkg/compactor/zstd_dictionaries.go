@@ -0,0 +1,86 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+)
+
+// zstdDictionariesGracePeriod is how long zstd dictionaries are kept after the retention period of their tenant.
+// The ingesters republish the dictionaries in use at each training, so it must exceed the training interval.
+const zstdDictionariesGracePeriod = 24 * time.Hour
+
+// expireZstdDictionaries deletes the zstd dictionaries which were not published for longer than the longest
+// retention period of their tenant, so the chunks compressed with them have expired too.
+// Dictionaries of tenants retaining some streams forever are kept.
+func expireZstdDictionaries(ctx context.Context, objectClient client.ObjectClient, limits retention.Limits, now time.Time, logger log.Logger) (int, error) {
+	_, tenants, err := objectClient.List(ctx, "", "/")
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int
+	for _, prefix := range tenants {
+		tenant := strings.TrimSuffix(string(prefix), "/")
+		period := retention.NewTenantRetentionSnapshot(limits, tenant).MaxRetentionPeriod()
+		if period == 0 {
+			continue
+		}
+
+		dictionaries, _, err := objectClient.List(ctx, client.ZstdDictionariesPrefix(tenant), "")
+		if err != nil {
+			return deleted, err
+		}
+		for _, dict := range dictionaries {
+			if now.Sub(dict.ModifiedAt) <= period+zstdDictionariesGracePeriod {
+				continue
+			}
+			if err := objectClient.DeleteObject(ctx, dict.Key); err != nil && !objectClient.IsObjectNotFoundErr(err) {
+				return deleted, err
+			}
+			level.Info(logger).Log("msg", "deleted expired zstd dictionary", "tenant", tenant, "key", dict.Key)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// copyZstdDictionaries copies the zstd dictionaries of the source tenant to the destination tenant, so the chunks
+// copied to the destination tenant can be decompressed. It fails when the destination tenant holds a different
+// dictionary with the same ID, as the copied chunks would be decompressed with the wrong dictionary.
+func copyZstdDictionaries(ctx context.Context, objectClient client.ObjectClient, source, destination string) error {
+	dictionaries, _, err := objectClient.List(ctx, client.ZstdDictionariesPrefix(source), "")
+	if err != nil {
+		return err
+	}
+
+	for _, object := range dictionaries {
+		dict, err := client.GetZstdDictionary(ctx, objectClient, object.Key)
+		if err != nil {
+			return err
+		}
+
+		key := client.ZstdDictionariesPrefix(destination) + strings.TrimPrefix(object.Key, client.ZstdDictionariesPrefix(source))
+		existing, err := client.GetZstdDictionary(ctx, objectClient, key)
+		switch {
+		case err == nil && bytes.Equal(existing, dict):
+			continue
+		case err == nil:
+			return fmt.Errorf("zstd dictionary %s differs from the dictionary of the source tenant with the same ID", key)
+		case !objectClient.IsObjectNotFoundErr(err):
+			return err
+		}
+		if err := objectClient.PutObject(ctx, key, bytes.NewReader(dict)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package compactor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/validation"
+)
+
+type tenantLimits map[string]*validation.Limits
+
+func (l tenantLimits) TenantLimits(userID string) *validation.Limits {
+	return l[userID]
+}
+
+func (l tenantLimits) AllByUserID() map[string]*validation.Limits {
+	return l
+}
+
+func TestExpireZstdDictionaries(t *testing.T) {
+	dir := t.TempDir()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: dir})
+	require.NoError(t, err)
+
+	defaultLimits := validation.Limits{}
+	flagext.DefaultValues(&defaultLimits)
+	defaultLimits.RetentionPeriod = model.Duration(7 * 24 * time.Hour)
+	forever := defaultLimits
+	forever.StreamRetention = []validation.StreamRetention{{Period: 0, Priority: 1}}
+	overrides, err := validation.NewOverrides(defaultLimits, tenantLimits{"forever": &forever})
+	require.NoError(t, err)
+
+	now := time.Now()
+	put := func(tenant string, id uint32, age time.Duration) string {
+		key := client.ZstdDictionaryObjectKey(tenant, id)
+		require.NoError(t, objectClient.PutObject(context.Background(), key, bytes.NewReader([]byte("dict"))))
+		require.NoError(t, os.Chtimes(filepath.Join(dir, filepath.FromSlash(key)), now.Add(-age), now.Add(-age)))
+		return key
+	}
+	expired := put("tenant", 1, 7*24*time.Hour+zstdDictionariesGracePeriod+time.Hour)
+	kept := put("tenant", 2, 7*24*time.Hour)
+	retainedForever := put("forever", 1, 30*24*time.Hour)
+
+	deleted, err := expireZstdDictionaries(context.Background(), objectClient, overrides, now, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, 1, deleted)
+
+	for key, exists := range map[string]bool{expired: false, kept: true, retainedForever: true} {
+		_, err := client.GetZstdDictionary(context.Background(), objectClient, key)
+		if exists {
+			require.NoError(t, err, key)
+		} else {
+			require.True(t, objectClient.IsObjectNotFoundErr(err), key)
+		}
+	}
+}
+
+func TestCopyZstdDictionaries(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, objectClient.PutObject(ctx, client.ZstdDictionaryObjectKey("source", 1), bytes.NewReader([]byte("one"))))
+	require.NoError(t, objectClient.PutObject(ctx, client.ZstdDictionaryObjectKey("source", 2), bytes.NewReader([]byte("two"))))
+	require.NoError(t, objectClient.PutObject(ctx, client.ZstdDictionaryObjectKey("destination", 2), bytes.NewReader([]byte("two"))))
+
+	require.NoError(t, copyZstdDictionaries(ctx, objectClient, "source", "destination"))
+	for id, expected := range map[uint32]string{1: "one", 2: "two"} {
+		dict, err := client.GetZstdDictionary(ctx, objectClient, client.ZstdDictionaryObjectKey("destination", id))
+		require.NoError(t, err)
+		require.Equal(t, expected, string(dict))
+	}
+
+	// the chunks of the source tenant can't be decompressed with another dictionary of the destination tenant.
+	require.NoError(t, objectClient.PutObject(ctx, client.ZstdDictionaryObjectKey("other", 1), bytes.NewReader([]byte("another"))))
+	require.Error(t, copyZstdDictionaries(ctx, objectClient, "source", "other"))
+}
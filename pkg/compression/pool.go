@@ -77,7 +77,9 @@ func GetPool(enc Codec) ReaderWriterPool {
 		return &noop
 	case Flate:
 		return &flate
-	case Zstd:
+	case Zstd, ZstdDict:
+		// ZstdDict blocks written without a dictionary are plain zstd frames,
+		// see GetZstdDictPool for the pools of the trained dictionaries.
 		return &zstd
 	default:
 		panic("unknown encoding")
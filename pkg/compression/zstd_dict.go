@@ -0,0 +1,242 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"runtime"
+	"sync"
+
+	lru "github.com/hashicorp/golang-lru/v2"
+	zstdlib "github.com/klauspost/compress/zstd"
+)
+
+const (
+	// minZstdDictionarySize is the minimum amount of sampled content required to train a dictionary.
+	minZstdDictionarySize = 8
+	// zstdDictionaryCacheSize is the number of dictionary pools kept in memory.
+	zstdDictionaryCacheSize = 128
+)
+
+// ZstdDictionaryFetcher returns the content of the dictionary of the tenant with the given ID.
+type ZstdDictionaryFetcher func(ctx context.Context, tenant string, id uint32) ([]byte, error)
+
+var zstdDictionaries = newZstdDictionaryRegistry(zstdDictionaryCacheSize)
+
+// SetZstdDictionaryFetcher sets the function used to fetch the dictionaries which are not cached yet.
+func SetZstdDictionaryFetcher(fetcher ZstdDictionaryFetcher) {
+	zstdDictionaries.setFetcher(fetcher)
+}
+
+// RegisterZstdDictionary makes a dictionary of the tenant available to the chunks referencing it and returns its pool.
+func RegisterZstdDictionary(tenant string, dict []byte) (*ZstdDictPool, error) {
+	return zstdDictionaries.register(tenant, dict)
+}
+
+// GetZstdDictPool returns the pool of the dictionary of the tenant with the given ID, fetching the dictionary when it isn't cached.
+func GetZstdDictPool(ctx context.Context, tenant string, id uint32) (*ZstdDictPool, error) {
+	return zstdDictionaries.get(ctx, tenant, id)
+}
+
+// zstdDictionaryKey identifies a dictionary, as the IDs are only unique among the dictionaries of a tenant.
+type zstdDictionaryKey struct {
+	tenant string
+	id     uint32
+}
+
+type zstdDictionaryRegistry struct {
+	mtx     sync.RWMutex
+	fetcher ZstdDictionaryFetcher
+	pools   *lru.Cache[zstdDictionaryKey, *ZstdDictPool]
+}
+
+func newZstdDictionaryRegistry(size int) *zstdDictionaryRegistry {
+	pools, err := lru.New[zstdDictionaryKey, *ZstdDictPool](size)
+	if err != nil {
+		panic(err) // never happens, error is only returned on a non-positive size.
+	}
+	return &zstdDictionaryRegistry{pools: pools}
+}
+
+func (r *zstdDictionaryRegistry) setFetcher(fetcher ZstdDictionaryFetcher) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.fetcher = fetcher
+}
+
+func (r *zstdDictionaryRegistry) register(tenant string, dict []byte) (*ZstdDictPool, error) {
+	pool, err := NewZstdDictPool(dict)
+	if err != nil {
+		return nil, err
+	}
+	key := zstdDictionaryKey{tenant: tenant, id: pool.ID()}
+	if cached, ok := r.pools.Get(key); ok && bytes.Equal(cached.dict, dict) {
+		return cached, nil
+	}
+	r.pools.Add(key, pool)
+	return pool, nil
+}
+
+func (r *zstdDictionaryRegistry) get(ctx context.Context, tenant string, id uint32) (*ZstdDictPool, error) {
+	if tenant == "" {
+		return nil, fmt.Errorf("zstd dictionary %d requires the tenant of the chunk", id)
+	}
+	if pool, ok := r.pools.Get(zstdDictionaryKey{tenant: tenant, id: id}); ok {
+		return pool, nil
+	}
+
+	r.mtx.RLock()
+	fetcher := r.fetcher
+	r.mtx.RUnlock()
+	if fetcher == nil {
+		return nil, fmt.Errorf("zstd dictionary %d of tenant %s is not available", id, tenant)
+	}
+
+	dict, err := fetcher(ctx, tenant, id)
+	if err != nil {
+		return nil, fmt.Errorf("fetching zstd dictionary %d of tenant %s: %w", id, tenant, err)
+	}
+	pool, err := r.register(tenant, dict)
+	if err != nil {
+		return nil, err
+	}
+	if pool.ID() != id {
+		return nil, fmt.Errorf("fetched zstd dictionary has ID %d, expected %d", pool.ID(), id)
+	}
+	return pool, nil
+}
+
+// TrainZstdDictionary trains a zstd dictionary of at most maxSize bytes out of the sampled lines.
+// The ID of the dictionary is derived from its content so that the same samples always give the same ID.
+func TrainZstdDictionary(samples [][]byte, maxSize int) ([]byte, error) {
+	size := 0
+	for _, s := range samples {
+		size += len(s)
+	}
+	if size < minZstdDictionarySize {
+		return nil, fmt.Errorf("not enough samples to train a zstd dictionary: got %d bytes, need at least %d", size, minZstdDictionarySize)
+	}
+
+	// the history is made of samples taken evenly across all the samples.
+	step := 1
+	if size > maxSize {
+		step = (size + maxSize - 1) / maxSize
+	}
+	history := make([]byte, 0, min(size, maxSize))
+	for i := 0; i < len(samples); i += step {
+		if len(history)+len(samples[i]) > maxSize {
+			break
+		}
+		history = append(history, samples[i]...)
+	}
+	if len(history) < minZstdDictionarySize {
+		return nil, fmt.Errorf("sampled lines are too large to fit in a zstd dictionary of %d bytes", maxSize)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write(history)
+	id := h.Sum32()
+	if id == 0 {
+		// 0 means no dictionary.
+		id = 1
+	}
+
+	return zstdlib.BuildDict(zstdlib.BuildDictOptions{
+		ID:       id,
+		Contents: samples,
+		History:  history,
+		Offsets:  [3]int{1, 4, 8},
+		Level:    zstdlib.SpeedDefault,
+	})
+}
+
+// WithZstdDictionaryID returns a copy of the dictionary with the given ID, used to pick another ID when the ID
+// derived from the content of a dictionary is already used by another dictionary of the tenant.
+func WithZstdDictionaryID(dict []byte, id uint32) ([]byte, error) {
+	if id == 0 {
+		return nil, fmt.Errorf("invalid zstd dictionary ID: 0 means no dictionary")
+	}
+	if _, err := zstdlib.InspectDictionary(dict); err != nil {
+		return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+	}
+	// the ID follows the magic number of the dictionary.
+	renamed := append([]byte(nil), dict...)
+	binary.LittleEndian.PutUint32(renamed[4:8], id)
+	return renamed, nil
+}
+
+// ZstdDictPool is a zstd compression pool using a trained dictionary.
+type ZstdDictPool struct {
+	id      uint32
+	dict    []byte
+	readers sync.Pool
+	writers sync.Pool
+}
+
+// NewZstdDictPool returns a pool compressing with the given zstd dictionary.
+func NewZstdDictPool(dict []byte) (*ZstdDictPool, error) {
+	d, err := zstdlib.InspectDictionary(dict)
+	if err != nil {
+		return nil, fmt.Errorf("invalid zstd dictionary: %w", err)
+	}
+	if d.ID() == 0 {
+		return nil, fmt.Errorf("invalid zstd dictionary: missing ID")
+	}
+	return &ZstdDictPool{id: d.ID(), dict: dict}, nil
+}
+
+// ID returns the ID of the dictionary.
+func (pool *ZstdDictPool) ID() uint32 {
+	return pool.id
+}
+
+// Dictionary returns the content of the dictionary.
+func (pool *ZstdDictPool) Dictionary() []byte {
+	return pool.dict
+}
+
+// GetReader gets or creates a new CompressionReader and reset it to read from src
+func (pool *ZstdDictPool) GetReader(src io.Reader) (io.Reader, error) {
+	if r := pool.readers.Get(); r != nil {
+		reader := r.(*zstdlib.Decoder)
+		err := reader.Reset(src)
+		if err != nil {
+			return nil, err
+		}
+		return reader, nil
+	}
+	reader, err := zstdlib.NewReader(src, zstdlib.WithDecoderDicts(pool.dict))
+	if err != nil {
+		return nil, err
+	}
+	runtime.SetFinalizer(reader, (*zstdlib.Decoder).Close)
+	return reader, nil
+}
+
+// PutReader places back in the pool a CompressionReader
+func (pool *ZstdDictPool) PutReader(reader io.Reader) {
+	pool.readers.Put(reader)
+}
+
+// GetWriter gets or creates a new CompressionWriter and reset it to write to dst
+func (pool *ZstdDictPool) GetWriter(dst io.Writer) io.WriteCloser {
+	if w := pool.writers.Get(); w != nil {
+		writer := w.(*zstdlib.Encoder)
+		writer.Reset(dst)
+		return writer
+	}
+
+	w, err := zstdlib.NewWriter(dst, zstdlib.WithEncoderDict(pool.dict))
+	if err != nil {
+		panic(err) // never happens, the dictionary is validated when the pool is created.
+	}
+	return w
+}
+
+// PutWriter places back in the pool a CompressionWriter
+func (pool *ZstdDictPool) PutWriter(writer io.WriteCloser) {
+	pool.writers.Put(writer)
+}
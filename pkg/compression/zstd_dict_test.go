@@ -0,0 +1,129 @@
+package compression
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func sampleLines(n int) [][]byte {
+	samples := make([][]byte, 0, n)
+	for i := 0; i < n; i++ {
+		samples = append(samples, []byte(fmt.Sprintf(`level=info ts=2024-01-01T00:00:%02dZ caller=handler.go:42 msg="request served" method=GET path=/api/v1/items/%d status=200 duration=%dms`, i%60, i, i%13)))
+	}
+	return samples
+}
+
+func TestTrainZstdDictionary(t *testing.T) {
+	_, err := TrainZstdDictionary(nil, 1024)
+	require.Error(t, err)
+
+	samples := sampleLines(1000)
+	dict, err := TrainZstdDictionary(samples, 4<<10)
+	require.NoError(t, err)
+
+	// the same samples give the same dictionary.
+	again, err := TrainZstdDictionary(samples, 4<<10)
+	require.NoError(t, err)
+	require.Equal(t, dict, again)
+
+	pool, err := NewZstdDictPool(dict)
+	require.NoError(t, err)
+	require.NotZero(t, pool.ID())
+
+	var plain, withDict bytes.Buffer
+	for _, p := range []struct {
+		pool WriterPool
+		buf  *bytes.Buffer
+	}{{GetWriterPool(Zstd), &plain}, {pool, &withDict}} {
+		w := p.pool.GetWriter(p.buf)
+		for _, s := range sampleLines(50) {
+			_, err := w.Write(s)
+			require.NoError(t, err)
+		}
+		require.NoError(t, w.Close())
+		p.pool.PutWriter(w)
+	}
+	require.Less(t, withDict.Len(), plain.Len())
+
+	r, err := pool.GetReader(&withDict)
+	require.NoError(t, err)
+	defer pool.PutReader(r)
+	decompressed, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, bytes.Join(sampleLines(50), nil), decompressed)
+}
+
+func TestZstdDictionaryRegistry(t *testing.T) {
+	dict, err := TrainZstdDictionary(sampleLines(500), 2<<10)
+	require.NoError(t, err)
+	expected, err := NewZstdDictPool(dict)
+	require.NoError(t, err)
+
+	r := newZstdDictionaryRegistry(10)
+	_, err = r.get(context.Background(), "tenant-a", expected.ID())
+	require.Error(t, err)
+
+	fetches := 0
+	r.setFetcher(func(_ context.Context, tenant string, id uint32) ([]byte, error) {
+		fetches++
+		if tenant != "tenant-a" || id != expected.ID() {
+			return nil, errors.New("not found")
+		}
+		return dict, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		pool, err := r.get(context.Background(), "tenant-a", expected.ID())
+		require.NoError(t, err)
+		require.Equal(t, expected.ID(), pool.ID())
+	}
+	require.Equal(t, 1, fetches)
+
+	_, err = r.get(context.Background(), "tenant-a", expected.ID()+1)
+	require.Error(t, err)
+	// the dictionaries of other tenants with the same ID are distinct.
+	_, err = r.get(context.Background(), "tenant-b", expected.ID())
+	require.Error(t, err)
+	_, err = r.get(context.Background(), "", expected.ID())
+	require.Error(t, err)
+}
+
+func TestWithZstdDictionaryID(t *testing.T) {
+	dict, err := TrainZstdDictionary(sampleLines(500), 2<<10)
+	require.NoError(t, err)
+	pool, err := NewZstdDictPool(dict)
+	require.NoError(t, err)
+
+	renamed, err := WithZstdDictionaryID(dict, pool.ID()+1)
+	require.NoError(t, err)
+	renamedPool, err := NewZstdDictPool(renamed)
+	require.NoError(t, err)
+	require.Equal(t, pool.ID()+1, renamedPool.ID())
+	require.NotEqual(t, dict, renamed)
+
+	var buf bytes.Buffer
+	w := renamedPool.GetWriter(&buf)
+	_, err = w.Write(bytes.Join(sampleLines(50), nil))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	r, err := renamedPool.GetReader(&buf)
+	require.NoError(t, err)
+	decompressed, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, bytes.Join(sampleLines(50), nil), decompressed)
+
+	_, err = WithZstdDictionaryID(dict, 0)
+	require.Error(t, err)
+}
+
+func TestParseZstdDict(t *testing.T) {
+	c, err := ParseCodec("zstd-dict")
+	require.NoError(t, err)
+	require.Equal(t, ZstdDict, c)
+}
@@ -64,6 +64,9 @@ type Config struct {
 	// In case it isn't explicitly set, it follows the same behavior of the other rings (ex: using the common configuration
 	// section and the ingester configuration by default).
 	Ring ring.RingConfig `yaml:"ring,omitempty" doc:"description=Defines the ring to be used by the index gateway servers and clients in case the servers are configured to run in 'ring' mode. In case this isn't configured, this block supports inheriting configuration from the common ring section."`
+
+	// ResultsCache configures the cache of the results of the queries on the immutable tables of the index.
+	ResultsCache ResultsCacheConfig `yaml:"results_cache" doc:"description=Cache of the chunk refs, label names and shards returned by the index gateway for the TSDB tables which no longer change. The cached results of a table are invalidated when its index files are compacted, uploaded or tombstoned."`
 }
 
 // RegisterFlags register all IndexGatewayClientConfig flags and all the flags of its subconfigs but with a prefix (ex: shipper).
@@ -83,11 +86,13 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	// multiple Index Gateway instances are expected to be returned as Index Gateway might be busy/locked for specific
 	// reasons (this is assured by the spikey behavior of Index Gateway latencies).
 	f.IntVar(&cfg.Ring.ReplicationFactor, "replication-factor", ReplicationFactor, "Deprecated: How many index gateway instances are assigned to each tenant. Use -index-gateway.shard-size instead. The shard size is also a per-tenant setting.")
+
+	cfg.ResultsCache.RegisterFlagsWithPrefix("index-gateway.results-cache.", f)
 }
 
 func (cfg *Config) Validate() error {
 	if cfg.Ring.NumTokens != NumTokens {
 		return errors.New("Num tokens must not be changed as it will not take effect")
 	}
-	return nil
+	return cfg.ResultsCache.Validate()
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"slices"
 	"sort"
 	"sync"
 	"time"
@@ -61,12 +62,19 @@ type BloomQuerier interface {
 	FilterChunkRefs(ctx context.Context, tenant string, from, through model.Time, series map[uint64]labels.Labels, chunks []*logproto.ChunkRef, plan plan.QueryPlan) ([]*logproto.ChunkRef, bool, error)
 }
 
+// TokenIndexQuerier filters chunk refs with the token index, see tokenindex.Querier.
+type TokenIndexQuerier interface {
+	FilterChunkRefs(ctx context.Context, tenant string, expr syntax.Expr, refs []*logproto.ChunkRef) ([]*logproto.ChunkRef, error)
+}
+
 type Gateway struct {
 	services.Service
 
 	indexQuerier IndexQuerier
 	indexClients []IndexClientWithRange
 	bloomQuerier BloomQuerier
+	tokenIndex   TokenIndexQuerier
+	resultsCache *ResultsCache
 	metrics      *Metrics
 
 	cfg    Config
@@ -78,10 +86,12 @@ type Gateway struct {
 //
 // In case it is configured to be in ring mode, a Basic Service wrapping the ring client is started.
 // Otherwise, it starts an Idle Service that doesn't have lifecycle hooks.
-func NewIndexGateway(cfg Config, limits Limits, log log.Logger, r prometheus.Registerer, indexQuerier IndexQuerier, indexClients []IndexClientWithRange, bloomQuerier BloomQuerier) (*Gateway, error) {
+func NewIndexGateway(cfg Config, limits Limits, log log.Logger, r prometheus.Registerer, indexQuerier IndexQuerier, indexClients []IndexClientWithRange, bloomQuerier BloomQuerier, tokenIndex TokenIndexQuerier, resultsCache *ResultsCache) (*Gateway, error) {
 	g := &Gateway{
 		indexQuerier: indexQuerier,
 		bloomQuerier: bloomQuerier,
+		tokenIndex:   tokenIndex,
+		resultsCache: resultsCache,
 		cfg:          cfg,
 		limits:       limits,
 		log:          log,
@@ -99,6 +109,9 @@ func NewIndexGateway(cfg Config, limits Limits, log log.Logger, r prometheus.Reg
 		for _, indexClient := range g.indexClients {
 			indexClient.Stop()
 		}
+		if g.resultsCache != nil {
+			g.resultsCache.Stop()
+		}
 		return nil
 	})
 
@@ -222,20 +235,11 @@ func (g *Gateway) GetChunkRef(ctx context.Context, req *logproto.GetChunkRefRequ
 	}
 
 	predicate := chunk.NewPredicate(matchers, &req.Plan)
-	chunks, _, err := g.indexQuerier.GetChunks(ctx, instanceID, req.From, req.Through, predicate, nil)
+	result, err = g.chunkRefs(ctx, instanceID, req, predicate)
 	if err != nil {
 		return nil, err
 	}
 
-	result = &logproto.GetChunkRefResponse{
-		Refs: make([]*logproto.ChunkRef, 0, len(chunks)),
-	}
-	for _, cs := range chunks {
-		for i := range cs {
-			result.Refs = append(result.Refs, &cs[i].ChunkRef)
-		}
-	}
-
 	initialChunkCount := len(result.Refs)
 	result.Stats.TotalChunks = int64(initialChunkCount)
 	result.Stats.PostFilterChunks = int64(initialChunkCount) // populate early for error reponses
@@ -247,15 +251,26 @@ func (g *Gateway) GetChunkRef(ctx context.Context, req *logproto.GetChunkRefRequ
 		}
 	}()
 
+	// Drop the chunks which the token index proves not to match the label filters of the query.
+	if g.tokenIndex != nil {
+		start := time.Now()
+		result.Refs, err = g.tokenIndex.FilterChunkRefs(ctx, instanceID, req.Plan.AST, result.Refs)
+		if err != nil {
+			return nil, err
+		}
+		sp.LogKV("msg", "tokenIndex.FilterChunkRefs", "duration", time.Since(start), "filtered", initialChunkCount-len(result.Refs))
+		result.Stats.PostFilterChunks = int64(len(result.Refs))
+	}
+
 	// Return unfiltered results if there is no bloom querier (Bloom Gateway disabled)
 	if g.bloomQuerier == nil {
 		return result, nil
 	}
 
-	// Extract testable LabelFilters from the plan. If there is none, we can
+	// Extract testable label and line filters from the plan. If there is none, we can
 	// short-circuit and return before making a req to the bloom-gateway (through
 	// the g.bloomQuerier)
-	if len(v1.ExtractTestableLabelMatchers(req.Plan.AST)) == 0 {
+	if len(v1.ExtractTestableMatchers(req.Plan.AST)) == 0 {
 		return result, nil
 	}
 
@@ -283,6 +298,58 @@ func (g *Gateway) GetChunkRef(ctx context.Context, req *logproto.GetChunkRefRequ
 	return result, nil
 }
 
+// chunkRefs returns the chunk refs of the index matching the predicate, using the results cache for the immutable
+// tables when it is enabled.
+func (g *Gateway) chunkRefs(ctx context.Context, instanceID string, req *logproto.GetChunkRefRequest, predicate chunk.Predicate) (*logproto.GetChunkRefResponse, error) {
+	run := func(ctx context.Context, from, through model.Time) (*logproto.GetChunkRefResponse, error) {
+		chunks, _, err := g.indexQuerier.GetChunks(ctx, instanceID, from, through, predicate, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp := &logproto.GetChunkRefResponse{
+			Refs: make([]*logproto.ChunkRef, 0, len(chunks)),
+		}
+		for _, cs := range chunks {
+			for i := range cs {
+				resp.Refs = append(resp.Refs, &cs[i].ChunkRef)
+			}
+		}
+		return resp, nil
+	}
+
+	if g.resultsCache == nil {
+		return run(ctx, req.From, req.Through)
+	}
+	responses, err := fetchByTable(ctx, g.resultsCache, routeChunkRefs, instanceID, req.Matchers+":"+req.Plan.String(), req.From, req.Through, run)
+	if err != nil {
+		return nil, err
+	}
+	if len(responses) == 1 {
+		return responses[0], nil
+	}
+
+	// chunks spanning several tables are in the responses of each of them.
+	type chunkKey struct {
+		fp            uint64
+		from, through model.Time
+		checksum      uint32
+	}
+	seen := make(map[chunkKey]struct{})
+	result := &logproto.GetChunkRefResponse{}
+	for _, resp := range responses {
+		for _, ref := range resp.Refs {
+			key := chunkKey{fp: ref.Fingerprint, from: ref.From, through: ref.Through, checksum: ref.Checksum}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			result.Refs = append(result.Refs, ref)
+		}
+	}
+	return result, nil
+}
+
 func (g *Gateway) GetSeries(ctx context.Context, req *logproto.GetSeriesRequest) (*logproto.GetSeriesResponse, error) {
 	instanceID, err := tenant.TenantID(ctx)
 	if err != nil {
@@ -329,12 +396,34 @@ func (g *Gateway) LabelNamesForMetricName(ctx context.Context, req *logproto.Lab
 		}
 		matchers = matcherExpr.Mts
 	}
-	names, err := g.indexQuerier.LabelNamesForMetricName(ctx, instanceID, req.From, req.Through, req.MetricName, matchers...)
+	run := func(ctx context.Context, from, through model.Time) (*logproto.LabelResponse, error) {
+		names, err := g.indexQuerier.LabelNamesForMetricName(ctx, instanceID, from, through, req.MetricName, matchers...)
+		if err != nil {
+			return nil, err
+		}
+		return &logproto.LabelResponse{
+			Values: names,
+		}, nil
+	}
+
+	if g.resultsCache == nil {
+		return run(ctx, req.From, req.Through)
+	}
+	responses, err := fetchByTable(ctx, g.resultsCache, routeLabelNames, instanceID, req.MetricName+":"+req.Matchers, req.From, req.Through, run)
 	if err != nil {
 		return nil, err
 	}
+	if len(responses) == 1 {
+		return responses[0], nil
+	}
+
+	var names []string
+	for _, resp := range responses {
+		names = append(names, resp.Values...)
+	}
+	sort.Strings(names)
 	return &logproto.LabelResponse{
-		Values: names,
+		Values: slices.Compact(names),
 	}, nil
 }
 
@@ -409,39 +498,47 @@ func (g *Gateway) GetShards(request *logproto.ShardsRequest, server logproto.Ind
 		return err
 	}
 
-	forSeries, ok := g.indexQuerier.HasForSeries(request.From, request.Through)
-	if !ok {
-		sp.LogKV(
-			"msg", "index does not support forSeries",
-			"action", "falling back to indexQuerier.GetShards impl",
-		)
-		shards, err := g.indexQuerier.GetShards(
-			ctx,
-			instanceID,
-			request.From, request.Through,
-			request.TargetBytesPerShard,
-			p,
-		)
-
-		if err != nil {
-			return err
+	run := func(ctx context.Context) (*logproto.ShardsResponse, error) {
+		forSeries, ok := g.indexQuerier.HasForSeries(request.From, request.Through)
+		if !ok {
+			sp.LogKV(
+				"msg", "index does not support forSeries",
+				"action", "falling back to indexQuerier.GetShards impl",
+			)
+			return g.indexQuerier.GetShards(
+				ctx,
+				instanceID,
+				request.From, request.Through,
+				request.TargetBytesPerShard,
+				p,
+			)
 		}
 
-		return server.Send(shards)
+		return g.boundedShards(ctx, request, instanceID, p, forSeries)
 	}
 
-	return g.boundedShards(ctx, request, server, instanceID, p, forSeries)
+	var shards *logproto.ShardsResponse
+	if g.resultsCache == nil {
+		shards, err = run(ctx)
+	} else {
+		query := fmt.Sprintf("%s:%d:%t", request.Query, request.TargetBytesPerShard, g.limits.TSDBPrecomputeChunks(instanceID))
+		shards, err = fetchWhole(ctx, g.resultsCache, routeShards, instanceID, query, request.From, request.Through, run)
+	}
+	if err != nil {
+		return err
+	}
+
+	return server.Send(shards)
 }
 
 // boundedShards handles bounded shard requests, optionally returning precomputed chunks.
 func (g *Gateway) boundedShards(
 	ctx context.Context,
 	req *logproto.ShardsRequest,
-	server logproto.IndexGateway_GetShardsServer,
 	instanceID string,
 	p chunk.Predicate,
 	forSeries sharding.ForSeries,
-) error {
+) (*logproto.ShardsResponse, error) {
 	// TODO(owen-d): instead of using GetChunks which buffers _all_ the chunks
 	// (expensive when looking at the full fingerprint space), we should
 	// use the `ForSeries` implementation to accumulate batches of chunks to dedupe,
@@ -461,7 +558,7 @@ func (g *Gateway) boundedShards(
 	// 1) for all bounds, get chunk refs
 	grps, _, err := g.indexQuerier.GetChunks(ctx, instanceID, req.From, req.Through, p, nil)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	var ct int
@@ -518,7 +615,7 @@ func (g *Gateway) boundedShards(
 	} else {
 		shards, chunkGrps, err := accumulateChunksToShards(ctx, instanceID, forSeries, req, p, filtered)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		resp.Shards = shards
 
@@ -554,7 +651,7 @@ func (g *Gateway) boundedShards(
 	)
 
 	// 3) build shards
-	return server.Send(resp)
+	return resp, nil
 }
 
 // ExtractShardRequestMatchersAndAST extracts the matchers and AST from a query string.
@@ -8,8 +8,9 @@ import (
 )
 
 const (
-	routeChunkRefs = "chunk_refs"
-	routeShards    = "shards"
+	routeChunkRefs  = "chunk_refs"
+	routeShards     = "shards"
+	routeLabelNames = "label_names"
 )
 
 type Metrics struct {
@@ -0,0 +1,298 @@
+package indexgateway
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	"github.com/grafana/loki/v3/pkg/util/constants"
+	util_log "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+var errNoTableGeneration = errors.New("no index storage configured for the period of the table")
+
+// ResultsCacheConfig configures the cache of the results of the index gateway.
+type ResultsCacheConfig struct {
+	Enabled                   bool          `yaml:"enabled"`
+	CacheConfig               cache.Config  `yaml:"cache"`
+	ImmutableAfter            time.Duration `yaml:"immutable_after"`
+	GenerationRefreshInterval time.Duration `yaml:"generation_refresh_interval"`
+}
+
+func (cfg *ResultsCacheConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, "Cache the chunk refs, label names and shards returned by the index gateway per table of the index, for the TSDB tables which no longer change.")
+	cfg.CacheConfig.RegisterFlagsWithPrefix(prefix, "", f)
+	f.DurationVar(&cfg.ImmutableAfter, prefix+"immutable-after", 30*time.Minute, "Time after the end of a table and after the last change of its index files after which the results of the table are cached. Must be longer than the time ingesters take to upload the index of a table.")
+	f.DurationVar(&cfg.GenerationRefreshInterval, prefix+"generation-refresh-interval", time.Minute, "How often the index files of the tables are listed to invalidate the cached results of the tables which were compacted or had index files uploaded.")
+}
+
+func (cfg *ResultsCacheConfig) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if !cache.IsCacheConfigured(cfg.CacheConfig) {
+		return errors.New("no cache configured for the index gateway results cache")
+	}
+	if cfg.ImmutableAfter <= 0 {
+		return errors.New("immutable_after of the index gateway results cache must be positive")
+	}
+	if cfg.GenerationRefreshInterval <= 0 {
+		return errors.New("generation_refresh_interval of the index gateway results cache must be positive")
+	}
+	return nil
+}
+
+type resultsCacheMetrics struct {
+	requests *prometheus.CounterVec
+	hits     *prometheus.CounterVec
+}
+
+func newResultsCacheMetrics(r prometheus.Registerer) *resultsCacheMetrics {
+	return &resultsCacheMetrics{
+		requests: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Subsystem: "index_gateway",
+			Name:      "results_cache_requests_total",
+			Help:      "Total number of results looked up in the results cache.",
+		}, []string{"route"}),
+		hits: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Subsystem: "index_gateway",
+			Name:      "results_cache_hits_total",
+			Help:      "Total number of results found in the results cache.",
+		}, []string{"route"}),
+	}
+}
+
+// ResultsCache caches the results of the queries per table of the index, for the tables which are immutable:
+// their time range ended and their index files did not change for a while. The cache keys include the
+// generation of the table so that compactions, late uploads and tombstones invalidate the cached results.
+type ResultsCache struct {
+	cfg          ResultsCacheConfig
+	cache        cache.Cache
+	schemaConfig config.SchemaConfig
+	generations  TableGenerations
+	metrics      *resultsCacheMetrics
+	logger       log.Logger
+	now          func() model.Time
+}
+
+func NewResultsCache(cfg ResultsCacheConfig, c cache.Cache, schemaConfig config.SchemaConfig, generations TableGenerations, r prometheus.Registerer, logger log.Logger) *ResultsCache {
+	return &ResultsCache{
+		cfg:          cfg,
+		cache:        c,
+		schemaConfig: schemaConfig,
+		generations:  generations,
+		metrics:      newResultsCacheMetrics(r),
+		logger:       logger,
+		now:          model.Now,
+	}
+}
+
+func (c *ResultsCache) Stop() {
+	c.cache.Stop()
+}
+
+// resultsTable is the part of the time range of a query which is in a table of the index.
+type resultsTable struct {
+	name          string
+	from, through model.Time
+	generation    TableGeneration
+	immutable     bool
+}
+
+// tables splits [from, through] into the tables of the index.
+func (c *ResultsCache) tables(ctx context.Context, tenant string, from, through model.Time) []resultsTable {
+	immutableBefore := c.now().Add(-c.cfg.ImmutableAfter)
+
+	var tables []resultsTable
+	for start := from; start <= through; {
+		end := through
+		for _, p := range c.schemaConfig.Configs {
+			if p.From.Time > start {
+				end = min(end, p.From.Time-1)
+				break
+			}
+		}
+
+		table := resultsTable{from: start}
+		period, err := c.schemaConfig.SchemaForTime(start)
+		if err == nil && period.IndexTables.Period > 0 && start >= 0 {
+			periodMs := int64(period.IndexTables.Period / time.Millisecond)
+			tableEnd := model.Time((int64(start)/periodMs+1)*periodMs - 1)
+			end = min(end, tableEnd)
+			table.name = period.IndexTables.TableFor(start)
+
+			if period.IndexType == types.TSDBType && tableEnd < immutableBefore {
+				table.generation, err = c.generations.Generation(ctx, period, table.name, tenant)
+				if err != nil {
+					level.Warn(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to get the generation of the table", "table", table.name, "err", err)
+				} else {
+					table.immutable = !table.generation.UpdatedAt.After(immutableBefore.Time())
+				}
+			}
+		} else if start < 0 {
+			end = min(end, -1)
+		}
+
+		table.through = end
+		tables = append(tables, table)
+		start = end + 1
+	}
+	return tables
+}
+
+func (c *ResultsCache) key(route, tenant, query string, from, through model.Time, tables ...resultsTable) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s:%s:%d:%d", route, tenant, from, through)
+	for _, t := range tables {
+		fmt.Fprintf(&sb, ":%s:%d", t.name, t.generation.ID)
+	}
+	sb.WriteString(":")
+	sb.WriteString(query)
+	return cache.HashKey(sb.String())
+}
+
+func (c *ResultsCache) fetch(ctx context.Context, route string, keys []string) map[string][]byte {
+	c.metrics.requests.WithLabelValues(route).Add(float64(len(keys)))
+
+	found, bufs, _, err := c.cache.Fetch(ctx, keys)
+	if err != nil {
+		level.Warn(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to fetch from the results cache", "route", route, "err", err)
+		return nil
+	}
+	c.metrics.hits.WithLabelValues(route).Add(float64(len(found)))
+
+	cached := make(map[string][]byte, len(found))
+	for i := range found {
+		cached[found[i]] = bufs[i]
+	}
+	return cached
+}
+
+func (c *ResultsCache) store(ctx context.Context, route string, keys []string, bufs [][]byte) {
+	if len(keys) == 0 {
+		return
+	}
+	if err := c.cache.Store(ctx, keys, bufs); err != nil {
+		level.Warn(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to store in the results cache", "route", route, "err", err)
+	}
+}
+
+type cachedResponse interface {
+	Marshal() ([]byte, error)
+	Unmarshal([]byte) error
+}
+
+// fetchByTable returns the responses of the query over [from, through], with one response per immutable table,
+// fetched from the cache or computed with run and cached, and one response per range of consecutive tables
+// which are not immutable.
+func fetchByTable[T any, PT interface {
+	*T
+	cachedResponse
+}](ctx context.Context, c *ResultsCache, route, tenant, query string, from, through model.Time, run func(ctx context.Context, from, through model.Time) (PT, error)) ([]PT, error) {
+	tables := c.tables(ctx, tenant, from, through)
+
+	keys := make([]string, len(tables))
+	immutableKeys := make([]string, 0, len(tables))
+	for i, t := range tables {
+		if t.immutable {
+			keys[i] = c.key(route, tenant, query, t.from, t.through, t)
+			immutableKeys = append(immutableKeys, keys[i])
+		}
+	}
+	if len(immutableKeys) == 0 {
+		resp, err := run(ctx, from, through)
+		if err != nil {
+			return nil, err
+		}
+		return []PT{resp}, nil
+	}
+
+	cached := c.fetch(ctx, route, immutableKeys)
+	responses := make([]PT, 0, len(tables))
+	var storeKeys []string
+	var storeBufs [][]byte
+	for i := 0; i < len(tables); i++ {
+		if !tables[i].immutable {
+			// query the consecutive tables which are not immutable at once.
+			j := i
+			for j+1 < len(tables) && !tables[j+1].immutable {
+				j++
+			}
+			resp, err := run(ctx, tables[i].from, tables[j].through)
+			if err != nil {
+				return nil, err
+			}
+			responses = append(responses, resp)
+			i = j
+			continue
+		}
+
+		if buf, ok := cached[keys[i]]; ok {
+			resp := PT(new(T))
+			if err := resp.Unmarshal(buf); err == nil {
+				responses = append(responses, resp)
+				continue
+			}
+			level.Warn(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to decode the cached results", "route", route, "table", tables[i].name)
+		}
+
+		resp, err := run(ctx, tables[i].from, tables[i].through)
+		if err != nil {
+			return nil, err
+		}
+		responses = append(responses, resp)
+		if buf, err := resp.Marshal(); err == nil {
+			storeKeys = append(storeKeys, keys[i])
+			storeBufs = append(storeBufs, buf)
+		}
+	}
+	c.store(ctx, route, storeKeys, storeBufs)
+
+	return responses, nil
+}
+
+// fetchWhole returns the response of the query over [from, through], cached only when all the tables of the range
+// are immutable.
+func fetchWhole[T any, PT interface {
+	*T
+	cachedResponse
+}](ctx context.Context, c *ResultsCache, route, tenant, query string, from, through model.Time, run func(ctx context.Context) (PT, error)) (PT, error) {
+	tables := c.tables(ctx, tenant, from, through)
+	for _, t := range tables {
+		if !t.immutable {
+			return run(ctx)
+		}
+	}
+
+	key := c.key(route, tenant, query, from, through, tables...)
+	if buf, ok := c.fetch(ctx, route, []string{key})[key]; ok {
+		resp := PT(new(T))
+		if err := resp.Unmarshal(buf); err == nil {
+			return resp, nil
+		}
+		level.Warn(util_log.WithContext(ctx, c.logger)).Log("msg", "failed to decode the cached results", "route", route)
+	}
+
+	resp, err := run(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if buf, err := resp.Marshal(); err == nil {
+		c.store(ctx, route, []string{key}, [][]byte{buf})
+	}
+	return resp, nil
+}
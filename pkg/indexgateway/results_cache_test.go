@@ -0,0 +1,214 @@
+package indexgateway
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/sharding"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+)
+
+const day = model.Time(24 * time.Hour / time.Millisecond)
+
+type fakeGenerations struct {
+	mtx         sync.Mutex
+	generations map[string]TableGeneration
+}
+
+func (f *fakeGenerations) Generation(_ context.Context, _ config.PeriodConfig, table, _ string) (TableGeneration, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.generations[table], nil
+}
+
+func (f *fakeGenerations) set(table string, generation TableGeneration) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	f.generations[table] = generation
+}
+
+// rangeIndexQuerier returns the chunks overlapping the queried range and records the queried ranges.
+type rangeIndexQuerier struct {
+	IndexQuerier
+
+	chunks  []chunk.Chunk
+	queries [][2]model.Time
+}
+
+func (q *rangeIndexQuerier) GetChunks(_ context.Context, _ string, from, through model.Time, _ chunk.Predicate, _ *logproto.ChunkRefGroup) ([][]chunk.Chunk, []*fetcher.Fetcher, error) {
+	q.queries = append(q.queries, [2]model.Time{from, through})
+	var chunks []chunk.Chunk
+	for _, chk := range q.chunks {
+		if chk.From <= through && chk.Through >= from {
+			chunks = append(chunks, chk)
+		}
+	}
+	return [][]chunk.Chunk{chunks}, nil, nil
+}
+
+func (q *rangeIndexQuerier) LabelNamesForMetricName(_ context.Context, _ string, from, through model.Time, _ string, _ ...*labels.Matcher) ([]string, error) {
+	q.queries = append(q.queries, [2]model.Time{from, through})
+	var names []string
+	for _, chk := range q.chunks {
+		if chk.From <= through && chk.Through >= from {
+			names = append(names, fmt.Sprintf("label_%d", chk.Checksum))
+		}
+	}
+	return names, nil
+}
+
+func (q *rangeIndexQuerier) HasForSeries(_, _ model.Time) (sharding.ForSeries, bool) {
+	return nil, false
+}
+
+func (q *rangeIndexQuerier) GetShards(_ context.Context, _ string, from, through model.Time, _ uint64, _ chunk.Predicate) (*logproto.ShardsResponse, error) {
+	q.queries = append(q.queries, [2]model.Time{from, through})
+	return &logproto.ShardsResponse{Shards: []logproto.Shard{{Bounds: logproto.FPBounds{Min: 0, Max: model.Fingerprint(len(q.queries))}}}}, nil
+}
+
+func (q *rangeIndexQuerier) reset() [][2]model.Time {
+	queries := q.queries
+	q.queries = nil
+	return queries
+}
+
+type shardsServerMock struct {
+	grpc.ServerStream
+	ctx  context.Context
+	resp *logproto.ShardsResponse
+}
+
+func (s *shardsServerMock) Context() context.Context {
+	return s.ctx
+}
+
+func (s *shardsServerMock) Send(resp *logproto.ShardsResponse) error {
+	s.resp = resp
+	return nil
+}
+
+func newResultsCacheGateway(t *testing.T, querier IndexQuerier, generations TableGenerations, now model.Time) *Gateway {
+	schemaConfig := config.SchemaConfig{
+		Configs: []config.PeriodConfig{{
+			From:      config.DayTime{Time: 0},
+			IndexType: types.TSDBType,
+			IndexTables: config.IndexPeriodicTableConfig{
+				PeriodicTableConfig: config.PeriodicTableConfig{
+					Prefix: "index_",
+					Period: config.ObjectStorageIndexRequiredPeriod,
+				}},
+		}},
+	}
+	cfg := ResultsCacheConfig{Enabled: true, ImmutableAfter: 30 * time.Minute, GenerationRefreshInterval: time.Minute}
+	resultsCache := NewResultsCache(cfg, cache.NewMockCache(), schemaConfig, generations, prometheus.NewRegistry(), log.NewNopLogger())
+	resultsCache.now = func() model.Time { return now }
+
+	gateway, err := NewIndexGateway(Config{}, mockLimits{}, log.NewNopLogger(), prometheus.NewRegistry(), querier, nil, nil, nil, resultsCache)
+	require.NoError(t, err)
+	return gateway
+}
+
+func TestResultsCache_GetChunkRef(t *testing.T) {
+	querier := &rangeIndexQuerier{
+		chunks: []chunk.Chunk{
+			{ChunkRef: logproto.ChunkRef{Fingerprint: 1, From: 1, Through: 10, Checksum: 1}},
+			// spans the first two tables.
+			{ChunkRef: logproto.ChunkRef{Fingerprint: 2, From: day - 10, Through: day + 10, Checksum: 2}},
+			{ChunkRef: logproto.ChunkRef{Fingerprint: 3, From: 2*day + 1, Through: 2*day + 10, Checksum: 3}},
+			{ChunkRef: logproto.ChunkRef{Fingerprint: 4, From: 3*day + 1, Through: 3*day + 10, Checksum: 4}},
+		},
+	}
+	generations := &fakeGenerations{generations: map[string]TableGeneration{}}
+	now := 3*day + model.Time(time.Hour/time.Millisecond)
+	gateway := newResultsCacheGateway(t, querier, generations, now)
+
+	ctx := user.InjectOrgID(context.Background(), "fake")
+	req := &logproto.GetChunkRefRequest{From: 0, Through: 3*day + 100, Matchers: `{app="foo"}`}
+	getChunkRef := func() []uint32 {
+		resp, err := gateway.GetChunkRef(ctx, req)
+		require.NoError(t, err)
+		var checksums []uint32
+		for _, ref := range resp.Refs {
+			checksums = append(checksums, ref.Checksum)
+		}
+		return checksums
+	}
+
+	// the immutable tables are queried one by one and the mutable one is queried live.
+	require.ElementsMatch(t, []uint32{1, 2, 3, 4}, getChunkRef())
+	require.Equal(t, [][2]model.Time{{0, day - 1}, {day, 2*day - 1}, {2 * day, 3*day - 1}, {3 * day, 3*day + 100}}, querier.reset())
+
+	require.ElementsMatch(t, []uint32{1, 2, 3, 4}, getChunkRef())
+	require.Equal(t, [][2]model.Time{{3 * day, 3*day + 100}}, querier.reset())
+
+	// a new generation of a table invalidates its cached results.
+	generations.set("index_1", TableGeneration{ID: 1})
+	require.ElementsMatch(t, []uint32{1, 2, 3, 4}, getChunkRef())
+	require.Equal(t, [][2]model.Time{{day, 2*day - 1}, {3 * day, 3*day + 100}}, querier.reset())
+
+	// the tables updated recently are not immutable, and are queried live with the following ones.
+	generations.set("index_2", TableGeneration{ID: 2, UpdatedAt: now.Time()})
+	require.ElementsMatch(t, []uint32{1, 2, 3, 4}, getChunkRef())
+	require.Equal(t, [][2]model.Time{{2 * day, 3*day + 100}}, querier.reset())
+
+	// the other queries have their own results.
+	req.Matchers = `{app="bar"}`
+	require.ElementsMatch(t, []uint32{1, 2, 3, 4}, getChunkRef())
+	require.Len(t, querier.reset(), 3)
+}
+
+func TestResultsCache_LabelNamesForMetricName(t *testing.T) {
+	querier := &rangeIndexQuerier{
+		chunks: []chunk.Chunk{
+			{ChunkRef: logproto.ChunkRef{Fingerprint: 1, From: day - 10, Through: day + 10, Checksum: 1}},
+			{ChunkRef: logproto.ChunkRef{Fingerprint: 2, From: day + 1, Through: day + 10, Checksum: 2}},
+		},
+	}
+	gateway := newResultsCacheGateway(t, querier, &fakeGenerations{generations: map[string]TableGeneration{}}, 3*day)
+
+	ctx := user.InjectOrgID(context.Background(), "fake")
+	req := &logproto.LabelNamesForMetricNameRequest{From: 0, Through: 2*day - 1, MetricName: "logs", Matchers: `{app="foo"}`}
+	for i := 0; i < 2; i++ {
+		resp, err := gateway.LabelNamesForMetricName(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"label_1", "label_2"}, resp.Values)
+	}
+	require.Len(t, querier.reset(), 2)
+}
+
+func TestResultsCache_GetShards(t *testing.T) {
+	querier := &rangeIndexQuerier{}
+	gateway := newResultsCacheGateway(t, querier, &fakeGenerations{generations: map[string]TableGeneration{}}, 3*day)
+
+	server := &shardsServerMock{ctx: user.InjectOrgID(context.Background(), "fake")}
+	getShards := func(through model.Time) model.Fingerprint {
+		req := &logproto.ShardsRequest{From: 0, Through: through, Query: `{app="foo"}`, TargetBytesPerShard: 1 << 20}
+		require.NoError(t, gateway.GetShards(req, server))
+		return server.resp.Shards[0].Bounds.Max
+	}
+
+	// the shards of the queries over immutable tables only are cached.
+	require.Equal(t, model.Fingerprint(1), getShards(2*day-1))
+	require.Equal(t, model.Fingerprint(1), getShards(2*day-1))
+	require.Len(t, querier.reset(), 1)
+
+	require.Equal(t, model.Fingerprint(1), getShards(3*day))
+	require.Equal(t, model.Fingerprint(2), getShards(3*day))
+	require.Len(t, querier.reset(), 2)
+}
@@ -0,0 +1,131 @@
+package indexgateway
+
+import (
+	"context"
+	"encoding/binary"
+	"hash/fnv"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+// TableGeneration identifies the state of the index of a tenant in a table.
+type TableGeneration struct {
+	// ID changes whenever index files of the tenant in the table are uploaded, compacted or deleted.
+	ID uint64
+	// UpdatedAt is the time of the last change of the index of the tenant in the table.
+	UpdatedAt time.Time
+}
+
+// TableGenerations returns the generations of the index of the tenants in the tables.
+type TableGenerations interface {
+	Generation(ctx context.Context, period config.PeriodConfig, table, tenant string) (TableGeneration, error)
+}
+
+// indexStorageGenerations computes the generations of the tables from the listing of their index files
+// in the object stores of the periods, listed again after the refresh interval.
+type indexStorageGenerations struct {
+	clients         map[config.DayTime]storage.Client
+	refreshInterval time.Duration
+	// tombstones adds the tombstones written by the compactor to the index of the tenants.
+	tombstones bool
+
+	mtx    sync.Mutex
+	tables map[string]*tableFiles
+}
+
+type tableFiles struct {
+	mtx         sync.Mutex
+	refreshedAt time.Time
+	tombstones  []storage.IndexFile
+	// seen are the generations computed for the tenants, to detect the deleted files.
+	seen map[string]TableGeneration
+}
+
+// NewIndexStorageGenerations returns the generations of the tables of the periods whose index is stored
+// with the given clients.
+func NewIndexStorageGenerations(clients map[config.DayTime]storage.Client, refreshInterval time.Duration, tombstones bool) TableGenerations {
+	return &indexStorageGenerations{
+		clients:         clients,
+		refreshInterval: refreshInterval,
+		tombstones:      tombstones,
+		tables:          map[string]*tableFiles{},
+	}
+}
+
+func (g *indexStorageGenerations) Generation(ctx context.Context, period config.PeriodConfig, table, tenant string) (TableGeneration, error) {
+	client, ok := g.clients[period.From]
+	if !ok {
+		return TableGeneration{}, errNoTableGeneration
+	}
+
+	g.mtx.Lock()
+	tf, ok := g.tables[table]
+	if !ok {
+		tf = &tableFiles{seen: map[string]TableGeneration{}}
+		g.tables[table] = tf
+	}
+	g.mtx.Unlock()
+
+	tf.mtx.Lock()
+	defer tf.mtx.Unlock()
+
+	if time.Since(tf.refreshedAt) >= g.refreshInterval {
+		client.RefreshIndexTableCache(ctx, table)
+		if g.tombstones {
+			files, err := tombstones.Tenants(ctx, client, table)
+			if err != nil {
+				return TableGeneration{}, err
+			}
+			tf.tombstones = files
+		}
+		tf.refreshedAt = time.Now()
+	}
+
+	commonFiles, _, err := client.ListFiles(ctx, table, false)
+	if err != nil {
+		return TableGeneration{}, err
+	}
+	userFiles, err := client.ListUserFiles(ctx, table, tenant, false)
+	if err != nil {
+		return TableGeneration{}, err
+	}
+	files := make([]storage.IndexFile, 0, len(commonFiles)+len(userFiles)+1)
+	files = append(files, commonFiles...)
+	files = append(files, userFiles...)
+	for _, file := range tf.tombstones {
+		if file.Name == tenant {
+			files = append(files, storage.IndexFile{Name: tombstones.TableName, ModifiedAt: file.ModifiedAt})
+		}
+	}
+
+	generation := generationOf(files)
+	if seen, ok := tf.seen[tenant]; ok && seen.ID != generation.ID && !generation.UpdatedAt.After(seen.UpdatedAt) {
+		// files were deleted without new uploads.
+		generation.UpdatedAt = time.Now()
+	} else if ok && seen.ID == generation.ID {
+		generation = seen
+	}
+	tf.seen[tenant] = generation
+	return generation, nil
+}
+
+func generationOf(files []storage.IndexFile) TableGeneration {
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+
+	var generation TableGeneration
+	h := fnv.New64a()
+	for _, file := range files {
+		_, _ = h.Write([]byte(file.Name))
+		_, _ = h.Write(binary.BigEndian.AppendUint64(nil, uint64(file.ModifiedAt.UnixNano())))
+		if file.ModifiedAt.After(generation.UpdatedAt) {
+			generation.UpdatedAt = file.ModifiedAt
+		}
+	}
+	generation.ID = h.Sum64()
+	return generation
+}
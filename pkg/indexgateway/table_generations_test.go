@@ -0,0 +1,49 @@
+package indexgateway
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+func TestIndexStorageGenerations(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	client := storage.NewIndexStorageClient(objectClient, "index/")
+	period := config.PeriodConfig{From: config.DayTime{Time: 0}}
+	generations := NewIndexStorageGenerations(map[config.DayTime]storage.Client{period.From: client}, time.Nanosecond, false)
+
+	ctx := context.Background()
+	generation := func(tenant string) TableGeneration {
+		g, err := generations.Generation(ctx, period, "index_1", tenant)
+		require.NoError(t, err)
+		return g
+	}
+
+	require.NoError(t, client.PutUserFile(ctx, "index_1", "fake", "1.tsdb", bytes.NewReader([]byte("index"))))
+	first := generation("fake")
+	require.False(t, first.UpdatedAt.IsZero())
+	require.Equal(t, first, generation("fake"))
+	require.NotEqual(t, first.ID, generation("other").ID)
+
+	// uploads change the generation.
+	require.NoError(t, client.PutUserFile(ctx, "index_1", "fake", "2.tsdb", bytes.NewReader([]byte("index"))))
+	second := generation("fake")
+	require.NotEqual(t, first.ID, second.ID)
+
+	// deletions change the generation and its update time.
+	require.NoError(t, client.DeleteUserFile(ctx, "index_1", "fake", "2.tsdb"))
+	third := generation("fake")
+	require.NotEqual(t, second.ID, third.ID)
+	require.True(t, third.UpdatedAt.After(second.UpdatedAt))
+
+	_, err = generations.Generation(ctx, config.PeriodConfig{From: config.DayTime{Time: 1}}, "index_1", "fake")
+	require.ErrorIs(t, err, errNoTableGeneration)
+}
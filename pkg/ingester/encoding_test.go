@@ -95,7 +95,7 @@ func Test_EncodingChunks(t *testing.T) {
 
 				_, headfmt := defaultChunkFormat(t)
 
-				backAgain, err := fromWireChunks(&conf, headfmt, chunks)
+				backAgain, err := fromWireChunks(&conf, "fake", headfmt, chunks)
 				require.Nil(t, err)
 
 				for i, to := range backAgain {
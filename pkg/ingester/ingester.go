@@ -100,6 +100,7 @@ type Config struct {
 	TargetChunkSize     int               `yaml:"chunk_target_size"`
 	ChunkEncoding       string            `yaml:"chunk_encoding"`
 	parsedEncoding      compression.Codec `yaml:"-"` // placeholder for validated encoding
+	zstdDictionaries    *zstdDictionaries `yaml:"-"` // set when the chunks are compressed with the trained dictionaries of the tenants
 	MaxChunkAge         time.Duration     `yaml:"max_chunk_age"`
 	AutoForgetUnhealthy bool              `yaml:"autoforget_unhealthy"`
 
@@ -133,6 +134,8 @@ type Config struct {
 	OwnedStreamsCheckInterval time.Duration `yaml:"owned_streams_check_interval" doc:"description=Interval at which the ingester ownedStreamService checks for changes in the ring to recalculate owned streams."`
 
 	KafkaIngestion KafkaIngestionConfig `yaml:"kafka_ingestion,omitempty"`
+
+	ZstdDictionaries ZstdDictionariesConfig `yaml:"zstd_dictionaries" doc:"description=Configures the training of the per tenant dictionaries of the chunks compressed with the zstd-dict encoding. The dictionaries are trained from lines sampled from the pushes and published to the object store, from where the readers of the chunks fetch them."`
 }
 
 // RegisterFlags registers the flags.
@@ -140,6 +143,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.LifecyclerConfig.RegisterFlags(f, util_log.Logger)
 	cfg.WAL.RegisterFlags(f)
 	cfg.KafkaIngestion.RegisterFlags(f)
+	cfg.ZstdDictionaries.RegisterFlags(f)
 
 	f.IntVar(&cfg.ConcurrentFlushes, "ingester.concurrent-flushes", 32, "How many flushes can happen concurrently from each stream.")
 	f.DurationVar(&cfg.FlushCheckPeriod, "ingester.flush-check-period", 30*time.Second, "How often should the ingester see if there are any blocks to flush. The first flush check is delayed by a random time up to 0.8x the flush check period. Additionally, there is +/- 1% jitter added to the interval.")
@@ -171,6 +175,12 @@ func (cfg *Config) Validate() error {
 	}
 	cfg.parsedEncoding = enc
 
+	if enc == compression.ZstdDict {
+		if err = cfg.ZstdDictionaries.Validate(); err != nil {
+			return err
+		}
+	}
+
 	if err = cfg.WAL.Validate(); err != nil {
 		return err
 	}
@@ -337,6 +347,10 @@ func New(cfg Config, clientConfig client.Config, store Store, limits Limits, con
 	}
 	i.replayController = newReplayController(metrics, cfg.WAL, &replayFlusher{i})
 
+	if cfg.parsedEncoding == compression.ZstdDict && cfg.ZstdDictionaries.ObjectClient != nil {
+		i.cfg.zstdDictionaries = newZstdDictionaries(cfg.ZstdDictionaries, logger)
+	}
+
 	if cfg.WAL.Enabled {
 		if err := os.MkdirAll(cfg.WAL.Dir, 0o750); err != nil {
 			// Best effort try to make path absolute for easier debugging.
@@ -784,11 +798,22 @@ func (i *Ingester) loop() {
 	flushTicker := util.NewTickerWithJitter(i.cfg.FlushCheckPeriod, j)
 	defer flushTicker.Stop()
 
+	// the dictionaries of the tenants are only trained when the chunks are compressed with them.
+	var trainDictionaries <-chan time.Time
+	if i.cfg.zstdDictionaries != nil {
+		trainTicker := time.NewTicker(i.cfg.ZstdDictionaries.TrainingInterval)
+		defer trainTicker.Stop()
+		trainDictionaries = trainTicker.C
+	}
+
 	for {
 		select {
 		case <-flushTicker.C:
 			i.sweepUsers(false, true)
 
+		case <-trainDictionaries:
+			i.cfg.zstdDictionaries.train(context.Background())
+
 		case <-i.loopQuit:
 			return
 		}
@@ -1316,21 +1341,31 @@ func (i *Ingester) Label(ctx context.Context, req *logproto.LabelRequest) (*logp
 		return resp, nil
 	}
 	from, through := model.TimeFromUnixNano(start.UnixNano()), model.TimeFromUnixNano(req.End.UnixNano())
-	var storeValues []string
-	if req.Values {
-		storeValues, err = cs.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name, matchers...)
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		storeValues, err = cs.LabelNamesForMetricName(ctx, userID, from, through, "logs", matchers...)
+	if !req.Values {
+		storeValues, err := cs.LabelNamesForMetricName(ctx, userID, from, through, "logs", matchers...)
 		if err != nil {
 			return nil, err
 		}
+		return &logproto.LabelResponse{
+			Values: util.MergeStringLists(resp.Values, storeValues),
+		}, nil
 	}
 
+	valueMatchers, err := req.ValueMatchers()
+	if err != nil {
+		return nil, err
+	}
+	storeValues, err := cs.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name, append(matchers, valueMatchers...)...)
+	if err != nil {
+		return nil, err
+	}
+	// apply the limit to the merged values.
+	values, err := req.FilterValues(util.MergeStringLists(resp.Values, storeValues))
+	if err != nil {
+		return nil, err
+	}
 	return &logproto.LabelResponse{
-		Values: util.MergeStringLists(resp.Values, storeValues),
+		Values: values,
 	}, nil
 }
 
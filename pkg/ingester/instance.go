@@ -623,8 +623,11 @@ func (i *instance) label(ctx context.Context, req *logproto.LabelRequest, matche
 			if err != nil {
 				return nil, err
 			}
-			labels = make([]string, len(values))
-			copy(labels, values)
+			// FilterValues copies the values of the index.
+			labels, err = req.FilterValues(values)
+			if err != nil {
+				return nil, err
+			}
 			return &logproto.LabelResponse{
 				Values: labels,
 			}, nil
@@ -657,8 +660,15 @@ func (i *instance) label(ctx context.Context, req *logproto.LabelRequest, matche
 		return nil, err
 	}
 
+	values := labels.Strings()
+	if req.Values {
+		values, err = req.FilterValues(values)
+		if err != nil {
+			return nil, err
+		}
+	}
 	return &logproto.LabelResponse{
-		Values: labels.Strings(),
+		Values: values,
 	}, nil
 }
 
@@ -395,6 +395,35 @@ func Test_LabelQuery(t *testing.T) {
 			},
 			[]*labels.Matcher{m},
 		},
+		{
+			"label values - with value filter",
+			&logproto.LabelRequest{
+				Name:       "app",
+				Values:     true,
+				Start:      start,
+				End:        end,
+				MatchRegex: ".*2",
+			},
+			logproto.LabelResponse{
+				Values: []string{"test2"},
+			},
+			nil,
+		},
+		{
+			"label values - with limit",
+			&logproto.LabelRequest{
+				Name:        "app",
+				Values:      true,
+				Start:       start,
+				End:         end,
+				MatchPrefix: "test",
+				Limit:       1,
+			},
+			logproto.LabelResponse{
+				Values: []string{"test"},
+			},
+			nil,
+		},
 	}
 
 	for _, tc := range tests {
@@ -16,6 +16,7 @@ import (
 	"github.com/prometheus/prometheus/model/labels"
 
 	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
 	"github.com/grafana/loki/v3/pkg/distributor/writefailures"
 	"github.com/grafana/loki/v3/pkg/ingester/wal"
 	"github.com/grafana/loki/v3/pkg/iter"
@@ -146,7 +147,7 @@ func newStream(
 // Must hold chunkMtx
 // DEPRECATED: chunk transfers are no longer suggested and remain for compatibility.
 func (s *stream) consumeChunk(_ context.Context, chunk *logproto.Chunk) error {
-	c, err := chunkenc.NewByteChunk(chunk.Data, s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	c, err := chunkenc.NewTenantByteChunk(s.tenant, chunk.Data, s.cfg.BlockSize, s.cfg.TargetChunkSize)
 	if err != nil {
 		return err
 	}
@@ -162,7 +163,7 @@ func (s *stream) consumeChunk(_ context.Context, chunk *logproto.Chunk) error {
 func (s *stream) setChunks(chunks []Chunk) (bytesAdded, entriesAdded int, err error) {
 	s.chunkMtx.Lock()
 	defer s.chunkMtx.Unlock()
-	chks, err := fromWireChunks(s.cfg, s.chunkHeadBlockFormat, chunks)
+	chks, err := fromWireChunks(s.cfg, s.tenant, s.chunkHeadBlockFormat, chunks)
 	if err != nil {
 		return 0, 0, err
 	}
@@ -175,6 +176,9 @@ func (s *stream) setChunks(chunks []Chunk) (bytesAdded, entriesAdded int, err er
 }
 
 func (s *stream) NewChunk() *chunkenc.MemChunk {
+	if s.cfg.parsedEncoding == compression.ZstdDict {
+		return chunkenc.NewMemChunkWithZstdDictionary(s.chunkFormat, s.cfg.zstdDictionaries.dictionary(s.tenant), s.chunkHeadBlockFormat, s.cfg.BlockSize, s.cfg.TargetChunkSize)
+	}
 	return chunkenc.NewMemChunk(s.chunkFormat, s.cfg.parsedEncoding, s.chunkHeadBlockFormat, s.cfg.BlockSize, s.cfg.TargetChunkSize)
 }
 
@@ -370,6 +374,7 @@ func (s *stream) storeEntries(ctx context.Context, entries []logproto.Entry, usa
 		storedEntries = append(storedEntries, entries[i])
 	}
 	s.reportMetrics(ctx, outOfOrderSamples, outOfOrderBytes, 0, 0, usageTracker)
+	s.cfg.zstdDictionaries.sample(s.tenant, storedEntries)
 	return bytesAdded, storedEntries, invalid
 }
 
@@ -0,0 +1,176 @@
+package ingester
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/util/flagext"
+)
+
+// ZstdDictionariesConfig configures the training of the per tenant dictionaries of zstd-dict chunks.
+type ZstdDictionariesConfig struct {
+	TrainingInterval time.Duration    `yaml:"training_interval"`
+	MinSampledLines  int              `yaml:"min_sampled_lines"`
+	MaxSampledLines  int              `yaml:"max_sampled_lines"`
+	MaxSize          flagext.ByteSize `yaml:"max_size"`
+
+	// ObjectClient is where the trained dictionaries are published, it is set by the ingester module.
+	ObjectClient client.ObjectClient `yaml:"-"`
+}
+
+// RegisterFlags registers the flags.
+func (cfg *ZstdDictionariesConfig) RegisterFlags(f *flag.FlagSet) {
+	f.DurationVar(&cfg.TrainingInterval, "ingester.zstd-dictionaries.training-interval", time.Hour, "How often the dictionaries of the tenants are trained from their sampled lines, when the chunk encoding is zstd-dict. Must not exceed 24h, as the current dictionaries are published again at each training to keep them from being expired by retention.")
+	f.IntVar(&cfg.MinSampledLines, "ingester.zstd-dictionaries.min-sampled-lines", 1000, "Minimum number of lines sampled from a tenant to train its dictionary.")
+	f.IntVar(&cfg.MaxSampledLines, "ingester.zstd-dictionaries.max-sampled-lines", 10000, "Maximum number of lines sampled per tenant between two trainings.")
+	cfg.MaxSize = 64 << 10
+	f.Var(&cfg.MaxSize, "ingester.zstd-dictionaries.max-size", "Maximum size of the trained dictionaries. A unit suffix (KB, MB, GB) may be applied.")
+}
+
+// Validate validates the config.
+func (cfg *ZstdDictionariesConfig) Validate() error {
+	if cfg.TrainingInterval <= 0 {
+		return errors.New("invalid zstd dictionaries training interval: must be positive")
+	}
+	// the compactor expires the dictionaries which were not published again for a day past the retention period.
+	if cfg.TrainingInterval > 24*time.Hour {
+		return errors.New("invalid zstd dictionaries training interval: must not exceed 24h")
+	}
+	if cfg.MinSampledLines <= 0 || cfg.MinSampledLines > cfg.MaxSampledLines {
+		return errors.New("invalid zstd dictionaries sampled lines: min must be positive and not greater than max")
+	}
+	if cfg.MaxSize < 1<<10 {
+		return errors.New("invalid zstd dictionaries max size: must be at least 1KB")
+	}
+	return nil
+}
+
+// zstdDictionaries samples the lines pushed by the tenants and periodically trains a dictionary for each of them.
+// The dictionaries are published to object storage before the chunks start using them so that readers can always fetch them.
+// The current dictionaries are published again at every training, so that the compactor only expires the dictionaries
+// which are no longer used.
+type zstdDictionaries struct {
+	cfg    ZstdDictionariesConfig
+	logger log.Logger
+
+	mtx     sync.Mutex
+	tenants map[string]*tenantZstdDictionary
+}
+
+type tenantZstdDictionary struct {
+	// samples is a reservoir of the lines pushed since the last training.
+	samples [][]byte
+	seen    int
+
+	current *compression.ZstdDictPool
+}
+
+func newZstdDictionaries(cfg ZstdDictionariesConfig, logger log.Logger) *zstdDictionaries {
+	return &zstdDictionaries{
+		cfg:     cfg,
+		logger:  log.With(logger, "component", "zstd-dictionaries"),
+		tenants: map[string]*tenantZstdDictionary{},
+	}
+}
+
+// sample adds the lines of the entries to the samples of the tenant.
+func (d *zstdDictionaries) sample(tenant string, entries []logproto.Entry) {
+	if d == nil || len(entries) == 0 {
+		return
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+
+	t, ok := d.tenants[tenant]
+	if !ok {
+		t = &tenantZstdDictionary{}
+		d.tenants[tenant] = t
+	}
+	for _, e := range entries {
+		t.seen++
+		if len(t.samples) < d.cfg.MaxSampledLines {
+			t.samples = append(t.samples, []byte(e.Line))
+			continue
+		}
+		if i := rand.Intn(t.seen); i < len(t.samples) { //#nosec G404 -- Sampling does not require a CSPRNG.
+			t.samples[i] = []byte(e.Line)
+		}
+	}
+}
+
+// dictionary returns the current dictionary of the tenant, nil when none was trained yet.
+func (d *zstdDictionaries) dictionary(tenant string) *compression.ZstdDictPool {
+	if d == nil {
+		return nil
+	}
+
+	d.mtx.Lock()
+	defer d.mtx.Unlock()
+	if t, ok := d.tenants[tenant]; ok {
+		return t.current
+	}
+	return nil
+}
+
+// train trains and publishes a new dictionary for each tenant having enough samples, and publishes again the current
+// dictionary of the other tenants.
+func (d *zstdDictionaries) train(ctx context.Context) {
+	d.mtx.Lock()
+	pending := map[string][][]byte{}
+	current := map[string]*compression.ZstdDictPool{}
+	for tenant, t := range d.tenants {
+		if len(t.samples) < d.cfg.MinSampledLines {
+			if t.current != nil {
+				current[tenant] = t.current
+			}
+			continue
+		}
+		pending[tenant] = t.samples
+		t.samples, t.seen = nil, 0
+	}
+	d.mtx.Unlock()
+
+	for tenant, pool := range current {
+		if err := d.cfg.ObjectClient.PutObject(ctx, client.ZstdDictionaryObjectKey(tenant, pool.ID()), bytes.NewReader(pool.Dictionary())); err != nil {
+			level.Warn(d.logger).Log("msg", "failed to publish zstd dictionary", "tenant", tenant, "id", pool.ID(), "err", err)
+		}
+	}
+
+	for tenant, samples := range pending {
+		pool, err := d.trainTenant(ctx, tenant, samples)
+		if err != nil {
+			level.Warn(d.logger).Log("msg", "failed to train zstd dictionary", "tenant", tenant, "err", err)
+			continue
+		}
+		level.Info(d.logger).Log("msg", "trained zstd dictionary", "tenant", tenant, "id", pool.ID(), "samples", len(samples), "size", len(pool.Dictionary()))
+
+		d.mtx.Lock()
+		d.tenants[tenant].current = pool
+		d.mtx.Unlock()
+	}
+}
+
+func (d *zstdDictionaries) trainTenant(ctx context.Context, tenant string, samples [][]byte) (*compression.ZstdDictPool, error) {
+	dict, err := compression.TrainZstdDictionary(samples, d.cfg.MaxSize.Val())
+	if err != nil {
+		return nil, err
+	}
+	// the stored dictionary may have another ID than the trained one, when its ID was used already.
+	dict, err = client.PutZstdDictionary(ctx, d.cfg.ObjectClient, tenant, dict)
+	if err != nil {
+		return nil, err
+	}
+	return compression.RegisterZstdDictionary(tenant, dict)
+}
@@ -0,0 +1,77 @@
+package ingester
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+)
+
+func TestZstdDictionaries(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	d := newZstdDictionaries(ZstdDictionariesConfig{
+		TrainingInterval: time.Hour,
+		MinSampledLines:  100,
+		MaxSampledLines:  500,
+		MaxSize:          4 << 10,
+		ObjectClient:     objectClient,
+	}, log.NewNopLogger())
+
+	entries := make([]logproto.Entry, 0, 1000)
+	for i := 0; i < 1000; i++ {
+		entries = append(entries, logproto.Entry{
+			Timestamp: time.Unix(int64(i), 0),
+			Line:      fmt.Sprintf(`level=info caller=handler.go:42 msg="request served" path=/api/v1/items/%d status=200 duration=%dms`, i, i%13),
+		})
+	}
+	d.sample("tenant-a", entries)
+	d.sample("tenant-b", entries[:10])
+	require.Len(t, d.tenants["tenant-a"].samples, 500)
+	require.Equal(t, 1000, d.tenants["tenant-a"].seen)
+
+	d.train(context.Background())
+
+	// tenants without enough samples keep sampling.
+	require.Nil(t, d.dictionary("tenant-b"))
+	require.Len(t, d.tenants["tenant-b"].samples, 10)
+
+	pool := d.dictionary("tenant-a")
+	require.NotNil(t, pool)
+	require.Empty(t, d.tenants["tenant-a"].samples)
+
+	// the dictionary is published and can be resolved by the readers.
+	key := client.ZstdDictionaryObjectKey("tenant-a", pool.ID())
+	published, err := client.GetZstdDictionary(context.Background(), objectClient, key)
+	require.NoError(t, err)
+	require.Equal(t, pool.Dictionary(), published)
+
+	resolved, err := compression.GetZstdDictPool(context.Background(), "tenant-a", pool.ID())
+	require.NoError(t, err)
+	require.Equal(t, pool.ID(), resolved.ID())
+	_, err = compression.GetZstdDictPool(context.Background(), "tenant-b", pool.ID())
+	require.Error(t, err)
+
+	// the current dictionary is published again by the next training.
+	require.NoError(t, objectClient.DeleteObject(context.Background(), key))
+	d.train(context.Background())
+	require.Equal(t, pool, d.dictionary("tenant-a"))
+	published, err = client.GetZstdDictionary(context.Background(), objectClient, key)
+	require.NoError(t, err)
+	require.Equal(t, pool.Dictionary(), published)
+}
+
+func TestZstdDictionaries_Disabled(t *testing.T) {
+	var d *zstdDictionaries
+	d.sample("tenant", []logproto.Entry{{Line: "foo"}})
+	require.Nil(t, d.dictionary("tenant"))
+}
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/metadata"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
@@ -355,6 +356,9 @@ type queryClientIterator struct {
 	direction logproto.Direction
 	err       error
 	curr      EntryIterator
+	// tracker accounts the size of the current batch for the query.
+	tracker  *memory.Tracker
+	currSize int64
 }
 
 // NewQueryClientIterator returns an iterator over a QueryClient.
@@ -377,6 +381,10 @@ func (i *queryClientIterator) Next() bool {
 		}
 		stats.JoinIngesters(ctx, batch.Stats)
 		_ = metadata.AddWarnings(ctx, batch.Warnings...)
+		if err := i.track(ctx, int64(batch.Size())); err != nil {
+			i.err = err
+			return false
+		}
 		i.curr = NewQueryResponseIterator(batch, i.direction)
 	}
 
@@ -398,9 +406,21 @@ func (i *queryClientIterator) Err() error {
 }
 
 func (i *queryClientIterator) Close() error {
+	i.tracker.Release(i.currSize)
+	i.currSize = 0
 	return i.client.CloseSend()
 }
 
+// track replaces the accounted size of the previous batch by the size of the new one.
+func (i *queryClientIterator) track(ctx context.Context, size int64) error {
+	if i.tracker == nil {
+		i.tracker = memory.FromContext(ctx)
+	}
+	i.tracker.Release(i.currSize)
+	i.currSize = size
+	return i.tracker.Reserve(size)
+}
+
 type nonOverlappingIterator struct {
 	iterators []EntryIterator
 	curr      EntryIterator
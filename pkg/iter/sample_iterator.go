@@ -6,6 +6,7 @@ import (
 	"io"
 	"sync"
 
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/metadata"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
@@ -467,6 +468,9 @@ type sampleQueryClientIterator struct {
 	client QuerySampleClient
 	err    error
 	curr   SampleIterator
+	// tracker accounts the size of the current batch for the query.
+	tracker  *memory.Tracker
+	currSize int64
 }
 
 // QuerySampleClient is GRPC stream client with only method used by the SampleQueryClientIterator
@@ -495,6 +499,10 @@ func (i *sampleQueryClientIterator) Next() bool {
 		}
 		stats.JoinIngesters(ctx, batch.Stats)
 		_ = metadata.AddWarnings(ctx, batch.Warnings...)
+		if err := i.track(ctx, int64(batch.Size())); err != nil {
+			i.err = err
+			return false
+		}
 
 		i.curr = NewSampleQueryResponseIterator(batch)
 	}
@@ -518,9 +526,21 @@ func (i *sampleQueryClientIterator) Err() error {
 }
 
 func (i *sampleQueryClientIterator) Close() error {
+	i.tracker.Release(i.currSize)
+	i.currSize = 0
 	return i.client.CloseSend()
 }
 
+// track replaces the accounted size of the previous batch by the size of the new one.
+func (i *sampleQueryClientIterator) track(ctx context.Context, size int64) error {
+	if i.tracker == nil {
+		i.tracker = memory.FromContext(ctx)
+	}
+	i.tracker.Release(i.currSize)
+	i.currSize = size
+	return i.tracker.Reserve(size)
+}
+
 // NewSampleQueryResponseIterator returns an iterator over a SampleQueryResponse.
 func NewSampleQueryResponseIterator(resp *logproto.SampleQueryResponse) SampleIterator {
 	return NewMultiSeriesIterator(resp.Series)
@@ -22,6 +22,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logcli/volume"
 	"github.com/grafana/loki/v3/pkg/loghttp"
 	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/analyze"
 	"github.com/grafana/loki/v3/pkg/storage/stores/index/seriesvolume"
 	"github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/build"
@@ -85,6 +86,7 @@ type DefaultClient struct {
 	Retries          int
 	QueryTags        string
 	NoCache          bool
+	Analyze          bool
 	AuthHeader       string
 	ProxyURL         string
 	BackoffConfig    BackoffConfig
@@ -101,6 +103,9 @@ func (c *DefaultClient) Query(queryStr string, limit int, time time.Time, direct
 	qsb.SetInt("limit", int64(limit))
 	qsb.SetInt("time", time.UnixNano())
 	qsb.SetString("direction", direction.String())
+	if c.Analyze {
+		qsb.SetString("explain", analyze.Explain)
+	}
 
 	return c.doQuery(queryPath, qsb.Encode(), quiet)
 }
@@ -126,6 +131,10 @@ func (c *DefaultClient) QueryRange(queryStr string, limit int, start, end time.T
 		params.SetFloat("interval", interval.Seconds())
 	}
 
+	if c.Analyze {
+		params.SetString("explain", analyze.Explain)
+	}
+
 	return c.doQuery(queryRangePath, params.Encode(), quiet)
 }
 
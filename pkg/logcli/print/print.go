@@ -8,13 +8,17 @@ import (
 	"sort"
 	"strings"
 	"text/tabwriter"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/fatih/color"
 
 	"github.com/grafana/loki/v3/pkg/logcli/output"
 	"github.com/grafana/loki/v3/pkg/logcli/util"
 	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/analyze"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 )
 
@@ -217,6 +221,41 @@ func (r *QueryResultPrinter) PrintStats(stats stats.Result) {
 	stats.Log(kvLogger{Writer: writer})
 }
 
+// PrintAnalysis prints the executed plan of a query executed with EXPLAIN ANALYZE.
+func (r *QueryResultPrinter) PrintAnalysis(node *analyze.Node) {
+	if node == nil {
+		return
+	}
+	tree := logql.NewTree()
+	printAnalysisNode(tree, node)
+	fmt.Fprint(os.Stderr, tree.String())
+}
+
+func printAnalysisNode(parent logql.Node, node *analyze.Node) {
+	execTime := "-"
+	if node.ExecTime != nil {
+		execTime = time.Duration(*node.ExecTime * float64(time.Second)).Round(time.Microsecond).String()
+	}
+	text := fmt.Sprintf("%s %s (%s) bytes=%s lines=%d chunks=%d/%d cache=%d/%d",
+		node.Name,
+		node.Details,
+		execTime,
+		humanize.Bytes(uint64(node.Stats.BytesProcessed)),
+		node.Stats.LinesProcessed,
+		node.Stats.ChunksDownloaded,
+		node.Stats.ChunksRef,
+		node.Stats.CacheHits,
+		node.Stats.CacheRequests,
+	)
+	if node.Query != "" {
+		text += "\n" + node.Query
+	}
+	n := parent.Child(text)
+	for _, child := range node.Children {
+		printAnalysisNode(n, child)
+	}
+}
+
 func matchLabels(on bool, l loghttp.LabelSet, names []string) loghttp.LabelSet {
 	return util.MatchLabels(on, l, names)
 }
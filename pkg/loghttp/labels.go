@@ -2,6 +2,7 @@ package loghttp
 
 import (
 	"errors"
+	"fmt"
 	"net/http"
 	"sort"
 	"strconv"
@@ -13,6 +14,8 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 )
 
+const labelSortAlphabetical = "alphabetical"
+
 // LabelResponse represents the http json response to a label query
 type LabelResponse struct {
 	Status string   `json:"status"`
@@ -85,6 +88,30 @@ func ParseLabelQuery(r *http.Request) (*logproto.LabelRequest, error) {
 	req.End = &end
 
 	req.Query = query(r)
+
+	req.MatchPrefix = r.Form.Get("match_prefix")
+	req.MatchRegex = r.Form.Get("match_regex")
+	req.Sort = r.Form.Get("sort")
+	l, err := parseInt(r.Form.Get("limit"), 0)
+	if err != nil {
+		return nil, err
+	}
+	if l < 0 {
+		return nil, errors.New("limit must not be negative")
+	}
+	req.Limit = uint32(l)
+
+	if !req.Values && (req.MatchPrefix != "" || req.MatchRegex != "" || req.Limit > 0 || req.Sort != "") {
+		return nil, errors.New("match_prefix, match_regex, limit and sort are only supported for label values")
+	}
+	switch req.Sort {
+	case "", labelSortAlphabetical, logproto.LabelSortFrequency:
+	default:
+		return nil, fmt.Errorf("invalid sort %q, must be %q or %q", req.Sort, labelSortAlphabetical, logproto.LabelSortFrequency)
+	}
+	if _, err := req.ValueMatchers(); err != nil {
+		return nil, fmt.Errorf("invalid match_regex: %w", err)
+	}
 	return req, nil
 }
 
@@ -41,6 +41,35 @@ func TestParseLabelQuery(t *testing.T) {
 				Start:  timePtr(time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC)),
 				End:    timePtr(time.Date(2017, 07, 10, 21, 42, 24, 760738998, time.UTC)),
 			}, false},
+		{"good with value filter",
+			requestWithVar(&http.Request{
+				URL: mustParseURL(`?start=2017-06-10T21:42:24.760738998Z&end=2017-07-10T21:42:24.760738998Z&match_prefix=api-&match_regex=.*-(eu|us)&limit=10&sort=frequency`),
+			}, "name", "test"), &logproto.LabelRequest{
+				Name:        "test",
+				Values:      true,
+				Start:       timePtr(time.Date(2017, 06, 10, 21, 42, 24, 760738998, time.UTC)),
+				End:         timePtr(time.Date(2017, 07, 10, 21, 42, 24, 760738998, time.UTC)),
+				MatchPrefix: "api-",
+				MatchRegex:  ".*-(eu|us)",
+				Limit:       10,
+				Sort:        "frequency",
+			}, false},
+		{"bad match_regex",
+			requestWithVar(&http.Request{
+				URL: mustParseURL(`?match_regex=(`),
+			}, "name", "test"), nil, true},
+		{"bad sort",
+			requestWithVar(&http.Request{
+				URL: mustParseURL(`?sort=random`),
+			}, "name", "test"), nil, true},
+		{"bad limit",
+			requestWithVar(&http.Request{
+				URL: mustParseURL(`?limit=-1`),
+			}, "name", "test"), nil, true},
+		{"value filter for label names",
+			&http.Request{
+				URL: mustParseURL(`?match_prefix=a`),
+			}, nil, true},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
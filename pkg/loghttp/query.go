@@ -19,6 +19,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/analyze"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/storage/stores/index/seriesvolume"
 	"github.com/grafana/loki/v3/pkg/util"
@@ -49,6 +50,7 @@ type QueryResponse struct {
 	Status   string            `json:"status"`
 	Warnings []string          `json:"warnings,omitempty"`
 	Data     QueryResponseData `json:"data"`
+	Analysis *analyze.Node     `json:"analysis,omitempty"`
 }
 
 func (q *QueryResponse) UnmarshalJSON(data []byte) error {
@@ -73,6 +75,12 @@ func (q *QueryResponse) UnmarshalJSON(data []byte) error {
 				return err
 			}
 			q.Data = responseData
+		case "analysis":
+			var analysis analyze.Node
+			if err := json.Unmarshal(value, &analysis); err != nil {
+				return err
+			}
+			q.Analysis = &analysis
 		}
 		return nil
 	})
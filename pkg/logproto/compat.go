@@ -5,6 +5,7 @@ import (
 	stdjson "encoding/json"
 	"fmt"
 	"math"
+	"regexp"
 	"sort"
 	"strconv"
 	"strings"
@@ -289,6 +290,52 @@ func (i *IndexStatsResponse) GetHeaders() []*definitions.PrometheusResponseHeade
 	return nil
 }
 
+// LabelSortFrequency orders label values by the volume of logs they are attached to, most frequent first.
+const LabelSortFrequency = "frequency"
+
+// ValueMatchers returns the matchers on the requested label selecting the values by match_prefix and match_regex.
+func (m *LabelRequest) ValueMatchers() ([]*labels.Matcher, error) {
+	var matchers []*labels.Matcher
+	if m.MatchPrefix != "" {
+		matcher, err := labels.NewMatcher(labels.MatchRegexp, m.Name, regexp.QuoteMeta(m.MatchPrefix)+".*")
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	if m.MatchRegex != "" {
+		matcher, err := labels.NewMatcher(labels.MatchRegexp, m.Name, m.MatchRegex)
+		if err != nil {
+			return nil, err
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+// FilterValues returns the sorted label values matching match_prefix and match_regex, up to the limit of the request.
+func (m *LabelRequest) FilterValues(values []string) ([]string, error) {
+	matchers, err := m.ValueMatchers()
+	if err != nil {
+		return nil, err
+	}
+	res := make([]string, 0, len(values))
+Values:
+	for _, v := range values {
+		for _, matcher := range matchers {
+			if !matcher.Matches(v) {
+				continue Values
+			}
+		}
+		res = append(res, v)
+	}
+	sort.Strings(res)
+	if m.Limit > 0 && len(res) > int(m.Limit) {
+		res = res[:m.Limit]
+	}
+	return res, nil
+}
+
 // Satisfy definitions.Request for Volume
 
 // GetStart returns the start timestamp of the request in milliseconds.
@@ -218,6 +218,31 @@ func TestMergeLabelResponses(t *testing.T) {
 	}
 }
 
+func TestLabelRequest_FilterValues(t *testing.T) {
+	values := []string{"api-us", "web-eu", "api-eu", "api-asia", "api.eu"}
+
+	for _, tc := range []struct {
+		name     string
+		req      LabelRequest
+		expected []string
+	}{
+		{name: "no filter", req: LabelRequest{Name: "svc"}, expected: []string{"api-asia", "api-eu", "api-us", "api.eu", "web-eu"}},
+		{name: "prefix", req: LabelRequest{Name: "svc", MatchPrefix: "api-"}, expected: []string{"api-asia", "api-eu", "api-us"}},
+		{name: "regex", req: LabelRequest{Name: "svc", MatchRegex: ".*-eu"}, expected: []string{"api-eu", "web-eu"}},
+		{name: "prefix and regex", req: LabelRequest{Name: "svc", MatchPrefix: "api", MatchRegex: ".*eu"}, expected: []string{"api-eu", "api.eu"}},
+		{name: "limit", req: LabelRequest{Name: "svc", MatchPrefix: "api-", Limit: 2}, expected: []string{"api-asia", "api-eu"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			filtered, err := tc.req.FilterValues(values)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, filtered)
+		})
+	}
+
+	_, err := (&LabelRequest{Name: "svc", MatchRegex: "("}).FilterValues(values)
+	require.Error(t, err)
+}
+
 func TestMergeSeriesResponses(t *testing.T) {
 	mockSeriesResponse := func(series [][]SeriesIdentifier_LabelsEntry) *SeriesResponse {
 		resp := &SeriesResponse{}
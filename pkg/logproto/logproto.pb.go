@@ -1110,11 +1110,15 @@ func (m *SampleQueryResponse) GetWarnings() []string {
 }
 
 type LabelRequest struct {
-	Name   string     `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
-	Values bool       `protobuf:"varint,2,opt,name=values,proto3" json:"values,omitempty"`
-	Start  *time.Time `protobuf:"bytes,3,opt,name=start,proto3,stdtime" json:"start,omitempty"`
-	End    *time.Time `protobuf:"bytes,4,opt,name=end,proto3,stdtime" json:"end,omitempty"`
-	Query  string     `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	Name        string     `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Values      bool       `protobuf:"varint,2,opt,name=values,proto3" json:"values,omitempty"`
+	Start       *time.Time `protobuf:"bytes,3,opt,name=start,proto3,stdtime" json:"start,omitempty"`
+	End         *time.Time `protobuf:"bytes,4,opt,name=end,proto3,stdtime" json:"end,omitempty"`
+	Query       string     `protobuf:"bytes,5,opt,name=query,proto3" json:"query,omitempty"`
+	MatchPrefix string     `protobuf:"bytes,6,opt,name=match_prefix,json=matchPrefix,proto3" json:"match_prefix,omitempty"`
+	MatchRegex  string     `protobuf:"bytes,7,opt,name=match_regex,json=matchRegex,proto3" json:"match_regex,omitempty"`
+	Limit       uint32     `protobuf:"varint,8,opt,name=limit,proto3" json:"limit,omitempty"`
+	Sort        string     `protobuf:"bytes,9,opt,name=sort,proto3" json:"sort,omitempty"`
 }
 
 func (m *LabelRequest) Reset()      { *m = LabelRequest{} }
@@ -1184,6 +1188,34 @@ func (m *LabelRequest) GetQuery() string {
 	return ""
 }
 
+func (m *LabelRequest) GetMatchPrefix() string {
+	if m != nil {
+		return m.MatchPrefix
+	}
+	return ""
+}
+
+func (m *LabelRequest) GetMatchRegex() string {
+	if m != nil {
+		return m.MatchRegex
+	}
+	return ""
+}
+
+func (m *LabelRequest) GetLimit() uint32 {
+	if m != nil {
+		return m.Limit
+	}
+	return 0
+}
+
+func (m *LabelRequest) GetSort() string {
+	if m != nil {
+		return m.Sort
+	}
+	return ""
+}
+
 type LabelResponse struct {
 	Values []string `protobuf:"bytes,1,rep,name=values,proto3" json:"values,omitempty"`
 }
@@ -3579,202 +3611,204 @@ func init() {
 func init() { proto.RegisterFile("pkg/logproto/logproto.proto", fileDescriptor_c28a5f14f1f4c79a) }
 
 var fileDescriptor_c28a5f14f1f4c79a = []byte{
-	// 3117 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x1a, 0x4b, 0x6c, 0x1b, 0xc7,
-	0x95, 0xcb, 0x9f, 0xc8, 0x47, 0x4a, 0x96, 0x47, 0xb2, 0x4c, 0xc8, 0x36, 0xa9, 0x0c, 0x52, 0xdb,
-	0x89, 0x1d, 0xd1, 0x56, 0x1a, 0x37, 0x71, 0x9a, 0xa6, 0xa6, 0x64, 0x2b, 0x76, 0xe4, 0x4f, 0x46,
-	0xb6, 0x93, 0x16, 0x0d, 0x82, 0x35, 0x39, 0xa2, 0x36, 0x22, 0x77, 0xe9, 0xdd, 0xa1, 0x6d, 0xf5,
-	0x50, 0xf4, 0xd0, 0x6b, 0xd1, 0x00, 0x45, 0xd1, 0xf6, 0x52, 0xa0, 0x40, 0x81, 0x16, 0x05, 0x72,
-	0x29, 0x7a, 0xe8, 0xa1, 0x68, 0x2f, 0x05, 0x9a, 0xde, 0xd2, 0x53, 0x83, 0x1c, 0xd8, 0x46, 0xb9,
-	0x14, 0x02, 0x0a, 0xe4, 0xd4, 0x02, 0x39, 0x15, 0xf3, 0xdb, 0x9d, 0x5d, 0x92, 0x51, 0xe4, 0xba,
-	0x48, 0x72, 0x21, 0x67, 0xde, 0xbc, 0x79, 0x33, 0xef, 0x33, 0xef, 0xbd, 0x79, 0x3b, 0x70, 0xa4,
-	0xb7, 0xd5, 0xae, 0x77, 0xbc, 0x76, 0xcf, 0xf7, 0x98, 0x17, 0x36, 0x16, 0xc5, 0x2f, 0x2a, 0xe8,
-	0xfe, 0xfc, 0x6c, 0xdb, 0x6b, 0x7b, 0x12, 0x87, 0xb7, 0xe4, 0xf8, 0x7c, 0xad, 0xed, 0x79, 0xed,
-	0x0e, 0xad, 0x8b, 0xde, 0x9d, 0xfe, 0x46, 0x9d, 0x39, 0x5d, 0x1a, 0x30, 0xbb, 0xdb, 0x53, 0x08,
-	0x0b, 0x8a, 0xfa, 0xdd, 0x4e, 0xd7, 0x6b, 0xd1, 0x4e, 0x3d, 0x60, 0x36, 0x0b, 0xe4, 0xaf, 0xc2,
-	0x98, 0xe1, 0x18, 0xbd, 0x7e, 0xb0, 0x29, 0x7e, 0x14, 0xf0, 0x0c, 0x07, 0x06, 0xcc, 0xf3, 0xed,
-	0x36, 0xad, 0x37, 0x37, 0xfb, 0xee, 0x56, 0xbd, 0x69, 0x37, 0x37, 0x69, 0xdd, 0xa7, 0x41, 0xbf,
-	0xc3, 0x02, 0xd9, 0x61, 0xdb, 0x3d, 0xaa, 0xc8, 0xe0, 0xdf, 0x5a, 0x70, 0x68, 0xcd, 0xbe, 0x43,
-	0x3b, 0x37, 0xbd, 0xdb, 0x76, 0xa7, 0x4f, 0x03, 0x42, 0x83, 0x9e, 0xe7, 0x06, 0x14, 0x2d, 0x43,
-	0xbe, 0xc3, 0x07, 0x82, 0x8a, 0xb5, 0x90, 0x39, 0x59, 0x5a, 0x3a, 0xb5, 0x18, 0x32, 0x39, 0x72,
-	0x82, 0x84, 0x06, 0x17, 0x5d, 0xe6, 0x6f, 0x13, 0x35, 0x75, 0xfe, 0x36, 0x94, 0x0c, 0x30, 0x9a,
-	0x86, 0xcc, 0x16, 0xdd, 0xae, 0x58, 0x0b, 0xd6, 0xc9, 0x22, 0xe1, 0x4d, 0x74, 0x16, 0x72, 0xf7,
-	0x38, 0x99, 0x4a, 0x7a, 0xc1, 0x3a, 0x59, 0x5a, 0x3a, 0x12, 0x2d, 0x72, 0xcb, 0x75, 0xee, 0xf6,
-	0xa9, 0x98, 0xad, 0x16, 0x92, 0x98, 0xe7, 0xd3, 0xcf, 0x5a, 0xf8, 0x14, 0x1c, 0x1c, 0x1a, 0x47,
-	0x73, 0x90, 0x17, 0x18, 0x72, 0xc7, 0x45, 0xa2, 0x7a, 0x78, 0x16, 0xd0, 0x3a, 0xf3, 0xa9, 0xdd,
-	0x25, 0x36, 0xe3, 0xfb, 0xbd, 0xdb, 0xa7, 0x01, 0xc3, 0x57, 0x61, 0x26, 0x06, 0x55, 0x6c, 0x9f,
-	0x83, 0x52, 0x10, 0x81, 0x15, 0xef, 0xb3, 0xd1, 0xb6, 0xa2, 0x39, 0xc4, 0x44, 0xc4, 0xdf, 0xb3,
-	0x60, 0x4a, 0x8e, 0x5d, 0xa5, 0xcc, 0x6e, 0xd9, 0xcc, 0x46, 0x55, 0x00, 0x89, 0xf1, 0x92, 0x1d,
-	0x6c, 0x0a, 0xa6, 0xb3, 0xc4, 0x80, 0xa0, 0x79, 0x28, 0x74, 0x1c, 0x97, 0xae, 0x3b, 0xdf, 0x96,
-	0xec, 0x67, 0x49, 0xd8, 0x47, 0xe7, 0x60, 0x2e, 0x60, 0x7e, 0xbf, 0xc9, 0xfa, 0x3e, 0x6d, 0x69,
-	0x8a, 0x02, 0x33, 0x23, 0x30, 0xc7, 0x8c, 0xe2, 0x3b, 0x30, 0x7b, 0xf1, 0x41, 0x93, 0xd2, 0x56,
-	0xb0, 0xe6, 0x74, 0x1d, 0xa6, 0xb9, 0xe5, 0xb2, 0x61, 0xd4, 0xb5, 0x5d, 0xa6, 0x84, 0xaf, 0x7a,
-	0x68, 0x09, 0x26, 0xe4, 0x8e, 0x82, 0x4a, 0x5a, 0xb0, 0x5a, 0x49, 0xb2, 0xaa, 0xc9, 0x13, 0x8d,
-	0x88, 0x03, 0x38, 0x94, 0x58, 0x43, 0xc9, 0x6e, 0xdc, 0x22, 0x0d, 0x38, 0xe0, 0xd3, 0x37, 0x69,
-	0x93, 0xd1, 0xd6, 0xfa, 0xb8, 0xc5, 0x48, 0x0c, 0x81, 0x24, 0x27, 0xe0, 0x97, 0x60, 0x2a, 0x8e,
-	0xb2, 0xa7, 0x78, 0xe7, 0x20, 0xef, 0x53, 0x3b, 0xf0, 0x5c, 0x21, 0xdc, 0x22, 0x51, 0x3d, 0xbe,
-	0xfd, 0x55, 0xca, 0x24, 0x91, 0x5b, 0x81, 0xdd, 0xa6, 0x7b, 0xc9, 0xa8, 0x0a, 0xd0, 0xb3, 0x7d,
-	0xe6, 0x30, 0xc7, 0x73, 0xe5, 0xce, 0x73, 0xc4, 0x80, 0x20, 0x0c, 0xe5, 0x68, 0x59, 0x1a, 0x54,
-	0x32, 0x0b, 0x99, 0x93, 0x59, 0x12, 0x83, 0xe1, 0xef, 0xc0, 0x5c, 0x72, 0xd1, 0x3d, 0x84, 0xf6,
-	0x38, 0x4c, 0xda, 0x4d, 0xe6, 0xdc, 0xa3, 0x91, 0xc8, 0x38, 0x87, 0x71, 0x20, 0x3a, 0x0e, 0x53,
-	0x7d, 0x77, 0xcb, 0xf5, 0xee, 0xbb, 0x1a, 0x4d, 0xae, 0x9e, 0x80, 0xe2, 0x9f, 0x59, 0x00, 0x91,
-	0xe9, 0xee, 0x29, 0xbb, 0xd3, 0x70, 0x30, 0xea, 0x5d, 0xf3, 0xd6, 0x37, 0x6d, 0xbf, 0xa5, 0x36,
-	0x30, 0x3c, 0x80, 0x10, 0x64, 0x7d, 0x9b, 0x49, 0xd3, 0xcc, 0x10, 0xd1, 0x36, 0xd8, 0xca, 0xc6,
-	0xd8, 0x9a, 0x83, 0x3c, 0x77, 0x58, 0x34, 0xa8, 0xe4, 0x16, 0xac, 0x93, 0x93, 0x44, 0xf5, 0x70,
-	0x15, 0x8e, 0xae, 0x52, 0x76, 0x21, 0x08, 0x9c, 0xb6, 0x4b, 0x5b, 0x37, 0x42, 0xe9, 0xea, 0xe3,
-	0xfa, 0x37, 0x0b, 0x8e, 0x8d, 0x41, 0x50, 0x82, 0xf4, 0x00, 0xd9, 0x43, 0xa3, 0xea, 0x00, 0xbf,
-	0x18, 0x19, 0xda, 0x27, 0x12, 0x59, 0x1c, 0x1e, 0x92, 0x0e, 0x6d, 0x04, 0xe9, 0xf9, 0x8b, 0x70,
-	0x78, 0x0c, 0xba, 0xe9, 0xe8, 0x72, 0xd2, 0xd1, 0xcd, 0x9a, 0x8e, 0x2e, 0x63, 0xfa, 0xb2, 0x7f,
-	0x67, 0xa0, 0xfc, 0x4a, 0x9f, 0xfa, 0xdb, 0xda, 0x0e, 0xab, 0x50, 0x08, 0x68, 0x87, 0x36, 0x99,
-	0xe7, 0x4b, 0x9b, 0x68, 0xa4, 0x2b, 0x16, 0x09, 0x61, 0x9c, 0x54, 0x87, 0x1f, 0x3c, 0x41, 0x6a,
-	0x92, 0xc8, 0x0e, 0x3a, 0x0f, 0xb9, 0x80, 0xd9, 0x3e, 0x13, 0x5a, 0x28, 0x2d, 0xcd, 0x2f, 0xca,
-	0x18, 0xb3, 0xa8, 0x63, 0xcc, 0xe2, 0x4d, 0x1d, 0x63, 0x1a, 0x85, 0x77, 0x06, 0xb5, 0xd4, 0x5b,
-	0x7f, 0xaf, 0x59, 0x44, 0x4e, 0x41, 0xe7, 0x20, 0x43, 0xdd, 0x96, 0xd0, 0xd4, 0xa7, 0x9d, 0xc9,
-	0x27, 0xa0, 0xb3, 0x50, 0x6c, 0x39, 0x3e, 0x6d, 0x72, 0xce, 0x85, 0x3e, 0xa7, 0x96, 0x66, 0x22,
-	0x49, 0xaf, 0xe8, 0x21, 0x12, 0x61, 0xa1, 0xd3, 0x90, 0x0f, 0xb8, 0xd1, 0x04, 0x95, 0x09, 0xee,
-	0xa4, 0x1b, 0xb3, 0xbb, 0x83, 0xda, 0xb4, 0x84, 0x9c, 0xf6, 0xba, 0x0e, 0xa3, 0xdd, 0x1e, 0xdb,
-	0x26, 0x0a, 0x07, 0x3d, 0x09, 0x13, 0x2d, 0xda, 0xa1, 0xdc, 0x13, 0x17, 0x84, 0x22, 0xa7, 0x0d,
-	0xf2, 0x62, 0x80, 0x68, 0x04, 0xf4, 0x3a, 0x64, 0x7b, 0x1d, 0xdb, 0xad, 0x14, 0x05, 0x17, 0x53,
-	0x11, 0xe2, 0x8d, 0x8e, 0xed, 0x36, 0x9e, 0x7b, 0x7f, 0x50, 0x7b, 0xa6, 0xed, 0xb0, 0xcd, 0xfe,
-	0x9d, 0xc5, 0xa6, 0xd7, 0xad, 0xb7, 0x7d, 0x7b, 0xc3, 0x76, 0xed, 0x7a, 0xc7, 0xdb, 0x72, 0xea,
-	0xf7, 0x9e, 0xae, 0xf3, 0xc8, 0x79, 0xb7, 0x4f, 0x7d, 0x87, 0xfa, 0x75, 0x4e, 0x66, 0x51, 0xa8,
-	0x84, 0x4f, 0x25, 0x82, 0x2c, 0xba, 0xc2, 0x03, 0x83, 0xe7, 0xd3, 0x65, 0x1e, 0x56, 0x83, 0x0a,
-	0x88, 0x55, 0x0e, 0x47, 0xab, 0x08, 0x38, 0xa1, 0x1b, 0xab, 0xbe, 0xd7, 0xef, 0x35, 0x0e, 0xec,
-	0x0e, 0x6a, 0x26, 0x3e, 0x31, 0x3b, 0x57, 0xb2, 0x85, 0xfc, 0xf4, 0x04, 0x7e, 0x3b, 0x03, 0x68,
-	0xdd, 0xee, 0xf6, 0x3a, 0x74, 0x5f, 0xea, 0x0f, 0x15, 0x9d, 0x7e, 0x68, 0x45, 0x67, 0xf6, 0xab,
-	0xe8, 0x48, 0x6b, 0xd9, 0xfd, 0x69, 0x2d, 0xf7, 0x69, 0xb5, 0x96, 0xff, 0xdc, 0x6b, 0x0d, 0x57,
-	0x20, 0xcb, 0x29, 0xf3, 0xc3, 0xed, 0xdb, 0xf7, 0x85, 0x6e, 0xca, 0x84, 0x37, 0xf1, 0x1a, 0xe4,
-	0x25, 0x5f, 0x3c, 0xa6, 0xc7, 0x95, 0x17, 0x3f, 0xb7, 0x91, 0xe2, 0x32, 0x5a, 0x25, 0xd3, 0x91,
-	0x4a, 0x32, 0x42, 0xd8, 0xf8, 0xf7, 0x16, 0x4c, 0x2a, 0x8b, 0x50, 0xae, 0xed, 0x4e, 0x14, 0xa5,
-	0xa5, 0x3f, 0x3b, 0x9c, 0x8c, 0xd2, 0x17, 0x5a, 0x76, 0x8f, 0x51, 0xbf, 0x51, 0x7f, 0x67, 0x50,
-	0xb3, 0xde, 0x1f, 0xd4, 0x4e, 0x8c, 0x13, 0x9a, 0x4e, 0x1b, 0x75, 0x22, 0xa3, 0x09, 0xa3, 0x53,
-	0x62, 0x77, 0x2c, 0x50, 0x66, 0x75, 0x60, 0x51, 0x66, 0x9b, 0x97, 0xdd, 0x36, 0x0d, 0x38, 0xe5,
-	0x2c, 0xb7, 0x08, 0x22, 0x71, 0x38, 0x9b, 0xf7, 0x6d, 0xdf, 0x75, 0xdc, 0xb6, 0x0c, 0x38, 0x45,
-	0x12, 0xf6, 0xf1, 0x4f, 0x2c, 0x98, 0x89, 0x99, 0xb5, 0x62, 0xe2, 0x59, 0xc8, 0x07, 0x5c, 0x53,
-	0x9a, 0x07, 0xc3, 0x28, 0xd6, 0x05, 0xbc, 0x31, 0xa5, 0x36, 0x9f, 0x97, 0x7d, 0xa2, 0xf0, 0x1f,
-	0xdd, 0xd6, 0xfe, 0x64, 0x41, 0x59, 0x64, 0x8c, 0xfa, 0xac, 0x21, 0xc8, 0xba, 0x76, 0x97, 0x2a,
-	0x55, 0x89, 0xb6, 0x91, 0x46, 0xf2, 0xe5, 0x0a, 0x3a, 0x8d, 0xdc, 0xaf, 0x83, 0xb5, 0x1e, 0xda,
-	0xc1, 0x5a, 0xd1, 0xb9, 0x9b, 0x85, 0x1c, 0x37, 0xef, 0x6d, 0xe1, 0x5c, 0x8b, 0x44, 0x76, 0xf0,
-	0x09, 0x98, 0x54, 0x5c, 0x44, 0x39, 0xc4, 0xc8, 0xcc, 0xb7, 0x0b, 0x79, 0xa9, 0x09, 0xf4, 0x38,
-	0x14, 0xc3, 0x3b, 0x86, 0xe0, 0x36, 0xd3, 0xc8, 0xef, 0x0e, 0x6a, 0x69, 0x16, 0x90, 0x68, 0x00,
-	0xd5, 0xcc, 0x20, 0x65, 0x35, 0x8a, 0xbb, 0x83, 0x9a, 0x04, 0xa8, 0x78, 0x85, 0x8e, 0x42, 0x76,
-	0x93, 0x67, 0x0c, 0x22, 0x09, 0x6d, 0x14, 0x76, 0x07, 0x35, 0xd1, 0x27, 0xe2, 0x17, 0xaf, 0x42,
-	0x79, 0x8d, 0xb6, 0xed, 0xe6, 0xb6, 0x5a, 0x34, 0x8c, 0x79, 0x7c, 0x41, 0x4b, 0xd3, 0x78, 0x0c,
-	0xca, 0xe1, 0x8a, 0x6f, 0xa8, 0xbc, 0x26, 0x43, 0x4a, 0x21, 0xec, 0x6a, 0x80, 0x7f, 0x6a, 0x81,
-	0xb2, 0x01, 0x84, 0x8d, 0x6b, 0x08, 0xf7, 0x85, 0xb0, 0x3b, 0xa8, 0x29, 0x88, 0xbe, 0x65, 0xa0,
-	0xe7, 0x61, 0x22, 0x10, 0x2b, 0xea, 0xbc, 0xd2, 0x34, 0x2d, 0x31, 0xd0, 0x38, 0xc0, 0x4d, 0x64,
-	0x77, 0x50, 0xd3, 0x88, 0x44, 0x37, 0xd0, 0x62, 0x2c, 0x15, 0x92, 0x8c, 0x4d, 0xed, 0x0e, 0x6a,
-	0x06, 0xd4, 0x4c, 0x8d, 0xf0, 0xc7, 0x16, 0x94, 0x6e, 0xda, 0x4e, 0x68, 0x42, 0x15, 0xad, 0xa2,
-	0xc8, 0x57, 0x4b, 0x00, 0xb7, 0xc4, 0x16, 0xed, 0xd8, 0xdb, 0x97, 0x3c, 0x5f, 0xd0, 0x9d, 0x24,
-	0x61, 0x3f, 0x8a, 0xe1, 0xd9, 0x91, 0x31, 0x3c, 0xb7, 0x7f, 0xd7, 0xfe, 0xff, 0x75, 0xa4, 0x57,
-	0xb2, 0x85, 0xf4, 0x74, 0x06, 0xbf, 0x6d, 0x41, 0x59, 0x32, 0xaf, 0x2c, 0xef, 0x5b, 0x90, 0x97,
-	0xb2, 0x11, 0xec, 0x7f, 0x82, 0x63, 0x3a, 0xb5, 0x1f, 0xa7, 0xa4, 0x68, 0xa2, 0x17, 0x61, 0xaa,
-	0xe5, 0x7b, 0xbd, 0x5e, 0xf2, 0xde, 0x60, 0xac, 0xb2, 0x62, 0x8e, 0x93, 0x04, 0x3a, 0xfe, 0x8b,
-	0x05, 0x93, 0xca, 0x99, 0x28, 0x75, 0x85, 0x22, 0xb6, 0x1e, 0x3a, 0x7a, 0xa6, 0xf7, 0x1b, 0x3d,
-	0xe7, 0x20, 0xdf, 0xe6, 0xf1, 0x45, 0x3b, 0x24, 0xd5, 0xdb, 0x5f, 0x54, 0xc5, 0x57, 0x60, 0x4a,
-	0xb3, 0x32, 0xc6, 0xa3, 0xce, 0x27, 0x3d, 0xea, 0xe5, 0x16, 0x75, 0x99, 0xb3, 0xe1, 0x84, 0x3e,
-	0x52, 0xe1, 0xe3, 0x1f, 0x58, 0x30, 0x9d, 0x44, 0x41, 0x2b, 0x89, 0x1b, 0xff, 0xf1, 0xf1, 0xe4,
-	0xcc, 0xcb, 0xbe, 0x26, 0xad, 0xae, 0xfc, 0xcf, 0xec, 0x75, 0xe5, 0x8f, 0x65, 0xc2, 0x45, 0xe5,
-	0x15, 0xf0, 0x8f, 0x2d, 0x98, 0x8c, 0xe9, 0x12, 0x3d, 0x0b, 0xd9, 0x0d, 0xdf, 0xeb, 0xee, 0x4b,
-	0x51, 0x62, 0x06, 0xfa, 0x32, 0xa4, 0x99, 0xb7, 0x2f, 0x35, 0xa5, 0x99, 0xc7, 0xb5, 0xa4, 0xd8,
-	0xcf, 0xc8, 0x1b, 0x8b, 0xec, 0xe1, 0x67, 0xa0, 0x28, 0x18, 0xba, 0x61, 0x3b, 0xfe, 0xc8, 0x80,
-	0x31, 0x9a, 0xa1, 0xe7, 0xe1, 0x80, 0x74, 0x86, 0xa3, 0x27, 0x97, 0x47, 0x4d, 0x2e, 0xeb, 0xc9,
-	0x47, 0x20, 0x27, 0x92, 0x0e, 0x3e, 0x85, 0x5f, 0xbe, 0xf5, 0x14, 0xde, 0xc6, 0x87, 0x60, 0x86,
-	0x9f, 0x41, 0xea, 0x07, 0xcb, 0x5e, 0xdf, 0x65, 0xfa, 0x86, 0x74, 0x1a, 0x66, 0xe3, 0x60, 0x65,
-	0x25, 0xb3, 0x90, 0x6b, 0x72, 0x80, 0xa0, 0x31, 0x49, 0x64, 0x07, 0xff, 0xc2, 0x02, 0xb4, 0x4a,
-	0x99, 0x58, 0xe5, 0xf2, 0x4a, 0x78, 0x3c, 0xe6, 0xa1, 0xd0, 0xb5, 0x59, 0x73, 0x93, 0xfa, 0x81,
-	0xce, 0x5f, 0x74, 0xff, 0xb3, 0x48, 0x3c, 0xf1, 0x59, 0x98, 0x89, 0xed, 0x52, 0xf1, 0x34, 0x0f,
-	0x85, 0xa6, 0x82, 0xa9, 0x90, 0x17, 0xf6, 0xf1, 0x6f, 0xd2, 0x50, 0xd0, 0x69, 0x1d, 0x3a, 0x0b,
-	0xa5, 0x0d, 0xc7, 0x6d, 0x53, 0xbf, 0xe7, 0x3b, 0x4a, 0x04, 0x59, 0x99, 0xe6, 0x19, 0x60, 0x62,
-	0x76, 0xd0, 0x53, 0x30, 0xd1, 0x0f, 0xa8, 0xff, 0x86, 0x23, 0x4f, 0x7a, 0xb1, 0x31, 0xbb, 0x33,
-	0xa8, 0xe5, 0x6f, 0x05, 0xd4, 0xbf, 0xbc, 0xc2, 0x83, 0x4f, 0x5f, 0xb4, 0x88, 0xfc, 0x6f, 0xa1,
-	0x97, 0x95, 0x99, 0x8a, 0x04, 0xae, 0xf1, 0x15, 0xbe, 0xfd, 0x84, 0xab, 0xeb, 0xf9, 0x5e, 0x97,
-	0xb2, 0x4d, 0xda, 0x0f, 0xea, 0x4d, 0xaf, 0xdb, 0xf5, 0xdc, 0xba, 0x28, 0xea, 0x09, 0xa6, 0x79,
-	0x04, 0xe5, 0xd3, 0x95, 0xe5, 0xde, 0x84, 0x09, 0xb6, 0xe9, 0x7b, 0xfd, 0xf6, 0xa6, 0x08, 0x0c,
-	0x99, 0xc6, 0xf9, 0xfd, 0xd3, 0xd3, 0x14, 0x88, 0x6e, 0xa0, 0xc7, 0xb8, 0xb4, 0x68, 0x73, 0x2b,
-	0xe8, 0x77, 0xe5, 0xad, 0xbb, 0x91, 0xdb, 0x1d, 0xd4, 0xac, 0xa7, 0x48, 0x08, 0xc6, 0x17, 0x60,
-	0x32, 0x96, 0x0a, 0xa3, 0x33, 0x90, 0xf5, 0xe9, 0x86, 0x76, 0x05, 0x68, 0x38, 0x63, 0x96, 0xd1,
-	0x9f, 0xe3, 0x10, 0xf1, 0x8b, 0xbf, 0x9f, 0x86, 0x9a, 0x51, 0x8e, 0xbb, 0xe4, 0xf9, 0x57, 0x29,
-	0xf3, 0x9d, 0xe6, 0x35, 0xbb, 0x1b, 0x96, 0x58, 0x6a, 0x50, 0xea, 0x0a, 0xe0, 0x1b, 0xc6, 0x29,
-	0x82, 0x6e, 0x88, 0x87, 0x8e, 0x01, 0x88, 0x63, 0x27, 0xc7, 0xe5, 0x81, 0x2a, 0x0a, 0x88, 0x18,
-	0x5e, 0x8e, 0x09, 0xbb, 0xbe, 0x4f, 0xe1, 0x28, 0x21, 0x5f, 0x4e, 0x0a, 0x79, 0xdf, 0x74, 0x42,
-	0xc9, 0x9a, 0xc7, 0x25, 0x17, 0x3f, 0x2e, 0xf8, 0x5f, 0x16, 0x54, 0xd7, 0xf4, 0xce, 0x1f, 0x52,
-	0x1c, 0x9a, 0xdf, 0xf4, 0x23, 0xe2, 0x37, 0xf3, 0x08, 0xf9, 0xcd, 0x26, 0xf8, 0xad, 0x02, 0xac,
-	0x39, 0x2e, 0xbd, 0xe4, 0x74, 0x18, 0xf5, 0x47, 0x5c, 0x92, 0x7e, 0x98, 0x89, 0x3c, 0x0e, 0xa1,
-	0x1b, 0x5a, 0x06, 0xcb, 0x86, 0x9b, 0x7f, 0x14, 0x2c, 0xa6, 0x1f, 0x21, 0x8b, 0x99, 0x84, 0x07,
-	0x74, 0x61, 0x62, 0x43, 0xb0, 0x27, 0x23, 0x76, 0xac, 0x30, 0x1c, 0xf1, 0xde, 0xf8, 0x9a, 0x5a,
-	0xfc, 0xdc, 0x1e, 0x09, 0x97, 0x28, 0xf0, 0xd7, 0x83, 0x6d, 0x97, 0xd9, 0x0f, 0x8c, 0xf9, 0x44,
-	0x2f, 0x82, 0x6c, 0x95, 0xd3, 0xe5, 0x46, 0xe6, 0x74, 0x2f, 0xa8, 0x65, 0xfe, 0x97, 0xbc, 0x0e,
-	0xb7, 0x23, 0x07, 0x2b, 0x94, 0xa2, 0x1c, 0xec, 0xf1, 0xbd, 0x8e, 0xbf, 0x3c, 0xf4, 0xe8, 0x64,
-	0xfc, 0x6a, 0x56, 0x0e, 0xaf, 0x66, 0x2d, 0xfa, 0x20, 0x76, 0x2f, 0xc3, 0x7f, 0xb0, 0x60, 0x7a,
-	0x95, 0xb2, 0x78, 0x36, 0xf6, 0x05, 0x52, 0x3e, 0x7e, 0x09, 0x0e, 0x1a, 0xfb, 0x57, 0x72, 0x7a,
-	0x3a, 0x91, 0x82, 0x1d, 0x8a, 0x24, 0x25, 0x64, 0xa0, 0x6e, 0xb6, 0xf1, 0xec, 0xeb, 0x06, 0x94,
-	0x8c, 0x41, 0x74, 0x21, 0x91, 0x77, 0xcd, 0x24, 0xbe, 0xb4, 0xf0, 0xdc, 0xa1, 0x31, 0xab, 0x78,
-	0x92, 0xf7, 0x57, 0x95, 0x55, 0x87, 0x39, 0xca, 0x3a, 0x20, 0xa1, 0x58, 0x41, 0xd6, 0x8c, 0x92,
-	0x02, 0xfa, 0x72, 0x98, 0x80, 0x85, 0x7d, 0xf4, 0x18, 0x64, 0x7d, 0xef, 0xbe, 0x4e, 0xa8, 0x27,
-	0x8d, 0x42, 0xbc, 0x77, 0x9f, 0x88, 0x21, 0xfc, 0x3c, 0x64, 0x88, 0x77, 0x1f, 0x55, 0x01, 0x7c,
-	0xdb, 0x6d, 0xd3, 0xdb, 0xe1, 0x55, 0xae, 0x4c, 0x0c, 0xc8, 0x98, 0x0c, 0x66, 0x19, 0x0e, 0x9a,
-	0x3b, 0x92, 0xea, 0x5e, 0x84, 0x89, 0x57, 0xfa, 0xa6, 0xb8, 0x66, 0x13, 0xe2, 0x92, 0x15, 0x03,
-	0x8d, 0xc4, 0x6d, 0x06, 0x22, 0x38, 0x3a, 0x0a, 0x45, 0x66, 0xdf, 0xe9, 0xd0, 0x6b, 0x91, 0xb3,
-	0x8c, 0x00, 0x7c, 0x94, 0xdf, 0x42, 0x6f, 0x1b, 0xa9, 0x58, 0x04, 0x40, 0x4f, 0xc2, 0x74, 0xb4,
-	0xe7, 0x1b, 0x3e, 0xdd, 0x70, 0x1e, 0x08, 0x0d, 0x97, 0xc9, 0x10, 0x1c, 0x9d, 0x84, 0x03, 0x11,
-	0x6c, 0x5d, 0xa4, 0x3c, 0x59, 0x81, 0x9a, 0x04, 0x73, 0xd9, 0x08, 0x76, 0x2f, 0xde, 0xed, 0xdb,
-	0x1d, 0x71, 0x4c, 0xcb, 0xc4, 0x80, 0xe0, 0x3f, 0x5a, 0x70, 0x50, 0xaa, 0x9a, 0x9f, 0x81, 0x2f,
-	0xa2, 0xd5, 0xff, 0xd2, 0x02, 0x64, 0x72, 0xa0, 0x4c, 0xeb, 0x4b, 0x66, 0x45, 0x8a, 0xe7, 0x54,
-	0x25, 0x71, 0xb9, 0x96, 0xa0, 0xa8, 0xa8, 0x84, 0x21, 0xdf, 0x94, 0x95, 0x37, 0xf1, 0xf1, 0x40,
-	0xde, 0xde, 0x25, 0x84, 0xa8, 0x7f, 0x54, 0x83, 0xdc, 0x9d, 0x6d, 0x46, 0x03, 0x75, 0xf7, 0x16,
-	0x45, 0x07, 0x01, 0x20, 0xf2, 0x8f, 0xaf, 0x45, 0x5d, 0x26, 0xac, 0x26, 0x1b, 0xad, 0xa5, 0x40,
-	0x44, 0x37, 0xf0, 0x7f, 0xd2, 0x30, 0x79, 0xdb, 0xeb, 0xf4, 0xa3, 0xf0, 0xfa, 0x45, 0x0a, 0x2d,
-	0xb1, 0x82, 0x40, 0x4e, 0x17, 0x04, 0x10, 0x64, 0x03, 0x46, 0x7b, 0xc2, 0xb2, 0x32, 0x44, 0xb4,
-	0x11, 0x86, 0x32, 0xb3, 0xfd, 0x36, 0x65, 0xf2, 0x9a, 0x55, 0xc9, 0x8b, 0xfc, 0x37, 0x06, 0x43,
-	0x0b, 0x50, 0xb2, 0xdb, 0x6d, 0x9f, 0xb6, 0x6d, 0x46, 0x1b, 0xdb, 0x95, 0x09, 0xb1, 0x98, 0x09,
-	0x42, 0x57, 0x60, 0xaa, 0x69, 0x37, 0x37, 0x1d, 0xb7, 0x7d, 0xbd, 0x27, 0xbf, 0x94, 0x14, 0x84,
-	0x07, 0x3f, 0xba, 0x68, 0x7e, 0x2b, 0x5e, 0x5c, 0x8e, 0xe1, 0x28, 0x3f, 0x96, 0x98, 0x89, 0x5f,
-	0x83, 0x29, 0x2d, 0x78, 0x65, 0x1e, 0x67, 0x60, 0xe2, 0x9e, 0x80, 0x8c, 0x28, 0xf6, 0x49, 0x54,
-	0x45, 0x4a, 0xa3, 0xc5, 0x3f, 0x6a, 0x68, 0xfe, 0xf1, 0x15, 0xc8, 0x4b, 0x74, 0x74, 0xd4, 0xbc,
-	0x78, 0xc9, 0xdc, 0x93, 0xf7, 0xd5, 0x2d, 0x0a, 0x43, 0x5e, 0x12, 0x52, 0x46, 0x24, 0xec, 0x4c,
-	0x42, 0x88, 0xfa, 0xc7, 0x3f, 0x4a, 0xc3, 0xa1, 0x15, 0xca, 0xc4, 0x27, 0xc4, 0x4b, 0x0e, 0xed,
-	0xb4, 0x3e, 0xd3, 0x9a, 0x40, 0x58, 0xd9, 0xcb, 0x18, 0x95, 0x3d, 0xee, 0xc3, 0x3a, 0x8e, 0x4b,
-	0xd7, 0x8c, 0xd2, 0x50, 0x04, 0x88, 0x64, 0x94, 0x33, 0x8b, 0x46, 0xda, 0x46, 0xf2, 0x86, 0x8d,
-	0x44, 0x05, 0xc1, 0x89, 0x58, 0x0d, 0x53, 0xdf, 0x40, 0x0b, 0xd1, 0xf5, 0x15, 0xff, 0xce, 0x82,
-	0xb9, 0xa4, 0x5c, 0x94, 0x1a, 0x2f, 0x42, 0x7e, 0x43, 0x40, 0x86, 0xcb, 0xce, 0xb1, 0x19, 0xb2,
-	0x72, 0x21, 0x51, 0xcd, 0xca, 0x85, 0x84, 0xa0, 0x27, 0x62, 0x1f, 0xac, 0x1a, 0x33, 0xbb, 0x83,
-	0xda, 0x01, 0x01, 0x30, 0x70, 0x15, 0x33, 0xa7, 0xc3, 0x8d, 0x67, 0xa2, 0x92, 0x88, 0x84, 0x98,
-	0x84, 0x55, 0x7d, 0xf3, 0xcf, 0x16, 0x4c, 0xc6, 0x36, 0x22, 0x44, 0xc4, 0x8f, 0x80, 0x0a, 0x0f,
-	0xb2, 0x83, 0x9e, 0x80, 0x2c, 0xdb, 0xee, 0xa9, 0xa8, 0xd0, 0x38, 0xf4, 0xf1, 0xa0, 0x76, 0x30,
-	0x36, 0xed, 0xe6, 0x76, 0x8f, 0x12, 0x81, 0xc2, 0x4f, 0x4e, 0xd3, 0xf6, 0x5b, 0x8e, 0x6b, 0x77,
-	0x1c, 0xb6, 0xad, 0xbe, 0xb6, 0x9b, 0x20, 0xee, 0x8e, 0x7a, 0xb6, 0x1f, 0xe8, 0x24, 0xb0, 0x28,
-	0xdd, 0x91, 0x02, 0x11, 0xdd, 0x10, 0xc5, 0x9d, 0x2d, 0xca, 0x9a, 0x9b, 0x32, 0x2c, 0xa8, 0xe2,
-	0x8e, 0x80, 0xc4, 0x8a, 0x3b, 0x02, 0x82, 0x7f, 0x6e, 0x45, 0xc6, 0x29, 0xcf, 0xf0, 0xe7, 0xce,
-	0x38, 0xf1, 0x37, 0x22, 0x3b, 0xd1, 0x5b, 0x54, 0x76, 0xf2, 0x22, 0x4c, 0xb5, 0x62, 0x23, 0xe3,
-	0xed, 0x45, 0x16, 0xae, 0x13, 0xe8, 0xb8, 0x1f, 0xe9, 0x51, 0x40, 0xc6, 0xe8, 0x31, 0xa1, 0x9c,
-	0xf4, 0xb0, 0x72, 0x22, 0xa9, 0x67, 0xf6, 0x96, 0xfa, 0x93, 0xc7, 0xa1, 0x18, 0x7e, 0xa4, 0x44,
-	0x25, 0x98, 0xb8, 0x74, 0x9d, 0xbc, 0x7a, 0x81, 0xac, 0x4c, 0xa7, 0x50, 0x19, 0x0a, 0x8d, 0x0b,
-	0xcb, 0x2f, 0x8b, 0x9e, 0xb5, 0xf4, 0xeb, 0xbc, 0x4e, 0x5c, 0x7c, 0xf4, 0x55, 0xc8, 0xc9, 0x6c,
-	0x64, 0x2e, 0x62, 0xce, 0xfc, 0x7e, 0x37, 0x7f, 0x78, 0x08, 0x2e, 0xa5, 0x84, 0x53, 0x67, 0x2c,
-	0x74, 0x0d, 0x4a, 0x02, 0xa8, 0x2a, 0xe4, 0x47, 0x93, 0x85, 0xea, 0x18, 0xa5, 0x63, 0x63, 0x46,
-	0x0d, 0x7a, 0xe7, 0x21, 0x27, 0x05, 0x36, 0x97, 0x48, 0x1a, 0x47, 0xec, 0x26, 0xf6, 0xcd, 0x00,
-	0xa7, 0xd0, 0x73, 0x90, 0xbd, 0x69, 0x3b, 0x1d, 0x64, 0xe4, 0xac, 0x46, 0x61, 0x7b, 0x7e, 0x2e,
-	0x09, 0x36, 0x96, 0x7d, 0x21, 0xac, 0xcf, 0x1f, 0x4e, 0x16, 0x09, 0xf5, 0xf4, 0xca, 0xf0, 0x40,
-	0xb8, 0xf2, 0x75, 0x59, 0x45, 0xd6, 0xa5, 0x2a, 0x74, 0x2c, 0xbe, 0x54, 0xa2, 0xb2, 0x35, 0x5f,
-	0x1d, 0x37, 0x1c, 0x12, 0x5c, 0x83, 0x92, 0x51, 0x26, 0x32, 0xc5, 0x3a, 0x5c, 0xe3, 0x32, 0xc5,
-	0x3a, 0xa2, 0xb6, 0x84, 0x53, 0x68, 0x15, 0x0a, 0xe2, 0xb1, 0x86, 0xcd, 0x02, 0x74, 0x24, 0x99,
-	0xd0, 0x1b, 0x89, 0xdc, 0xfc, 0xd1, 0xd1, 0x83, 0x21, 0xa1, 0xaf, 0x43, 0x71, 0x95, 0x32, 0x15,
-	0xc1, 0x0e, 0x27, 0x43, 0xe0, 0x08, 0x49, 0xc5, 0xc3, 0x28, 0x4e, 0xa1, 0xd7, 0xc4, 0xa5, 0x23,
-	0xee, 0x9e, 0x51, 0x6d, 0x8c, 0x1b, 0x0e, 0xf7, 0xb5, 0x30, 0x1e, 0x21, 0xa4, 0xfc, 0x6a, 0x8c,
-	0xb2, 0xca, 0x1b, 0x6a, 0x63, 0x0e, 0x6c, 0x48, 0xb9, 0xb6, 0xc7, 0x2b, 0x30, 0x9c, 0x5a, 0x7a,
-	0x5d, 0xbf, 0x34, 0x59, 0xb1, 0x99, 0x8d, 0xae, 0xc3, 0x54, 0xf8, 0xf0, 0x45, 0xbc, 0x94, 0x8a,
-	0xd9, 0xfc, 0xd0, 0xb3, 0xac, 0x98, 0xcd, 0x0f, 0x3f, 0xcf, 0xc2, 0xa9, 0xa5, 0x37, 0x61, 0x56,
-	0x7e, 0xf8, 0x93, 0x8f, 0x8f, 0x2e, 0xf9, 0x9e, 0xcb, 0xb8, 0xcf, 0x22, 0x30, 0x19, 0x7b, 0x95,
-	0x84, 0x0c, 0xab, 0x19, 0xf5, 0x24, 0xca, 0x64, 0x65, 0xe4, 0x73, 0x26, 0x9c, 0x5a, 0xfa, 0xab,
-	0x05, 0x65, 0x73, 0x31, 0x74, 0xcb, 0xe0, 0x46, 0x3c, 0xe3, 0x31, 0x25, 0x36, 0xf2, 0x55, 0x91,
-	0xa9, 0x8b, 0xd1, 0x2f, 0x80, 0x70, 0x0a, 0xbd, 0x29, 0x9e, 0x24, 0x0d, 0x3f, 0x26, 0x41, 0xc7,
-	0xf7, 0x7c, 0xb7, 0x22, 0x17, 0x39, 0xf1, 0x29, 0xdf, 0xb7, 0xe0, 0x54, 0xe3, 0xf5, 0x77, 0x3f,
-	0xa8, 0xa6, 0xde, 0xfb, 0xa0, 0x9a, 0xfa, 0xe8, 0x83, 0xaa, 0xf5, 0xdd, 0x9d, 0xaa, 0xf5, 0xab,
-	0x9d, 0xaa, 0xf5, 0xce, 0x4e, 0xd5, 0x7a, 0x77, 0xa7, 0x6a, 0xfd, 0x63, 0xa7, 0x6a, 0xfd, 0x73,
-	0xa7, 0x9a, 0xfa, 0x68, 0xa7, 0x6a, 0xbd, 0xf5, 0x61, 0x35, 0xf5, 0xee, 0x87, 0xd5, 0xd4, 0x7b,
-	0x1f, 0x56, 0x53, 0xdf, 0x3c, 0xb1, 0x77, 0x29, 0x43, 0x86, 0x95, 0xbc, 0xf8, 0x7b, 0xfa, 0xbf,
-	0x01, 0x00, 0x00, 0xff, 0xff, 0xe5, 0xe6, 0x69, 0x24, 0x20, 0x29, 0x00, 0x00,
+	// 3146 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xd4, 0x3a, 0x4d, 0x6c, 0x1b, 0xc7,
+	0xd5, 0x5a, 0x2e, 0x49, 0x91, 0x8f, 0x94, 0x2c, 0x8f, 0x64, 0x99, 0xa0, 0x6d, 0x52, 0x1e, 0xe4,
+	0xb3, 0x95, 0xd8, 0x21, 0x6d, 0xe5, 0x8b, 0xbf, 0xc4, 0xf9, 0xf2, 0xe5, 0x33, 0x25, 0x5b, 0xb1,
+	0x23, 0xff, 0x64, 0x64, 0x3b, 0x69, 0xd1, 0xc0, 0x58, 0x91, 0x23, 0x6a, 0x23, 0x72, 0x97, 0xde,
+	0x1d, 0xda, 0x56, 0x0f, 0x45, 0x0f, 0xbd, 0x16, 0x0d, 0x50, 0x14, 0x6d, 0x2f, 0x05, 0x0a, 0x14,
+	0x68, 0x51, 0x20, 0x97, 0xa2, 0x87, 0x1e, 0x8a, 0xf6, 0xd8, 0xf4, 0x96, 0x9e, 0x1a, 0xe4, 0xc0,
+	0x36, 0xca, 0xa5, 0x10, 0x50, 0x20, 0xa7, 0x16, 0xc8, 0xa9, 0x98, 0x9f, 0xdd, 0x9d, 0x5d, 0x91,
+	0x51, 0xe8, 0xba, 0x48, 0x72, 0x21, 0x77, 0xde, 0xbc, 0x79, 0x33, 0xef, 0x67, 0xde, 0x7b, 0xf3,
+	0x66, 0xe0, 0x58, 0x6f, 0xbb, 0x5d, 0xef, 0xb8, 0xed, 0x9e, 0xe7, 0x32, 0x37, 0xfc, 0xa8, 0x89,
+	0x5f, 0x94, 0x0b, 0xda, 0xe5, 0xb9, 0xb6, 0xdb, 0x76, 0x25, 0x0e, 0xff, 0x92, 0xfd, 0xe5, 0x6a,
+	0xdb, 0x75, 0xdb, 0x1d, 0x5a, 0x17, 0xad, 0x8d, 0xfe, 0x66, 0x9d, 0xd9, 0x5d, 0xea, 0x33, 0xab,
+	0xdb, 0x53, 0x08, 0x0b, 0x8a, 0xfa, 0xfd, 0x4e, 0xd7, 0x6d, 0xd1, 0x4e, 0xdd, 0x67, 0x16, 0xf3,
+	0xe5, 0xaf, 0xc2, 0x98, 0xe5, 0x18, 0xbd, 0xbe, 0xbf, 0x25, 0x7e, 0x14, 0xf0, 0x1c, 0x07, 0xfa,
+	0xcc, 0xf5, 0xac, 0x36, 0xad, 0x37, 0xb7, 0xfa, 0xce, 0x76, 0xbd, 0x69, 0x35, 0xb7, 0x68, 0xdd,
+	0xa3, 0x7e, 0xbf, 0xc3, 0x7c, 0xd9, 0x60, 0x3b, 0x3d, 0xaa, 0xc8, 0xe0, 0x5f, 0x1b, 0x70, 0x64,
+	0xcd, 0xda, 0xa0, 0x9d, 0xdb, 0xee, 0x5d, 0xab, 0xd3, 0xa7, 0x3e, 0xa1, 0x7e, 0xcf, 0x75, 0x7c,
+	0x8a, 0x96, 0x21, 0xdb, 0xe1, 0x1d, 0x7e, 0xc9, 0x58, 0x30, 0x17, 0x0b, 0x4b, 0x67, 0x6a, 0x21,
+	0x93, 0x43, 0x07, 0x48, 0xa8, 0x7f, 0xd9, 0x61, 0xde, 0x0e, 0x51, 0x43, 0xcb, 0x77, 0xa1, 0xa0,
+	0x81, 0xd1, 0x0c, 0x98, 0xdb, 0x74, 0xa7, 0x64, 0x2c, 0x18, 0x8b, 0x79, 0xc2, 0x3f, 0xd1, 0x79,
+	0xc8, 0x3c, 0xe0, 0x64, 0x4a, 0xa9, 0x05, 0x63, 0xb1, 0xb0, 0x74, 0x2c, 0x9a, 0xe4, 0x8e, 0x63,
+	0xdf, 0xef, 0x53, 0x31, 0x5a, 0x4d, 0x24, 0x31, 0x2f, 0xa6, 0x5e, 0x30, 0xf0, 0x19, 0x38, 0xbc,
+	0xaf, 0x1f, 0xcd, 0x43, 0x56, 0x60, 0xc8, 0x15, 0xe7, 0x89, 0x6a, 0xe1, 0x39, 0x40, 0xeb, 0xcc,
+	0xa3, 0x56, 0x97, 0x58, 0x8c, 0xaf, 0xf7, 0x7e, 0x9f, 0xfa, 0x0c, 0x5f, 0x87, 0xd9, 0x18, 0x54,
+	0xb1, 0x7d, 0x01, 0x0a, 0x7e, 0x04, 0x56, 0xbc, 0xcf, 0x45, 0xcb, 0x8a, 0xc6, 0x10, 0x1d, 0x11,
+	0x7f, 0xc7, 0x80, 0x69, 0xd9, 0x77, 0x9d, 0x32, 0xab, 0x65, 0x31, 0x0b, 0x55, 0x00, 0x24, 0xc6,
+	0xab, 0x96, 0xbf, 0x25, 0x98, 0x4e, 0x13, 0x0d, 0x82, 0xca, 0x90, 0xeb, 0xd8, 0x0e, 0x5d, 0xb7,
+	0xbf, 0x29, 0xd9, 0x4f, 0x93, 0xb0, 0x8d, 0x2e, 0xc0, 0xbc, 0xcf, 0xbc, 0x7e, 0x93, 0xf5, 0x3d,
+	0xda, 0x0a, 0x28, 0x0a, 0x4c, 0x53, 0x60, 0x8e, 0xe8, 0xc5, 0x1b, 0x30, 0x77, 0xf9, 0x51, 0x93,
+	0xd2, 0x96, 0xbf, 0x66, 0x77, 0x6d, 0x16, 0x70, 0xcb, 0x65, 0xc3, 0xa8, 0x63, 0x39, 0x4c, 0x09,
+	0x5f, 0xb5, 0xd0, 0x12, 0x4c, 0xca, 0x15, 0xf9, 0xa5, 0x94, 0x60, 0xb5, 0x94, 0x64, 0x35, 0x20,
+	0x4f, 0x02, 0x44, 0xec, 0xc3, 0x91, 0xc4, 0x1c, 0x4a, 0x76, 0xa3, 0x26, 0x69, 0xc0, 0x21, 0x8f,
+	0xbe, 0x4d, 0x9b, 0x8c, 0xb6, 0xd6, 0x47, 0x4d, 0x46, 0x62, 0x08, 0x24, 0x39, 0x00, 0xbf, 0x0a,
+	0xd3, 0x71, 0x94, 0x03, 0xc5, 0x3b, 0x0f, 0x59, 0x8f, 0x5a, 0xbe, 0xeb, 0x08, 0xe1, 0xe6, 0x89,
+	0x6a, 0xf1, 0xe5, 0xaf, 0x52, 0x26, 0x89, 0xdc, 0xf1, 0xad, 0x36, 0x3d, 0x48, 0x46, 0x15, 0x80,
+	0x9e, 0xe5, 0x31, 0x9b, 0xd9, 0xae, 0x23, 0x57, 0x9e, 0x21, 0x1a, 0x04, 0x61, 0x28, 0x46, 0xd3,
+	0x52, 0xbf, 0x64, 0x2e, 0x98, 0x8b, 0x69, 0x12, 0x83, 0xe1, 0x6f, 0xc1, 0x7c, 0x72, 0xd2, 0x03,
+	0x84, 0xf6, 0x14, 0x4c, 0x59, 0x4d, 0x66, 0x3f, 0xa0, 0x91, 0xc8, 0x38, 0x87, 0x71, 0x20, 0x3a,
+	0x05, 0xd3, 0x7d, 0x67, 0xdb, 0x71, 0x1f, 0x3a, 0x01, 0x9a, 0x9c, 0x3d, 0x01, 0xc5, 0x3f, 0x31,
+	0x00, 0x22, 0xd3, 0x3d, 0x50, 0x76, 0x67, 0xe1, 0x70, 0xd4, 0xba, 0xe1, 0xae, 0x6f, 0x59, 0x5e,
+	0x4b, 0x2d, 0x60, 0x7f, 0x07, 0x42, 0x90, 0xf6, 0x2c, 0x26, 0x4d, 0xd3, 0x24, 0xe2, 0x5b, 0x63,
+	0x2b, 0x1d, 0x63, 0x6b, 0x1e, 0xb2, 0xdc, 0x61, 0x51, 0xbf, 0x94, 0x59, 0x30, 0x16, 0xa7, 0x88,
+	0x6a, 0xe1, 0x0a, 0x1c, 0x5f, 0xa5, 0xec, 0x92, 0xef, 0xdb, 0x6d, 0x87, 0xb6, 0x6e, 0x85, 0xd2,
+	0x0d, 0xb6, 0xeb, 0x9f, 0x0d, 0x38, 0x31, 0x02, 0x41, 0x09, 0xd2, 0x05, 0x64, 0xed, 0xeb, 0x55,
+	0x1b, 0xf8, 0x95, 0xc8, 0xd0, 0x3e, 0x93, 0x48, 0x6d, 0x7f, 0x97, 0x74, 0x68, 0x43, 0x48, 0x97,
+	0x2f, 0xc3, 0xd1, 0x11, 0xe8, 0xba, 0xa3, 0xcb, 0x48, 0x47, 0x37, 0xa7, 0x3b, 0x3a, 0x53, 0xf7,
+	0x65, 0xff, 0x30, 0xa1, 0xf8, 0x7a, 0x9f, 0x7a, 0x3b, 0x81, 0x1d, 0x56, 0x20, 0xe7, 0xd3, 0x0e,
+	0x6d, 0x32, 0xd7, 0x93, 0x36, 0xd1, 0x48, 0x95, 0x0c, 0x12, 0xc2, 0x38, 0xa9, 0x0e, 0xdf, 0x78,
+	0x82, 0xd4, 0x14, 0x91, 0x0d, 0x74, 0x11, 0x32, 0x3e, 0xb3, 0x3c, 0x26, 0xb4, 0x50, 0x58, 0x2a,
+	0xd7, 0x64, 0x8c, 0xa9, 0x05, 0x31, 0xa6, 0x76, 0x3b, 0x88, 0x31, 0x8d, 0xdc, 0x7b, 0x83, 0xea,
+	0xc4, 0x3b, 0x7f, 0xa9, 0x1a, 0x44, 0x0e, 0x41, 0x17, 0xc0, 0xa4, 0x4e, 0xab, 0x94, 0x1e, 0x63,
+	0x24, 0x1f, 0x80, 0xce, 0x43, 0xbe, 0x65, 0x7b, 0xb4, 0xc9, 0x39, 0x17, 0xfa, 0x9c, 0x5e, 0x9a,
+	0x8d, 0x24, 0xbd, 0x12, 0x74, 0x91, 0x08, 0x0b, 0x9d, 0x85, 0xac, 0xcf, 0x8d, 0xc6, 0x2f, 0x4d,
+	0x72, 0x27, 0xdd, 0x98, 0xdb, 0x1b, 0x54, 0x67, 0x24, 0xe4, 0xac, 0xdb, 0xb5, 0x19, 0xed, 0xf6,
+	0xd8, 0x0e, 0x51, 0x38, 0xe8, 0x19, 0x98, 0x6c, 0xd1, 0x0e, 0xe5, 0x9e, 0x38, 0x27, 0x14, 0x39,
+	0xa3, 0x91, 0x17, 0x1d, 0x24, 0x40, 0x40, 0x6f, 0x41, 0xba, 0xd7, 0xb1, 0x9c, 0x52, 0x5e, 0x70,
+	0x31, 0x1d, 0x21, 0xde, 0xea, 0x58, 0x4e, 0xe3, 0xc5, 0x0f, 0x07, 0xd5, 0xe7, 0xdb, 0x36, 0xdb,
+	0xea, 0x6f, 0xd4, 0x9a, 0x6e, 0xb7, 0xde, 0xf6, 0xac, 0x4d, 0xcb, 0xb1, 0xea, 0x1d, 0x77, 0xdb,
+	0xae, 0x3f, 0x78, 0xae, 0xce, 0x23, 0xe7, 0xfd, 0x3e, 0xf5, 0x6c, 0xea, 0xd5, 0x39, 0x99, 0x9a,
+	0x50, 0x09, 0x1f, 0x4a, 0x04, 0x59, 0x74, 0x8d, 0x07, 0x06, 0xd7, 0xa3, 0xcb, 0x3c, 0xac, 0xfa,
+	0x25, 0x10, 0xb3, 0x1c, 0x8d, 0x66, 0x11, 0x70, 0x42, 0x37, 0x57, 0x3d, 0xb7, 0xdf, 0x6b, 0x1c,
+	0xda, 0x1b, 0x54, 0x75, 0x7c, 0xa2, 0x37, 0xae, 0xa5, 0x73, 0xd9, 0x99, 0x49, 0xfc, 0xae, 0x09,
+	0x68, 0xdd, 0xea, 0xf6, 0x3a, 0x74, 0x2c, 0xf5, 0x87, 0x8a, 0x4e, 0x3d, 0xb6, 0xa2, 0xcd, 0x71,
+	0x15, 0x1d, 0x69, 0x2d, 0x3d, 0x9e, 0xd6, 0x32, 0x9f, 0x57, 0x6b, 0xd9, 0x2f, 0xbd, 0xd6, 0x70,
+	0x09, 0xd2, 0x9c, 0x32, 0xdf, 0xdc, 0x9e, 0xf5, 0x50, 0xe8, 0xa6, 0x48, 0xf8, 0x27, 0x5e, 0x83,
+	0xac, 0xe4, 0x8b, 0xc7, 0xf4, 0xb8, 0xf2, 0xe2, 0xfb, 0x36, 0x52, 0x9c, 0x19, 0xa8, 0x64, 0x26,
+	0x52, 0x89, 0x29, 0x84, 0x8d, 0x7f, 0x6b, 0xc0, 0x94, 0xb2, 0x08, 0xe5, 0xda, 0x36, 0xa2, 0x28,
+	0x2d, 0xfd, 0xd9, 0xd1, 0x64, 0x94, 0xbe, 0xd4, 0xb2, 0x7a, 0x8c, 0x7a, 0x8d, 0xfa, 0x7b, 0x83,
+	0xaa, 0xf1, 0xe1, 0xa0, 0x7a, 0x7a, 0x94, 0xd0, 0x82, 0xb4, 0x51, 0x8d, 0x0b, 0xa3, 0x3a, 0x3a,
+	0x23, 0x56, 0xc7, 0x7c, 0x65, 0x56, 0x87, 0x6a, 0xa2, 0x55, 0xbb, 0xea, 0xb4, 0xa9, 0xcf, 0x29,
+	0xa7, 0xb9, 0x45, 0x10, 0x89, 0xc3, 0xd9, 0x7c, 0x68, 0x79, 0x8e, 0xed, 0xb4, 0x65, 0xc0, 0xc9,
+	0x93, 0xb0, 0x8d, 0x7f, 0x64, 0xc0, 0x6c, 0xcc, 0xac, 0x15, 0x13, 0x2f, 0x40, 0xd6, 0xe7, 0x9a,
+	0x0a, 0x78, 0xd0, 0x8c, 0x62, 0x5d, 0xc0, 0x1b, 0xd3, 0x6a, 0xf1, 0x59, 0xd9, 0x26, 0x0a, 0xff,
+	0xc9, 0x2d, 0xed, 0x57, 0x29, 0x28, 0x8a, 0x8c, 0x31, 0xd8, 0x6b, 0x08, 0xd2, 0x8e, 0xd5, 0xa5,
+	0x4a, 0x55, 0xe2, 0x5b, 0x4b, 0x23, 0xf9, 0x74, 0xb9, 0x20, 0x8d, 0x1c, 0xd7, 0xc1, 0x1a, 0x8f,
+	0xed, 0x60, 0x8d, 0x68, 0xdf, 0xcd, 0x41, 0x86, 0x9b, 0xf7, 0x8e, 0x70, 0xae, 0x79, 0x22, 0x1b,
+	0xe8, 0x24, 0x14, 0xbb, 0x16, 0x6b, 0x6e, 0xdd, 0xeb, 0x79, 0x74, 0xd3, 0x7e, 0x24, 0xf6, 0x4e,
+	0x9e, 0x14, 0x04, 0xec, 0x96, 0x00, 0xa1, 0x2a, 0xc8, 0xe6, 0x3d, 0x8f, 0xb6, 0xe9, 0xa3, 0xd2,
+	0xa4, 0xc0, 0x00, 0x01, 0x22, 0x1c, 0x12, 0x05, 0x91, 0x9c, 0x1e, 0x44, 0x10, 0xa4, 0x7d, 0xd7,
+	0x63, 0xc2, 0x87, 0xe6, 0x89, 0xf8, 0xc6, 0xa7, 0x61, 0x4a, 0xc9, 0x2c, 0xca, 0x58, 0x86, 0xe6,
+	0xd9, 0x5d, 0xc8, 0x4a, 0xbd, 0xa3, 0xa7, 0x20, 0x1f, 0x9e, 0x68, 0x84, 0x6c, 0xcd, 0x46, 0x76,
+	0x6f, 0x50, 0x4d, 0x31, 0x9f, 0x44, 0x1d, 0xa8, 0xaa, 0x87, 0x44, 0xa3, 0x91, 0xdf, 0x1b, 0x54,
+	0x25, 0x40, 0x45, 0x47, 0x74, 0x1c, 0xd2, 0x5b, 0x3c, 0x3f, 0x11, 0x29, 0x6f, 0x23, 0xb7, 0x37,
+	0xa8, 0x8a, 0x36, 0x11, 0xbf, 0x78, 0x15, 0x8a, 0x6b, 0xb4, 0x6d, 0x35, 0x77, 0xd4, 0xa4, 0x61,
+	0x84, 0xe5, 0x13, 0x1a, 0x01, 0x8d, 0x93, 0x50, 0x0c, 0x67, 0xbc, 0xa7, 0xb2, 0x28, 0x93, 0x14,
+	0x42, 0xd8, 0x75, 0x1f, 0xff, 0xd8, 0x00, 0x65, 0x71, 0x08, 0x6b, 0x87, 0x1e, 0xee, 0x79, 0x61,
+	0x6f, 0x50, 0x55, 0x90, 0xe0, 0x4c, 0x83, 0x5e, 0x82, 0x49, 0x5f, 0xcc, 0x18, 0x64, 0xb1, 0xba,
+	0x21, 0x8b, 0x8e, 0xc6, 0x21, 0x6e, 0x90, 0x7b, 0x83, 0x6a, 0x80, 0x48, 0x82, 0x0f, 0x54, 0x8b,
+	0x25, 0x5e, 0x92, 0xb1, 0xe9, 0xbd, 0x41, 0x55, 0x83, 0xea, 0x89, 0x18, 0xfe, 0xd4, 0x80, 0xc2,
+	0x6d, 0xcb, 0x0e, 0x0d, 0xb6, 0x14, 0x18, 0x44, 0x14, 0x19, 0x94, 0x51, 0x94, 0x21, 0xd7, 0xa2,
+	0x1d, 0x6b, 0xe7, 0x8a, 0xeb, 0x09, 0xba, 0x53, 0x24, 0x6c, 0x47, 0xca, 0x4e, 0x0f, 0xcd, 0x18,
+	0x32, 0xe3, 0x07, 0x92, 0xff, 0xac, 0xdb, 0xbe, 0x96, 0xce, 0xa5, 0x66, 0x4c, 0xfc, 0xae, 0x01,
+	0x45, 0xc9, 0xbc, 0xb2, 0xbc, 0x6f, 0x40, 0x56, 0xca, 0x46, 0xb0, 0xff, 0x19, 0x6e, 0xf0, 0xcc,
+	0x38, 0x2e, 0x50, 0xd1, 0x44, 0xaf, 0xc0, 0x74, 0xcb, 0x73, 0x7b, 0xbd, 0xe4, 0x29, 0x45, 0x9b,
+	0x65, 0x45, 0xef, 0x27, 0x09, 0x74, 0xfc, 0x47, 0x03, 0xa6, 0x94, 0xeb, 0x52, 0xea, 0x0a, 0x45,
+	0x6c, 0x3c, 0x76, 0xac, 0x4e, 0x8d, 0x1b, 0xab, 0xe7, 0x21, 0xdb, 0xe6, 0xd1, 0x2c, 0x70, 0x7f,
+	0xaa, 0x35, 0x5e, 0x0c, 0xc7, 0xd7, 0x60, 0x3a, 0x60, 0x65, 0x84, 0xff, 0x2e, 0x27, 0xfd, 0xf7,
+	0xd5, 0x16, 0x75, 0x98, 0xbd, 0x69, 0x87, 0x1e, 0x59, 0xe1, 0xe3, 0xef, 0x19, 0x30, 0x93, 0x44,
+	0x41, 0x2b, 0x89, 0xfa, 0xc2, 0xa9, 0xd1, 0xe4, 0xf4, 0xd2, 0x42, 0x40, 0x5a, 0x15, 0x18, 0x9e,
+	0x3f, 0xa8, 0xc0, 0x10, 0xcb, 0xbb, 0xf3, 0xca, 0x2b, 0xe0, 0x1f, 0x1a, 0x30, 0x15, 0xd3, 0x25,
+	0x7a, 0x01, 0xd2, 0x9b, 0x9e, 0xdb, 0x1d, 0x4b, 0x51, 0x62, 0x04, 0xfa, 0x6f, 0x48, 0x31, 0x77,
+	0x2c, 0x35, 0xa5, 0x98, 0xcb, 0xb5, 0xa4, 0xd8, 0x37, 0xe5, 0xf9, 0x48, 0xb6, 0xf0, 0xf3, 0x90,
+	0x17, 0x0c, 0xdd, 0xb2, 0x6c, 0x6f, 0x68, 0x78, 0x1a, 0xce, 0xd0, 0x4b, 0x70, 0x48, 0x3a, 0xc3,
+	0xe1, 0x83, 0x8b, 0xc3, 0x06, 0x17, 0x83, 0xc1, 0xc7, 0x20, 0x23, 0x52, 0x1c, 0x3e, 0x84, 0x1f,
+	0xf5, 0x83, 0x21, 0xfc, 0x1b, 0x1f, 0x81, 0x59, 0xbe, 0x07, 0xa9, 0xe7, 0x2f, 0xbb, 0x7d, 0x87,
+	0x05, 0xe7, 0xb1, 0xb3, 0x30, 0x17, 0x07, 0x2b, 0x2b, 0x99, 0x83, 0x4c, 0x93, 0x03, 0x04, 0x8d,
+	0x29, 0x22, 0x1b, 0xf8, 0x67, 0x06, 0xa0, 0x55, 0xca, 0xc4, 0x2c, 0x57, 0x57, 0xc2, 0xed, 0x51,
+	0x86, 0x9c, 0x08, 0x49, 0xd4, 0xf3, 0x83, 0x6c, 0x29, 0x68, 0x7f, 0x11, 0x69, 0x2e, 0x3e, 0x0f,
+	0xb3, 0xb1, 0x55, 0x2a, 0x9e, 0xca, 0x90, 0x6b, 0x2a, 0x98, 0x0a, 0x79, 0x61, 0x9b, 0xa7, 0x14,
+	0xb9, 0x20, 0x89, 0x44, 0xe7, 0xa1, 0xb0, 0x69, 0x3b, 0x6d, 0xea, 0xf5, 0x3c, 0x5b, 0x89, 0x20,
+	0x2d, 0x93, 0x4a, 0x0d, 0x4c, 0xf4, 0x06, 0x7a, 0x16, 0x26, 0xfb, 0x3e, 0xf5, 0xee, 0xd9, 0x72,
+	0xa7, 0xe7, 0x1b, 0x73, 0xbb, 0x83, 0x6a, 0xf6, 0x8e, 0x4f, 0xbd, 0xab, 0x2b, 0x3c, 0xf8, 0xf4,
+	0xc5, 0x17, 0x91, 0xff, 0x2d, 0xf4, 0x9a, 0x32, 0x53, 0x91, 0x2e, 0x36, 0xfe, 0x87, 0x2f, 0x3f,
+	0xe1, 0xea, 0x7a, 0x9e, 0xdb, 0xa5, 0x6c, 0x8b, 0xf6, 0xfd, 0x7a, 0xd3, 0xed, 0x76, 0x5d, 0xa7,
+	0x2e, 0x4a, 0x88, 0x82, 0x69, 0x1e, 0x41, 0xf9, 0x70, 0x65, 0xb9, 0xb7, 0x61, 0x92, 0x6d, 0x79,
+	0x6e, 0xbf, 0xbd, 0x25, 0x02, 0x83, 0xd9, 0xb8, 0x38, 0x3e, 0xbd, 0x80, 0x02, 0x09, 0x3e, 0xd0,
+	0x49, 0x2e, 0x2d, 0xda, 0xdc, 0xf6, 0xfb, 0x5d, 0x79, 0xc6, 0x6f, 0x64, 0xf6, 0x06, 0x55, 0xe3,
+	0x59, 0x12, 0x82, 0xf1, 0x25, 0x98, 0x8a, 0x25, 0xde, 0xe8, 0x1c, 0xa4, 0x3d, 0xba, 0x19, 0xb8,
+	0x02, 0xb4, 0x3f, 0x3f, 0x97, 0xd1, 0x9f, 0xe3, 0x10, 0xf1, 0x8b, 0xbf, 0x9b, 0x82, 0xaa, 0x56,
+	0xfc, 0xbb, 0xe2, 0x7a, 0xd7, 0x29, 0xf3, 0xec, 0xe6, 0x0d, 0xab, 0x1b, 0x16, 0x74, 0x78, 0x12,
+	0x24, 0x80, 0xf7, 0xb4, 0x5d, 0x04, 0xdd, 0x10, 0x0f, 0x9d, 0x00, 0x10, 0xdb, 0x4e, 0xf6, 0xcb,
+	0x0d, 0x95, 0x17, 0x10, 0xd1, 0xbd, 0x1c, 0x13, 0x76, 0x7d, 0x4c, 0xe1, 0x28, 0x21, 0x5f, 0x4d,
+	0x0a, 0x79, 0x6c, 0x3a, 0xa1, 0x64, 0xf5, 0xed, 0x92, 0x89, 0x6f, 0x17, 0xfc, 0x77, 0x03, 0x2a,
+	0x6b, 0xc1, 0xca, 0x1f, 0x53, 0x1c, 0x01, 0xbf, 0xa9, 0x27, 0xc4, 0xaf, 0xf9, 0x04, 0xf9, 0x4d,
+	0x27, 0xf8, 0xad, 0x00, 0xac, 0xd9, 0x0e, 0xbd, 0x62, 0x77, 0x18, 0xf5, 0x86, 0x1c, 0xc9, 0xbe,
+	0x6f, 0x46, 0x1e, 0x87, 0xd0, 0xcd, 0x40, 0x06, 0xcb, 0x9a, 0x9b, 0x7f, 0x12, 0x2c, 0xa6, 0x9e,
+	0x20, 0x8b, 0x66, 0xc2, 0x03, 0x3a, 0x30, 0xb9, 0x29, 0xd8, 0x93, 0x11, 0x3b, 0x56, 0x86, 0x8e,
+	0x78, 0x6f, 0xfc, 0x9f, 0x9a, 0xfc, 0xc2, 0x01, 0x09, 0x97, 0xb8, 0x4e, 0xa8, 0xfb, 0x3b, 0x0e,
+	0xb3, 0x1e, 0x69, 0xe3, 0x49, 0x30, 0x09, 0xb2, 0x54, 0x4e, 0x97, 0x19, 0x9a, 0xd3, 0xbd, 0xac,
+	0xa6, 0xf9, 0x77, 0xf2, 0x3a, 0xdc, 0x86, 0xd9, 0x98, 0x52, 0x94, 0x83, 0x3d, 0x75, 0xd0, 0xf6,
+	0x97, 0x9b, 0x1e, 0x2d, 0xc6, 0x0f, 0x82, 0xc5, 0xf0, 0x20, 0xd8, 0xa2, 0x8f, 0x62, 0xa7, 0x40,
+	0xfc, 0x3b, 0x03, 0x66, 0x78, 0xc1, 0x35, 0x96, 0x8d, 0x7d, 0x85, 0x94, 0x8f, 0x5f, 0x85, 0xc3,
+	0xda, 0xfa, 0x95, 0x9c, 0x9e, 0x4b, 0xa4, 0x60, 0x47, 0x22, 0x49, 0x09, 0x19, 0xa8, 0x73, 0x74,
+	0x3c, 0xfb, 0xba, 0x05, 0x05, 0xad, 0x13, 0x5d, 0x4a, 0xe4, 0x5d, 0xb3, 0x89, 0x7b, 0x1d, 0x9e,
+	0x3b, 0x34, 0xe6, 0x14, 0x4f, 0xf2, 0xb4, 0xac, 0xb2, 0xea, 0x30, 0x47, 0x59, 0x07, 0x24, 0x14,
+	0x2b, 0xc8, 0xea, 0x51, 0x52, 0x40, 0x5f, 0x0b, 0x13, 0xb0, 0xb0, 0x8d, 0x4e, 0x42, 0xda, 0x73,
+	0x1f, 0x06, 0x09, 0xf5, 0x54, 0x34, 0x25, 0x71, 0x1f, 0x12, 0xd1, 0x85, 0x5f, 0x02, 0x93, 0xb8,
+	0x0f, 0x79, 0x65, 0xda, 0xb3, 0x9c, 0x36, 0xbd, 0x1b, 0x1e, 0xe5, 0x8a, 0x44, 0x83, 0x8c, 0xc8,
+	0x60, 0x96, 0xe1, 0xb0, 0xbe, 0x22, 0xa9, 0xee, 0x1a, 0x4c, 0xbe, 0xde, 0xd7, 0xc5, 0x35, 0x97,
+	0x10, 0x97, 0x18, 0x42, 0x02, 0x24, 0x6e, 0x33, 0x10, 0xc1, 0xd1, 0x71, 0xc8, 0x33, 0x6b, 0xa3,
+	0x43, 0x6f, 0x44, 0xce, 0x32, 0x02, 0xf0, 0x5e, 0x7e, 0x0a, 0xbd, 0xab, 0xa5, 0x62, 0x11, 0x00,
+	0x3d, 0x03, 0x33, 0xd1, 0x9a, 0xe5, 0x91, 0x5c, 0x68, 0xb8, 0x48, 0xf6, 0xc1, 0xd1, 0x22, 0x1c,
+	0x8a, 0x60, 0xeb, 0x22, 0xe5, 0x49, 0x0b, 0xd4, 0x24, 0x98, 0xcb, 0x46, 0xb0, 0x7b, 0xf9, 0x7e,
+	0xdf, 0xea, 0x88, 0x6d, 0x5a, 0x24, 0x1a, 0x04, 0xff, 0xde, 0x80, 0xc3, 0x52, 0xd5, 0x7c, 0x0f,
+	0x7c, 0x15, 0xad, 0xfe, 0xe7, 0x06, 0x20, 0x9d, 0x03, 0x65, 0x5a, 0xff, 0xa5, 0xd7, 0xbf, 0x78,
+	0x4e, 0x55, 0x10, 0x87, 0x6b, 0x09, 0x8a, 0x4a, 0x58, 0x18, 0xb2, 0x4d, 0x59, 0xe7, 0x13, 0x57,
+	0x15, 0xf2, 0xf4, 0x2e, 0x21, 0x44, 0xfd, 0xf3, 0xa2, 0xc3, 0xc6, 0x0e, 0xa3, 0xbe, 0x3a, 0x7b,
+	0x8b, 0xa2, 0x83, 0x00, 0x10, 0xf9, 0xc7, 0xe7, 0xa2, 0x0e, 0x13, 0x56, 0x93, 0x8e, 0xe6, 0x52,
+	0x20, 0x12, 0x7c, 0xe0, 0x7f, 0xa6, 0x60, 0xea, 0xae, 0xdb, 0xe9, 0x77, 0xe9, 0x57, 0x50, 0xce,
+	0xf1, 0x82, 0x40, 0x46, 0xaf, 0xfe, 0x30, 0xda, 0x13, 0x96, 0x65, 0x12, 0xf1, 0xcd, 0x2f, 0xb7,
+	0x98, 0xe5, 0xb5, 0x29, 0x93, 0xc7, 0xac, 0x52, 0x56, 0xe4, 0xbf, 0x31, 0x18, 0x5a, 0x80, 0x82,
+	0xd5, 0x6e, 0x7b, 0xb4, 0x6d, 0x31, 0xda, 0xd8, 0x51, 0xc5, 0x26, 0x1d, 0x84, 0xae, 0xc1, 0x34,
+	0xbf, 0x7b, 0xb6, 0x9d, 0xf6, 0xcd, 0x9e, 0xbc, 0x97, 0xc9, 0x09, 0x0f, 0x7e, 0xbc, 0xa6, 0xdf,
+	0x4c, 0xd7, 0x96, 0x63, 0x38, 0xca, 0x8f, 0x25, 0x46, 0xe2, 0x37, 0x61, 0x3a, 0x10, 0xbc, 0x32,
+	0x8f, 0x73, 0x30, 0xf9, 0x40, 0x40, 0x86, 0x94, 0x16, 0x25, 0xaa, 0x22, 0x15, 0xa0, 0xc5, 0xaf,
+	0x50, 0x02, 0xfe, 0xf1, 0x35, 0xc8, 0x4a, 0x74, 0x5e, 0x79, 0x8a, 0x72, 0x24, 0x99, 0x7b, 0xf2,
+	0xb6, 0x3a, 0x45, 0x61, 0xc8, 0x4a, 0x42, 0x25, 0x33, 0xb2, 0x33, 0x09, 0x21, 0xea, 0x1f, 0xff,
+	0x20, 0x05, 0x47, 0x56, 0x28, 0x13, 0x17, 0x96, 0x57, 0x6c, 0xda, 0x69, 0x7d, 0xa1, 0x35, 0x81,
+	0xb0, 0x8e, 0x68, 0xea, 0x75, 0xc4, 0xe3, 0x90, 0xe7, 0x17, 0xce, 0x6b, 0x5a, 0x69, 0x28, 0x02,
+	0x44, 0x32, 0xca, 0x24, 0x2b, 0x84, 0xdc, 0x46, 0xb2, 0x9a, 0x8d, 0x44, 0x05, 0xc1, 0xc9, 0x58,
+	0xc5, 0x34, 0x38, 0x81, 0xe6, 0xa2, 0xe3, 0x2b, 0xfe, 0x8d, 0x01, 0xf3, 0x49, 0xb9, 0x28, 0x35,
+	0x5e, 0x86, 0xec, 0xa6, 0x80, 0xec, 0x2f, 0x72, 0xc7, 0x46, 0xc8, 0xca, 0x85, 0x44, 0xd5, 0x2b,
+	0x17, 0x12, 0x82, 0x9e, 0x8e, 0x5d, 0x8f, 0x35, 0x66, 0xf7, 0x06, 0xd5, 0x43, 0x02, 0xa0, 0xe1,
+	0x2a, 0x66, 0xce, 0x86, 0x0b, 0x37, 0xa3, 0x92, 0x88, 0x84, 0xe8, 0x84, 0x25, 0x04, 0xff, 0x81,
+	0x17, 0x0d, 0xf4, 0x85, 0x08, 0x11, 0xf1, 0x2d, 0xa0, 0xc2, 0x83, 0x6c, 0xa0, 0xa7, 0x21, 0xcd,
+	0x9f, 0x58, 0xa8, 0xf3, 0xdc, 0x91, 0x4f, 0x07, 0xd5, 0xc3, 0xb1, 0x61, 0xb7, 0x77, 0x7a, 0x94,
+	0x08, 0x14, 0xbe, 0x73, 0x9a, 0x96, 0xd7, 0xb2, 0x1d, 0xab, 0x63, 0xb3, 0x1d, 0x75, 0xb7, 0xaf,
+	0x83, 0xb8, 0x3b, 0xea, 0x59, 0x9e, 0x1f, 0x24, 0x81, 0x79, 0xe9, 0x8e, 0x14, 0x88, 0x04, 0x1f,
+	0x9c, 0x13, 0x7f, 0x9b, 0xb2, 0xe6, 0x96, 0x0c, 0x0b, 0x92, 0x13, 0x09, 0xd1, 0x39, 0x91, 0x10,
+	0xfc, 0x53, 0x23, 0x32, 0x4e, 0xb9, 0x87, 0xbf, 0x74, 0xc6, 0x89, 0xbf, 0x06, 0xf3, 0xc9, 0x25,
+	0x2a, 0x3b, 0xe1, 0x75, 0xba, 0x58, 0xcf, 0x68, 0x7b, 0x11, 0xfd, 0x24, 0x81, 0x8e, 0xfb, 0x91,
+	0x1e, 0x05, 0x64, 0x84, 0x1e, 0x13, 0xca, 0x49, 0xed, 0x57, 0x4e, 0x24, 0x75, 0xf3, 0x60, 0xa9,
+	0x3f, 0x73, 0x0a, 0xf2, 0xe1, 0x95, 0x28, 0x2a, 0xc0, 0xe4, 0x95, 0x9b, 0xe4, 0x8d, 0x4b, 0x64,
+	0x65, 0x66, 0x02, 0x15, 0x21, 0xd7, 0xb8, 0xb4, 0xfc, 0x9a, 0x68, 0x19, 0x4b, 0xbf, 0xcc, 0x06,
+	0x89, 0x8b, 0x87, 0xfe, 0x17, 0x32, 0x32, 0x1b, 0x99, 0x8f, 0x98, 0xd3, 0x6f, 0x0b, 0xcb, 0x47,
+	0xf7, 0xc1, 0xa5, 0x94, 0xf0, 0xc4, 0x39, 0x03, 0xdd, 0x80, 0x82, 0x00, 0xaa, 0x0a, 0xf9, 0xf1,
+	0x64, 0xa1, 0x3a, 0x46, 0xe9, 0xc4, 0x88, 0x5e, 0x8d, 0xde, 0x45, 0xc8, 0x48, 0x81, 0xcd, 0x27,
+	0x92, 0xc6, 0x21, 0xab, 0x89, 0xdd, 0x19, 0xe0, 0x09, 0xf4, 0x22, 0xa4, 0x79, 0xc1, 0x08, 0x69,
+	0x39, 0xab, 0x56, 0xd8, 0x2e, 0xcf, 0x27, 0xc1, 0xda, 0xb4, 0x2f, 0x87, 0xf5, 0xf9, 0xa3, 0xc9,
+	0x22, 0x61, 0x30, 0xbc, 0xb4, 0xbf, 0x23, 0x9c, 0xf9, 0x26, 0x14, 0xf5, 0x52, 0x15, 0x3a, 0x11,
+	0x9f, 0x2a, 0x51, 0xd9, 0x2a, 0x57, 0x46, 0x75, 0x87, 0x04, 0xd7, 0xa0, 0xa0, 0x95, 0x89, 0x74,
+	0xb1, 0xee, 0xaf, 0x71, 0x95, 0x4f, 0x8c, 0xe8, 0x0d, 0xa9, 0xad, 0x42, 0x4e, 0x3c, 0x0d, 0xe1,
+	0x97, 0x57, 0xc7, 0x92, 0x09, 0xbd, 0x96, 0xc8, 0x95, 0x8f, 0x0f, 0xef, 0x0c, 0x09, 0xfd, 0x3f,
+	0xe4, 0x57, 0x29, 0x53, 0x11, 0xec, 0x68, 0x32, 0x04, 0x0e, 0x91, 0x54, 0x3c, 0x8c, 0xe2, 0x09,
+	0xf4, 0xa6, 0x38, 0x74, 0xc4, 0xdd, 0x33, 0xaa, 0x8e, 0x70, 0xc3, 0xe1, 0xba, 0x16, 0x46, 0x23,
+	0x84, 0x94, 0xdf, 0x88, 0x51, 0x56, 0x79, 0x43, 0x75, 0xc4, 0x86, 0x0d, 0x29, 0x57, 0x0f, 0x78,
+	0x73, 0x86, 0x27, 0x96, 0xde, 0x0a, 0xde, 0xb5, 0xac, 0xf0, 0x27, 0x57, 0x37, 0x61, 0x3a, 0x7c,
+	0x66, 0x23, 0xde, 0x65, 0xc5, 0x6c, 0x7e, 0xdf, 0x23, 0xb0, 0xf2, 0x89, 0x11, 0xbd, 0x21, 0xf9,
+	0xb7, 0x61, 0x4e, 0x5e, 0x33, 0xca, 0xa7, 0x4e, 0x57, 0x3c, 0xd7, 0x61, 0xdc, 0x67, 0x11, 0x98,
+	0x8a, 0xbd, 0x81, 0x42, 0x9a, 0xd5, 0x0c, 0x7b, 0x80, 0x55, 0xae, 0x8e, 0xec, 0x0f, 0xe7, 0xfa,
+	0x93, 0x01, 0x45, 0x7d, 0x32, 0x74, 0x07, 0xa6, 0xe3, 0x8f, 0x86, 0x74, 0x89, 0x0d, 0x7d, 0xc3,
+	0x54, 0x5e, 0x18, 0x8d, 0x10, 0xea, 0xe2, 0x6d, 0xf1, 0x00, 0x6a, 0xff, 0xd3, 0x15, 0x74, 0xea,
+	0xc0, 0x57, 0x32, 0x72, 0x92, 0xd3, 0x9f, 0xf3, 0x35, 0x0d, 0x9e, 0x68, 0xbc, 0xf5, 0xfe, 0x47,
+	0x95, 0x89, 0x0f, 0x3e, 0xaa, 0x4c, 0x7c, 0xf2, 0x51, 0xc5, 0xf8, 0xf6, 0x6e, 0xc5, 0xf8, 0xc5,
+	0x6e, 0xc5, 0x78, 0x6f, 0xb7, 0x62, 0xbc, 0xbf, 0x5b, 0x31, 0xfe, 0xba, 0x5b, 0x31, 0xfe, 0xb6,
+	0x5b, 0x99, 0xf8, 0x64, 0xb7, 0x62, 0xbc, 0xf3, 0x71, 0x65, 0xe2, 0xfd, 0x8f, 0x2b, 0x13, 0x1f,
+	0x7c, 0x5c, 0x99, 0xf8, 0xfa, 0xe9, 0x83, 0x4b, 0x19, 0x32, 0xac, 0x64, 0xc5, 0xdf, 0x73, 0xff,
+	0x1a, 0x00, 0x5e, 0x78, 0xce, 0x4e, 0x8e, 0x29, 0x00, 0x00,
 }
 
 func (x Direction) String() string {
@@ -4468,6 +4502,18 @@ func (this *LabelRequest) Equal(that interface{}) bool {
 	if this.Query != that1.Query {
 		return false
 	}
+	if this.MatchPrefix != that1.MatchPrefix {
+		return false
+	}
+	if this.MatchRegex != that1.MatchRegex {
+		return false
+	}
+	if this.Limit != that1.Limit {
+		return false
+	}
+	if this.Sort != that1.Sort {
+		return false
+	}
 	return true
 }
 func (this *LabelResponse) Equal(that interface{}) bool {
@@ -6100,13 +6146,17 @@ func (this *LabelRequest) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 9)
+	s := make([]string, 0, 13)
 	s = append(s, "&logproto.LabelRequest{")
 	s = append(s, "Name: "+fmt.Sprintf("%#v", this.Name)+",\n")
 	s = append(s, "Values: "+fmt.Sprintf("%#v", this.Values)+",\n")
 	s = append(s, "Start: "+fmt.Sprintf("%#v", this.Start)+",\n")
 	s = append(s, "End: "+fmt.Sprintf("%#v", this.End)+",\n")
 	s = append(s, "Query: "+fmt.Sprintf("%#v", this.Query)+",\n")
+	s = append(s, "MatchPrefix: "+fmt.Sprintf("%#v", this.MatchPrefix)+",\n")
+	s = append(s, "MatchRegex: "+fmt.Sprintf("%#v", this.MatchRegex)+",\n")
+	s = append(s, "Limit: "+fmt.Sprintf("%#v", this.Limit)+",\n")
+	s = append(s, "Sort: "+fmt.Sprintf("%#v", this.Sort)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -8380,6 +8430,32 @@ func (m *LabelRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if len(m.Sort) > 0 {
+		i -= len(m.Sort)
+		copy(dAtA[i:], m.Sort)
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.Sort)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if m.Limit != 0 {
+		i = encodeVarintLogproto(dAtA, i, uint64(m.Limit))
+		i--
+		dAtA[i] = 0x40
+	}
+	if len(m.MatchRegex) > 0 {
+		i -= len(m.MatchRegex)
+		copy(dAtA[i:], m.MatchRegex)
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.MatchRegex)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.MatchPrefix) > 0 {
+		i -= len(m.MatchPrefix)
+		copy(dAtA[i:], m.MatchPrefix)
+		i = encodeVarintLogproto(dAtA, i, uint64(len(m.MatchPrefix)))
+		i--
+		dAtA[i] = 0x32
+	}
 	if len(m.Query) > 0 {
 		i -= len(m.Query)
 		copy(dAtA[i:], m.Query)
@@ -10732,6 +10808,21 @@ func (m *LabelRequest) Size() (n int) {
 	if l > 0 {
 		n += 1 + l + sovLogproto(uint64(l))
 	}
+	l = len(m.MatchPrefix)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	l = len(m.MatchRegex)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
+	if m.Limit != 0 {
+		n += 1 + sovLogproto(uint64(m.Limit))
+	}
+	l = len(m.Sort)
+	if l > 0 {
+		n += 1 + l + sovLogproto(uint64(l))
+	}
 	return n
 }
 
@@ -11846,6 +11937,10 @@ func (this *LabelRequest) String() string {
 		`Start:` + strings.Replace(fmt.Sprintf("%v", this.Start), "Timestamp", "types.Timestamp", 1) + `,`,
 		`End:` + strings.Replace(fmt.Sprintf("%v", this.End), "Timestamp", "types.Timestamp", 1) + `,`,
 		`Query:` + fmt.Sprintf("%v", this.Query) + `,`,
+		`MatchPrefix:` + fmt.Sprintf("%v", this.MatchPrefix) + `,`,
+		`MatchRegex:` + fmt.Sprintf("%v", this.MatchRegex) + `,`,
+		`Limit:` + fmt.Sprintf("%v", this.Limit) + `,`,
+		`Sort:` + fmt.Sprintf("%v", this.Sort) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -15379,6 +15474,121 @@ func (m *LabelRequest) Unmarshal(dAtA []byte) error {
 			}
 			m.Query = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MatchPrefix", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MatchPrefix = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MatchRegex", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.MatchRegex = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Limit", wireType)
+			}
+			m.Limit = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Limit |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Sort", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowLogproto
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthLogproto
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Sort = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipLogproto(dAtA[iNdEx:])
@@ -9,8 +9,12 @@ import (
 	"sort"
 	"time"
 
+	"github.com/prometheus/prometheus/promql"
+	promql_parser "github.com/prometheus/prometheus/promql/parser"
+
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/metadata"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase/definitions"
@@ -29,9 +33,30 @@ type BufferedAccumulator struct {
 	results []logqlmodel.Result
 }
 
-func (a *BufferedAccumulator) Accumulate(_ context.Context, acc logqlmodel.Result, i int) error {
+func (a *BufferedAccumulator) Accumulate(ctx context.Context, acc logqlmodel.Result, i int) error {
 	a.results[i] = acc
-	return nil
+	return memory.FromContext(ctx).Reserve(resultSize(acc.Data))
+}
+
+// resultSize returns the approximate amount of bytes held by a query result.
+func resultSize(v promql_parser.Value) int64 {
+	var size int64
+	switch r := v.(type) {
+	case promql.Vector:
+		size = vectorSize(r)
+	case promql.Matrix:
+		for _, s := range r {
+			size += memory.LabelsSize(s.Metric) + int64(len(s.Floats))*memory.SampleSize
+		}
+	case logqlmodel.Streams:
+		for _, s := range r {
+			size += int64(len(s.Labels))
+			for _, e := range s.Entries {
+				size += memory.EntrySize(e)
+			}
+		}
+	}
+	return size
 }
 
 func (a *BufferedAccumulator) Result() []logqlmodel.Result {
@@ -205,6 +230,7 @@ func (a *CountMinSketchAccumulator) Result() []logqlmodel.Result {
 // to otherwise call this type.
 type AccumulatedStreams struct {
 	count, limit int
+	bytes        int64 // approximate bytes of the entries currently held
 	labelmap     map[string]int
 	streams      []*logproto.Stream
 	order        logproto.Direction
@@ -378,6 +404,10 @@ func (acc *AccumulatedStreams) addStream(s *logproto.Stream) {
 	i := len(acc.streams) - 1
 	acc.labelmap[s.Labels] = i
 	acc.count += len(s.Entries)
+	acc.bytes += int64(len(s.Labels))
+	for _, e := range s.Entries {
+		acc.bytes += memory.EntrySize(e)
+	}
 	heap.Fix(acc, i)
 }
 
@@ -396,6 +426,7 @@ func (acc *AccumulatedStreams) appendTo(dst, src *logproto.Stream) {
 			needsSort = true
 		}
 		dst.Entries = append(dst.Entries, e)
+		acc.bytes += memory.EntrySize(e)
 	}
 
 	if needsSort {
@@ -422,6 +453,7 @@ func (acc *AccumulatedStreams) Pop() any {
 	stream.Entries = stream.Entries[:len(stream.Entries)-1]
 
 	acc.count--
+	acc.bytes -= memory.EntrySize(cpy.Entries[0])
 
 	if len(stream.Entries) == 0 {
 		// remove stream
@@ -429,6 +461,7 @@ func (acc *AccumulatedStreams) Pop() any {
 		acc.streams[n-1] = nil // avoid leaking reference
 		delete(acc.labelmap, stream.Labels)
 		acc.streams = acc.streams[:n-1]
+		acc.bytes -= int64(len(stream.Labels))
 
 	}
 
@@ -478,7 +511,7 @@ func (acc *AccumulatedStreams) Result() []logqlmodel.Result {
 	return []logqlmodel.Result{res}
 }
 
-func (acc *AccumulatedStreams) Accumulate(_ context.Context, x logqlmodel.Result, _ int) error {
+func (acc *AccumulatedStreams) Accumulate(ctx context.Context, x logqlmodel.Result, _ int) error {
 	// TODO(owen-d/ewelch): Shard counts should be set by the querier
 	// so we don't have to do it in tricky ways in multiple places.
 	// See pkg/logql/downstream.go:DownstreamEvaluator.Downstream
@@ -495,9 +528,16 @@ func (acc *AccumulatedStreams) Accumulate(_ context.Context, x logqlmodel.Result
 
 	switch got := x.Data.(type) {
 	case logqlmodel.Streams:
+		before := acc.bytes
 		for i := range got {
 			acc.Push(&got[i])
 		}
+		// The accumulator is bounded by the limit, so pushing can also free memory.
+		if delta := acc.bytes - before; delta < 0 {
+			memory.FromContext(ctx).Release(-delta)
+		} else if err := memory.FromContext(ctx).Reserve(delta); err != nil {
+			return err
+		}
 	default:
 		return fmt.Errorf("unexpected response type during response result accumulation. Got (%T), wanted %s", got, logqlmodel.ValueTypeStreams)
 	}
@@ -29,6 +29,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/util"
 	"github.com/grafana/loki/v3/pkg/util/constants"
@@ -56,6 +57,12 @@ var (
 		Help:      "Count of queries blocked by per-tenant policy",
 	}, []string{"user"})
 
+	QueriesMemoryLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "query_memory_limited_queries_total",
+		Help:      "Count of queries cancelled because they reached the per-tenant query memory limit",
+	}, []string{"user"})
+
 	lastEntryMinTime = time.Unix(-100, 0)
 )
 
@@ -218,6 +225,7 @@ func (ng *Engine) Query(params Params) Query {
 		logExecQuery: ng.opts.LogExecutingQuery,
 		limits:       ng.limits,
 		multiVariant: ng.opts.EnableMutiVariantQueries,
+		trackMemory:  true,
 	}
 }
 
@@ -235,6 +243,9 @@ type query struct {
 	record       bool
 	logExecQuery bool
 	multiVariant bool
+	// trackMemory enforces the max_query_memory limit. It is disabled for queries
+	// executed by the query-frontend, whose subqueries are limited by the queriers.
+	trackMemory bool
 }
 
 func (q *query) resultLength(res promql_parser.Value) int {
@@ -327,6 +338,26 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 		return nil, logqlmodel.ErrBlocked
 	}
 
+	if !q.trackMemory {
+		return q.eval(ctx)
+	}
+	memoryCapture := func(id string) int { return q.limits.MaxQueryMemory(ctx, id) }
+	maxMemory := validation.SmallestPositiveNonZeroIntPerTenant(tenants, memoryCapture)
+	tracker, ctx := memory.NewContext(ctx, int64(maxMemory))
+	defer tracker.Close()
+
+	value, err := q.eval(ctx)
+	stats.FromContext(ctx).SetQueryMemoryBytes(tracker.Peak())
+	// Once the memory limit is reached the query context gets cancelled,
+	// so the error returned by the evaluation is likely a context error.
+	if memErr := tracker.Err(); memErr != nil {
+		QueriesMemoryLimited.WithLabelValues(tenant.JoinTenantIDs(tenants)).Inc()
+		return nil, memErr
+	}
+	return value, err
+}
+
+func (q *query) eval(ctx context.Context) (promql_parser.Value, error) {
 	switch e := q.params.GetExpression().(type) {
 	// A VariantsExpr is a specific type of SampleExpr, so make sure this case is evaulated first
 	case syntax.VariantsExpr:
@@ -352,7 +383,7 @@ func (q *query) Eval(ctx context.Context) (promql_parser.Value, error) {
 		}
 
 		defer util.LogErrorWithContext(ctx, "closing iterator", itr.Close)
-		streams, err := readStreams(itr, q.params.Limit(), q.params.Direction(), q.params.Interval())
+		streams, err := readStreams(ctx, itr, q.params.Limit(), q.params.Direction(), q.params.Interval())
 		return streams, err
 	default:
 		return nil, fmt.Errorf("unexpected type (%T): cannot evaluate", e)
@@ -420,7 +451,7 @@ func (q *query) evalSample(ctx context.Context, expr syntax.SampleExpr) (promql_
 			if rae, ok := expr.(*syntax.RangeAggregationExpr); ok && (rae.Operation == syntax.OpRangeTypeFirstWithTimestamp || rae.Operation == syntax.OpRangeTypeLastWithTimestamp) {
 				mfl = true
 			}
-			return q.JoinSampleVector(next, vec, stepEvaluator, maxSeries, mfl)
+			return q.JoinSampleVector(ctx, next, vec, stepEvaluator, maxSeries, mfl)
 		case ProbabilisticQuantileVector:
 			return JoinQuantileSketchVector(next, vec, stepEvaluator, q.params)
 		case CountMinSketchVector:
@@ -434,7 +465,10 @@ func (q *query) evalSample(ctx context.Context, expr syntax.SampleExpr) (promql_
 	return nil, errors.New("unexpected empty result")
 }
 
-func vectorsToSeries(vec promql.Vector, sm map[uint64]promql.Series) {
+// vectorsToSeries merges the vector into the series index and returns the
+// approximate amount of bytes added to it.
+func vectorsToSeries(vec promql.Vector, sm map[uint64]promql.Series) int64 {
+	var size int64
 	for _, p := range vec {
 		var (
 			series promql.Series
@@ -449,16 +483,20 @@ func vectorsToSeries(vec promql.Vector, sm map[uint64]promql.Series) {
 				Floats: make([]promql.FPoint, 0, 1),
 			}
 			sm[hash] = series
+			size += memory.LabelsSize(p.Metric)
 		}
+		size += memory.SampleSize
 		series.Floats = append(series.Floats, promql.FPoint{
 			T: p.T,
 			F: p.F,
 		})
 		sm[hash] = series
 	}
+	return size
 }
 
-func (q *query) JoinSampleVector(next bool, r StepResult, stepEvaluator StepEvaluator, maxSeries int, mergeFirstLast bool) (promql_parser.Value, error) {
+func (q *query) JoinSampleVector(ctx context.Context, next bool, r StepResult, stepEvaluator StepEvaluator, maxSeries int, mergeFirstLast bool) (promql_parser.Value, error) {
+	tracker := memory.FromContext(ctx)
 	vec := promql.Vector{}
 	if next {
 		vec = r.SampleVector()
@@ -471,6 +509,10 @@ func (q *query) JoinSampleVector(next bool, r StepResult, stepEvaluator StepEval
 	seriesIndex := map[uint64]promql.Series{}
 
 	if GetRangeType(q.params) == InstantType {
+		if err := tracker.Reserve(vectorSize(vec)); err != nil {
+			return nil, err
+		}
+
 		// an instant query sharded first/last_over_time can return a single vector
 		if mergeFirstLast {
 			vectorsToSeries(vec, seriesIndex)
@@ -495,11 +537,14 @@ func (q *query) JoinSampleVector(next bool, r StepResult, stepEvaluator StepEval
 
 	for next {
 		vec = r.SampleVector()
-		vectorsToSeries(vec, seriesIndex)
+		size := vectorsToSeries(vec, seriesIndex)
 		// as we slowly build the full query for each steps, make sure we don't go over the limit of unique series.
 		if len(seriesIndex) > maxSeries {
 			return nil, logqlmodel.NewSeriesLimitError(maxSeries)
 		}
+		if err := tracker.Reserve(size); err != nil {
+			return nil, err
+		}
 		next, _, r = stepEvaluator.Next()
 		if stepEvaluator.Error() != nil {
 			return nil, stepEvaluator.Error()
@@ -595,7 +640,8 @@ func PopulateMatrixFromScalar(data promql.Scalar, params Params) promql.Matrix {
 // If categorizeLabels is true, the stream labels contains just the stream labels and entries inside each stream have their
 // structuredMetadata and parsed fields populated with structured metadata labels plus the parsed labels respectively.
 // Otherwise, the stream labels are the whole series labels including the stream labels, structured metadata labels and parsed labels.
-func readStreams(i iter.EntryIterator, size uint32, dir logproto.Direction, interval time.Duration) (logqlmodel.Streams, error) {
+func readStreams(ctx context.Context, i iter.EntryIterator, size uint32, dir logproto.Direction, interval time.Duration) (logqlmodel.Streams, error) {
+	tracker := memory.FromContext(ctx)
 	streams := map[string]*logproto.Stream{}
 	respSize := uint32(0)
 	// lastEntry should be a really old time so that the first comparison is always true, we use a negative
@@ -619,8 +665,15 @@ func readStreams(i iter.EntryIterator, size uint32, dir logproto.Direction, inte
 					Labels: streamLabels,
 				}
 				streams[streamLabels] = stream
+				if err := tracker.Reserve(int64(len(streamLabels))); err != nil {
+					return nil, err
+				}
 			}
 			stream.Entries = append(stream.Entries, entry)
+			// The entries are held until the result is returned, so they are never released.
+			if err := tracker.Reserve(memory.EntrySize(entry)); err != nil {
+				return nil, err
+			}
 			lastEntry = i.At().Timestamp
 			respSize++
 		}
@@ -696,7 +749,7 @@ func (q *query) evalVariants(
 			// if rae, ok := expr.(*syntax.RangeAggregationExpr); ok && (rae.Operation == syntax.OpRangeTypeFirstWithTimestamp || rae.Operation == syntax.OpRangeTypeLastWithTimestamp) {
 			// 	mfl = true
 			// }
-			return q.JoinSampleVector(next, vec, stepEvaluator, maxSeries, mfl)
+			return q.JoinSampleVector(ctx, next, vec, stepEvaluator, maxSeries, mfl)
 		default:
 			return nil, fmt.Errorf("unsupported result type: %T", r)
 		}
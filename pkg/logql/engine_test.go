@@ -2862,6 +2862,37 @@ func TestEngine_MaxSeries(t *testing.T) {
 	}
 }
 
+func TestEngine_MaxQueryMemory(t *testing.T) {
+	for _, test := range []struct {
+		qs             string
+		direction      logproto.Direction
+		maxMemory      int
+		expectLimitErr bool
+	}{
+		{`{app="foo"}`, logproto.FORWARD, 1 << 10, true},
+		{`{app="foo"}`, logproto.FORWARD, 1 << 30, false},
+		{`rate({app="foo"}[30s])`, logproto.FORWARD, 1 << 10, true},
+		{`sum by (app) (rate({app=~"foo|bar"}[1m]))`, logproto.FORWARD, 1 << 10, true},
+		{`sum by (app) (rate({app=~"foo|bar"}[1m]))`, logproto.FORWARD, 0, false},
+	} {
+		t.Run(fmt.Sprintf("%s/%d", test.qs, test.maxMemory), func(t *testing.T) {
+			eng := NewEngine(EngineOpts{}, getLocalQuerier(100000), &fakeLimits{maxSeries: 100000, maxMemory: test.maxMemory}, log.NewNopLogger())
+			params, err := NewLiteralParams(test.qs, time.Unix(0, 0), time.Unix(100000, 0), 60*time.Second, 0, test.direction, 1000, nil, nil)
+			require.NoError(t, err)
+			q := eng.Query(params)
+			res, err := q.Exec(user.InjectOrgID(context.Background(), "fake"))
+			if test.expectLimitErr {
+				require.Error(t, err)
+				require.ErrorIs(t, err, logqlmodel.ErrMemoryLimit)
+				require.ErrorIs(t, err, logqlmodel.ErrLimit)
+			} else {
+				require.NoError(t, err)
+				require.Greater(t, res.Statistics.QueryMemoryBytes(), int64(0))
+			}
+		})
+	}
+}
+
 func TestEngine_MaxRangeInterval(t *testing.T) {
 	eng := NewEngine(EngineOpts{}, getLocalQuerier(100000), &fakeLimits{rangeLimit: 24 * time.Hour, maxSeries: 100000}, log.NewNopLogger())
 
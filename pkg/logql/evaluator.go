@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache/resultscache"
 	"github.com/grafana/loki/v3/pkg/util"
@@ -418,6 +419,7 @@ func newVectorAggEvaluator(
 		expr:          expr,
 		buf:           make([]byte, 0, 1024),
 		lb:            labels.NewBuilder(nil),
+		tracker:       memory.FromContext(ctx),
 	}, nil
 }
 
@@ -426,11 +428,22 @@ type VectorAggEvaluator struct {
 	expr          *syntax.VectorAggregationExpr
 	buf           []byte
 	lb            *labels.Builder
+
+	// tracker accounts the groups of the current step, they are released on the next step.
+	tracker *memory.Tracker
+	held    int64
+	err     error
 }
 
+// groupedAggregationSize is the approximate size of a groupedAggregation without its labels and heaps,
+// see vectorByValueHeap.size for the latter.
+const groupedAggregationSize = 96
+
 func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 	next, ts, r := e.nextEvaluator.Next()
 
+	e.tracker.Release(e.held)
+	e.held = 0
 	if !next {
 		return false, 0, SampleVector{}
 	}
@@ -472,6 +485,7 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 				}
 				sort.Sort(m)
 			}
+			e.held += groupedAggregationSize + memory.LabelsSize(m)
 			result[groupingKey] = &groupedAggregation{
 				labels:     m,
 				value:      s.F,
@@ -575,6 +589,13 @@ func (e *VectorAggEvaluator) Next() (bool, int64, StepResult) {
 			panic(errors.Errorf("expected aggregation operator but got %q", e.expr.Operation))
 		}
 	}
+	for _, aggr := range result {
+		e.held += aggr.heap.size() + aggr.reverseHeap.size()
+	}
+	if err := e.tracker.Reserve(e.held); err != nil {
+		e.err = err
+		return false, 0, SampleVector{}
+	}
 	vec = vec[:0]
 	for _, aggr := range result {
 		switch e.expr.Operation {
@@ -629,6 +650,9 @@ func (e *VectorAggEvaluator) Close() error {
 }
 
 func (e *VectorAggEvaluator) Error() error {
+	if e.err != nil {
+		return e.err
+	}
 	return e.nextEvaluator.Error()
 }
 
@@ -1396,7 +1420,7 @@ func (ev *DefaultEvaluator) NewVariantsStepEvaluator(
 }
 
 func (ev *DefaultEvaluator) newVariantsEvaluator(
-	_ context.Context,
+	ctx context.Context,
 	it iter.PeekingSampleIterator,
 	expr *syntax.MultiVariantExpr,
 	q Params,
@@ -1440,6 +1464,7 @@ func (ev *DefaultEvaluator) newVariantsEvaluator(
 						expr:          e,
 						buf:           make([]byte, 0, 1024),
 						lb:            labels.NewBuilder(nil),
+						tracker:       memory.FromContext(ctx),
 					}
 				} else {
 					return nil, fmt.Errorf("expected range aggregation expression but got %T", e.Left)
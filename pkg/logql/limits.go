@@ -16,6 +16,7 @@ var NoLimits = &fakeLimits{
 // Limits allow the engine to fetch limits for a given users.
 type Limits interface {
 	MaxQuerySeries(context.Context, string) int
+	MaxQueryMemory(context.Context, string) int
 	MaxQueryRange(ctx context.Context, userID string) time.Duration
 	QueryTimeout(context.Context, string) time.Duration
 	BlockedQueries(context.Context, string) []*validation.BlockedQuery
@@ -23,6 +24,7 @@ type Limits interface {
 
 type fakeLimits struct {
 	maxSeries      int
+	maxMemory      int
 	timeout        time.Duration
 	blockedQueries []*validation.BlockedQuery
 	rangeLimit     time.Duration
@@ -33,6 +35,10 @@ func (f fakeLimits) MaxQuerySeries(_ context.Context, _ string) int {
 	return f.maxSeries
 }
 
+func (f fakeLimits) MaxQueryMemory(_ context.Context, _ string) int {
+	return f.maxMemory
+}
+
 func (f fakeLimits) MaxQueryRange(_ context.Context, _ string) time.Duration {
 	return f.rangeLimit
 }
@@ -5,8 +5,19 @@ import (
 	"time"
 
 	"github.com/prometheus/prometheus/promql"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 )
 
+// vectorSize returns the approximate amount of bytes held by a vector.
+func vectorSize(vec promql.Vector) int64 {
+	var size int64
+	for _, p := range vec {
+		size += memory.LabelsSize(p.Metric) + memory.SampleSize
+	}
+	return size
+}
+
 type vectorByValueHeap promql.Vector
 
 func (s vectorByValueHeap) Len() int {
@@ -20,6 +31,12 @@ func (s vectorByValueHeap) Less(i, j int) bool {
 	return s[i].F < s[j].F
 }
 
+// size returns the approximate amount of bytes held by the heap.
+// The labels of the samples are shared with the input vector.
+func (s vectorByValueHeap) size() int64 {
+	return int64(len(s)) * memory.SampleSize
+}
+
 func (s vectorByValueHeap) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
@@ -49,6 +66,12 @@ func (s vectorByReverseValueHeap) Less(i, j int) bool {
 	return s[i].F > s[j].F
 }
 
+// size returns the approximate amount of bytes held by the heap.
+// The labels of the samples are shared with the input vector.
+func (s vectorByReverseValueHeap) size() int64 {
+	return int64(len(s)) * memory.SampleSize
+}
+
 func (s vectorByReverseValueHeap) Swap(i, j int) {
 	s[i], s[j] = s[j], s[i]
 }
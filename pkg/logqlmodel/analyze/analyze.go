@@ -0,0 +1,209 @@
+/*
+Package analyze records how a query was executed for EXPLAIN ANALYZE.
+The tree of executed nodes is passed through the query context.
+To start analyzing a query use:
+
+	root, ctx := analyze.NewContext(ctx, "Query", query, details)
+
+Every component splitting the query into sub-queries then adds a child node
+per sub-query and finishes it with the statistics of its response:
+
+	node, ctx := analyze.FromContext(ctx).Child(ctx, "Split", query, details)
+	resp, err := next.Do(ctx, req)
+	node.Finish(resp.Statistics)
+
+Each node captures the statistics recorded in its context, e.g. cache hits of
+the query-frontend, in addition to the statistics of its response.
+*/
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+type (
+	ctxKeyType string
+)
+
+const (
+	nodeKey ctxKeyType = "analyze"
+
+	// Explain is the value of the `explain` query parameter to execute a query with EXPLAIN ANALYZE.
+	Explain = "analyze"
+)
+
+// Stats are the statistics of a single node.
+type Stats struct {
+	BytesProcessed   int64 `json:"bytesProcessed"`
+	LinesProcessed   int64 `json:"linesProcessed"`
+	ChunksRef        int64 `json:"chunksRef"`
+	ChunksDownloaded int64 `json:"chunksDownloaded"`
+	CacheHits        int64 `json:"cacheHits"`
+	CacheRequests    int64 `json:"cacheRequests"`
+}
+
+// Node is a node of the executed plan. A nil Node is valid and records nothing.
+type Node struct {
+	Name    string `json:"name"`
+	Query   string `json:"query,omitempty"`
+	Details string `json:"details,omitempty"`
+	// ExecTime is the execution time in seconds. It is nil for nodes whose time
+	// isn't known, such as the ingester path of a querier.
+	ExecTime *float64 `json:"execTime,omitempty"`
+	Stats    Stats    `json:"stats"`
+	Children []*Node  `json:"children,omitempty"`
+
+	mtx    sync.Mutex
+	start  time.Time
+	stats  *stats.Context
+	parent *stats.Context
+}
+
+// NewContext creates the root node of the analyzed query.
+func NewContext(ctx context.Context, name, query, details string) (*Node, context.Context) {
+	n := newNode(name, query, details)
+	return n, n.withContext(ctx)
+}
+
+// FromContext returns the current node of the query or nil if the query is not analyzed.
+func FromContext(ctx context.Context) *Node {
+	v, ok := ctx.Value(nodeKey).(*Node)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// Child adds a new node executed as part of n and returns a context carrying it.
+func (n *Node) Child(ctx context.Context, name, query, details string) (*Node, context.Context) {
+	if n == nil {
+		return nil, ctx
+	}
+	child := newNode(name, query, details)
+
+	n.mtx.Lock()
+	n.Children = append(n.Children, child)
+	n.mtx.Unlock()
+
+	return child, child.withContext(ctx)
+}
+
+// Finish records the execution time and the statistics of the node.
+// Nodes without children are split into the ingester and store paths of the query.
+func (n *Node) Finish(res stats.Result) {
+	if n == nil {
+		return
+	}
+	captured := n.stats.Result(0, 0, 0)
+	// statistics recorded below this node still account to the parent.
+	n.parent.Merge(captured)
+	res.Merge(captured)
+
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	execTime := time.Since(n.start).Seconds()
+	n.ExecTime = &execTime
+	n.Stats = statsFromResult(res)
+	if len(n.Children) == 0 {
+		n.Children = pathNodes(res)
+	}
+}
+
+// MarshalJSON implements json.Marshaler. Nodes still executing can be marshalled safely.
+func (n *Node) MarshalJSON() ([]byte, error) {
+	n.mtx.Lock()
+	defer n.mtx.Unlock()
+
+	return json.Marshal(struct {
+		Name     string   `json:"name"`
+		Query    string   `json:"query,omitempty"`
+		Details  string   `json:"details,omitempty"`
+		ExecTime *float64 `json:"execTime,omitempty"`
+		Stats    Stats    `json:"stats"`
+		Children []*Node  `json:"children,omitempty"`
+	}{
+		Name:     n.Name,
+		Query:    n.Query,
+		Details:  n.Details,
+		ExecTime: n.ExecTime,
+		Stats:    n.Stats,
+		Children: append([]*Node(nil), n.Children...),
+	})
+}
+
+func newNode(name, query, details string) *Node {
+	return &Node{
+		Name:    name,
+		Query:   query,
+		Details: details,
+		start:   time.Now(),
+	}
+}
+
+func (n *Node) withContext(ctx context.Context) context.Context {
+	n.parent = stats.FromContext(ctx)
+	n.stats, ctx = stats.NewContext(ctx)
+	return context.WithValue(ctx, nodeKey, n)
+}
+
+func statsFromResult(r stats.Result) Stats {
+	s := Stats{
+		BytesProcessed:   r.Summary.TotalBytesProcessed,
+		LinesProcessed:   r.Summary.TotalLinesProcessed,
+		ChunksRef:        r.TotalChunksRef(),
+		ChunksDownloaded: r.TotalChunksDownloaded(),
+	}
+	for _, c := range []stats.Cache{
+		r.Caches.Chunk, r.Caches.Index, r.Caches.Result, r.Caches.StatsResult, r.Caches.VolumeResult,
+		r.Caches.SeriesResult, r.Caches.LabelResult, r.Caches.InstantMetricResult,
+	} {
+		s.CacheHits += int64(c.EntriesFound)
+		s.CacheRequests += int64(c.EntriesRequested)
+	}
+	return s
+}
+
+// pathNodes returns a node for each of the ingester and store paths that processed data.
+// Ingesters don't report how long they took, so their node has no execution time.
+func pathNodes(r stats.Result) []*Node {
+	var nodes []*Node
+
+	ingester := r.Ingester.Store
+	if r.Ingester.TotalReached > 0 {
+		nodes = append(nodes, &Node{
+			Name:    "Ingester",
+			Details: "ingesters reached: " + strconv.Itoa(int(r.Ingester.TotalReached)),
+			Stats: Stats{
+				BytesProcessed:   ingester.Chunk.HeadChunkBytes + ingester.Chunk.DecompressedBytes,
+				LinesProcessed:   ingester.Chunk.HeadChunkLines + ingester.Chunk.DecompressedLines,
+				ChunksRef:        ingester.TotalChunksRef,
+				ChunksDownloaded: ingester.TotalChunksDownloaded,
+			},
+		})
+	}
+
+	store := r.Querier.Store
+	if store.TotalChunksRef > 0 || store.Chunk.DecompressedBytes > 0 || store.Chunk.HeadChunkBytes > 0 {
+		execTime := (time.Duration(store.ChunksDownloadTime) + time.Duration(store.ChunkRefsFetchTime)).Seconds()
+		nodes = append(nodes, &Node{
+			Name:     "Store",
+			ExecTime: &execTime,
+			Stats: Stats{
+				BytesProcessed:   store.Chunk.HeadChunkBytes + store.Chunk.DecompressedBytes,
+				LinesProcessed:   store.Chunk.HeadChunkLines + store.Chunk.DecompressedLines,
+				ChunksRef:        store.TotalChunksRef,
+				ChunksDownloaded: store.TotalChunksDownloaded,
+				CacheHits:        int64(r.Caches.Chunk.EntriesFound + r.Caches.Index.EntriesFound),
+				CacheRequests:    int64(r.Caches.Chunk.EntriesRequested + r.Caches.Index.EntriesRequested),
+			},
+		})
+	}
+	return nodes
+}
@@ -0,0 +1,81 @@
+package analyze
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+)
+
+func TestNode(t *testing.T) {
+	parentStats, ctx := stats.NewContext(context.Background())
+	root, ctx := NewContext(ctx, "Query", `{app="foo"}`, "")
+	require.Same(t, root, FromContext(ctx))
+
+	split, splitCtx := FromContext(ctx).Child(ctx, "Split", `{app="foo"}`, "[0, 1]")
+	require.Same(t, split, FromContext(splitCtx))
+
+	// statistics recorded in the context of a node are captured by the node.
+	stats.FromContext(splitCtx).AddCacheEntriesRequested(stats.ResultCache, 2)
+	stats.FromContext(splitCtx).AddCacheEntriesFound(stats.ResultCache, 1)
+
+	split.Finish(stats.Result{
+		Ingester: stats.Ingester{
+			TotalReached: 2,
+			Store: stats.Store{
+				Chunk: stats.Chunk{HeadChunkBytes: 10, HeadChunkLines: 1},
+			},
+		},
+		Querier: stats.Querier{
+			Store: stats.Store{
+				TotalChunksRef:        3,
+				TotalChunksDownloaded: 2,
+				Chunk:                 stats.Chunk{DecompressedBytes: 20, DecompressedLines: 2},
+			},
+		},
+	})
+	root.Finish(stats.Result{})
+
+	require.Equal(t, Stats{
+		BytesProcessed:   30,
+		LinesProcessed:   3,
+		ChunksRef:        3,
+		ChunksDownloaded: 2,
+		CacheHits:        1,
+		CacheRequests:    2,
+	}, split.Stats)
+
+	// leaf nodes are split into the ingester and store paths.
+	require.Len(t, split.Children, 2)
+	require.Equal(t, "Ingester", split.Children[0].Name)
+	require.Equal(t, int64(10), split.Children[0].Stats.BytesProcessed)
+	require.Nil(t, split.Children[0].ExecTime)
+	require.Equal(t, "Store", split.Children[1].Name)
+	require.NotNil(t, split.Children[1].ExecTime)
+	require.Equal(t, int64(20), split.Children[1].Stats.BytesProcessed)
+
+	// the statistics captured by the nodes are still accounted by the parent context.
+	require.Equal(t, int32(1), parentStats.Result(0, 0, 0).Caches.Result.EntriesFound)
+	require.Equal(t, int64(1), root.Stats.CacheHits)
+
+	b, err := json.Marshal(root)
+	require.NoError(t, err)
+	var decoded Node
+	require.NoError(t, json.Unmarshal(b, &decoded))
+	require.Equal(t, "Split", decoded.Children[0].Name)
+	require.Equal(t, split.Stats, decoded.Children[0].Stats)
+}
+
+func TestNode_Nil(t *testing.T) {
+	ctx := context.Background()
+	node := FromContext(ctx)
+	require.Nil(t, node)
+
+	child, childCtx := node.Child(ctx, "Split", "", "")
+	require.Nil(t, child)
+	require.Equal(t, ctx, childCtx)
+	child.Finish(stats.Result{})
+}
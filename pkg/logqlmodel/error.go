@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/dustin/go-humanize"
 	"github.com/prometheus/prometheus/model/labels"
 )
 
@@ -13,6 +14,7 @@ var (
 	ErrParse                            = errors.New("failed to parse the log query")
 	ErrPipeline                         = errors.New("failed execute pipeline")
 	ErrLimit                            = errors.New("limit reached while evaluating the query")
+	ErrMemoryLimit                      = errors.New("query memory limit reached")
 	ErrIntervalLimit                    = errors.New("[interval] value exceeds limit")
 	ErrBlocked                          = errors.New("query blocked by policy")
 	ErrParseMatchers                    = errors.New("only label matchers are supported")
@@ -101,3 +103,21 @@ func NewSeriesLimitError(limit int) *LimitError {
 func (e LimitError) Is(target error) bool {
 	return target == ErrLimit
 }
+
+// MemoryLimitError is returned when a query allocated more memory than allowed.
+type MemoryLimitError struct {
+	limit int64
+}
+
+func NewMemoryLimitError(limit int64) *MemoryLimitError {
+	return &MemoryLimitError{limit: limit}
+}
+
+func (e MemoryLimitError) Error() string {
+	return fmt.Sprintf("maximum of query memory (%s) reached for a single query", humanize.IBytes(uint64(e.limit)))
+}
+
+// Is allows to use errors.Is(err,ErrMemoryLimit) or errors.Is(err,ErrLimit) on this error.
+func (e MemoryLimitError) Is(target error) bool {
+	return target == ErrMemoryLimit || target == ErrLimit
+}
@@ -0,0 +1,141 @@
+/*
+Package memory provides primitives for accounting the memory used by a single query.
+The tracker is passed through the query context.
+To start tracking a query use:
+
+	tracker, ctx := memory.NewContext(ctx, limit)
+
+Components holding on to query data then reserve and release bytes:
+
+	if err := memory.FromContext(ctx).Reserve(n); err != nil {
+		return err
+	}
+	defer memory.FromContext(ctx).Release(n)
+
+When a reservation exceeds the limit the query context is cancelled and every
+subsequent reservation fails, so in-flight work stops as soon as possible.
+*/
+package memory
+
+import (
+	"context"
+
+	"go.uber.org/atomic"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+type (
+	ctxKeyType string
+)
+
+const (
+	trackerKey ctxKeyType = "memory"
+)
+
+// Tracker accounts the bytes held by a query. A nil Tracker is valid and
+// accounts nothing.
+type Tracker struct {
+	limit  int64
+	used   atomic.Int64
+	peak   atomic.Int64
+	err    atomic.Error
+	cancel context.CancelCauseFunc
+}
+
+// NewContext creates a new memory tracker with the given limit in bytes and
+// returns a context that is cancelled once the limit is exceeded.
+// A limit of 0 disables enforcement, but bytes are still accounted.
+func NewContext(ctx context.Context, limit int64) (*Tracker, context.Context) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	t := &Tracker{
+		limit:  limit,
+		cancel: cancel,
+	}
+	ctx = context.WithValue(ctx, trackerKey, t)
+	return t, ctx
+}
+
+// FromContext returns the memory tracker of the query or nil if there is none.
+func FromContext(ctx context.Context) *Tracker {
+	v, ok := ctx.Value(trackerKey).(*Tracker)
+	if !ok {
+		return nil
+	}
+	return v
+}
+
+// Reserve accounts n bytes for the query. It returns an error if the query
+// exceeded its limit, in which case the query context is cancelled.
+// The bytes are accounted even when an error is returned.
+func (t *Tracker) Reserve(n int64) error {
+	if t == nil {
+		return nil
+	}
+	used := t.used.Add(n)
+	for {
+		peak := t.peak.Load()
+		if used <= peak || t.peak.CompareAndSwap(peak, used) {
+			break
+		}
+	}
+	if err := t.err.Load(); err != nil {
+		return err
+	}
+	if t.limit > 0 && used > t.limit {
+		err := logqlmodel.NewMemoryLimitError(t.limit)
+		if t.err.CompareAndSwap(nil, err) {
+			t.cancel(err)
+		}
+		return t.err.Load()
+	}
+	return nil
+}
+
+// Release returns n previously reserved bytes.
+func (t *Tracker) Release(n int64) {
+	if t == nil {
+		return
+	}
+	t.used.Sub(n)
+}
+
+// Used returns the bytes currently accounted.
+func (t *Tracker) Used() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.used.Load()
+}
+
+// Peak returns the highest amount of bytes accounted at any time.
+func (t *Tracker) Peak() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.peak.Load()
+}
+
+// Limit returns the limit in bytes, 0 means unlimited.
+func (t *Tracker) Limit() int64 {
+	if t == nil {
+		return 0
+	}
+	return t.limit
+}
+
+// Err returns the error the query was cancelled with, if the limit was exceeded.
+func (t *Tracker) Err() error {
+	if t == nil {
+		return nil
+	}
+	return t.err.Load()
+}
+
+// Close releases the resources associated with the tracker context.
+func (t *Tracker) Close() {
+	if t == nil {
+		return
+	}
+	t.cancel(context.Canceled)
+}
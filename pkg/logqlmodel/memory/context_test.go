@@ -0,0 +1,57 @@
+package memory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel"
+)
+
+func TestTracker(t *testing.T) {
+	tracker, ctx := NewContext(context.Background(), 100)
+	defer tracker.Close()
+	require.Same(t, tracker, FromContext(ctx))
+
+	require.NoError(t, tracker.Reserve(60))
+	require.NoError(t, tracker.Reserve(40))
+	tracker.Release(50)
+	require.Equal(t, int64(50), tracker.Used())
+	require.Equal(t, int64(100), tracker.Peak())
+	require.NoError(t, ctx.Err())
+
+	err := tracker.Reserve(51)
+	require.ErrorIs(t, err, logqlmodel.ErrMemoryLimit)
+	require.ErrorIs(t, err, logqlmodel.ErrLimit)
+	require.Equal(t, int64(101), tracker.Peak())
+	require.ErrorIs(t, ctx.Err(), context.Canceled)
+	require.ErrorIs(t, context.Cause(ctx), logqlmodel.ErrMemoryLimit)
+
+	// once exceeded every reservation fails, even after releasing memory.
+	tracker.Release(101)
+	require.ErrorIs(t, tracker.Reserve(1), logqlmodel.ErrMemoryLimit)
+	require.ErrorIs(t, tracker.Err(), logqlmodel.ErrMemoryLimit)
+}
+
+func TestTracker_Unlimited(t *testing.T) {
+	tracker, ctx := NewContext(context.Background(), 0)
+	defer tracker.Close()
+
+	require.NoError(t, tracker.Reserve(1<<40))
+	require.Equal(t, int64(1<<40), tracker.Peak())
+	require.NoError(t, ctx.Err())
+	require.NoError(t, tracker.Err())
+}
+
+func TestTracker_Nil(t *testing.T) {
+	tracker := FromContext(context.Background())
+	require.Nil(t, tracker)
+
+	require.NoError(t, tracker.Reserve(10))
+	tracker.Release(10)
+	tracker.Close()
+	require.Equal(t, int64(0), tracker.Used())
+	require.Equal(t, int64(0), tracker.Peak())
+	require.NoError(t, tracker.Err())
+}
@@ -0,0 +1,35 @@
+package memory
+
+import (
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+)
+
+const (
+	// SampleSize is the approximate size of a single float sample or point.
+	SampleSize = 16
+	// entryOverhead is the approximate size of the timestamp and slice headers of an entry.
+	entryOverhead = 48
+)
+
+// EntrySize returns the approximate bytes held by a log entry.
+func EntrySize(e logproto.Entry) int64 {
+	n := int64(entryOverhead + len(e.Line))
+	for _, l := range e.StructuredMetadata {
+		n += int64(len(l.Name) + len(l.Value))
+	}
+	for _, l := range e.Parsed {
+		n += int64(len(l.Name) + len(l.Value))
+	}
+	return n
+}
+
+// LabelsSize returns the approximate bytes held by a label set.
+func LabelsSize(ls labels.Labels) int64 {
+	var n int64
+	ls.Range(func(l labels.Label) {
+		n += int64(len(l.Name) + len(l.Value))
+	})
+	return n
+}
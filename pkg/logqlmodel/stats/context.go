@@ -69,6 +69,7 @@ const (
 	BloomFilterCache          CacheType = "bloom-filter"          //nolint:staticcheck
 	BloomBlocksCache          CacheType = "bloom-blocks"          //nolint:staticcheck
 	BloomMetasCache           CacheType = "bloom-metas"           //nolint:staticcheck
+	IndexGatewayResultCache   CacheType = "index-gateway-result"  //nolint:staticcheck
 )
 
 // NewContext creates a new statistics context
@@ -148,7 +149,8 @@ func (c *Context) Result(execTime time.Duration, queueTime time.Duration, totalE
 
 	r.Merge(Result{
 		Querier: Querier{
-			Store: c.store,
+			Store:            c.store,
+			QueryMemoryBytes: c.querier.QueryMemoryBytes,
 		},
 		Ingester: c.ingester,
 		Caches:   c.caches,
@@ -162,11 +164,15 @@ func (c *Context) Result(execTime time.Duration, queueTime time.Duration, totalE
 
 // JoinResults merges a Result with the embedded Result in a context in a concurrency-safe manner.
 func JoinResults(ctx context.Context, res Result) {
-	stats := FromContext(ctx)
-	stats.mtx.Lock()
-	defer stats.mtx.Unlock()
+	FromContext(ctx).Merge(res)
+}
 
-	stats.result.Merge(res)
+// Merge merges a Result with the embedded Result in a concurrency-safe manner.
+func (c *Context) Merge(res Result) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	c.result.Merge(res)
 }
 
 // JoinIngesters joins the ingester result statistics in a concurrency-safe manner.
@@ -233,6 +239,8 @@ func (s *Summary) Merge(m Summary) {
 
 func (q *Querier) Merge(m Querier) {
 	q.Store.Merge(m.Store)
+	// Subqueries are executed by different queriers, so summing their peaks is meaningless.
+	q.QueryMemoryBytes = max(q.QueryMemoryBytes, m.QueryMemoryBytes)
 }
 
 func (i *Ingester) Merge(m Ingester) {
@@ -340,6 +348,10 @@ func (r Result) TotalDecompressedLines() int64 {
 	return r.Querier.Store.Chunk.DecompressedLines + r.Ingester.Store.Chunk.DecompressedLines
 }
 
+func (r Result) QueryMemoryBytes() int64 {
+	return r.Querier.QueryMemoryBytes
+}
+
 func (r Result) QueryReferencedStructuredMetadata() bool {
 	return r.Querier.Store.QueryReferencedStructured || r.Ingester.Store.QueryReferencedStructured
 }
@@ -353,6 +365,11 @@ func (c *Context) AddIngesterTotalChunkMatched(i int64) {
 	atomic.AddInt64(&c.ingester.TotalChunksMatched, i)
 }
 
+// SetQueryMemoryBytes records the peak memory accounted for the query.
+func (c *Context) SetQueryMemoryBytes(i int64) {
+	atomic.StoreInt64(&c.querier.QueryMemoryBytes, i)
+}
+
 func (c *Context) AddIngesterReached(i int32) {
 	atomic.AddInt32(&c.ingester.TotalReached, i)
 }
@@ -576,6 +593,7 @@ func (r Result) KVList() []any {
 		"Querier.CompressedBytes", humanize.Bytes(uint64(r.Querier.Store.Chunk.CompressedBytes)),
 		"Querier.TotalDuplicates", r.Querier.Store.Chunk.TotalDuplicates,
 		"Querier.QueryReferencedStructuredMetadata", r.Querier.Store.QueryReferencedStructured,
+		"Querier.QueryMemoryBytes", humanize.Bytes(uint64(r.Querier.QueryMemoryBytes)),
 	}
 
 	result = append(result, r.Caches.kvList()...)
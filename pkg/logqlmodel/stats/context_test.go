@@ -296,6 +296,13 @@ func TestResult_Merge(t *testing.T) {
 	}, res)
 }
 
+func TestQuerier_MergeQueryMemoryBytes(t *testing.T) {
+	q := Querier{QueryMemoryBytes: 10}
+	q.Merge(Querier{QueryMemoryBytes: 30})
+	q.Merge(Querier{QueryMemoryBytes: 20})
+	require.Equal(t, int64(30), q.QueryMemoryBytes)
+}
+
 func TestReset(t *testing.T) {
 	statsCtx, ctx := NewContext(context.Background())
 	fakeIngesterQuery(ctx)
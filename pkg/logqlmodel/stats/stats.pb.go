@@ -426,6 +426,9 @@ func (m *Index) GetUsedBloomFilters() bool {
 
 type Querier struct {
 	Store Store `protobuf:"bytes,1,opt,name=store,proto3" json:"store"`
+	// Peak bytes accounted by the query memory tracker.
+	// When merging results of subqueries the highest peak is kept.
+	QueryMemoryBytes int64 `protobuf:"varint,2,opt,name=queryMemoryBytes,proto3" json:"queryMemoryBytes"`
 }
 
 func (m *Querier) Reset()      { *m = Querier{} }
@@ -467,6 +470,13 @@ func (m *Querier) GetStore() Store {
 	return Store{}
 }
 
+func (m *Querier) GetQueryMemoryBytes() int64 {
+	if m != nil {
+		return m.QueryMemoryBytes
+	}
+	return 0
+}
+
 type Ingester struct {
 	// Total ingester reached for this query.
 	TotalReached int32 `protobuf:"varint,1,opt,name=totalReached,proto3" json:"totalReached"`
@@ -882,95 +892,95 @@ func init() {
 func init() { proto.RegisterFile("pkg/logqlmodel/stats/stats.proto", fileDescriptor_6cdfe5d2aea33ebb) }
 
 var fileDescriptor_6cdfe5d2aea33ebb = []byte{
-	// 1397 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x58, 0xcd, 0x8f, 0xdc, 0xc4,
-	0x12, 0x9f, 0xd9, 0x89, 0x67, 0x37, 0xbd, 0x5f, 0x49, 0xef, 0xe6, 0x65, 0xf2, 0x12, 0xd9, 0xfb,
-	0xe6, 0x11, 0x11, 0x84, 0xb4, 0xa3, 0x10, 0x24, 0x04, 0x22, 0x12, 0xf2, 0x86, 0x95, 0x22, 0x6d,
-	0x44, 0xa8, 0x05, 0x81, 0xe0, 0xe4, 0xb5, 0x7b, 0x67, 0xac, 0x78, 0xdc, 0xb3, 0x76, 0x7b, 0xc9,
-	0x9e, 0xe0, 0x4f, 0xe0, 0xce, 0x1d, 0x71, 0xe1, 0xc4, 0x89, 0x33, 0x97, 0x1c, 0x73, 0xcc, 0xc9,
-	0x22, 0x93, 0x0b, 0xf2, 0x29, 0x67, 0xc4, 0x01, 0x75, 0x75, 0x8f, 0xbf, 0xc6, 0xb3, 0xd9, 0xcb,
-	0xb8, 0xeb, 0x57, 0xf5, 0xab, 0x6a, 0x97, 0xab, 0xab, 0x5a, 0x43, 0x76, 0x26, 0x4f, 0x86, 0x83,
-	0x80, 0x0f, 0x4f, 0x82, 0x31, 0xf7, 0x58, 0x30, 0x88, 0x85, 0x23, 0x62, 0xf5, 0xbb, 0x3b, 0x89,
-	0xb8, 0xe0, 0xd4, 0x40, 0xe1, 0xbf, 0xdb, 0x43, 0x3e, 0xe4, 0x88, 0x0c, 0xe4, 0x4a, 0x29, 0xfb,
-	0x3f, 0x2f, 0x91, 0x2e, 0xb0, 0x38, 0x09, 0x04, 0xfd, 0x90, 0x2c, 0xc7, 0xc9, 0x78, 0xec, 0x44,
-	0x67, 0xbd, 0xf6, 0x4e, 0xfb, 0xce, 0xea, 0x7b, 0x1b, 0xbb, 0xca, 0xcd, 0xa1, 0x42, 0xed, 0xcd,
-	0x67, 0xa9, 0xd5, 0xca, 0x52, 0x6b, 0x66, 0x06, 0xb3, 0x85, 0xa4, 0x9e, 0x24, 0x2c, 0xf2, 0x59,
-	0xd4, 0x5b, 0xaa, 0x50, 0x3f, 0x57, 0x68, 0x41, 0xd5, 0x66, 0x30, 0x5b, 0xd0, 0xfb, 0x64, 0xc5,
-	0x0f, 0x87, 0x2c, 0x16, 0x2c, 0xea, 0x75, 0x90, 0xbb, 0xa9, 0xb9, 0x0f, 0x35, 0x6c, 0x5f, 0xd1,
-	0xe4, 0xdc, 0x10, 0xf2, 0x15, 0x7d, 0x9f, 0x74, 0x5d, 0xc7, 0x1d, 0xb1, 0xb8, 0x77, 0x09, 0xc9,
-	0xeb, 0x9a, 0xbc, 0x87, 0xa0, 0xbd, 0xae, 0xa9, 0x06, 0x1a, 0x81, 0xb6, 0xa5, 0x77, 0x89, 0xe1,
-	0x87, 0x1e, 0x7b, 0xda, 0x33, 0x90, 0xb4, 0x96, 0x47, 0xf4, 0xd8, 0xd3, 0x82, 0x83, 0x26, 0xa0,
-	0x1e, 0xfd, 0x9f, 0x2e, 0x91, 0xee, 0x5e, 0xce, 0x76, 0x47, 0x49, 0xf8, 0x44, 0xa7, 0x69, 0xad,
-	0x1c, 0xb2, 0x14, 0x51, 0x9a, 0x80, 0x7a, 0x14, 0x01, 0x97, 0xce, 0xa3, 0x94, 0x03, 0xca, 0x37,
-	0x8b, 0xf0, 0xc3, 0xe8, 0xb4, 0x54, 0x39, 0x1b, 0x9a, 0xa3, 0x6d, 0x40, 0x3f, 0xe9, 0x1e, 0x59,
-	0x45, 0x33, 0xf5, 0x4d, 0x75, 0x52, 0xaa, 0xd4, 0x2d, 0x4d, 0x2d, 0x1b, 0x42, 0x59, 0xa0, 0xfb,
-	0x64, 0xed, 0x94, 0x07, 0xc9, 0x98, 0x69, 0x2f, 0x46, 0x83, 0x97, 0x6d, 0xed, 0xa5, 0x62, 0x09,
-	0x15, 0x49, 0xfa, 0x89, 0xe5, 0x57, 0x9e, 0xed, 0xa6, 0x7b, 0x9e, 0x9f, 0xb2, 0x25, 0x54, 0x24,
-	0xf9, 0x52, 0x81, 0x73, 0xc4, 0x02, 0xed, 0x66, 0xf9, 0xbc, 0x97, 0x2a, 0x19, 0x42, 0x59, 0xa0,
-	0xdf, 0x92, 0x2d, 0x3f, 0x8c, 0x85, 0x13, 0x8a, 0x47, 0x4c, 0x44, 0xbe, 0xab, 0x9d, 0xad, 0x34,
-	0x38, 0xbb, 0xa9, 0x9d, 0x35, 0x11, 0xa0, 0x09, 0xec, 0xff, 0xde, 0x25, 0xcb, 0xfa, 0x98, 0xd0,
-	0x2f, 0xc9, 0xf5, 0xa3, 0x33, 0xc1, 0xe2, 0xc7, 0x11, 0x77, 0x59, 0x1c, 0x33, 0xef, 0x31, 0x8b,
-	0x0e, 0x99, 0xcb, 0x43, 0x0f, 0x0b, 0xa6, 0x63, 0xdf, 0xcc, 0x52, 0x6b, 0x91, 0x09, 0x2c, 0x52,
-	0x48, 0xb7, 0x81, 0x1f, 0x36, 0xba, 0x5d, 0x2a, 0xdc, 0x2e, 0x30, 0x81, 0x45, 0x0a, 0xfa, 0x90,
-	0x6c, 0x09, 0x2e, 0x9c, 0xc0, 0xae, 0x84, 0xc5, 0x9a, 0xeb, 0xd8, 0xd7, 0x65, 0x12, 0x1a, 0xd4,
-	0xd0, 0x04, 0xe6, 0xae, 0x0e, 0x2a, 0xa1, 0xb0, 0x06, 0xcb, 0xae, 0xaa, 0x6a, 0x68, 0x02, 0xe9,
-	0x1d, 0xb2, 0xc2, 0x9e, 0x32, 0xf7, 0x0b, 0x7f, 0xcc, 0xb0, 0xfa, 0xda, 0xf6, 0x9a, 0x6c, 0x00,
-	0x33, 0x0c, 0xf2, 0x15, 0x7d, 0x97, 0x5c, 0x3e, 0x49, 0x58, 0xc2, 0xd0, 0xb4, 0x8b, 0xa6, 0xeb,
-	0x59, 0x6a, 0x15, 0x20, 0x14, 0x4b, 0xba, 0x4b, 0x48, 0x9c, 0x1c, 0xa9, 0xd6, 0x13, 0x63, 0x1d,
-	0x75, 0xec, 0x8d, 0x2c, 0xb5, 0x4a, 0x28, 0x94, 0xd6, 0xf4, 0x80, 0x6c, 0xe3, 0xee, 0x3e, 0x0d,
-	0x85, 0x2a, 0x47, 0x91, 0x44, 0x21, 0xf3, 0xb0, 0x68, 0x3a, 0x76, 0x2f, 0x4b, 0xad, 0x46, 0x3d,
-	0x34, 0xa2, 0xb4, 0x4f, 0xba, 0xf1, 0x24, 0xf0, 0x45, 0xdc, 0xbb, 0x8c, 0x7c, 0x22, 0xcf, 0xaf,
-	0x42, 0x40, 0x3f, 0xd1, 0x66, 0xe4, 0x44, 0x5e, 0xdc, 0x23, 0x25, 0x1b, 0x44, 0x40, 0x3f, 0xf3,
-	0x5d, 0x3d, 0xe6, 0xb1, 0xd8, 0xf7, 0x03, 0xc1, 0x22, 0xcc, 0x5e, 0x6f, 0xb5, 0xb6, 0xab, 0x9a,
-	0x1e, 0x1a, 0x51, 0xfa, 0x3d, 0xb9, 0x8d, 0xf8, 0xa1, 0x88, 0x12, 0x57, 0x24, 0x11, 0xf3, 0x1e,
-	0x31, 0xe1, 0x78, 0x8e, 0x70, 0x6a, 0x25, 0xb1, 0x86, 0xee, 0xdf, 0xc9, 0x52, 0xeb, 0x62, 0x04,
-	0xb8, 0x98, 0x59, 0xff, 0xef, 0x36, 0x31, 0xb0, 0xf3, 0xd2, 0xbb, 0x64, 0x15, 0x29, 0x7b, 0xb2,
-	0x67, 0xc6, 0xfa, 0xb4, 0x6c, 0xca, 0x53, 0x5d, 0x82, 0xa1, 0x2c, 0xd0, 0x4f, 0xc8, 0x95, 0x49,
-	0xfe, 0x42, 0x9a, 0xa7, 0x8e, 0xc3, 0x76, 0x96, 0x5a, 0x73, 0x3a, 0x98, 0x43, 0xe8, 0x47, 0x64,
-	0x43, 0xe5, 0xf5, 0x41, 0x12, 0x39, 0xc2, 0xe7, 0xa1, 0xae, 0x7d, 0x9a, 0xa5, 0x56, 0x4d, 0x03,
-	0x35, 0x59, 0x46, 0x4f, 0x62, 0xe6, 0xd9, 0x01, 0xe7, 0x63, 0xe5, 0x54, 0xcd, 0xa1, 0x15, 0x15,
-	0xbd, 0xae, 0x83, 0x39, 0xa4, 0xff, 0x31, 0x59, 0xd6, 0x33, 0x52, 0xce, 0x88, 0x58, 0xf0, 0x88,
-	0xd5, 0xc6, 0xca, 0xa1, 0xc4, 0x8a, 0x19, 0x81, 0x26, 0xa0, 0x1e, 0xfd, 0x5f, 0x97, 0xc8, 0xca,
-	0xc3, 0x62, 0x14, 0xae, 0x61, 0x66, 0x80, 0xc9, 0x26, 0xa6, 0x9a, 0x8d, 0x61, 0x5f, 0x91, 0xbd,
-	0xb5, 0x8c, 0x43, 0x45, 0xa2, 0xfb, 0x84, 0x96, 0xf2, 0xf9, 0xc8, 0x11, 0xc8, 0x55, 0x29, 0xfc,
-	0x4f, 0x96, 0x5a, 0x0d, 0x5a, 0x68, 0xc0, 0xf2, 0xe8, 0x36, 0xca, 0xb1, 0x4e, 0x62, 0x11, 0x5d,
-	0xe3, 0x50, 0x91, 0x64, 0xf2, 0x8b, 0xe3, 0x7f, 0xc8, 0x42, 0xa1, 0xbb, 0x05, 0x26, 0xbf, 0xaa,
-	0x81, 0x9a, 0x5c, 0xe4, 0xcb, 0xb8, 0x70, 0xbe, 0xfe, 0xb9, 0x44, 0x0c, 0xd4, 0xe7, 0x81, 0x75,
-	0x59, 0xb0, 0x63, 0x5d, 0x6d, 0x45, 0xe0, 0x5c, 0x03, 0x35, 0x99, 0x7e, 0x46, 0xae, 0x95, 0x90,
-	0x07, 0xfc, 0xbb, 0x30, 0xe0, 0x8e, 0x97, 0x67, 0xed, 0x46, 0x96, 0x5a, 0xcd, 0x06, 0xd0, 0x0c,
-	0xcb, 0x6f, 0xe0, 0x56, 0x30, 0x6c, 0x66, 0x9d, 0xe2, 0x1b, 0xcc, 0x6b, 0xa1, 0x01, 0xa3, 0x2e,
-	0xb9, 0x21, 0x3b, 0xd7, 0x19, 0xb0, 0x63, 0x16, 0xb1, 0xd0, 0x65, 0x5e, 0x71, 0xf8, 0x7a, 0xeb,
-	0x58, 0x97, 0xb7, 0xb3, 0xd4, 0xfa, 0xdf, 0x42, 0xa3, 0xd9, 0x09, 0x85, 0xc5, 0x7e, 0x8a, 0xdb,
-	0x4f, 0xed, 0x6e, 0x21, 0xb1, 0x05, 0xb7, 0x9f, 0xd9, 0xfb, 0x01, 0x3b, 0x8e, 0xf7, 0x99, 0x70,
-	0x47, 0x79, 0x5f, 0x2f, 0xbf, 0x5f, 0x45, 0x0b, 0x0d, 0x18, 0xfd, 0x9a, 0xf4, 0x5c, 0x8e, 0xe5,
-	0xee, 0xf3, 0x70, 0x8f, 0x87, 0x22, 0xe2, 0xc1, 0x81, 0x23, 0x58, 0xe8, 0x9e, 0x61, 0xeb, 0xef,
-	0xd8, 0xb7, 0xb2, 0xd4, 0x5a, 0x68, 0x03, 0x0b, 0x35, 0xd4, 0x23, 0xb7, 0x26, 0xfe, 0x84, 0xc9,
-	0x21, 0xf9, 0x55, 0xe4, 0x4c, 0x26, 0x2c, 0x52, 0x07, 0x94, 0x79, 0xaa, 0xb5, 0xaa, 0x51, 0xb1,
-	0x93, 0xa5, 0xd6, 0xb9, 0x76, 0x70, 0xae, 0xb6, 0xff, 0x9b, 0x41, 0x0c, 0xcc, 0x93, 0x2c, 0xbf,
-	0x11, 0x73, 0x3c, 0x95, 0x34, 0xd9, 0x0e, 0xcb, 0x75, 0x5f, 0xd5, 0x40, 0x4d, 0xae, 0x70, 0xd5,
-	0xee, 0x8c, 0x06, 0xae, 0xda, 0x4f, 0x4d, 0xa6, 0x7b, 0xe4, 0xaa, 0xc7, 0x5c, 0x3e, 0x9e, 0x44,
-	0xd8, 0x7b, 0x55, 0x68, 0x95, 0xba, 0x6b, 0x59, 0x6a, 0xcd, 0x2b, 0x61, 0x1e, 0xaa, 0x3b, 0x29,
-	0x67, 0x68, 0xce, 0x89, 0xda, 0xc6, 0x3c, 0x44, 0xef, 0x93, 0xcd, 0xfa, 0x3e, 0xd4, 0x54, 0xdd,
-	0xca, 0x52, 0xab, 0xae, 0x82, 0x3a, 0x20, 0xe9, 0x78, 0x96, 0x1e, 0x24, 0x93, 0xc0, 0x77, 0x1d,
-	0x49, 0xbf, 0x5c, 0xd0, 0x6b, 0x2a, 0xa8, 0x03, 0x92, 0x3e, 0xa9, 0x4d, 0x4f, 0x52, 0xd0, 0x6b,
-	0x2a, 0xa8, 0x03, 0x74, 0x42, 0x76, 0xf2, 0xc4, 0x2e, 0x98, 0x6f, 0x7a, 0x1a, 0xbf, 0x95, 0xa5,
-	0xd6, 0x1b, 0x6d, 0xe1, 0x8d, 0x16, 0xf4, 0x8c, 0xfc, 0xbf, 0x9c, 0xc3, 0x45, 0x41, 0xd5, 0x8c,
-	0x7e, 0x3b, 0x4b, 0xad, 0x8b, 0x98, 0xc3, 0x45, 0x8c, 0xfa, 0x7f, 0x74, 0x88, 0x81, 0xf7, 0x62,
-	0xd9, 0xe3, 0x99, 0xba, 0xd3, 0xec, 0xf3, 0x24, 0xac, 0x4c, 0x98, 0x32, 0x0e, 0x15, 0x49, 0x0e,
-	0x49, 0x36, 0xbb, 0x09, 0x9d, 0x24, 0x72, 0x56, 0xa9, 0x4e, 0x69, 0xa8, 0x21, 0x59, 0xd7, 0xc1,
-	0x1c, 0x42, 0x3f, 0x20, 0xeb, 0x1a, 0xc3, 0xe6, 0xad, 0x6e, 0xa7, 0x86, 0x7d, 0x35, 0x4b, 0xad,
-	0xaa, 0x02, 0xaa, 0xa2, 0x24, 0xe2, 0x75, 0x1a, 0x98, 0xcb, 0xfc, 0xd3, 0xfc, 0x2e, 0x8a, 0xc4,
-	0x8a, 0x02, 0xaa, 0xa2, 0xbc, 0x55, 0x22, 0x80, 0x23, 0x49, 0x1d, 0x2f, 0xbc, 0x55, 0xe6, 0x20,
-	0x14, 0x4b, 0x79, 0x59, 0x8d, 0xd4, 0x5e, 0xd5, 0x59, 0x32, 0xd4, 0x65, 0x75, 0x86, 0x41, 0xbe,
-	0x92, 0x09, 0xf4, 0xca, 0x2d, 0x7e, 0xb9, 0x18, 0x92, 0x65, 0x1c, 0x2a, 0x92, 0x3c, 0x6f, 0xd8,
-	0x8e, 0x0f, 0x58, 0x38, 0x14, 0xa3, 0x43, 0x16, 0x9d, 0xe6, 0x57, 0x50, 0x3c, 0x6f, 0x73, 0x4a,
-	0x98, 0x87, 0x6c, 0xf6, 0xfc, 0xa5, 0xd9, 0x7a, 0xf1, 0xd2, 0x6c, 0xbd, 0x7e, 0x69, 0xb6, 0x7f,
-	0x98, 0x9a, 0xed, 0x5f, 0xa6, 0x66, 0xfb, 0xd9, 0xd4, 0x6c, 0x3f, 0x9f, 0x9a, 0xed, 0x3f, 0xa7,
-	0x66, 0xfb, 0xaf, 0xa9, 0xd9, 0x7a, 0x3d, 0x35, 0xdb, 0x3f, 0xbe, 0x32, 0x5b, 0xcf, 0x5f, 0x99,
-	0xad, 0x17, 0xaf, 0xcc, 0xd6, 0x37, 0x83, 0xa1, 0x2f, 0x46, 0xc9, 0xd1, 0xae, 0xcb, 0xc7, 0x83,
-	0x61, 0xe4, 0x1c, 0x3b, 0xa1, 0x33, 0x08, 0xf8, 0x13, 0x7f, 0x70, 0x7a, 0x6f, 0xd0, 0xf4, 0xc7,
-	0xc3, 0x51, 0x17, 0xff, 0x56, 0xb8, 0xf7, 0x6f, 0x00, 0x00, 0x00, 0xff, 0xff, 0x2a, 0x3e, 0x6e,
-	0x00, 0x97, 0x10, 0x00, 0x00,
+	// 1407 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x8c, 0x58, 0xcd, 0x6f, 0xdc, 0x44,
+	0x14, 0xdf, 0xcd, 0xc6, 0x9b, 0x74, 0xf2, 0xd5, 0x4e, 0x52, 0xea, 0xd2, 0xca, 0x0e, 0x0b, 0x15,
+	0x45, 0x48, 0x59, 0x95, 0x22, 0x21, 0x90, 0x2a, 0x21, 0xa7, 0x44, 0xaa, 0x94, 0x88, 0xf2, 0x02,
+	0x02, 0xc1, 0xc9, 0xb1, 0x27, 0xbb, 0x56, 0xbd, 0xf6, 0xc6, 0x1e, 0x87, 0xe6, 0x02, 0xfc, 0x09,
+	0xdc, 0xb9, 0x23, 0x2e, 0x9c, 0x38, 0x71, 0xe6, 0xd2, 0x63, 0x8f, 0x3d, 0x59, 0x74, 0x73, 0x41,
+	0x3e, 0xf5, 0x8c, 0x38, 0xa0, 0x79, 0x33, 0xeb, 0xaf, 0xf5, 0xa6, 0xb9, 0xac, 0xe7, 0xfd, 0x7e,
+	0xef, 0xf7, 0xe6, 0xfb, 0xbd, 0xd1, 0x92, 0xed, 0xf1, 0x93, 0x41, 0xdf, 0x0f, 0x07, 0x27, 0xfe,
+	0x28, 0x74, 0x99, 0xdf, 0x8f, 0xb9, 0xcd, 0x63, 0xf9, 0xbb, 0x33, 0x8e, 0x42, 0x1e, 0x52, 0x0d,
+	0x8d, 0x37, 0xb7, 0x06, 0xe1, 0x20, 0x44, 0xa4, 0x2f, 0x5a, 0x92, 0xec, 0xfd, 0xba, 0x40, 0xba,
+	0xc0, 0xe2, 0xc4, 0xe7, 0xf4, 0x63, 0xb2, 0x14, 0x27, 0xa3, 0x91, 0x1d, 0x9d, 0xe9, 0xed, 0xed,
+	0xf6, 0xdd, 0x95, 0x0f, 0xd6, 0x77, 0x64, 0x98, 0x43, 0x89, 0x5a, 0x1b, 0xcf, 0x52, 0xb3, 0x95,
+	0xa5, 0xe6, 0xd4, 0x0d, 0xa6, 0x0d, 0x21, 0x3d, 0x49, 0x58, 0xe4, 0xb1, 0x48, 0x5f, 0xa8, 0x48,
+	0xbf, 0x90, 0x68, 0x21, 0x55, 0x6e, 0x30, 0x6d, 0xd0, 0x07, 0x64, 0xd9, 0x0b, 0x06, 0x2c, 0xe6,
+	0x2c, 0xd2, 0x3b, 0xa8, 0xdd, 0x50, 0xda, 0x47, 0x0a, 0xb6, 0xae, 0x2a, 0x71, 0xee, 0x08, 0x79,
+	0x8b, 0x7e, 0x48, 0xba, 0x8e, 0xed, 0x0c, 0x59, 0xac, 0x2f, 0xa2, 0x78, 0x4d, 0x89, 0x77, 0x11,
+	0xb4, 0xd6, 0x94, 0x54, 0x43, 0x27, 0x50, 0xbe, 0xf4, 0x1e, 0xd1, 0xbc, 0xc0, 0x65, 0x4f, 0x75,
+	0x0d, 0x45, 0xab, 0x79, 0x8f, 0x2e, 0x7b, 0x5a, 0x68, 0xd0, 0x05, 0xe4, 0xa7, 0xf7, 0xcb, 0x22,
+	0xe9, 0xee, 0xe6, 0x6a, 0x67, 0x98, 0x04, 0x4f, 0xf4, 0x76, 0x45, 0x8d, 0x6c, 0xa9, 0x47, 0xe1,
+	0x02, 0xf2, 0x53, 0x74, 0xb8, 0x70, 0x91, 0xa4, 0xdc, 0xa1, 0x98, 0x59, 0x84, 0x1b, 0xa3, 0x77,
+	0x1a, 0x34, 0xeb, 0x4a, 0xa3, 0x7c, 0x40, 0x7d, 0xe9, 0x2e, 0x59, 0x41, 0x37, 0xb9, 0xa7, 0xfa,
+	0x62, 0x83, 0x74, 0x53, 0x49, 0xcb, 0x8e, 0x50, 0x36, 0xe8, 0x1e, 0x59, 0x3d, 0x0d, 0xfd, 0x64,
+	0xc4, 0x54, 0x14, 0xad, 0x21, 0xca, 0x96, 0x8a, 0x52, 0xf1, 0x84, 0x8a, 0x25, 0xe2, 0xc4, 0x62,
+	0x97, 0xa7, 0xa3, 0xe9, 0x5e, 0x14, 0xa7, 0xec, 0x09, 0x15, 0x4b, 0x4c, 0xca, 0xb7, 0x8f, 0x98,
+	0xaf, 0xc2, 0x2c, 0x5d, 0x34, 0xa9, 0x92, 0x23, 0x94, 0x0d, 0xfa, 0x1d, 0xd9, 0xf4, 0x82, 0x98,
+	0xdb, 0x01, 0x3f, 0x60, 0x3c, 0xf2, 0x1c, 0x15, 0x6c, 0xb9, 0x21, 0xd8, 0x2d, 0x15, 0xac, 0x49,
+	0x00, 0x4d, 0x60, 0xef, 0xcf, 0x2e, 0x59, 0x52, 0xd7, 0x84, 0x7e, 0x45, 0x6e, 0x1c, 0x9d, 0x71,
+	0x16, 0x3f, 0x8e, 0x42, 0x87, 0xc5, 0x31, 0x73, 0x1f, 0xb3, 0xe8, 0x90, 0x39, 0x61, 0xe0, 0xe2,
+	0x81, 0xe9, 0x58, 0xb7, 0xb2, 0xd4, 0x9c, 0xe7, 0x02, 0xf3, 0x08, 0x11, 0xd6, 0xf7, 0x82, 0xc6,
+	0xb0, 0x0b, 0x45, 0xd8, 0x39, 0x2e, 0x30, 0x8f, 0xa0, 0x8f, 0xc8, 0x26, 0x0f, 0xb9, 0xed, 0x5b,
+	0x95, 0x6e, 0xf1, 0xcc, 0x75, 0xac, 0x1b, 0x62, 0x11, 0x1a, 0x68, 0x68, 0x02, 0xf3, 0x50, 0xfb,
+	0x95, 0xae, 0xf4, 0xc5, 0x5a, 0xa8, 0x2a, 0x0d, 0x4d, 0x20, 0xbd, 0x4b, 0x96, 0xd9, 0x53, 0xe6,
+	0x7c, 0xe9, 0x8d, 0x18, 0x9e, 0xbe, 0xb6, 0xb5, 0x2a, 0x12, 0xc0, 0x14, 0x83, 0xbc, 0x45, 0xdf,
+	0x27, 0x57, 0x4e, 0x12, 0x96, 0x30, 0x74, 0xed, 0xa2, 0xeb, 0x5a, 0x96, 0x9a, 0x05, 0x08, 0x45,
+	0x93, 0xee, 0x10, 0x12, 0x27, 0x47, 0x32, 0xf5, 0xc4, 0x78, 0x8e, 0x3a, 0xd6, 0x7a, 0x96, 0x9a,
+	0x25, 0x14, 0x4a, 0x6d, 0xba, 0x4f, 0xb6, 0x70, 0x74, 0x9f, 0x05, 0x1c, 0x39, 0xc6, 0x93, 0x28,
+	0x60, 0x2e, 0x1e, 0x9a, 0x8e, 0xa5, 0x67, 0xa9, 0xd9, 0xc8, 0x43, 0x23, 0x4a, 0x7b, 0xa4, 0x1b,
+	0x8f, 0x7d, 0x8f, 0xc7, 0xfa, 0x15, 0xd4, 0x13, 0x71, 0x7f, 0x25, 0x02, 0xea, 0x8b, 0x3e, 0x43,
+	0x3b, 0x72, 0x63, 0x9d, 0x94, 0x7c, 0x10, 0x01, 0xf5, 0xcd, 0x47, 0xf5, 0x38, 0x8c, 0xf9, 0x9e,
+	0xe7, 0x73, 0x16, 0xe1, 0xea, 0xe9, 0x2b, 0xb5, 0x51, 0xd5, 0x78, 0x68, 0x44, 0xe9, 0x8f, 0xe4,
+	0x0e, 0xe2, 0x87, 0x3c, 0x4a, 0x1c, 0x9e, 0x44, 0xcc, 0x3d, 0x60, 0xdc, 0x76, 0x6d, 0x6e, 0xd7,
+	0x8e, 0xc4, 0x2a, 0x86, 0x7f, 0x2f, 0x4b, 0xcd, 0xcb, 0x09, 0xe0, 0x72, 0x6e, 0xbd, 0x7f, 0xdb,
+	0x44, 0xc3, 0xcc, 0x4b, 0xef, 0x91, 0x15, 0x94, 0xec, 0x8a, 0x9c, 0x19, 0xab, 0xdb, 0xb2, 0x21,
+	0x6e, 0x75, 0x09, 0x86, 0xb2, 0x41, 0x3f, 0x25, 0x57, 0xc7, 0xf9, 0x84, 0x94, 0x4e, 0x5e, 0x87,
+	0xad, 0x2c, 0x35, 0x67, 0x38, 0x98, 0x41, 0xe8, 0x27, 0x64, 0x5d, 0xae, 0xeb, 0xc3, 0x24, 0xb2,
+	0xb9, 0x17, 0x06, 0xea, 0xec, 0xd3, 0x2c, 0x35, 0x6b, 0x0c, 0xd4, 0x6c, 0xd1, 0x7b, 0x12, 0x33,
+	0xd7, 0xf2, 0xc3, 0x70, 0x24, 0x83, 0xca, 0x3a, 0xb4, 0x2c, 0x7b, 0xaf, 0x73, 0x30, 0x83, 0xf4,
+	0x7e, 0x20, 0x4b, 0xaa, 0x46, 0x8a, 0x1a, 0x11, 0xf3, 0x30, 0x62, 0xb5, 0xb2, 0x72, 0x28, 0xb0,
+	0xa2, 0x46, 0xa0, 0x0b, 0xc8, 0x8f, 0xe8, 0x5f, 0x1c, 0xd5, 0xb3, 0x03, 0x36, 0x0a, 0xa3, 0x33,
+	0x5c, 0xd7, 0xf2, 0xec, 0xeb, 0x1c, 0xcc, 0x20, 0xbd, 0xdf, 0x17, 0xc8, 0xf2, 0xa3, 0xa2, 0x98,
+	0xae, 0xe2, 0xda, 0x02, 0x13, 0x69, 0x50, 0xa6, 0x2b, 0xcd, 0xba, 0x2a, 0xb2, 0x73, 0x19, 0x87,
+	0x8a, 0x45, 0xf7, 0x08, 0x2d, 0xed, 0xc8, 0x81, 0xcd, 0x51, 0x2b, 0x87, 0xf1, 0x46, 0x96, 0x9a,
+	0x0d, 0x2c, 0x34, 0x60, 0x79, 0xef, 0x16, 0xda, 0xb1, 0xda, 0x86, 0xa2, 0x77, 0x85, 0x43, 0xc5,
+	0x12, 0xdb, 0x57, 0x24, 0x90, 0x43, 0x16, 0x70, 0x7d, 0xb1, 0xd8, 0xbe, 0x2a, 0x03, 0x35, 0xbb,
+	0x58, 0x71, 0xed, 0xb2, 0x2b, 0xde, 0xfb, 0x6f, 0x91, 0x68, 0xc8, 0xe7, 0x1d, 0xab, 0x83, 0xc5,
+	0x8e, 0xf5, 0x76, 0xad, 0xe3, 0x9c, 0x81, 0x9a, 0x4d, 0x3f, 0x27, 0xd7, 0x4b, 0xc8, 0xc3, 0xf0,
+	0xfb, 0xc0, 0x0f, 0x6d, 0x37, 0x5f, 0xb5, 0x9b, 0x59, 0x6a, 0x36, 0x3b, 0x40, 0x33, 0x2c, 0xf6,
+	0xc0, 0xa9, 0x60, 0x98, 0x0e, 0x3b, 0xc5, 0x1e, 0xcc, 0xb2, 0xd0, 0x80, 0x51, 0x87, 0xdc, 0xc4,
+	0x23, 0x02, 0xec, 0x98, 0x45, 0x2c, 0x70, 0x98, 0x5b, 0x5c, 0x5f, 0x7d, 0x0d, 0x4f, 0xf6, 0x9d,
+	0x2c, 0x35, 0xdf, 0x9a, 0xeb, 0x34, 0xbd, 0xe3, 0x30, 0x3f, 0x4e, 0xf1, 0x7e, 0xaa, 0xbd, 0x4e,
+	0x04, 0x36, 0xe7, 0xfd, 0x34, 0x9d, 0x1f, 0xb0, 0xe3, 0x78, 0x8f, 0x71, 0x67, 0x98, 0x57, 0x86,
+	0xf2, 0xfc, 0x2a, 0x2c, 0x34, 0x60, 0xf4, 0x1b, 0xa2, 0x3b, 0x21, 0x1e, 0x77, 0x2f, 0x0c, 0x76,
+	0xc3, 0x80, 0x47, 0xa1, 0xbf, 0x6f, 0x73, 0x16, 0x38, 0x67, 0x58, 0x3c, 0x3a, 0xd6, 0xed, 0x2c,
+	0x35, 0xe7, 0xfa, 0xc0, 0x5c, 0x86, 0xba, 0xe4, 0xf6, 0xd8, 0x1b, 0x33, 0x51, 0x66, 0xbf, 0x8e,
+	0xec, 0xf1, 0x98, 0x45, 0xf2, 0x8a, 0x33, 0x57, 0x26, 0x67, 0x59, 0x6c, 0xb6, 0xb3, 0xd4, 0xbc,
+	0xd0, 0x0f, 0x2e, 0x64, 0x7b, 0x7f, 0x68, 0x44, 0xc3, 0x75, 0x12, 0xc7, 0x6f, 0xc8, 0x6c, 0x57,
+	0x2e, 0x1a, 0x5e, 0xfc, 0xd2, 0xb9, 0xaf, 0x32, 0x50, 0xb3, 0x2b, 0x5a, 0x39, 0x3a, 0xad, 0x41,
+	0x2b, 0xc7, 0x53, 0xb3, 0xe9, 0x2e, 0xb9, 0xe6, 0x32, 0x27, 0x1c, 0x8d, 0x23, 0xcc, 0xde, 0xb2,
+	0x6b, 0xb9, 0x74, 0xd7, 0xb3, 0xd4, 0x9c, 0x25, 0x61, 0x16, 0xaa, 0x07, 0x29, 0xaf, 0xd0, 0x4c,
+	0x10, 0x39, 0x8c, 0x59, 0x88, 0x3e, 0x20, 0x1b, 0xf5, 0x71, 0xc8, 0xba, 0xbc, 0x99, 0xa5, 0x66,
+	0x9d, 0x82, 0x3a, 0x20, 0xe4, 0x78, 0x97, 0x1e, 0x26, 0x63, 0xdf, 0x73, 0x6c, 0xce, 0xa6, 0x65,
+	0x19, 0xe5, 0x35, 0x0a, 0xea, 0x80, 0x90, 0x8f, 0x6b, 0xf5, 0x97, 0x14, 0xf2, 0x1a, 0x05, 0x75,
+	0x80, 0x8e, 0xc9, 0x76, 0xbe, 0xb0, 0x73, 0x2a, 0xa4, 0xaa, 0xe7, 0xef, 0x64, 0xa9, 0xf9, 0x5a,
+	0x5f, 0x78, 0xad, 0x07, 0x3d, 0x23, 0x6f, 0x97, 0xd7, 0x70, 0x5e, 0xa7, 0xb2, 0xca, 0xbf, 0x9b,
+	0xa5, 0xe6, 0x65, 0xdc, 0xe1, 0x32, 0x4e, 0xbd, 0xbf, 0x3a, 0x44, 0xc3, 0x97, 0xb5, 0xc8, 0xf1,
+	0x4c, 0xbe, 0x8a, 0xf6, 0xc2, 0x24, 0xa8, 0x54, 0x98, 0x32, 0x0e, 0x15, 0x4b, 0x94, 0x39, 0x36,
+	0x7d, 0x4b, 0x9d, 0x24, 0x2c, 0xe6, 0x2a, 0x53, 0x6a, 0xb2, 0xcc, 0xd5, 0x39, 0x98, 0x41, 0xe8,
+	0x47, 0x64, 0x4d, 0x61, 0x98, 0xbc, 0xe5, 0xfb, 0x56, 0xb3, 0xae, 0x65, 0xa9, 0x59, 0x25, 0xa0,
+	0x6a, 0x0a, 0x21, 0x3e, 0xc8, 0x81, 0x39, 0xcc, 0x3b, 0xcd, 0x5f, 0xb3, 0x28, 0xac, 0x10, 0x50,
+	0x35, 0xc5, 0xbb, 0x14, 0x01, 0x2c, 0x49, 0xf2, 0x7a, 0xe1, 0xbb, 0x34, 0x07, 0xa1, 0x68, 0x8a,
+	0xe7, 0x6e, 0x24, 0xc7, 0x2a, 0xef, 0x92, 0x26, 0x9f, 0xbb, 0x53, 0x0c, 0xf2, 0x96, 0x58, 0x40,
+	0xb7, 0x9c, 0xe2, 0x97, 0x8a, 0x22, 0x59, 0xc6, 0xa1, 0x62, 0x89, 0xfb, 0x86, 0xe9, 0x78, 0x9f,
+	0x05, 0x03, 0x3e, 0x3c, 0x64, 0xd1, 0x69, 0xfe, 0x88, 0xc5, 0xfb, 0x36, 0x43, 0xc2, 0x2c, 0x64,
+	0xb1, 0xe7, 0x2f, 0x8d, 0xd6, 0x8b, 0x97, 0x46, 0xeb, 0xd5, 0x4b, 0xa3, 0xfd, 0xd3, 0xc4, 0x68,
+	0xff, 0x36, 0x31, 0xda, 0xcf, 0x26, 0x46, 0xfb, 0xf9, 0xc4, 0x68, 0xff, 0x3d, 0x31, 0xda, 0xff,
+	0x4c, 0x8c, 0xd6, 0xab, 0x89, 0xd1, 0xfe, 0xf9, 0xdc, 0x68, 0x3d, 0x3f, 0x37, 0x5a, 0x2f, 0xce,
+	0x8d, 0xd6, 0xb7, 0xfd, 0x81, 0xc7, 0x87, 0xc9, 0xd1, 0x8e, 0x13, 0x8e, 0xfa, 0x83, 0xc8, 0x3e,
+	0xb6, 0x03, 0xbb, 0xef, 0x87, 0x4f, 0xbc, 0xfe, 0xe9, 0xfd, 0x7e, 0xd3, 0x5f, 0x17, 0x47, 0x5d,
+	0xfc, 0x63, 0xe2, 0xfe, 0xff, 0x03, 0x00, 0x62, 0x56, 0x4f, 0xdd, 0xd9, 0x10, 0x00, 0x00,
 }
 
 func (this *Result) Equal(that interface{}) bool {
@@ -1166,6 +1176,9 @@ func (this *Querier) Equal(that interface{}) bool {
 	if !this.Store.Equal(&that1.Store) {
 		return false
 	}
+	if this.QueryMemoryBytes != that1.QueryMemoryBytes {
+		return false
+	}
 	return true
 }
 func (this *Ingester) Equal(that interface{}) bool {
@@ -1411,9 +1424,10 @@ func (this *Querier) GoString() string {
 	if this == nil {
 		return "nil"
 	}
-	s := make([]string, 0, 5)
+	s := make([]string, 0, 6)
 	s = append(s, "&stats.Querier{")
 	s = append(s, "Store: "+strings.Replace(this.Store.GoString(), `&`, ``, 1)+",\n")
+	s = append(s, "QueryMemoryBytes: "+fmt.Sprintf("%#v", this.QueryMemoryBytes)+",\n")
 	s = append(s, "}")
 	return strings.Join(s, "")
 }
@@ -1820,6 +1834,11 @@ func (m *Querier) MarshalToSizedBuffer(dAtA []byte) (int, error) {
 	_ = i
 	var l int
 	_ = l
+	if m.QueryMemoryBytes != 0 {
+		i = encodeVarintStats(dAtA, i, uint64(m.QueryMemoryBytes))
+		i--
+		dAtA[i] = 0x10
+	}
 	{
 		size, err := m.Store.MarshalToSizedBuffer(dAtA[:i])
 		if err != nil {
@@ -2219,6 +2238,9 @@ func (m *Querier) Size() (n int) {
 	_ = l
 	l = m.Store.Size()
 	n += 1 + l + sovStats(uint64(l))
+	if m.QueryMemoryBytes != 0 {
+		n += 1 + sovStats(uint64(m.QueryMemoryBytes))
+	}
 	return n
 }
 
@@ -2423,6 +2445,7 @@ func (this *Querier) String() string {
 	}
 	s := strings.Join([]string{`&Querier{`,
 		`Store:` + strings.Replace(strings.Replace(this.Store.String(), "Store", "Store", 1), `&`, ``, 1) + `,`,
+		`QueryMemoryBytes:` + fmt.Sprintf("%v", this.QueryMemoryBytes) + `,`,
 		`}`,
 	}, "")
 	return s
@@ -3493,6 +3516,25 @@ func (m *Querier) Unmarshal(dAtA []byte) error {
 				return err
 			}
 			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryMemoryBytes", wireType)
+			}
+			m.QueryMemoryBytes = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStats
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.QueryMemoryBytes |= int64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipStats(dAtA[iNdEx:])
@@ -393,6 +393,9 @@ func applyPathPrefixDefaults(r, defaults *ConfigWrapper) {
 		if r.CompactorConfig.WorkingDirectory == defaults.CompactorConfig.WorkingDirectory {
 			r.CompactorConfig.WorkingDirectory = fmt.Sprintf("%s/compactor", prefix)
 		}
+		if r.Scrubber.WorkingDirectory == defaults.Scrubber.WorkingDirectory {
+			r.Scrubber.WorkingDirectory = fmt.Sprintf("%s/scrubber", prefix)
+		}
 		if len(r.StorageConfig.BloomShipperConfig.WorkingDirectory) == 1 &&
 			len(r.StorageConfig.BloomShipperConfig.WorkingDirectory) == len(defaults.StorageConfig.BloomShipperConfig.WorkingDirectory) &&
 			r.StorageConfig.BloomShipperConfig.WorkingDirectory[0] == defaults.StorageConfig.BloomShipperConfig.WorkingDirectory[0] {
@@ -38,6 +38,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/compactor"
 	compactorclient "github.com/grafana/loki/v3/pkg/compactor/client"
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/tokenindex"
 	dataobjconfig "github.com/grafana/loki/v3/pkg/dataobj/config"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer"
 	"github.com/grafana/loki/v3/pkg/distributor"
@@ -49,6 +50,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/loki/common"
 	"github.com/grafana/loki/v3/pkg/lokifrontend"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/transport"
+	"github.com/grafana/loki/v3/pkg/lokifrontend/insights"
 	"github.com/grafana/loki/v3/pkg/pattern"
 	"github.com/grafana/loki/v3/pkg/querier"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange"
@@ -59,6 +61,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/ruler/rulestore"
 	"github.com/grafana/loki/v3/pkg/runtime"
 	"github.com/grafana/loki/v3/pkg/scheduler"
+	"github.com/grafana/loki/v3/pkg/scrubber"
 	internalserver "github.com/grafana/loki/v3/pkg/server"
 	"github.com/grafana/loki/v3/pkg/storage"
 	"github.com/grafana/loki/v3/pkg/storage/config"
@@ -107,6 +110,7 @@ type Config struct {
 	CompactorConfig     compactor.Config           `yaml:"compactor,omitempty"`
 	CompactorHTTPClient compactorclient.HTTPConfig `yaml:"compactor_client,omitempty" doc:"hidden"`
 	CompactorGRPCClient compactorclient.GRPCConfig `yaml:"compactor_grpc_client,omitempty"`
+	Scrubber            scrubber.Config            `yaml:"scrubber,omitempty" category:"experimental"`
 	LimitsConfig        validation.Limits          `yaml:"limits_config"`
 	Worker              worker.Config              `yaml:"frontend_worker,omitempty"`
 	TableManager        index.TableManagerConfig   `yaml:"table_manager,omitempty"`
@@ -186,6 +190,7 @@ func (c *Config) RegisterFlags(f *flag.FlagSet) {
 	c.MemberlistKV.RegisterFlags(f)
 	c.Tracing.RegisterFlags(f)
 	c.CompactorConfig.RegisterFlags(f)
+	c.Scrubber.RegisterFlags(f)
 	c.BloomBuild.RegisterFlags(f)
 	c.QueryScheduler.RegisterFlags(f)
 	c.Analytics.RegisterFlags(f)
@@ -289,9 +294,16 @@ func (c *Config) Validate() error {
 	if err := c.IndexGateway.Validate(); err != nil {
 		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid index_gateway config"))
 	}
+	// the results of a table are cached once the index gateway synced its latest index files.
+	if c.IndexGateway.ResultsCache.Enabled && c.IndexGateway.ResultsCache.ImmutableAfter <= c.StorageConfig.TSDBShipperConfig.ResyncInterval {
+		errs = append(errs, errors.New("CONFIG ERROR: index_gateway.results_cache.immutable_after must be greater than tsdb_shipper.resync_interval"))
+	}
 	if err := c.CompactorConfig.Validate(); err != nil {
 		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid compactor config"))
 	}
+	if err := c.Scrubber.Validate(); err != nil {
+		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid scrubber config"))
+	}
 	if err := c.ChunkStoreConfig.Validate(); err != nil {
 		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid chunk_store_config config"))
 	}
@@ -307,6 +319,9 @@ func (c *Config) Validate() error {
 	if err := c.Pattern.Validate(); err != nil {
 		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid pattern_ingester config"))
 	}
+	if err := c.Frontend.QueryInsights.Validate(); err != nil {
+		errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid frontend query_insights config"))
+	}
 	if c.Ingester.KafkaIngestion.Enabled {
 		if err := c.KafkaConfig.Validate(); err != nil {
 			errs = append(errs, errors.Wrap(err, "CONFIG ERROR: invalid kafka_config config"))
@@ -383,6 +398,7 @@ type Loki struct {
 	Store                     storage.Store
 	BloomStore                bloomshipper.Store
 	bloomGatewayClient        bloomgateway.Client
+	tokenIndexQuerier         *tokenindex.Querier
 	tableManager              *index.TableManager
 	frontend                  Frontend
 	ruler                     *base_ruler.Ruler
@@ -390,9 +406,11 @@ type Loki struct {
 	RulerStorage              rulestore.RuleStore
 	rulerAPI                  *base_ruler.API
 	stopper                   queryrange.Stopper
+	queryInsights             *insights.Store
 	runtimeConfig             *runtimeconfig.Manager
 	MemberlistKV              *memberlist.KVInitService
 	compactor                 *compactor.Compactor
+	scrubber                  *scrubber.Scrubber
 	QueryFrontEndMiddleware   queryrangebase.Middleware
 	queryScheduler            *scheduler.Scheduler
 	querySchedulerRingManager *lokiring.RingManager
@@ -701,6 +719,7 @@ func (t *Loki) setupModuleManager() error {
 	mm.RegisterModule(RuleEvaluator, t.initRuleEvaluator, modules.UserInvisibleModule)
 	mm.RegisterModule(TableManager, t.initTableManager)
 	mm.RegisterModule(Compactor, t.initCompactor)
+	mm.RegisterModule(Scrubber, t.initScrubber)
 	mm.RegisterModule(BloomStore, t.initBloomStore, modules.UserInvisibleModule)
 	mm.RegisterModule(BloomPlanner, t.initBloomPlanner)
 	mm.RegisterModule(BloomBuilder, t.initBloomBuilder)
@@ -713,6 +732,7 @@ func (t *Loki) setupModuleManager() error {
 	mm.RegisterModule(QuerySchedulerRing, t.initQuerySchedulerRing, modules.UserInvisibleModule)
 	mm.RegisterModule(Analytics, t.initAnalytics, modules.UserInvisibleModule)
 	mm.RegisterModule(CacheGenerationLoader, t.initCacheGenerationLoader, modules.UserInvisibleModule)
+	mm.RegisterModule(ZstdDictionaries, t.initZstdDictionaries, modules.UserInvisibleModule)
 	mm.RegisterModule(PatternRingClient, t.initPatternRingClient, modules.UserInvisibleModule)
 	mm.RegisterModule(PatternIngesterTee, t.initPatternIngesterTee, modules.UserInvisibleModule)
 	mm.RegisterModule(PatternIngester, t.initPatternIngester)
@@ -737,7 +757,7 @@ func (t *Loki) setupModuleManager() error {
 		TenantConfigs:            {RuntimeConfig},
 		UI:                       {Server},
 		Distributor:              {Ring, Server, Overrides, TenantConfigs, PatternRingClient, PatternIngesterTee, Analytics, PartitionRing, UI},
-		Store:                    {Overrides, IndexGatewayRing},
+		Store:                    {Overrides, IndexGatewayRing, ZstdDictionaries},
 		Ingester:                 {Store, Server, MemberlistKV, TenantConfigs, Analytics, PartitionRing, UI},
 		Querier:                  {Store, Ring, Server, IngesterQuerier, PatternRingClient, Overrides, Analytics, CacheGenerationLoader, QuerySchedulerRing, UI},
 		QueryFrontendTripperware: {Server, Overrides, TenantConfigs},
@@ -746,7 +766,8 @@ func (t *Loki) setupModuleManager() error {
 		Ruler:                    {Ring, Server, RulerStorage, RuleEvaluator, Overrides, TenantConfigs, Analytics, UI},
 		RuleEvaluator:            {Ring, Server, Store, IngesterQuerier, Overrides, TenantConfigs, Analytics},
 		TableManager:             {Server, Analytics, UI},
-		Compactor:                {Server, Overrides, MemberlistKV, Analytics, UI},
+		Compactor:                {Server, Overrides, MemberlistKV, Analytics, ZstdDictionaries, UI},
+		Scrubber:                 {Server, Analytics, ZstdDictionaries},
 		IndexGateway:             {Server, Store, BloomStore, IndexGatewayRing, IndexGatewayInterceptors, Analytics, UI},
 		BloomGateway:             {Server, BloomStore, Analytics, UI},
 		BloomPlanner:             {Server, BloomStore, Analytics, Store, UI},
@@ -801,6 +822,11 @@ func (t *Loki) setupModuleManager() error {
 		deps[Store] = append(deps[Store], IngesterQuerier)
 	}
 
+	// The compactor reads the logs it exports and the chunks of the delete request previews through the store.
+	if t.Cfg.CompactorConfig.Export.Enabled || t.Cfg.CompactorConfig.DeleteRequestPreview.Enabled {
+		deps[Compactor] = append(deps[Compactor], Store)
+	}
+
 	// If the query scheduler and querier are running together, make sure the scheduler goes
 	// first to initialize the ring that will also be used by the querier
 	if (t.Cfg.isTarget(Querier) && t.Cfg.isTarget(QueryScheduler)) || t.Cfg.isTarget(All) {
@@ -11,6 +11,7 @@ import (
 	"net/http/httputil"
 	"net/url"
 	"os"
+	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
@@ -53,7 +54,10 @@ import (
 	compactorclient "github.com/grafana/loki/v3/pkg/compactor/client"
 	"github.com/grafana/loki/v3/pkg/compactor/client/grpc"
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/export"
 	"github.com/grafana/loki/v3/pkg/compactor/generationnumber"
+	"github.com/grafana/loki/v3/pkg/compactor/tokenindex"
+	"github.com/grafana/loki/v3/pkg/compression"
 	"github.com/grafana/loki/v3/pkg/dataobj/consumer"
 	"github.com/grafana/loki/v3/pkg/dataobj/explorer"
 	"github.com/grafana/loki/v3/pkg/dataobj/metastore"
@@ -69,6 +73,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/transport"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v1/frontendv1pb"
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v2/frontendv2pb"
+	"github.com/grafana/loki/v3/pkg/lokifrontend/insights"
 	"github.com/grafana/loki/v3/pkg/pattern"
 	"github.com/grafana/loki/v3/pkg/querier"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange"
@@ -79,6 +84,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/runtime"
 	"github.com/grafana/loki/v3/pkg/scheduler"
 	"github.com/grafana/loki/v3/pkg/scheduler/schedulerpb"
+	"github.com/grafana/loki/v3/pkg/scrubber"
 	"github.com/grafana/loki/v3/pkg/storage"
 	"github.com/grafana/loki/v3/pkg/storage/bucket"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
@@ -90,6 +96,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/boltdb"
 	boltdbcompactor "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/boltdb/compactor"
+	indexstorage "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb"
 	"github.com/grafana/loki/v3/pkg/storage/types"
 	"github.com/grafana/loki/v3/pkg/ui"
@@ -131,6 +138,7 @@ const (
 	Ruler                    = "ruler"
 	RuleEvaluator            = "rule-evaluator"
 	Compactor                = "compactor"
+	Scrubber                 = "scrubber"
 	IndexGateway             = "index-gateway"
 	IndexGatewayRing         = "index-gateway-ring"
 	IndexGatewayInterceptors = "index-gateway-interceptors"
@@ -147,6 +155,7 @@ const (
 	MemberlistKV             = "memberlist-kv"
 	Analytics                = "analytics"
 	CacheGenerationLoader    = "cache-generation-loader"
+	ZstdDictionaries         = "zstd-dictionaries"
 	PartitionRing            = "partition-ring"
 	BlockBuilder             = "block-builder"
 	BlockScheduler           = "block-scheduler"
@@ -647,6 +656,18 @@ func (t *Loki) initIngester() (_ services.Service, err error) {
 		level.Warn(util_log.Logger).Log("msg", "The config setting shutdown marker path is not set. The /ingester/prepare_shutdown endpoint won't work")
 	}
 
+	if enc, _ := compression.ParseCodec(t.Cfg.Ingester.ChunkEncoding); enc == compression.ZstdDict {
+		// the trained dictionaries are published to the object store of the active period.
+		period, err := t.Cfg.SchemaConfig.SchemaForTime(model.Now())
+		if err != nil {
+			return nil, err
+		}
+		t.Cfg.Ingester.ZstdDictionaries.ObjectClient, err = storage.NewObjectClient(period.ObjectType, "zstd-dictionaries", t.Cfg.StorageConfig, t.ClientMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("creating object client for zstd dictionaries: %w", err)
+		}
+	}
+
 	t.Ingester, err = ingester.New(t.Cfg.Ingester, t.Cfg.IngesterClient, t.Store, t.Overrides, t.tenantConfigs, prometheus.DefaultRegisterer, t.Cfg.Distributor.WriteFailuresLogging, t.Cfg.MetricsNamespace, logger, t.UsageTracker, t.ring, t.partitionRingWatcher)
 	if err != nil {
 		return
@@ -794,6 +815,22 @@ func (t *Loki) initTableManager() (services.Service, error) {
 	return t.tableManager, nil
 }
 
+// initTokenIndexQuerier returns the querier of the token index shared by the store and the index gateway,
+// or nil if the token index is disabled.
+func (t *Loki) initTokenIndexQuerier() (*tokenindex.Querier, error) {
+	cfg := t.Cfg.CompactorConfig.TokenIndex
+	if !cfg.Enabled || t.tokenIndexQuerier != nil {
+		return t.tokenIndexQuerier, nil
+	}
+
+	objectClient, err := storage.NewObjectClient(cfg.ObjectStore, "token-index", t.Cfg.StorageConfig, t.ClientMetrics)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token index object client: %w", err)
+	}
+	t.tokenIndexQuerier, err = tokenindex.NewQuerier(cfg, objectClient, t.Cfg.SchemaConfig, prometheus.DefaultRegisterer, log.With(util_log.Logger, "component", "token-index"))
+	return t.tokenIndexQuerier, err
+}
+
 func (t *Loki) initStore() (services.Service, error) {
 	// Set configs pertaining to object storage based indices
 	if config.UsingObjectStorageIndex(t.Cfg.SchemaConfig.Configs) {
@@ -811,6 +848,16 @@ func (t *Loki) initStore() (services.Service, error) {
 
 	t.Store = store
 
+	if t.Cfg.isTarget(Querier) || t.Cfg.isTarget(Ruler) || t.Cfg.isTarget(Read) || t.Cfg.isTarget(All) {
+		tokenIndex, err := t.initTokenIndexQuerier()
+		if err != nil {
+			return nil, err
+		}
+		if tokenIndex != nil {
+			store.SetTokenIndex(tokenIndex)
+		}
+	}
+
 	return services.NewIdleService(nil, func(_ error) error {
 		t.Store.Stop()
 		return nil
@@ -930,7 +977,8 @@ func (t *Loki) updateConfigForShipperStore() {
 		t.Cfg.StorageConfig.TSDBShipperConfig.Mode = indexshipper.ModeWriteOnly
 		t.Cfg.StorageConfig.TSDBShipperConfig.IngesterDBRetainPeriod = shipperQuerierIndexUpdateDelay(t.Cfg.StorageConfig.IndexCacheValidity, t.Cfg.StorageConfig.TSDBShipperConfig.ResyncInterval)
 
-	case t.Cfg.isTarget(Querier), t.Cfg.isTarget(Ruler), t.Cfg.isTarget(Read), t.Cfg.isTarget(Backend), t.isModuleActive(IndexGateway), t.Cfg.isTarget(BloomPlanner), t.Cfg.isTarget(BloomBuilder):
+	// The export of the compactor reads the logs through the store, which must not upload index files then.
+	case t.Cfg.isTarget(Querier), t.Cfg.isTarget(Ruler), t.Cfg.isTarget(Read), t.Cfg.isTarget(Backend), t.isModuleActive(IndexGateway), t.Cfg.isTarget(BloomPlanner), t.Cfg.isTarget(BloomBuilder), t.Cfg.isTarget(Compactor) && t.Cfg.CompactorConfig.Export.Enabled:
 		// We do not want query to do any updates to index
 		t.Cfg.StorageConfig.BoltDBShipperConfig.Mode = indexshipper.ModeReadOnly
 		t.Cfg.StorageConfig.TSDBShipperConfig.Mode = indexshipper.ModeReadOnly
@@ -948,6 +996,11 @@ func (t *Loki) updateConfigForShipperStore() {
 		t.Cfg.StorageConfig.TSDBShipperConfig.Mode = indexshipper.ModeReadWrite
 		t.Cfg.StorageConfig.TSDBShipperConfig.IngesterDBRetainPeriod = shipperQuerierIndexUpdateDelay(t.Cfg.StorageConfig.IndexCacheValidity, t.Cfg.StorageConfig.TSDBShipperConfig.ResyncInterval)
 	}
+
+	if t.Cfg.CompactorConfig.Tombstones.Enabled {
+		// apply the tombstones written by the compactor wherever the index is read from the shipper.
+		t.Cfg.StorageConfig.TSDBShipperConfig.TombstonesRefreshInterval = t.Cfg.CompactorConfig.Tombstones.RefreshInterval
+	}
 }
 
 func (t *Loki) setupAsyncStore() error {
@@ -1087,6 +1140,16 @@ func (t *Loki) initQueryFrontendMiddleware() (_ services.Service, err error) {
 	return services.NewIdleService(nil, nil), nil
 }
 
+// initZstdDictionaries sets the fetcher of the zstd dictionaries for the modules decoding chunks, as the chunks
+// compressed with trained dictionaries can only be decoded once their dictionary is fetched from the object stores.
+func (t *Loki) initZstdDictionaries() (services.Service, error) {
+	if err := t.Cfg.SchemaConfig.Load(); err != nil {
+		return nil, err
+	}
+	compression.SetZstdDictionaryFetcher(storage.NewZstdDictionaryFetcher(t.Cfg.StorageConfig, t.Cfg.SchemaConfig, t.ClientMetrics))
+	return nil, nil
+}
+
 func (t *Loki) initCacheGenerationLoader() (_ services.Service, err error) {
 	var client generationnumber.CacheGenClient
 	if t.supportIndexDeleteRequest() {
@@ -1182,13 +1245,23 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		frontendHandler = gziphandler.GzipHandler(frontendHandler)
 	}
 
+	statsHTTPMiddleware := queryrange.StatsHTTPMiddleware
+	if t.Cfg.Frontend.QueryInsights.Enabled {
+		t.queryInsights, err = insights.New(t.Cfg.Frontend.QueryInsights, util_log.Logger, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		statsHTTPMiddleware = queryrange.NewStatsHTTPMiddleware(t.queryInsights)
+		t.Server.HTTP.Path("/loki/api/v1/query_insights").Methods("GET").Handler(t.HTTPAuthMiddleware.Wrap(t.queryInsights))
+	}
+
 	// TODO: add SerializeHTTPHandler
 	toMerge := []middleware.Interface{
 		httpreq.ExtractQueryTagsMiddleware(),
-		httpreq.PropagateHeadersMiddleware(httpreq.LokiActorPathHeader, httpreq.LokiEncodingFlagsHeader, httpreq.LokiDisablePipelineWrappersHeader),
+		httpreq.PropagateHeadersMiddleware(httpreq.LokiActorPathHeader, httpreq.LokiEncodingFlagsHeader, httpreq.LokiDisablePipelineWrappersHeader, httpreq.LokiFederatedHeader),
 		serverutil.RecoveryHTTPMiddleware,
 		t.HTTPAuthMiddleware,
-		queryrange.StatsHTTPMiddleware,
+		statsHTTPMiddleware,
 		serverutil.NewPrepopulateMiddleware(),
 		serverutil.ResponseJSONMiddleware(),
 	}
@@ -1266,6 +1339,9 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 				t.stopper.Stop()
 				t.stopper = nil
 			}
+			if t.queryInsights != nil {
+				t.queryInsights.Stop()
+			}
 			return nil
 		}), nil
 	}
@@ -1282,6 +1358,9 @@ func (t *Loki) initQueryFrontend() (_ services.Service, err error) {
 		if t.stopper != nil {
 			t.stopper.Stop()
 		}
+		if t.queryInsights != nil {
+			t.queryInsights.Stop()
+		}
 		return nil
 	}), nil
 }
@@ -1501,6 +1580,7 @@ func (t *Loki) initCompactor() (services.Service, error) {
 	}
 
 	objectClients := make(map[config.DayTime]client.ObjectClient)
+	tierClients := make(map[string]client.ObjectClient)
 	for _, periodConfig := range t.Cfg.SchemaConfig.Configs {
 		if !config.IsObjectStorageIndex(periodConfig.IndexType) {
 			continue
@@ -1512,17 +1592,27 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		}
 
 		objectClients[periodConfig.From] = objectClient
+
+		for _, tier := range periodConfig.StorageTiers {
+			if _, ok := tierClients[tier.ObjectType]; ok {
+				continue
+			}
+			tierClients[tier.ObjectType], err = storage.NewObjectClient(tier.ObjectType, "compactor", t.Cfg.StorageConfig, t.ClientMetrics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create object client of storage tier %s: %w", tier.ObjectType, err)
+			}
+		}
 	}
 
 	var deleteRequestStoreClient client.ObjectClient
-	if t.Cfg.CompactorConfig.RetentionEnabled {
+	if t.Cfg.CompactorConfig.RetentionEnabled || t.Cfg.CompactorConfig.TenantMove.Enabled {
 		if deleteStore := t.Cfg.CompactorConfig.DeleteRequestStore; deleteStore != "" {
 			deleteRequestStoreClient, err = storage.NewObjectClient(deleteStore, "delete-store", t.Cfg.StorageConfig, t.ClientMetrics)
 			if err != nil {
 				return nil, fmt.Errorf("failed to create delete request store object client: %w", err)
 			}
 		} else {
-			return nil, fmt.Errorf("compactor.delete-request-store should be configured when retention is enabled")
+			return nil, fmt.Errorf("compactor.delete-request-store should be configured when retention or tenant moves are enabled")
 		}
 	}
 
@@ -1530,6 +1620,7 @@ func (t *Loki) initCompactor() (services.Service, error) {
 	t.compactor, err = compactor.NewCompactor(
 		t.Cfg.CompactorConfig,
 		objectClients,
+		tierClients,
 		deleteRequestStoreClient,
 		t.Cfg.SchemaConfig,
 		t.Overrides,
@@ -1543,6 +1634,40 @@ func (t *Loki) initCompactor() (services.Service, error) {
 
 	t.compactor.RegisterIndexCompactor(types.BoltDBShipperType, boltdbcompactor.NewIndexCompactor())
 	t.compactor.RegisterIndexCompactor(types.TSDBType, tsdb.NewIndexCompactor())
+
+	previewsEnabled := t.Cfg.CompactorConfig.RetentionEnabled && t.Cfg.CompactorConfig.DeleteRequestPreview.Enabled
+	if previewsEnabled {
+		t.compactor.DeleteRequestsHandler.RegisterPreviewer(deletion.NewPreviewer(t.Cfg.CompactorConfig.DeleteRequestPreview, deleteRequestStoreClient, t.Store))
+	}
+
+	var exporter *export.Exporter
+	if exportCfg := t.Cfg.CompactorConfig.Export; exportCfg.Enabled {
+		exportClient, err := storage.NewObjectClient(exportCfg.ObjectStore, "compactor-export", t.Cfg.StorageConfig, t.ClientMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create export object client: %w", err)
+		}
+
+		// the logs of a day are all in the store once the ingesters flushed their chunks and the readers see the index of the day.
+		completionDelay := t.Cfg.Ingester.MaxChunkAge + indexUpdatePropagationMaxDelay
+		exporter, err = export.NewExporter(exportCfg, exportClient, t.Store, completionDelay, filepath.Join(t.Cfg.CompactorConfig.WorkingDirectory, "export"), prometheus.DefaultRegisterer, util_log.Logger)
+		if err != nil {
+			return nil, err
+		}
+		t.compactor.RegisterExporter(exporter)
+	}
+
+	if tokenIndexCfg := t.Cfg.CompactorConfig.TokenIndex; tokenIndexCfg.Enabled {
+		tokenIndexClient, err := storage.NewObjectClient(tokenIndexCfg.ObjectStore, "compactor-token-index", t.Cfg.StorageConfig, t.ClientMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create token index object client: %w", err)
+		}
+
+		builder, err := compactor.NewTokenIndexBuilder(tokenIndexCfg, tokenIndexClient, t.compactor, prometheus.DefaultRegisterer)
+		if err != nil {
+			return nil, err
+		}
+		t.compactor.RegisterTokenIndexBuilder(builder)
+	}
 	prefix, compactorHandler := t.compactor.Handler()
 	t.Server.HTTP.PathPrefix(prefix).Handler(compactorHandler)
 
@@ -1554,13 +1679,86 @@ func (t *Loki) initCompactor() (services.Service, error) {
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("PUT", "POST").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.AddDeleteRequestHandler))
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetAllDeleteRequestsHandler))
 		t.Server.HTTP.Path("/loki/api/v1/delete").Methods("DELETE").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.CancelDeleteRequestHandler))
+		if previewsEnabled {
+			t.Server.HTTP.Path("/loki/api/v1/delete/preview").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetDeletePreviewsHandler))
+			t.Server.HTTP.Path("/loki/api/v1/delete/approve").Methods("PUT", "POST").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.ApproveDeletePreviewHandler))
+		}
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold").Methods("PUT", "POST").Handler(t.addCompactorMiddleware(t.compactor.LegalHoldsHandler.AddLegalHoldHandler))
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.LegalHoldsHandler.GetLegalHoldsHandler))
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold").Methods("DELETE").Handler(t.addCompactorMiddleware(t.compactor.LegalHoldsHandler.ReleaseLegalHoldHandler))
+		t.Server.HTTP.Path("/loki/api/v1/legal_hold/audit").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.LegalHoldsHandler.GetLegalHoldEventsHandler))
 		t.Server.HTTP.Path("/loki/api/v1/cache/generation_numbers").Methods("GET").Handler(t.addCompactorMiddleware(t.compactor.DeleteRequestsHandler.GetCacheGenerationNumberHandler))
 		grpc.RegisterCompactorServer(t.Server.GRPC, t.compactor.DeleteRequestsGRPCHandler)
 	}
 
+	if exporter != nil {
+		t.Server.HTTP.Path("/loki/api/v1/export").Methods("PUT", "POST").Handler(t.addCompactorMiddleware(exporter.RequestExportHandler))
+		t.Server.HTTP.Path("/loki/api/v1/export").Methods("GET").Handler(t.addCompactorMiddleware(exporter.GetExportHandler))
+	}
+
+	if mover := t.compactor.TenantMover; mover != nil {
+		t.Server.HTTP.Path("/loki/api/v1/tenant/move").Methods("PUT", "POST").Handler(t.addCompactorMiddleware(mover.RequestTenantMoveHandler))
+		t.Server.HTTP.Path("/loki/api/v1/tenant/move").Methods("GET").Handler(t.addCompactorMiddleware(mover.GetTenantMoveHandler))
+	}
+
 	return t.compactor, nil
 }
 
+func (t *Loki) initScrubber() (services.Service, error) {
+	err := t.Cfg.SchemaConfig.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	if !config.UsingObjectStorageIndex(t.Cfg.SchemaConfig.Configs) {
+		level.Info(util_log.Logger).Log("msg", "schema does not contain tsdb or boltdb-shipper index types, not starting scrubber")
+		return nil, nil
+	}
+
+	objectClients := make(map[config.DayTime]client.ObjectClient)
+	tierClients := make(map[string]client.ObjectClient)
+	for _, periodConfig := range t.Cfg.SchemaConfig.Configs {
+		if !config.IsObjectStorageIndex(periodConfig.IndexType) {
+			continue
+		}
+
+		objectClient, err := storage.NewObjectClient(periodConfig.ObjectType, "scrubber", t.Cfg.StorageConfig, t.ClientMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create object client: %w", err)
+		}
+
+		objectClients[periodConfig.From] = objectClient
+
+		for _, tier := range periodConfig.StorageTiers {
+			if _, ok := tierClients[tier.ObjectType]; ok {
+				continue
+			}
+			tierClients[tier.ObjectType], err = storage.NewObjectClient(tier.ObjectType, "scrubber", t.Cfg.StorageConfig, t.ClientMetrics)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create object client of storage tier %s: %w", tier.ObjectType, err)
+			}
+		}
+	}
+
+	t.scrubber, err = scrubber.NewScrubber(
+		t.Cfg.Scrubber,
+		objectClients,
+		tierClients,
+		t.Cfg.SchemaConfig,
+		prometheus.DefaultRegisterer,
+		t.Cfg.MetricsNamespace,
+		util_log.Logger,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.scrubber.RegisterIndexCompactor(types.BoltDBShipperType, boltdbcompactor.NewIndexCompactor())
+	t.scrubber.RegisterIndexCompactor(types.TSDBType, tsdb.NewIndexCompactor())
+
+	return t.scrubber, nil
+}
+
 func (t *Loki) addCompactorMiddleware(h http.HandlerFunc) http.Handler {
 	return middleware.Merge(t.HTTPAuthMiddleware, deletion.TenantMiddleware(t.Overrides)).Wrap(h)
 }
@@ -1619,7 +1817,22 @@ func (t *Loki) initIndexGateway() (services.Service, error) {
 		bloomQuerier = bloomgateway.NewQuerier(t.bloomGatewayClient, querierCfg, t.Overrides, resolver, prometheus.DefaultRegisterer, logger)
 	}
 
-	gateway, err := indexgateway.NewIndexGateway(t.Cfg.IndexGateway, t.Overrides, logger, prometheus.DefaultRegisterer, t.Store, indexClients, bloomQuerier)
+	// avoid passing a nil *tokenindex.Querier as a non-nil interface.
+	var tokenIndex indexgateway.TokenIndexQuerier
+	tokenIndexQuerier, err := t.initTokenIndexQuerier()
+	if err != nil {
+		return nil, err
+	}
+	if tokenIndexQuerier != nil {
+		tokenIndex = tokenIndexQuerier
+	}
+
+	resultsCache, err := t.initIndexGatewayResultsCache(logger)
+	if err != nil {
+		return nil, err
+	}
+
+	gateway, err := indexgateway.NewIndexGateway(t.Cfg.IndexGateway, t.Overrides, logger, prometheus.DefaultRegisterer, t.Store, indexClients, bloomQuerier, tokenIndex, resultsCache)
 	if err != nil {
 		return nil, err
 	}
@@ -1628,6 +1841,34 @@ func (t *Loki) initIndexGateway() (services.Service, error) {
 	return gateway, nil
 }
 
+// initIndexGatewayResultsCache creates the results cache of the index gateway, with the generations of the tables
+// listed from the index storage of the TSDB periods.
+func (t *Loki) initIndexGatewayResultsCache(logger log.Logger) (*indexgateway.ResultsCache, error) {
+	cfg := t.Cfg.IndexGateway.ResultsCache
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	clients := make(map[config.DayTime]indexstorage.Client)
+	for _, period := range t.Cfg.SchemaConfig.Configs {
+		if period.IndexType != types.TSDBType {
+			continue
+		}
+		objectClient, err := storage.NewObjectClient(period.ObjectType, "index-gateway-results-cache", t.Cfg.StorageConfig, t.ClientMetrics)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create index gateway results cache object client: %w", err)
+		}
+		clients[period.From] = indexstorage.NewIndexStorageClient(objectClient, period.IndexTables.PathPrefix)
+	}
+
+	c, err := cache.New(cfg.CacheConfig, prometheus.DefaultRegisterer, logger, stats.IndexGatewayResultCache, constants.Loki)
+	if err != nil {
+		return nil, err
+	}
+	generations := indexgateway.NewIndexStorageGenerations(clients, cfg.GenerationRefreshInterval, t.Cfg.CompactorConfig.Tombstones.Enabled)
+	return indexgateway.NewResultsCache(cfg, c, t.Cfg.SchemaConfig, generations, prometheus.DefaultRegisterer, logger), nil
+}
+
 func (t *Loki) initIndexGatewayRing() (_ services.Service, err error) {
 	// Inherit ring listen port from gRPC config
 	t.Cfg.IndexGateway.Ring.ListenPort = t.Cfg.Server.GRPCListenPort
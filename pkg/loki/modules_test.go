@@ -1,6 +1,8 @@
 package loki
 
 import (
+	"bytes"
+	"context"
 	"fmt"
 	"path/filepath"
 	"testing"
@@ -8,13 +10,19 @@ import (
 
 	"github.com/grafana/dskit/flagext"
 	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
 	"github.com/grafana/loki/v3/pkg/indexgateway"
+	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	bloomshipperconfig "github.com/grafana/loki/v3/pkg/storage/stores/shipper/bloomshipper/config"
@@ -353,6 +361,48 @@ func TestIndexGatewayClientConfig(t *testing.T) {
 
 const localhost = "localhost"
 
+func TestZstdDictionaries_Compactor(t *testing.T) {
+	dir := t.TempDir()
+	cfg := minimalWorkingConfig(t, dir, Compactor)
+	compression.SetZstdDictionaryFetcher(nil)
+	t.Cleanup(func() { compression.SetZstdDictionaryFetcher(nil) })
+
+	c, err := New(cfg)
+	require.NoError(t, err)
+	_, err = c.ModuleManager.InitModuleServices(Compactor)
+	require.NoError(t, err)
+	defer c.Server.Stop()
+
+	// the dictionary was trained by an ingester, so the compactor can only fetch it from the object store.
+	samples := make([][]byte, 0, 500)
+	for i := 0; i < 500; i++ {
+		samples = append(samples, []byte(fmt.Sprintf("level=debug component=compactor msg=\"compacting table\" table=index_%d files=%d", i, i%11)))
+	}
+	dict, err := compression.TrainZstdDictionary(samples, 4<<10)
+	require.NoError(t, err)
+	pool, err := compression.NewZstdDictPool(dict)
+	require.NoError(t, err)
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: dir})
+	require.NoError(t, err)
+	require.NoError(t, objectClient.PutObject(context.Background(), client.ZstdDictionaryObjectKey("fake", pool.ID()), bytes.NewReader(dict)))
+
+	chk := chunkenc.NewMemChunkWithZstdDictionary(chunkenc.ChunkFormatV4, pool, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 1024, 0)
+	for i := 0; i < 100; i++ {
+		_, err := chk.Append(&logproto.Entry{Timestamp: time.Unix(0, int64(i)), Line: string(samples[i])})
+		require.NoError(t, err)
+	}
+	require.NoError(t, chk.Close())
+	lbs := labels.FromStrings("app", "compactor")
+	encoded := chunk.NewChunk("fake", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(chk, 1024, 0), 0, 1)
+	require.NoError(t, encoded.Encode())
+	b, err := encoded.Encoded()
+	require.NoError(t, err)
+
+	decoded := chunk.Chunk{ChunkRef: encoded.ChunkRef}
+	require.NoError(t, decoded.Decode(chunk.NewDecodeContext(), b))
+	require.Equal(t, 100, decoded.Data.Entries())
+}
+
 func minimalWorkingConfig(t *testing.T, dir, target string, cfgTransformers ...func(*Config)) Config {
 	prepareGlobalMetricsRegistry(t)
 
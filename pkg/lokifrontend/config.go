@@ -8,6 +8,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/lokifrontend/frontend/transport"
 	v1 "github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v1"
 	v2 "github.com/grafana/loki/v3/pkg/lokifrontend/frontend/v2"
+	"github.com/grafana/loki/v3/pkg/lokifrontend/insights"
 )
 
 type Config struct {
@@ -22,6 +23,8 @@ type Config struct {
 	TLS          tls.ClientConfig `yaml:"tail_tls_config"`
 
 	SupportParquetEncoding bool `yaml:"support_parquet_encoding" doc:"description=Support 'application/vnd.apache.parquet' content type in HTTP responses."`
+
+	QueryInsights insights.Config `yaml:"query_insights" doc:"description=Configures the per-query insights log of the query-frontend."`
 }
 
 // RegisterFlags adds the flags required to config this to the given FlagSet.
@@ -30,6 +33,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.FrontendV1.RegisterFlags(f)
 	cfg.FrontendV2.RegisterFlags(f)
 	cfg.TLS.RegisterFlagsWithPrefix("frontend.tail-tls-config", f)
+	cfg.QueryInsights.RegisterFlags(f)
 
 	f.BoolVar(&cfg.CompressResponses, "querier.compress-http-responses", true, "Compress HTTP responses.")
 	f.StringVar(&cfg.DownstreamURL, "frontend.downstream-url", "", "URL of downstream Loki.")
@@ -0,0 +1,65 @@
+package insights
+
+import (
+	"errors"
+	"flag"
+	"time"
+
+	"github.com/grafana/dskit/backoff"
+	"github.com/prometheus/common/config"
+
+	"github.com/grafana/loki/v3/pkg/pattern/aggregation"
+)
+
+const flagPrefix = "frontend.query-insights."
+
+type Config struct {
+	Enabled     bool          `yaml:"enabled"`
+	RingSize    int           `yaml:"ring_size"`
+	MaxTenants  int           `yaml:"max_tenants"`
+	IdleTimeout time.Duration `yaml:"idle_timeout"`
+
+	TenantID         string                  `yaml:"tenant_id" doc:"description=Tenant to push query insights records to. Records are only kept in memory if empty."`
+	LokiAddr         string                  `yaml:"loki_address" doc:"description=The address of the Loki instance to push query insights records to."`
+	WriteTimeout     time.Duration           `yaml:"timeout"`
+	PushPeriod       time.Duration           `yaml:"push_period"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:"http_client_config,omitempty" doc:"description=The HTTP client configuration for pushing query insights records to Loki."`
+	UseTLS           bool                    `yaml:"use_tls"`
+	BasicAuth        aggregation.BasicAuth   `yaml:"basic_auth,omitempty" doc:"description=The basic auth configuration for pushing query insights records to Loki."`
+	BackoffConfig    backoff.Config          `yaml:"backoff_config,omitempty" doc:"description=The backoff configuration for pushing query insights records to Loki."`
+}
+
+// RegisterFlags adds the flags required to config this to the given FlagSet.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, flagPrefix+"enabled", false, "Record a structured record of every query handled by the query-frontend and expose the most expensive queries on /loki/api/v1/query_insights.")
+	f.IntVar(&cfg.RingSize, flagPrefix+"ring-size", 10000, "Number of most recent query records kept in memory per tenant. Queries spanning multiple tenants are kept separately for each combination of tenants.")
+	f.IntVar(&cfg.MaxTenants, flagPrefix+"max-tenants", 100, "Maximum number of tenants, or combinations of tenants, whose records are kept in memory. The records of the least recently querying tenant are dropped to make room for another tenant.")
+	f.DurationVar(&cfg.IdleTimeout, flagPrefix+"idle-timeout", time.Hour, "The records of the tenants which didn't query for longer than this are dropped. 0 to keep them until they are evicted by other tenants.")
+	f.StringVar(&cfg.TenantID, flagPrefix+"tenant-id", "", "Tenant to push query insights records to. Records are only kept in memory if empty.")
+	f.StringVar(&cfg.LokiAddr, flagPrefix+"loki-address", "", "Loki address to push query insights records to.")
+	f.DurationVar(&cfg.WriteTimeout, flagPrefix+"timeout", 10*time.Second, "How long to wait for a write response from Loki.")
+	f.DurationVar(&cfg.PushPeriod, flagPrefix+"push-period", 30*time.Second, "How long to wait in between pushes to Loki.")
+	f.BoolVar(&cfg.UseTLS, flagPrefix+"tls", false, "Does the Loki connection use TLS?")
+
+	cfg.BackoffConfig.RegisterFlagsWithPrefix("frontend.query-insights", f)
+	cfg.BasicAuth.RegisterFlagsWithPrefix(flagPrefix, f)
+}
+
+func (cfg *Config) Validate() error {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.RingSize <= 0 {
+		return errors.New("query insights ring size must be greater than 0")
+	}
+	if cfg.MaxTenants <= 0 {
+		return errors.New("query insights max tenants must be greater than 0")
+	}
+	if cfg.IdleTimeout < 0 {
+		return errors.New("query insights idle timeout must not be negative")
+	}
+	if cfg.TenantID != "" && cfg.LokiAddr == "" {
+		return errors.New("query insights loki address is required when a tenant is configured")
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package insights
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/grafana/dskit/tenant"
+
+	"github.com/grafana/loki/v3/pkg/util"
+	serverutil "github.com/grafana/loki/v3/pkg/util/server"
+)
+
+const defaultTopN = 10
+
+type response struct {
+	Status string `json:"status"`
+	Data   Top    `json:"data"`
+}
+
+// ServeHTTP returns the top-N queries of the requesting tenants by cost, by frequency and by error rate.
+// N is set with the `limit` parameter.
+func (s *Store) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	tenants, err := tenant.TenantIDs(r.Context())
+	if err != nil {
+		serverutil.WriteError(err, w)
+		return
+	}
+
+	n := defaultTopN
+	if v := r.FormValue("limit"); v != "" {
+		n, err = strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "limit must be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+
+	util.WriteJSONResponse(w, response{
+		Status: "success",
+		Data:   s.Top(n, tenants...),
+	})
+}
@@ -0,0 +1,349 @@
+// Package insights keeps a structured record of the queries handled by the
+// query-frontend and ranks them by cost, frequency and error rate.
+package insights
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/go-logfmt/logfmt"
+	"github.com/grafana/dskit/tenant"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/pattern/aggregation"
+)
+
+// Record is the structured record of a single query.
+type Record struct {
+	Timestamp      time.Time     `json:"timestamp"`
+	Tenant         string        `json:"tenant"`
+	Query          string        `json:"query"`
+	QueryType      string        `json:"queryType"`
+	Duration       time.Duration `json:"duration"`
+	BytesProcessed int64         `json:"bytesProcessed"`
+	Shards         int64         `json:"shards"`
+	CacheHitRatio  float64       `json:"cacheHitRatio"`
+	Status         string        `json:"status"`
+	Error          string        `json:"error,omitempty"`
+}
+
+// Failed returns true if the query returned an error.
+func (r Record) Failed() bool {
+	return r.Error != ""
+}
+
+// Summary aggregates all the records of a normalized query of a tenant.
+type Summary struct {
+	Tenant              string        `json:"tenant"`
+	Query               string        `json:"query"`
+	QueryType           string        `json:"queryType"`
+	Count               int           `json:"count"`
+	Errors              int           `json:"errors"`
+	ErrorRate           float64       `json:"errorRate"`
+	TotalBytesProcessed int64         `json:"totalBytesProcessed"`
+	TotalDuration       time.Duration `json:"totalDuration"`
+	MaxDuration         time.Duration `json:"maxDuration"`
+	MaxShards           int64         `json:"maxShards"`
+	AvgCacheHitRatio    float64       `json:"avgCacheHitRatio"`
+	LastSeen            time.Time     `json:"lastSeen"`
+}
+
+// Top holds the top-N queries of each ranking.
+type Top struct {
+	ByCost      []Summary `json:"byCost"`
+	ByFrequency []Summary `json:"byFrequency"`
+	ByErrorRate []Summary `json:"byErrorRate"`
+}
+
+// Store keeps the most recent query records of each tenant in a bounded ring
+// and optionally pushes every record to a Loki tenant. The rings of the tenants
+// which stopped querying are dropped, and the number of rings is capped.
+type Store struct {
+	mtx         sync.Mutex
+	size        int
+	maxTenants  int
+	idleTimeout time.Duration
+	rings       map[string]*ring
+	now         func() time.Time
+
+	writer aggregation.EntryWriter
+	labels labels.Labels
+	logger log.Logger
+}
+
+// ring keeps the most recent records of a tenant, overwriting the oldest one when full.
+type ring struct {
+	records []Record
+	next    int
+	full    bool
+	// lastRecord is when the last record was added.
+	lastRecord time.Time
+}
+
+func (r *ring) add(rec Record, now time.Time) {
+	r.lastRecord = now
+	if !r.full {
+		r.records = append(r.records, rec)
+		r.full = len(r.records) == cap(r.records)
+		return
+	}
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+}
+
+// appendTo appends the records of the ring to dst, oldest first.
+func (r *ring) appendTo(dst []Record) []Record {
+	dst = append(dst, r.records[r.next:]...)
+	return append(dst, r.records[:r.next]...)
+}
+
+// New creates a new Store. A writer pushing to Loki is only created if a tenant is configured.
+func New(cfg Config, logger log.Logger, reg prometheus.Registerer) (*Store, error) {
+	s := &Store{
+		size:        cfg.RingSize,
+		maxTenants:  cfg.MaxTenants,
+		idleTimeout: cfg.IdleTimeout,
+		rings:       map[string]*ring{},
+		now:         time.Now,
+		labels:      labels.FromStrings("service_name", "loki-query-insights"),
+		logger:      log.With(logger, "component", "query-insights"),
+	}
+	if cfg.TenantID == "" {
+		return s, nil
+	}
+
+	writer, err := aggregation.NewPush(
+		cfg.LokiAddr,
+		cfg.TenantID,
+		cfg.WriteTimeout,
+		cfg.PushPeriod,
+		cfg.HTTPClientConfig,
+		cfg.BasicAuth.Username,
+		string(cfg.BasicAuth.Password),
+		cfg.UseTLS,
+		&cfg.BackoffConfig,
+		s.logger,
+		aggregation.NewMetricsWithSubsystem(reg, "query_insights"),
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.writer = writer
+	return s, nil
+}
+
+// Record adds the record to the ring of its tenant.
+// Queries spanning multiple tenants are kept in a ring of their own.
+func (s *Store) Record(r Record) {
+	r.Query = NormalizeQuery(r.Query)
+
+	s.mtx.Lock()
+	now := s.now()
+	rg, ok := s.rings[r.Tenant]
+	if !ok {
+		s.evict(now)
+		rg = &ring{records: make([]Record, 0, s.size)}
+		s.rings[r.Tenant] = rg
+	}
+	rg.add(r, now)
+	s.mtx.Unlock()
+
+	if s.writer != nil {
+		line, err := r.logfmt()
+		if err != nil {
+			level.Warn(s.logger).Log("msg", "failed to encode query insights record", "err", err)
+			return
+		}
+		s.writer.WriteEntry(r.Timestamp, line, s.labels)
+	}
+}
+
+// evict drops the rings of the tenants which didn't query for longer than the idle timeout,
+// and the ring of the least recently querying tenant if there is no room for another ring.
+func (s *Store) evict(now time.Time) {
+	var (
+		oldestTenant string
+		oldest       *ring
+	)
+	for t, rg := range s.rings {
+		if s.idleTimeout > 0 && now.Sub(rg.lastRecord) > s.idleTimeout {
+			delete(s.rings, t)
+			continue
+		}
+		if oldest == nil || rg.lastRecord.Before(oldest.lastRecord) {
+			oldestTenant, oldest = t, rg
+		}
+	}
+	if s.maxTenants > 0 && len(s.rings) >= s.maxTenants {
+		delete(s.rings, oldestTenant)
+	}
+}
+
+// Records returns the records of the given tenants ordered by time, including
+// the records of queries spanning several of them but no other tenant.
+// All tenants are considered if none is given.
+func (s *Store) Records(tenants ...string) []Record {
+	s.mtx.Lock()
+	var res []Record
+	for t, rg := range s.rings {
+		if matchTenants(t, tenants) {
+			res = rg.appendTo(res)
+		}
+	}
+	s.mtx.Unlock()
+
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].Timestamp.Before(res[j].Timestamp)
+	})
+	return res
+}
+
+// matchTenants returns true if every tenant of the possibly joined orgID is one of tenants,
+// so that the records of queries spanning other tenants aren't disclosed.
+func matchTenants(orgID string, tenants []string) bool {
+	if len(tenants) == 0 {
+		return true
+	}
+	ids, err := tenant.TenantIDsFromOrgID(orgID)
+	if err != nil {
+		ids = []string{orgID}
+	}
+	for _, id := range ids {
+		if !slices.Contains(tenants, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// Top returns the n most expensive, most frequent and most failing queries of the given tenants.
+// All tenants are considered if none is given.
+func (s *Store) Top(n int, tenants ...string) Top {
+	summaries := summarize(s.Records(tenants...))
+
+	byCost := append([]Summary(nil), summaries...)
+	sort.SliceStable(byCost, func(i, j int) bool {
+		if byCost[i].TotalBytesProcessed == byCost[j].TotalBytesProcessed {
+			return byCost[i].TotalDuration > byCost[j].TotalDuration
+		}
+		return byCost[i].TotalBytesProcessed > byCost[j].TotalBytesProcessed
+	})
+
+	byFrequency := append([]Summary(nil), summaries...)
+	sort.SliceStable(byFrequency, func(i, j int) bool {
+		return byFrequency[i].Count > byFrequency[j].Count
+	})
+
+	byErrorRate := make([]Summary, 0, len(summaries))
+	for _, sum := range summaries {
+		if sum.Errors > 0 {
+			byErrorRate = append(byErrorRate, sum)
+		}
+	}
+	sort.SliceStable(byErrorRate, func(i, j int) bool {
+		if byErrorRate[i].ErrorRate == byErrorRate[j].ErrorRate {
+			return byErrorRate[i].Errors > byErrorRate[j].Errors
+		}
+		return byErrorRate[i].ErrorRate > byErrorRate[j].ErrorRate
+	})
+
+	return Top{
+		ByCost:      truncate(byCost, n),
+		ByFrequency: truncate(byFrequency, n),
+		ByErrorRate: truncate(byErrorRate, n),
+	}
+}
+
+// Stop stops pushing records to Loki.
+func (s *Store) Stop() {
+	if s.writer != nil {
+		s.writer.Stop()
+	}
+}
+
+// NormalizeQuery returns the canonical form of a LogQL query so that the same
+// query written differently is accounted together. Queries that cannot be
+// parsed only have their whitespace collapsed.
+func NormalizeQuery(query string) string {
+	expr, err := syntax.ParseExpr(query)
+	if err != nil {
+		return strings.Join(strings.Fields(query), " ")
+	}
+	return expr.String()
+}
+
+func summarize(records []Record) []Summary {
+	type key struct{ tenant, query string }
+
+	var (
+		order []key
+		byKey = map[key]*Summary{}
+	)
+	for _, r := range records {
+		k := key{tenant: r.Tenant, query: r.Query}
+		sum, ok := byKey[k]
+		if !ok {
+			sum = &Summary{Tenant: r.Tenant, Query: r.Query, QueryType: r.QueryType}
+			byKey[k] = sum
+			order = append(order, k)
+		}
+		sum.Count++
+		if r.Failed() {
+			sum.Errors++
+		}
+		sum.TotalBytesProcessed += r.BytesProcessed
+		sum.TotalDuration += r.Duration
+		sum.MaxDuration = max(sum.MaxDuration, r.Duration)
+		sum.MaxShards = max(sum.MaxShards, r.Shards)
+		sum.AvgCacheHitRatio += r.CacheHitRatio
+		if r.Timestamp.After(sum.LastSeen) {
+			sum.LastSeen = r.Timestamp
+		}
+	}
+
+	res := make([]Summary, 0, len(order))
+	for _, k := range order {
+		sum := byKey[k]
+		sum.ErrorRate = float64(sum.Errors) / float64(sum.Count)
+		sum.AvgCacheHitRatio /= float64(sum.Count)
+		res = append(res, *sum)
+	}
+	return res
+}
+
+func truncate(s []Summary, n int) []Summary {
+	if n > 0 && len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
+func (r Record) logfmt() (string, error) {
+	var buf bytes.Buffer
+	enc := logfmt.NewEncoder(&buf)
+	err := enc.EncodeKeyvals(
+		"tenant", r.Tenant,
+		"query", r.Query,
+		"query_type", r.QueryType,
+		"duration", r.Duration,
+		"bytes_processed", r.BytesProcessed,
+		"shards", r.Shards,
+		"cache_hit_ratio", r.CacheHitRatio,
+		"status", r.Status,
+	)
+	if err == nil && r.Failed() {
+		err = enc.EncodeKeyvals("error", r.Error)
+	}
+	if err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
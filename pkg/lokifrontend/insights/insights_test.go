@@ -0,0 +1,145 @@
+package insights
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestStore(t *testing.T, size int) *Store {
+	s, err := New(Config{RingSize: size}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	return s
+}
+
+func TestStore_Ring(t *testing.T) {
+	s := newTestStore(t, 3)
+	require.Empty(t, s.Records())
+
+	for i := 0; i < 5; i++ {
+		s.Record(Record{Tenant: "fake", Query: `{app="foo"}`, BytesProcessed: int64(i)})
+	}
+
+	records := s.Records()
+	require.Len(t, records, 3)
+	for i, r := range records {
+		require.Equal(t, int64(i+2), r.BytesProcessed)
+	}
+}
+
+func TestStore_RingPerTenant(t *testing.T) {
+	s := newTestStore(t, 2)
+	now := time.Now()
+
+	s.Record(Record{Timestamp: now, Tenant: "quiet", Query: `{app="foo"}`})
+	for i := 1; i <= 5; i++ {
+		s.Record(Record{Timestamp: now.Add(time.Duration(i)), Tenant: "busy", Query: `{app="bar"}`})
+	}
+	s.Record(Record{Timestamp: now.Add(10), Tenant: "busy|quiet", Query: `{app="baz"}`})
+
+	// a busy tenant doesn't evict the records of other tenants.
+	records := s.Records("quiet")
+	require.Len(t, records, 1)
+	require.Equal(t, "quiet", records[0].Tenant)
+
+	require.Len(t, s.Records("busy"), 2)
+	require.Len(t, s.Records(), 4)
+
+	// queries spanning multiple tenants only show up for all of them.
+	require.Len(t, s.Top(10, "quiet").ByFrequency, 1)
+	records = s.Records("busy", "quiet")
+	require.Len(t, records, 4)
+	require.Equal(t, "busy|quiet", records[3].Tenant)
+}
+
+func TestStore_EvictsTenants(t *testing.T) {
+	s, err := New(Config{RingSize: 10, MaxTenants: 2, IdleTimeout: time.Hour}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+	now := time.Now()
+	s.now = func() time.Time { return now }
+
+	s.Record(Record{Tenant: "a", Query: `{app="foo"}`})
+	now = now.Add(time.Minute)
+	s.Record(Record{Tenant: "b", Query: `{app="foo"}`})
+	now = now.Add(time.Minute)
+	s.Record(Record{Tenant: "a", Query: `{app="foo"}`})
+
+	// the least recently querying tenant makes room for another tenant.
+	now = now.Add(time.Minute)
+	s.Record(Record{Tenant: "c", Query: `{app="foo"}`})
+	require.Len(t, s.Records("a"), 2)
+	require.Empty(t, s.Records("b"))
+	require.Len(t, s.Records("c"), 1)
+
+	// idle tenants are dropped.
+	now = now.Add(time.Hour + time.Second)
+	s.Record(Record{Tenant: "d", Query: `{app="foo"}`})
+	require.Empty(t, s.Records("a"))
+	require.Empty(t, s.Records("c"))
+	require.Len(t, s.Records(), 1)
+}
+
+func TestStore_Top(t *testing.T) {
+	s := newTestStore(t, 100)
+	now := time.Now()
+
+	// the same query written differently is accounted together.
+	s.Record(Record{Timestamp: now, Tenant: "a", Query: `{app="foo"}   |= "bar"`, BytesProcessed: 10})
+	s.Record(Record{Timestamp: now, Tenant: "a", Query: `{app="foo"} |= "bar"`, BytesProcessed: 10})
+	s.Record(Record{Timestamp: now, Tenant: "a", Query: `{app="foo"} |= "bar"`, BytesProcessed: 10, Error: "timeout"})
+	s.Record(Record{Timestamp: now, Tenant: "a", Query: `sum(rate({app="foo"}[1m]))`, BytesProcessed: 100})
+	s.Record(Record{Timestamp: now, Tenant: "a", Query: `{app="baz"}`, BytesProcessed: 1, Error: "bad request"})
+	s.Record(Record{Timestamp: now, Tenant: "b", Query: `{app="other"}`, BytesProcessed: 1000})
+
+	top := s.Top(2, "a")
+
+	require.Len(t, top.ByCost, 2)
+	require.Equal(t, `sum(rate({app="foo"}[1m]))`, top.ByCost[0].Query)
+	require.Equal(t, `{app="foo"} |= "bar"`, top.ByCost[1].Query)
+	require.Equal(t, int64(30), top.ByCost[1].TotalBytesProcessed)
+
+	require.Len(t, top.ByFrequency, 2)
+	require.Equal(t, `{app="foo"} |= "bar"`, top.ByFrequency[0].Query)
+	require.Equal(t, 3, top.ByFrequency[0].Count)
+
+	require.Len(t, top.ByErrorRate, 2)
+	require.Equal(t, `{app="baz"}`, top.ByErrorRate[0].Query)
+	require.Equal(t, 1.0, top.ByErrorRate[0].ErrorRate)
+	require.Equal(t, `{app="foo"} |= "bar"`, top.ByErrorRate[1].Query)
+	require.InDelta(t, 1.0/3, top.ByErrorRate[1].ErrorRate, 1e-9)
+
+	// all tenants
+	top = s.Top(1)
+	require.Equal(t, "b", top.ByCost[0].Tenant)
+}
+
+func TestStore_ServeHTTP(t *testing.T) {
+	s := newTestStore(t, 10)
+	s.Record(Record{Tenant: "a", Query: `{app="foo"}`})
+	s.Record(Record{Tenant: "a", Query: `{app="bar"}`})
+	s.Record(Record{Tenant: "b", Query: `{app="baz"}`})
+
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_insights?limit=1", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "a"))
+	w := httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var resp response
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "success", resp.Status)
+	require.Len(t, resp.Data.ByFrequency, 1)
+	require.Equal(t, "a", resp.Data.ByFrequency[0].Tenant)
+
+	req = httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_insights?limit=-1", nil)
+	req = req.WithContext(user.InjectOrgID(req.Context(), "a"))
+	w = httptest.NewRecorder()
+	s.ServeHTTP(w, req)
+	require.Equal(t, http.StatusBadRequest, w.Code)
+}
@@ -168,7 +168,13 @@ func (q *MultiTenantQuerier) Label(ctx context.Context, req *logproto.LabelReque
 		responses = append(responses, &logproto.LabelResponse{Values: []string{defaultTenantLabel}})
 	}
 
-	return logproto.MergeLabelResponses(responses)
+	resp, err := logproto.MergeLabelResponses(responses)
+	if err != nil || !req.Values {
+		return resp, err
+	}
+	// the values of multiple tenants are merged in alphabetical order.
+	resp.Values, err = req.FilterValues(resp.Values)
+	return resp, err
 }
 
 func (q *MultiTenantQuerier) Series(ctx context.Context, req *logproto.SeriesRequest) (*logproto.SeriesResponse, error) {
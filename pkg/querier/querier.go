@@ -3,6 +3,7 @@ package querier
 import (
 	"context"
 	"flag"
+	"math"
 	"net/http"
 	"slices"
 	"sort"
@@ -376,6 +377,17 @@ func (q *SingleTenantQuerier) Label(ctx context.Context, req *logproto.LabelRequ
 		}
 	}
 
+	var valueMatchers []*labels.Matcher
+	if req.Values {
+		valueMatchers, err = req.ValueMatchers()
+		if err != nil {
+			return nil, err
+		}
+		if req.Sort == logproto.LabelSortFrequency {
+			return q.labelValuesByFrequency(ctx, req, append(matchers, valueMatchers...))
+		}
+	}
+
 	// Enforce the query timeout while querying backends
 	queryTimeout := q.limits.QueryTimeout(ctx, userID)
 	ctx, cancel := context.WithDeadline(ctx, time.Now().Add(queryTimeout))
@@ -408,7 +420,7 @@ func (q *SingleTenantQuerier) Label(ctx context.Context, req *logproto.LabelRequ
 			)
 
 			if req.Values {
-				storeValues, err = q.store.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name, matchers...)
+				storeValues, err = q.store.LabelValuesForMetricName(ctx, userID, from, through, "logs", req.Name, append(matchers, valueMatchers...)...)
 			} else {
 				storeValues, err = q.store.LabelNamesForMetricName(ctx, userID, from, through, "logs", matchers...)
 			}
@@ -421,8 +433,47 @@ func (q *SingleTenantQuerier) Label(ctx context.Context, req *logproto.LabelRequ
 	}
 
 	results := append(ingesterValues, storeValues)
+	values := listutil.MergeStringLists(results...)
+	if req.Values {
+		// apply the limit to the merged values.
+		if values, err = req.FilterValues(values); err != nil {
+			return nil, err
+		}
+	}
+	return &logproto.LabelResponse{
+		Values: values,
+	}, nil
+}
+
+// labelValuesByFrequency returns the values of the requested label ordered by the volume of
+// the streams they are attached to, using the volume of the ingesters and the index.
+func (q *SingleTenantQuerier) labelValuesByFrequency(ctx context.Context, req *logproto.LabelRequest, matchers []*labels.Matcher) (*logproto.LabelResponse, error) {
+	limit := int32(math.MaxInt32)
+	if req.Limit > 0 {
+		limit = int32(req.Limit)
+	}
+	resp, err := q.Volume(ctx, &logproto.VolumeRequest{
+		From:         model.TimeFromUnixNano(req.Start.UnixNano()),
+		Through:      model.TimeFromUnixNano(req.End.UnixNano()),
+		Matchers:     syntax.MatchersString(append(matchers, labels.MustNewMatcher(labels.MatchNotEqual, req.Name, ""))),
+		Limit:        limit,
+		TargetLabels: []string{req.Name},
+		AggregateBy:  seriesvolume.Series,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	values := make([]string, 0, len(resp.Volumes))
+	for _, v := range resp.Volumes {
+		lbls, err := syntax.ParseLabels(v.Name)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, lbls.Get(req.Name))
+	}
 	return &logproto.LabelResponse{
-		Values: listutil.MergeStringLists(results...),
+		Values: values,
 	}, nil
 }
 
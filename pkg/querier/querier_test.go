@@ -984,6 +984,50 @@ func TestQuerier_Volumes(t *testing.T) {
 	})
 }
 
+func TestQuerier_LabelValuesByFrequency(t *testing.T) {
+	limits, err := validation.NewOverrides(defaultLimitsTestConfig(), nil)
+	require.NoError(t, err)
+
+	ingesterClient := newQuerierClientMock()
+	ingesterClient.On("GetVolume", mock.Anything, mock.Anything, mock.Anything).Return(&logproto.VolumeResponse{Volumes: []logproto.Volume{
+		{Name: `{app="api-eu"}`, Volume: 10},
+		{Name: `{app="api-us"}`, Volume: 30},
+	}}, nil)
+
+	store := newStoreMock()
+	store.On("Volume", mock.Anything, "test", mock.Anything, mock.Anything, []string{"app"}, mock.Anything).Return(&logproto.VolumeResponse{Volumes: []logproto.Volume{
+		{Name: `{app="api-eu"}`, Volume: 25},
+		{Name: `{app="api-asia"}`, Volume: 5},
+	}}, nil)
+
+	conf := mockQuerierConfig()
+	conf.QueryIngestersWithin = time.Minute * 30
+	conf.IngesterQueryStoreMaxLookback = conf.QueryIngestersWithin
+
+	querier, err := newQuerier(
+		conf,
+		mockIngesterClientConfig(),
+		newIngesterClientMockFactory(ingesterClient),
+		mockReadRingWithOneActiveIngester(),
+		&mockDeleteGettter{},
+		store, limits)
+	require.NoError(t, err)
+
+	now := time.Now()
+	from, through := now.Add(-time.Hour), now
+	resp, err := querier.Label(user.InjectOrgID(context.Background(), "test"), &logproto.LabelRequest{
+		Name:        "app",
+		Values:      true,
+		Start:       &from,
+		End:         &through,
+		MatchPrefix: "api-",
+		Limit:       2,
+		Sort:        logproto.LabelSortFrequency,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"api-eu", "api-us"}, resp.Values)
+}
+
 func setupIngesterQuerierMocks(conf Config, limits *validation.Overrides) (*querierClientMock, *storeMock, *SingleTenantQuerier, error) {
 	queryClient := newQueryClientMock()
 	queryClient.On("Recv").Return(mockQueryResponse([]logproto.Stream{mockStream(1, 1)}), nil)
@@ -0,0 +1,71 @@
+package queryrange
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/analyze"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+)
+
+// newAnalyzeContext starts analyzing the request if it is a log or metric query with `explain=analyze`.
+func newAnalyzeContext(ctx context.Context, r *http.Request, req queryrangebase.Request) (*analyze.Node, context.Context) {
+	if r.FormValue("explain") != analyze.Explain {
+		return nil, ctx
+	}
+	switch req.(type) {
+	case *LokiRequest, *LokiInstantRequest:
+		return analyze.NewContext(ctx, "Query", req.GetQuery(), timeRange(req))
+	default:
+		return nil, ctx
+	}
+}
+
+// analyzeChild adds a node for the request to the analyzed query of the context, if any.
+func analyzeChild(ctx context.Context, name string, req queryrangebase.Request, details ...string) (*analyze.Node, context.Context) {
+	return analyze.FromContext(ctx).Child(ctx, name, req.GetQuery(), strings.Join(append([]string{timeRange(req)}, details...), " "))
+}
+
+func responseStatistics(resp queryrangebase.Response) stats.Result {
+	switch r := resp.(type) {
+	case *LokiResponse:
+		return r.Statistics
+	case *LokiPromResponse:
+		return r.Statistics
+	default:
+		return stats.Result{}
+	}
+}
+
+// writeAnalysis adds the analysis of the query as last field of the JSON object
+// encoded in buf, so the response doesn't have to be decoded again.
+func writeAnalysis(buf *bytes.Buffer, node *analyze.Node) error {
+	body := bytes.TrimRight(buf.Bytes(), " \n")
+	if len(body) == 0 || body[len(body)-1] != '}' {
+		return fmt.Errorf("cannot add analysis to response: not a JSON object")
+	}
+	buf.Truncate(len(body) - 1)
+	if len(body) > 2 {
+		buf.WriteByte(',')
+	}
+	buf.WriteString(`"analysis":`)
+	if err := json.NewEncoder(buf).Encode(node); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // json.Encoder terminates the value with a newline.
+	buf.WriteByte('}')
+	return nil
+}
+
+func timeRange(req queryrangebase.Request) string {
+	if req.GetStart().Equal(req.GetEnd()) {
+		return fmt.Sprintf("at %s", req.GetStart().UTC().Format(time.RFC3339Nano))
+	}
+	return fmt.Sprintf("[%s, %s]", req.GetStart().UTC().Format(time.RFC3339Nano), req.GetEnd().UTC().Format(time.RFC3339Nano))
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/analyze"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
@@ -209,6 +210,11 @@ func NewLabelRequest(start, end time.Time, query, name, path string) *LabelReque
 	}
 }
 
+// SortedByFrequency returns true if the request asks for label values ordered by frequency.
+func (r *LabelRequest) SortedByFrequency() bool {
+	return r.Values && r.Sort == logproto.LabelSortFrequency
+}
+
 func (r *LabelRequest) AsProto() *logproto.LabelRequest {
 	return &r.LabelRequest
 }
@@ -810,6 +816,18 @@ func (c Codec) EncodeRequest(ctx context.Context, r queryrangebase.Request) (*ht
 			"end":   []string{fmt.Sprintf("%d", request.End.UnixNano())},
 			"query": []string{request.GetQuery()},
 		}
+		if request.MatchPrefix != "" {
+			params.Set("match_prefix", request.MatchPrefix)
+		}
+		if request.MatchRegex != "" {
+			params.Set("match_regex", request.MatchRegex)
+		}
+		if request.Limit > 0 {
+			params.Set("limit", fmt.Sprintf("%d", request.Limit))
+		}
+		if request.Sort != "" {
+			params.Set("sort", request.Sort)
+		}
 
 		u := &url.URL{
 			Path:     request.Path(), // NOTE: this could be either /label or /label/{name}/values endpoint. So forward the original path as it is.
@@ -1302,6 +1320,12 @@ func encodeResponseJSON(ctx context.Context, version loghttp.Version, res queryr
 		return nil, err
 	}
 
+	if node := analyze.FromContext(ctx); node != nil {
+		if err := writeAnalysis(&buf, node); err != nil {
+			return nil, err
+		}
+	}
+
 	sp.LogFields(otlog.Int("bytes", buf.Len()))
 
 	resp := http.Response{
@@ -1922,6 +1922,7 @@ var (
 			"totalReached": 10
 		},
 		"querier": {
+			"queryMemoryBytes": 0,
 			"store" : {
 				"chunk": {
 					"compressedBytes": 11,
@@ -16,6 +16,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 )
@@ -144,11 +145,15 @@ func (in instance) Downstream(ctx context.Context, queries []logql.DownstreamQue
 		defer sp.Finish()
 		sp.LogKV("shards", fmt.Sprintf("%+v", qry.Params.Shards()), "query", req.GetQuery(), "start", req.GetStart(), "end", req.GetEnd(), "step", req.GetStep(), "handler", reflect.TypeOf(in.handler), "engine", "downstream")
 
+		node, ctx := analyzeChild(ctx, "Downstream", req, fmt.Sprintf("shards=%v", qry.Params.Shards()))
 		res, err := in.handler.Do(ctx, req)
 		if err != nil {
+			node.Finish(stats.Result{})
 			return logqlmodel.Result{}, err
 		}
-		return ResponseToResult(res)
+		result, err := ResponseToResult(res)
+		node.Finish(result.Statistics)
+		return result, err
 	})
 }
 
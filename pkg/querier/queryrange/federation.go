@@ -0,0 +1,307 @@
+package queryrange
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/httpgrpc"
+	"github.com/pkg/errors"
+	"github.com/prometheus/common/config"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	base "github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/httpreq"
+	logutil "github.com/grafana/loki/v3/pkg/util/log"
+)
+
+// ClusterLabel is the label added to every stream and series of a federated query
+// to identify the cluster it was returned from.
+const ClusterLabel = "__cluster__"
+
+// FederationConfig configures querying remote Loki clusters in addition to the local one.
+type FederationConfig struct {
+	ClusterName    string                `yaml:"cluster_name"`
+	DefaultTimeout time.Duration         `yaml:"default_timeout"`
+	RemoteClusters []RemoteClusterConfig `yaml:"remote_clusters" doc:"description=Remote clusters receiving the log and metric queries of this cluster. The results of each cluster are labelled with __cluster__.\nExample:\n remote_clusters:\n - name: eu-west\n url: https://loki.eu-west.example.com\n timeout: 30s"`
+}
+
+// RemoteClusterConfig configures a single remote cluster.
+type RemoteClusterConfig struct {
+	Name             string                  `yaml:"name"`
+	URL              string                  `yaml:"url"`
+	Timeout          time.Duration           `yaml:"timeout"`
+	HTTPClientConfig config.HTTPClientConfig `yaml:"http_client_config,omitempty"`
+}
+
+// RegisterFlagsWithPrefix registers flags for the federation config.
+func (cfg *FederationConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.StringVar(&cfg.ClusterName, prefix+"cluster-name", "local", "Value of the __cluster__ label added to the results of the local cluster when remote clusters are configured.")
+	f.DurationVar(&cfg.DefaultTimeout, prefix+"default-timeout", time.Minute, "Timeout of the queries sent to remote clusters without a timeout of their own.")
+}
+
+// Enabled returns true if any remote cluster is configured.
+func (cfg *FederationConfig) Enabled() bool {
+	return len(cfg.RemoteClusters) > 0
+}
+
+// Validate validates the federation config.
+func (cfg *FederationConfig) Validate() error {
+	if !cfg.Enabled() {
+		return nil
+	}
+	if cfg.ClusterName == "" {
+		return errors.New("cluster_name must be set when remote clusters are configured")
+	}
+	names := map[string]struct{}{cfg.ClusterName: {}}
+	for _, rc := range cfg.RemoteClusters {
+		if rc.Name == "" {
+			return errors.New("remote cluster name must not be empty")
+		}
+		if _, ok := names[rc.Name]; ok {
+			return fmt.Errorf("duplicate cluster name %q", rc.Name)
+		}
+		names[rc.Name] = struct{}{}
+
+		u, err := url.Parse(rc.URL)
+		if err != nil {
+			return errors.Wrapf(err, "invalid url of remote cluster %q", rc.Name)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("url of remote cluster %q must be absolute", rc.Name)
+		}
+		if rc.Timeout < 0 {
+			return fmt.Errorf("timeout of remote cluster %q must not be negative", rc.Name)
+		}
+	}
+	return nil
+}
+
+type remoteCluster struct {
+	name    string
+	url     *url.URL
+	timeout time.Duration
+	client  *http.Client
+}
+
+// NewFederationMiddleware returns a middleware sending log and metric queries to the
+// remote clusters in addition to the local one. A remote cluster failing the query
+// turns into a warning of the response instead of failing the whole query.
+func NewFederationMiddleware(cfg FederationConfig, logger log.Logger) (base.Middleware, error) {
+	clusters := make([]remoteCluster, 0, len(cfg.RemoteClusters))
+	for _, rc := range cfg.RemoteClusters {
+		u, err := url.Parse(rc.URL)
+		if err != nil {
+			return nil, err
+		}
+		client, err := config.NewClientFromConfig(rc.HTTPClientConfig, "remote-cluster-"+rc.Name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "creating client of remote cluster %q", rc.Name)
+		}
+		timeout := rc.Timeout
+		if timeout == 0 {
+			timeout = cfg.DefaultTimeout
+		}
+		clusters = append(clusters, remoteCluster{
+			name:    rc.Name,
+			url:     u,
+			timeout: timeout,
+			client:  client,
+		})
+	}
+
+	return base.MiddlewareFunc(func(next base.Handler) base.Handler {
+		return &federation{
+			next:    next,
+			cluster: cfg.ClusterName,
+			remotes: clusters,
+			logger:  logger,
+			codec:   DefaultCodec,
+		}
+	}), nil
+}
+
+type federation struct {
+	next    base.Handler
+	cluster string
+	remotes []remoteCluster
+	logger  log.Logger
+	codec   base.Codec
+}
+
+// queryLimitDirection is implemented by log and metric query requests.
+type queryLimitDirection interface {
+	GetLimit() uint32
+	GetDirection() logproto.Direction
+}
+
+type clusterResponse struct {
+	cluster string
+	resp    base.Response
+	err     error
+}
+
+func (f *federation) Do(ctx context.Context, req base.Request) (base.Response, error) {
+	switch req.(type) {
+	case *LokiRequest, *LokiInstantRequest:
+	default:
+		return f.next.Do(ctx, req)
+	}
+	// Queries sent by another cluster are only answered by this cluster to prevent loops.
+	if httpreq.ExtractHeader(ctx, httpreq.LokiFederatedHeader) != "" {
+		return f.next.Do(ctx, req)
+	}
+
+	// Remote queries are cancelled as soon as the local query failed.
+	remoteCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]clusterResponse, len(f.remotes)+1)
+	var wg sync.WaitGroup
+	for i, rc := range f.remotes {
+		wg.Add(1)
+		go func(i int, rc remoteCluster) {
+			defer wg.Done()
+			resp, err := f.doRemote(remoteCtx, rc, req)
+			responses[i+1] = clusterResponse{cluster: rc.name, resp: resp, err: err}
+		}(i, rc)
+	}
+
+	node, localCtx := analyzeChild(ctx, "Cluster", req, "cluster="+f.cluster)
+	resp, err := f.next.Do(localCtx, req)
+	node.Finish(responseStatistics(resp))
+	responses[0] = clusterResponse{cluster: f.cluster, resp: resp, err: err}
+	// The local cluster enforces the limits of the query and has to succeed.
+	if err != nil {
+		cancel()
+		wg.Wait()
+		return nil, err
+	}
+	wg.Wait()
+
+	var (
+		merged   = make([]base.Response, 0, len(responses))
+		warnings []string
+	)
+	for _, r := range responses {
+		if r.err == nil {
+			r.err = withClusterLabel(r.resp, r.cluster)
+		}
+		if r.err != nil {
+			level.Warn(logutil.WithContext(ctx, f.logger)).Log("msg", "cluster failed to execute query", "cluster", r.cluster, "err", r.err)
+			warnings = append(warnings, fmt.Sprintf("cluster %s: %s", r.cluster, errorMessage(r.err)))
+			continue
+		}
+		merged = append(merged, r.resp)
+	}
+
+	res, err := f.codec.MergeResponse(merged...)
+	if err != nil {
+		return nil, err
+	}
+	if lokiRes, ok := res.(*LokiResponse); ok {
+		lokiRes.Data.Result = mergeClusterStreams(merged, req.(queryLimitDirection).GetLimit(), req.(queryLimitDirection).GetDirection())
+	}
+	return withWarnings(res, warnings), nil
+}
+
+// mergeClusterStreams merges the streams of all clusters and only then applies
+// the limit and direction of the query. Merging the responses one after the
+// other would stop at the first clusters filling the limit, and the streams of
+// the clusters never overlap since they differ by the cluster label.
+func mergeClusterStreams(responses []base.Response, limit uint32, direction logproto.Direction) []logproto.Stream {
+	all := &LokiResponse{}
+	for _, resp := range responses {
+		all.Data.Result = append(all.Data.Result, resp.(*LokiResponse).Data.Result...)
+	}
+	return mergeOrderedNonOverlappingStreams([]*LokiResponse{all}, limit, direction)
+}
+
+// doRemote sends the query to the remote cluster within its timeout.
+func (f *federation) doRemote(ctx context.Context, rc remoteCluster, req base.Request) (base.Response, error) {
+	node, ctx := analyzeChild(ctx, "Cluster", req, "cluster="+rc.name)
+	ctx, cancel := context.WithTimeout(ctx, rc.timeout)
+	defer cancel()
+
+	httpReq, err := f.codec.EncodeRequest(ctx, req)
+	if err != nil {
+		node.Finish(stats.Result{})
+		return nil, err
+	}
+	u := *rc.url
+	u.Path = path.Join(rc.url.Path, httpReq.URL.Path)
+	u.RawQuery = httpReq.URL.RawQuery
+	httpReq.URL = &u
+	httpReq.Host = u.Host
+	httpReq.RequestURI = ""
+	httpReq.Header.Set(httpreq.LokiFederatedHeader, "true")
+
+	httpResp, err := rc.client.Do(httpReq.WithContext(ctx))
+	if err != nil {
+		node.Finish(stats.Result{})
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	resp, err := f.codec.DecodeResponse(ctx, httpResp, req)
+	if err != nil {
+		node.Finish(stats.Result{})
+		return nil, err
+	}
+	node.Finish(responseStatistics(resp))
+	return resp, nil
+}
+
+// withClusterLabel sets the cluster label on every stream or series of the response.
+func withClusterLabel(resp base.Response, cluster string) error {
+	switch r := resp.(type) {
+	case *LokiResponse:
+		for i, s := range r.Data.Result {
+			lbls, err := syntax.ParseLabels(s.Labels)
+			if err != nil {
+				return err
+			}
+			r.Data.Result[i].Labels = labels.NewBuilder(lbls).Set(ClusterLabel, cluster).Labels().String()
+		}
+	case *LokiPromResponse:
+		for i, s := range r.Response.Data.Result {
+			lbls := labels.NewBuilder(logproto.FromLabelAdaptersToLabels(s.Labels)).Set(ClusterLabel, cluster).Labels()
+			r.Response.Data.Result[i].Labels = logproto.FromLabelsToLabelAdapters(lbls)
+		}
+	default:
+		return fmt.Errorf("unexpected response type %T", resp)
+	}
+	return nil
+}
+
+// errorMessage returns the body of HTTP errors returned by remote clusters or the error itself.
+func errorMessage(err error) string {
+	if resp, ok := httpgrpc.HTTPResponseFromError(err); ok {
+		return strings.TrimSpace(string(resp.Body))
+	}
+	return err.Error()
+}
+
+func withWarnings(resp base.Response, warnings []string) base.Response {
+	if len(warnings) == 0 {
+		return resp
+	}
+	switch r := resp.(type) {
+	case *LokiResponse:
+		r.Warnings = append(r.Warnings, warnings...)
+	case *LokiPromResponse:
+		r.Response.Warnings = append(r.Response.Warnings, warnings...)
+	}
+	return resp
+}
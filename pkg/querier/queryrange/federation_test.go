@@ -0,0 +1,216 @@
+package queryrange
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/loghttp"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
+	"github.com/grafana/loki/v3/pkg/util/httpreq"
+)
+
+const remoteStreamsResponse = `{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"foo"},"values":[["1575285010000000010","remote"]]}]}}`
+
+func federatedLogRequest() *LokiRequest {
+	query := `{app="foo"}`
+	return &LokiRequest{
+		Query:     query,
+		Limit:     100,
+		StartTs:   testTime.Add(-time.Hour),
+		EndTs:     testTime,
+		Direction: logproto.FORWARD,
+		Path:      "/loki/api/v1/query_range",
+		Plan: &plan.QueryPlan{
+			AST: syntax.MustParseExpr(query),
+		},
+	}
+}
+
+func localLogHandler(called *bool) queryrangebase.Handler {
+	return queryrangebase.HandlerFunc(func(_ context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+		*called = true
+		return &LokiResponse{
+			Status:    loghttp.QueryStatusSuccess,
+			Direction: logproto.FORWARD,
+			Limit:     100,
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+				Result: []logproto.Stream{{
+					Labels:  `{app="foo"}`,
+					Entries: []logproto.Entry{{Timestamp: testTime, Line: "local"}},
+				}},
+			},
+		}, nil
+	})
+}
+
+func newTestFederation(t *testing.T, remotes ...RemoteClusterConfig) queryrangebase.Middleware {
+	cfg := FederationConfig{ClusterName: "local", DefaultTimeout: time.Second, RemoteClusters: remotes}
+	require.NoError(t, cfg.Validate())
+	mw, err := NewFederationMiddleware(cfg, log.NewNopLogger())
+	require.NoError(t, err)
+	return mw
+}
+
+func TestFederation_Merge(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/prefix/loki/api/v1/query_range", r.URL.Path)
+		require.Equal(t, "fake", r.Header.Get(user.OrgIDHeaderName))
+		require.Equal(t, "true", r.Header.Get(httpreq.LokiFederatedHeader))
+		_, _ = w.Write([]byte(remoteStreamsResponse))
+	}))
+	defer remote.Close()
+
+	var localCalled bool
+	handler := newTestFederation(t, RemoteClusterConfig{Name: "remote", URL: remote.URL + "/prefix"}).Wrap(localLogHandler(&localCalled))
+
+	resp, err := handler.Do(user.InjectOrgID(context.Background(), "fake"), federatedLogRequest())
+	require.NoError(t, err)
+	require.True(t, localCalled)
+
+	res := resp.(*LokiResponse)
+	require.Empty(t, res.Warnings)
+	require.Len(t, res.Data.Result, 2)
+	require.Equal(t, `{__cluster__="local", app="foo"}`, res.Data.Result[0].Labels)
+	require.Equal(t, "local", res.Data.Result[0].Entries[0].Line)
+	require.Equal(t, `{__cluster__="remote", app="foo"}`, res.Data.Result[1].Labels)
+	require.Equal(t, "remote", res.Data.Result[1].Entries[0].Line)
+}
+
+func TestFederation_MergeLimit(t *testing.T) {
+	// the remote entry is older than the local one and comes first in forward direction.
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status":"success","data":{"resultType":"streams","result":[{"stream":{"app":"foo"},"values":[["1575285009000000010","remote"]]}]}}`))
+	}))
+	defer remote.Close()
+
+	var localCalled bool
+	handler := newTestFederation(t, RemoteClusterConfig{Name: "remote", URL: remote.URL}).Wrap(localLogHandler(&localCalled))
+
+	// the local cluster alone fills the limit.
+	req := federatedLogRequest()
+	req.Limit = 1
+	resp, err := handler.Do(user.InjectOrgID(context.Background(), "fake"), req)
+	require.NoError(t, err)
+	require.True(t, localCalled)
+
+	res := resp.(*LokiResponse)
+	require.Len(t, res.Data.Result, 1)
+	require.Equal(t, `{__cluster__="remote", app="foo"}`, res.Data.Result[0].Labels)
+	require.Len(t, res.Data.Result[0].Entries, 1)
+	require.Equal(t, "remote", res.Data.Result[0].Entries[0].Line)
+}
+
+func TestFederation_LocalFailureCancelsRemotes(t *testing.T) {
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = w.Write([]byte(remoteStreamsResponse))
+	}))
+	defer remote.Close()
+
+	local := queryrangebase.HandlerFunc(func(_ context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
+		<-started
+		return nil, errors.New("local failure")
+	})
+	handler := newTestFederation(t, RemoteClusterConfig{Name: "remote", URL: remote.URL, Timeout: 10 * time.Second}).Wrap(local)
+
+	start := time.Now()
+	_, err := handler.Do(user.InjectOrgID(context.Background(), "fake"), federatedLogRequest())
+	require.ErrorContains(t, err, "local failure")
+	require.Less(t, time.Since(start), 5*time.Second)
+
+	select {
+	case <-cancelled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("remote query was not cancelled")
+	}
+}
+
+func TestFederation_PartialFailures(t *testing.T) {
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "boom", http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+		_, _ = w.Write([]byte(remoteStreamsResponse))
+	}))
+	defer slow.Close()
+
+	var localCalled bool
+	handler := newTestFederation(t,
+		RemoteClusterConfig{Name: "failing", URL: failing.URL},
+		RemoteClusterConfig{Name: "slow", URL: slow.URL, Timeout: 50 * time.Millisecond},
+	).Wrap(localLogHandler(&localCalled))
+
+	resp, err := handler.Do(user.InjectOrgID(context.Background(), "fake"), federatedLogRequest())
+	require.NoError(t, err)
+
+	res := resp.(*LokiResponse)
+	require.Len(t, res.Data.Result, 1)
+	require.Equal(t, `{__cluster__="local", app="foo"}`, res.Data.Result[0].Labels)
+	require.Len(t, res.Warnings, 2)
+	require.Contains(t, res.Warnings[0], "cluster failing: boom")
+	require.Contains(t, res.Warnings[1], "cluster slow:")
+	require.Contains(t, res.Warnings[1], "deadline exceeded")
+}
+
+func TestFederation_FederatedRequest(t *testing.T) {
+	remote := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, _ *http.Request) {
+		t.Fatal("federated queries must not be forwarded")
+	}))
+	defer remote.Close()
+
+	var localCalled bool
+	handler := newTestFederation(t, RemoteClusterConfig{Name: "remote", URL: remote.URL}).Wrap(localLogHandler(&localCalled))
+
+	ctx := httpreq.InjectHeader(user.InjectOrgID(context.Background(), "fake"), httpreq.LokiFederatedHeader, "true")
+	resp, err := handler.Do(ctx, federatedLogRequest())
+	require.NoError(t, err)
+	require.True(t, localCalled)
+	require.Equal(t, `{app="foo"}`, resp.(*LokiResponse).Data.Result[0].Labels)
+}
+
+func TestFederationConfig_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		cfg  FederationConfig
+		err  string
+	}{
+		{name: "disabled", cfg: FederationConfig{}},
+		{name: "valid", cfg: FederationConfig{ClusterName: "a", RemoteClusters: []RemoteClusterConfig{{Name: "b", URL: "http://b"}}}},
+		{name: "duplicate", cfg: FederationConfig{ClusterName: "a", RemoteClusters: []RemoteClusterConfig{{Name: "a", URL: "http://b"}}}, err: "duplicate cluster name"},
+		{name: "relative url", cfg: FederationConfig{ClusterName: "a", RemoteClusters: []RemoteClusterConfig{{Name: "b", URL: "/b"}}}, err: "must be absolute"},
+		{name: "no cluster name", cfg: FederationConfig{RemoteClusters: []RemoteClusterConfig{{Name: "b", URL: "http://b"}}}, err: "cluster_name must be set"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.Validate()
+			if tc.err == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
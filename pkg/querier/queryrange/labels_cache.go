@@ -43,7 +43,7 @@ func metadataSplitIntervalForTimeRange(limits Limits, tenantIDs []string, ref, s
 }
 
 // GenerateCacheKey generates a cache key based on the userID, split duration and the interval of the request.
-// It also includes the label name and the provided query for label values request.
+// It also includes the label name, the provided query and the value filter for label values request.
 func (i cacheKeyLabels) GenerateCacheKey(ctx context.Context, userID string, r resultscache.Request) string {
 	lr := r.(*LabelRequest)
 	split := metadataSplitIntervalForTimeRange(i.Limits, []string{userID}, time.Now().UTC(), r.GetStart().UTC())
@@ -58,6 +58,9 @@ func (i cacheKeyLabels) GenerateCacheKey(ctx context.Context, userID string, r r
 	}
 
 	if lr.GetValues() {
+		if lr.MatchPrefix != "" || lr.MatchRegex != "" || lr.Limit > 0 || lr.Sort != "" {
+			return fmt.Sprintf("labelvalues:%s:%s:%s:%s:%s:%d:%s:%d:%d", userID, lr.GetName(), lr.GetQuery(), lr.MatchPrefix, lr.MatchRegex, lr.Limit, lr.Sort, currentInterval, split)
+		}
 		return fmt.Sprintf("labelvalues:%s:%s:%s:%d:%d", userID, lr.GetName(), lr.GetQuery(), currentInterval, split)
 	}
 
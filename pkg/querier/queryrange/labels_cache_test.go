@@ -58,6 +58,11 @@ func TestCacheKeyLabels_GenerateCacheKey(t *testing.T) {
 
 		req.Query = `{cluster="eu-west1"}`
 		require.Equal(t, fmt.Sprintf(`labelvalues:fake:foo:{cluster="eu-west1"}:%d:%d`, expectedInterval, time.Hour.Nanoseconds()), k.GenerateCacheKey(context.Background(), "fake", &req))
+
+		req.MatchPrefix = "api-"
+		req.MatchRegex = ".*-eu"
+		req.Limit = 10
+		require.Equal(t, fmt.Sprintf(`labelvalues:fake:foo:{cluster="eu-west1"}:api-:.*-eu:10::%d:%d`, expectedInterval, time.Hour.Nanoseconds()), k.GenerateCacheKey(context.Background(), "fake", &req))
 	})
 }
 
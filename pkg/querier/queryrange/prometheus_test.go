@@ -46,6 +46,7 @@ var emptyStats = `"stats": {
 		"totalReached": 0
 	},
 	"querier": {
+		"queryMemoryBytes": 0,
 		"store": {
 			"chunksDownloadTime": 0,
 			"congestionControlLatency": 0,
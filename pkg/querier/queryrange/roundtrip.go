@@ -5,6 +5,7 @@ import (
 	"flag"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
@@ -59,6 +60,7 @@ type Config struct {
 	SeriesCacheConfig            SeriesCacheConfig        `yaml:"series_results_cache" doc:"description=If series_results_cache is not configured and cache_series_results is true, the config for the results cache is used."`
 	CacheLabelResults            bool                     `yaml:"cache_label_results"`
 	LabelsCacheConfig            LabelsCacheConfig        `yaml:"label_results_cache" doc:"description=If label_results_cache is not configured and cache_label_results is true, the config for the results cache is used."`
+	Federation                   FederationConfig         `yaml:"federation" doc:"description=Federates log and metric queries across remote Loki clusters."`
 }
 
 // RegisterFlags adds the flags required to configure this flag set.
@@ -75,6 +77,7 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	cfg.SeriesCacheConfig.RegisterFlags(f)
 	f.BoolVar(&cfg.CacheLabelResults, "querier.cache-label-results", true, "Cache label query results.")
 	cfg.LabelsCacheConfig.RegisterFlags(f)
+	cfg.Federation.RegisterFlagsWithPrefix("querier.federation.", f)
 }
 
 // Validate validates the config.
@@ -88,6 +91,10 @@ func (cfg *Config) Validate() error {
 			return errors.Wrap(err, "invalid index_stats_results_cache config")
 		}
 	}
+
+	if err := cfg.Federation.Validate(); err != nil {
+		return errors.Wrap(err, "invalid federation config")
+	}
 	return nil
 }
 
@@ -193,6 +200,14 @@ func NewMiddleware(
 
 	var codec base.Codec = DefaultCodec
 
+	var federation base.Middleware
+	if cfg.Federation.Enabled() {
+		federation, err = NewFederationMiddleware(cfg.Federation, log)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
 	indexStatsTripperware, err := NewIndexStatsTripperware(cfg, log, limits, schema, codec, iqo, statsCache,
 		cacheGenNumLoader, retentionEnabled, metrics, metricsNamespace)
 	if err != nil {
@@ -272,7 +287,7 @@ func NewMiddleware(
 			detectedLabelsRT = detectedLabelsTripperware.Wrap(next)
 		)
 
-		return newRoundTripper(
+		rt := newRoundTripper(
 			log,
 			next,
 			limitedRT,
@@ -287,6 +302,10 @@ func NewMiddleware(
 			detectedLabelsRT,
 			limits,
 		)
+		if federation != nil {
+			return federation.Wrap(rt)
+		}
+		return rt
 	}), StopperWrapper{resultsCache, statsCache, volumeCache}, nil
 }
 
@@ -472,7 +491,19 @@ func (r roundTripper) Do(ctx context.Context, req base.Request) (base.Response,
 			"length", op.LabelRequest.End.Sub(*op.LabelRequest.Start),
 			"query", op.Query,
 		)
-		return r.labels.Do(ctx, req)
+		resp, err := r.labels.Do(ctx, req)
+		// Values ordered by frequency are neither split nor cached, so the querier applied the limit.
+		if err != nil || !op.Values || op.Limit == 0 || op.SortedByFrequency() {
+			return resp, err
+		}
+		// Apply the limit to the values merged across split intervals.
+		if res, ok := resp.(*LokiLabelNamesResponse); ok {
+			sort.Strings(res.Data)
+			if len(res.Data) > int(op.Limit) {
+				res.Data = res.Data[:op.Limit]
+			}
+		}
+		return resp, nil
 	case *LokiInstantRequest:
 		queryHash := util.HashedQuery(op.Query)
 		logQueryExecution(ctx, logger,
@@ -831,6 +862,10 @@ func NewLabelsTripperware(
 			c,
 			cacheGenNumLoader,
 			func(_ context.Context, r base.Request) bool {
+				// Values ordered by frequency of different time ranges cannot be merged.
+				if lr, ok := r.(*LabelRequest); ok && lr.SortedByFrequency() {
+					return false
+				}
 				return !r.GetCachingOptions().Disabled
 			},
 			func(ctx context.Context, tenantIDs []string, r base.Request) int {
@@ -1483,6 +1483,10 @@ func (f fakeLimits) MaxQuerySeries(context.Context, string) int {
 	return f.maxSeries
 }
 
+func (f fakeLimits) MaxQueryMemory(context.Context, string) int {
+	return 0
+}
+
 func (f fakeLimits) MaxCacheFreshness(context.Context, string) time.Duration {
 	return 1 * time.Minute
 }
@@ -1,6 +1,7 @@
 package queryrange
 
 import (
+	"io"
 	"net/http"
 
 	"github.com/opentracing/opentracing-go"
@@ -35,10 +36,12 @@ func (rt *serializeRoundTripper) RoundTrip(r *http.Request) (*http.Response, err
 		return nil, err
 	}
 
+	node, ctx := newAnalyzeContext(ctx, r, request)
 	response, err := rt.next.Do(ctx, request)
 	if err != nil {
 		return nil, err
 	}
+	node.Finish(responseStatistics(response))
 
 	if r.Header.Get("Accept") == ParquetType && !rt.parquetSupport {
 		return nil, serverutil.UserError("support for Parquet encoded responses is disabled. Enable with -frontend.support-parquet-encoding=true")
@@ -70,11 +73,13 @@ func (rt *serializeHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	node, ctx := newAnalyzeContext(ctx, r, request)
 	response, err := rt.next.Do(ctx, request)
 	if err != nil {
 		serverutil.WriteError(err, w)
 		return
 	}
+	node.Finish(responseStatistics(response))
 
 	// TODO(karsten): use rt.codec.EncodeResponse(ctx, r, response) which is the central encoding logic instead.
 	if r.Header.Get("Accept") == ParquetType {
@@ -86,6 +91,16 @@ func (rt *serializeHTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request
 	}
 	version := loghttp.GetVersion(r.RequestURI)
 	encodingFlags := httpreq.ExtractEncodingFlags(r)
+	if node != nil {
+		resp, err := encodeResponseJSON(ctx, version, response, encodingFlags)
+		if err != nil {
+			serverutil.WriteError(err, w)
+			return
+		}
+		w.Header().Set("Content-Type", resp.Header.Get("Content-Type"))
+		_, _ = io.Copy(w, resp.Body)
+		return
+	}
 	if err := encodeResponseJSONTo(version, response, w, encodingFlags); err != nil {
 		serverutil.WriteError(err, w)
 	}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/loghttp"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 )
 
@@ -130,3 +132,51 @@ func TestResponseFormat(t *testing.T) {
 		})
 	}
 }
+
+func TestResponseFormat_Analyze(t *testing.T) {
+	handler := queryrangebase.HandlerFunc(func(ctx context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		node, _ := analyzeChild(ctx, "Split", r)
+		node.Finish(stats.Result{
+			Querier: stats.Querier{
+				Store: stats.Store{
+					Chunk: stats.Chunk{DecompressedBytes: 10, DecompressedLines: 2},
+				},
+			},
+		})
+		return &LokiResponse{
+			Status: "success",
+			Data: LokiData{
+				ResultType: loghttp.ResultTypeStream,
+			},
+		}, nil
+	})
+	httpHandler := NewSerializeHTTPHandler(handler, DefaultCodec)
+
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range?start=0&end=1&explain=analyze&query=%7Bfoo%3D%22bar%22%7D", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "1"))
+	httpHandler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// the analysis is appended to the encoded response without reordering it.
+	require.True(t, strings.HasPrefix(w.Body.String(), `{"status":"success",`), w.Body.String())
+
+	var resp loghttp.QueryResponse
+	require.NoError(t, resp.UnmarshalJSON(w.Body.Bytes()))
+	require.Equal(t, "success", resp.Status)
+	require.NotNil(t, resp.Analysis)
+	require.Equal(t, "Query", resp.Analysis.Name)
+	require.Equal(t, `{foo="bar"}`, resp.Analysis.Query)
+	require.Len(t, resp.Analysis.Children, 1)
+	require.Equal(t, "Split", resp.Analysis.Children[0].Name)
+	require.Equal(t, int64(10), resp.Analysis.Children[0].Stats.BytesProcessed)
+	require.Equal(t, int64(2), resp.Analysis.Children[0].Stats.LinesProcessed)
+
+	// without explain=analyze the response is not analyzed.
+	w = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodGet, "/loki/api/v1/query_range?start=0&end=1&query=%7Bfoo%3D%22bar%22%7D", nil)
+	req = req.WithContext(user.InjectOrgID(context.Background(), "1"))
+	httpHandler.ServeHTTP(w, req)
+	require.Equal(t, http.StatusOK, w.Code)
+	require.NotContains(t, w.Body.String(), "analysis")
+}
@@ -157,7 +157,9 @@ func (h *splitByInterval) loop(ctx context.Context, ch <-chan *lokiResult, next
 		sp, ctx := opentracing.StartSpanFromContext(ctx, "interval")
 		data.req.LogToSpan(sp)
 
+		node, ctx := analyzeChild(ctx, "Split", data.req)
 		resp, err := next.Do(ctx, data.req)
+		node.Finish(responseStatistics(resp))
 		sp.Finish()
 
 		select {
@@ -180,8 +182,13 @@ func (h *splitByInterval) Do(ctx context.Context, r queryrangebase.Request) (que
 	}
 
 	var interval time.Duration
-	switch r.(type) {
-	case *LokiSeriesRequest, *LabelRequest:
+	switch req := r.(type) {
+	case *LabelRequest:
+		// Values ordered by frequency cannot be merged across split intervals.
+		if !req.SortedByFrequency() {
+			interval = validation.MaxDurationOrZeroPerTenant(tenantIDs, h.limits.MetadataQuerySplitDuration)
+		}
+	case *LokiSeriesRequest:
 		interval = validation.MaxDurationOrZeroPerTenant(tenantIDs, h.limits.MetadataQuerySplitDuration)
 	default:
 		interval = validation.SmallestPositiveNonZeroDurationPerTenant(tenantIDs, h.limits.QuerySplitDuration)
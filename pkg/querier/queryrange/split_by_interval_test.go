@@ -1633,6 +1633,61 @@ func Test_series_splitByInterval_Do(t *testing.T) {
 	}
 }
 
+func Test_labelValues_splitByInterval_Do(t *testing.T) {
+	ctx := user.InjectOrgID(context.Background(), "1")
+
+	var (
+		mtx  sync.Mutex
+		reqs []*LabelRequest
+	)
+	next := queryrangebase.HandlerFunc(func(_ context.Context, r queryrangebase.Request) (queryrangebase.Response, error) {
+		mtx.Lock()
+		reqs = append(reqs, r.(*LabelRequest))
+		mtx.Unlock()
+		return &LokiLabelNamesResponse{
+			Status:  "success",
+			Version: uint32(loghttp.VersionV1),
+			Data:    []string{"foo"},
+		}, nil
+	})
+
+	l := fakeLimits{
+		maxQueryParallelism: 1,
+		metadataSplitDuration: map[string]time.Duration{
+			"1": time.Hour,
+		},
+	}
+	split := SplitByIntervalMiddleware(
+		testSchemas,
+		l,
+		DefaultCodec,
+		newDefaultSplitter(fakeLimits{}, nil),
+		nilMetrics,
+	).Wrap(next)
+
+	req := NewLabelRequest(time.Unix(0, 0), time.Unix(0, (4 * time.Hour).Nanoseconds()), `{job="varlogs"}`, "test", "/loki/api/v1/label/test/values")
+	req.MatchPrefix = "f"
+	req.Limit = 10
+
+	// the split requests keep the filters of the request.
+	_, err := split.Do(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, reqs, 4)
+	for _, r := range reqs {
+		require.Equal(t, "f", r.MatchPrefix)
+		require.Equal(t, uint32(10), r.Limit)
+	}
+
+	// values ordered by frequency are not split.
+	reqs = nil
+	req.Sort = logproto.LabelSortFrequency
+	_, err = split.Do(ctx, req)
+	require.NoError(t, err)
+	require.Len(t, reqs, 1)
+	require.Equal(t, req.GetStart(), reqs[0].GetStart())
+	require.Equal(t, req.GetEnd(), reqs[0].GetEnd())
+}
+
 func Test_seriesvolume_splitByInterval_Do(t *testing.T) {
 	ctx := user.InjectOrgID(context.Background(), "1")
 	defSplitter := newDefaultSplitter(fakeLimits{}, nil)
@@ -69,7 +69,7 @@ func (s *defaultSplitter) split(execTime time.Time, tenantIDs []string, req quer
 		// Set endTimeInclusive to true so that ForInterval keeps a gap of 1ms between splits to
 		// avoid querying duplicate data in adjacent queries.
 		factory = func(start, end time.Time) {
-			reqs = append(reqs, NewLabelRequest(start, end, r.Query, r.Name, r.Path()))
+			reqs = append(reqs, r.WithStartEnd(start, end))
 		}
 	case *logproto.IndexStatsRequest:
 		factory = func(start, end time.Time) {
@@ -12,13 +12,17 @@ import (
 	"github.com/go-kit/log"
 	"github.com/go-kit/log/level"
 	"github.com/grafana/dskit/middleware"
+	"github.com/grafana/dskit/tenant"
 	promql_parser "github.com/prometheus/prometheus/promql/parser"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/lokifrontend/insights"
 
 	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
 	"github.com/grafana/loki/v3/pkg/logqlmodel"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 	util_log "github.com/grafana/loki/v3/pkg/util/log"
 	"github.com/grafana/loki/v3/pkg/util/spanlogger"
@@ -43,12 +47,58 @@ const (
 
 var (
 	defaultMetricRecorder = metricRecorderFn(func(data *queryData) {
+		if data.err != nil {
+			return
+		}
 		recordQueryMetrics(data)
 	})
 
 	StatsHTTPMiddleware middleware.Interface = statsHTTPMiddleware(defaultMetricRecorder)
 )
 
+// NewStatsHTTPMiddleware returns a StatsHTTPMiddleware which also adds a record
+// of every query, including failed ones, to the query insights store.
+func NewStatsHTTPMiddleware(store *insights.Store) middleware.Interface {
+	return statsHTTPMiddleware(metricRecorderFn(func(data *queryData) {
+		defaultMetricRecorder.Record(data)
+		store.Record(insightsRecord(data))
+	}))
+}
+
+func insightsRecord(data *queryData) insights.Record {
+	r := insights.Record{
+		Timestamp:      time.Now(),
+		QueryType:      data.queryType,
+		Duration:       time.Duration(data.statistics.Summary.ExecTime * float64(time.Second)),
+		BytesProcessed: data.statistics.Summary.TotalBytesProcessed,
+		Shards:         data.statistics.Summary.Shards,
+		CacheHitRatio:  cacheHitRatio(data.statistics.Caches),
+		Status:         data.status,
+	}
+	if tenants, err := tenant.TenantIDs(data.ctx); err == nil {
+		r.Tenant = tenant.JoinTenantIDs(tenants)
+	}
+	if data.params != nil {
+		r.Query = data.params.QueryString()
+	}
+	if data.err != nil {
+		r.Error = data.err.Error()
+	}
+	return r
+}
+
+func cacheHitRatio(c stats.Caches) float64 {
+	var found, requested int32
+	for _, cache := range []stats.Cache{c.Result, c.StatsResult, c.VolumeResult, c.SeriesResult, c.LabelResult, c.InstantMetricResult} {
+		found += cache.EntriesFound
+		requested += cache.EntriesRequested
+	}
+	if requested == 0 {
+		return 0
+	}
+	return float64(found) / float64(requested)
+}
+
 // recordQueryMetrics will be called from Query Frontend middleware chain for any type of query.
 func recordQueryMetrics(data *queryData) {
 	logger := log.With(util_log.Logger, "component", "frontend")
@@ -92,6 +142,7 @@ type queryData struct {
 	queryType  string
 	match      []string // used in `series` query
 	label      string   // used in `labels` query
+	err        error
 
 	recorded bool
 }
@@ -106,7 +157,7 @@ func statsHTTPMiddleware(recorder metricRecorder) middleware.Interface {
 				interceptor,
 				r,
 			)
-			if data.recorded {
+			if data.recorded || data.err != nil {
 				if data.statistics == nil {
 					data.statistics = &stats.Result{}
 				}
@@ -131,6 +182,14 @@ func StatsCollectorMiddleware() queryrangebase.Middleware {
 			// execute the request
 			resp, err := next.Do(statsCtx, req)
 			if err != nil {
+				// Failed queries are only kept for the query insights, the query metrics are not recorded for them.
+				if data, ok := ctx.Value(ctxKey).(*queryData); ok {
+					result := middlewareStats.Result(time.Since(start), 0, 0)
+					data.err = err
+					data.statistics = &result
+					data.queryType = queryTypeFromRequest(req)
+					data.params, _ = ParamsFromRequest(req)
+				}
 				return resp, err
 			}
 
@@ -224,6 +283,43 @@ func StatsCollectorMiddleware() queryrangebase.Middleware {
 	})
 }
 
+// queryTypeFromRequest returns the query type of a request which did not return a response.
+func queryTypeFromRequest(req queryrangebase.Request) string {
+	switch r := req.(type) {
+	case *LokiRequest:
+		return logOrMetricQueryType(r.Plan)
+	case *LokiInstantRequest:
+		return logOrMetricQueryType(r.Plan)
+	case *LokiSeriesRequest:
+		return queryTypeSeries
+	case *LabelRequest:
+		return queryTypeLabel
+	case *logproto.IndexStatsRequest:
+		return queryTypeStats
+	case *logproto.VolumeRequest:
+		return queryTypeVolume
+	case *logproto.ShardsRequest:
+		return queryTypeShards
+	case *DetectedFieldsRequest:
+		return queryTypeDetectedFields
+	case *logproto.QueryPatternsRequest:
+		return queryTypeQueryPatterns
+	case *DetectedLabelsRequest:
+		return queryTypeDetectedLabels
+	default:
+		return ""
+	}
+}
+
+func logOrMetricQueryType(p *plan.QueryPlan) string {
+	if p != nil {
+		if _, ok := p.AST.(syntax.SampleExpr); ok {
+			return queryTypeMetric
+		}
+	}
+	return queryTypeLog
+}
+
 // interceptor implements WriteHeader to intercept status codes. WriteHeader
 // may not be called on success, so initialize statusCode with the status you
 // want to report on success, i.e. http.StatusOK.
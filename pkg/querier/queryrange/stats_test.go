@@ -10,10 +10,13 @@ import (
 	"testing"
 	"time"
 
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
 	"github.com/stretchr/testify/require"
 
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/lokifrontend/insights"
 	"github.com/grafana/loki/v3/pkg/querier/queryrange/queryrangebase"
 )
 
@@ -76,6 +79,8 @@ func TestStatsCollectorMiddleware(t *testing.T) {
 		StartTs: now,
 	})
 	require.Equal(t, false, data.recorded)
+	require.EqualError(t, data.err, "request timedout")
+	require.Equal(t, "foo", data.params.QueryString())
 }
 
 func Test_StatsHTTP(t *testing.T) {
@@ -179,6 +184,23 @@ func Test_StatsHTTP(t *testing.T) {
 				require.Equal(t, streams, data.result)
 			},
 		},
+		{
+			"failed query",
+			http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data := r.Context().Value(ctxKey).(*queryData)
+				data.err = errors.New("failed")
+				data.params, _ = ParamsFromRequest(&LokiRequest{
+					Query: "foo",
+				})
+				w.WriteHeader(http.StatusInternalServerError)
+			}),
+			func(t *testing.T, data *queryData) {
+				require.Equal(t, fmt.Sprintf("%d", http.StatusInternalServerError), data.status)
+				require.Equal(t, "foo", data.params.QueryString())
+				require.EqualError(t, data.err, "failed")
+				require.Equal(t, stats.Result{}, *data.statistics)
+			},
+		},
 	} {
 		t.Run(test.name, func(t *testing.T) {
 			statsHTTPMiddleware(metricRecorderFn(func(data *queryData) {
@@ -188,6 +210,30 @@ func Test_StatsHTTP(t *testing.T) {
 	}
 }
 
+func Test_StatsHTTPInsights(t *testing.T) {
+	store, err := insights.New(insights.Config{RingSize: 10}, log.NewNopLogger(), nil)
+	require.NoError(t, err)
+
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data := r.Context().Value(ctxKey).(*queryData)
+		data.err = errors.New("failed")
+		data.queryType = queryTypeLog
+		data.params, _ = ParamsFromRequest(&LokiRequest{Query: `{app="foo"}`})
+		w.WriteHeader(http.StatusBadRequest)
+	})
+	req := httptest.NewRequest("GET", "/foo", strings.NewReader(""))
+	req = req.WithContext(user.InjectOrgID(req.Context(), "tenant"))
+	NewStatsHTTPMiddleware(store).Wrap(next).ServeHTTP(httptest.NewRecorder(), req)
+
+	records := store.Records()
+	require.Len(t, records, 1)
+	require.Equal(t, "tenant", records[0].Tenant)
+	require.Equal(t, `{app="foo"}`, records[0].Query)
+	require.Equal(t, queryTypeLog, records[0].QueryType)
+	require.Equal(t, "400", records[0].Status)
+	require.Equal(t, "failed", records[0].Error)
+}
+
 func Test_StatsUpdateResult(t *testing.T) {
 	resp, err := StatsCollectorMiddleware().Wrap(queryrangebase.HandlerFunc(func(_ context.Context, _ queryrangebase.Request) (queryrangebase.Response, error) {
 		time.Sleep(20 * time.Millisecond)
@@ -14,6 +14,7 @@ type MockLimits struct {
 	MaxQueryTimeoutVal            time.Duration
 	MaxQueryRangeVal              time.Duration
 	MaxQuerySeriesVal             int
+	MaxQueryMemoryVal             int
 	MaxConcurrentTailRequestsVal  int
 	MaxEntriesLimitPerQueryVal    int
 	MaxStreamsMatchersPerQueryVal int
@@ -39,6 +40,10 @@ func (m *MockLimits) MaxQuerySeries(_ context.Context, _ string) int {
 	return m.MaxQuerySeriesVal
 }
 
+func (m *MockLimits) MaxQueryMemory(_ context.Context, _ string) int {
+	return m.MaxQueryMemoryVal
+}
+
 func (m *MockLimits) MaxConcurrentTailRequests(_ context.Context, _ string) int {
 	return m.MaxConcurrentTailRequestsVal
 }
@@ -0,0 +1,61 @@
+package scrubber
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+const (
+	// OrphanActionNone only reports the orphaned chunks.
+	OrphanActionNone = "none"
+	// OrphanActionQuarantine moves the orphaned chunks under the quarantine prefix of their object store.
+	OrphanActionQuarantine = "quarantine"
+	// OrphanActionDelete deletes the orphaned chunks.
+	OrphanActionDelete = "delete"
+)
+
+type Config struct {
+	WorkingDirectory  string        `yaml:"working_directory"`
+	ScrubInterval     time.Duration `yaml:"scrub_interval"`
+	Concurrency       int           `yaml:"concurrency"`
+	ReportPath        string        `yaml:"report_path"`
+	OrphanAction      string        `yaml:"orphan_action"`
+	OrphanGracePeriod time.Duration `yaml:"orphan_grace_period"`
+	QuarantinePrefix  string        `yaml:"quarantine_prefix"`
+}
+
+// RegisterFlags registers flags.
+func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&cfg.WorkingDirectory, "scrubber.working-directory", "", "Directory where the index files are downloaded while scrubbing.")
+	f.DurationVar(&cfg.ScrubInterval, "scrubber.scrub-interval", 24*time.Hour, "Interval at which the index tables and chunk objects are scrubbed.")
+	f.IntVar(&cfg.Concurrency, "scrubber.concurrency", 16, "Maximum number of chunks fetched and verified concurrently.")
+	f.StringVar(&cfg.ReportPath, "scrubber.report-path", "", "Path of the JSON report of the missing, corrupt and orphaned chunks written after each scrub. No report is written when empty.")
+	f.StringVar(&cfg.OrphanAction, "scrubber.orphan-action", OrphanActionNone, fmt.Sprintf("What to do with the chunks which are not referenced by any index once the grace period elapsed. Supported values: %s, %s, %s.", OrphanActionNone, OrphanActionQuarantine, OrphanActionDelete))
+	f.DurationVar(&cfg.OrphanGracePeriod, "scrubber.orphan-grace-period", 7*24*time.Hour, "Minimum age of a chunk object not referenced by any index before it is reported as orphaned. It protects the chunks flushed by the ingesters whose index is not uploaded yet.")
+	f.StringVar(&cfg.QuarantinePrefix, "scrubber.quarantine-prefix", "quarantine/", "Prefix under which the orphaned chunks are moved when the orphan action is quarantine.")
+}
+
+// Validate verifies the config does not contain inappropriate values
+func (cfg *Config) Validate() error {
+	if cfg.ScrubInterval <= 0 {
+		return errors.New("scrubber.scrub-interval must be positive")
+	}
+	if cfg.Concurrency <= 0 {
+		return errors.New("scrubber.concurrency must be positive")
+	}
+	switch cfg.OrphanAction {
+	case OrphanActionNone, OrphanActionDelete:
+	case OrphanActionQuarantine:
+		if cfg.QuarantinePrefix == "" {
+			return errors.New("scrubber.quarantine-prefix must be set when the orphan action is quarantine")
+		}
+	default:
+		return fmt.Errorf("unsupported scrubber.orphan-action %q", cfg.OrphanAction)
+	}
+	if cfg.OrphanGracePeriod < time.Hour {
+		return errors.New("scrubber.orphan-grace-period must be at least 1h")
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package scrubber
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const (
+	statusFailure = "failure"
+	statusSuccess = "success"
+)
+
+type metrics struct {
+	scrubsTotal          *prometheus.CounterVec
+	scrubDurationSeconds prometheus.Gauge
+	scrubLastSuccess     prometheus.Gauge
+	chunksCheckedTotal   prometheus.Counter
+	missingChunks        *prometheus.GaugeVec
+	corruptChunks        *prometheus.GaugeVec
+	orphanedChunks       *prometheus.GaugeVec
+	orphansHandledTotal  *prometheus.CounterVec
+}
+
+func newMetrics(r prometheus.Registerer, namespace string) *metrics {
+	return &metrics{
+		scrubsTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "scrubs_total",
+			Help:      "Total number of scrubs done by status",
+		}, []string{"status"}),
+		scrubDurationSeconds: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "scrub_duration_seconds",
+			Help:      "Time (in seconds) spent in the last scrub",
+		}),
+		scrubLastSuccess: promauto.With(r).NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "last_successful_scrub_timestamp_seconds",
+			Help:      "Unix timestamp of the last successful scrub",
+		}),
+		chunksCheckedTotal: promauto.With(r).NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "chunks_checked_total",
+			Help:      "Total number of chunks referenced by the index which were fetched and verified",
+		}),
+		missingChunks: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "missing_chunks",
+			Help:      "Number of chunks referenced by the index but missing from the object store, as of the last scrub",
+		}, []string{"tenant"}),
+		corruptChunks: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "corrupt_chunks",
+			Help:      "Number of chunks failing to decode or having invalid checksums, as of the last scrub",
+		}, []string{"tenant"}),
+		orphanedChunks: promauto.With(r).NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "orphaned_chunks",
+			Help:      "Number of chunk objects older than the grace period not referenced by any index, as of the last scrub",
+		}, []string{"tenant"}),
+		orphansHandledTotal: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "scrubber",
+			Name:      "orphans_handled_total",
+			Help:      "Total number of orphaned chunks quarantined or deleted",
+		}, []string{"action"}),
+	}
+}
@@ -0,0 +1,77 @@
+package scrubber
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Report lists the problems found by a scrub.
+type Report struct {
+	StartedAt  time.Time                `json:"started_at"`
+	FinishedAt time.Time                `json:"finished_at"`
+	Tenants    map[string]*TenantReport `json:"tenants"`
+
+	mtx sync.Mutex
+}
+
+// TenantReport lists the problems found in the chunks of a tenant.
+type TenantReport struct {
+	CheckedChunks  int      `json:"checked_chunks"`
+	MissingChunks  []string `json:"missing_chunks,omitempty"`
+	CorruptChunks  []string `json:"corrupt_chunks,omitempty"`
+	OrphanedChunks []string `json:"orphaned_chunks,omitempty"`
+}
+
+func newReport(now time.Time) *Report {
+	return &Report{StartedAt: now, Tenants: map[string]*TenantReport{}}
+}
+
+// update calls f with the report of the tenant while holding the lock of the report.
+func (r *Report) update(tenant string, f func(*TenantReport)) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	t, ok := r.Tenants[tenant]
+	if !ok {
+		t = &TenantReport{}
+		r.Tenants[tenant] = t
+	}
+	f(t)
+}
+
+// finish sorts the chunk keys so that reports of the same state are identical.
+func (r *Report) finish(now time.Time) {
+	r.FinishedAt = now
+	for _, t := range r.Tenants {
+		sort.Strings(t.MissingChunks)
+		sort.Strings(t.CorruptChunks)
+		sort.Strings(t.OrphanedChunks)
+	}
+}
+
+// writeFile atomically replaces the report file at path.
+func (r *Report) writeFile(path string) error {
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,489 @@
+package scrubber
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/services"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/grafana/loki/v3/pkg/compactor"
+	"github.com/grafana/loki/v3/pkg/compactor/retention"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	chunk_util "github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+/*
+The scrubber verifies the integrity of the object stores:
+
+1. It walks the per tenant index files of all the tables of the periods using an object storage index.
+2. Each chunk referenced by the index is fetched from the object store of its period, or one of its storage tiers,
+   and decoded, which verifies its checksums. Chunks which can't be found are reported as missing and chunks which
+   can't be decoded as corrupt.
+3. The chunk objects of the tenants which are not referenced by any index are reported as orphaned once they are older
+   than the grace period, and optionally quarantined or deleted.
+
+Chunks are only considered orphaned when all the tables they could be referenced from were fully compacted, since
+the multi-tenant index files uploaded by the ingesters are not read by the scrubber. Orphans are only searched in the
+chunk key layout of schema v12 and newer, where all the chunks of a tenant are stored under the tenant prefix.
+*/
+
+// location is an object store holding chunks of a period.
+type location struct {
+	objectType   string
+	objectClient client.ObjectClient
+	encoder      client.KeyEncoder
+}
+
+// objectKey returns the key of the chunk in the object store.
+func (l location) objectKey(schemaConfig config.SchemaConfig, chk chunk.Chunk) string {
+	if l.encoder != nil {
+		return l.encoder(schemaConfig, chk)
+	}
+	return schemaConfig.ExternalKey(chk.ChunkRef)
+}
+
+// parseObjectKey parses the chunk stored under the key, undoing the encoding of the object store.
+func (l location) parseObjectKey(tenant, key string) (chunk.Chunk, error) {
+	if l.encoder != nil {
+		split := strings.LastIndexByte(key, '/')
+		tail, err := base64.StdEncoding.DecodeString(key[split+1:])
+		if err != nil {
+			return chunk.Chunk{}, err
+		}
+		key = key[:split+1] + string(tail)
+	}
+	return chunk.ParseExternalKey(tenant, key)
+}
+
+type period struct {
+	config             config.PeriodConfig
+	indexStorageClient storage.Client
+	// locations[0] is the object_store of the period and locations[i+1] the storage tier i.
+	locations []location
+}
+
+// Scrubber periodically verifies that the chunks referenced by the index exist and are not corrupt,
+// and finds the chunks which are not referenced by any index.
+type Scrubber struct {
+	services.Service
+
+	cfg             Config
+	schemaConfig    config.SchemaConfig
+	periods         []period
+	indexCompactors map[string]compactor.IndexCompactor
+
+	metrics *metrics
+	logger  log.Logger
+	now     func() time.Time
+}
+
+// NewScrubber returns a scrubber of the periods having an object client.
+// tierClients must have a client for each of the storage tiers of these periods.
+func NewScrubber(cfg Config, objectClients map[config.DayTime]client.ObjectClient, tierClients map[string]client.ObjectClient, schemaConfig config.SchemaConfig, r prometheus.Registerer, metricsNamespace string, logger log.Logger) (*Scrubber, error) {
+	if err := chunk_util.EnsureDirectory(cfg.WorkingDirectory); err != nil {
+		return nil, err
+	}
+
+	s := &Scrubber{
+		cfg:             cfg,
+		schemaConfig:    schemaConfig,
+		indexCompactors: map[string]compactor.IndexCompactor{},
+		metrics:         newMetrics(r, metricsNamespace),
+		logger:          log.With(logger, "component", "scrubber"),
+		now:             time.Now,
+	}
+
+	for _, p := range schemaConfig.Configs {
+		objectClient, ok := objectClients[p.From]
+		if !ok {
+			continue
+		}
+
+		locations := []location{newLocation(p.ObjectType, objectClient)}
+		for _, tier := range p.StorageTiers {
+			tierClient, ok := tierClients[tier.ObjectType]
+			if !ok {
+				return nil, fmt.Errorf("missing object client of storage tier %s", tier.ObjectType)
+			}
+			locations = append(locations, newLocation(tier.ObjectType, tierClient))
+		}
+
+		s.periods = append(s.periods, period{
+			config:             p,
+			indexStorageClient: storage.NewIndexStorageClient(objectClient, p.IndexTables.PathPrefix),
+			locations:          locations,
+		})
+	}
+
+	s.Service = services.NewBasicService(nil, s.running, nil)
+	return s, nil
+}
+
+func newLocation(objectType string, objectClient client.ObjectClient) location {
+	raw := objectClient
+	if casted, ok := objectClient.(client.PrefixedObjectClient); ok {
+		raw = casted.GetDownstream()
+	}
+	var encoder client.KeyEncoder
+	if _, ok := raw.(*local.FSObjectClient); ok {
+		encoder = client.FSEncoder
+	}
+	return location{objectType: objectType, objectClient: objectClient, encoder: encoder}
+}
+
+// RegisterIndexCompactor registers the index compactor used to open the index files of the given index type.
+func (s *Scrubber) RegisterIndexCompactor(indexType string, indexCompactor compactor.IndexCompactor) {
+	s.indexCompactors[indexType] = indexCompactor
+}
+
+func (s *Scrubber) running(ctx context.Context) error {
+	s.runScrub(ctx)
+
+	ticker := time.NewTicker(s.cfg.ScrubInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.runScrub(ctx)
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func (s *Scrubber) runScrub(ctx context.Context) {
+	start := s.now()
+	report, err := s.Scrub(ctx)
+	if err != nil {
+		s.metrics.scrubsTotal.WithLabelValues(statusFailure).Inc()
+		level.Error(s.logger).Log("msg", "failed to scrub object stores", "err", err)
+		return
+	}
+
+	s.metrics.scrubsTotal.WithLabelValues(statusSuccess).Inc()
+	s.metrics.scrubDurationSeconds.Set(time.Since(start).Seconds())
+	s.metrics.scrubLastSuccess.SetToCurrentTime()
+
+	s.metrics.missingChunks.Reset()
+	s.metrics.corruptChunks.Reset()
+	s.metrics.orphanedChunks.Reset()
+	for tenant, t := range report.Tenants {
+		s.metrics.missingChunks.WithLabelValues(tenant).Set(float64(len(t.MissingChunks)))
+		s.metrics.corruptChunks.WithLabelValues(tenant).Set(float64(len(t.CorruptChunks)))
+		s.metrics.orphanedChunks.WithLabelValues(tenant).Set(float64(len(t.OrphanedChunks)))
+	}
+
+	if s.cfg.ReportPath != "" {
+		if err := report.writeFile(s.cfg.ReportPath); err != nil {
+			level.Error(s.logger).Log("msg", "failed to write scrub report", "path", s.cfg.ReportPath, "err", err)
+		}
+	}
+}
+
+// scrubState is the state shared by the steps of a scrub.
+type scrubState struct {
+	report *Report
+
+	mtx sync.Mutex
+	// referenced holds the object keys of the chunks referenced by the index, per object store.
+	referenced map[string]map[string]struct{}
+	// tenants holds the tenants having index files, per object store.
+	tenants map[string]map[string]struct{}
+	// compactedTables holds the tables without multi-tenant index files.
+	compactedTables map[string]bool
+	// checked holds the external keys of the chunks which were already checked,
+	// chunks overlapping several tables are referenced by each of them.
+	checked map[string]struct{}
+}
+
+// addReferences records the chunks of the tenant in the object stores of the period
+// and returns the ones which were not checked yet.
+func (st *scrubState) addReferences(schemaConfig config.SchemaConfig, p period, tenant string, chunks []chunk.Chunk) []chunk.Chunk {
+	st.mtx.Lock()
+	defer st.mtx.Unlock()
+
+	for _, l := range p.locations {
+		if st.referenced[l.objectType] == nil {
+			st.referenced[l.objectType] = map[string]struct{}{}
+			st.tenants[l.objectType] = map[string]struct{}{}
+		}
+		st.tenants[l.objectType][tenant] = struct{}{}
+		for _, chk := range chunks {
+			st.referenced[l.objectType][l.objectKey(schemaConfig, chk)] = struct{}{}
+		}
+	}
+
+	unchecked := chunks[:0]
+	for _, chk := range chunks {
+		key := schemaConfig.ExternalKey(chk.ChunkRef)
+		if _, ok := st.checked[key]; ok {
+			continue
+		}
+		st.checked[key] = struct{}{}
+		unchecked = append(unchecked, chk)
+	}
+	return unchecked
+}
+
+// Scrub verifies the chunks referenced by the index of all the periods and finds the orphaned chunks.
+func (s *Scrubber) Scrub(ctx context.Context) (*Report, error) {
+	st := &scrubState{
+		report:          newReport(s.now()),
+		referenced:      map[string]map[string]struct{}{},
+		tenants:         map[string]map[string]struct{}{},
+		compactedTables: map[string]bool{},
+		checked:         map[string]struct{}{},
+	}
+
+	for _, p := range s.periods {
+		indexCompactor, ok := s.indexCompactors[p.config.IndexType]
+		if !ok {
+			level.Info(s.logger).Log("msg", "skipping period with unsupported index type", "period", p.config.From.String(), "index_type", p.config.IndexType)
+			continue
+		}
+
+		tables, err := p.indexStorageClient.ListTables(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("listing tables: %w", err)
+		}
+		sort.Strings(tables)
+		for _, table := range tables {
+			if tablePeriod, ok := compactor.SchemaPeriodForTable(s.schemaConfig, table); !ok || tablePeriod.From != p.config.From {
+				continue
+			}
+			if err := s.scrubTable(ctx, st, p, indexCompactor, table); err != nil {
+				return nil, fmt.Errorf("scrubbing table %s: %w", table, err)
+			}
+		}
+	}
+
+	if err := s.findOrphans(ctx, st); err != nil {
+		return nil, fmt.Errorf("finding orphaned chunks: %w", err)
+	}
+
+	st.report.finish(s.now())
+	return st.report, nil
+}
+
+func (s *Scrubber) scrubTable(ctx context.Context, st *scrubState, p period, indexCompactor compactor.IndexCompactor, table string) error {
+	commonFiles, users, err := p.indexStorageClient.ListFiles(ctx, table, true)
+	if err != nil {
+		return err
+	}
+	st.compactedTables[table] = len(commonFiles) == 0
+
+	for _, user := range users {
+		files, err := p.indexStorageClient.ListUserFiles(ctx, table, user, true)
+		if err != nil {
+			return err
+		}
+		for _, f := range files {
+			chunks, err := s.chunksOfIndexFile(ctx, p, indexCompactor, table, user, f.Name)
+			if err != nil {
+				return fmt.Errorf("reading index file %s of tenant %s: %w", f.Name, user, err)
+			}
+
+			chunks = st.addReferences(s.schemaConfig, p, user, chunks)
+			if err := s.checkChunks(ctx, st, p, user, chunks); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// chunksOfIndexFile downloads the index file and returns the chunks it references.
+func (s *Scrubber) chunksOfIndexFile(ctx context.Context, p period, indexCompactor compactor.IndexCompactor, table, user, fileName string) ([]chunk.Chunk, error) {
+	workingDir := filepath.Join(s.cfg.WorkingDirectory, table, user)
+	if err := chunk_util.EnsureDirectory(workingDir); err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workingDir)
+
+	decompress := storage.IsCompressedFile(fileName)
+	localPath := filepath.Join(workingDir, strings.TrimSuffix(fileName, ".gz"))
+	if err := storage.DownloadFileFromStorage(localPath, decompress, false, s.logger, func() (io.ReadCloser, error) {
+		return p.indexStorageClient.GetUserFile(ctx, table, user, fileName)
+	}); err != nil {
+		return nil, err
+	}
+
+	compactedIndex, err := indexCompactor.OpenCompactedIndexFile(ctx, localPath, table, user, workingDir, p.config, s.logger)
+	if err != nil {
+		return nil, err
+	}
+	defer compactedIndex.Cleanup()
+
+	var chunks []chunk.Chunk
+	err = compactedIndex.ForEachChunk(ctx, func(ce retention.ChunkEntry) (bool, error) {
+		chk, err := chunk.ParseExternalKey(user, string(ce.ChunkID))
+		if err != nil {
+			return false, err
+		}
+		chunks = append(chunks, chk)
+		return false, nil
+	})
+	return chunks, err
+}
+
+// checkChunks fetches and decodes the chunks of the tenant, reporting the missing and corrupt ones.
+func (s *Scrubber) checkChunks(ctx context.Context, st *scrubState, p period, tenant string, chunks []chunk.Chunk) error {
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(s.cfg.Concurrency)
+
+	for _, chk := range chunks {
+		g.Go(func() error {
+			found, decodeErr, err := s.checkChunk(ctx, p, chk)
+			if err != nil {
+				// the chunk couldn't be checked, it is not reported as missing or corrupt.
+				level.Warn(s.logger).Log("msg", "failed to fetch chunk", "tenant", tenant, "chunk", s.schemaConfig.ExternalKey(chk.ChunkRef), "err", err)
+				return nil
+			}
+			s.metrics.chunksCheckedTotal.Inc()
+
+			key := s.schemaConfig.ExternalKey(chk.ChunkRef)
+			st.report.update(tenant, func(t *TenantReport) {
+				t.CheckedChunks++
+				switch {
+				case !found:
+					t.MissingChunks = append(t.MissingChunks, key)
+				case decodeErr != nil:
+					t.CorruptChunks = append(t.CorruptChunks, key)
+				}
+			})
+			if decodeErr != nil {
+				level.Warn(s.logger).Log("msg", "corrupt chunk", "tenant", tenant, "chunk", key, "err", decodeErr)
+			}
+			return nil
+		})
+	}
+	return g.Wait()
+}
+
+// checkChunk looks for the chunk in the locations of the period and decodes it, which verifies its checksums.
+func (s *Scrubber) checkChunk(ctx context.Context, p period, chk chunk.Chunk) (found bool, decodeErr, err error) {
+	for _, l := range p.locations {
+		rc, _, err := l.objectClient.GetObject(ctx, l.objectKey(s.schemaConfig, chk))
+		if err != nil {
+			if l.objectClient.IsObjectNotFoundErr(err) {
+				continue
+			}
+			return false, nil, err
+		}
+		buf, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return false, nil, err
+		}
+
+		decoded := chk
+		return true, decoded.Decode(chunk.NewDecodeContext(), buf), nil
+	}
+	return false, nil, nil
+}
+
+// findOrphans lists the chunks of the tenants having index files and reports the ones which are not referenced.
+func (s *Scrubber) findOrphans(ctx context.Context, st *scrubState) error {
+	cutoff := s.now().Add(-s.cfg.OrphanGracePeriod)
+	scanned := map[string]struct{}{}
+
+	for _, p := range s.periods {
+		for _, l := range p.locations {
+			if _, ok := scanned[l.objectType]; ok {
+				continue
+			}
+			scanned[l.objectType] = struct{}{}
+
+			tenants := make([]string, 0, len(st.tenants[l.objectType]))
+			for tenant := range st.tenants[l.objectType] {
+				tenants = append(tenants, tenant)
+			}
+			sort.Strings(tenants)
+
+			for _, tenant := range tenants {
+				objects, _, err := l.objectClient.List(ctx, tenant+"/", "")
+				if err != nil {
+					return err
+				}
+				for _, obj := range objects {
+					if !obj.ModifiedAt.Before(cutoff) || !s.isOrphan(st, l, tenant, obj.Key) {
+						continue
+					}
+
+					key := obj.Key
+					st.report.update(tenant, func(t *TenantReport) {
+						t.OrphanedChunks = append(t.OrphanedChunks, key)
+					})
+					if err := s.handleOrphan(ctx, l, key); err != nil {
+						level.Error(s.logger).Log("msg", "failed to handle orphaned chunk", "tenant", tenant, "chunk", key, "action", s.cfg.OrphanAction, "err", err)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// isOrphan tells if the object is a chunk not referenced by the index of fully compacted tables.
+func (s *Scrubber) isOrphan(st *scrubState, l location, tenant, key string) bool {
+	if _, ok := st.referenced[l.objectType][key]; ok {
+		return false
+	}
+
+	chk, err := l.parseObjectKey(tenant, key)
+	if err != nil {
+		// not a chunk.
+		return false
+	}
+	p, err := s.schemaConfig.SchemaForTime(chk.From)
+	if err != nil {
+		return false
+	}
+	// the chunks referenced by the tables which were not scrubbed, or are not fully compacted, are unknown.
+	return st.compactedTables[p.IndexTables.TableFor(chk.From)]
+}
+
+func (s *Scrubber) handleOrphan(ctx context.Context, l location, key string) error {
+	switch s.cfg.OrphanAction {
+	case OrphanActionQuarantine:
+		rc, _, err := l.objectClient.GetObject(ctx, key)
+		if err != nil {
+			return err
+		}
+		buf, err := io.ReadAll(rc)
+		_ = rc.Close()
+		if err != nil {
+			return err
+		}
+		if err := l.objectClient.PutObject(ctx, s.cfg.QuarantinePrefix+key, bytes.NewReader(buf)); err != nil {
+			return err
+		}
+	case OrphanActionDelete:
+	default:
+		return nil
+	}
+
+	if err := l.objectClient.DeleteObject(ctx, key); err != nil {
+		return err
+	}
+	s.metrics.orphansHandledTotal.WithLabelValues(s.cfg.OrphanAction).Inc()
+	return nil
+}
@@ -0,0 +1,192 @@
+package scrubber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
+	ingesterclient "github.com/grafana/loki/v3/pkg/ingester/client"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb"
+	tsdbindex "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+)
+
+const tenant = "fake"
+
+func createChunk(t *testing.T, lbs labels.Labels, from model.Time) chunk.Chunk {
+	t.Helper()
+	through := from.Add(time.Hour)
+	memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256*1024, 0)
+	for ts := from; !ts.After(through); ts = ts.Add(time.Minute) {
+		_, err := memChunk.Append(&logproto.Entry{Timestamp: ts.Time(), Line: ts.String()})
+		require.NoError(t, err)
+	}
+	require.NoError(t, memChunk.Close())
+
+	c := chunk.NewChunk(tenant, ingesterclient.Fingerprint(lbs), lbs, chunkenc.NewFacade(memChunk, 256*1024, 0), from, through)
+	require.NoError(t, c.Encode())
+	return c
+}
+
+func TestScrubber(t *testing.T) {
+	objectDir := t.TempDir()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: objectDir})
+	require.NoError(t, err)
+
+	periodConfig := config.PeriodConfig{
+		From:       config.DayTime{Time: model.Time(0)},
+		IndexType:  types.TSDBType,
+		ObjectType: types.StorageTypeFileSystem,
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PathPrefix: "index/",
+			PeriodicTableConfig: config.PeriodicTableConfig{
+				Prefix: "index_",
+				Period: 24 * time.Hour,
+			},
+		},
+	}
+	schemaConfig := config.SchemaConfig{Configs: []config.PeriodConfig{periodConfig}}
+	chunkClient := client.NewClient(objectClient, client.FSEncoder, schemaConfig)
+
+	from := model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	lbs := labels.FromStrings("app", "foo")
+	var (
+		valid   = createChunk(t, lbs, from)
+		missing = createChunk(t, lbs, from.Add(2*time.Hour))
+		corrupt = createChunk(t, lbs, from.Add(4*time.Hour))
+		orphan  = createChunk(t, lbs, from.Add(6*time.Hour))
+		recent  = createChunk(t, lbs, from.Add(8*time.Hour))
+	)
+	require.NoError(t, chunkClient.PutChunks(context.Background(), []chunk.Chunk{valid, corrupt, orphan, recent}))
+
+	// corrupt the chunk in the object store.
+	corruptPath := filepath.Join(objectDir, client.FSEncoder(schemaConfig, corrupt))
+	b, err := os.ReadFile(corruptPath)
+	require.NoError(t, err)
+	b[len(b)-10] ^= 0xff
+	require.NoError(t, os.WriteFile(corruptPath, b, 0o644))
+
+	// all chunks but the recent one are older than the grace period.
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	for _, c := range []chunk.Chunk{valid, corrupt, orphan} {
+		require.NoError(t, os.Chtimes(filepath.Join(objectDir, client.FSEncoder(schemaConfig, c)), old, old))
+	}
+
+	// the index references all the chunks but the orphaned and recent ones.
+	builder := tsdb.NewBuilder(tsdbindex.FormatV3)
+	var metas tsdbindex.ChunkMetas
+	for _, c := range []chunk.Chunk{valid, missing, corrupt} {
+		metas = append(metas, tsdbindex.ChunkMeta{Checksum: c.Checksum, MinTime: int64(c.From), MaxTime: int64(c.Through), KB: 1, Entries: 1})
+	}
+	builder.AddSeries(lbs, model.Fingerprint(valid.Fingerprint), metas)
+	indexDir := t.TempDir()
+	id, err := builder.Build(context.Background(), t.TempDir(), func(from, through model.Time, checksum uint32) tsdb.Identifier {
+		return tsdb.NewPrefixedIdentifier(tsdb.SingleTenantTSDBIdentifier{TS: time.Now(), From: from, Through: through, Checksum: checksum}, indexDir, "")
+	})
+	require.NoError(t, err)
+	indexFile, err := os.ReadFile(id.Path())
+	require.NoError(t, err)
+
+	table := periodConfig.IndexTables.TableFor(from)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, periodConfig.IndexTables.PathPrefix)
+	require.NoError(t, indexStorageClient.PutUserFile(context.Background(), table, tenant, id.Name(), bytes.NewReader(indexFile)))
+
+	var cfg Config
+	cfg.RegisterFlags(flag.NewFlagSet("scrubber", flag.PanicOnError))
+	cfg.WorkingDirectory = t.TempDir()
+	cfg.ReportPath = filepath.Join(t.TempDir(), "report.json")
+	cfg.OrphanAction = OrphanActionQuarantine
+	require.NoError(t, cfg.Validate())
+
+	s, err := NewScrubber(cfg, map[config.DayTime]client.ObjectClient{periodConfig.From: objectClient}, nil, schemaConfig, prometheus.NewPedanticRegistry(), "loki", log.NewNopLogger())
+	require.NoError(t, err)
+	s.RegisterIndexCompactor(types.TSDBType, tsdb.NewIndexCompactor())
+
+	s.runScrub(context.Background())
+
+	b, err = os.ReadFile(cfg.ReportPath)
+	require.NoError(t, err)
+	var report Report
+	require.NoError(t, json.Unmarshal(b, &report))
+
+	require.Equal(t, map[string]*TenantReport{
+		tenant: {
+			CheckedChunks:  3,
+			MissingChunks:  []string{schemaConfig.ExternalKey(missing.ChunkRef)},
+			CorruptChunks:  []string{schemaConfig.ExternalKey(corrupt.ChunkRef)},
+			OrphanedChunks: []string{client.FSEncoder(schemaConfig, orphan)},
+		},
+	}, report.Tenants)
+
+	// the orphan was quarantined.
+	_, err = os.Stat(filepath.Join(objectDir, client.FSEncoder(schemaConfig, orphan)))
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(objectDir, cfg.QuarantinePrefix+client.FSEncoder(schemaConfig, orphan)))
+	require.NoError(t, err)
+	_, err = os.Stat(filepath.Join(objectDir, client.FSEncoder(schemaConfig, recent)))
+	require.NoError(t, err)
+}
+
+func TestScrubber_UncompactedTables(t *testing.T) {
+	objectDir := t.TempDir()
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: objectDir})
+	require.NoError(t, err)
+
+	periodConfig := config.PeriodConfig{
+		From:       config.DayTime{Time: model.Time(0)},
+		IndexType:  types.TSDBType,
+		ObjectType: types.StorageTypeFileSystem,
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PathPrefix:          "index/",
+			PeriodicTableConfig: config.PeriodicTableConfig{Prefix: "index_", Period: 24 * time.Hour},
+		},
+	}
+	schemaConfig := config.SchemaConfig{Configs: []config.PeriodConfig{periodConfig}}
+
+	from := model.TimeFromUnix(time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC).Unix())
+	c := createChunk(t, labels.FromStrings("app", "foo"), from)
+	require.NoError(t, client.NewClient(objectClient, client.FSEncoder, schemaConfig).PutChunks(context.Background(), []chunk.Chunk{c}))
+	old := time.Now().Add(-30 * 24 * time.Hour)
+	require.NoError(t, os.Chtimes(filepath.Join(objectDir, client.FSEncoder(schemaConfig, c)), old, old))
+
+	// the table only has a multi-tenant index file, which may reference the chunk.
+	table := periodConfig.IndexTables.TableFor(from)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, periodConfig.IndexTables.PathPrefix)
+	require.NoError(t, indexStorageClient.PutFile(context.Background(), table, "multi-tenant.tsdb", bytes.NewReader([]byte("index"))))
+
+	var cfg Config
+	cfg.RegisterFlags(flag.NewFlagSet("scrubber", flag.PanicOnError))
+	cfg.WorkingDirectory = t.TempDir()
+	cfg.OrphanAction = OrphanActionDelete
+
+	s, err := NewScrubber(cfg, map[config.DayTime]client.ObjectClient{periodConfig.From: objectClient}, nil, schemaConfig, prometheus.NewPedanticRegistry(), "loki", log.NewNopLogger())
+	require.NoError(t, err)
+	s.RegisterIndexCompactor(types.TSDBType, tsdb.NewIndexCompactor())
+
+	report, err := s.Scrub(context.Background())
+	require.NoError(t, err)
+	require.Empty(t, report.Tenants)
+	_, err = os.Stat(filepath.Join(objectDir, client.FSEncoder(schemaConfig, c)))
+	require.NoError(t, err)
+}
@@ -18,10 +18,10 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql/log"
 	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/querier/astmapper"
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
-	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/util/constants"
 	util_log "github.com/grafana/loki/v3/pkg/util/log"
@@ -33,6 +33,10 @@ type ChunkMetrics struct {
 	series       *prometheus.CounterVec
 	chunks       *prometheus.CounterVec
 	batches      *prometheus.HistogramVec
+
+	batchWait         prometheus.Histogram
+	batchDecode       prometheus.Histogram
+	prefetchThrottled *prometheus.CounterVec
 }
 
 const (
@@ -80,6 +84,26 @@ func NewChunkMetrics(r prometheus.Registerer, maxBatchSize int) *ChunkMetrics {
 			// split buckets evenly across 0->maxBatchSize
 			Buckets: prometheus.LinearBuckets(0, float64(maxBatchSize/buckets), buckets+1), // increment buckets by one to ensure upper bound bucket exists.
 		}, []string{"status"}),
+		batchWait: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Namespace: constants.Loki,
+			Subsystem: "store",
+			Name:      "chunk_batch_wait_seconds",
+			Help:      "Time spent by queries waiting for a batch of chunks to be fetched.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		batchDecode: promauto.With(r).NewHistogram(prometheus.HistogramOpts{
+			Namespace: constants.Loki,
+			Subsystem: "store",
+			Name:      "chunk_batch_decode_seconds",
+			Help:      "Time spent by queries decoding a batch of chunks before requesting the next one.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		prefetchThrottled: promauto.With(r).NewCounterVec(prometheus.CounterOpts{
+			Namespace: constants.Loki,
+			Subsystem: "store",
+			Name:      "chunk_batch_prefetch_throttled_total",
+			Help:      "Number of times the prefetch of a batch of chunks was delayed, partitioned by whether the query exceeded its prefetch budget or the object store was congested.",
+		}, []string{"reason"}),
 	}
 }
 
@@ -87,20 +111,31 @@ func NewChunkMetrics(r prometheus.Registerer, maxBatchSize int) *ChunkMetrics {
 // Since chunks can overlap across batches for each iteration the iterator will keep all overlapping
 // chunks with the next chunk from the next batch and added it to the next iteration. In this case the boundaries of the batch
 // is reduced to non-overlapping chunks boundaries.
+// The following batches are fetched concurrently while the current one is iterated, as bounded by the prefetcher.
 type batchChunkIterator struct {
 	schemas         config.SchemaConfig
 	chunks          lazyChunks
 	batchSize       int
 	lastOverlapping []*LazyChunk
-	metrics         *ChunkMetrics
-	matchers        []*labels.Matcher
-	chunkFilterer   chunk.Filterer
+	// lastOverlappingRefs are the refs of the last overlapping chunks, read before they are fetched concurrently.
+	lastOverlappingRefs []logproto.ChunkRef
+	metrics             *ChunkMetrics
+	matchers            []*labels.Matcher
+	chunkFilterer       chunk.Filterer
 
 	begun      bool
 	ctx        context.Context
 	start, end time.Time
 	direction  logproto.Direction
-	next       chan *chunkBatch
+	next       chan *pendingBatch
+
+	prefetcher *batchPrefetcher
+	// returned is the time the last batch was returned to the caller.
+	returned time.Time
+
+	// tracker accounts the chunks of the batch currently iterated for the query.
+	tracker *memory.Tracker
+	held    int64
 }
 
 // newBatchChunkIterator creates a new batch iterator with the given batchSize.
@@ -109,6 +144,7 @@ func newBatchChunkIterator(
 	s config.SchemaConfig,
 	chunks []*LazyChunk,
 	batchSize int,
+	prefetch batchPrefetch,
 	direction logproto.Direction,
 	start, end time.Time,
 	metrics *ChunkMetrics,
@@ -129,13 +165,28 @@ func newBatchChunkIterator(
 		direction:     direction,
 		ctx:           ctx,
 		chunks:        lazyChunks{direction: direction, chunks: chunks},
-		next:          make(chan *chunkBatch),
+		next:          make(chan *pendingBatch, max(prefetch.Batches, 1)),
+		prefetcher:    newBatchPrefetcher(prefetch, metrics, memory.FromContext(ctx)),
 		chunkFilterer: chunkFilterer,
+		tracker:       memory.FromContext(ctx),
 	}
 	sort.Sort(res.chunks)
 	return res
 }
 
+// track replaces the accounted size of the previous batch by the size of the given one.
+func (it *batchChunkIterator) track(b *chunkBatch) error {
+	it.untrack()
+	it.held = b.size()
+	return it.tracker.Reserve(it.held)
+}
+
+// untrack releases the accounted size of the current batch.
+func (it *batchChunkIterator) untrack() {
+	it.tracker.Release(it.held)
+	it.held = 0
+}
+
 // Start is idempotent and will begin the processing thread which seeds the iterator data.
 func (it *batchChunkIterator) Start() {
 	if !it.begun {
@@ -145,26 +196,103 @@ func (it *batchChunkIterator) Start() {
 }
 
 func (it *batchChunkIterator) loop() {
-	for {
-		if it.chunks.Len() == 0 {
-			close(it.next)
+	defer close(it.next)
+
+	var prev *pendingBatch
+	for it.chunks.Len() > 0 {
+		if !it.prefetcher.wait(it.ctx) {
 			return
 		}
+		prev = it.startBatch(prev)
 		select {
 		case <-it.ctx.Done():
-			close(it.next)
+			<-prev.done
+			it.prefetcher.release(prev.size)
 			return
-		case it.next <- it.nextBatch():
+		case it.next <- prev:
 		}
 	}
 }
 
+// releasePrefetched releases the batches fetched ahead which were not iterated. The context of the iterator must be
+// cancelled first.
+func (it *batchChunkIterator) releasePrefetched() {
+	if !it.begun {
+		return
+	}
+	for p := range it.next {
+		<-p.done
+		it.prefetcher.release(p.size)
+	}
+}
+
 func (it *batchChunkIterator) Next() *chunkBatch {
 	it.Start() // Ensure the iterator has started.
-	return <-it.next
+	if !it.returned.IsZero() {
+		it.metrics.batchDecode.Observe(time.Since(it.returned).Seconds())
+	}
+
+	start := time.Now()
+	p, ok := <-it.next
+	if !ok {
+		return nil
+	}
+	<-p.done
+	it.prefetcher.release(p.size)
+	it.metrics.batchWait.Observe(time.Since(start).Seconds())
+
+	it.returned = time.Now()
+	return p.batch
+}
+
+// pendingBatch is a batch of chunks being fetched.
+type pendingBatch struct {
+	batch *chunkBatch
+	size  int64
+	// done is closed once the batch is fetched.
+	done chan struct{}
+}
+
+// batchPlan holds the chunks and the boundaries of a batch to fetch.
+type batchPlan struct {
+	chunks []*LazyChunk
+	// owned are the chunks which are not shared with the previous batch.
+	owned []*LazyChunk
+
+	from, through time.Time
+	nextChunk     *LazyChunk
+}
+
+// startBatch plans the next batch and fetches it in the background once the chunks it shares with the previous
+// batch are fetched.
+func (it *batchChunkIterator) startBatch(prev *pendingBatch) *pendingBatch {
+	p := &pendingBatch{done: make(chan struct{})}
+	it.prefetcher.start()
+	plan, err := it.planBatch()
+	if err != nil || plan == nil {
+		if err != nil {
+			p.batch = &chunkBatch{err: err}
+		}
+		close(p.done)
+		return p
+	}
+
+	go func() {
+		defer close(p.done)
+		p.batch = it.fetchBatch(plan, prev)
+		if p.batch.err == nil {
+			p.size = p.batch.size()
+		}
+		if err := it.prefetcher.fetched(p.size); err != nil {
+			p.batch = &chunkBatch{err: err}
+		}
+	}()
+	return p
 }
 
-func (it *batchChunkIterator) nextBatch() (res *chunkBatch) {
+// fetchBatch fetches the chunks of the planned batch. The chunks which are not shared with the previous batch are
+// fetched concurrently with it.
+func (it *batchChunkIterator) fetchBatch(plan *batchPlan, prev *pendingBatch) (res *chunkBatch) {
 	defer func() {
 		if p := recover(); p != nil {
 			level.Error(util_log.Logger).Log("msg", "panic while fetching chunks", "panic", p)
@@ -173,10 +301,41 @@ func (it *batchChunkIterator) nextBatch() (res *chunkBatch) {
 			}
 		}
 	}()
+
+	if prev != nil && len(plan.owned) < len(plan.chunks) {
+		if err := preloadChunks(it.ctx, it.schemas, plan.owned, it.matchers, it.chunkFilterer); err != nil {
+			return &chunkBatch{err: err}
+		}
+		<-prev.done
+	}
+
+	// download chunk for this batch.
+	chksBySeries, err := fetchChunkBySeries(it.ctx, it.schemas, it.metrics, plan.chunks, it.matchers, it.chunkFilterer)
+	if err != nil {
+		return &chunkBatch{err: err}
+	}
+	return &chunkBatch{
+		chunksBySeries: chksBySeries,
+		from:           plan.from,
+		through:        plan.through,
+		nextChunk:      plan.nextChunk,
+	}
+}
+
+// planBatch pops the chunks of the next batch. It returns nil if the batch can be discarded.
+func (it *batchChunkIterator) planBatch() (res *batchPlan, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			level.Error(util_log.Logger).Log("msg", "panic while planning chunks", "panic", p)
+			res, err = nil, errors.Errorf("panic while planning chunks %+v", p)
+		}
+	}()
 	// the first chunk of the batch
 	headChunk := it.chunks.Peek()
 	from, through := it.start, it.end
 	batch := make([]*LazyChunk, 0, it.batchSize+len(it.lastOverlapping))
+	refs := make([]logproto.ChunkRef, 0, cap(batch))
+	var owned []*LazyChunk
 	var nextChunk *LazyChunk
 
 	var includesOverlap bool
@@ -187,10 +346,17 @@ func (it *batchChunkIterator) nextBatch() (res *chunkBatch) {
 		// so we can merge/de-dupe overlapping entries.
 		if !includesOverlap && it.direction == logproto.FORWARD {
 			batch = append(batch, it.lastOverlapping...)
+			refs = append(refs, it.lastOverlappingRefs...)
+		}
+		popped := it.chunks.pop(it.batchSize)
+		owned = append(owned, popped...)
+		batch = append(batch, popped...)
+		for _, c := range popped {
+			refs = append(refs, c.Chunk.ChunkRef)
 		}
-		batch = append(batch, it.chunks.pop(it.batchSize)...)
 		if !includesOverlap && it.direction == logproto.BACKWARD {
 			batch = append(batch, it.lastOverlapping...)
+			refs = append(refs, it.lastOverlappingRefs...)
 		}
 
 		includesOverlap = true
@@ -253,7 +419,7 @@ func (it *batchChunkIterator) nextBatch() (res *chunkBatch) {
 			// if the start of the batch is equal to the end of the query, since the end is not inclusive we can discard that batch.
 			if from.Equal(it.end) {
 				if it.end != it.start {
-					return nil
+					return nil, nil
 				}
 				// unless end and start are equal in which case start and end are both inclusive.
 				from = it.end
@@ -285,24 +451,23 @@ func (it *batchChunkIterator) nextBatch() (res *chunkBatch) {
 
 	if it.chunks.Len() > 0 {
 		it.lastOverlapping = it.lastOverlapping[:0]
-		for _, c := range batch {
-			if c.IsOverlapping(nextChunk, it.direction) {
+		it.lastOverlappingRefs = it.lastOverlappingRefs[:0]
+		for i, c := range batch {
+			if isChunkRefOverlapping(refs[i], nextChunk.Chunk.ChunkRef, it.direction) {
 				it.lastOverlapping = append(it.lastOverlapping, c)
+				it.lastOverlappingRefs = append(it.lastOverlappingRefs, refs[i])
 			}
 		}
+		// the next chunk is fetched concurrently with the iteration of this batch, which only needs its bounds.
+		nextChunk = &LazyChunk{Chunk: chunk.Chunk{ChunkRef: nextChunk.Chunk.ChunkRef}}
 	}
-	// download chunk for this batch.
-	chksBySeries, err := fetchChunkBySeries(it.ctx, it.schemas, it.metrics, batch, it.matchers, it.chunkFilterer)
-	if err != nil {
-		return &chunkBatch{err: err}
-	}
-	return &chunkBatch{
-		chunksBySeries: chksBySeries,
-		err:            err,
-		from:           from,
-		through:        through,
-		nextChunk:      nextChunk,
-	}
+	return &batchPlan{
+		chunks:    batch,
+		owned:     owned,
+		from:      from,
+		through:   through,
+		nextChunk: nextChunk,
+	}, nil
 }
 
 type chunkBatch struct {
@@ -313,6 +478,21 @@ type chunkBatch struct {
 	nextChunk     *LazyChunk
 }
 
+// size returns the approximate amount of bytes held by the chunks of the batch.
+func (b *chunkBatch) size() int64 {
+	var size int64
+	for _, series := range b.chunksBySeries {
+		for _, chks := range series {
+			for _, c := range chks {
+				if c.Chunk.Data != nil {
+					size += int64(c.Chunk.Data.Size())
+				}
+			}
+		}
+	}
+	return size
+}
+
 type logBatchIterator struct {
 	*batchChunkIterator
 	curr iter.EntryIterator
@@ -329,6 +509,7 @@ func newLogBatchIterator(
 	metrics *ChunkMetrics,
 	chunks []*LazyChunk,
 	batchSize int,
+	prefetch batchPrefetch,
 	matchers []*labels.Matcher,
 	pipeline syntax.Pipeline,
 	direction logproto.Direction,
@@ -340,7 +521,7 @@ func newLogBatchIterator(
 		pipeline:           pipeline,
 		ctx:                ctx,
 		cancel:             cancel,
-		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, direction, start, end, metrics, matchers, chunkFilterer),
+		batchChunkIterator: newBatchChunkIterator(ctx, schemas, chunks, batchSize, prefetch, direction, start, end, metrics, matchers, chunkFilterer),
 	}, nil
 }
 
@@ -367,6 +548,8 @@ func (it *logBatchIterator) Err() error {
 
 func (it *logBatchIterator) Close() error {
 	it.cancel()
+	it.releasePrefetched()
+	it.untrack()
 	if it.curr != nil {
 		return it.curr.Close()
 	}
@@ -395,6 +578,10 @@ func (it *logBatchIterator) Next() bool {
 			it.err = next.err
 			return false
 		}
+		if err := it.track(next); err != nil {
+			it.err = err
+			return false
+		}
 		var err error
 		it.curr, err = it.newChunksIterator(next)
 		if err != nil {
@@ -475,6 +662,7 @@ func newSampleBatchIterator(
 	metrics *ChunkMetrics,
 	chunks []*LazyChunk,
 	batchSize int,
+	prefetch batchPrefetch,
 	matchers []*labels.Matcher,
 	start, end time.Time,
 	chunkFilterer chunk.Filterer,
@@ -490,6 +678,7 @@ func newSampleBatchIterator(
 			schemas,
 			chunks,
 			batchSize,
+			prefetch,
 			logproto.FORWARD,
 			start,
 			end,
@@ -523,6 +712,8 @@ func (it *sampleBatchIterator) Err() error {
 
 func (it *sampleBatchIterator) Close() error {
 	it.cancel()
+	it.releasePrefetched()
+	it.untrack()
 	if it.curr != nil {
 		return it.curr.Close()
 	}
@@ -551,6 +742,10 @@ func (it *sampleBatchIterator) Next() bool {
 			it.err = next.err
 			return false
 		}
+		if err := it.track(next); err != nil {
+			it.err = err
+			return false
+		}
 		var err error
 		it.curr, err = it.newChunksIterator(next)
 		if err != nil {
@@ -694,17 +889,9 @@ func filterSeriesByMatchers(
 			filteredChks += len(grp)
 		}
 	}
-outer:
 	for fp, chunks := range chks {
-		for _, matcher := range matchers {
-			if !matcher.Matches(chunks[0][0].Chunk.Metric.Get(matcher.Name)) {
-				removeSeries(fp, chunks)
-				continue outer
-			}
-		}
-		if chunkFilterer != nil && chunkFilterer.ShouldFilter(chunks[0][0].Chunk.Metric) {
+		if !seriesMatches(chunks[0][0].Chunk.Metric, matchers, chunkFilterer) {
 			removeSeries(fp, chunks)
-			continue outer
 		}
 	}
 	metrics.chunks.WithLabelValues(statusDiscarded).Add(float64(filteredChks))
@@ -712,6 +899,35 @@ outer:
 	return chks
 }
 
+func seriesMatches(lbs labels.Labels, matchers []*labels.Matcher, chunkFilterer chunk.Filterer) bool {
+	for _, matcher := range matchers {
+		if !matcher.Matches(lbs.Get(matcher.Name)) {
+			return false
+		}
+	}
+	return chunkFilterer == nil || !chunkFilterer.ShouldFilter(lbs)
+}
+
+// preloadChunks fetches the given chunks of the series satisfying the matchers, without accounting them in the
+// metrics as they are accounted once their batch is fetched.
+func preloadChunks(ctx context.Context, s config.SchemaConfig, chunks []*LazyChunk, matchers []*labels.Matcher, chunkFilterer chunk.Filterer) error {
+	chksBySeries := partitionBySeriesChunks(chunks)
+	if err := loadFirstChunks(ctx, s, chksBySeries); err != nil {
+		return err
+	}
+
+	var toLoad []*LazyChunk
+	for _, series := range chksBySeries {
+		if !seriesMatches(series[0][0].Chunk.Metric, matchers, chunkFilterer) {
+			continue
+		}
+		for _, chunks := range series {
+			toLoad = append(toLoad, chunks...)
+		}
+	}
+	return fetchLazyChunks(ctx, s, toLoad)
+}
+
 func fetchLazyChunks(ctx context.Context, s config.SchemaConfig, chunks []*LazyChunk) error {
 	var (
 		totalChunks int64
@@ -724,10 +940,11 @@ func fetchLazyChunks(ctx context.Context, s config.SchemaConfig, chunks []*LazyC
 		stats.AddChunksDownloaded(totalChunks)
 	}()
 
-	chksByFetcher := map[*fetcher.Fetcher][]*LazyChunk{}
+	chksByFetcher := map[lazyChunkFetch][]*LazyChunk{}
 	for _, c := range chunks {
 		if c.Chunk.Data == nil {
-			chksByFetcher[c.Fetcher] = append(chksByFetcher[c.Fetcher], c)
+			f := lazyChunkFetch{fetcher: c.Fetcher, readRange: c.readRange}
+			chksByFetcher[f] = append(chksByFetcher[f], c)
 			totalChunks++
 		}
 	}
@@ -738,7 +955,7 @@ func fetchLazyChunks(ctx context.Context, s config.SchemaConfig, chunks []*LazyC
 
 	errChan := make(chan error)
 	for f, chunks := range chksByFetcher {
-		go func(fetcher *fetcher.Fetcher, chunks []*LazyChunk) {
+		go func(f lazyChunkFetch, chunks []*LazyChunk) {
 			chks := make([]chunk.Chunk, 0, len(chunks))
 			index := make(map[string]*LazyChunk, len(chunks))
 
@@ -747,7 +964,7 @@ func fetchLazyChunks(ctx context.Context, s config.SchemaConfig, chunks []*LazyC
 				chks = append(chks, chk.Chunk)
 				index[key] = chk
 			}
-			chks, err := fetcher.FetchChunks(ctx, chks)
+			chks, err := f.fetch(ctx, chks)
 			if ctx.Err() != nil {
 				errChan <- nil
 				return
@@ -783,9 +1000,12 @@ func fetchLazyChunks(ctx context.Context, s config.SchemaConfig, chunks []*LazyC
 		return lastErr
 	}
 
-	for _, c := range chunks {
-		if c.Chunk.Data != nil {
-			c.IsValid = true
+	// only the fetched chunks are updated, as the others may be iterated concurrently by a previous batch.
+	for _, chunks := range chksByFetcher {
+		for _, c := range chunks {
+			if c.Chunk.Data != nil {
+				c.IsValid = true
+			}
 		}
 	}
 	return nil
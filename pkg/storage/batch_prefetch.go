@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"context"
+	"flag"
+	"sync"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
+	"github.com/grafana/loki/v3/pkg/util/flagext"
+)
+
+const (
+	prefetchBudgetReason     = "budget"
+	prefetchCongestionReason = "congestion"
+)
+
+// ChunkPrefetchConfig configures how many batches of chunks of a query are fetched ahead of the iterated one.
+type ChunkPrefetchConfig struct {
+	Batches  int              `yaml:"batches"`
+	MaxBytes flagext.ByteSize `yaml:"max_bytes"`
+}
+
+func (cfg *ChunkPrefetchConfig) RegisterFlagsWithPrefix(prefix string, f *flag.FlagSet) {
+	f.IntVar(&cfg.Batches, prefix+"batches", 1, "Number of batches of chunks of a query fetched concurrently ahead of the batch being iterated. Only one batch is fetched ahead while the object store requests are throttled by the congestion control.")
+	_ = cfg.MaxBytes.Set("256MB")
+	f.Var(&cfg.MaxBytes, prefix+"max-bytes", "Maximum size of the chunks of a query fetched ahead of the batch being iterated. No more batches are fetched ahead until the query iterates the fetched ones. The chunks fetched ahead count towards the max_query_memory limit. 0 to disable.")
+}
+
+// batchPrefetch configures the prefetch of the batches of a query.
+type batchPrefetch struct {
+	ChunkPrefetchConfig
+	// congested reports whether the object store requests are throttled.
+	congested func() bool
+}
+
+// batchPrefetcher bounds the number and the size of the batches fetched ahead of the iterated one.
+// The batches fetched ahead are accounted on the memory tracker of the query until they are iterated.
+type batchPrefetcher struct {
+	cfg     batchPrefetch
+	metrics *ChunkMetrics
+	tracker *memory.Tracker
+
+	mtx      sync.Mutex
+	pending  int
+	bytes    int64
+	released chan struct{}
+}
+
+func newBatchPrefetcher(cfg batchPrefetch, metrics *ChunkMetrics, tracker *memory.Tracker) *batchPrefetcher {
+	return &batchPrefetcher{
+		cfg:      cfg,
+		metrics:  metrics,
+		tracker:  tracker,
+		released: make(chan struct{}, 1),
+	}
+}
+
+// wait waits until another batch can be fetched ahead. It returns false if the context is done first.
+func (p *batchPrefetcher) wait(ctx context.Context) bool {
+	throttled := false
+	for {
+		reason := p.throttled()
+		if reason == "" {
+			return true
+		}
+		if !throttled {
+			p.metrics.prefetchThrottled.WithLabelValues(reason).Inc()
+			throttled = true
+		}
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-p.released:
+		}
+	}
+}
+
+// throttled returns why no other batch can be fetched ahead, or an empty string if one can.
+func (p *batchPrefetcher) throttled() string {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.pending == 0 {
+		return ""
+	}
+	if p.cfg.MaxBytes > 0 && p.bytes >= int64(p.cfg.MaxBytes) {
+		return prefetchBudgetReason
+	}
+	if p.cfg.congested != nil && p.cfg.congested() {
+		return prefetchCongestionReason
+	}
+	if p.pending >= max(p.cfg.Batches, 1) {
+		return prefetchBudgetReason
+	}
+	return ""
+}
+
+// start accounts a batch fetched ahead.
+func (p *batchPrefetcher) start() {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.pending++
+}
+
+// fetched accounts the size of a batch fetched ahead. The size is reserved on the memory tracker even when the
+// reservation exceeds the limit of the query, and released with the batch.
+func (p *batchPrefetcher) fetched(size int64) error {
+	p.mtx.Lock()
+	p.bytes += size
+	p.mtx.Unlock()
+	return p.tracker.Reserve(size)
+}
+
+// release releases a batch fetched ahead once it is iterated.
+func (p *batchPrefetcher) release(size int64) {
+	p.mtx.Lock()
+	p.pending--
+	p.bytes -= size
+	p.mtx.Unlock()
+	p.tracker.Release(size)
+
+	select {
+	case p.released <- struct{}{}:
+	default:
+	}
+}
@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+)
+
+func TestBatchPrefetcher(t *testing.T) {
+	ctx := context.Background()
+	metrics := NewChunkMetrics(nil, 0)
+
+	t.Run("batches", func(t *testing.T) {
+		p := newBatchPrefetcher(batchPrefetch{ChunkPrefetchConfig: ChunkPrefetchConfig{Batches: 2}}, metrics, nil)
+		for i := 0; i < 2; i++ {
+			require.True(t, p.wait(ctx))
+			p.start()
+			require.NoError(t, p.fetched(10))
+		}
+		require.Equal(t, prefetchBudgetReason, p.throttled())
+
+		p.release(10)
+		require.True(t, p.wait(ctx))
+	})
+
+	t.Run("bytes", func(t *testing.T) {
+		p := newBatchPrefetcher(batchPrefetch{ChunkPrefetchConfig: ChunkPrefetchConfig{Batches: 10, MaxBytes: 100}}, metrics, nil)
+		require.True(t, p.wait(ctx))
+		p.start()
+		require.NoError(t, p.fetched(100))
+
+		// the first batch is always fetched, whatever its size.
+		require.Equal(t, prefetchBudgetReason, p.throttled())
+		done := make(chan bool)
+		go func() { done <- p.wait(ctx) }()
+		select {
+		case <-done:
+			t.Fatal("the prefetch should wait for the batch to be released")
+		case <-time.After(50 * time.Millisecond):
+		}
+		p.release(100)
+		require.True(t, <-done)
+		require.Equal(t, "", p.throttled())
+	})
+
+	t.Run("congested", func(t *testing.T) {
+		congested := atomic.NewBool(true)
+		p := newBatchPrefetcher(batchPrefetch{ChunkPrefetchConfig: ChunkPrefetchConfig{Batches: 10}, congested: congested.Load}, metrics, nil)
+		before := testutil.ToFloat64(metrics.prefetchThrottled.WithLabelValues(prefetchCongestionReason))
+
+		// a single batch is fetched ahead while the object store is congested.
+		require.True(t, p.wait(ctx))
+		p.start()
+		require.Equal(t, prefetchCongestionReason, p.throttled())
+
+		ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+		defer cancel()
+		require.False(t, p.wait(ctx))
+		require.Equal(t, before+1, testutil.ToFloat64(metrics.prefetchThrottled.WithLabelValues(prefetchCongestionReason)))
+
+		congested.Store(false)
+		require.Equal(t, "", p.throttled())
+	})
+}
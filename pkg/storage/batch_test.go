@@ -18,6 +18,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logql"
 	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logqlmodel/memory"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 )
@@ -57,7 +58,7 @@ func Test_batchIterSafeStart(t *testing.T) {
 		},
 	}
 
-	batch := newBatchChunkIterator(context.Background(), s, chks, 1, logproto.FORWARD, from, from.Add(4*time.Millisecond), NilMetrics, []*labels.Matcher{}, nil)
+	batch := newBatchChunkIterator(context.Background(), s, chks, 1, batchPrefetch{}, logproto.FORWARD, from, from.Add(4*time.Millisecond), NilMetrics, []*labels.Matcher{}, nil)
 
 	// if it was started already, we should see a panic before this
 	time.Sleep(time.Millisecond)
@@ -996,20 +997,27 @@ func Test_newLogBatchChunkIterator(t *testing.T) {
 		},
 	}
 
+	prefetches := map[string]batchPrefetch{
+		"sequential": {},
+		"prefetch":   {ChunkPrefetchConfig: ChunkPrefetchConfig{Batches: 3}},
+	}
+
 	for _, schemaConfig := range schemaConfigs {
 		s := schemaConfig
 		for name, tt := range tests {
-			t.Run(name, func(t *testing.T) {
-				it, err := newLogBatchIterator(context.Background(), s, NilMetrics, tt.chunks, tt.batchSize, newMatchers(tt.matchers), log.NewNoopPipeline(), tt.direction, tt.start, tt.end, nil)
-				require.NoError(t, err)
-				streams, _, err := iter.ReadBatch(it, 1000)
-				_ = it.Close()
-				if err != nil {
-					t.Fatalf("error reading batch %s", err)
-				}
+			for prefetchName, prefetch := range prefetches {
+				t.Run(name+"/"+prefetchName, func(t *testing.T) {
+					it, err := newLogBatchIterator(context.Background(), s, NilMetrics, tt.chunks, tt.batchSize, prefetch, newMatchers(tt.matchers), log.NewNoopPipeline(), tt.direction, tt.start, tt.end, nil)
+					require.NoError(t, err)
+					streams, _, err := iter.ReadBatch(it, 1000)
+					_ = it.Close()
+					if err != nil {
+						t.Fatalf("error reading batch %s", err)
+					}
 
-				assertStream(t, tt.expected, streams.Streams)
-			})
+					assertStream(t, tt.expected, streams.Streams)
+				})
+			}
 		}
 	}
 }
@@ -1424,6 +1432,7 @@ func Test_newSampleBatchChunkIterator(t *testing.T) {
 				NilMetrics,
 				tt.chunks,
 				tt.batchSize,
+				batchPrefetch{},
 				newMatchers(tt.matchers),
 				tt.start,
 				tt.end,
@@ -1747,7 +1756,7 @@ func TestBatchCancel(t *testing.T) {
 		},
 	}
 
-	it, err := newLogBatchIterator(ctx, s, NilMetrics, chunks, 1, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil)
+	it, err := newLogBatchIterator(ctx, s, NilMetrics, chunks, 1, batchPrefetch{}, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil)
 	require.NoError(t, err)
 	defer require.NoError(t, it.Close())
 	//nolint:revive
@@ -1756,6 +1765,71 @@ func TestBatchCancel(t *testing.T) {
 	require.Equal(t, context.Canceled, it.Err())
 }
 
+func TestBatchMemoryTracking(t *testing.T) {
+	s := config.SchemaConfig{
+		Configs: []config.PeriodConfig{
+			{
+				From:      config.DayTime{Time: 0},
+				Schema:    "v11",
+				RowShards: 16,
+			},
+		},
+	}
+	chunkfmt, headfmt, err := s.Configs[0].ChunkFormat()
+	require.NoError(t, err)
+
+	createChunks := func() []*LazyChunk {
+		var chunks []*LazyChunk
+		for i := 0; i < 3; i++ {
+			chunks = append(chunks, newLazyChunk(chunkfmt, headfmt, logproto.Stream{
+				Labels: fooLabelsWithName.String(),
+				Entries: []logproto.Entry{
+					{Timestamp: from.Add(time.Duration(i) * 10 * time.Millisecond), Line: "1"},
+				},
+			}))
+		}
+		return chunks
+	}
+
+	t.Run("accounted", func(t *testing.T) {
+		tracker, ctx := memory.NewContext(context.Background(), 0)
+		it, err := newLogBatchIterator(ctx, s, NilMetrics, createChunks(), 1, batchPrefetch{}, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil)
+		require.NoError(t, err)
+		var lines int
+		for it.Next() {
+			lines++
+		}
+		require.NoError(t, it.Err())
+		require.Equal(t, 3, lines)
+		require.NoError(t, it.Close())
+		require.Greater(t, tracker.Peak(), int64(0))
+		require.Equal(t, int64(0), tracker.Used())
+	})
+
+	t.Run("prefetched", func(t *testing.T) {
+		tracker, ctx := memory.NewContext(context.Background(), 0)
+		prefetch := batchPrefetch{ChunkPrefetchConfig: ChunkPrefetchConfig{Batches: 1}}
+		it, err := newLogBatchIterator(ctx, s, NilMetrics, createChunks(), 1, prefetch, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil)
+		require.NoError(t, err)
+		require.True(t, it.Next())
+
+		// the batch fetched ahead is accounted with the iterated one until the iterator is closed.
+		held := it.(*logBatchIterator).held
+		require.Eventually(t, func() bool { return tracker.Used() > held }, time.Second, time.Millisecond)
+		require.NoError(t, it.Close())
+		require.Equal(t, int64(0), tracker.Used())
+	})
+
+	t.Run("limited", func(t *testing.T) {
+		_, ctx := memory.NewContext(context.Background(), 1)
+		it, err := newLogBatchIterator(ctx, s, NilMetrics, createChunks(), 1, batchPrefetch{}, newMatchers(fooLabels.String()), log.NewNoopPipeline(), logproto.FORWARD, from, time.Now(), nil)
+		require.NoError(t, err)
+		require.False(t, it.Next())
+		require.ErrorContains(t, it.Err(), "memory")
+		require.NoError(t, it.Close())
+	})
+}
+
 var entry logproto.Entry
 
 func Benchmark_store_OverlappingChunks(b *testing.B) {
@@ -20,7 +20,8 @@ import (
 const maxRegexMatchers = 200
 
 // LabelMatcher represents bloom tests for key-value pairs, mapped from
-// LabelFilterExprs from the AST.
+// LabelFilterExprs from the AST, or for the content of log lines, mapped from
+// LineFilterExprs.
 type LabelMatcher interface{ isLabelMatcher() }
 
 // UnsupportedLabelMatcher represents a label matcher which could not be
@@ -35,6 +36,10 @@ type KeyValueMatcher struct{ Key, Value string }
 // exists in the bloom.
 type KeyMatcher struct{ Key string }
 
+// LineMatcher represents a line content matcher. Bloom tests must only pass if
+// the tokens of a log line containing Value exist in the bloom.
+type LineMatcher struct{ Value string }
+
 // OrLabelMatcher represents a logical OR test. Bloom tests must only pass if
 // one of the Left or Right label matcher bloom tests pass.
 type OrLabelMatcher struct{ Left, Right LabelMatcher }
@@ -58,6 +63,68 @@ func ExtractTestableLabelMatchers(expr syntax.Expr) []LabelMatcher {
 	return buildLabelMatchers(filters)
 }
 
+// ExtractTestableLineMatchers extracts line matchers from the line filters in
+// an expression. The resulting line matchers can then be used for testing
+// against bloom filters built with a line tokenizer. Only the `|=` line filters
+// of literals before the first stage modifying the log line are included, and
+// unsupported line filters are ignored.
+func ExtractTestableLineMatchers(expr syntax.Expr) []LabelMatcher {
+	if expr == nil {
+		return nil
+	}
+	var (
+		matchers          []LabelMatcher
+		foundLineFmtStage bool
+	)
+	visitor := &syntax.DepthFirstTraversal{
+		VisitLineFilterFn: func(_ syntax.RootVisitor, e *syntax.LineFilterExpr) {
+			if !foundLineFmtStage {
+				matchers = appendLineMatchers(matchers, e)
+			}
+		},
+		VisitLineFmtFn:    func(_ syntax.RootVisitor, _ *syntax.LineFmtExpr) { foundLineFmtStage = true },
+		VisitDecolorizeFn: func(_ syntax.RootVisitor, _ *syntax.DecolorizeExpr) { foundLineFmtStage = true },
+	}
+	expr.Accept(visitor)
+	return matchers
+}
+
+// ExtractTestableMatchers extracts both the label matchers and the line
+// matchers of an expression.
+func ExtractTestableMatchers(expr syntax.Expr) []LabelMatcher {
+	return append(ExtractTestableLabelMatchers(expr), ExtractTestableLineMatchers(expr)...)
+}
+
+// appendLineMatchers appends the matchers of the chain of line filters, which
+// must all match.
+func appendLineMatchers(matchers []LabelMatcher, e *syntax.LineFilterExpr) []LabelMatcher {
+	if e.Left != nil {
+		matchers = appendLineMatchers(matchers, e.Left)
+	}
+	if matcher, ok := buildLineMatcher(e); ok {
+		matchers = append(matchers, matcher)
+	}
+	return matchers
+}
+
+// buildLineMatcher builds the matcher of the line filter and of its `or`
+// alternatives. It returns false if any of them can't be tested.
+func buildLineMatcher(e *syntax.LineFilterExpr) (LabelMatcher, bool) {
+	if e.Ty != log.LineMatchEqual || e.Op != "" || e.Match == "" {
+		return nil, false
+	}
+
+	var matcher LabelMatcher = LineMatcher{Value: e.Match}
+	if e.Or != nil {
+		right, ok := buildLineMatcher(e.Or)
+		if !ok {
+			return nil, false
+		}
+		matcher = OrLabelMatcher{Left: matcher, Right: right}
+	}
+	return matcher, true
+}
+
 func buildLabelMatchers(exprs []*syntax.LabelFilterExpr) []LabelMatcher {
 	matchers := make([]LabelMatcher, 0, len(exprs))
 	for _, expr := range exprs {
@@ -275,5 +342,6 @@ func expandSubexpr(reg *regexsyn.Regexp) (prefixes []string, ok bool) {
 func (UnsupportedLabelMatcher) isLabelMatcher() {}
 func (KeyValueMatcher) isLabelMatcher()         {}
 func (KeyMatcher) isLabelMatcher()              {}
+func (LineMatcher) isLabelMatcher()             {}
 func (OrLabelMatcher) isLabelMatcher()          {}
 func (AndLabelMatcher) isLabelMatcher()         {}
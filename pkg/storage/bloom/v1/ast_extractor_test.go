@@ -242,3 +242,70 @@ func TestExtractLabelMatchers_IgnoreAfterParse(t *testing.T) {
 		})
 	}
 }
+
+func TestExtractLineMatchers(t *testing.T) {
+	tt := []struct {
+		name   string
+		input  string
+		expect []v1.LabelMatcher
+	}{
+		{
+			name:  "basic line matcher",
+			input: `{app="foo"} |= "order-8f3a"`,
+			expect: []v1.LabelMatcher{
+				v1.LineMatcher{Value: "order-8f3a"},
+			},
+		},
+		{
+			name:  "multiple line matchers",
+			input: `{app="foo"} |= "foo" | json |= "bar"`,
+			expect: []v1.LabelMatcher{
+				v1.LineMatcher{Value: "foo"},
+				v1.LineMatcher{Value: "bar"},
+			},
+		},
+		{
+			name:  "or line matcher",
+			input: `{app="foo"} |= "foo" or "bar"`,
+			expect: []v1.LabelMatcher{
+				v1.OrLabelMatcher{
+					Left:  v1.LineMatcher{Value: "foo"},
+					Right: v1.LineMatcher{Value: "bar"},
+				},
+			},
+		},
+		{
+			name:  "unsupported line filters",
+			input: `{app="foo"} != "foo" |~ "ba.r" |= ip("127.0.0.1") |= ""`,
+		},
+		{
+			name:  "or with unsupported alternative",
+			input: `{app="foo"} |= "foo" |~ "bar" or "baz"`,
+			expect: []v1.LabelMatcher{
+				v1.LineMatcher{Value: "foo"},
+			},
+		},
+		{
+			name:  "after line_format",
+			input: `{app="foo"} |= "foo" | line_format "{{.msg}}" |= "bar"`,
+			expect: []v1.LabelMatcher{
+				v1.LineMatcher{Value: "foo"},
+			},
+		},
+		{
+			name:  "after decolorize",
+			input: `{app="foo"} |= "foo" | decolorize |= "bar"`,
+			expect: []v1.LabelMatcher{
+				v1.LineMatcher{Value: "foo"},
+			},
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.input)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, v1.ExtractTestableLineMatchers(expr))
+		})
+	}
+}
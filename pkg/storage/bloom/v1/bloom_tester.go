@@ -106,15 +106,18 @@ func (a andTest) MatchesWithPrefixBuf(series labels.Labels, bloom filter.Checker
 	return a.left.MatchesWithPrefixBuf(series, bloom, buf, prefixLen) && a.right.MatchesWithPrefixBuf(series, bloom, buf, prefixLen)
 }
 
-func LabelMatchersToBloomTest(matchers ...LabelMatcher) BloomTest {
+// LabelMatchersToBloomTest converts the matchers to bloom tests. Line matchers are
+// tested with the tokens of the line tokenizer the blooms were built with, and
+// always pass if log lines were not tokenized.
+func LabelMatchersToBloomTest(lineTokenizer LineTokenizerConfig, matchers ...LabelMatcher) BloomTest {
 	tests := make(BloomTests, 0, len(matchers))
 	for _, matcher := range matchers {
-		tests = append(tests, matcherToBloomTest(matcher))
+		tests = append(tests, matcherToBloomTest(lineTokenizer, matcher))
 	}
 	return tests
 }
 
-func matcherToBloomTest(matcher LabelMatcher) BloomTest {
+func matcherToBloomTest(lineTokenizer LineTokenizerConfig, matcher LabelMatcher) BloomTest {
 	switch matcher := matcher.(type) {
 	case UnsupportedLabelMatcher:
 		return matchAllTest{}
@@ -125,16 +128,19 @@ func matcherToBloomTest(matcher LabelMatcher) BloomTest {
 	case KeyMatcher:
 		return newKeyMatcherTest(matcher)
 
+	case LineMatcher:
+		return newLineMatcherTest(lineTokenizer, matcher)
+
 	case OrLabelMatcher:
 		return newOrTest(
-			matcherToBloomTest(matcher.Left),
-			matcherToBloomTest(matcher.Right),
+			matcherToBloomTest(lineTokenizer, matcher.Left),
+			matcherToBloomTest(lineTokenizer, matcher.Right),
 		)
 
 	case AndLabelMatcher:
 		return newAndTest(
-			matcherToBloomTest(matcher.Left),
-			matcherToBloomTest(matcher.Right),
+			matcherToBloomTest(lineTokenizer, matcher.Left),
+			matcherToBloomTest(lineTokenizer, matcher.Right),
 		)
 
 	default:
@@ -252,3 +258,85 @@ func (km keyMatcherTest) match(series labels.Labels, bloom filter.Checker, key [
 	inBloom := bloom.Test(key)
 	return inSeries || inBloom
 }
+
+// newLineMatcherTest returns the test of the tokens a line containing the
+// value of the matcher must have added to the bloom.
+func newLineMatcherTest(lineTokenizer LineTokenizerConfig, matcher LineMatcher) BloomTest {
+	switch lineTokenizer.Mode {
+	case LineTokenizerNGram:
+		return newNGramTest(lineTokenizer.NGramLength, lineTokenizer.NGramSkip, matcher.Value)
+	case LineTokenizerWords:
+		return newWordsTest(matcher.Value)
+	default:
+		return MatchAll
+	}
+}
+
+// newNGramTest tests the n-grams of the search string. As the offset of the
+// search string in the line is unknown, there is one sequence of n-grams per
+// possible offset with skip>0, and the test passes if any of them matches.
+// See orTest.
+func newNGramTest(n, skip int, search string) BloomTest {
+	if n <= 0 {
+		return MatchAll
+	}
+	offsets := runeOffsets(search, nil)
+
+	var test BloomTest
+	for from := 0; from <= skip; from++ {
+		var seq BloomTest
+		forEachNGram(search, offsets, from, n, skip, func(tok string) {
+			if seq == nil {
+				seq = tokenTest{token: tok}
+			} else {
+				seq = newAndTest(seq, tokenTest{token: tok})
+			}
+		})
+		if seq == nil {
+			// the search string is too short to contain any indexed n-gram at this offset.
+			return MatchAll
+		}
+		if test == nil {
+			test = seq
+		} else {
+			test = newOrTest(test, seq)
+		}
+	}
+	return test
+}
+
+// newWordsTest tests the words of the search string. The words at the start
+// and end of the search string are skipped, as they may only be part of a word
+// of the line.
+func newWordsTest(search string) BloomTest {
+	var test BloomTest
+	forEachWord(search, func(word string, first, last bool) {
+		if first || last {
+			return
+		}
+		if test == nil {
+			test = tokenTest{token: word}
+		} else {
+			test = newAndTest(test, tokenTest{token: word})
+		}
+	})
+	if test == nil {
+		return MatchAll
+	}
+	return test
+}
+
+// tokenTest tests a single token of a log line.
+type tokenTest struct {
+	token string
+}
+
+// Matches implements BloomTest
+func (t tokenTest) Matches(_ labels.Labels, bloom filter.Checker) bool {
+	return bloom.Test(unsafe.Slice(unsafe.StringData(t.token), len(t.token))) // #nosec G103 -- we know the string is not mutated
+}
+
+// MatchesWithPrefixBuf implements BloomTest
+func (t tokenTest) MatchesWithPrefixBuf(_ labels.Labels, bloom filter.Checker, buf []byte, prefixLen int) bool {
+	return bloom.Test(appendToBuf(buf, prefixLen, t.token))
+}
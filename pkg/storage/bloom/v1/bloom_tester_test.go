@@ -134,7 +134,7 @@ func TestLabelMatchersToBloomTest(t *testing.T) {
 			require.NoError(t, err)
 
 			matchers := ExtractTestableLabelMatchers(expr)
-			bloomTest := LabelMatchersToBloomTest(matchers...)
+			bloomTest := LabelMatchersToBloomTest(LineTokenizerConfig{}, matchers...)
 
 			// .Matches and .MatchesWithPrefixBuf should both have the same result.
 			require.Equal(t, tc.match, bloomTest.Matches(series, bloom))
@@ -166,3 +166,75 @@ func (f fakeMetadataBloom) Test(data []byte) bool {
 	}
 	return false
 }
+
+func TestLineMatchersToBloomTest(t *testing.T) {
+	const (
+		prefix = "fakeprefix"
+		line   = `level=info msg="order-8f3a created" duration=12ms`
+	)
+	series := labels.FromStrings("app", "fake")
+
+	for _, cfg := range []LineTokenizerConfig{
+		{Mode: LineTokenizerNGram, NGramLength: 4},
+		{Mode: LineTokenizerNGram, NGramLength: 3, NGramSkip: 2},
+		{Mode: LineTokenizerWords},
+	} {
+		bloom := newFakeLineBloom(NewLineTokenizer(cfg, prefix), line)
+
+		for _, tc := range []struct {
+			name  string
+			query string
+			match bool
+		}{
+			{
+				name:  "substring of the line",
+				query: `{app="fake"} |= "r-8f3a cre"`,
+				match: true,
+			},
+			{
+				name:  "missing from the line",
+				query: `{app="fake"} |= "order 9b2c missing"`,
+				match: false,
+			},
+			{
+				name:  "or matcher",
+				query: `{app="fake"} |= "order 9b2c missing" or "msg=\"order"`,
+				match: true,
+			},
+			{
+				name:  "too short to be tested",
+				query: `{app="fake"} |= "zz"`,
+				match: true,
+			},
+			{
+				name:  "unsupported line filter",
+				query: `{app="fake"} != "order 9b2c missing"`,
+				match: true,
+			},
+		} {
+			t.Run(cfg.String()+"/"+tc.name, func(t *testing.T) {
+				expr, err := syntax.ParseExpr(tc.query)
+				require.NoError(t, err)
+
+				bloomTest := LabelMatchersToBloomTest(cfg, ExtractTestableMatchers(expr)...)
+				require.Equal(t, tc.match, bloomTest.Matches(series, bloom))
+				require.Equal(t, tc.match, bloomTest.MatchesWithPrefixBuf(series, bloom, []byte(prefix), len(prefix)))
+			})
+		}
+	}
+
+	// line matchers always pass for blooms built without line tokens
+	expr, err := syntax.ParseExpr(`{app="fake"} |= "order 9b2c missing"`)
+	require.NoError(t, err)
+	require.True(t, LabelMatchersToBloomTest(LineTokenizerConfig{}, ExtractTestableMatchers(expr)...).Matches(series, fakeMetadataBloom{}))
+}
+
+func newFakeLineBloom(tokenizer *LineTokenizer, lines ...string) (res fakeMetadataBloom) {
+	for _, line := range lines {
+		it := tokenizer.Tokens(line)
+		for it.Next() {
+			res = append(res, it.At())
+		}
+	}
+	return res
+}
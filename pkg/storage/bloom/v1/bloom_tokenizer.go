@@ -23,8 +23,9 @@ type BloomTokenizer struct {
 	metrics *Metrics
 	logger  log.Logger
 
-	maxBloomSize int // size in bytes
-	cache        map[string]interface{}
+	maxBloomSize  int // size in bytes
+	lineTokenizer LineTokenizerConfig
+	cache         map[string]interface{}
 }
 
 const cacheSize = 150000
@@ -36,12 +37,14 @@ const eightBits = 8
 // 1) The token slices generated must not be mutated externally
 // 2) The token slice must not be used after the next call to `Tokens()` as it will repopulate the slice.
 // 2) This is not thread safe.
-func NewBloomTokenizer(maxBloomSize int, metrics *Metrics, logger log.Logger) *BloomTokenizer {
+// Log lines are only tokenized if the line tokenizer is enabled.
+func NewBloomTokenizer(maxBloomSize int, lineTokenizer LineTokenizerConfig, metrics *Metrics, logger log.Logger) *BloomTokenizer {
 	return &BloomTokenizer{
-		metrics:      metrics,
-		logger:       logger,
-		cache:        make(map[string]interface{}, cacheSize),
-		maxBloomSize: maxBloomSize,
+		metrics:       metrics,
+		logger:        logger,
+		cache:         make(map[string]interface{}, cacheSize),
+		maxBloomSize:  maxBloomSize,
+		lineTokenizer: lineTokenizer,
 	}
 }
 
@@ -147,7 +150,8 @@ func prefixForChunkRef(chk ChunkRef) []byte {
 	return enc.Get()
 }
 
-// addChunkToBloom adds the values from structured metadata from the entries of the given chunk to the given bloom.
+// addChunkToBloom adds the values from structured metadata from the entries of the given chunk to the given bloom,
+// as well as the tokens of the log lines if the line tokenizer is enabled.
 // addChunkToBloom returns true if the bloom has been completely filled, and may not have consumed the entire iterator.
 // addChunkToBloom must be called multiple times until returning false with new blooms until the iterator has been fully consumed.
 func (bt *BloomTokenizer) addChunkToBloom(bloom *Bloom, ref ChunkRef, entryIter v2iter.PeekIterator[push.Entry]) (bool, indexingInfo) {
@@ -164,7 +168,43 @@ func (bt *BloomTokenizer) addChunkToBloom(bloom *Bloom, ref ChunkRef, entryIter
 	// return values
 	full, info := false, newIndexingInfo()
 
-	tokenizer := NewStructuredMetadataTokenizer(string(prefixForChunkRef(ref)))
+	prefix := string(prefixForChunkRef(ref))
+	tokenizer := NewStructuredMetadataTokenizer(prefix)
+	var lineTokenizer *LineTokenizer
+	if bt.lineTokenizer.Enabled() {
+		lineTokenizer = NewLineTokenizer(bt.lineTokenizer, prefix)
+	}
+
+	addTokens := func(tokenItr v2iter.Iterator[string]) {
+		for tokenItr.Next() {
+			tok := tokenItr.At()
+			tokens++
+
+			// A cache is used ahead of the SBF, as it cuts out the costly operations of scaling bloom filters
+			if _, found := bt.cache[tok]; found {
+				cachedInserts++
+				continue
+			}
+
+			// maxBloomSize is in bytes, but blooms operate at the bit level; adjust
+			var bloomFull bool
+			collision, bloomFull = bloom.TestAndAddWithMaxSize([]byte(tok), bt.maxBloomSize*eightBits)
+			full = full || bloomFull
+
+			if collision {
+				collisionInserts++
+			} else {
+				successfulInserts++
+			}
+
+			// only register the key in the cache if it was successfully added to the bloom
+			// as can prevent us from trying subsequent copies
+			bt.cache[tok] = nil
+			if len(bt.cache) >= cacheSize { // While crude, this has proven efficient in performance testing.  This speaks to the similarity in log lines near each other
+				clear(bt.cache)
+			}
+		}
+	}
 
 	// We use a peeking iterator to avoid advancing the iterator until we're sure the bloom has accepted the line.
 	for entry, ok := entryIter.Peek(); ok; entry, ok = entryIter.Peek() {
@@ -172,40 +212,19 @@ func (bt *BloomTokenizer) addChunkToBloom(bloom *Bloom, ref ChunkRef, entryIter
 			info.sourceBytes += len(kv.Name) + len(kv.Value)
 			info.indexedFields.Add(Field(kv.Name))
 
-			tokenItr := tokenizer.Tokens(kv)
-			for tokenItr.Next() {
-				tok := tokenItr.At()
-				tokens++
-
-				// A cache is used ahead of the SBF, as it cuts out the costly operations of scaling bloom filters
-				if _, found := bt.cache[tok]; found {
-					cachedInserts++
-					continue
-				}
-
-				// maxBloomSize is in bytes, but blooms operate at the bit level; adjust
-				collision, full = bloom.TestAndAddWithMaxSize([]byte(tok), bt.maxBloomSize*eightBits)
-
-				if collision {
-					collisionInserts++
-				} else {
-					successfulInserts++
-				}
-
-				// only register the key in the cache if it was successfully added to the bloom
-				// as can prevent us from trying subsequent copies
-				bt.cache[tok] = nil
-				if len(bt.cache) >= cacheSize { // While crude, this has proven efficient in performance testing.  This speaks to the similarity in log lines near each other
-					clear(bt.cache)
-				}
-			}
+			addTokens(tokenizer.Tokens(kv))
+		}
+
+		if lineTokenizer != nil {
+			info.sourceBytes += len(entry.Line)
+			addTokens(lineTokenizer.Tokens(entry.Line))
 		}
 
 		// Only advance the iterator once we're sure the bloom has accepted the line
 		linesAdded++
 		_ = entryIter.Next()
 
-		// Only break out of the loop if the bloom filter is full after indexing all structured metadata of an entry.
+		// Only break out of the loop if the bloom filter is full after indexing all tokens of an entry.
 		if full {
 			break
 		}
@@ -32,7 +32,7 @@ var metrics = NewMetrics(prometheus.DefaultRegisterer)
 func TestTokenizerPopulate(t *testing.T) {
 	t.Parallel()
 	var testLine = "this is a log line"
-	bt := NewBloomTokenizer(0, metrics, logger.NewNopLogger())
+	bt := NewBloomTokenizer(0, LineTokenizerConfig{}, metrics, logger.NewNopLogger())
 
 	metadata := push.LabelsAdapter{
 		{Name: "pod", Value: "loki-1"},
@@ -75,9 +75,49 @@ func TestTokenizerPopulate(t *testing.T) {
 	}
 }
 
+func TestTokenizerPopulateWithLineTokens(t *testing.T) {
+	t.Parallel()
+	var testLine = "this is a log line"
+	lineTokenizer := LineTokenizerConfig{Mode: LineTokenizerNGram, NGramLength: 4, NGramSkip: 1}
+	bt := NewBloomTokenizer(0, lineTokenizer, metrics, logger.NewNopLogger())
+
+	memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV4), 256000, 1500000)
+	_, _ = memChunk.Append(&push.Entry{
+		Timestamp: time.Unix(0, 1),
+		Line:      testLine,
+	})
+	itr, err := memChunk.Iterator(
+		context.Background(),
+		time.Unix(0, 0),
+		time.Unix(0, math.MaxInt64),
+		logproto.FORWARD,
+		log.NewNoopPipeline().ForStream(nil),
+	)
+	require.Nil(t, err)
+
+	ref := ChunkRef{}
+	blooms, err := populateAndConsumeBloom(
+		bt,
+		v2.NewEmptyIter[*Bloom](),
+		v2.NewSliceIter([]ChunkRefWithIter{{Ref: ref, Itr: itr}}),
+	)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(blooms))
+
+	tokens := NewLineTokenizer(lineTokenizer, string(prefixForChunkRef(ref))).Tokens(testLine)
+	for tokens.Next() {
+		require.True(t, blooms[0].Test([]byte(tokens.At())))
+	}
+
+	test := newLineMatcherTest(lineTokenizer, LineMatcher{Value: "a log"})
+	require.True(t, test.Matches(nil, blooms[0]))
+	test = newLineMatcherTest(lineTokenizer, LineMatcher{Value: "no such line"})
+	require.False(t, test.Matches(nil, blooms[0]))
+}
+
 func TestBloomTokenizerPopulateWithoutPreexistingBloom(t *testing.T) {
 	var testLine = "this is a log line"
-	bt := NewBloomTokenizer(0, metrics, logger.NewNopLogger())
+	bt := NewBloomTokenizer(0, LineTokenizerConfig{}, metrics, logger.NewNopLogger())
 
 	metadata := push.LabelsAdapter{
 		{Name: "pod", Value: "loki-1"},
@@ -154,7 +194,7 @@ func randomStr(ln int) string {
 
 func TestTokenizerPopulateWontExceedMaxSize(t *testing.T) {
 	maxSize := 4 << 10
-	bt := NewBloomTokenizer(maxSize, NewMetrics(nil), logger.NewNopLogger())
+	bt := NewBloomTokenizer(maxSize, LineTokenizerConfig{}, NewMetrics(nil), logger.NewNopLogger())
 	ch := make(chan *BloomCreation)
 
 	metadata := make([]push.LabelsAdapter, 0, 4<<10)
@@ -201,7 +241,7 @@ func populateAndConsumeBloom(
 
 func BenchmarkPopulateSeriesWithBloom(b *testing.B) {
 	for i := 0; i < b.N; i++ {
-		bt := NewBloomTokenizer(0, metrics, logger.NewNopLogger())
+		bt := NewBloomTokenizer(0, LineTokenizerConfig{}, metrics, logger.NewNopLogger())
 
 		sbf := filter.NewScalableBloomFilter(1024, 0.01, 0.8)
 
@@ -238,7 +278,7 @@ func BenchmarkPopulateSeriesWithBloom(b *testing.B) {
 }
 
 func TestTokenizerClearsCacheBetweenPopulateCalls(t *testing.T) {
-	bt := NewBloomTokenizer(0, NewMetrics(nil), logger.NewNopLogger())
+	bt := NewBloomTokenizer(0, LineTokenizerConfig{}, NewMetrics(nil), logger.NewNopLogger())
 	md := push.LabelsAdapter{
 		{Name: "trace_id", Value: "3bef3c91643bde73"},
 	}
@@ -276,7 +316,7 @@ func TestTokenizerClearsCacheBetweenPopulateCalls(t *testing.T) {
 }
 
 func BenchmarkMapClear(b *testing.B) {
-	bt := NewBloomTokenizer(0, metrics, logger.NewNopLogger())
+	bt := NewBloomTokenizer(0, LineTokenizerConfig{}, metrics, logger.NewNopLogger())
 	for i := 0; i < b.N; i++ {
 		for k := 0; k < cacheSize; k++ {
 			bt.cache[fmt.Sprint(k)] = k
@@ -287,7 +327,7 @@ func BenchmarkMapClear(b *testing.B) {
 }
 
 func BenchmarkNewMap(b *testing.B) {
-	bt := NewBloomTokenizer(0, metrics, logger.NewNopLogger())
+	bt := NewBloomTokenizer(0, LineTokenizerConfig{}, metrics, logger.NewNopLogger())
 	for i := 0; i < b.N; i++ {
 		for k := 0; k < cacheSize; k++ {
 			bt.cache[fmt.Sprint(k)] = k
@@ -44,17 +44,18 @@ func (b BlockOptions) Len() int {
 }
 
 func (b *BlockOptions) DecodeFrom(r io.ReadSeeker) error {
-	buf := make([]byte, b.Len())
+	// the length of the schema depends on its version
+	if err := b.Schema.DecodeFrom(r); err != nil {
+		return errors.Wrap(err, "decoding schema")
+	}
+
+	buf := make([]byte, b.Len()-b.Schema.Len())
 	_, err := io.ReadFull(r, buf)
 	if err != nil {
 		return errors.Wrap(err, "reading block options")
 	}
 
 	dec := encoding.DecWith(buf)
-
-	if err := b.Schema.Decode(&dec); err != nil {
-		return errors.Wrap(err, "decoding schema")
-	}
 	b.SeriesPageSize = dec.Be64()
 	b.BloomPageSize = dec.Be64()
 	b.BlockSize = dec.Be64()
@@ -42,21 +42,44 @@ func TestBlockOptions_BloomPageSize(t *testing.T) {
 
 func TestBlockOptions_RoundTrip(t *testing.T) {
 	t.Parallel()
-	opts := BlockOptions{
-		Schema:         NewSchema(CurrentSchemaVersion, compression.Snappy),
-		SeriesPageSize: 100,
-		BloomPageSize:  10 << 10,
-		BlockSize:      10 << 20,
+	for _, schema := range []Schema{
+		NewSchema(CurrentSchemaVersion, compression.Snappy),
+		NewSchema(CurrentSchemaVersion, compression.Snappy).WithLineTokenizer(LineTokenizerConfig{Mode: LineTokenizerNGram, NGramLength: 4, NGramSkip: 1}),
+	} {
+		opts := BlockOptions{
+			Schema:         schema,
+			SeriesPageSize: 100,
+			BloomPageSize:  10 << 10,
+			BlockSize:      10 << 20,
+		}
+
+		var enc encoding.Encbuf
+		opts.Encode(&enc)
+
+		var got BlockOptions
+		err := got.DecodeFrom(bytes.NewReader(enc.Get()))
+		require.Nil(t, err)
+
+		require.Equal(t, opts, got)
 	}
+}
 
-	var enc encoding.Encbuf
-	opts.Encode(&enc)
+func TestBlockBuilder_LineTokenizerSchema(t *testing.T) {
+	schema := NewSchema(CurrentSchemaVersion, compression.Snappy).WithLineTokenizer(LineTokenizerConfig{Mode: LineTokenizerWords, NGramLength: 4})
+	require.Equal(t, V4, schema.Version())
+	require.Equal(t, LineTokenizerConfig{Mode: LineTokenizerWords}, schema.LineTokenizer())
 
-	var got BlockOptions
-	err := got.DecodeFrom(bytes.NewReader(enc.Get()))
+	indexBuf, bloomsBuf := bytes.NewBuffer(nil), bytes.NewBuffer(nil)
+	builder, err := NewBlockBuilder(BlockOptions{Schema: schema, SeriesPageSize: 100, BloomPageSize: 10 << 10}, NewMemoryBlockWriter(indexBuf, bloomsBuf))
+	require.Nil(t, err)
+	data, _ := MkBasicSeriesWithBlooms(10, 0, 0xffff, 0, 10000)
+	_, err = builder.BuildFrom(iter.NewSliceIter(data))
 	require.Nil(t, err)
 
-	require.Equal(t, opts, got)
+	block := NewBlock(NewByteReader(indexBuf, bloomsBuf), NewMetrics(nil))
+	got, err := NewBlockQuerier(block, &mempool.SimpleHeapAllocator{}, DefaultMaxPageSize).Schema()
+	require.Nil(t, err)
+	require.Equal(t, schema, got)
 }
 
 func TestBlockBuilder_RoundTrip(t *testing.T) {
@@ -26,19 +26,22 @@ const (
 	V2
 	// V2 indicated schema for indexed structured metadata
 	V3
+	// V4 additionally indexes the tokens of log lines, as configured by the line tokenizer of the schema
+	V4
 
 	CurrentSchemaVersion = V3
 )
 
 var (
-	SupportedVersions = []Version{V3}
+	SupportedVersions = []Version{V3, V4}
 
 	ErrUnsupportedSchemaVersion = errors.New("unsupported schema version")
 )
 
 type Schema struct {
-	version  Version
-	encoding compression.Codec
+	version       Version
+	encoding      compression.Codec
+	lineTokenizer LineTokenizerConfig
 }
 
 func NewSchema(version Version, encoding compression.Codec) Schema {
@@ -48,7 +51,24 @@ func NewSchema(version Version, encoding compression.Codec) Schema {
 	}
 }
 
+// WithLineTokenizer returns a V4 schema indexing log lines with the given tokenizer.
+// The schema is returned unchanged if the tokenizer is not enabled.
+func (s Schema) WithLineTokenizer(cfg LineTokenizerConfig) Schema {
+	if !cfg.Enabled() {
+		return s
+	}
+	if cfg.Mode != LineTokenizerNGram {
+		cfg.NGramLength, cfg.NGramSkip = 0, 0
+	}
+	s.version = V4
+	s.lineTokenizer = cfg
+	return s
+}
+
 func (s Schema) String() string {
+	if s.version >= V4 {
+		return fmt.Sprintf("%s,encoding=%s,line_tokenizer=%s", s.version, s.encoding, s.lineTokenizer)
+	}
 	return fmt.Sprintf("%s,encoding=%s", s.version, s.encoding)
 }
 
@@ -60,10 +80,20 @@ func (s Schema) Version() Version {
 	return s.version
 }
 
+// LineTokenizer returns how log lines are tokenized in the blooms of the schema.
+func (s Schema) LineTokenizer() LineTokenizerConfig {
+	return s.lineTokenizer
+}
+
 // byte length
 func (s Schema) Len() int {
 	// magic number + version + encoding
-	return 4 + 1 + 1
+	n := 4 + 1 + 1
+	if s.version >= V4 {
+		// line tokenizer mode + ngram length + ngram skip
+		n += 1 + 1 + 1
+	}
+	return n
 }
 
 func (s *Schema) DecompressorPool() compression.ReaderPool {
@@ -79,15 +109,28 @@ func (s *Schema) Encode(enc *encoding.Encbuf) {
 	enc.PutBE32(magicNumber)
 	enc.PutByte(byte(s.version))
 	enc.PutByte(byte(s.encoding))
+	if s.version >= V4 {
+		enc.PutByte(byte(s.lineTokenizer.Mode))
+		enc.PutByte(byte(s.lineTokenizer.NGramLength))
+		enc.PutByte(byte(s.lineTokenizer.NGramSkip))
+	}
 }
 
 func (s *Schema) DecodeFrom(r io.ReadSeeker) error {
 	// TODO(owen-d): improve allocations
-	schemaBytes := make([]byte, s.Len())
+	schemaBytes := make([]byte, Schema{}.Len())
 	_, err := io.ReadFull(r, schemaBytes)
 	if err != nil {
 		return errors.Wrap(err, "reading schema")
 	}
+	// the length of the schema depends on its version, which follows the magic number
+	if v := Version(schemaBytes[4]); v >= V4 {
+		extra := make([]byte, Schema{version: v}.Len()-len(schemaBytes))
+		if _, err := io.ReadFull(r, extra); err != nil {
+			return errors.Wrap(err, "reading schema")
+		}
+		schemaBytes = append(schemaBytes, extra...)
+	}
 
 	dec := encoding.DecWith(schemaBytes)
 	return s.Decode(&dec)
@@ -99,8 +142,8 @@ func (s *Schema) Decode(dec *encoding.Decbuf) error {
 		return errors.Errorf("invalid magic number. expected %x, got  %x", magicNumber, number)
 	}
 	s.version = Version(dec.Byte())
-	if s.version != V3 {
-		return errors.Errorf("invalid version. expected %d, got %d", 3, s.version)
+	if s.version != V3 && s.version != V4 {
+		return errors.Errorf("invalid version. expected %d or %d, got %d", 3, 4, s.version)
 	}
 
 	s.encoding = compression.Codec(dec.Byte())
@@ -108,5 +151,15 @@ func (s *Schema) Decode(dec *encoding.Decbuf) error {
 		return errors.Wrap(err, "parsing encoding")
 	}
 
+	s.lineTokenizer = LineTokenizerConfig{}
+	if s.version >= V4 {
+		s.lineTokenizer.Mode = LineTokenizerMode(dec.Byte())
+		s.lineTokenizer.NGramLength = int(dec.Byte())
+		s.lineTokenizer.NGramSkip = int(dec.Byte())
+		if _, err := ParseLineTokenizerMode(s.lineTokenizer.Mode.String()); err != nil {
+			return errors.Wrap(err, "parsing line tokenizer")
+		}
+	}
+
 	return dec.Err()
 }
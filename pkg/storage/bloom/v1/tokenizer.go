@@ -2,6 +2,8 @@ package v1
 
 import (
 	"fmt"
+	"unicode"
+	"unicode/utf8"
 
 	iter "github.com/grafana/loki/v3/pkg/iter/v2"
 
@@ -29,3 +31,137 @@ func (t *StructuredMetadataTokenizer) Tokens(kv push.LabelAdapter) iter.Iterator
 	)
 	return iter.NewSliceIter(t.tokens)
 }
+
+// LineTokenizerMode determines how log lines are split into the tokens indexed into blooms.
+type LineTokenizerMode byte
+
+const (
+	// LineTokenizerNone does not index log lines.
+	LineTokenizerNone LineTokenizerMode = iota
+	// LineTokenizerNGram indexes the n-grams of log lines.
+	LineTokenizerNGram
+	// LineTokenizerWords indexes the words of log lines, split on whitespace and punctuation.
+	LineTokenizerWords
+)
+
+func (m LineTokenizerMode) String() string {
+	switch m {
+	case LineTokenizerNone:
+		return "none"
+	case LineTokenizerNGram:
+		return "ngram"
+	case LineTokenizerWords:
+		return "words"
+	default:
+		return fmt.Sprintf("unknown(%d)", byte(m))
+	}
+}
+
+// ParseLineTokenizerMode parses the name of a line tokenizer mode. An empty name is LineTokenizerNone.
+func ParseLineTokenizerMode(s string) (LineTokenizerMode, error) {
+	if s == "" {
+		return LineTokenizerNone, nil
+	}
+	for _, m := range []LineTokenizerMode{LineTokenizerNone, LineTokenizerNGram, LineTokenizerWords} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return LineTokenizerNone, fmt.Errorf("invalid line tokenizer %q, expected one of none, ngram or words", s)
+}
+
+// LineTokenizerConfig configures the tokenization of log lines.
+type LineTokenizerConfig struct {
+	Mode LineTokenizerMode
+	// NGramLength is the number of runes of the n-grams, and NGramSkip the number
+	// of n-grams skipped after each indexed n-gram. They only apply to LineTokenizerNGram.
+	NGramLength, NGramSkip int
+}
+
+// Enabled returns whether log lines are tokenized.
+func (c LineTokenizerConfig) Enabled() bool {
+	return c.Mode != LineTokenizerNone
+}
+
+func (c LineTokenizerConfig) String() string {
+	if c.Mode == LineTokenizerNGram {
+		return fmt.Sprintf("%s(n=%d,skip=%d)", c.Mode, c.NGramLength, c.NGramSkip)
+	}
+	return c.Mode.String()
+}
+
+// LineTokenizer splits log lines into tokens. Like the structured metadata
+// tokens, every token is returned both as is and prefixed.
+type LineTokenizer struct {
+	cfg LineTokenizerConfig
+	// prefix to add to tokens, typically the encoded chunkref
+	prefix  string
+	offsets []int
+	tokens  []string
+}
+
+func NewLineTokenizer(cfg LineTokenizerConfig, prefix string) *LineTokenizer {
+	return &LineTokenizer{
+		cfg:    cfg,
+		prefix: prefix,
+	}
+}
+
+func (t *LineTokenizer) Tokens(line string) iter.Iterator[string] {
+	t.tokens = t.tokens[:0]
+	add := func(tok string) {
+		t.tokens = append(t.tokens, tok, t.prefix+tok)
+	}
+
+	switch t.cfg.Mode {
+	case LineTokenizerNGram:
+		t.offsets = runeOffsets(line, t.offsets[:0])
+		forEachNGram(line, t.offsets, 0, t.cfg.NGramLength, t.cfg.NGramSkip, add)
+	case LineTokenizerWords:
+		forEachWord(line, func(tok string, _, _ bool) { add(tok) })
+	}
+	return iter.NewSliceIter(t.tokens)
+}
+
+// runeOffsets appends the byte offsets of the runes of s, followed by len(s), to offsets.
+func runeOffsets(s string, offsets []int) []int {
+	for i := range s {
+		offsets = append(offsets, i)
+	}
+	return append(offsets, len(s))
+}
+
+// forEachNGram calls f with the n-grams of s starting at the rune from, then at every skip+1 runes.
+// offsets are the rune offsets of s, as returned by runeOffsets.
+func forEachNGram(s string, offsets []int, from, n, skip int, f func(string)) {
+	runes := len(offsets) - 1
+	for i := from; i+n <= runes; i += skip + 1 {
+		f(s[offsets[i]:offsets[i+n]])
+	}
+}
+
+// forEachWord calls f with the words of s, which are the runs of letters and digits.
+// first and last are set for the words starting and ending s, which may be
+// truncated when s is a substring of a longer line.
+func forEachWord(s string, f func(word string, first, last bool)) {
+	start := -1
+	for i, r := range s {
+		if isWordRune(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 {
+			f(s[start:i], start == 0, false)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		f(s[start:], start == 0, true)
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r))
+}
@@ -21,3 +21,62 @@ func TestStructuredMetadataTokenizer(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, expected, got)
 }
+
+func TestLineTokenizer(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		cfg      LineTokenizerConfig
+		line     string
+		expected []string
+	}{
+		{
+			name:     "ngrams",
+			cfg:      LineTokenizerConfig{Mode: LineTokenizerNGram, NGramLength: 3},
+			line:     "foobar",
+			expected: []string{"foo", "oob", "oba", "bar"},
+		},
+		{
+			name:     "ngrams with skip",
+			cfg:      LineTokenizerConfig{Mode: LineTokenizerNGram, NGramLength: 3, NGramSkip: 1},
+			line:     "foobar",
+			expected: []string{"foo", "oba"},
+		},
+		{
+			name:     "ngrams of runes",
+			cfg:      LineTokenizerConfig{Mode: LineTokenizerNGram, NGramLength: 2},
+			line:     "äöü",
+			expected: []string{"äö", "öü"},
+		},
+		{
+			name: "line shorter than ngrams",
+			cfg:  LineTokenizerConfig{Mode: LineTokenizerNGram, NGramLength: 4},
+			line: "foo",
+		},
+		{
+			name:     "words",
+			cfg:      LineTokenizerConfig{Mode: LineTokenizerWords},
+			line:     `level=info msg="order-8f3a created"`,
+			expected: []string{"level", "info", "msg", "order", "8f3a", "created"},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var expected []string
+			for _, tok := range tc.expected {
+				expected = append(expected, tok, "chunk"+tok)
+			}
+			got, err := v2.Collect(NewLineTokenizer(tc.cfg, "chunk").Tokens(tc.line))
+			require.NoError(t, err)
+			require.Equal(t, expected, got)
+		})
+	}
+}
+
+func TestParseLineTokenizerMode(t *testing.T) {
+	for _, m := range []LineTokenizerMode{LineTokenizerNone, LineTokenizerNGram, LineTokenizerWords} {
+		parsed, err := ParseLineTokenizerMode(m.String())
+		require.NoError(t, err)
+		require.Equal(t, m, parsed)
+	}
+	_, err := ParseLineTokenizerMode("chars")
+	require.Error(t, err)
+}
@@ -41,9 +41,10 @@ type SeriesWithBlooms struct {
 	Blooms iter.SizedIterator[*Bloom]
 }
 
+// NewBlockBuilderV3 also builds V4 blocks, which only differ from V3 blocks by their schema.
 func NewBlockBuilderV3(opts BlockOptions, writer BlockWriter) (*V3Builder, error) {
-	if opts.Schema.version != V3 {
-		return nil, errors.Errorf("schema mismatch creating builder, expected v3, got %v", opts.Schema.version)
+	if opts.Schema.version != V3 && opts.Schema.version != V4 {
+		return nil, errors.Errorf("schema mismatch creating builder, expected v3 or v4, got %v", opts.Schema.version)
 	}
 
 	index, err := writer.Index()
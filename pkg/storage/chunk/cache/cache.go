@@ -32,6 +32,7 @@ type Config struct {
 	MemcacheClient MemcachedClientConfig `yaml:"memcached_client"`
 	Redis          RedisConfig           `yaml:"redis"`
 	EmbeddedCache  EmbeddedCacheConfig   `yaml:"embedded_cache"`
+	DiskCache      DiskCacheConfig       `yaml:"disk_cache"`
 
 	// This is to name the cache metrics properly.
 	Prefix string `yaml:"prefix" doc:"hidden"`
@@ -47,6 +48,7 @@ func (cfg *Config) RegisterFlagsWithPrefix(prefix string, description string, f
 	cfg.MemcacheClient.RegisterFlagsWithPrefix(prefix, description, f)
 	cfg.Redis.RegisterFlagsWithPrefix(prefix, description, f)
 	cfg.EmbeddedCache.RegisterFlagsWithPrefix(prefix+"embedded-cache.", description, f)
+	cfg.DiskCache.RegisterFlagsWithPrefix(prefix+"disk-cache.", description, f)
 	f.DurationVar(&cfg.DefaultValidity, prefix+"default-validity", time.Hour, description+"The default validity of entries for caches unless overridden.")
 
 	cfg.Prefix = prefix
@@ -71,6 +73,10 @@ func IsEmbeddedCacheSet(cfg Config) bool {
 	return cfg.EmbeddedCache.Enabled
 }
 
+func IsDiskCacheSet(cfg Config) bool {
+	return cfg.DiskCache.Enabled
+}
+
 func IsSpecificImplementationSet(cfg Config) bool {
 	return cfg.Cache != nil
 }
@@ -79,9 +85,10 @@ func IsSpecificImplementationSet(cfg Config) bool {
 // - memcached
 // - redis
 // - embedded-cache
+// - disk-cache
 // - specific cache implementation
 func IsCacheConfigured(cfg Config) bool {
-	return IsMemcacheSet(cfg) || IsRedisSet(cfg) || IsEmbeddedCacheSet(cfg) || IsSpecificImplementationSet(cfg)
+	return IsMemcacheSet(cfg) || IsRedisSet(cfg) || IsEmbeddedCacheSet(cfg) || IsDiskCacheSet(cfg) || IsSpecificImplementationSet(cfg)
 }
 
 // New creates a new Cache using Config.
@@ -104,6 +111,15 @@ func New(cfg Config, reg prometheus.Registerer, logger log.Logger, cacheType sta
 		}
 	}
 
+	if cfg.DiskCache.IsEnabled() {
+		cacheName := cfg.Prefix + "disk-cache"
+		cache, err := NewDiskCache(cacheName, cfg.DiskCache, reg, logger, cacheType)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, CollectStats(NewBackground(cacheName, cfg.Background, Instrument(cacheName, cache, reg), reg)))
+	}
+
 	if IsMemcacheSet(cfg) && IsRedisSet(cfg) {
 		return nil, errors.New("use of multiple cache storage systems is not supported")
 	}
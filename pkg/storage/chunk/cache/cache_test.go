@@ -211,6 +211,13 @@ func TestEmbeddedCache(t *testing.T) {
 	testCache(t, cache)
 }
 
+func TestDiskCache(t *testing.T) {
+	cache, err := cache.NewDiskCache("test", cache.DiskCacheConfig{Directory: t.TempDir(), MaxSizeMB: 100, TTL: time.Hour},
+		nil, log.NewNopLogger(), "test")
+	require.NoError(t, err)
+	testCache(t, cache)
+}
+
 func TestSnappyCache(t *testing.T) {
 	cache := cache.NewSnappy(cache.NewMockCache(), log.NewNopLogger())
 	testCache(t, cache)
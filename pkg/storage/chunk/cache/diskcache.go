@@ -0,0 +1,444 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/crc32"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/multierror"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
+	"github.com/grafana/loki/v3/pkg/util/constants"
+)
+
+const (
+	diskCacheMagic   = "LDC1"
+	diskCacheTempDir = "tmp"
+
+	corruptedReason = "corrupted"
+)
+
+var (
+	errDiskCacheEntryCorrupted = errors.New("corrupted disk cache entry")
+
+	diskCacheCastagnoli = crc32.MakeTable(crc32.Castagnoli)
+)
+
+// DiskCacheConfig configures a cache storing its entries in the files of a local directory.
+type DiskCacheConfig struct {
+	Enabled          bool          `yaml:"enabled,omitempty"`
+	Directory        string        `yaml:"directory"`
+	MaxSizeMB        int64         `yaml:"max_size_mb"`
+	TTL              time.Duration `yaml:"ttl"`
+	EvictionInterval time.Duration `yaml:"eviction_interval"`
+}
+
+func (cfg *DiskCacheConfig) RegisterFlagsWithPrefix(prefix, description string, f *flag.FlagSet) {
+	f.BoolVar(&cfg.Enabled, prefix+"enabled", false, description+"Whether the disk cache is enabled. It is queried after the embedded cache and before memcached or redis.")
+	f.StringVar(&cfg.Directory, prefix+"directory", "", description+"Directory of the disk cache. The entries of each cache are stored in a subdirectory named after the cache, so the caches can share the directory.")
+	f.Int64Var(&cfg.MaxSizeMB, prefix+"max-size-mb", 10240, description+"Maximum size of the entries of the disk cache in MB.")
+	f.DurationVar(&cfg.TTL, prefix+"ttl", 24*time.Hour, description+"The time to live for items in the disk cache before they get evicted.")
+	f.DurationVar(&cfg.EvictionInterval, prefix+"eviction-interval", time.Minute, description+"How often the expired items are evicted from the disk cache. The least recently used items are evicted as soon as the cache is full.")
+}
+
+func (cfg *DiskCacheConfig) IsEnabled() bool {
+	return cfg.Enabled
+}
+
+// DiskCache is a cache storing each entry in a checksummed file of a local directory, evicting the least
+// recently used entries in the background once the files exceed the maximum size.
+//
+// The files are written to a temporary directory and renamed into place, and the index of the entries is
+// rebuilt from the directory on startup, so the cache survives restarts and crashes: the files left partially
+// written by a crash fail their checksum and are removed when they are read.
+type DiskCache struct {
+	cacheType    stats.CacheType
+	dir          string
+	maxSizeBytes int64
+	ttl          time.Duration
+	logger       log.Logger
+
+	lock          sync.Mutex
+	entries       map[string]*list.Element
+	lru           *list.List
+	currSizeBytes int64
+
+	evict chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+
+	entriesAddedNew prometheus.Counter
+	entriesEvicted  *prometheus.CounterVec
+	entriesCurrent  prometheus.Gauge
+	diskBytes       prometheus.Gauge
+}
+
+type diskCacheEntry struct {
+	name   string
+	size   int64
+	stored time.Time
+}
+
+// NewDiskCache returns a new DiskCache storing its entries in the subdirectory of the configured directory
+// named after the cache, with the entries already stored there.
+func NewDiskCache(name string, cfg DiskCacheConfig, reg prometheus.Registerer, logger log.Logger, cacheType stats.CacheType) (*DiskCache, error) {
+	if cfg.Directory == "" {
+		return nil, fmt.Errorf("no directory configured for the disk cache %s", name)
+	}
+	if cfg.MaxSizeMB <= 0 {
+		return nil, fmt.Errorf("max size of the disk cache %s must be positive", name)
+	}
+	if cfg.EvictionInterval <= 0 {
+		cfg.EvictionInterval = defaultPurgeInterval
+	}
+
+	c := &DiskCache{
+		cacheType:    cacheType,
+		dir:          filepath.Join(cfg.Directory, name),
+		maxSizeBytes: cfg.MaxSizeMB * 1e6,
+		ttl:          cfg.TTL,
+		logger:       log.With(logger, "cache", name),
+
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+
+		evict: make(chan struct{}, 1),
+		done:  make(chan struct{}),
+
+		entriesAddedNew: promauto.With(reg).NewCounter(prometheus.CounterOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "added_new_total",
+			Help:        "The total number of new entries added to the cache",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}),
+		entriesEvicted: promauto.With(reg).NewCounterVec(prometheus.CounterOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "evicted_total",
+			Help:        "The total number of evicted entries",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}, []string{"reason"}),
+		entriesCurrent: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "entries",
+			Help:        "Current number of entries in the cache",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}),
+		diskBytes: promauto.With(reg).NewGauge(prometheus.GaugeOpts{
+			Namespace:   constants.Loki,
+			Subsystem:   "diskcache",
+			Name:        "disk_bytes",
+			Help:        "The current size of the files of the cache in bytes",
+			ConstLabels: prometheus.Labels{"cache": name},
+		}),
+	}
+
+	if err := c.load(); err != nil {
+		return nil, fmt.Errorf("failed to load the disk cache %s: %w", name, err)
+	}
+
+	c.wg.Add(1)
+	go c.runEviction(cfg.EvictionInterval)
+
+	return c, nil
+}
+
+// load rebuilds the index of the entries from the files of the directory, the most recently stored first.
+func (c *DiskCache) load() error {
+	if err := os.RemoveAll(filepath.Join(c.dir, diskCacheTempDir)); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(c.dir, diskCacheTempDir), 0o750); err != nil {
+		return err
+	}
+
+	var entries []diskCacheEntry
+	err := filepath.WalkDir(c.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == diskCacheTempDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		// skip the files which aren't entries.
+		if filepath.Dir(path) != filepath.Join(c.dir, diskCacheShard(d.Name())) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entries = append(entries, diskCacheEntry{name: d.Name(), size: info.Size(), stored: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].stored.Before(entries[j].stored) })
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for i := range entries {
+		c.entries[entries[i].name] = c.lru.PushFront(&entries[i])
+		c.currSizeBytes += entries[i].size
+	}
+	c.entriesCurrent.Set(float64(len(c.entries)))
+	c.diskBytes.Set(float64(c.currSizeBytes))
+	c.evictEntries()
+
+	level.Info(c.logger).Log("msg", "loaded disk cache", "entries", len(c.entries), "bytes", c.currSizeBytes)
+	return nil
+}
+
+func (c *DiskCache) runEviction(interval time.Duration) {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+		case <-c.evict:
+		}
+
+		c.lock.Lock()
+		c.evictEntries()
+		c.lock.Unlock()
+	}
+}
+
+// evictEntries evicts the expired entries, then the least recently used ones until the cache fits its size.
+func (c *DiskCache) evictEntries() {
+	if c.ttl > 0 {
+		for name, element := range c.entries {
+			if time.Since(element.Value.(*diskCacheEntry).stored) > c.ttl {
+				c.remove(name, element, expiredReason)
+			}
+		}
+	}
+
+	for c.currSizeBytes > c.maxSizeBytes {
+		element := c.lru.Back()
+		if element == nil {
+			break
+		}
+		c.remove(element.Value.(*diskCacheEntry).name, element, fullReason)
+	}
+}
+
+// remove removes the entry and its file. It must be called with the lock held.
+func (c *DiskCache) remove(name string, element *list.Element, reason string) {
+	entry := c.lru.Remove(element).(*diskCacheEntry)
+	delete(c.entries, name)
+	c.currSizeBytes -= entry.size
+	c.entriesCurrent.Dec()
+	c.entriesEvicted.WithLabelValues(reason).Inc()
+	c.diskBytes.Set(float64(c.currSizeBytes))
+
+	if err := os.Remove(c.path(name)); err != nil && !os.IsNotExist(err) {
+		level.Warn(c.logger).Log("msg", "failed to remove disk cache entry", "file", name, "err", err)
+	}
+}
+
+// Fetch implements Cache.
+func (c *DiskCache) Fetch(_ context.Context, keys []string) (found []string, bufs [][]byte, missing []string, err error) {
+	found, bufs, missing = make([]string, 0, len(keys)), make([][]byte, 0, len(keys)), make([]string, 0, len(keys))
+	for _, key := range keys {
+		buf, ok := c.get(key)
+		if !ok {
+			missing = append(missing, key)
+			continue
+		}
+
+		found = append(found, key)
+		bufs = append(bufs, buf)
+	}
+	return
+}
+
+func (c *DiskCache) get(key string) ([]byte, bool) {
+	name := HashKey(key)
+
+	c.lock.Lock()
+	element, ok := c.entries[name]
+	if ok {
+		if c.ttl > 0 && time.Since(element.Value.(*diskCacheEntry).stored) > c.ttl {
+			c.lock.Unlock()
+			return nil, false
+		}
+		c.lru.MoveToFront(element)
+	}
+	c.lock.Unlock()
+	if !ok {
+		return nil, false
+	}
+
+	// the file may have been evicted since.
+	b, err := os.ReadFile(c.path(name))
+	if err != nil {
+		return nil, false
+	}
+	storedKey, value, err := decodeDiskCacheEntry(b)
+	if err != nil {
+		level.Warn(c.logger).Log("msg", "removing corrupted disk cache entry", "file", name, "err", err)
+		c.lock.Lock()
+		if element, ok := c.entries[name]; ok {
+			c.remove(name, element, corruptedReason)
+		}
+		c.lock.Unlock()
+		return nil, false
+	}
+	// the keys share the file name of their hash.
+	if storedKey != key {
+		return nil, false
+	}
+	return value, true
+}
+
+// Store implements Cache.
+func (c *DiskCache) Store(_ context.Context, keys []string, bufs [][]byte) error {
+	var errs multierror.MultiError
+	for i := range keys {
+		if err := c.put(keys[i], bufs[i]); err != nil {
+			errs.Add(err)
+		}
+	}
+
+	c.lock.Lock()
+	full := c.currSizeBytes > c.maxSizeBytes
+	c.lock.Unlock()
+	if full {
+		select {
+		case c.evict <- struct{}{}:
+		default:
+		}
+	}
+
+	return errs.Err()
+}
+
+func (c *DiskCache) put(key string, value []byte) error {
+	b := encodeDiskCacheEntry(key, value)
+	if int64(len(b)) > c.maxSizeBytes {
+		c.entriesEvicted.WithLabelValues(tooBigReason).Inc()
+		return nil
+	}
+
+	name := HashKey(key)
+	tmp, err := os.CreateTemp(filepath.Join(c.dir, diskCacheTempDir), name)
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if err := os.MkdirAll(filepath.Join(c.dir, diskCacheShard(name)), 0o750); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), c.path(name)); err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	entry := &diskCacheEntry{name: name, size: int64(len(b)), stored: time.Now()}
+	if element, ok := c.entries[name]; ok {
+		c.currSizeBytes -= element.Value.(*diskCacheEntry).size
+		element.Value = entry
+		c.lru.MoveToFront(element)
+	} else {
+		c.entries[name] = c.lru.PushFront(entry)
+		c.entriesAddedNew.Inc()
+		c.entriesCurrent.Inc()
+	}
+	c.currSizeBytes += entry.size
+	c.diskBytes.Set(float64(c.currSizeBytes))
+	return nil
+}
+
+// Stop implements Cache. The entries are kept on disk for the next start.
+func (c *DiskCache) Stop() {
+	close(c.done)
+	c.wg.Wait()
+}
+
+func (c *DiskCache) GetCacheType() stats.CacheType {
+	return c.cacheType
+}
+
+func (c *DiskCache) path(name string) string {
+	return filepath.Join(c.dir, diskCacheShard(name), name)
+}
+
+// diskCacheShard spreads the files of the entries across subdirectories.
+func diskCacheShard(name string) string {
+	if len(name) < 2 {
+		return "00"
+	}
+	return name[:2]
+}
+
+// encodeDiskCacheEntry encodes the entry as the magic, the checksum of the rest, then the key and the value.
+func encodeDiskCacheEntry(key string, value []byte) []byte {
+	b := make([]byte, 0, len(diskCacheMagic)+crc32.Size+binary.MaxVarintLen64+len(key)+len(value))
+	b = append(b, diskCacheMagic...)
+	b = append(b, make([]byte, crc32.Size)...)
+	b = binary.AppendUvarint(b, uint64(len(key)))
+	b = append(b, key...)
+	b = append(b, value...)
+
+	checksumStart := len(diskCacheMagic)
+	binary.BigEndian.PutUint32(b[checksumStart:], crc32.Checksum(b[checksumStart+crc32.Size:], diskCacheCastagnoli))
+	return b
+}
+
+func decodeDiskCacheEntry(b []byte) (string, []byte, error) {
+	headerSize := len(diskCacheMagic) + crc32.Size
+	if len(b) < headerSize || string(b[:len(diskCacheMagic)]) != diskCacheMagic {
+		return "", nil, errDiskCacheEntryCorrupted
+	}
+	if binary.BigEndian.Uint32(b[len(diskCacheMagic):]) != crc32.Checksum(b[headerSize:], diskCacheCastagnoli) {
+		return "", nil, errDiskCacheEntryCorrupted
+	}
+
+	keyLen, n := binary.Uvarint(b[headerSize:])
+	if n <= 0 || uint64(len(b)-headerSize-n) < keyLen {
+		return "", nil, errDiskCacheEntryCorrupted
+	}
+	keyStart := headerSize + n
+	return string(b[keyStart : keyStart+int(keyLen)]), b[keyStart+int(keyLen):], nil
+}
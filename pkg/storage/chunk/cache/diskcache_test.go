@@ -0,0 +1,126 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDiskCache(t *testing.T, cfg DiskCacheConfig) *DiskCache {
+	c, err := NewDiskCache("test", cfg, nil, log.NewNopLogger(), "test")
+	require.NoError(t, err)
+	t.Cleanup(c.Stop)
+	return c
+}
+
+func TestDiskCache_Restart(t *testing.T) {
+	ctx := context.Background()
+	cfg := DiskCacheConfig{Directory: t.TempDir(), MaxSizeMB: 1, TTL: time.Hour}
+
+	c, err := NewDiskCache("test", cfg, nil, log.NewNopLogger(), "test")
+	require.NoError(t, err)
+	require.NoError(t, c.Store(ctx, []string{"a", "b"}, [][]byte{[]byte("1"), []byte("2")}))
+	// a write interrupted by a crash.
+	require.NoError(t, os.WriteFile(filepath.Join(cfg.Directory, "test", diskCacheTempDir, "partial"), []byte("x"), 0o600))
+	c.Stop()
+
+	c = newTestDiskCache(t, cfg)
+	found, bufs, missing, err := c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b"}, found)
+	require.Equal(t, [][]byte{[]byte("1"), []byte("2")}, bufs)
+	require.Equal(t, []string{"c"}, missing)
+	require.Equal(t, 2, len(c.entries))
+
+	entries, err := os.ReadDir(filepath.Join(cfg.Directory, "test", diskCacheTempDir))
+	require.NoError(t, err)
+	require.Empty(t, entries)
+}
+
+func TestDiskCache_Corrupted(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, DiskCacheConfig{Directory: t.TempDir(), MaxSizeMB: 1, TTL: time.Hour})
+	require.NoError(t, c.Store(ctx, []string{"a"}, [][]byte{[]byte("value")}))
+
+	path := c.path(HashKey("a"))
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	b[len(b)-1] ^= 0xff
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	_, _, missing, err := c.Fetch(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, missing)
+	require.Equal(t, float64(1), testutil.ToFloat64(c.entriesEvicted.WithLabelValues(corruptedReason)))
+	require.NoFileExists(t, path)
+	require.Empty(t, c.entries)
+}
+
+func TestDiskCache_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, DiskCacheConfig{Directory: t.TempDir(), MaxSizeMB: 1, TTL: time.Hour})
+	value := make([]byte, 400e3)
+
+	require.NoError(t, c.Store(ctx, []string{"a", "b"}, [][]byte{value, value}))
+	// a is used more recently than b.
+	found, _, _, err := c.Fetch(ctx, []string{"a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a"}, found)
+
+	require.NoError(t, c.Store(ctx, []string{"c"}, [][]byte{value}))
+	require.Eventually(t, func() bool {
+		c.lock.Lock()
+		defer c.lock.Unlock()
+		return c.currSizeBytes <= c.maxSizeBytes
+	}, 5*time.Second, 10*time.Millisecond)
+
+	found, _, missing, err := c.Fetch(ctx, []string{"a", "b", "c"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "c"}, found)
+	require.Equal(t, []string{"b"}, missing)
+	require.Equal(t, float64(1), testutil.ToFloat64(c.entriesEvicted.WithLabelValues(fullReason)))
+
+	// the entries larger than the cache are not stored.
+	require.NoError(t, c.Store(ctx, []string{"d"}, [][]byte{make([]byte, 2e6)}))
+	_, _, missing, err = c.Fetch(ctx, []string{"d"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"d"}, missing)
+}
+
+func TestDiskCache_Expired(t *testing.T) {
+	ctx := context.Background()
+	c := newTestDiskCache(t, DiskCacheConfig{Directory: t.TempDir(), MaxSizeMB: 1, TTL: time.Hour})
+	require.NoError(t, c.Store(ctx, []string{"a", "b"}, [][]byte{[]byte("1"), []byte("2")}))
+
+	c.lock.Lock()
+	c.entries[HashKey("a")].Value.(*diskCacheEntry).stored = time.Now().Add(-2 * time.Hour)
+	c.lock.Unlock()
+
+	found, _, _, err := c.Fetch(ctx, []string{"a", "b"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"b"}, found)
+
+	c.lock.Lock()
+	c.evictEntries()
+	c.lock.Unlock()
+	require.NoFileExists(t, c.path(HashKey("a")))
+	require.FileExists(t, c.path(HashKey("b")))
+}
+
+func TestDecodeDiskCacheEntry(t *testing.T) {
+	key, value, err := decodeDiskCacheEntry(encodeDiskCacheEntry("key", []byte("value")))
+	require.NoError(t, err)
+	require.Equal(t, "key", key)
+	require.Equal(t, []byte("value"), value)
+
+	for _, b := range [][]byte{nil, []byte("LDC1"), []byte("XXXX0000"), encodeDiskCacheEntry("key", nil)[:9]} {
+		_, _, err := decodeDiskCacheEntry(b)
+		require.ErrorIs(t, err, errDiskCacheEntryCorrupted)
+	}
+}
@@ -8,7 +8,9 @@ import (
 
 type tiered []Cache
 
-// NewTiered makes a new tiered cache.
+// NewTiered makes a new tiered cache. The caches are ordered from the fastest to the slowest, for example
+// the embedded cache, then the disk cache, then memcached or redis: the keys missing from a cache are fetched
+// from the next ones, and the entries found there are stored back into the faster caches.
 func NewTiered(caches []Cache) Cache {
 	if len(caches) == 1 {
 		return caches[0]
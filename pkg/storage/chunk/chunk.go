@@ -50,6 +50,12 @@ type Chunk struct {
 	Encoding Encoding `json:"encoding"`
 	Data     Data     `json:"-"`
 
+	// ContentAddressed is true when the checksum of the chunk is the hash of its entries rather than the checksum of
+	// its encoding, see EncodeContentAddressed.
+	ContentAddressed bool `json:"content_addressed,omitempty"`
+	// DataChecksum is the checksum of the encoded data of content addressed chunks, verified when they are decoded.
+	DataChecksum uint32 `json:"data_checksum,omitempty"`
+
 	// The encoded version of the chunk, held so we don't need to re-encode it
 	encoded []byte
 }
@@ -230,11 +236,41 @@ func (c *Chunk) Encode() error {
 	return c.EncodeTo(nil, util_log.Logger)
 }
 
+// EncodeContentAddressed is like Encode but the checksum of the chunk is the hash of its entries, so that the chunks
+// of the same entries flushed by several replicas get the same key even when they are encoded differently.
+func (c *Chunk) EncodeContentAddressed() error {
+	c.ContentAddressed = true
+	c.encoded = nil
+	return c.Encode()
+}
+
 // EncodeTo is like Encode but you can provide your own buffer to use.
 func (c *Chunk) EncodeTo(buf *bytes.Buffer, log log.Logger) error {
 	if buf == nil {
 		buf = bytes.NewBuffer(nil)
 	}
+
+	// The checksum of content addressed chunks is the hash of their entries, so the checksum of their data is
+	// written in their metadata.
+	var data *bytes.Buffer
+	if c.ContentAddressed {
+		hasher, ok := c.Data.(ContentHasher)
+		if !ok {
+			return errors.Errorf("chunk encoding %s can't be content addressed", c.Encoding)
+		}
+		checksum, err := hasher.ContentHash()
+		if err != nil {
+			return err
+		}
+		c.Checksum = checksum
+
+		data = bytes.NewBuffer(nil)
+		if err := c.Data.Marshal(data); err != nil {
+			return err
+		}
+		c.DataChecksum = crc32.Checksum(data.Bytes(), castagnoliTable)
+	}
+
 	// Write 4 empty bytes first - we will come back and put the len in here.
 	metadataLenBytes := [4]byte{}
 	if _, err := buf.Write(metadataLenBytes[:]); err != nil {
@@ -264,7 +300,11 @@ func (c *Chunk) EncodeTo(buf *bytes.Buffer, log log.Logger) error {
 	}
 
 	// And now the chunk data
-	if err := c.Data.Marshal(buf); err != nil {
+	if data != nil {
+		if _, err := buf.Write(data.Bytes()); err != nil {
+			return err
+		}
+	} else if err := c.Data.Marshal(buf); err != nil {
 		return err
 	}
 
@@ -274,7 +314,9 @@ func (c *Chunk) EncodeTo(buf *bytes.Buffer, log log.Logger) error {
 
 	// Now work out the checksum
 	c.encoded = buf.Bytes()
-	c.Checksum = crc32.Checksum(c.encoded, castagnoliTable)
+	if !c.ContentAddressed {
+		c.Checksum = crc32.Checksum(c.encoded, castagnoliTable)
+	}
 
 	newCh := Chunk{
 		ChunkRef: logproto.ChunkRef{
@@ -330,21 +372,27 @@ func NewDecodeContext() *DecodeContext {
 // expected.
 func (c *Chunk) Decode(decodeContext *DecodeContext, input []byte) error {
 	// First, calculate the checksum of the chunk and confirm it matches
-	// what we expected.
-	if c.Checksum != crc32.Checksum(input, castagnoliTable) {
-		return errors.WithStack(ErrInvalidChecksum)
-	}
+	// what we expected. The checksum of content addressed chunks is the
+	// hash of their entries, their data is verified by the checksum in
+	// their metadata instead.
+	checksumMatches := c.Checksum == crc32.Checksum(input, castagnoliTable)
 
 	// Now unmarshal the chunk metadata.
 	r := bytes.NewReader(input)
 	var metadataLen uint32
 	if err := binary.Read(r, binary.BigEndian, &metadataLen); err != nil {
+		if !checksumMatches {
+			return errors.WithStack(ErrInvalidChecksum)
+		}
 		return errors.Wrap(err, "when reading metadata length from chunk")
 	}
 	var tempMetadata Chunk
 	decodeContext.reader.Reset(r)
 	json := jsoniter.ConfigFastest
 	err := json.NewDecoder(decodeContext.reader).Decode(&tempMetadata)
+	if !checksumMatches && (err != nil || !tempMetadata.ContentAddressed) {
+		return errors.WithStack(ErrInvalidChecksum)
+	}
 	if err != nil {
 		return errors.Wrap(err, "when decoding chunk metadata")
 	}
@@ -370,6 +418,9 @@ func (c *Chunk) Decode(decodeContext *DecodeContext, input []byte) error {
 	if err != nil {
 		return errors.Wrap(err, "when creating new chunk")
 	}
+	if data, ok := c.Data.(TenantData); ok {
+		data.SetUserID(c.UserID)
+	}
 
 	var dataLen uint32
 	if err := binary.Read(r, binary.BigEndian, &dataLen); err != nil {
@@ -381,10 +432,91 @@ func (c *Chunk) Decode(decodeContext *DecodeContext, input []byte) error {
 	if int(dataLen) != len(remainingData) {
 		return ErrDataLength
 	}
+	if !checksumMatches && c.DataChecksum != crc32.Checksum(remainingData, castagnoliTable) {
+		return errors.WithStack(ErrInvalidChecksum)
+	}
 
 	return c.Data.UnmarshalFromBuf(remainingData[:int(dataLen)])
 }
 
+// chunkHeadReadSize is the size of the start of an encoded chunk first read by DecodeRange, which usually holds its
+// metadata.
+const chunkHeadReadSize = 16 << 10
+
+// DecodeRange is like Decode but reads the encoded chunk with the given ranged reads, and only reads the blocks of
+// its data overlapping [from, through] when the data supports it. As the checksum of the whole chunk can't be
+// verified then, the data is verified by the checksums of its parts. read may return fewer bytes than requested
+// past the end of the encoded chunk.
+func (c *Chunk) DecodeRange(decodeContext *DecodeContext, read func(offset, length int) ([]byte, error), from, through model.Time) error {
+	head, err := read(0, chunkHeadReadSize)
+	if err != nil {
+		return err
+	}
+	if len(head) < chunkHeadReadSize {
+		// the whole chunk is read already.
+		return c.Decode(decodeContext, head)
+	}
+
+	// the metadata is followed by the data length, and older versions don't include the initial length word in
+	// the metadata length.
+	metadataLen := int(binary.BigEndian.Uint32(head))
+	if len(head) < metadataLen+8 {
+		rest, err := read(len(head), metadataLen+8-len(head))
+		if err != nil {
+			return err
+		}
+		head = append(head, rest...)
+	}
+
+	r := bytes.NewReader(head[4:])
+	var tempMetadata Chunk
+	decodeContext.reader.Reset(r)
+	json := jsoniter.ConfigFastest
+	if err := json.NewDecoder(decodeContext.reader).Decode(&tempMetadata); err != nil {
+		return errors.Wrap(err, "when decoding chunk metadata")
+	}
+	metadataRead := len(head) - r.Len()
+	if !(metadataRead == metadataLen || metadataRead == metadataLen+4) {
+		return errors.Wrapf(ErrMetadataLength, "expected %d, got %d", metadataLen, metadataRead)
+	}
+	tempMetadata.Checksum = c.Checksum
+	if !equalByKey(*c, tempMetadata) {
+		return errors.WithStack(ErrWrongMetadata)
+	}
+
+	var dataLen uint32
+	if err := binary.Read(r, binary.BigEndian, &dataLen); err != nil {
+		return errors.Wrap(err, "when reading data length from chunk")
+	}
+	dataOffset := len(head) - r.Len()
+
+	data, err := NewForEncoding(tempMetadata.Encoding)
+	if err != nil {
+		return errors.Wrap(err, "when creating new chunk")
+	}
+	if tenantData, ok := data.(TenantData); ok {
+		tenantData.SetUserID(tempMetadata.UserID)
+	}
+	rangeData, ok := data.(RangeData)
+	if !ok {
+		rest, err := read(len(head), dataOffset+int(dataLen)-len(head))
+		if err != nil {
+			return err
+		}
+		return c.Decode(decodeContext, append(head, rest...))
+	}
+
+	*c = tempMetadata
+	c.Data = data
+	return rangeData.UnmarshalRange(int(dataLen), func(offset, length int) ([]byte, error) {
+		offset += dataOffset
+		if offset+length <= len(head) {
+			return head[offset : offset+length], nil
+		}
+		return read(offset, length)
+	}, from, through)
+}
+
 func equalByKey(a, b Chunk) bool {
 	return a.UserID == b.UserID && a.Fingerprint == b.Fingerprint &&
 		a.From == b.From && a.Through == b.Through && a.Checksum == b.Checksum
@@ -4,6 +4,8 @@ import (
 	"context"
 	"errors"
 
+	"github.com/prometheus/common/model"
+
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/storage/stores/series/index"
 )
@@ -25,6 +27,34 @@ type Client interface {
 	IsRetryableErr(err error) bool
 }
 
+// RangeClient is implemented by the clients which can fetch the blocks of chunks overlapping a time range only.
+// The fetched chunks can only be iterated within that range.
+type RangeClient interface {
+	GetChunksInRange(ctx context.Context, chunks []chunk.Chunk, from, through model.Time) ([]chunk.Chunk, error)
+}
+
+// GetChunksInRange fetches the blocks of the chunks overlapping [from, through] if the client supports it, or the whole
+// chunks otherwise.
+func GetChunksInRange(ctx context.Context, c Client, chunks []chunk.Chunk, from, through model.Time) ([]chunk.Chunk, error) {
+	if rc, ok := c.(RangeClient); ok {
+		return rc.GetChunksInRange(ctx, chunks, from, through)
+	}
+	return c.GetChunks(ctx, chunks)
+}
+
+// ExistsClient is implemented by the clients which can check whether a chunk is stored without fetching it.
+type ExistsClient interface {
+	ChunkExists(ctx context.Context, chk chunk.Chunk) (bool, error)
+}
+
+// ChunkExists checks whether the chunk is stored, or returns ErrMethodNotImplemented if the client can't check it.
+func ChunkExists(ctx context.Context, c Client, chk chunk.Chunk) (bool, error) {
+	if ec, ok := c.(ExistsClient); ok {
+		return ec.ChunkExists(ctx, chk)
+	}
+	return false, ErrMethodNotImplemented
+}
+
 // ObjectAndIndexClient allows optimisations where the same client handles both
 // Only used by DynamoDB (dynamodbIndexReader and dynamoDBStorageClient)
 type ObjectAndIndexClient interface {
@@ -198,6 +198,11 @@ func (a *AIMDController) multiplicativeDecrease() {
 	a.updateLimitMetric()
 }
 
+// Congested reports whether the requests are waiting for the rate-limit to replenish.
+func (a *AIMDController) Congested() bool {
+	return a.limiter.Tokens() < 1
+}
+
 func (a *AIMDController) updateLimitMetric() {
 	a.metrics.currentLimit.Set(float64(a.limiter.Limit()))
 }
@@ -236,6 +241,7 @@ func (n *NoopController) IsObjectNotFoundErr(error) bool                 { retur
 func (n *NoopController) IsRetryableErr(error) bool                      { return false }
 func (n *NoopController) Stop()                                          {}
 func (n *NoopController) Wrap(c client.ObjectClient) client.ObjectClient { return c }
+func (n *NoopController) Congested() bool                                { return false }
 
 func (n *NoopController) withLogger(logger log.Logger) Controller {
 	n.logger = logger
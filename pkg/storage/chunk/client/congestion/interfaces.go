@@ -20,6 +20,9 @@ type Controller interface {
 	// Wrap wraps a given object store client and handles congestion against its backend service
 	Wrap(client client.ObjectClient) client.ObjectClient
 
+	// Congested reports whether requests to the backend service are currently throttled
+	Congested() bool
+
 	withLogger(log.Logger) Controller
 	withRetrier(Retrier) Controller
 	withHedger(Hedger) Controller
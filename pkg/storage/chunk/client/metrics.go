@@ -5,6 +5,7 @@ import (
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/util/constants"
@@ -90,6 +91,21 @@ func (c MetricsChunkClient) PutChunks(ctx context.Context, chunks []chunk.Chunk)
 
 func (c MetricsChunkClient) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
 	chks, err := c.Client.GetChunks(ctx, chunks)
+	return c.observeFetched(chks, err)
+}
+
+// GetChunksInRange implements RangeClient.
+func (c MetricsChunkClient) GetChunksInRange(ctx context.Context, chunks []chunk.Chunk, from, through model.Time) ([]chunk.Chunk, error) {
+	chks, err := GetChunksInRange(ctx, c.Client, chunks, from, through)
+	return c.observeFetched(chks, err)
+}
+
+// ChunkExists implements ExistsClient.
+func (c MetricsChunkClient) ChunkExists(ctx context.Context, chk chunk.Chunk) (bool, error) {
+	return ChunkExists(ctx, c.Client, chk)
+}
+
+func (c MetricsChunkClient) observeFetched(chks []chunk.Chunk, err error) ([]chunk.Chunk, error) {
 	if err != nil {
 		// Get chunks fetches chunks in parallel, and returns any error. As a result we don't know which chunk failed,
 		// so we increment the metric for all tenants with chunks in the request. I think in practice we're only ever
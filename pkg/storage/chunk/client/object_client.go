@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/pkg/errors"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
@@ -210,6 +211,66 @@ func (o *client) getChunk(ctx context.Context, decodeContext *chunk.DecodeContex
 	return c, nil
 }
 
+// GetChunksInRange implements RangeClient by reading the chunks with ranged reads.
+func (o *client) GetChunksInRange(ctx context.Context, chunks []chunk.Chunk, from, through model.Time) ([]chunk.Chunk, error) {
+	getChunkMaxParallel := o.getChunkMaxParallel
+	if getChunkMaxParallel == 0 {
+		getChunkMaxParallel = defaultMaxParallel
+	}
+	return util.GetParallelChunks(ctx, getChunkMaxParallel, chunks, func(ctx context.Context, decodeContext *chunk.DecodeContext, c chunk.Chunk) (chunk.Chunk, error) {
+		return o.getChunkInRange(ctx, decodeContext, c, from, through)
+	})
+}
+
+func (o *client) getChunkInRange(ctx context.Context, decodeContext *chunk.DecodeContext, c chunk.Chunk, from, through model.Time) (chunk.Chunk, error) {
+	if ctx.Err() != nil {
+		return chunk.Chunk{}, ctx.Err()
+	}
+
+	key := o.schema.ExternalKey(c.ChunkRef)
+	if o.keyEncoder != nil {
+		key = o.keyEncoder(o.schema, c)
+	}
+
+	read := func(offset, length int) ([]byte, error) {
+		readCloser, err := o.store.GetObjectRange(ctx, key, int64(offset), int64(length))
+		if err != nil {
+			return nil, errors.WithStack(errors.Wrapf(err, "failed to load chunk '%s'", key))
+		}
+		if readCloser == nil {
+			return nil, errors.New("object client getChunkInRange fail because object is nil")
+		}
+		defer readCloser.Close()
+
+		buf := bytes.NewBuffer(make([]byte, 0, length+bytes.MinRead))
+		if _, err := buf.ReadFrom(io.LimitReader(readCloser, int64(length))); err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return buf.Bytes(), nil
+	}
+
+	if err := c.DecodeRange(decodeContext, read, from, through); err != nil {
+		return chunk.Chunk{}, errors.WithStack(
+			fmt.Errorf(
+				"failed to decode chunk '%s' for tenant `%s`: %w",
+				key,
+				c.ChunkRef.UserID,
+				err,
+			),
+		)
+	}
+	return c, nil
+}
+
+// ChunkExists implements ExistsClient with a HEAD request of the chunk object.
+func (o *client) ChunkExists(ctx context.Context, c chunk.Chunk) (bool, error) {
+	key := o.schema.ExternalKey(c.ChunkRef)
+	if o.keyEncoder != nil {
+		key = o.keyEncoder(o.schema, c)
+	}
+	return o.store.ObjectExists(ctx, key)
+}
+
 // GetChunks retrieves the specified chunks from the configured backend
 func (o *client) DeleteChunk(ctx context.Context, userID, chunkID string) error {
 	key := chunkID
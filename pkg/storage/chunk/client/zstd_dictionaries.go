@@ -0,0 +1,81 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+)
+
+const (
+	// zstdDictionariesDir is the directory of the trained zstd dictionaries of a tenant in the object stores.
+	zstdDictionariesDir = "zstd_dictionaries/"
+	// maxZstdDictionaryIDAttempts is the number of IDs tried when storing a dictionary whose ID is already used.
+	maxZstdDictionaryIDAttempts = 8
+)
+
+// ZstdDictionariesPrefix returns the prefix of the zstd dictionaries of the tenant in the object stores. The
+// dictionaries are stored with the chunks of their tenant, as they are made of samples of its lines.
+func ZstdDictionariesPrefix(tenant string) string {
+	return tenant + "/" + zstdDictionariesDir
+}
+
+// ZstdDictionaryObjectKey returns the key of the zstd dictionary of the tenant with the given ID in the object stores.
+func ZstdDictionaryObjectKey(tenant string, id uint32) string {
+	return fmt.Sprintf("%s%08x", ZstdDictionariesPrefix(tenant), id)
+}
+
+// PutZstdDictionary stores the dictionary of the tenant and returns the stored dictionary. The IDs of the dictionaries
+// are derived from their content, so when the ID is already used by another dictionary of the tenant, the dictionary
+// is stored under the next free ID instead. A stored dictionary is never overwritten by another one.
+func PutZstdDictionary(ctx context.Context, objectClient ObjectClient, tenant string, dict []byte) ([]byte, error) {
+	pool, err := compression.NewZstdDictPool(dict)
+	if err != nil {
+		return nil, err
+	}
+
+	id := pool.ID()
+	for attempt := 0; attempt < maxZstdDictionaryIDAttempts; attempt++ {
+		key := ZstdDictionaryObjectKey(tenant, id)
+		stored, err := GetZstdDictionary(ctx, objectClient, key)
+		switch {
+		case err == nil && bytes.Equal(stored, dict):
+			return dict, nil
+		case err != nil && !objectClient.IsObjectNotFoundErr(err):
+			return nil, err
+		case err != nil:
+			if err := objectClient.PutObject(ctx, key, bytes.NewReader(dict)); err != nil {
+				return nil, err
+			}
+			// another writer may have stored a dictionary with the same ID meanwhile.
+			stored, err := GetZstdDictionary(ctx, objectClient, key)
+			if err != nil {
+				return nil, err
+			}
+			if bytes.Equal(stored, dict) {
+				return dict, nil
+			}
+		}
+
+		id++
+		if id == 0 {
+			id = 1
+		}
+		if dict, err = compression.WithZstdDictionaryID(dict, id); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("no free zstd dictionary ID for tenant %s after %d attempts", tenant, maxZstdDictionaryIDAttempts)
+}
+
+// GetZstdDictionary returns the content of the zstd dictionary stored under the key.
+func GetZstdDictionary(ctx context.Context, objectClient ObjectClient, key string) ([]byte, error) {
+	rc, _, err := objectClient.GetObject(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
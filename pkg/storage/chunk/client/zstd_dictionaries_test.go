@@ -0,0 +1,62 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+)
+
+func TestPutZstdDictionary(t *testing.T) {
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+
+	train := func(prefix string) ([]byte, *compression.ZstdDictPool) {
+		samples := make([][]byte, 0, 500)
+		for i := 0; i < 500; i++ {
+			samples = append(samples, []byte(fmt.Sprintf("%s level=info msg=\"request served\" path=/api/v1/items/%d duration=%dms", prefix, i, i%7)))
+		}
+		dict, err := compression.TrainZstdDictionary(samples, 2<<10)
+		require.NoError(t, err)
+		pool, err := compression.NewZstdDictPool(dict)
+		require.NoError(t, err)
+		return dict, pool
+	}
+
+	dict, pool := train("a")
+	stored, err := client.PutZstdDictionary(context.Background(), objectClient, "tenant-a", dict)
+	require.NoError(t, err)
+	require.Equal(t, dict, stored)
+
+	// storing the same dictionary again is a no-op.
+	stored, err = client.PutZstdDictionary(context.Background(), objectClient, "tenant-a", dict)
+	require.NoError(t, err)
+	require.Equal(t, dict, stored)
+
+	// another dictionary with the same ID is stored under the next ID, without overwriting the stored one.
+	other, _ := train("b")
+	other, err = compression.WithZstdDictionaryID(other, pool.ID())
+	require.NoError(t, err)
+	stored, err = client.PutZstdDictionary(context.Background(), objectClient, "tenant-a", other)
+	require.NoError(t, err)
+	storedPool, err := compression.NewZstdDictPool(stored)
+	require.NoError(t, err)
+	require.Equal(t, pool.ID()+1, storedPool.ID())
+
+	first, err := client.GetZstdDictionary(context.Background(), objectClient, client.ZstdDictionaryObjectKey("tenant-a", pool.ID()))
+	require.NoError(t, err)
+	require.Equal(t, dict, first)
+	second, err := client.GetZstdDictionary(context.Background(), objectClient, client.ZstdDictionaryObjectKey("tenant-a", pool.ID()+1))
+	require.NoError(t, err)
+	require.Equal(t, stored, second)
+
+	// the IDs are only unique among the dictionaries of a tenant.
+	stored, err = client.PutZstdDictionary(context.Background(), objectClient, "tenant-b", other)
+	require.NoError(t, err)
+	require.Equal(t, other, stored)
+}
@@ -9,6 +9,7 @@ import (
 	"github.com/opentracing/opentracing-go"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
@@ -124,6 +125,21 @@ func (c *Fetcher) Client() client.Client {
 
 // FetchChunks fetches a set of chunks from cache and store. Note, returned chunks are not in the same order they are passed in
 func (c *Fetcher) FetchChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	return c.fetchChunks(ctx, chunks, nil)
+}
+
+// FetchChunksInRange is like FetchChunks but only fetches the blocks overlapping [from, through] of the chunks
+// missing from the cache which are not within that range, when the store supports it. Those chunks can only be
+// iterated within that range and are not written back to the cache.
+func (c *Fetcher) FetchChunksInRange(ctx context.Context, chunks []chunk.Chunk, from, through model.Time) ([]chunk.Chunk, error) {
+	return c.fetchChunks(ctx, chunks, &timeRange{from: from, through: through})
+}
+
+type timeRange struct {
+	from, through model.Time
+}
+
+func (c *Fetcher) fetchChunks(ctx context.Context, chunks []chunk.Chunk, bounds *timeRange) ([]chunk.Chunk, error) {
 	if ctx.Err() != nil {
 		return nil, ctx.Err()
 	}
@@ -195,8 +211,26 @@ func (c *Fetcher) FetchChunks(ctx context.Context, chunks []chunk.Chunk) ([]chun
 		level.Warn(log).Log("msg", "error process response from cache", "err", err)
 	}
 
-	// Fetch missing from storage
-	var fromStorage []chunk.Chunk
+	// Fetch missing from storage, with ranged reads of the chunks extending past the bounds.
+	var fromStorage, inRange []chunk.Chunk
+	if _, ok := c.storage.(client.RangeClient); ok && bounds != nil {
+		var whole, partial []chunk.Chunk
+		for _, m := range missing {
+			if m.From >= bounds.from && m.Through <= bounds.through {
+				whole = append(whole, m)
+			} else {
+				partial = append(partial, m)
+			}
+		}
+		missing = whole
+		if len(partial) > 0 {
+			var rangeErr error
+			inRange, rangeErr = client.GetChunksInRange(ctx, c.storage, partial, bounds.from, bounds.through)
+			if rangeErr != nil {
+				level.Error(log).Log("msg", "failed downloading chunks in range", "err", rangeErr)
+			}
+		}
+	}
 	if len(missing) > 0 {
 		fromStorage, err = c.storage.GetChunks(ctx, missing)
 	}
@@ -225,7 +259,12 @@ func (c *Fetcher) FetchChunks(ctx context.Context, chunks []chunk.Chunk) ([]chun
 		level.Error(log).Log("msg", "failed downloading chunks", "err", err)
 	}
 
+	for _, c := range inRange {
+		chunkFetchedSize.WithLabelValues("store_range").Observe(float64(c.Data.Size()))
+	}
+
 	allChunks := append(fromCache, fromStorage...)
+	allChunks = append(allChunks, inRange...)
 	return allChunks, nil
 }
 
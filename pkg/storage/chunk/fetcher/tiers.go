@@ -0,0 +1,177 @@
+package fetcher
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/common/model"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+// TierLimits provides the per-tenant overrides of the storage tier delays.
+type TierLimits interface {
+	StorageTierAfter(userID, objectStore string) time.Duration
+}
+
+// StorageTierFor returns the index of the storage tier of the period holding the
+// chunks of the user ending at through, or -1 for the object_store of the period.
+func StorageTierFor(period config.PeriodConfig, limits TierLimits, userID string, through model.Time, now time.Time) int {
+	return period.StorageTierFor(now.Sub(through.Time()), func(t config.StorageTier) time.Duration {
+		if limits != nil {
+			if after := limits.StorageTierAfter(userID, t.ObjectType); after > 0 {
+				return after
+			}
+		}
+		return time.Duration(t.After)
+	})
+}
+
+// tieredClient reads chunks from the storage tier matching their age, falling back
+// to the other tiers for chunks which were not moved yet or were moved with another
+// policy. New chunks are always written to the object_store of the period.
+type tieredClient struct {
+	schema config.SchemaConfig
+	period config.PeriodConfig
+	limits TierLimits
+
+	// clients[0] is the client of the object_store of the period and
+	// clients[i+1] the client of the storage tier i.
+	clients []client.Client
+
+	now func() time.Time
+}
+
+// NewTieredClient returns a client resolving the storage tiers of the period.
+// tiers must have a client for each of the storage tiers of the period, in order.
+func NewTieredClient(schema config.SchemaConfig, period config.PeriodConfig, primary client.Client, tiers []client.Client, limits TierLimits) client.Client {
+	return &tieredClient{
+		schema:  schema,
+		period:  period,
+		limits:  limits,
+		clients: append([]client.Client{primary}, tiers...),
+		now:     time.Now,
+	}
+}
+
+func (c *tieredClient) Stop() {
+	for _, cl := range c.clients {
+		cl.Stop()
+	}
+}
+
+func (c *tieredClient) PutChunks(ctx context.Context, chunks []chunk.Chunk) error {
+	return c.clients[0].PutChunks(ctx, chunks)
+}
+
+func (c *tieredClient) GetChunks(ctx context.Context, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+	return c.getChunks(chunks, func(cl client.Client, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+		return cl.GetChunks(ctx, chunks)
+	})
+}
+
+// GetChunksInRange implements client.RangeClient.
+func (c *tieredClient) GetChunksInRange(ctx context.Context, chunks []chunk.Chunk, from, through model.Time) ([]chunk.Chunk, error) {
+	return c.getChunks(chunks, func(cl client.Client, chunks []chunk.Chunk) ([]chunk.Chunk, error) {
+		return client.GetChunksInRange(ctx, cl, chunks, from, through)
+	})
+}
+
+// ChunkExists implements client.ExistsClient. Chunks are written to the object_store of the period, so only it is
+// checked.
+func (c *tieredClient) ChunkExists(ctx context.Context, chk chunk.Chunk) (bool, error) {
+	return client.ChunkExists(ctx, c.clients[0], chk)
+}
+
+func (c *tieredClient) getChunks(chunks []chunk.Chunk, get func(client.Client, []chunk.Chunk) ([]chunk.Chunk, error)) ([]chunk.Chunk, error) {
+	now := c.now()
+	byLocation := make([][]chunk.Chunk, len(c.clients))
+	for _, chk := range chunks {
+		location := StorageTierFor(c.period, c.limits, chk.UserID, chk.Through, now) + 1
+		byLocation[location] = append(byLocation[location], chk)
+	}
+
+	result := make([]chunk.Chunk, 0, len(chunks))
+	for location, missing := range byLocation {
+		var err error
+		for _, next := range c.fallbackOrder(location) {
+			if len(missing) == 0 {
+				break
+			}
+
+			var fetched []chunk.Chunk
+			fetched, err = get(c.clients[next], missing)
+			result = append(result, fetched...)
+			if err == nil {
+				missing = nil
+				break
+			}
+			if !c.clients[next].IsChunkNotFoundErr(err) {
+				return result, err
+			}
+			missing = c.notFetched(missing, fetched)
+		}
+		if len(missing) > 0 {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+// fallbackOrder returns the locations to look for a chunk expected in the given
+// location: the expected one, then the lower tiers down to the object_store of the
+// period and finally the higher tiers.
+func (c *tieredClient) fallbackOrder(location int) []int {
+	order := make([]int, 0, len(c.clients))
+	for i := location; i >= 0; i-- {
+		order = append(order, i)
+	}
+	for i := location + 1; i < len(c.clients); i++ {
+		order = append(order, i)
+	}
+	return order
+}
+
+func (c *tieredClient) notFetched(chunks, fetched []chunk.Chunk) []chunk.Chunk {
+	keys := make(map[string]struct{}, len(fetched))
+	for _, chk := range fetched {
+		keys[c.schema.ExternalKey(chk.ChunkRef)] = struct{}{}
+	}
+	missing := make([]chunk.Chunk, 0, len(chunks)-len(fetched))
+	for _, chk := range chunks {
+		if _, ok := keys[c.schema.ExternalKey(chk.ChunkRef)]; !ok {
+			missing = append(missing, chk)
+		}
+	}
+	return missing
+}
+
+// DeleteChunk deletes the chunk from all the tiers since it may be in any of them.
+func (c *tieredClient) DeleteChunk(ctx context.Context, userID, chunkID string) error {
+	for _, cl := range c.clients {
+		if err := cl.DeleteChunk(ctx, userID, chunkID); err != nil && !cl.IsChunkNotFoundErr(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *tieredClient) IsChunkNotFoundErr(err error) bool {
+	for _, cl := range c.clients {
+		if cl.IsChunkNotFoundErr(err) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *tieredClient) IsRetryableErr(err error) bool {
+	for _, cl := range c.clients {
+		if cl.IsRetryableErr(err) {
+			return true
+		}
+	}
+	return false
+}
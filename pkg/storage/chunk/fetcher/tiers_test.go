@@ -0,0 +1,90 @@
+package fetcher
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+type fakeTierLimits map[string]time.Duration
+
+func (l fakeTierLimits) StorageTierAfter(_, objectStore string) time.Duration {
+	return l[objectStore]
+}
+
+func TestTieredClient(t *testing.T) {
+	now := time.Now()
+	sc := config.SchemaConfig{Configs: testutils.NewMockStorage().GetSchemaConfigs()}
+	period := config.PeriodConfig{
+		ObjectType: "hot",
+		StorageTiers: []config.StorageTier{
+			{After: model.Duration(24 * time.Hour), ObjectType: "warm"},
+			{After: model.Duration(72 * time.Hour), ObjectType: "cold"},
+		},
+	}
+
+	var (
+		hot  = client.NewClient(testutils.NewInMemoryObjectClient(), nil, sc)
+		warm = client.NewClient(testutils.NewInMemoryObjectClient(), nil, sc)
+		cold = client.NewClient(testutils.NewInMemoryObjectClient(), nil, sc)
+
+		recent   = makeChunks(now, c{2 * time.Hour, time.Hour})
+		old      = makeChunks(now, c{49 * time.Hour, 48 * time.Hour})
+		notMoved = makeChunks(now, c{97 * time.Hour, 96 * time.Hour})
+		overrode = makeChunks(now, c{13 * time.Hour, 12 * time.Hour})
+	)
+	require.NoError(t, hot.PutChunks(context.Background(), recent))
+	require.NoError(t, warm.PutChunks(context.Background(), old))
+	// chunks not moved yet to the cold tier are found in the warm one.
+	require.NoError(t, warm.PutChunks(context.Background(), notMoved))
+	// chunks moved to the cold tier with a tenant override are found as well.
+	require.NoError(t, cold.PutChunks(context.Background(), overrode))
+
+	tiered := NewTieredClient(sc, period, hot, []client.Client{warm, cold}, fakeTierLimits{})
+	tiered.(*tieredClient).now = func() time.Time { return now }
+
+	fetch := append(append(append(append([]chunk.Chunk{}, recent...), old...), notMoved...), overrode...)
+	chks, err := tiered.GetChunks(context.Background(), fetch)
+	require.NoError(t, err)
+	assertChunks(t, fetch, chks)
+
+	// new chunks are written to the object_store of the period.
+	fresh := makeChunks(now, c{3 * time.Hour, 2 * time.Hour})
+	require.NoError(t, tiered.PutChunks(context.Background(), fresh))
+	chks, err = hot.GetChunks(context.Background(), fresh)
+	require.NoError(t, err)
+	assertChunks(t, fresh, chks)
+
+	// chunks missing from all the tiers are reported as not found.
+	missing := makeChunks(now, c{50 * time.Hour, 49 * time.Hour})
+	_, err = tiered.GetChunks(context.Background(), missing)
+	require.True(t, tiered.IsChunkNotFoundErr(err))
+
+	// deletes remove the chunk from whichever tier holds it.
+	require.NoError(t, tiered.DeleteChunk(context.Background(), "fake", sc.ExternalKey(notMoved[0].ChunkRef)))
+	_, err = warm.GetChunks(context.Background(), notMoved)
+	require.True(t, warm.IsChunkNotFoundErr(err))
+}
+
+func TestStorageTierFor(t *testing.T) {
+	now := time.Now()
+	period := config.PeriodConfig{
+		StorageTiers: []config.StorageTier{
+			{After: model.Duration(24 * time.Hour), ObjectType: "warm"},
+			{After: model.Duration(72 * time.Hour), ObjectType: "cold"},
+		},
+	}
+	through := model.TimeFromUnixNano(now.Add(-48 * time.Hour).UnixNano())
+
+	require.Equal(t, 0, StorageTierFor(period, nil, "fake", through, now))
+	require.Equal(t, 1, StorageTierFor(period, fakeTierLimits{"cold": 36 * time.Hour}, "fake", through, now))
+	require.Equal(t, -1, StorageTierFor(period, fakeTierLimits{"warm": 96 * time.Hour}, "fake", through, now))
+}
@@ -59,6 +59,24 @@ type Data interface {
 	Utilization() float64
 }
 
+// RangeData is implemented by the chunk data which can be decoded from ranged reads of the blocks overlapping a time
+// range only. The decoded data can only be iterated within that range.
+type RangeData interface {
+	UnmarshalRange(size int, read func(offset, length int) ([]byte, error), from, through model.Time) error
+}
+
+// TenantData is implemented by the chunk data which needs the tenant of the chunk to be decoded, like the chunks
+// compressed with the trained zstd dictionaries of their tenant.
+type TenantData interface {
+	SetUserID(userID string)
+}
+
+// ContentHasher is implemented by the chunk data which can hash its decoded entries, so that chunks holding the
+// same entries get the same hash however they are encoded.
+type ContentHasher interface {
+	ContentHash() (uint32, error)
+}
+
 // RequestChunkFilterer creates ChunkFilterer for a given request context.
 type RequestChunkFilterer interface {
 	ForRequest(ctx context.Context) Filterer
@@ -47,6 +47,13 @@ var (
 	errUpcomingBoltdbShipperNon24Hours = errors.New("boltdb-shipper with future date must always have periodic config for index set to 24h")
 	errTSDBNon24HoursIndexPeriod       = errors.New("tsdb must always have periodic config for index set to 24h")
 	errZeroLengthConfig                = errors.New("must specify at least one schema configuration")
+	errStorageTierObjectStoreNotSet    = errors.New("storage tier object_store must be set")
+	errStorageTierAfterNotIncreasing   = errors.New("storage tier after must be positive and increasing")
+	errInvalidChunkFormat              = errors.New("invalid chunk format, must be one of v4 or v5")
+	errChunkFormatSchemaTooOld         = errors.New("chunk_format requires schema v13 or greater")
+	errInvalidIndexFormat              = errors.New("invalid index format, must be one of v3 or v4")
+	errIndexFormatNotSupported         = errors.New("index_format requires the tsdb store and schema v13 or greater")
+	errChunkSketchFieldIndexFormat     = errors.New("chunk_sketch_field requires index_format v4")
 
 	// regexp for finding the trailing index table number at the end of the table name
 	extractTableNumberRegex = regexp.MustCompile(`[0-9]+$`)
@@ -145,11 +152,59 @@ type PeriodConfig struct {
 	IndexTables IndexPeriodicTableConfig `yaml:"index" doc:"description=Configures how the index is updated and stored."`
 	ChunkTables PeriodicTableConfig      `yaml:"chunks" doc:"description=Configured how the chunks are updated and stored."`
 	RowShards   uint32                   `yaml:"row_shards" doc:"default=16|description=How many shards will be created. Only used if schema is v10 or greater."`
+	// chunk format overriding the one of the schema version.
+	ChunkFormatVersion string `yaml:"chunk_format,omitempty" doc:"description=The format of the chunks written by the ingesters. Either v4 or v5. v5 stores the timestamps, the structured metadata and the lines of each block as separate columns, and compresses the lines of zstd encoded chunks with a dictionary trained on the stream. Requires schema v13 or greater. Defaults to the chunk format of the schema version."`
+	// tsdb index format overriding the one of the schema version.
+	IndexFormatVersion string `yaml:"index_format,omitempty" doc:"description=The format of the TSDB index files. Either v3 or v4. v4 stores a sketch of the content of each chunk written by the ingesters, holding the length of its longest line, a histogram and a bloom filter of its detected levels, and the bounds of the values of chunk_sketch_field, so that the queries which can't match a chunk skip it. Requires the tsdb store and schema v13 or greater. Defaults to the index format of the schema version."`
+	// numeric structured metadata field sketched by the v4 index format.
+	ChunkSketchField string `yaml:"chunk_sketch_field,omitempty" doc:"description=Name of a numeric or duration field, either a stream label or a structured metadata key, whose minimum and maximum values are stored in the chunk sketches of the v4 index format. Queries comparing the field to a number or a duration before any parser skip the chunks whose values can't match. Requires index_format v4."`
+	// storage tiers the compactor moves old chunks to.
+	StorageTiers []StorageTier `yaml:"storage_tiers,omitempty" doc:"description=Storage tiers the compactor moves chunks to once they are older than the tier's delay. Chunks are read from the tier matching their age, falling back to the other tiers and the object_store of the period.\nExample:\n storage_tiers:\n  - after: 720h\n    object_store: cold-bucket"`
 
 	// Integer representation of schema used for hot path calculation. Populated on unmarshaling.
 	schemaInt *int `yaml:"-"`
 }
 
+// StorageTier defines an object store the chunks of a period are moved to once
+// they are older than After.
+type StorageTier struct {
+	After      model.Duration `yaml:"after"`
+	ObjectType string         `yaml:"object_store"`
+}
+
+// StorageTierFor returns the index of the storage tier holding chunks of the given
+// age, or -1 if they are kept in the object_store of the period. after returns the
+// delay of a tier, allowing it to be overridden per tenant.
+func (cfg PeriodConfig) StorageTierFor(age time.Duration, after func(StorageTier) time.Duration) int {
+	tier := -1
+	for i, t := range cfg.StorageTiers {
+		if age >= after(t) {
+			tier = i
+		}
+	}
+	return tier
+}
+
+func (cfg PeriodConfig) validateStorageTiers() error {
+	objectStores := map[string]struct{}{cfg.ObjectType: {}}
+	var previous model.Duration
+	for _, t := range cfg.StorageTiers {
+		if t.ObjectType == "" {
+			return errStorageTierObjectStoreNotSet
+		}
+		if _, ok := objectStores[t.ObjectType]; ok {
+			return fmt.Errorf("storage tier object_store %q must differ from the object_store of the period and the other tiers", t.ObjectType)
+		}
+		objectStores[t.ObjectType] = struct{}{}
+
+		if t.After <= previous {
+			return errStorageTierAfterNotIncreasing
+		}
+		previous = t.After
+	}
+	return nil
+}
+
 // UnmarshalYAML implements yaml.Unmarshaller.
 func (cfg *PeriodConfig) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	type plain PeriodConfig
@@ -429,7 +484,7 @@ func (cfg *PeriodConfig) applyDefaults() {
 }
 
 // ChunkFormat returns chunk format including it's headBlockFormat corresponding to the `schema` version
-// in the given `PeriodConfig`.
+// and the `chunk_format` in the given `PeriodConfig`.
 func (cfg *PeriodConfig) ChunkFormat() (byte, chunkenc.HeadBlockFmt, error) {
 	sver, err := cfg.VersionAsInt()
 	if err != nil {
@@ -439,6 +494,8 @@ func (cfg *PeriodConfig) ChunkFormat() (byte, chunkenc.HeadBlockFmt, error) {
 	switch {
 	case sver <= 12:
 		return chunkenc.ChunkFormatV3, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV3), nil
+	case cfg.ChunkFormatVersion == "v5":
+		return chunkenc.ChunkFormatV5, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV5), nil
 	default: // for v13 and above
 		return chunkenc.ChunkFormatV4, chunkenc.ChunkHeadFormatFor(chunkenc.ChunkFormatV4), nil
 	}
@@ -455,6 +512,8 @@ func (cfg *PeriodConfig) TSDBFormat() (int, error) {
 	switch {
 	case sver <= 12:
 		return index.FormatV2, nil
+	case cfg.IndexFormatVersion == "v4":
+		return index.FormatV4, nil
 	default: // for v13 and above
 		return index.FormatV3, nil
 	}
@@ -479,11 +538,39 @@ func (cfg PeriodConfig) validate() error {
 		return fmt.Errorf("validating chunk tables: %w", err)
 	}
 
+	if err := cfg.validateStorageTiers(); err != nil {
+		return fmt.Errorf("validating storage tiers: %w", err)
+	}
+
 	v, err := cfg.VersionAsInt()
 	if err != nil {
 		return err
 	}
 
+	switch cfg.ChunkFormatVersion {
+	case "":
+	case "v4", "v5":
+		if v < 13 {
+			return errChunkFormatSchemaTooOld
+		}
+	default:
+		return errInvalidChunkFormat
+	}
+
+	switch cfg.IndexFormatVersion {
+	case "":
+	case "v3", "v4":
+		if v < 13 || cfg.IndexType != types.TSDBType {
+			return errIndexFormatNotSupported
+		}
+	default:
+		return errInvalidIndexFormat
+	}
+
+	if cfg.ChunkSketchField != "" && cfg.IndexFormatVersion != "v4" {
+		return errChunkSketchFieldIndexFormat
+	}
+
 	switch v {
 	case 10, 11, 12, 13:
 		if cfg.RowShards == 0 {
@@ -12,8 +12,10 @@ import (
 	"github.com/stretchr/testify/require"
 	yaml "gopkg.in/yaml.v2"
 
+	"github.com/grafana/loki/v3/pkg/chunkenc"
 	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
 	"github.com/grafana/loki/v3/pkg/storage/types"
 )
 
@@ -483,6 +485,151 @@ func TestPeriodConfig_Validate(t *testing.T) {
 				ChunkTables: PeriodicTableConfig{Period: 0},
 			},
 		},
+		{
+			desc: "storage tiers",
+			in: PeriodConfig{
+				Schema:     "v13",
+				RowShards:  16,
+				ObjectType: "hot",
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:  PeriodicTableConfig{Period: 0},
+				StorageTiers: []StorageTier{{After: model.Duration(24 * time.Hour), ObjectType: "warm"}, {After: model.Duration(48 * time.Hour), ObjectType: "cold"}},
+			},
+		},
+		{
+			desc: "error storage tiers not increasing",
+			in: PeriodConfig{
+				Schema:     "v13",
+				RowShards:  16,
+				ObjectType: "hot",
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:  PeriodicTableConfig{Period: 0},
+				StorageTiers: []StorageTier{{After: model.Duration(48 * time.Hour), ObjectType: "warm"}, {After: model.Duration(24 * time.Hour), ObjectType: "cold"}},
+			},
+			err: "storage tier after must be positive and increasing",
+		},
+		{
+			desc: "error storage tier on the object store of the period",
+			in: PeriodConfig{
+				Schema:     "v13",
+				RowShards:  16,
+				ObjectType: "hot",
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:  PeriodicTableConfig{Period: 0},
+				StorageTiers: []StorageTier{{After: model.Duration(24 * time.Hour), ObjectType: "hot"}},
+			},
+			err: `storage tier object_store "hot" must differ`,
+		},
+		{
+			desc: "chunk format",
+			in: PeriodConfig{
+				Schema:    "v13",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:        PeriodicTableConfig{Period: 0},
+				ChunkFormatVersion: "v5",
+			},
+		},
+		{
+			desc: "error invalid chunk format",
+			in: PeriodConfig{
+				Schema:    "v13",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:        PeriodicTableConfig{Period: 0},
+				ChunkFormatVersion: "v6",
+			},
+			err: "invalid chunk format",
+		},
+		{
+			desc: "error chunk format with schema older than v13",
+			in: PeriodConfig{
+				Schema:    "v12",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:        PeriodicTableConfig{Period: 0},
+				ChunkFormatVersion: "v5",
+			},
+			err: "chunk_format requires schema v13 or greater",
+		},
+		{
+			desc: "index format",
+			in: PeriodConfig{
+				Schema:    "v13",
+				IndexType: "tsdb",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 24 * time.Hour},
+				},
+				ChunkTables:        PeriodicTableConfig{Period: 0},
+				IndexFormatVersion: "v4",
+				ChunkSketchField:   "duration",
+			},
+		},
+		{
+			desc: "error invalid index format",
+			in: PeriodConfig{
+				Schema:    "v13",
+				IndexType: "tsdb",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 24 * time.Hour},
+				},
+				ChunkTables:        PeriodicTableConfig{Period: 0},
+				IndexFormatVersion: "v5",
+			},
+			err: "invalid index format",
+		},
+		{
+			desc: "error index format without tsdb",
+			in: PeriodConfig{
+				Schema:    "v13",
+				IndexType: "boltdb-shipper",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 0},
+				},
+				ChunkTables:        PeriodicTableConfig{Period: 0},
+				IndexFormatVersion: "v4",
+			},
+			err: "index_format requires the tsdb store and schema v13 or greater",
+		},
+		{
+			desc: "error chunk sketch field without index format v4",
+			in: PeriodConfig{
+				Schema:    "v13",
+				IndexType: "tsdb",
+				RowShards: 16,
+				IndexTables: IndexPeriodicTableConfig{
+					PathPrefix:          "index/",
+					PeriodicTableConfig: PeriodicTableConfig{Period: 24 * time.Hour},
+				},
+				ChunkTables:      PeriodicTableConfig{Period: 0},
+				ChunkSketchField: "duration",
+			},
+			err: "chunk_sketch_field requires index_format v4",
+		},
 	} {
 		t.Run(tc.desc, func(t *testing.T) {
 			if tc.err == "" {
@@ -494,6 +641,66 @@ func TestPeriodConfig_Validate(t *testing.T) {
 	}
 }
 
+func TestPeriodConfig_ChunkFormat(t *testing.T) {
+	for _, tc := range []struct {
+		schema, chunkFormat string
+		expected            byte
+	}{
+		{schema: "v12", expected: chunkenc.ChunkFormatV3},
+		{schema: "v13", expected: chunkenc.ChunkFormatV4},
+		{schema: "v13", chunkFormat: "v4", expected: chunkenc.ChunkFormatV4},
+		{schema: "v13", chunkFormat: "v5", expected: chunkenc.ChunkFormatV5},
+	} {
+		t.Run(tc.schema+tc.chunkFormat, func(t *testing.T) {
+			cfg := PeriodConfig{Schema: tc.schema, ChunkFormatVersion: tc.chunkFormat}
+			chunkFormat, headFormat, err := cfg.ChunkFormat()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, chunkFormat)
+			require.Equal(t, chunkenc.ChunkHeadFormatFor(tc.expected), headFormat)
+		})
+	}
+}
+
+func TestPeriodConfig_TSDBFormat(t *testing.T) {
+	for _, tc := range []struct {
+		schema, indexFormat string
+		expected            int
+	}{
+		{schema: "v12", expected: index.FormatV2},
+		{schema: "v13", expected: index.FormatV3},
+		{schema: "v13", indexFormat: "v3", expected: index.FormatV3},
+		{schema: "v13", indexFormat: "v4", expected: index.FormatV4},
+	} {
+		t.Run(tc.schema+tc.indexFormat, func(t *testing.T) {
+			cfg := PeriodConfig{Schema: tc.schema, IndexFormatVersion: tc.indexFormat}
+			indexFormat, err := cfg.TSDBFormat()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, indexFormat)
+		})
+	}
+}
+
+func TestPeriodConfig_StorageTierFor(t *testing.T) {
+	cfg := PeriodConfig{StorageTiers: []StorageTier{
+		{After: model.Duration(24 * time.Hour), ObjectType: "warm"},
+		{After: model.Duration(48 * time.Hour), ObjectType: "cold"},
+	}}
+	defaults := func(t StorageTier) time.Duration { return time.Duration(t.After) }
+
+	require.Equal(t, -1, cfg.StorageTierFor(time.Hour, defaults))
+	require.Equal(t, 0, cfg.StorageTierFor(24*time.Hour, defaults))
+	require.Equal(t, 1, cfg.StorageTierFor(72*time.Hour, defaults))
+
+	// a tenant override moving chunks to the cold tier earlier.
+	overridden := func(t StorageTier) time.Duration {
+		if t.ObjectType == "cold" {
+			return 12 * time.Hour
+		}
+		return time.Duration(t.After)
+	}
+	require.Equal(t, 1, cfg.StorageTierFor(12*time.Hour, overridden))
+}
+
 func MustParseDayTime(s string) DayTime {
 	t, err := time.Parse("2006-01-02", s)
 	if err != nil {
@@ -2,6 +2,7 @@ package config
 
 import (
 	"flag"
+	"fmt"
 	"time"
 
 	"github.com/prometheus/common/model"
@@ -9,6 +10,14 @@ import (
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
 )
 
+const (
+	// ChunkDedupCache skips the upload of the chunks found in the chunks cache.
+	ChunkDedupCache = "cache"
+	// ChunkDedupContent keys the chunks by the hash of their entries, and skips the upload of the chunks found in
+	// the chunks cache or in the object store.
+	ChunkDedupContent = "content"
+)
+
 type ChunkStoreConfig struct {
 	ChunkCacheConfig            cache.Config  `yaml:"chunk_cache_config"`
 	ChunkCacheConfigL2          cache.Config  `yaml:"chunk_cache_config_l2"`
@@ -18,6 +27,8 @@ type ChunkStoreConfig struct {
 	L2ChunkCacheHandoff   time.Duration  `yaml:"l2_chunk_cache_handoff"`
 	CacheLookupsOlderThan model.Duration `yaml:"cache_lookups_older_than"`
 
+	ChunkDedupMode string `yaml:"chunk_dedup_mode" category:"experimental"`
+
 	// Not visible in yaml because the setting shouldn't be common between ingesters and queriers.
 	// This exists in case we don't want to cache all the chunks but still want to take advantage of
 	// ingester chunk write deduplication. But for the queriers we need the full value. So when this option
@@ -42,8 +53,14 @@ func (cfg *ChunkStoreConfig) RegisterFlags(f *flag.FlagSet) {
 	f.DurationVar(&cfg.SkipQueryWritebackOlderThan, "store.skip-query-writeback-older-than", 0, "Chunks fetched from queriers before this duration will not be written to the cache. A value of 0 will write all chunks to the cache")
 
 	f.Var(&cfg.CacheLookupsOlderThan, "store.cache-lookups-older-than", "Cache index entries older than this period. 0 to disable.")
+	f.StringVar(&cfg.ChunkDedupMode, "store.chunk-dedup-mode", ChunkDedupCache, "Experimental. How the chunks already stored by another replica are found before they are uploaded. Supported values are: cache, content. With 'cache', only the chunks with the same encoding found in the chunks cache are skipped. With 'content', the chunks are keyed by the hash of their entries rather than the checksum of their encoding, and the chunks with the same entries found in the chunks cache or with a HEAD request to the object store are skipped. Content addressed chunks can't be read by older Loki versions.")
 }
 
 func (cfg *ChunkStoreConfig) Validate() error {
+	switch cfg.ChunkDedupMode {
+	case "", ChunkDedupCache, ChunkDedupContent:
+	default:
+		return fmt.Errorf("invalid chunk dedup mode %q, supported values are: %s, %s", cfg.ChunkDedupMode, ChunkDedupCache, ChunkDedupContent)
+	}
 	return nil
 }
@@ -31,6 +31,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/openstack"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/testutils"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/storage/stores"
 	"github.com/grafana/loki/v3/pkg/storage/stores/series/index"
@@ -70,6 +71,7 @@ type StoreLimits interface {
 	downloads.Limits
 	stores.StoreLimits
 	indexgateway.Limits
+	fetcher.TierLimits
 	CardinalityLimit(string) int
 }
 
@@ -299,6 +301,8 @@ type Config struct {
 	ObjectStore       bucket.ConfigWithNamedStores `yaml:"object_store"`
 
 	MaxChunkBatchSize   int                       `yaml:"max_chunk_batch_size"`
+	ChunkPrefetch       ChunkPrefetchConfig       `yaml:"chunk_prefetch" doc:"description=Configures the concurrent fetch of the batches of chunks of a query ahead of the batch being iterated."`
+	ChunkRangedReads    bool                      `yaml:"chunk_ranged_reads" category:"experimental"`
 	BoltDBShipperConfig boltdb.IndexCfg           `yaml:"boltdb_shipper" doc:"description=Configures storing index in an Object Store (GCS/S3/Azure/Swift/COS/Filesystem) in the form of boltdb files. Required fields only required when boltdb-shipper is defined in config."`
 	TSDBShipperConfig   indexshipper.Config       `yaml:"tsdb_shipper" doc:"description=Configures storing index in an Object Store (GCS/S3/Azure/Swift/COS/Filesystem) in a prometheus TSDB-like format. Required fields only required when TSDB is defined in config."`
 	BloomShipperConfig  bloomshipperconfig.Config `yaml:"bloom_shipper" category:"experimental" doc:"description=Experimental: Configures the bloom shipper component, which contains the store abstraction to fetch bloom filters from and put them to object storage."`
@@ -336,6 +340,8 @@ func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
 	f.IntVar(&cfg.MaxParallelGetChunk, "store.max-parallel-get-chunk", 150, "Maximum number of parallel chunk reads.")
 	cfg.BoltDBShipperConfig.RegisterFlags(f)
 	f.IntVar(&cfg.MaxChunkBatchSize, "store.max-chunk-batch-size", 50, "The maximum number of chunks to fetch per batch.")
+	cfg.ChunkPrefetch.RegisterFlagsWithPrefix("store.chunk-prefetch.", f)
+	f.BoolVar(&cfg.ChunkRangedReads, "store.chunk-ranged-reads", false, "Experimental. Read only the blocks of the chunks overlapping the queried time range from the object store, with ranged reads of their header, block index and blocks. The chunks within the queried time range are still read whole and written back to the chunks cache.")
 	cfg.TSDBShipperConfig.RegisterFlagsWithPrefix("tsdb.", f)
 	cfg.BloomShipperConfig.RegisterFlagsWithPrefix("bloom.", f)
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/chunkenc"
 	"github.com/grafana/loki/v3/pkg/iter"
@@ -22,12 +23,33 @@ type LazyChunk struct {
 	IsValid bool
 	Fetcher *fetcher.Fetcher
 
+	// readRange restricts the blocks of the chunk read from the storage, or is nil to read it whole.
+	readRange *readRange
+
 	// cache of overlapping block.
 	// We use the offset of the block as key since it's unique per chunk.
 	overlappingBlocks       map[int]iter.CacheEntryIterator
 	overlappingSampleBlocks map[int]iter.CacheSampleIterator
 }
 
+// readRange is the time range of the blocks of the chunks of a query read from the storage.
+type readRange struct {
+	from, through model.Time
+}
+
+// lazyChunkFetch fetches the lazy chunks of a fetcher sharing the same read range.
+type lazyChunkFetch struct {
+	fetcher   *fetcher.Fetcher
+	readRange *readRange
+}
+
+func (f lazyChunkFetch) fetch(ctx context.Context, chks []chunk.Chunk) ([]chunk.Chunk, error) {
+	if f.readRange == nil {
+		return f.fetcher.FetchChunks(ctx, chks)
+	}
+	return f.fetcher.FetchChunksInRange(ctx, chks, f.readRange.from, f.readRange.through)
+}
+
 // Iterator returns an entry iterator.
 // The iterator returned will cache overlapping block's entries with the next chunk if passed.
 // This way when we re-use them for ordering across batches we don't re-decompress the data again.
@@ -187,12 +209,16 @@ func IsBlockOverlapping(b chunkenc.Block, with *LazyChunk, direction logproto.Di
 }
 
 func (c *LazyChunk) IsOverlapping(with *LazyChunk, direction logproto.Direction) bool {
+	return isChunkRefOverlapping(c.Chunk.ChunkRef, with.Chunk.ChunkRef, direction)
+}
+
+func isChunkRefOverlapping(ref, with logproto.ChunkRef, direction logproto.Direction) bool {
 	if direction == logproto.BACKWARD {
-		if c.Chunk.From.Before(with.Chunk.Through) || c.Chunk.From == with.Chunk.Through {
+		if ref.From.Before(with.Through) || ref.From == with.Through {
 			return true
 		}
 	} else {
-		if !c.Chunk.Through.Before(with.Chunk.From) {
+		if !ref.Through.Before(with.From) {
 			return true
 		}
 	}
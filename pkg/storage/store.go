@@ -21,6 +21,7 @@ import (
 	"github.com/grafana/dskit/tenant"
 
 	"github.com/grafana/loki/v3/pkg/analytics"
+	"github.com/grafana/loki/v3/pkg/compactor/tokenindex"
 	"github.com/grafana/loki/v3/pkg/indexgateway"
 	"github.com/grafana/loki/v3/pkg/iter"
 	"github.com/grafana/loki/v3/pkg/logproto"
@@ -95,9 +96,11 @@ type LokiStore struct {
 	logger log.Logger
 
 	chunkFilterer               chunk.RequestChunkFilterer
+	tokenIndex                  *tokenindex.Querier
 	extractorWrapper            lokilog.SampleExtractorWrapper
 	pipelineWrapper             lokilog.PipelineWrapper
 	congestionControllerFactory func(cfg congestion.Config, logger log.Logger, metrics *congestion.Metrics) congestion.Controller
+	congestionControllers       []congestion.Controller
 
 	metricsNamespace string
 }
@@ -238,6 +241,7 @@ func (s *LokiStore) chunkClientForPeriod(p config.PeriodConfig) (client.Client,
 			s.logger,
 			congestion.NewMetrics(fmt.Sprintf("%s-%s", objectStoreType, p.From.String()), ccCfg),
 		)
+		s.congestionControllers = append(s.congestionControllers, cc)
 	}
 
 	component := "chunk-store-" + p.From.String()
@@ -249,7 +253,21 @@ func (s *LokiStore) chunkClientForPeriod(p config.PeriodConfig) (client.Client,
 	}
 
 	chunks = client.NewMetricsChunkClient(chunks, s.chunkClientMetrics)
-	return chunks, nil
+	if len(p.StorageTiers) == 0 {
+		return chunks, nil
+	}
+
+	tiers := make([]client.Client, 0, len(p.StorageTiers))
+	for _, tier := range p.StorageTiers {
+		tierComponent := component + "-" + tier.ObjectType
+		tierReg := prometheus.WrapRegistererWith(prometheus.Labels{"component": tierComponent}, s.registerer)
+		tierChunks, err := NewChunkClient(tier.ObjectType, tierComponent, s.cfg, s.schemaCfg, nil, tierReg, s.clientMetrics, s.logger)
+		if err != nil {
+			return nil, errors.Wrapf(err, "error creating object client of storage tier %s", tier.ObjectType)
+		}
+		tiers = append(tiers, client.NewMetricsChunkClient(tierChunks, s.chunkClientMetrics))
+	}
+	return fetcher.NewTieredClient(s.schemaCfg, p, chunks, tiers, s.limits), nil
 }
 
 func shouldUseIndexGatewayClient(cfg indexshipper.Config) bool {
@@ -297,7 +315,7 @@ func (s *LokiStore) storeForPeriod(p config.PeriodConfig, tableRange config.Tabl
 		}
 
 		indexReaderWriter = index.NewMonitoredReaderWriter(indexReaderWriter, indexClientReg)
-		chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, indexReaderWriter, s.storeCfg.DisableIndexDeduplication)
+		chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, indexReaderWriter, s.storeCfg.DisableIndexDeduplication, s.storeCfg.ChunkDedupMode)
 
 		return chunkWriter, indexReaderWriter,
 			func() {
@@ -323,7 +341,7 @@ func (s *LokiStore) storeForPeriod(p config.PeriodConfig, tableRange config.Tabl
 
 	indexReaderWriter := series.NewIndexReaderWriter(s.schemaCfg, schema, idx, f, s.cfg.MaxChunkBatchSize, s.writeDedupeCache)
 	monitoredReaderWriter := index.NewMonitoredReaderWriter(indexReaderWriter, indexClientReg)
-	chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, monitoredReaderWriter, s.storeCfg.DisableIndexDeduplication)
+	chunkWriter := stores.NewChunkWriter(f, s.schemaCfg, monitoredReaderWriter, s.storeCfg.DisableIndexDeduplication, s.storeCfg.ChunkDedupMode)
 
 	return chunkWriter,
 		monitoredReaderWriter,
@@ -388,6 +406,15 @@ func (s *LokiStore) SetChunkFilterer(chunkFilterer chunk.RequestChunkFilterer) {
 	s.Store.SetChunkFilterer(chunkFilterer)
 }
 
+// SetTokenIndex sets the token index used to drop the chunks which can't match the label filters of the queries.
+// It is ignored when the tsdb index is read through the index gateways, which filter the chunk refs themselves.
+func (s *LokiStore) SetTokenIndex(tokenIndex *tokenindex.Querier) {
+	if shouldUseIndexGatewayClient(s.cfg.TSDBShipperConfig) {
+		return
+	}
+	s.tokenIndex = tokenIndex
+}
+
 func (s *LokiStore) SetExtractorWrapper(wrapper lokilog.SampleExtractorWrapper) {
 	s.extractorWrapper = wrapper
 }
@@ -424,6 +451,11 @@ func (s *LokiStore) lazyChunks(
 		prefiltered += len(chks[i])
 		stats.AddChunksRef(int64(len(chks[i])))
 		chks[i] = filterChunksByTime(from, through, chks[i])
+		if s.tokenIndex != nil {
+			if chks[i], err = s.tokenIndex.FilterChunks(ctx, userID, predicate.Plan().AST, chks[i]); err != nil {
+				return nil, err
+			}
+		}
 		filtered += len(chks[i])
 	}
 
@@ -433,11 +465,17 @@ func (s *LokiStore) lazyChunks(
 	s.chunkMetrics.refs.WithLabelValues(statusDiscarded).Add(float64(prefiltered - filtered))
 	s.chunkMetrics.refs.WithLabelValues(statusMatched).Add(float64(filtered))
 
+	// only the blocks of the chunks within the queried range are read when ranged reads are enabled.
+	var r *readRange
+	if s.cfg.ChunkRangedReads {
+		r = &readRange{from: from, through: through}
+	}
+
 	// creates lazychunks with chunks ref.
 	lazyChunks := make([]*LazyChunk, 0, filtered)
 	for i := range chks {
 		for _, c := range chks[i] {
-			lazyChunks = append(lazyChunks, &LazyChunk{Chunk: c, Fetcher: fetchers[i]})
+			lazyChunks = append(lazyChunks, &LazyChunk{Chunk: c, Fetcher: fetchers[i], readRange: r})
 		}
 	}
 	return lazyChunks, nil
@@ -528,7 +566,7 @@ func (s *LokiStore) SelectLogs(ctx context.Context, req logql.SelectLogParams) (
 		chunkFilterer = s.chunkFilterer.ForRequest(ctx)
 	}
 
-	return newLogBatchIterator(ctx, s.schemaCfg, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, matchers, pipeline, req.Direction, req.Start, req.End, chunkFilterer)
+	return newLogBatchIterator(ctx, s.schemaCfg, s.chunkMetrics, lazyChunks, s.cfg.MaxChunkBatchSize, s.batchPrefetch(), matchers, pipeline, req.Direction, req.Start, req.End, chunkFilterer)
 }
 
 func (s *LokiStore) SelectSamples(ctx context.Context, req logql.SelectSampleParams) (iter.SampleIterator, error) {
@@ -586,6 +624,7 @@ func (s *LokiStore) SelectSamples(ctx context.Context, req logql.SelectSamplePar
 		s.chunkMetrics,
 		lazyChunks,
 		s.cfg.MaxChunkBatchSize,
+		s.batchPrefetch(),
 		matchers,
 		req.Start,
 		req.End,
@@ -594,6 +633,22 @@ func (s *LokiStore) SelectSamples(ctx context.Context, req logql.SelectSamplePar
 	)
 }
 
+// batchPrefetch returns the prefetch of the batches of chunks of a query, which is restricted while the object
+// store requests are throttled by the congestion control.
+func (s *LokiStore) batchPrefetch() batchPrefetch {
+	return batchPrefetch{
+		ChunkPrefetchConfig: s.cfg.ChunkPrefetch,
+		congested: func() bool {
+			for _, cc := range s.congestionControllers {
+				if cc.Congested() {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
 func (s *LokiStore) GetSchemaConfigs() []config.PeriodConfig {
 	return s.schemaCfg.Configs
 }
@@ -495,35 +495,41 @@ func Test_store_SelectLogs(t *testing.T) {
 		},
 	}
 
+	// the small batches are fetched concurrently ahead of the iterated one.
+	configs := map[string]Config{
+		"sequential": {MaxChunkBatchSize: 10},
+		"prefetch":   {MaxChunkBatchSize: 2, ChunkPrefetch: ChunkPrefetchConfig{Batches: 3}},
+	}
+
 	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			s := &LokiStore{
-				Store: storeFixture,
-				cfg: Config{
-					MaxChunkBatchSize: 10,
-				},
-				chunkMetrics: NilMetrics,
-				logger:       log.NewNopLogger(),
-			}
+		for cfgName, cfg := range configs {
+			t.Run(tt.name+"/"+cfgName, func(t *testing.T) {
+				s := &LokiStore{
+					Store:        storeFixture,
+					cfg:          cfg,
+					chunkMetrics: NilMetrics,
+					logger:       log.NewNopLogger(),
+				}
 
-			tt.req.Plan = &plan.QueryPlan{
-				AST: syntax.MustParseExpr(tt.req.Selector),
-			}
+				tt.req.Plan = &plan.QueryPlan{
+					AST: syntax.MustParseExpr(tt.req.Selector),
+				}
 
-			ctx = user.InjectOrgID(context.Background(), "test-user")
-			it, err := s.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: tt.req})
-			if err != nil {
-				t.Errorf("store.LazyQuery() error = %v", err)
-				return
-			}
+				ctx = user.InjectOrgID(context.Background(), "test-user")
+				it, err := s.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: tt.req})
+				if err != nil {
+					t.Errorf("store.LazyQuery() error = %v", err)
+					return
+				}
 
-			streams, _, err := iter.ReadBatch(it, tt.req.Limit)
-			_ = it.Close()
-			if err != nil {
-				t.Fatalf("error reading batch %s", err)
-			}
-			assertStream(t, tt.expected, streams.Streams)
-		})
+				streams, _, err := iter.ReadBatch(it, tt.req.Limit)
+				_ = it.Close()
+				if err != nil {
+					t.Fatalf("error reading batch %s", err)
+				}
+				assertStream(t, tt.expected, streams.Streams)
+			})
+		}
 	}
 }
 
@@ -2230,3 +2236,102 @@ func TestQueryReferencingStructuredMetadata(t *testing.T) {
 		}
 	})
 }
+
+func TestStore_ChunkRangedReads(t *testing.T) {
+	chkFrom := parseDate("2024-01-01")
+	chkThrough := chkFrom.Add(6 * time.Hour)
+
+	// a chunk of small blocks, so that a query of a few minutes overlaps only some of them.
+	chk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 4*1024, 0)
+	for ts := chkFrom; !ts.After(chkThrough); ts = ts.Add(time.Second) {
+		_, err := chk.Append(&logproto.Entry{Timestamp: ts, Line: fmt.Sprintf("%s %x", ts, xxhash.Sum64String(ts.String()))})
+		require.NoError(t, err)
+	}
+	require.NoError(t, chk.Close())
+	lbs := labels.FromStrings(labels.MetricName, "logs", "foo", "bar")
+	c := chunk.NewChunk("fake", client.Fingerprint(lbs), lbs, chunkenc.NewFacade(chk, 0, 0), model.TimeFromUnixNano(chkFrom.UnixNano()), model.TimeFromUnixNano(chkThrough.UnixNano()))
+	require.NoError(t, c.Encode())
+	encoded, err := c.Encoded()
+	require.NoError(t, err)
+	require.Greater(t, len(encoded), 256*1024)
+
+	schemaConfig := config.SchemaConfig{
+		Configs: []config.PeriodConfig{
+			{
+				From:       config.DayTime{Time: timeToModelTime(chkFrom.Add(-24 * time.Hour))},
+				IndexType:  types.TSDBType,
+				ObjectType: types.StorageTypeFileSystem,
+				Schema:     "v13",
+				IndexTables: config.IndexPeriodicTableConfig{
+					PathPrefix: "index/",
+					PeriodicTableConfig: config.PeriodicTableConfig{
+						Prefix: "index_",
+						Period: time.Hour * 24,
+					}},
+				RowShards: 2,
+			},
+		},
+	}
+
+	limits, err := validation.NewOverrides(validation.Limits{MaxQueryLength: model.Duration(6000 * time.Hour)}, nil)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name       string
+		from, to   time.Time
+		rangedRead bool
+	}{
+		{name: "whole", from: chkFrom.Add(time.Hour), to: chkFrom.Add(time.Hour + 5*time.Minute)},
+		{name: "ranged", from: chkFrom.Add(time.Hour), to: chkFrom.Add(time.Hour + 5*time.Minute), rangedRead: true},
+		{name: "ranged chunk start", from: chkFrom.Add(-time.Minute), to: chkFrom.Add(time.Minute), rangedRead: true},
+		{name: "ranged chunk end", from: chkThrough.Add(-time.Minute), to: chkThrough.Add(time.Minute), rangedRead: true},
+		{name: "ranged whole chunk", from: chkFrom, to: chkThrough.Add(time.Second), rangedRead: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			tempDir := t.TempDir()
+
+			shipperConfig := indexshipper.Config{}
+			flagext.DefaultValues(&shipperConfig)
+			shipperConfig.ActiveIndexDirectory = path.Join(tempDir, "active_index")
+			shipperConfig.CacheLocation = path.Join(tempDir, "cache")
+			shipperConfig.Mode = indexshipper.ModeReadWrite
+
+			cfg := Config{
+				FSConfig:          local.FSConfig{Directory: path.Join(tempDir, "chunks")},
+				TSDBShipperConfig: shipperConfig,
+				MaxChunkBatchSize: 10,
+				ChunkRangedReads:  tc.rangedRead,
+			}
+
+			store, err := NewStore(cfg, config.ChunkStoreConfig{}, schemaConfig, limits, cm, nil, log.NewNopLogger(), constants.Loki)
+			require.NoError(t, err)
+			defer store.Stop()
+
+			ctx := user.InjectOrgID(context.Background(), "fake")
+			require.NoError(t, store.PutOne(ctx, c.From, c.Through, c))
+
+			req := newQuery(`{foo="bar"}`, tc.from, tc.to, nil, nil)
+			it, err := store.SelectLogs(ctx, logql.SelectLogParams{QueryRequest: req})
+			require.NoError(t, err)
+			defer it.Close()
+
+			expected := tc.from
+			if expected.Before(chkFrom) {
+				expected = chkFrom
+			}
+			for it.Next() {
+				e := it.At()
+				require.Equal(t, expected.UnixNano(), e.Timestamp.UnixNano())
+				require.Equal(t, fmt.Sprintf("%s %x", expected, xxhash.Sum64String(expected.String())), e.Line)
+				expected = expected.Add(time.Second)
+			}
+			require.NoError(t, it.Err())
+
+			end := tc.to
+			if end.After(chkThrough) {
+				end = chkThrough.Add(time.Second)
+			}
+			require.Equal(t, end.UnixNano(), expected.UnixNano())
+		})
+	}
+}
@@ -2,6 +2,7 @@ package stores
 
 import (
 	"context"
+	"errors"
 
 	"github.com/go-kit/log/level"
 	"github.com/opentracing/opentracing-go"
@@ -10,6 +11,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/storage/stores/index"
@@ -30,6 +32,12 @@ var (
 		Help:      "Count of bytes from chunks which were not stored because they have already been stored by another replica.",
 	})
 
+	DedupLookupsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: constants.Loki,
+		Name:      "chunk_store_dedup_lookups_total",
+		Help:      "Count of lookups of content addressed chunks in the object store before they are uploaded, by result.",
+	}, []string{"result"})
+
 	IndexEntriesPerChunk = promauto.NewHistogram(prometheus.HistogramOpts{
 		Namespace: constants.Loki,
 		Name:      "chunk_store_index_entries_per_chunk",
@@ -41,15 +49,18 @@ var (
 type Writer struct {
 	schemaCfg                 config.SchemaConfig
 	DisableIndexDeduplication bool
+	// DedupMode is one of config.ChunkDedupCache or config.ChunkDedupContent.
+	DedupMode string
 
 	indexWriter index.Writer
 	fetcher     *fetcher.Fetcher
 }
 
-func NewChunkWriter(fetcher *fetcher.Fetcher, schemaCfg config.SchemaConfig, indexWriter index.Writer, disableIndexDeduplication bool) ChunkWriter {
+func NewChunkWriter(fetcher *fetcher.Fetcher, schemaCfg config.SchemaConfig, indexWriter index.Writer, disableIndexDeduplication bool, dedupMode string) ChunkWriter {
 	return &Writer{
 		schemaCfg:                 schemaCfg,
 		DisableIndexDeduplication: disableIndexDeduplication,
+		DedupMode:                 dedupMode,
 		fetcher:                   fetcher,
 		indexWriter:               indexWriter,
 	}
@@ -82,10 +93,21 @@ func (c *Writer) PutOne(ctx context.Context, from, through model.Time, chk chunk
 		overlap = true
 	}
 
+	// content addressed chunks get the same key when they hold the same entries, whichever replica flushed them.
+	if c.DedupMode == config.ChunkDedupContent && !chk.ContentAddressed {
+		if err := chk.EncodeContentAddressed(); err != nil {
+			return err
+		}
+	}
+
 	// If this chunk is in cache it must already be in the database so we don't need to write it again
 	found, _, _, _ := c.fetcher.Cache().Fetch(ctx, []string{c.schemaCfg.ExternalKey(chk.ChunkRef)})
+	stored := len(found) > 0
+	if !stored && !overlap && chk.ContentAddressed {
+		stored = c.storedContent(ctx, chk)
+	}
 
-	if len(found) > 0 && !overlap {
+	if stored && !overlap {
 		writeChunk = false
 		DedupedChunksTotal.Inc()
 		encoded, err := chk.Encoded()
@@ -127,3 +149,22 @@ func (c *Writer) PutOne(ctx context.Context, from, through model.Time, chk chunk
 
 	return nil
 }
+
+// storedContent checks whether the content addressed chunk was already uploaded by another replica, with a HEAD
+// request to the object store. The chunk is written anyway if the object store can't tell.
+func (c *Writer) storedContent(ctx context.Context, chk chunk.Chunk) bool {
+	exists, err := client.ChunkExists(ctx, c.fetcher.Client(), chk)
+	switch {
+	case errors.Is(err, client.ErrMethodNotImplemented):
+		return false
+	case err != nil:
+		DedupLookupsTotal.WithLabelValues("error").Inc()
+		level.Warn(spanlogger.FromContext(ctx)).Log("msg", "failed to check whether chunk is stored", "err", err)
+		return false
+	case exists:
+		DedupLookupsTotal.WithLabelValues("found").Inc()
+	default:
+		DedupLookupsTotal.WithLabelValues("missing").Inc()
+	}
+	return exists
+}
@@ -2,7 +2,9 @@ package stores
 
 import (
 	"context"
+	"errors"
 	"testing"
+	"time"
 
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/grafana/loki/v3/pkg/chunkenc"
 	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
 	"github.com/grafana/loki/v3/pkg/logqlmodel/stats"
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/fetcher"
@@ -163,7 +166,7 @@ func TestChunkWriter_PutOne(t *testing.T) {
 			f, err := fetcher.New(cache, nil, false, schemaConfig, client, 0, 0)
 			require.NoError(t, err)
 
-			cw := NewChunkWriter(f, schemaConfig, idx, true)
+			cw := NewChunkWriter(f, schemaConfig, idx, true, config.ChunkDedupCache)
 
 			err = cw.PutOne(context.Background(), tc.from, tc.through, chk)
 			require.NoError(t, err)
@@ -173,3 +176,80 @@ func TestChunkWriter_PutOne(t *testing.T) {
 		})
 	}
 }
+
+type mockExistsChunksClient struct {
+	mockChunksClient
+	stored  bool
+	lookups int
+}
+
+func (m *mockExistsChunksClient) ChunkExists(_ context.Context, chk chunk.Chunk) (bool, error) {
+	m.lookups++
+	if !chk.ContentAddressed {
+		return false, errors.New("chunk is not content addressed")
+	}
+	return m.stored, nil
+}
+
+func TestChunkWriter_PutOne_ContentDedup(t *testing.T) {
+	periodConfig := config.PeriodConfig{
+		From:   config.DayTime{Time: 0},
+		Schema: "v13",
+	}
+	schemaConfig := config.SchemaConfig{
+		Configs: []config.PeriodConfig{periodConfig},
+	}
+
+	chunkfmt, headfmt, err := periodConfig.ChunkFormat()
+	require.NoError(t, err)
+	memchk := chunkenc.NewMemChunk(chunkfmt, compression.GZIP, headfmt, 256*1024, 0)
+	_, err = memchk.Append(&logproto.Entry{Timestamp: time.Unix(0, 200*int64(time.Millisecond)), Line: "foo"})
+	require.NoError(t, err)
+	require.NoError(t, memchk.Close())
+	chk := chunk.NewChunk("fake", model.Fingerprint(0), []labels.Label{{Name: "foo", Value: "bar"}}, chunkenc.NewFacade(memchk, 0, 0), 100, 400)
+	require.NoError(t, chk.Encode())
+
+	for name, tc := range map[string]struct {
+		from, through                                                     model.Time
+		stored                                                            bool
+		expectedLookups, expectedWriteChunkCalls, expectedIndexWriteCalls int
+	}{
+		"stored": {
+			from:                    0,
+			through:                 500,
+			stored:                  true,
+			expectedLookups:         1,
+			expectedIndexWriteCalls: 1,
+		},
+		"not_stored": {
+			from:                    0,
+			through:                 500,
+			expectedLookups:         1,
+			expectedWriteChunkCalls: 1,
+			expectedIndexWriteCalls: 1,
+		},
+		"overlapping_chunk_stored": {
+			from:                    200,
+			through:                 500,
+			stored:                  true,
+			expectedWriteChunkCalls: 1,
+			expectedIndexWriteCalls: 1,
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			idx := &mockIndexWriter{}
+			client := &mockExistsChunksClient{stored: tc.stored}
+
+			f, err := fetcher.New(&mockCache{}, nil, false, schemaConfig, client, 0, 0)
+			require.NoError(t, err)
+
+			cw := NewChunkWriter(f, schemaConfig, idx, true, config.ChunkDedupContent)
+
+			err = cw.PutOne(context.Background(), tc.from, tc.through, chk)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectedLookups, client.lookups)
+			require.Equal(t, tc.expectedIndexWriteCalls, idx.called)
+			require.Equal(t, tc.expectedWriteChunkCalls, client.called)
+		})
+	}
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/prometheus/common/model"
 
 	"github.com/grafana/loki/v3/pkg/compactor/deletion"
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/client/util"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/index"
@@ -330,7 +331,7 @@ func (tm *tableManager) ensureQueryReadiness(ctx context.Context) error {
 	}
 
 	for _, tableName := range tables {
-		if tableName == deletion.DeleteRequestsTableName {
+		if tableName == deletion.DeleteRequestsTableName || tableName == tombstones.TableName {
 			continue
 		}
 
@@ -71,6 +71,9 @@ type Config struct {
 	IngesterName           string
 	Mode                   Mode
 	IngesterDBRetainPeriod time.Duration
+	// TombstonesRefreshInterval is the interval at which the tombstones written by the compactor are read again.
+	// They are not applied when it is 0.
+	TombstonesRefreshInterval time.Duration
 }
 
 func (cfg *Config) RegisterFlags(f *flag.FlagSet) {
@@ -346,12 +346,22 @@ func (c *compactedIndex) IndexChunk(chk chunk.Chunk) (bool, error) {
 
 	approxKB := math.Round(float64(chk.Data.UncompressedSize()) / float64(1<<10))
 
+	var sketch *tsdbindex.ChunkSketch
+	if indexFormat, _ := c.periodConfig.TSDBFormat(); indexFormat >= tsdbindex.FormatV4 {
+		var err error
+		sketch, err = sketchChunk(c.ctx, chk, c.periodConfig.ChunkSketchField)
+		if err != nil {
+			return false, fmt.Errorf("sketching chunk: %w", err)
+		}
+	}
+
 	c.indexChunks[ls] = append(c.indexChunks[ls], tsdbindex.ChunkMeta{
 		Checksum: chk.Checksum,
 		MinTime:  int64(chk.From),
 		MaxTime:  int64(chk.Through),
 		KB:       uint32(approxKB),
 		Entries:  uint32(chk.Data.Entries()),
+		Sketch:   sketch,
 	})
 
 	return true, nil
@@ -380,7 +390,7 @@ func (c *compactedIndex) ToIndexFile() (shipperindex.Index, error) {
 				return nil, err
 			}
 			if !chunkFound {
-				return nil, fmt.Errorf("could not drop non-existent chunk %x from series %s", chk, seriesID)
+				return nil, fmt.Errorf("could not drop non-existent chunk %x:%x:%x from series %s", chk.MinTime, chk.MaxTime, chk.Checksum, seriesID)
 			}
 		}
 	}
@@ -76,8 +76,8 @@ func (h *headIndexReader) LabelValues(name string, matchers ...*labels.Matcher)
 		return []string{}, nil
 	}
 
-	if len(matchers) == 0 {
-		return h.head.postings.LabelValues(name), nil
+	if onlyMatchersOn(name, matchers) {
+		return filterLabelValues(h.head.postings.LabelValues(name), matchers), nil
 	}
 
 	return labelValuesWithMatchers(h, name, matchers...)
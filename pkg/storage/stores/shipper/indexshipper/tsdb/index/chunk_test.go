@@ -592,7 +592,7 @@ func TestSearchWithPageMarkers(t *testing.T) {
 				decbuf := encoding.DecWrap(tsdb_enc.Decbuf{B: primary.Get()})
 				dec := newDecoder(nil, 0)
 				dst := []ChunkMeta{}
-				require.Nil(t, dec.readChunksV3(&decbuf, tc.mint, tc.maxt, &dst))
+				require.Nil(t, dec.readChunksV3(FormatV3, &decbuf, tc.mint, tc.maxt, &dst))
 				require.Equal(t, tc.exp, dst)
 			})
 		}
@@ -55,6 +55,9 @@ const (
 	// FormatV3 represents 3 version of index. It adds support for
 	// paging through batches of chunks within a series
 	FormatV3 = 3
+	// FormatV4 represents 4 version of index. It adds an optional
+	// sketch of the content of each chunk
+	FormatV4 = 4
 
 	IndexFilename = "index"
 
@@ -131,8 +134,9 @@ type Creator struct {
 	postingsStart uint64 // Due to padding, can differ from TOC entry.
 
 	// Reusable memory.
-	buf1 encoding.Encbuf
-	buf2 encoding.Encbuf
+	buf1      encoding.Encbuf
+	buf2      encoding.Encbuf
+	sketchBuf encoding.Encbuf
 
 	numSymbols  int
 	symbols     *Symbols
@@ -563,6 +567,9 @@ func (w *Creator) addChunksV3(chunks []ChunkMeta, primary, scratch *encoding.Enc
 			t0 = c.MaxTime
 
 			scratch.PutBE32(c.Checksum)
+			if w.Version >= FormatV4 {
+				putChunkSketch(scratch, &w.sketchBuf, c.Sketch)
+			}
 
 			// test if this is the last chunk in the page
 			if i%chunkPageSize == chunkPageSize-1 {
@@ -1281,7 +1288,7 @@ func newReader(b ByteSlice, c io.Closer) (*Reader, error) {
 	}
 	r.version = int(r.b.Range(4, 5)[0])
 
-	if r.version != FormatV1 && r.version != FormatV2 && r.version != FormatV3 {
+	if r.version != FormatV1 && r.version != FormatV2 && r.version != FormatV3 && r.version != FormatV4 {
 		return nil, errors.Errorf("unknown index file version %d", r.version)
 	}
 
@@ -1627,10 +1634,20 @@ func (r *Reader) SortedLabelValues(name string, matchers ...*labels.Matcher) ([]
 }
 
 // LabelValues returns value tuples that exist for the given label name.
-// TODO(replay): Support filtering by matchers
+// Only matchers on the given label name are supported, they filter the returned values.
 func (r *Reader) LabelValues(name string, matchers ...*labels.Matcher) ([]string, error) {
-	if len(matchers) > 0 {
-		return nil, errors.Errorf("matchers parameter is not implemented: %+v", matchers)
+	for _, m := range matchers {
+		if m.Name != name {
+			return nil, errors.Errorf("matchers on other labels than %q are not implemented: %+v", name, matchers)
+		}
+	}
+	matches := func(v string) bool {
+		for _, m := range matchers {
+			if !m.Matches(v) {
+				return false
+			}
+		}
+		return true
 	}
 
 	if r.version == FormatV1 {
@@ -1640,7 +1657,9 @@ func (r *Reader) LabelValues(name string, matchers ...*labels.Matcher) ([]string
 		}
 		values := make([]string, 0, len(e))
 		for k := range e {
-			values = append(values, k)
+			if matches(k) {
+				values = append(values, k)
+			}
 		}
 		return values, nil
 
@@ -1671,7 +1690,9 @@ func (r *Reader) LabelValues(name string, matchers ...*labels.Matcher) ([]string
 			d.Skip(skip)
 		}
 		s := string(d.UvarintBytes()) // Label value.
-		values = append(values, s)
+		if matches(s) {
+			values = append(values, s)
+		}
 		if s == lastVal {
 			break
 		}
@@ -2097,7 +2118,7 @@ func buildChunkSamples(d encoding.Decbuf, numChunks int, info *chunkSamples) err
 
 	chunkPos := bufLen - d.Len()
 	chunkMeta := &ChunkMeta{}
-	if err := readChunkMeta(&d, 0, chunkMeta); err != nil {
+	if err := readChunkMeta(&d, FormatV2, 0, chunkMeta); err != nil {
 		return errors.Wrapf(d.Err(), "read meta for chunk %d", 0)
 	}
 
@@ -2113,7 +2134,7 @@ func buildChunkSamples(d encoding.Decbuf, numChunks int, info *chunkSamples) err
 
 	for i := 1; i < numChunks; i++ {
 		chunkPos = bufLen - d.Len()
-		if err := readChunkMeta(&d, t0, chunkMeta); err != nil {
+		if err := readChunkMeta(&d, FormatV2, t0, chunkMeta); err != nil {
 			return errors.Wrapf(d.Err(), "read meta for chunk %d", i)
 		}
 		if chunkMeta.MaxTime > largestMaxt {
@@ -2225,19 +2246,19 @@ func (dec *Decoder) ChunkStats(version int, b []byte, seriesRef storage.SeriesRe
 
 func (dec *Decoder) readChunkStats(version int, d *encoding.Decbuf, seriesRef storage.SeriesRef, from, through int64) (ChunkStats, error) {
 	if version > FormatV2 {
-		return dec.readChunkStatsV3(d, from, through)
+		return dec.readChunkStatsV3(version, d, from, through)
 	}
 	return dec.readChunkStatsPriorV3(d, seriesRef, from, through)
 }
 
-func (dec *Decoder) readChunkStatsV3(d *encoding.Decbuf, from, through int64) (res ChunkStats, err error) {
+func (dec *Decoder) readChunkStatsV3(version int, d *encoding.Decbuf, from, through int64) (res ChunkStats, err error) {
 	nChunks := d.Uvarint()
 	markersLn := int(d.Be32()) // markersLn
 	startMarkers := d.Len()
 
 	if nChunks < dec.maxChunksToBypassMarkerLookup {
 		d.Skip(markersLn)
-		return dec.accumulateChunkStats(d, nChunks, from, through)
+		return dec.accumulateChunkStats(version, d, nChunks, from, through)
 	}
 
 	nMarkers := d.Uvarint()
@@ -2295,9 +2316,9 @@ func (dec *Decoder) readChunkStatsV3(d *encoding.Decbuf, from, through int64) (r
 				// but this doesn't reset at page boundaries
 				// (maybe it should for more ergonomic programming).
 				// instead, we can just force the min-time to the page's min-time
-				err = readChunkMetaWithForcedMintime(d, curMarker.MinTime, chunkMeta, true)
+				err = readChunkMetaWithForcedMintime(d, version, curMarker.MinTime, chunkMeta, true)
 			} else {
-				err = readChunkMeta(d, prevMaxT, chunkMeta)
+				err = readChunkMeta(d, version, prevMaxT, chunkMeta)
 			}
 			if err != nil {
 				return res, errors.Wrap(d.Err(), "read meta for chunk")
@@ -2317,11 +2338,11 @@ func (dec *Decoder) readChunkStatsV3(d *encoding.Decbuf, from, through int64) (r
 	return res, d.Err()
 }
 
-func (dec *Decoder) accumulateChunkStats(d *encoding.Decbuf, nChunks int, from, through int64) (res ChunkStats, err error) {
+func (dec *Decoder) accumulateChunkStats(version int, d *encoding.Decbuf, nChunks int, from, through int64) (res ChunkStats, err error) {
 	var prevMaxT int64
 	chunkMeta := &ChunkMeta{}
 	for i := 0; i < nChunks; i++ {
-		if err := readChunkMeta(d, prevMaxT, chunkMeta); err != nil {
+		if err := readChunkMeta(d, version, prevMaxT, chunkMeta); err != nil {
 			return res, errors.Wrap(d.Err(), "read meta for chunk")
 		}
 		prevMaxT = chunkMeta.MaxTime
@@ -2373,12 +2394,12 @@ func (dec *Decoder) Series(version int, b []byte, seriesRef storage.SeriesRef, f
 func (dec *Decoder) readChunks(version int, d *encoding.Decbuf, seriesRef storage.SeriesRef, from int64, through int64, chks *[]ChunkMeta) error {
 	// read chunks based on fmt
 	if version > FormatV2 {
-		return dec.readChunksV3(d, from, through, chks)
+		return dec.readChunksV3(version, d, from, through, chks)
 	}
 	return dec.readChunksPriorV3(d, seriesRef, from, through, chks)
 }
 
-func (dec *Decoder) readChunksV3(d *encoding.Decbuf, from int64, through int64, chks *[]ChunkMeta) error {
+func (dec *Decoder) readChunksV3(version int, d *encoding.Decbuf, from int64, through int64, chks *[]ChunkMeta) error {
 	nChunks := d.Uvarint()
 	chunksRemaining := nChunks
 
@@ -2430,9 +2451,9 @@ iterate:
 		chunkMeta := &ChunkMeta{}
 		var err error
 		if i == 0 && forceMinTime {
-			err = readChunkMetaWithForcedMintime(d, marker.MinTime, chunkMeta, true)
+			err = readChunkMetaWithForcedMintime(d, version, marker.MinTime, chunkMeta, true)
 		} else {
-			err = readChunkMeta(d, prevMaxT, chunkMeta)
+			err = readChunkMeta(d, version, prevMaxT, chunkMeta)
 		}
 		if err != nil {
 			return errors.Wrapf(d.Err(), "read meta for chunk %d", nChunks-chunksRemaining+i)
@@ -2469,7 +2490,7 @@ func (dec *Decoder) readChunksPriorV3(d *encoding.Decbuf, seriesRef storage.Seri
 	d.Skip(cs.offset)
 
 	chunkMeta := &ChunkMeta{}
-	if err := readChunkMeta(d, cs.prevChunkMaxt, chunkMeta); err != nil {
+	if err := readChunkMeta(d, FormatV2, cs.prevChunkMaxt, chunkMeta); err != nil {
 		return errors.Wrapf(d.Err(), "read meta for chunk %d", cs.idx)
 	}
 
@@ -2479,7 +2500,7 @@ func (dec *Decoder) readChunksPriorV3(d *encoding.Decbuf, seriesRef storage.Seri
 	t0 := chunkMeta.MaxTime
 
 	for i := cs.idx + 1; i < k; i++ {
-		if err := readChunkMeta(d, t0, chunkMeta); err != nil {
+		if err := readChunkMeta(d, FormatV2, t0, chunkMeta); err != nil {
 			return errors.Wrapf(d.Err(), "read meta for chunk %d", cs.idx)
 		}
 		t0 = chunkMeta.MaxTime
@@ -2493,14 +2514,14 @@ func (dec *Decoder) readChunksPriorV3(d *encoding.Decbuf, seriesRef storage.Seri
 	return d.Err()
 }
 
-func readChunkMeta(d *encoding.Decbuf, prevChunkMaxt int64, chunkMeta *ChunkMeta) error {
+func readChunkMeta(d *encoding.Decbuf, version int, prevChunkMaxt int64, chunkMeta *ChunkMeta) error {
 	// Decode the diff against previous chunk as varint
 	// instead of uvarint because chunks may overlap
 	mint := d.Varint64() + prevChunkMaxt
-	return readChunkMetaWithForcedMintime(d, mint, chunkMeta, false)
+	return readChunkMetaWithForcedMintime(d, version, mint, chunkMeta, false)
 }
 
-func readChunkMetaWithForcedMintime(d *encoding.Decbuf, mint int64, chunkMeta *ChunkMeta, decodeMinT bool) error {
+func readChunkMetaWithForcedMintime(d *encoding.Decbuf, version int, mint int64, chunkMeta *ChunkMeta, decodeMinT bool) error {
 	if decodeMinT {
 		// skip the mint delta since we're forcing, but still need to
 		// remove the bytes from our buffer
@@ -2512,6 +2533,15 @@ func readChunkMetaWithForcedMintime(d *encoding.Decbuf, mint int64, chunkMeta *C
 	chunkMeta.Entries = uint32(d.Uvarint64())
 	chunkMeta.Checksum = d.Be32()
 
+	chunkMeta.Sketch = nil
+	if version >= FormatV4 {
+		sketch, err := readChunkSketch(d)
+		if err != nil {
+			return err
+		}
+		chunkMeta.Sketch = sketch
+	}
+
 	if d.Err() != nil {
 		return d.Err()
 	}
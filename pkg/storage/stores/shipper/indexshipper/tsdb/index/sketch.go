@@ -0,0 +1,196 @@
+package index
+
+import (
+	"time"
+
+	"github.com/cespare/xxhash/v2"
+	"github.com/pkg/errors"
+
+	"github.com/grafana/loki/v3/pkg/util/constants"
+	"github.com/grafana/loki/v3/pkg/util/encoding"
+)
+
+// SketchLevels are the detected log levels counted by the level histogram of the ChunkSketch.
+var SketchLevels = [...]string{
+	constants.LogLevelTrace,
+	constants.LogLevelDebug,
+	constants.LogLevelInfo,
+	constants.LogLevelWarn,
+	constants.LogLevelError,
+	constants.LogLevelCritical,
+	constants.LogLevelFatal,
+	constants.LogLevelUnknown,
+}
+
+const (
+	sketchHasValues byte = 1 << iota
+	sketchNumbers
+	sketchDurations
+)
+
+// ChunkSketch summarizes the content of a chunk, so that the queries which can't
+// match any of its entries skip it. Sketches are only stored by FormatV4.
+type ChunkSketch struct {
+	// MaxLineLength is the length in bytes of the longest line of the chunk.
+	MaxLineLength uint32
+
+	// Levels counts the entries of the chunk per detected level, in the order of SketchLevels.
+	Levels [len(SketchLevels)]uint32
+	// LevelBloom is a bloom filter of all the detected levels of the chunk, including the
+	// ones which are not counted by Levels.
+	LevelBloom uint64
+
+	// Field is the hash of the name of the numeric field sketched by the chunk, zero if none.
+	Field uint64
+	// HasValues is true when at least one entry has a value of the field.
+	HasValues bool
+	// Numbers is true when all the values of the field are numbers, bounded by MinNumber and MaxNumber.
+	Numbers              bool
+	MinNumber, MaxNumber float64
+	// Durations is true when all the values of the field are durations, bounded by MinDuration and MaxDuration.
+	Durations                bool
+	MinDuration, MaxDuration time.Duration
+}
+
+// SketchField returns the hash identifying a sketched field.
+func SketchField(name string) uint64 {
+	return xxhash.Sum64String(name)
+}
+
+// AddLevel counts an entry with the given detected level.
+func (s *ChunkSketch) AddLevel(level string) {
+	for i, l := range SketchLevels {
+		if l == level {
+			s.Levels[i]++
+			break
+		}
+	}
+	s.LevelBloom |= levelBloomBits(level)
+}
+
+// MayHaveLevel returns false if no entry of the chunk has the given detected level.
+func (s *ChunkSketch) MayHaveLevel(level string) bool {
+	for i, l := range SketchLevels {
+		if l == level {
+			return s.Levels[i] > 0
+		}
+	}
+	bits := levelBloomBits(level)
+	return s.LevelBloom&bits == bits
+}
+
+// levelBloomBits returns the 3 bits of the level bloom filter set by a level.
+func levelBloomBits(level string) uint64 {
+	h := xxhash.Sum64String(level)
+	return 1<<(h&63) | 1<<((h>>6)&63) | 1<<((h>>12)&63)
+}
+
+// AddValue accounts for a value of the sketched field. Values which are neither numbers nor
+// durations prevent from using the bounds of the sketch.
+func (s *ChunkSketch) AddValue(number float64, isNumber bool, duration time.Duration, isDuration bool) {
+	if !s.HasValues {
+		s.HasValues = true
+		s.Numbers, s.MinNumber, s.MaxNumber = isNumber, number, number
+		s.Durations, s.MinDuration, s.MaxDuration = isDuration, duration, duration
+		return
+	}
+
+	s.Numbers = s.Numbers && isNumber
+	if s.Numbers {
+		s.MinNumber = min(s.MinNumber, number)
+		s.MaxNumber = max(s.MaxNumber, number)
+	}
+	s.Durations = s.Durations && isDuration
+	if s.Durations {
+		s.MinDuration = min(s.MinDuration, duration)
+		s.MaxDuration = max(s.MaxDuration, duration)
+	}
+}
+
+func (s *ChunkSketch) encode(e *encoding.Encbuf) {
+	e.PutUvarint32(s.MaxLineLength)
+
+	e.PutUvarint(len(s.Levels))
+	for _, n := range s.Levels {
+		e.PutUvarint32(n)
+	}
+	e.PutBE64(s.LevelBloom)
+
+	e.PutBE64(s.Field)
+	var flags byte
+	if s.HasValues {
+		flags |= sketchHasValues
+	}
+	if s.Numbers {
+		flags |= sketchNumbers
+	}
+	if s.Durations {
+		flags |= sketchDurations
+	}
+	e.PutByte(flags)
+	if s.Numbers {
+		e.PutBEFloat64(s.MinNumber)
+		e.PutBEFloat64(s.MaxNumber)
+	}
+	if s.Durations {
+		e.PutVarint64(int64(s.MinDuration))
+		e.PutVarint64(int64(s.MaxDuration))
+	}
+}
+
+func (s *ChunkSketch) decode(d *encoding.Decbuf) error {
+	s.MaxLineLength = d.Uvarint32()
+
+	nLevels := d.Uvarint()
+	for i := 0; i < nLevels; i++ {
+		n := d.Uvarint32()
+		// levels added by later versions are ignored.
+		if i < len(s.Levels) {
+			s.Levels[i] = n
+		}
+	}
+	s.LevelBloom = d.Be64()
+
+	s.Field = d.Be64()
+	flags := d.Byte()
+	s.HasValues = flags&sketchHasValues != 0
+	s.Numbers = flags&sketchNumbers != 0
+	s.Durations = flags&sketchDurations != 0
+	if s.Numbers {
+		s.MinNumber = d.Be64Float64()
+		s.MaxNumber = d.Be64Float64()
+	}
+	if s.Durations {
+		s.MinDuration = time.Duration(d.Varint64())
+		s.MaxDuration = time.Duration(d.Varint64())
+	}
+	return d.Err()
+}
+
+// putChunkSketch writes the length prefixed sketch of a chunk, or a zero length if it has none.
+func putChunkSketch(e, scratch *encoding.Encbuf, s *ChunkSketch) {
+	if s == nil {
+		e.PutUvarint(0)
+		return
+	}
+	scratch.Reset()
+	s.encode(scratch)
+	e.PutUvarintBytes(scratch.Get())
+}
+
+// readChunkSketch reads the length prefixed sketch of a chunk, returning nil if it has none.
+func readChunkSketch(d *encoding.Decbuf) (*ChunkSketch, error) {
+	n := d.Uvarint()
+	if n == 0 || d.Err() != nil {
+		return nil, d.Err()
+	}
+	sd := encoding.DecWith(d.Bytes(n))
+	if d.Err() != nil {
+		return nil, d.Err()
+	}
+	s := &ChunkSketch{}
+	if err := s.decode(&sd); err != nil {
+		return nil, errors.Wrap(err, "read chunk sketch")
+	}
+	return s, nil
+}
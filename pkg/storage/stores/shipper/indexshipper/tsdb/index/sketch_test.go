@@ -0,0 +1,105 @@
+package index
+
+import (
+	"testing"
+	"time"
+
+	tsdb_enc "github.com/prometheus/prometheus/tsdb/encoding"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/util/encoding"
+)
+
+func TestChunkSketch_Levels(t *testing.T) {
+	var s ChunkSketch
+	s.AddLevel("error")
+	s.AddLevel("error")
+	s.AddLevel("notice")
+
+	require.Equal(t, uint32(2), s.Levels[4])
+	require.True(t, s.MayHaveLevel("error"))
+	require.True(t, s.MayHaveLevel("notice"))
+	require.False(t, s.MayHaveLevel("info"))
+	require.False(t, s.MayHaveLevel("alert"))
+}
+
+func TestChunkSketch_AddValue(t *testing.T) {
+	var s ChunkSketch
+	s.AddValue(3, true, 0, false)
+	s.AddValue(-1, true, 0, false)
+	require.True(t, s.HasValues)
+	require.True(t, s.Numbers)
+	require.False(t, s.Durations)
+	require.Equal(t, float64(-1), s.MinNumber)
+	require.Equal(t, float64(3), s.MaxNumber)
+
+	s = ChunkSketch{}
+	s.AddValue(0, true, 0, true)
+	s.AddValue(0, false, 2*time.Second, true)
+	s.AddValue(0, false, time.Second, true)
+	require.False(t, s.Numbers)
+	require.True(t, s.Durations)
+	require.Equal(t, time.Duration(0), s.MinDuration)
+	require.Equal(t, 2*time.Second, s.MaxDuration)
+
+	// a value which is neither a number nor a duration disables the bounds.
+	s.AddValue(0, false, 0, false)
+	require.True(t, s.HasValues)
+	require.False(t, s.Numbers)
+	require.False(t, s.Durations)
+}
+
+func TestChunkSketch_EncodeDecode(t *testing.T) {
+	for _, sketch := range []*ChunkSketch{
+		nil,
+		{MaxLineLength: 10, LevelBloom: 1 << 63},
+		{
+			MaxLineLength: 1 << 20,
+			Levels:        [len(SketchLevels)]uint32{1, 2, 3, 4, 5, 6, 7, 8},
+			Field:         SketchField("duration"),
+			HasValues:     true,
+			Durations:     true,
+			MinDuration:   -time.Second,
+			MaxDuration:   time.Hour,
+		},
+		{
+			Field:     SketchField("size"),
+			HasValues: true,
+			Numbers:   true,
+			MinNumber: 0.5,
+			MaxNumber: 1e9,
+		},
+	} {
+		var e, scratch encoding.Encbuf
+		putChunkSketch(&e, &scratch, sketch)
+		e.PutBE32(42)
+
+		d := encoding.DecWrap(tsdb_enc.Decbuf{B: e.Get()})
+		decoded, err := readChunkSketch(&d)
+		require.NoError(t, err)
+		require.Equal(t, sketch, decoded)
+		require.Equal(t, uint32(42), d.Be32())
+	}
+}
+
+func TestAddChunksV4(t *testing.T) {
+	sketch := &ChunkSketch{MaxLineLength: 12, Field: SketchField("duration"), HasValues: true}
+	chks := []ChunkMeta{
+		{MinTime: 0, MaxTime: 1, Checksum: 1, Sketch: sketch},
+		{MinTime: 1, MaxTime: 2, Checksum: 2},
+		{MinTime: 2, MaxTime: 3, Checksum: 3, Sketch: sketch},
+	}
+
+	for _, pageSize := range []int{1, 2, 16} {
+		var w Creator
+		w.Version = FormatV4
+		primary := encoding.EncWrap(tsdb_enc.Encbuf{B: make([]byte, 0)})
+		scratch := encoding.EncWrap(tsdb_enc.Encbuf{B: make([]byte, 0)})
+		w.addChunks(chks, &primary, &scratch, pageSize)
+
+		decbuf := encoding.DecWrap(tsdb_enc.Decbuf{B: primary.Get()})
+		dst := []ChunkMeta{}
+		require.NoError(t, newDecoder(nil, 0).readChunksV3(FormatV4, &decbuf, 2, 100, &dst))
+		require.Equal(t, chks[1:], dst)
+	}
+}
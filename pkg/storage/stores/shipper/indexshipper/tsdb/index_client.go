@@ -122,6 +122,9 @@ func (c *IndexClient) GetChunkRefs(ctx context.Context, userID string, from, thr
 		return nil, err
 	}
 
+	// skip the sketched chunks which can't match the filters of the query.
+	ctx = withChunkSketchFilter(ctx, predicate.Plan().AST)
+
 	// TODO(owen-d): use a pool to reduce allocs here
 	chks, err := c.idx.GetChunkRefs(ctx, userID, from, through, nil, shard, matchers...)
 	if err != nil {
@@ -76,7 +76,7 @@ func BenchmarkIndexClient_Stats(b *testing.B) {
 		Start:        0,
 		End:          math.MaxInt64,
 		PeriodConfig: &config.PeriodConfig{},
-	})
+	}, nil)
 
 	indexClient := NewIndexClient(idx, IndexClientOptions{UseBloomFilters: true}, &fakeLimits{})
 
@@ -143,7 +143,7 @@ func TestIndexClient_Stats(t *testing.T) {
 		Start:        0,
 		End:          math.MaxInt64,
 		PeriodConfig: &config.PeriodConfig{},
-	})
+	}, nil)
 
 	indexClient := NewIndexClient(idx, IndexClientOptions{UseBloomFilters: true}, &fakeLimits{})
 
@@ -272,7 +272,7 @@ func TestIndexClient_Volume(t *testing.T) {
 		Start:        0,
 		End:          math.MaxInt64,
 		PeriodConfig: &config.PeriodConfig{},
-	})
+	}, nil)
 
 	limits := &fakeLimits{volumeMaxSeries: 5}
 	indexClient := NewIndexClient(idx, IndexClientOptions{UseBloomFilters: true}, limits)
@@ -10,6 +10,7 @@ import (
 	"github.com/prometheus/common/model"
 	"github.com/prometheus/prometheus/model/labels"
 
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
 	"github.com/grafana/loki/v3/pkg/storage/chunk"
 	"github.com/grafana/loki/v3/pkg/storage/config"
 	shipperindex "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/index"
@@ -25,10 +26,12 @@ type indexShipperQuerier struct {
 	shipper     indexShipperIterator
 	chunkFilter chunk.RequestChunkFilterer
 	tableRange  config.TableRange
+	// tombstones is nil when the tombstones written by the compactor are not applied.
+	tombstones *tombstonesReader
 }
 
-func newIndexShipperQuerier(shipper indexShipperIterator, tableRange config.TableRange) Index {
-	return &indexShipperQuerier{shipper: shipper, tableRange: tableRange}
+func newIndexShipperQuerier(shipper indexShipperIterator, tableRange config.TableRange, tombstones *tombstonesReader) Index {
+	return &indexShipperQuerier{shipper: shipper, tableRange: tableRange, tombstones: tombstones}
 }
 
 type indexIterFunc func(func(context.Context, Index) error) error
@@ -42,6 +45,10 @@ func (i *indexShipperQuerier) indices(ctx context.Context, from, through model.T
 		// Ensure we query both per tenant and multitenant TSDBs
 		idxBuckets := IndexBuckets(from, through, []config.TableRange{i.tableRange})
 		for _, bkt := range idxBuckets {
+			var ts *tombstones.Tombstones
+			if i.tombstones != nil {
+				ts = i.tombstones.get(ctx, bkt.Prefix, user)
+			}
 			if err := i.shipper.ForEachConcurrent(ctx, bkt.Prefix, user, func(multitenant bool, idx shipperindex.Index) error {
 				impl, ok := idx.(Index)
 				if !ok {
@@ -50,6 +57,7 @@ func (i *indexShipperQuerier) indices(ctx context.Context, from, through model.T
 				if multitenant {
 					impl = NewMultiTenantIndex(impl)
 				}
+				impl = newTombstonedIndex(impl, ts)
 
 				return f(ctx, impl)
 			}); err != nil {
@@ -336,6 +336,34 @@ func labelValuesWithMatchers(r IndexReader, name string, matchers ...*labels.Mat
 	return values, nil
 }
 
+// onlyMatchersOn returns true if all matchers select values of the given label name.
+func onlyMatchersOn(name string, matchers []*labels.Matcher) bool {
+	for _, m := range matchers {
+		if m.Name != name {
+			return false
+		}
+	}
+	return true
+}
+
+// filterLabelValues returns the values matching all matchers.
+func filterLabelValues(values []string, matchers []*labels.Matcher) []string {
+	if len(matchers) == 0 {
+		return values
+	}
+	res := make([]string, 0, len(values))
+Values:
+	for _, v := range values {
+		for _, m := range matchers {
+			if !m.Matches(v) {
+				continue Values
+			}
+		}
+		res = append(res, v)
+	}
+	return res
+}
+
 func labelNamesWithMatchers(r IndexReader, matchers ...*labels.Matcher) ([]string, error) {
 	p, err := PostingsForMatchers(r, nil, matchers...)
 	if err != nil {
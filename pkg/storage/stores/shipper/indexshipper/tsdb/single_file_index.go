@@ -218,8 +218,12 @@ func (i *TSDBIndex) GetChunkRefs(ctx context.Context, userID string, from, throu
 	}
 	res = res[:0]
 
+	sketchFilter := chunkSketchFilterFromContext(ctx)
 	if err := i.ForSeries(ctx, "", fpFilter, from, through, func(_ labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) (stop bool) {
 		for _, chk := range chks {
+			if !sketchFilter.Matches(chk) {
+				continue
+			}
 
 			res = append(res, ChunkRef{
 				User:        userID, // assumed to be the same, will be enforced by caller.
@@ -268,8 +272,9 @@ func (i *TSDBIndex) LabelNames(_ context.Context, _ string, _, _ model.Time, mat
 }
 
 func (i *TSDBIndex) LabelValues(_ context.Context, _ string, _, _ model.Time, name string, matchers ...*labels.Matcher) ([]string, error) {
-	if len(matchers) == 0 {
-		return i.reader.LabelValues(name)
+	// Matchers on the requested label only filter its values and do not require reading postings.
+	if onlyMatchersOn(name, matchers) {
+		return i.reader.LabelValues(name, matchers...)
 	}
 	return labelValuesWithMatchers(i.reader, name, matchers...)
 }
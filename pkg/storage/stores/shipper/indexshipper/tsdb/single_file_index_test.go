@@ -202,6 +202,12 @@ func TestSingleIdx(t *testing.T) {
 				require.Nil(t, err)
 				require.Equal(t, []string{"bar"}, vs)
 			})
+
+			t.Run("LabelValuesWithValueMatchers", func(t *testing.T) {
+				vs, err := idx.LabelValues(context.Background(), "fake", 9, 10, "foo", labels.MustNewMatcher(labels.MatchRegexp, "foo", "bar.+"))
+				require.Nil(t, err)
+				require.Equal(t, []string{"bard"}, vs)
+			})
 		})
 	}
 }
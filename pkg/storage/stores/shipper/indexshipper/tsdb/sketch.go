@@ -0,0 +1,220 @@
+package tsdb
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	v1 "github.com/grafana/loki/v3/pkg/storage/bloom/v1"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+	"github.com/grafana/loki/v3/pkg/util/constants"
+)
+
+// sketchChunk returns the sketch of the content of a chunk, with the bounds of the values of field
+// if not empty. It returns nil if the data of the chunk can't be read.
+func sketchChunk(ctx context.Context, c chunk.Chunk, field string) (*index.ChunkSketch, error) {
+	facade, ok := c.Data.(*chunkenc.Facade)
+	if !ok || facade.LokiChunk() == nil {
+		return nil, nil
+	}
+
+	sketch := &index.ChunkSketch{}
+	if field != "" {
+		sketch.Field = index.SketchField(field)
+	}
+	streamLevel := c.Metric.Get(constants.LevelLabel)
+	streamValue, hasStreamValue := "", false
+	if field != "" && c.Metric.Has(field) {
+		streamValue, hasStreamValue = c.Metric.Get(field), true
+	}
+
+	itr, err := facade.LokiChunk().Iterator(ctx, time.Unix(0, 0), time.Unix(0, math.MaxInt64), logproto.FORWARD, log.NewNoopPipeline().ForStream(c.Metric))
+	if err != nil {
+		return nil, err
+	}
+	defer itr.Close()
+
+	for itr.Next() {
+		entry := itr.At()
+		sketch.MaxLineLength = max(sketch.MaxLineLength, uint32(len(entry.Line)))
+		if hasStreamValue {
+			addSketchValue(sketch, streamValue)
+		}
+		if streamLevel != "" {
+			// the detected level of the stream takes precedence over the one of the entry.
+			sketch.AddLevel(streamLevel)
+		}
+		for _, kv := range entry.StructuredMetadata {
+			switch {
+			case kv.Name == constants.LevelLabel && streamLevel == "":
+				sketch.AddLevel(kv.Value)
+			case kv.Name == field:
+				addSketchValue(sketch, kv.Value)
+			}
+		}
+	}
+	return sketch, itr.Err()
+}
+
+// addSketchValue adds a value of the sketched field, parsed the way the numeric and duration label filters do.
+func addSketchValue(sketch *index.ChunkSketch, value string) {
+	number, err := strconv.ParseFloat(value, 64)
+	isNumber := err == nil && !math.IsNaN(number)
+	duration, err := time.ParseDuration(value)
+	sketch.AddValue(number, isNumber, duration, err == nil)
+}
+
+type chunkSketchFilterKey struct{}
+
+// chunkSketchFilter skips the chunks whose sketch proves that none of their entries can pass
+// the line and label filters applied before the first parser of a query.
+type chunkSketchFilter struct {
+	lineMatchers []v1.LabelMatcher
+	labelFilters []log.LabelFilterer
+}
+
+// newChunkSketchFilter returns the sketch filter of a query, or nil if none of its filters can skip chunks.
+func newChunkSketchFilter(expr syntax.Expr) *chunkSketchFilter {
+	if expr == nil {
+		return nil
+	}
+
+	f := &chunkSketchFilter{lineMatchers: v1.ExtractTestableLineMatchers(expr)}
+	for _, filter := range syntax.ExtractLabelFiltersBeforeParser(expr) {
+		f.labelFilters = append(f.labelFilters, filter.LabelFilterer)
+	}
+	if len(f.lineMatchers) == 0 && len(f.labelFilters) == 0 {
+		return nil
+	}
+	return f
+}
+
+// withChunkSketchFilter returns a context skipping the chunks which can't match the filters of expr
+// when their chunk refs are read from the index.
+func withChunkSketchFilter(ctx context.Context, expr syntax.Expr) context.Context {
+	f := newChunkSketchFilter(expr)
+	if f == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, chunkSketchFilterKey{}, f)
+}
+
+func chunkSketchFilterFromContext(ctx context.Context) *chunkSketchFilter {
+	f, _ := ctx.Value(chunkSketchFilterKey{}).(*chunkSketchFilter)
+	return f
+}
+
+// Matches returns false if the chunk is sketched and none of its entries can match the filters.
+func (f *chunkSketchFilter) Matches(chk index.ChunkMeta) bool {
+	if f == nil || chk.Sketch == nil {
+		return true
+	}
+	for _, m := range f.lineMatchers {
+		if !lineMayMatch(chk.Sketch, m) {
+			return false
+		}
+	}
+	for _, filter := range f.labelFilters {
+		if !labelFilterMayMatch(chk.Sketch, filter) {
+			return false
+		}
+	}
+	return true
+}
+
+func lineMayMatch(sketch *index.ChunkSketch, m v1.LabelMatcher) bool {
+	switch m := m.(type) {
+	case v1.LineMatcher:
+		// lines shorter than a literal can't contain it.
+		return len(m.Value) <= int(sketch.MaxLineLength)
+	case v1.OrLabelMatcher:
+		return lineMayMatch(sketch, m.Left) || lineMayMatch(sketch, m.Right)
+	default:
+		return true
+	}
+}
+
+func labelFilterMayMatch(sketch *index.ChunkSketch, filter log.LabelFilterer) bool {
+	switch filter := filter.(type) {
+	case *log.BinaryLabelFilter:
+		if filter.And {
+			return labelFilterMayMatch(sketch, filter.Left) && labelFilterMayMatch(sketch, filter.Right)
+		}
+		return labelFilterMayMatch(sketch, filter.Left) || labelFilterMayMatch(sketch, filter.Right)
+	case *log.StringLabelFilter:
+		return levelMayMatch(sketch, filter.Matcher)
+	case *log.LineFilterLabelFilter:
+		return levelMayMatch(sketch, filter.Matcher)
+	case *log.NumericLabelFilter:
+		if sketch.Field != index.SketchField(filter.Name) {
+			return true
+		}
+		// entries without the field are filtered out, the ones which can't be parsed are kept.
+		if !sketch.HasValues {
+			return false
+		}
+		return !sketch.Numbers || boundsMayMatch(filter.Type, sketch.MinNumber, sketch.MaxNumber, filter.Value)
+	case *log.DurationLabelFilter:
+		if sketch.Field != index.SketchField(filter.Name) {
+			return true
+		}
+		if !sketch.HasValues {
+			return false
+		}
+		return !sketch.Durations || boundsMayMatch(filter.Type, sketch.MinDuration, sketch.MaxDuration, filter.Value)
+	default:
+		return true
+	}
+}
+
+// levelMayMatch tests the equality and set matchers of the detected level against the sketch.
+func levelMayMatch(sketch *index.ChunkSketch, m *labels.Matcher) bool {
+	if m.Name != constants.LevelLabel {
+		return true
+	}
+
+	var values []string
+	switch m.Type {
+	case labels.MatchEqual:
+		values = []string{m.Value}
+	case labels.MatchRegexp:
+		values = m.SetMatches()
+	}
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		// entries without a detected level match the empty value.
+		if v == "" || sketch.MayHaveLevel(v) {
+			return true
+		}
+	}
+	return false
+}
+
+func boundsMayMatch[T float64 | time.Duration](ty log.LabelFilterType, minValue, maxValue, value T) bool {
+	switch ty {
+	case log.LabelFilterEqual:
+		return minValue <= value && value <= maxValue
+	case log.LabelFilterNotEqual:
+		return minValue != value || maxValue != value
+	case log.LabelFilterGreaterThan:
+		return maxValue > value
+	case log.LabelFilterGreaterThanOrEqual:
+		return maxValue >= value
+	case log.LabelFilterLesserThan:
+		return minValue < value
+	case log.LabelFilterLesserThanOrEqual:
+		return minValue <= value
+	default:
+		return true
+	}
+}
@@ -0,0 +1,128 @@
+package tsdb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+)
+
+func TestSketchChunk(t *testing.T) {
+	memChunk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.Snappy, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256*1024, 0)
+	for i, e := range []struct {
+		line               string
+		structuredMetadata labels.Labels
+	}{
+		{line: "short", structuredMetadata: labels.FromStrings("detected_level", "error", "duration", "2s")},
+		{line: "a longer line", structuredMetadata: labels.FromStrings("detected_level", "notice", "duration", "500ms")},
+		{line: "no metadata"},
+	} {
+		_, err := memChunk.Append(&logproto.Entry{
+			Timestamp:          time.Unix(int64(i), 0),
+			Line:               e.line,
+			StructuredMetadata: logproto.FromLabelsToLabelAdapters(e.structuredMetadata),
+		})
+		require.NoError(t, err)
+	}
+	require.NoError(t, memChunk.Close())
+	lbs := labels.FromStrings("app", "foo")
+	c := chunk.NewChunk("fake", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(memChunk, 256*1024, 0), 0, model.TimeFromUnix(2))
+
+	sketch, err := sketchChunk(context.Background(), c, "duration")
+	require.NoError(t, err)
+	require.Equal(t, uint32(len("a longer line")), sketch.MaxLineLength)
+	require.Equal(t, uint32(1), sketch.Levels[4])
+	require.True(t, sketch.MayHaveLevel("notice"))
+	require.False(t, sketch.MayHaveLevel("info"))
+	require.Equal(t, index.SketchField("duration"), sketch.Field)
+	require.True(t, sketch.HasValues)
+	require.False(t, sketch.Numbers)
+	require.True(t, sketch.Durations)
+	require.Equal(t, 500*time.Millisecond, sketch.MinDuration)
+	require.Equal(t, 2*time.Second, sketch.MaxDuration)
+
+	// the detected level and the field of the stream apply to all its entries.
+	lbs = labels.FromStrings("app", "foo", "detected_level", "info", "size", "12")
+	c = chunk.NewChunk("fake", model.Fingerprint(lbs.Hash()), lbs, chunkenc.NewFacade(memChunk, 256*1024, 0), 0, model.TimeFromUnix(2))
+	sketch, err = sketchChunk(context.Background(), c, "size")
+	require.NoError(t, err)
+	require.Equal(t, uint32(3), sketch.Levels[2])
+	require.False(t, sketch.MayHaveLevel("error"))
+	require.True(t, sketch.Numbers)
+	require.Equal(t, float64(12), sketch.MinNumber)
+	require.Equal(t, float64(12), sketch.MaxNumber)
+}
+
+func TestIndexClient_GetChunkRefs_ChunkSketches(t *testing.T) {
+	dir := t.TempDir()
+	durations := func(minDuration, maxDuration time.Duration) *index.ChunkSketch {
+		s := &index.ChunkSketch{MaxLineLength: 100, Field: index.SketchField("duration")}
+		s.AddValue(0, false, minDuration, true)
+		s.AddValue(0, false, maxDuration, true)
+		s.AddLevel("info")
+		return s
+	}
+	unparsed := &index.ChunkSketch{MaxLineLength: 10, Field: index.SketchField("duration")}
+	unparsed.AddLevel("error")
+	unparsed.AddValue(0, false, 0, false)
+
+	b := NewBuilder(index.FormatV4)
+	lbs := labels.FromStrings("app", "foo")
+	b.AddSeries(lbs, model.Fingerprint(lbs.Hash()), []index.ChunkMeta{
+		{MinTime: 0, MaxTime: 10, Checksum: 1, Sketch: durations(time.Second, 2*time.Second)},
+		{MinTime: 10, MaxTime: 20, Checksum: 2, Sketch: durations(time.Second, 10*time.Second)},
+		{MinTime: 20, MaxTime: 30, Checksum: 3, Sketch: unparsed},
+		{MinTime: 30, MaxTime: 40, Checksum: 4},
+	})
+	dst, err := b.Build(context.Background(), dir, func(from, through model.Time, checksum uint32) Identifier {
+		return NewPrefixedIdentifier(SingleTenantTSDBIdentifier{TS: time.Now(), From: from, Through: through, Checksum: checksum}, dir, dir)
+	})
+	require.NoError(t, err)
+	idx, err := NewShippableTSDBFile(dst)
+	require.NoError(t, err)
+	indexClient := NewIndexClient(idx, IndexClientOptions{}, &fakeLimits{})
+
+	for _, tc := range []struct {
+		query     string
+		checksums []uint32
+	}{
+		{query: `{app="foo"}`, checksums: []uint32{1, 2, 3, 4}},
+		{query: `{app="foo"} | duration > 5s`, checksums: []uint32{2, 3, 4}},
+		{query: `{app="foo"} | duration <= 500ms`, checksums: []uint32{3, 4}},
+		{query: `{app="foo"} | detected_level="error"`, checksums: []uint32{3, 4}},
+		{query: `{app="foo"} | detected_level=~"error|info"`, checksums: []uint32{1, 2, 3, 4}},
+		{query: `{app="foo"} | detected_level="warn" or duration < 500ms`, checksums: []uint32{3, 4}},
+		{query: `{app="foo"} |= "this literal is longer than the short lines"`, checksums: []uint32{1, 2, 4}},
+		{query: `{app="foo"} | other > 5s`, checksums: []uint32{1, 2, 3, 4}},
+		// filters after a parser may apply to extracted labels.
+		{query: `{app="foo"} | logfmt | duration > 5s`, checksums: []uint32{1, 2, 3, 4}},
+		{query: `sum(rate({app="foo"} | duration > 5s [1m]))`, checksums: []uint32{2, 3, 4}},
+	} {
+		t.Run(tc.query, func(t *testing.T) {
+			expr, err := syntax.ParseExpr(tc.query)
+			require.NoError(t, err)
+
+			refs, err := indexClient.GetChunkRefs(context.Background(), "fake", 0, 100, chunk.NewPredicate(
+				[]*labels.Matcher{labels.MustNewMatcher(labels.MatchEqual, "app", "foo")},
+				&plan.QueryPlan{AST: expr},
+			))
+			require.NoError(t, err)
+			var checksums []uint32
+			for _, ref := range refs {
+				checksums = append(checksums, ref.Checksum)
+			}
+			require.Equal(t, tc.checksums, checksums)
+		})
+	}
+}
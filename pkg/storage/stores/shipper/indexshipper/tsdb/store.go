@@ -20,6 +20,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/storage/stores/index"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper"
 	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/downloads"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
 	tsdbindex "github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
 )
 
@@ -31,6 +32,7 @@ type store struct {
 	index.Reader
 	indexShipper indexshipper.IndexShipper
 	indexWriter  IndexWriter
+	schemaCfg    config.SchemaConfig
 	logger       log.Logger
 	stopOnce     sync.Once
 }
@@ -53,7 +55,8 @@ func NewStore(
 ) {
 
 	storeInstance := &store{
-		logger: logger,
+		schemaCfg: schemaCfg,
+		logger:    logger,
 	}
 
 	if err := storeInstance.init(name, prefix, indexShipperCfg, schemaCfg, objectClient, limits, tableRange, reg); err != nil {
@@ -137,7 +140,11 @@ func (s *store) init(name, prefix string, indexShipperCfg indexshipper.Config, s
 		s.indexWriter = failingIndexWriter{}
 	}
 
-	indices = append(indices, newIndexShipperQuerier(s.indexShipper, tableRange))
+	var tombstones *tombstonesReader
+	if indexShipperCfg.TombstonesRefreshInterval > 0 {
+		tombstones = newTombstonesReader(storage.NewIndexStorageClient(objectClient, prefix), indexShipperCfg.TombstonesRefreshInterval, s.logger)
+	}
+	indices = append(indices, newIndexShipperQuerier(s.indexShipper, tableRange, tombstones))
 	multiIndex := NewMultiIndex(IndexSlice(indices))
 
 	s.Reader = NewIndexClient(multiIndex, opts, limits)
@@ -156,7 +163,7 @@ func (s *store) Stop() {
 	})
 }
 
-func (s *store) IndexChunk(_ context.Context, _ model.Time, _ model.Time, chk chunk.Chunk) error {
+func (s *store) IndexChunk(ctx context.Context, _ model.Time, _ model.Time, chk chunk.Chunk) error {
 	// Always write the index to benefit durability via replication factor.
 	approxKB := math.Round(float64(chk.Data.UncompressedSize()) / float64(1<<10))
 	metas := tsdbindex.ChunkMetas{
@@ -168,12 +175,30 @@ func (s *store) IndexChunk(_ context.Context, _ model.Time, _ model.Time, chk ch
 			Entries:  uint32(chk.Data.Entries()),
 		},
 	}
+	if sketch, err := s.sketchChunk(ctx, chk); err != nil {
+		// the chunk is indexed without a sketch, and never skipped by queries.
+		level.Warn(s.logger).Log("msg", "failed to sketch chunk", "chunk", s.schemaCfg.ExternalKey(chk.ChunkRef), "err", err)
+	} else {
+		metas[0].Sketch = sketch
+	}
 	if err := s.indexWriter.Append(chk.UserID, chk.Metric, chk.ChunkRef.Fingerprint, metas); err != nil {
 		return errors.Wrap(err, "writing index entry")
 	}
 	return nil
 }
 
+// sketchChunk returns the sketch of the chunk if the index format of its period stores sketches.
+func (s *store) sketchChunk(ctx context.Context, chk chunk.Chunk) (*tsdbindex.ChunkSketch, error) {
+	periodConfig, err := s.schemaCfg.SchemaForTime(chk.From)
+	if err != nil {
+		return nil, err
+	}
+	if indexFormat, err := periodConfig.TSDBFormat(); err != nil || indexFormat < tsdbindex.FormatV4 {
+		return nil, err
+	}
+	return sketchChunk(ctx, chk, periodConfig.ChunkSketchField)
+}
+
 type failingIndexWriter struct{}
 
 func (f failingIndexWriter) Append(_ string, _ labels.Labels, _ uint64, _ tsdbindex.ChunkMetas) error {
@@ -0,0 +1,247 @@
+package tsdb
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/tsdb/index"
+)
+
+// tombstonesReader reads the tombstones written by the compactor for the tables of the store.
+// The tenants having tombstones in a table are listed again after the refresh interval,
+// and their tombstones are read again when they were modified since.
+type tombstonesReader struct {
+	client          storage.Client
+	refreshInterval time.Duration
+	logger          log.Logger
+
+	mtx    sync.Mutex
+	tables map[string]*tableTombstones
+}
+
+type tableTombstones struct {
+	mtx         sync.Mutex
+	refreshedAt time.Time
+	tenants     map[string]*tenantTombstones
+}
+
+type tenantTombstones struct {
+	modifiedAt time.Time
+	readAt     time.Time
+	tombstones *tombstones.Tombstones
+}
+
+func newTombstonesReader(client storage.Client, refreshInterval time.Duration, logger log.Logger) *tombstonesReader {
+	return &tombstonesReader{
+		client:          client,
+		refreshInterval: refreshInterval,
+		logger:          logger,
+		tables:          map[string]*tableTombstones{},
+	}
+}
+
+// get returns the tombstones of the tenant in the table, nil if it has none.
+// The last known tombstones are kept when they can't be read, so that queries don't fail.
+func (r *tombstonesReader) get(ctx context.Context, table, tenant string) *tombstones.Tombstones {
+	r.mtx.Lock()
+	tt, ok := r.tables[table]
+	if !ok {
+		tt = &tableTombstones{tenants: map[string]*tenantTombstones{}}
+		r.tables[table] = tt
+	}
+	r.mtx.Unlock()
+
+	tt.mtx.Lock()
+	defer tt.mtx.Unlock()
+
+	if time.Since(tt.refreshedAt) >= r.refreshInterval {
+		files, err := tombstones.Tenants(ctx, r.client, table)
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to list tombstones", "table", table, "err", err)
+		} else {
+			tenants := make(map[string]*tenantTombstones, len(files))
+			for _, file := range files {
+				t := tt.tenants[file.Name]
+				if t == nil {
+					t = &tenantTombstones{}
+				}
+				t.modifiedAt = file.ModifiedAt
+				tenants[file.Name] = t
+			}
+			tt.tenants = tenants
+			tt.refreshedAt = time.Now()
+		}
+	}
+
+	t, ok := tt.tenants[tenant]
+	if !ok {
+		return nil
+	}
+	if t.readAt.IsZero() || t.modifiedAt.After(t.readAt) {
+		readAt := time.Now()
+		ts, err := tombstones.Read(ctx, r.client, table, tenant)
+		if err != nil {
+			level.Warn(r.logger).Log("msg", "failed to read tombstones", "table", table, "tenant", tenant, "err", err)
+			return t.tombstones
+		}
+		t.tombstones, t.readAt = ts, readAt
+	}
+	return t.tombstones
+}
+
+// tombstonedIndex hides the tombstoned chunks of an index, and the series having all their chunks tombstoned.
+// Label names and values are not filtered, like the ones of the chunks deleted by delete requests
+// before the compactor rebuilds the index.
+type tombstonedIndex struct {
+	Index
+	tombstones *tombstones.Tombstones
+}
+
+// newTombstonedIndex returns the index without the tombstoned chunks.
+func newTombstonedIndex(idx Index, t *tombstones.Tombstones) Index {
+	if t.Len() == 0 {
+		return idx
+	}
+	return &tombstonedIndex{Index: idx, tombstones: t}
+}
+
+func (t *tombstonedIndex) covers(fp model.Fingerprint, chk index.ChunkMeta) bool {
+	return t.tombstones.Covers(fp, tombstones.Chunk{From: chk.From(), Through: chk.Through(), Checksum: chk.Checksum})
+}
+
+func (t *tombstonedIndex) GetChunkRefs(ctx context.Context, userID string, from, through model.Time, res []ChunkRef, fpFilter index.FingerprintFilter, matchers ...*labels.Matcher) ([]ChunkRef, error) {
+	refs, err := t.Index.GetChunkRefs(ctx, userID, from, through, res, fpFilter, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	live := refs[:0]
+	for _, ref := range refs {
+		if !t.tombstones.Covers(ref.Fingerprint, tombstones.Chunk{From: ref.Start, Through: ref.End, Checksum: ref.Checksum}) {
+			live = append(live, ref)
+		}
+	}
+	return live, nil
+}
+
+func (t *tombstonedIndex) ForSeries(ctx context.Context, userID string, fpFilter index.FingerprintFilter, from, through model.Time, fn func(labels.Labels, model.Fingerprint, []index.ChunkMeta) (stop bool), matchers ...*labels.Matcher) error {
+	return t.Index.ForSeries(ctx, userID, fpFilter, from, through, func(ls labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) (stop bool) {
+		if !t.tombstones.HasSeries(fp) || len(chks) == 0 {
+			return fn(ls, fp, chks)
+		}
+
+		live := make([]index.ChunkMeta, 0, len(chks))
+		for _, chk := range chks {
+			if !t.covers(fp, chk) {
+				live = append(live, chk)
+			}
+		}
+		if len(live) == 0 {
+			return false
+		}
+		return fn(ls, fp, live)
+	}, matchers...)
+}
+
+// hiddenSeries returns the tombstoned series which have no live chunks in the range.
+func (t *tombstonedIndex) hiddenSeries(ctx context.Context, userID string, fpFilter index.FingerprintFilter, from, through model.Time, matchers ...*labels.Matcher) (map[model.Fingerprint]struct{}, error) {
+	hidden := map[model.Fingerprint]struct{}{}
+	err := t.Index.ForSeries(ctx, userID, fpFilter, from, through, func(_ labels.Labels, fp model.Fingerprint, chks []index.ChunkMeta) (stop bool) {
+		if !t.tombstones.HasSeries(fp) || len(chks) == 0 {
+			return false
+		}
+		for _, chk := range chks {
+			if !t.covers(fp, chk) {
+				return false
+			}
+		}
+		hidden[fp] = struct{}{}
+		return false
+	}, matchers...)
+	return hidden, err
+}
+
+func (t *tombstonedIndex) Series(ctx context.Context, userID string, from, through model.Time, res []Series, fpFilter index.FingerprintFilter, matchers ...*labels.Matcher) ([]Series, error) {
+	series, err := t.Index.Series(ctx, userID, from, through, res, fpFilter, matchers...)
+	if err != nil {
+		return nil, err
+	}
+
+	tombstoned := false
+	for _, s := range series {
+		if t.tombstones.HasSeries(s.Fingerprint) {
+			tombstoned = true
+			break
+		}
+	}
+	if !tombstoned {
+		return series, nil
+	}
+
+	hidden, err := t.hiddenSeries(ctx, userID, fpFilter, from, through, matchers...)
+	if err != nil {
+		return nil, err
+	}
+	live := series[:0]
+	for _, s := range series {
+		if _, ok := hidden[s.Fingerprint]; !ok {
+			live = append(live, s)
+		}
+	}
+	return live, nil
+}
+
+// Stats skips the series having all their chunks tombstoned. The tombstoned chunks of the series
+// having live chunks are still counted, like the chunks partially deleted by delete requests.
+func (t *tombstonedIndex) Stats(ctx context.Context, userID string, from, through model.Time, acc IndexStatsAccumulator, fpFilter index.FingerprintFilter, shouldIncludeChunk shouldIncludeChunk, matchers ...*labels.Matcher) error {
+	hidden, err := t.hiddenSeries(ctx, userID, fpFilter, from, through, matchers...)
+	if err != nil {
+		return err
+	}
+	return t.Index.Stats(ctx, userID, from, through, acc, withoutSeries(fpFilter, hidden), shouldIncludeChunk, matchers...)
+}
+
+// Volume skips the series having all their chunks tombstoned, like Stats.
+func (t *tombstonedIndex) Volume(ctx context.Context, userID string, from, through model.Time, acc VolumeAccumulator, fpFilter index.FingerprintFilter, shouldIncludeChunk shouldIncludeChunk, targetLabels []string, aggregateBy string, matchers ...*labels.Matcher) error {
+	hidden, err := t.hiddenSeries(ctx, userID, fpFilter, from, through, matchers...)
+	if err != nil {
+		return err
+	}
+	return t.Index.Volume(ctx, userID, from, through, acc, withoutSeries(fpFilter, hidden), shouldIncludeChunk, targetLabels, aggregateBy, matchers...)
+}
+
+// excludingFingerprintFilter excludes some series from the ones matched by a fingerprint filter.
+type excludingFingerprintFilter struct {
+	index.FingerprintFilter
+	excluded map[model.Fingerprint]struct{}
+}
+
+func withoutSeries(fpFilter index.FingerprintFilter, excluded map[model.Fingerprint]struct{}) index.FingerprintFilter {
+	if len(excluded) == 0 {
+		return fpFilter
+	}
+	return &excludingFingerprintFilter{FingerprintFilter: fpFilter, excluded: excluded}
+}
+
+func (f *excludingFingerprintFilter) Match(fp model.Fingerprint) bool {
+	if _, ok := f.excluded[fp]; ok {
+		return false
+	}
+	return f.FingerprintFilter == nil || f.FingerprintFilter.Match(fp)
+}
+
+func (f *excludingFingerprintFilter) GetFromThrough() (model.Fingerprint, model.Fingerprint) {
+	if f.FingerprintFilter == nil {
+		return 0, math.MaxUint64
+	}
+	return f.FingerprintFilter.GetFromThrough()
+}
@@ -0,0 +1,91 @@
+package tsdb
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/compactor/tombstones"
+	"github.com/grafana/loki/v3/pkg/storage/chunk"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client/local"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper/storage"
+)
+
+func TestIndexShipperQuerier_Tombstones(t *testing.T) {
+	tableRange := config.TableRange{
+		Start: 0,
+		End:   math.MaxInt64,
+		PeriodConfig: &config.PeriodConfig{
+			IndexTables: config.IndexPeriodicTableConfig{
+				PeriodicTableConfig: config.PeriodicTableConfig{
+					Prefix: "index_",
+					Period: config.ObjectStorageIndexRequiredPeriod,
+				}},
+		},
+	}
+	table := tableRange.PeriodConfig.IndexTables.TableFor(0)
+	seriesA, seriesB := mustParseLabels(`{app="a"}`), mustParseLabels(`{app="b"}`)
+	tables := map[string][]*TSDBFile{
+		table: {
+			BuildIndex(t, t.TempDir(), []LoadableSeries{
+				{Labels: seriesA, Chunks: buildChunkMetas(0, 10, 10)},
+				{Labels: seriesB, Chunks: buildChunkMetas(0, 10, 10)},
+			}),
+		},
+	}
+
+	objectClient, err := local.NewFSObjectClient(local.FSConfig{Directory: t.TempDir()})
+	require.NoError(t, err)
+	indexStorageClient := storage.NewIndexStorageClient(objectClient, "index/")
+	reader := newTombstonesReader(indexStorageClient, time.Nanosecond, log.NewNopLogger())
+	indexClient := NewIndexClient(newIndexShipperQuerier(mockIndexShipperIndexIterator{tables: tables}, tableRange, reader), IndexClientOptions{}, &fakeLimits{})
+
+	query := func(t *testing.T, tenant string) ([]uint32, []labels.Labels, uint64) {
+		matcher := labels.MustNewMatcher(labels.MatchRegexp, "app", ".+")
+		refs, err := indexClient.GetChunkRefs(context.Background(), tenant, 0, 100, chunk.NewPredicate([]*labels.Matcher{matcher}, nil))
+		require.NoError(t, err)
+		var checksums []uint32
+		for _, ref := range refs {
+			checksums = append(checksums, ref.Checksum)
+		}
+
+		series, err := indexClient.GetSeries(context.Background(), tenant, 0, 100, matcher)
+		require.NoError(t, err)
+
+		stats, err := indexClient.Stats(context.Background(), tenant, 0, 100, matcher)
+		require.NoError(t, err)
+		return checksums, series, stats.Streams
+	}
+
+	checksums, series, streams := query(t, "fake")
+	require.Len(t, checksums, 4)
+	require.ElementsMatch(t, []labels.Labels{seriesA, seriesB}, series)
+	require.Equal(t, uint64(2), streams)
+
+	// all the chunks of the first series and the first chunk of the second one are tombstoned.
+	ts := tombstones.New()
+	ts.Add(model.Fingerprint(seriesA.Hash()), tombstones.Chunk{From: 0, Through: 10, Checksum: 0}, tombstones.Chunk{From: 10, Through: 20, Checksum: 10})
+	ts.Add(model.Fingerprint(seriesB.Hash()), tombstones.Chunk{From: 0, Through: 10, Checksum: 0})
+	require.NoError(t, tombstones.Write(context.Background(), indexStorageClient, table, "fake", ts))
+
+	checksums, series, streams = query(t, "fake")
+	require.Equal(t, []uint32{10}, checksums)
+	require.Equal(t, []labels.Labels{seriesB}, series)
+	require.Equal(t, uint64(1), streams)
+
+	// the tombstones of other tenants don't apply.
+	checksums, _, _ = query(t, "other")
+	require.Len(t, checksums, 4)
+
+	// the tombstones are dropped once folded into the index.
+	require.NoError(t, tombstones.Delete(context.Background(), indexStorageClient, table, "fake"))
+	checksums, _, _ = query(t, "fake")
+	require.Len(t, checksums, 4)
+}
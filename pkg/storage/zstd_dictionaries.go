@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/storage/chunk/client"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+)
+
+// NewZstdDictionaryFetcher returns a fetcher looking up the zstd dictionaries of the tenants in the object stores of all the periods.
+// The object clients are only created when the first dictionary is fetched.
+func NewZstdDictionaryFetcher(cfg Config, schemaCfg config.SchemaConfig, clientMetrics ClientMetrics) compression.ZstdDictionaryFetcher {
+	var (
+		once    sync.Once
+		clients []client.ObjectClient
+		initErr error
+	)
+
+	return func(ctx context.Context, tenant string, id uint32) ([]byte, error) {
+		once.Do(func() {
+			seen := map[string]struct{}{}
+			for _, p := range schemaCfg.Configs {
+				if _, ok := seen[p.ObjectType]; ok {
+					continue
+				}
+				seen[p.ObjectType] = struct{}{}
+
+				objectClient, err := NewObjectClient(p.ObjectType, "zstd-dictionaries", cfg, clientMetrics)
+				if err != nil {
+					initErr = fmt.Errorf("creating object client for %s: %w", p.ObjectType, err)
+					return
+				}
+				clients = append(clients, objectClient)
+			}
+		})
+		if initErr != nil {
+			return nil, initErr
+		}
+
+		key := client.ZstdDictionaryObjectKey(tenant, id)
+		for _, objectClient := range clients {
+			dict, err := client.GetZstdDictionary(ctx, objectClient, key)
+			if err != nil {
+				if objectClient.IsObjectNotFoundErr(err) {
+					continue
+				}
+				return nil, err
+			}
+			return dict, nil
+		}
+		return nil, fmt.Errorf("zstd dictionary %s not found", key)
+	}
+}
@@ -0,0 +1,54 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+const exportAPIPath = "/loki/api/v1/export"
+
+// ExportManifest is the progress of the exports of a tenant.
+type ExportManifest struct {
+	Days []ExportDay `json:"days"`
+}
+
+// ExportDay is the export of a single day of a tenant.
+type ExportDay struct {
+	Day         string    `json:"day"`
+	Status      string    `json:"status"`
+	RequestedAt time.Time `json:"requested_at"`
+	CompletedAt time.Time `json:"completed_at,omitempty"`
+	Files       []string  `json:"files,omitempty"`
+	Rows        int64     `json:"rows"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// RequestExport requests the export of the days between start and end, both in YYYY-MM-DD format.
+func (r *LokiClient) RequestExport(ctx context.Context, start, end string) (*ExportManifest, error) {
+	params := url.Values{}
+	params.Set("start", start)
+	params.Set("end", end)
+
+	return r.doExportRequest(ctx, exportAPIPath+"?"+params.Encode(), "POST")
+}
+
+// GetExport retrieves the export manifest of the tenant.
+func (r *LokiClient) GetExport(ctx context.Context) (*ExportManifest, error) {
+	return r.doExportRequest(ctx, exportAPIPath, "GET")
+}
+
+func (r *LokiClient) doExportRequest(ctx context.Context, path, method string) (*ExportManifest, error) {
+	res, err := r.doRequest(ctx, path, method, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var m ExportManifest
+	if err := json.NewDecoder(res.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+const tenantMoveAPIPath = "/loki/api/v1/tenant/move"
+
+// TenantMove is the move of the data of a source tenant to a destination tenant.
+type TenantMove struct {
+	Source      string            `json:"source"`
+	Destination string            `json:"destination"`
+	Mode        string            `json:"mode"`
+	Status      string            `json:"status"`
+	RequestedAt time.Time         `json:"requested_at"`
+	CompletedAt time.Time         `json:"completed_at,omitempty"`
+	Tables      []TenantMoveTable `json:"tables"`
+}
+
+// TenantMoveTable is the move of the data of a single index table.
+type TenantMoveTable struct {
+	Table      string   `json:"table"`
+	Status     string   `json:"status"`
+	Chunks     int      `json:"chunks"`
+	IndexFiles []string `json:"index_files,omitempty"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// RequestTenantMove requests the move of the data of the tenant to the destination tenant
+// for the days between start and end, both in YYYY-MM-DD format.
+func (r *LokiClient) RequestTenantMove(ctx context.Context, destination, mode, start, end string) (*TenantMove, error) {
+	params := url.Values{}
+	params.Set("destination", destination)
+	params.Set("mode", mode)
+	params.Set("start", start)
+	params.Set("end", end)
+
+	return r.doTenantMoveRequest(ctx, tenantMoveAPIPath+"?"+params.Encode(), "POST")
+}
+
+// GetTenantMove retrieves the last move of the tenant.
+func (r *LokiClient) GetTenantMove(ctx context.Context) (*TenantMove, error) {
+	return r.doTenantMoveRequest(ctx, tenantMoveAPIPath, "GET")
+}
+
+func (r *LokiClient) doTenantMoveRequest(ctx context.Context, path, method string) (*TenantMove, error) {
+	res, err := r.doRequest(ctx, path, method, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	var m TenantMove
+	if err := json.NewDecoder(res.Body).Decode(&m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
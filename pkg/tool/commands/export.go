@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kingpin/v2"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/grafana/loki/v3/pkg/tool/client"
+)
+
+// ExportCommand requests and follows exports of tenant data to Parquet files
+type ExportCommand struct {
+	ClientConfig client.Config
+
+	cli *client.LokiClient
+
+	// Request Export Config
+	Start string
+	End   string
+}
+
+// Register export related commands and flags with the kingpin application
+func (e *ExportCommand) Register(app *kingpin.Application) {
+	exportCmd := app.Command("export", "Export the logs of a tenant to Parquet files with the compactor.").PreAction(e.setup)
+	exportCmd.Flag("address", "Address of the loki cluster, alternatively set LOKI_ADDRESS.").Envar("LOKI_ADDRESS").Required().StringVar(&e.ClientConfig.Address)
+	exportCmd.Flag("id", "Loki tenant id, alternatively set LOKI_TENANT_ID.").Envar("LOKI_TENANT_ID").Required().StringVar(&e.ClientConfig.ID)
+	exportCmd.Flag("authToken", "Authentication token for bearer token or JWT auth, alternatively set LOKI_AUTH_TOKEN.").Default("").Envar("LOKI_AUTH_TOKEN").StringVar(&e.ClientConfig.AuthToken)
+	exportCmd.Flag("user", "API user to use when contacting loki, alternatively set LOKI_API_USER. If empty, LOKI_TENANT_ID will be used instead.").Default("").Envar("LOKI_API_USER").StringVar(&e.ClientConfig.User)
+	exportCmd.Flag("key", "API key to use when contacting loki, alternatively set LOKI_API_KEY.").Default("").Envar("LOKI_API_KEY").StringVar(&e.ClientConfig.Key)
+	exportCmd.Flag("tls-ca-path", "TLS CA certificate to verify Loki API as part of mTLS, alternatively set LOKI_TLS_CA_PATH.").Default("").Envar("LOKI_TLS_CA_CERT").StringVar(&e.ClientConfig.TLS.CAPath)
+	exportCmd.Flag("tls-cert-path", "TLS client certificate to authenticate with Loki API as part of mTLS, alternatively set Loki_TLS_CERT_PATH.").Default("").Envar("LOKI_TLS_CLIENT_CERT").StringVar(&e.ClientConfig.TLS.CertPath)
+	exportCmd.Flag("tls-key-path", "TLS client certificate private key to authenticate with Loki API as part of mTLS, alternatively set LOKI_TLS_KEY_PATH.").Default("").Envar("LOKI_TLS_CLIENT_KEY").StringVar(&e.ClientConfig.TLS.KeyPath)
+
+	requestCmd := exportCmd.
+		Command("request", "Request the export of the days between start and end, both inclusive.").
+		Action(e.requestExport)
+	requestCmd.Flag("start", "First day to export, in YYYY-MM-DD format.").Required().StringVar(&e.Start)
+	requestCmd.Flag("end", "Last day to export, in YYYY-MM-DD format. Defaults to the start day.").StringVar(&e.End)
+
+	exportCmd.
+		Command("status", "Print the status of the exported days.").
+		Action(e.exportStatus)
+}
+
+func (e *ExportCommand) setup(_ *kingpin.ParseContext) error {
+	cli, err := client.New(e.ClientConfig)
+	if err != nil {
+		return err
+	}
+	e.cli = cli
+
+	return nil
+}
+
+func (e *ExportCommand) requestExport(_ *kingpin.ParseContext) error {
+	end := e.End
+	if end == "" {
+		end = e.Start
+	}
+
+	m, err := e.cli.RequestExport(context.Background(), e.Start, end)
+	if err != nil {
+		log.Fatalf("unable to request export, %v", err)
+	}
+
+	printExportManifest(os.Stdout, m)
+	return nil
+}
+
+func (e *ExportCommand) exportStatus(_ *kingpin.ParseContext) error {
+	m, err := e.cli.GetExport(context.Background())
+	if err != nil {
+		log.Fatalf("unable to read export status, %v", err)
+	}
+
+	printExportManifest(os.Stdout, m)
+	return nil
+}
+
+func printExportManifest(writer io.Writer, m *client.ExportManifest) {
+	w := tabwriter.NewWriter(writer, 0, 0, 1, ' ', tabwriter.Debug)
+
+	fmt.Fprintln(w, "Day\t Status\t Files\t Rows\t Error")
+	for _, d := range m.Days {
+		fmt.Fprintf(w, "%s\t %s\t %d\t %d\t %s\n", d.Day, d.Status, len(d.Files), d.Rows, d.Error)
+	}
+
+	w.Flush()
+}
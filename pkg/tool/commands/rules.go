@@ -76,6 +76,9 @@ type RuleCommand struct {
 	// Rules check flags
 	Strict bool
 
+	// Test Rules Config
+	TestFiles []string
+
 	// List Rules Config
 	Format string
 
@@ -123,6 +126,9 @@ func (r *RuleCommand) Register(app *kingpin.Application) {
 	checkCmd := rulesCmd.
 		Command("check", "runs various best practice checks against rules.").
 		Action(r.checkRecordingRuleNames)
+	testCmd := rulesCmd.
+		Command("test", "runs unit tests of rules, evaluating them over the log lines of the test files and checking the alerts and samples they produce.").
+		Action(r.testRules)
 
 	// Require Loki cluster address and tentant ID on all these commands
 	for _, c := range []*kingpin.CmdClause{listCmd, printRulesCmd, getRuleGroupCmd, deleteRuleGroupCmd, loadRulesCmd, diffRulesCmd, syncRulesCmd} {
@@ -227,6 +233,9 @@ func (r *RuleCommand) Register(app *kingpin.Application) {
 	).StringVar(&r.RuleFilesPath)
 	checkCmd.Flag("strict", "fails rules checks that do not match best practices exactly").BoolVar(&r.Strict)
 
+	// Test Command
+	testCmd.Arg("test-files", "The test files to run.").Required().ExistingFilesVar(&r.TestFiles)
+
 	// List Command
 	listCmd.Flag("format", "Backend type to interact with: <json|yaml|table>").Default("table").EnumVar(&r.Format, formats...)
 	listCmd.Flag("disable-color", "disable colored output").BoolVar(&r.DisableColor)
@@ -720,6 +729,26 @@ func (r *RuleCommand) checkRecordingRuleNames(_ *kingpin.ParseContext) error {
 	return nil
 }
 
+func (r *RuleCommand) testRules(_ *kingpin.ParseContext) error {
+	var failed int
+	for _, f := range r.TestFiles {
+		errs := rules.RunUnitTests(f)
+		for _, err := range errs {
+			log.WithError(err).WithField("file", f).Errorln("rule unit test failed")
+		}
+		if len(errs) > 0 {
+			failed++
+			continue
+		}
+		log.WithField("file", f).Infoln("SUCCESS: rule unit tests passed")
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test files failed", failed, len(r.TestFiles))
+	}
+	return nil
+}
+
 // Taken from https://github.com/prometheus/prometheus/blob/8c8de46003d1800c9d40121b4a5e5de8582ef6e1/cmd/promtool/main.go#L403
 type compareRuleType struct {
 	metric string
@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/alecthomas/kingpin/v2"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/grafana/loki/v3/pkg/tool/client"
+)
+
+// TenantCommand requests and follows moves of the data of a tenant to another tenant
+type TenantCommand struct {
+	ClientConfig client.Config
+
+	cli *client.LokiClient
+
+	// Move Config
+	Destination string
+	Mode        string
+	Start       string
+	End         string
+}
+
+// Register tenant related commands and flags with the kingpin application
+func (t *TenantCommand) Register(app *kingpin.Application) {
+	tenantCmd := app.Command("tenant", "Move the data of a tenant to another tenant with the compactor.").PreAction(t.setup)
+	tenantCmd.Flag("address", "Address of the loki cluster, alternatively set LOKI_ADDRESS.").Envar("LOKI_ADDRESS").Required().StringVar(&t.ClientConfig.Address)
+	tenantCmd.Flag("id", "Loki tenant id of the source tenant, alternatively set LOKI_TENANT_ID.").Envar("LOKI_TENANT_ID").Required().StringVar(&t.ClientConfig.ID)
+	tenantCmd.Flag("authToken", "Authentication token for bearer token or JWT auth, alternatively set LOKI_AUTH_TOKEN.").Default("").Envar("LOKI_AUTH_TOKEN").StringVar(&t.ClientConfig.AuthToken)
+	tenantCmd.Flag("user", "API user to use when contacting loki, alternatively set LOKI_API_USER. If empty, LOKI_TENANT_ID will be used instead.").Default("").Envar("LOKI_API_USER").StringVar(&t.ClientConfig.User)
+	tenantCmd.Flag("key", "API key to use when contacting loki, alternatively set LOKI_API_KEY.").Default("").Envar("LOKI_API_KEY").StringVar(&t.ClientConfig.Key)
+	tenantCmd.Flag("tls-ca-path", "TLS CA certificate to verify Loki API as part of mTLS, alternatively set LOKI_TLS_CA_PATH.").Default("").Envar("LOKI_TLS_CA_CERT").StringVar(&t.ClientConfig.TLS.CAPath)
+	tenantCmd.Flag("tls-cert-path", "TLS client certificate to authenticate with Loki API as part of mTLS, alternatively set Loki_TLS_CERT_PATH.").Default("").Envar("LOKI_TLS_CLIENT_CERT").StringVar(&t.ClientConfig.TLS.CertPath)
+	tenantCmd.Flag("tls-key-path", "TLS client certificate private key to authenticate with Loki API as part of mTLS, alternatively set LOKI_TLS_KEY_PATH.").Default("").Envar("LOKI_TLS_CLIENT_KEY").StringVar(&t.ClientConfig.TLS.KeyPath)
+
+	moveCmd := tenantCmd.
+		Command("move", "Request the move of the data of the days between start and end, both inclusive, to the destination tenant.").
+		Action(t.requestMove)
+	moveCmd.Flag("destination", "Tenant the data is moved to.").Required().StringVar(&t.Destination)
+	moveCmd.Flag("mode", "Either copy, which keeps the data of the source tenant, or move, which deletes it once copied.").Default("copy").EnumVar(&t.Mode, "copy", "move")
+	moveCmd.Flag("start", "First day to move, in YYYY-MM-DD format.").Required().StringVar(&t.Start)
+	moveCmd.Flag("end", "Last day to move, in YYYY-MM-DD format. Defaults to the start day.").StringVar(&t.End)
+
+	tenantCmd.
+		Command("status", "Print the status of the last move of the tenant.").
+		Action(t.moveStatus)
+}
+
+func (t *TenantCommand) setup(_ *kingpin.ParseContext) error {
+	cli, err := client.New(t.ClientConfig)
+	if err != nil {
+		return err
+	}
+	t.cli = cli
+
+	return nil
+}
+
+func (t *TenantCommand) requestMove(_ *kingpin.ParseContext) error {
+	end := t.End
+	if end == "" {
+		end = t.Start
+	}
+
+	// the move writes into the destination tenant, so it is requested for both tenants.
+	cfg := t.ClientConfig
+	if cfg.User == "" && cfg.Key != "" {
+		cfg.User = cfg.ID
+	}
+	cfg.ID = cfg.ID + "|" + t.Destination
+	cli, err := client.New(cfg)
+	if err != nil {
+		return err
+	}
+
+	m, err := cli.RequestTenantMove(context.Background(), t.Destination, t.Mode, t.Start, end)
+	if err != nil {
+		log.Fatalf("unable to request tenant move, %v", err)
+	}
+
+	printTenantMove(os.Stdout, m)
+	return nil
+}
+
+func (t *TenantCommand) moveStatus(_ *kingpin.ParseContext) error {
+	m, err := t.cli.GetTenantMove(context.Background())
+	if err != nil {
+		log.Fatalf("unable to read tenant move status, %v", err)
+	}
+
+	printTenantMove(os.Stdout, m)
+	return nil
+}
+
+func printTenantMove(writer io.Writer, m *client.TenantMove) {
+	fmt.Fprintf(writer, "%s %s to %s: %s\n", m.Mode, m.Source, m.Destination, m.Status)
+
+	w := tabwriter.NewWriter(writer, 0, 0, 1, ' ', tabwriter.Debug)
+
+	fmt.Fprintln(w, "Table\t Status\t Chunks\t Error")
+	for _, table := range m.Tables {
+		fmt.Fprintf(w, "%s\t %s\t %d\t %s\n", table.Table, table.Status, table.Chunks, table.Error)
+	}
+
+	w.Flush()
+}
@@ -0,0 +1,97 @@
+package importer
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/grafana/dskit/flagext"
+	dskitlog "github.com/grafana/dskit/log"
+
+	"github.com/grafana/loki/v3/pkg/blockbuilder/builder"
+	"github.com/grafana/loki/v3/pkg/storage"
+	lokiStorage "github.com/grafana/loki/v3/pkg/storage/config"
+	lokiflagext "github.com/grafana/loki/v3/pkg/util/flagext"
+)
+
+type FileConfig struct {
+	ConfigFile string
+}
+
+func (c *FileConfig) RegisterFlags(f *flag.FlagSet) {
+	f.StringVar(&c.ConfigFile, "config.file", "config.yaml", "configuration file to load")
+}
+
+// Config Loki related storage and schema configs and the import settings
+type Config struct {
+	FileConfig        `yaml:",inline"`
+	Tenant            string                          `yaml:"tenant,omitempty"`
+	SchemaConfig      lokiStorage.SchemaConfig        `yaml:"schema_config,omitempty"`
+	StorageConfig     storage.Config                  `yaml:"storage_config,omitempty"`
+	ChunkStoreConfig  lokiStorage.ChunkStoreConfig    `yaml:"chunk_store_config,omitempty"`
+	Builder           builder.Config                  `yaml:"builder,omitempty"`
+	LogLevel          dskitlog.Level                  `yaml:"log_level"`
+	NodeName          string                          `yaml:"node_name"`
+	BatchSize         int                             `yaml:"batch_size"`
+	MaxPendingBytes   lokiflagext.ByteSize            `yaml:"max_pending_bytes"`
+	MaxPendingStreams int                             `yaml:"max_pending_streams"`
+	TenantRateLimit   lokiflagext.ByteSize            `yaml:"tenant_rate_limit"`
+	TenantRateLimits  map[string]lokiflagext.ByteSize `yaml:"tenant_rate_limits,omitempty"`
+}
+
+func (c *Config) RegisterFlags(f *flag.FlagSet) {
+	c.FileConfig.RegisterFlags(f)
+	c.SchemaConfig.RegisterFlags(f)
+	c.StorageConfig.RegisterFlags(f)
+	c.ChunkStoreConfig.RegisterFlags(f)
+	c.Builder.RegisterFlagsWithPrefix("builder.", f)
+	c.LogLevel.RegisterFlags(f)
+	f.StringVar(&c.Tenant, "tenant", "", "tenant to import the logs into, unless set by the records of the files")
+	f.StringVar(&c.NodeName, "node-name", "lokitool-import", "name of the importer in the names of the written index files")
+	f.IntVar(&c.BatchSize, "batch-size", 1000, "maximum number of log lines of a stream appended at once")
+	c.MaxPendingBytes = 256 << 20
+	f.Var(&c.MaxPendingBytes, "max-pending-bytes", "maximum number of bytes of log lines buffered across all the streams before the buffered log lines of all the streams are appended")
+	f.IntVar(&c.MaxPendingStreams, "max-pending-streams", 10000, "maximum number of streams buffering log lines before the buffered log lines of all the streams are appended")
+	f.Var(&c.TenantRateLimit, "tenant-rate-limit", "maximum number of bytes of log lines imported per second and tenant, 0 to disable")
+}
+
+func (c *Config) Validate() error {
+	if err := c.SchemaConfig.Validate(); err != nil {
+		return fmt.Errorf("schema config is invalid: %v", err)
+	}
+	if err := c.StorageConfig.Validate(); err != nil {
+		return fmt.Errorf("storage config is invalid: %v", err)
+	}
+	if err := c.Builder.Validate(); err != nil {
+		return fmt.Errorf("builder config is invalid: %v", err)
+	}
+	if c.NodeName == "" {
+		return errors.New("node name argument missing. Use -node-name flag or add 'node_name' to the config file")
+	}
+	if c.BatchSize <= 0 {
+		return errors.New("batch size argument needs to be greater than 0")
+	}
+	if c.MaxPendingBytes <= 0 {
+		return errors.New("max pending bytes argument needs to be greater than 0")
+	}
+	if c.MaxPendingStreams <= 0 {
+		return errors.New("max pending streams argument needs to be greater than 0")
+	}
+	return nil
+}
+
+// rateLimit returns the maximum number of bytes imported per second for the tenant.
+func (c *Config) rateLimit(tenant string) int {
+	if limit, ok := c.TenantRateLimits[tenant]; ok {
+		return limit.Val()
+	}
+	return c.TenantRateLimit.Val()
+}
+
+// Clone takes advantage of pass-by-value semantics to return a distinct *Config.
+// This is primarily used to parse a different flag set without mutating the original *Config.
+func (c *Config) Clone() flagext.Registerer {
+	return func(c Config) *Config {
+		return &c
+	}(*c)
+}
@@ -0,0 +1,246 @@
+package importer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/go-kit/log/level"
+	"github.com/grafana/dskit/flagext"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"golang.org/x/time/rate"
+
+	"github.com/grafana/loki/v3/pkg/blockbuilder/builder"
+	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	"github.com/grafana/loki/v3/pkg/util/constants"
+	"github.com/grafana/loki/v3/pkg/validation"
+
+	"github.com/grafana/loki/pkg/push"
+)
+
+// Stats are the statistics of an import.
+type Stats struct {
+	Files   int
+	Entries int64
+	Bytes   int64
+}
+
+// Run imports the log lines of the files into the storage with their original timestamps,
+// writing chunks and TSDB indexes directly instead of pushing the log lines to the distributors.
+func Run(ctx context.Context, cfg Config, files []string, logger log.Logger) (Stats, error) {
+	level.Info(logger).Log("msg", "importing files", "files", len(files), "tenant", cfg.Tenant)
+
+	// Chunks are written through the store while the TSDB indexes are built by the builder,
+	// same as the block builder does.
+	storageCfg := cfg.StorageConfig
+	storageCfg.TSDBShipperConfig.Mode = indexshipper.ModeDisabled
+	storageCfg.TSDBShipperConfig.IndexGatewayClientConfig.Disabled = true
+	storageCfg.BoltDBShipperConfig.Mode = indexshipper.ModeReadOnly
+	storageCfg.BoltDBShipperConfig.IndexGatewayClientConfig.Disabled = true
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	overrides, err := validation.NewOverrides(limits, nil)
+	if err != nil {
+		return Stats{}, err
+	}
+
+	// Use a dedicated registry to not register the metrics of the store and the builder globally.
+	reg := prometheus.NewRegistry()
+	clientMetrics := storage.NewClientMetrics()
+	defer clientMetrics.Unregister()
+
+	store, err := storage.NewStore(storageCfg, cfg.ChunkStoreConfig, cfg.SchemaConfig, overrides, clientMetrics, reg, logger, constants.Loki)
+	if err != nil {
+		return Stats{}, fmt.Errorf("couldn't create store: %w", err)
+	}
+	defer store.Stop()
+
+	objStore, err := builder.NewMultiStore(cfg.SchemaConfig.Configs, cfg.StorageConfig, clientMetrics)
+	if err != nil {
+		return Stats{}, fmt.Errorf("couldn't create index object store: %w", err)
+	}
+	defer objStore.Stop()
+
+	i := newImporter(cfg, logger)
+	w := builder.NewWriter(cfg.NodeName, cfg.Builder, cfg.SchemaConfig.Configs, store, objStore, logger, reg)
+	err = w.Write(ctx, func(ctx context.Context, ch chan<- []builder.AppendInput) error {
+		for _, file := range files {
+			if err := i.importFile(ctx, file, ch); err != nil {
+				return fmt.Errorf("importing %s: %w", file, err)
+			}
+		}
+		return i.flush(ctx, ch)
+	})
+	return i.stats, err
+}
+
+type streamKey struct {
+	tenant string
+	labels string
+}
+
+// pendingStream holds the entries of a stream until a batch is full.
+type pendingStream struct {
+	labels  labels.Labels
+	entries []push.Entry
+	bytes   int
+}
+
+// importer batches the records of the files per stream and throttles them per tenant.
+// The entries buffered by all the streams are bounded by the max pending bytes and streams.
+type importer struct {
+	cfg    Config
+	logger log.Logger
+
+	pending      map[streamKey]*pendingStream
+	pendingBytes int
+	limiters     map[string]*rate.Limiter
+	stats        Stats
+}
+
+func newImporter(cfg Config, logger log.Logger) *importer {
+	return &importer{
+		cfg:      cfg,
+		logger:   logger,
+		pending:  make(map[streamKey]*pendingStream),
+		limiters: make(map[string]*rate.Limiter),
+	}
+}
+
+func (i *importer) importFile(ctx context.Context, file string, ch chan<- []builder.AppendInput) error {
+	start := time.Now()
+	entries := i.stats.Entries
+	if err := readFile(file, i.cfg.Tenant, func(r record) error {
+		return i.add(ctx, r, ch)
+	}); err != nil {
+		return err
+	}
+	i.stats.Files++
+	level.Info(i.logger).Log("msg", "imported file", "file", file, "entries", i.stats.Entries-entries, "duration", time.Since(start))
+	return nil
+}
+
+func (i *importer) add(ctx context.Context, r record, ch chan<- []builder.AppendInput) error {
+	if err := i.validate(r); err != nil {
+		return err
+	}
+
+	key := streamKey{tenant: r.tenant, labels: r.labels.String()}
+	s, ok := i.pending[key]
+	if !ok {
+		if len(i.pending) >= i.cfg.MaxPendingStreams {
+			if err := i.flush(ctx, ch); err != nil {
+				return err
+			}
+		}
+		s = &pendingStream{labels: r.labels}
+		i.pending[key] = s
+	}
+	s.entries = append(s.entries, r.entry)
+	s.bytes += len(r.entry.Line)
+	i.pendingBytes += len(r.entry.Line)
+
+	if len(s.entries) >= i.cfg.BatchSize {
+		return i.send(ctx, key, ch)
+	}
+	if i.pendingBytes >= i.cfg.MaxPendingBytes.Val() {
+		return i.flush(ctx, ch)
+	}
+	return nil
+}
+
+func (i *importer) validate(r record) error {
+	if r.tenant == "" {
+		return errors.New("record without tenant, use -tenant to set the tenant of the records")
+	}
+	if r.labels.IsEmpty() {
+		return errors.New("record without labels")
+	}
+
+	period, err := i.cfg.SchemaConfig.SchemaForTime(model.TimeFromUnixNano(r.entry.Timestamp.UnixNano()))
+	if err != nil {
+		return fmt.Errorf("record at %s: %w", r.entry.Timestamp.Format(time.RFC3339Nano), err)
+	}
+	if period.IndexType != types.TSDBType {
+		return fmt.Errorf("record at %s: importing into periods with a %s index is not supported, only %s", r.entry.Timestamp.Format(time.RFC3339Nano), period.IndexType, types.TSDBType)
+	}
+	if len(r.entry.StructuredMetadata) > 0 {
+		if version, err := period.VersionAsInt(); err != nil || version < 13 {
+			return fmt.Errorf("record at %s: structured metadata requires schema v13 or later, the period uses %s", r.entry.Timestamp.Format(time.RFC3339Nano), period.Schema)
+		}
+	}
+	return nil
+}
+
+// send throttles and sends the pending entries of the stream to the builder.
+func (i *importer) send(ctx context.Context, key streamKey, ch chan<- []builder.AppendInput) error {
+	s := i.pending[key]
+	delete(i.pending, key)
+	i.pendingBytes -= s.bytes
+
+	if err := i.throttle(ctx, key.tenant, s.bytes); err != nil {
+		return err
+	}
+
+	select {
+	case ch <- []builder.AppendInput{builder.NewAppendInput(key.tenant, s.labels, s.entries)}:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	i.stats.Entries += int64(len(s.entries))
+	i.stats.Bytes += int64(s.bytes)
+	return nil
+}
+
+// flush sends the entries of all the pending streams.
+func (i *importer) flush(ctx context.Context, ch chan<- []builder.AppendInput) error {
+	keys := make([]streamKey, 0, len(i.pending))
+	for key := range i.pending {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].tenant != keys[b].tenant {
+			return keys[a].tenant < keys[b].tenant
+		}
+		return keys[a].labels < keys[b].labels
+	})
+
+	for _, key := range keys {
+		if err := i.send(ctx, key, ch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// throttle waits until n bytes of log lines of the tenant can be imported.
+func (i *importer) throttle(ctx context.Context, tenant string, n int) error {
+	limit := i.cfg.rateLimit(tenant)
+	if limit <= 0 {
+		return nil
+	}
+
+	limiter, ok := i.limiters[tenant]
+	if !ok {
+		limiter = rate.NewLimiter(rate.Limit(limit), limit)
+		i.limiters[tenant] = limiter
+	}
+
+	// a batch can be larger than the burst of the limiter.
+	for n > 0 {
+		wait := min(n, limiter.Burst())
+		if err := limiter.WaitN(ctx, wait); err != nil {
+			return err
+		}
+		n -= wait
+	}
+	return nil
+}
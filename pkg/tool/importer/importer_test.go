@@ -0,0 +1,220 @@
+package importer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/flagext"
+	"github.com/grafana/dskit/user"
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/stretchr/testify/require"
+
+	"github.com/grafana/loki/v3/pkg/blockbuilder/builder"
+	"github.com/grafana/loki/v3/pkg/compactor/export"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/querier/plan"
+	"github.com/grafana/loki/v3/pkg/storage"
+	"github.com/grafana/loki/v3/pkg/storage/config"
+	"github.com/grafana/loki/v3/pkg/storage/stores/shipper/indexshipper"
+	"github.com/grafana/loki/v3/pkg/storage/types"
+	"github.com/grafana/loki/v3/pkg/util/constants"
+	lokiflagext "github.com/grafana/loki/v3/pkg/util/flagext"
+	"github.com/grafana/loki/v3/pkg/validation"
+
+	"github.com/grafana/loki/pkg/push"
+)
+
+func testConfig(t *testing.T) Config {
+	var cfg Config
+	flagext.DefaultValues(&cfg)
+
+	dir := t.TempDir()
+	cfg.Tenant = "tenant-a"
+	cfg.StorageConfig.FSConfig.Directory = filepath.Join(dir, "chunks")
+	cfg.StorageConfig.TSDBShipperConfig.ActiveIndexDirectory = filepath.Join(dir, "active")
+	cfg.StorageConfig.TSDBShipperConfig.CacheLocation = filepath.Join(dir, "cache")
+	cfg.SchemaConfig.Configs = []config.PeriodConfig{{
+		From:       config.DayTime{Time: model.TimeFromUnix(time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC).Unix())},
+		IndexType:  types.TSDBType,
+		ObjectType: types.StorageTypeFileSystem,
+		Schema:     "v13",
+		IndexTables: config.IndexPeriodicTableConfig{
+			PathPrefix: "index/",
+			PeriodicTableConfig: config.PeriodicTableConfig{
+				Prefix: "index_",
+				Period: 24 * time.Hour,
+			}},
+	}}
+	require.NoError(t, cfg.Validate())
+	return cfg
+}
+
+func selectLogs(t *testing.T, cfg Config, tenant string, from, through time.Time) []logproto.Stream {
+	storageCfg := cfg.StorageConfig
+	storageCfg.TSDBShipperConfig.Mode = indexshipper.ModeReadOnly
+	storageCfg.TSDBShipperConfig.IndexGatewayClientConfig.Disabled = true
+	storageCfg.TSDBShipperConfig.CacheLocation = t.TempDir()
+
+	var limits validation.Limits
+	flagext.DefaultValues(&limits)
+	overrides, err := validation.NewOverrides(limits, nil)
+	require.NoError(t, err)
+
+	clientMetrics := storage.NewClientMetrics()
+	defer clientMetrics.Unregister()
+
+	store, err := storage.NewStore(storageCfg, cfg.ChunkStoreConfig, cfg.SchemaConfig, overrides, clientMetrics, prometheus.NewRegistry(), log.NewNopLogger(), constants.Loki)
+	require.NoError(t, err)
+	defer store.Stop()
+
+	expr := &syntax.MatchersExpr{Mts: []*labels.Matcher{labels.MustNewMatcher(labels.MatchRegexp, "app", ".+")}}
+	it, err := store.SelectLogs(user.InjectOrgID(context.Background(), tenant), logql.SelectLogParams{QueryRequest: &logproto.QueryRequest{
+		Selector:  expr.String(),
+		Start:     from,
+		End:       through,
+		Direction: logproto.FORWARD,
+		Limit:     1000,
+		Plan:      &plan.QueryPlan{AST: expr},
+	}})
+	require.NoError(t, err)
+	defer it.Close()
+
+	// entries are grouped by the labels of their stream, which include the structured metadata.
+	streams := map[string]int{}
+	var result []logproto.Stream
+	for it.Next() {
+		i, ok := streams[it.Labels()]
+		if !ok {
+			i = len(result)
+			streams[it.Labels()] = i
+			result = append(result, logproto.Stream{Labels: it.Labels()})
+		}
+		entry := it.At()
+		result[i].Entries = append(result[i].Entries, logproto.Entry{Timestamp: entry.Timestamp.UTC(), Line: entry.Line, StructuredMetadata: entry.StructuredMetadata})
+	}
+	require.NoError(t, it.Err())
+	return result
+}
+
+func TestRun(t *testing.T) {
+	cfg := testConfig(t)
+	day := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+
+	dir := t.TempDir()
+	jsonl := filepath.Join(dir, "logs.jsonl")
+	require.NoError(t, os.WriteFile(jsonl, []byte(`{"timestamp":"2023-05-01T10:00:00Z","labels":{"app":"foo"},"line":"1"}
+{"timestamp":"1682935260000000000","labels":{"app":"foo"},"structured_metadata":{"trace_id":"abc"},"line":"2"}
+
+{"timestamp":1683021600000000000,"labels":{"app":"bar"},"line":"other tenant","tenant":"tenant-b"}
+`), 0o600))
+
+	pq := filepath.Join(dir, "part-00000.parquet")
+	f, err := os.Create(pq)
+	require.NoError(t, err)
+	require.NoError(t, parquet.Write(f, []export.Row{
+		{Timestamp: day.Add(12 * time.Hour).UnixNano(), Labels: map[string]string{"app": "foo"}, Line: "3"},
+		{Timestamp: day.Add(30 * time.Hour).UnixNano(), Labels: map[string]string{"app": "baz"}, Line: "4"},
+	}))
+	require.NoError(t, f.Close())
+
+	stats, err := Run(context.Background(), cfg, []string{jsonl, pq}, log.NewNopLogger())
+	require.NoError(t, err)
+	require.Equal(t, Stats{Files: 2, Entries: 5, Bytes: 16}, stats)
+
+	require.Equal(t, []logproto.Stream{
+		{Labels: `{app="foo"}`, Entries: []logproto.Entry{
+			{Timestamp: day.Add(10 * time.Hour), Line: "1"},
+			{Timestamp: day.Add(12 * time.Hour), Line: "3"},
+		}},
+		{Labels: `{app="foo", trace_id="abc"}`, Entries: []logproto.Entry{
+			{Timestamp: day.Add(10*time.Hour + time.Minute), Line: "2", StructuredMetadata: logproto.FromLabelsToLabelAdapters(labels.FromStrings("trace_id", "abc"))},
+		}},
+	}, selectLogs(t, cfg, "tenant-a", day, day.Add(24*time.Hour)))
+	require.Equal(t, []logproto.Stream{
+		{Labels: `{app="baz"}`, Entries: []logproto.Entry{{Timestamp: day.Add(30 * time.Hour), Line: "4"}}},
+	}, selectLogs(t, cfg, "tenant-a", day.Add(24*time.Hour), day.Add(48*time.Hour)))
+	require.Equal(t, []logproto.Stream{
+		{Labels: `{app="bar"}`, Entries: []logproto.Entry{{Timestamp: day.Add(34 * time.Hour), Line: "other tenant"}}},
+	}, selectLogs(t, cfg, "tenant-b", day, day.Add(48*time.Hour)))
+}
+
+func TestRun_Invalid(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		tenant string
+		line   string
+		err    string
+	}{
+		{name: "no tenant", line: `{"timestamp":"2023-05-01T10:00:00Z","labels":{"app":"foo"},"line":"1"}`, err: "record without tenant"},
+		{name: "no labels", tenant: "a", line: `{"timestamp":"2023-05-01T10:00:00Z","line":"1"}`, err: "record without labels"},
+		{name: "before schema", tenant: "a", line: `{"timestamp":"2019-05-01T10:00:00Z","labels":{"app":"foo"},"line":"1"}`, err: "no schema config found"},
+		{name: "invalid timestamp", tenant: "a", line: `{"timestamp":"yesterday","labels":{"app":"foo"},"line":"1"}`, err: "invalid timestamp"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig(t)
+			cfg.Tenant = tc.tenant
+
+			file := filepath.Join(t.TempDir(), "logs.jsonl")
+			require.NoError(t, os.WriteFile(file, []byte(tc.line), 0o600))
+
+			_, err := Run(context.Background(), cfg, []string{file}, log.NewNopLogger())
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
+
+func TestImporter_MaxPending(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.MaxPendingBytes = 10
+	cfg.MaxPendingStreams = 2
+	i := newImporter(cfg, log.NewNopLogger())
+
+	ch := make(chan []builder.AppendInput, 10)
+	add := func(app, line string) {
+		require.NoError(t, i.add(context.Background(), record{
+			tenant: "tenant-a",
+			labels: labels.FromStrings("app", app),
+			entry:  push.Entry{Timestamp: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Line: line},
+		}, ch))
+	}
+
+	add("a", "1234")
+	add("b", "1234")
+	require.Empty(t, ch)
+	// a third stream flushes the pending streams first.
+	add("c", "1234")
+	require.Len(t, ch, 2)
+	require.Len(t, i.pending, 1)
+
+	// reaching the pending bytes flushes all the streams.
+	add("a", "1234")
+	add("a", "12")
+	require.Len(t, ch, 4)
+	require.Empty(t, i.pending)
+	require.Zero(t, i.pendingBytes)
+}
+
+func TestThrottle(t *testing.T) {
+	cfg := testConfig(t)
+	cfg.TenantRateLimit = 1000
+	cfg.TenantRateLimits = map[string]lokiflagext.ByteSize{"unlimited": 0}
+	i := newImporter(cfg, log.NewNopLogger())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	// the burst is the rate limit, larger batches wait for it.
+	require.NoError(t, i.throttle(ctx, "tenant-a", 1000))
+	require.Error(t, i.throttle(ctx, "tenant-a", 500))
+	require.NoError(t, i.throttle(ctx, "tenant-b", 1000))
+	require.NoError(t, i.throttle(ctx, "unlimited", 1<<30))
+}
@@ -0,0 +1,159 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/prometheus/model/labels"
+
+	"github.com/grafana/loki/v3/pkg/compactor/export"
+	"github.com/grafana/loki/v3/pkg/logproto"
+
+	"github.com/grafana/loki/pkg/push"
+)
+
+// record is a log line read from a file.
+type record struct {
+	tenant string
+	labels labels.Labels
+	entry  push.Entry
+}
+
+// jsonRecord is a line of the JSONL files.
+type jsonRecord struct {
+	Tenant             string            `json:"tenant"`
+	Timestamp          jsonTimestamp     `json:"timestamp"`
+	Labels             map[string]string `json:"labels"`
+	StructuredMetadata map[string]string `json:"structured_metadata"`
+	Line               string            `json:"line"`
+}
+
+// jsonTimestamp is either a RFC3339 string or the number of nanoseconds since epoch, as a number or string.
+type jsonTimestamp time.Time
+
+func (t *jsonTimestamp) UnmarshalJSON(b []byte) error {
+	s := string(b)
+	if len(b) > 0 && b[0] == '"' {
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		if ts, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			*t = jsonTimestamp(ts)
+			return nil
+		}
+	}
+	ns, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid timestamp %s, expected RFC3339 or nanoseconds since epoch", b)
+	}
+	*t = jsonTimestamp(time.Unix(0, ns))
+	return nil
+}
+
+// readFile calls fn for every log line of the file. The format of the file is given by its extension:
+// Parquet files use the schema of the compactor export, JSONL files, optionally gzipped, one jsonRecord per line.
+func readFile(path, tenant string, fn func(record) error) error {
+	switch ext := filepath.Ext(strings.TrimSuffix(path, ".gz")); ext {
+	case ".parquet":
+		return readParquet(path, tenant, fn)
+	case ".jsonl", ".ndjson", ".json":
+		return readJSONL(path, tenant, fn)
+	default:
+		return fmt.Errorf("unsupported file format %q, expected .parquet or .jsonl", ext)
+	}
+}
+
+func readParquet(path, tenant string, fn func(record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	reader := parquet.NewGenericReader[export.Row](f)
+	defer reader.Close()
+
+	rows := make([]export.Row, 1024)
+	for {
+		n, err := reader.Read(rows)
+		for _, row := range rows[:n] {
+			if err := fn(record{
+				tenant: tenant,
+				labels: labels.FromMap(row.Labels),
+				entry:  newEntry(time.Unix(0, row.Timestamp), row.Line, row.StructuredMetadata),
+			}); err != nil {
+				return err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func readJSONL(path, tenant string, fn func(record) error) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	reader := bufio.NewReader(r)
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if line = bytes.TrimSpace(line); len(line) > 0 {
+			var jr jsonRecord
+			if err := json.Unmarshal(line, &jr); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+			if jr.Tenant == "" {
+				jr.Tenant = tenant
+			}
+			if err := fn(record{
+				tenant: jr.Tenant,
+				labels: labels.FromMap(jr.Labels),
+				entry:  newEntry(time.Time(jr.Timestamp), jr.Line, jr.StructuredMetadata),
+			}); err != nil {
+				return fmt.Errorf("line %d: %w", n, err)
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func newEntry(ts time.Time, line string, structuredMetadata map[string]string) push.Entry {
+	entry := push.Entry{Timestamp: ts, Line: line}
+	if len(structuredMetadata) > 0 {
+		entry.StructuredMetadata = logproto.FromLabelsToLabelAdapters(labels.FromMap(structuredMetadata))
+	}
+	return entry
+}
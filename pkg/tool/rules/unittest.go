@@ -0,0 +1,476 @@
+package rules
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-kit/log"
+	"github.com/grafana/dskit/user"
+	"github.com/prometheus/common/model"
+	"github.com/prometheus/common/promslog"
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/promql"
+	"github.com/prometheus/prometheus/promql/parser"
+	"github.com/prometheus/prometheus/rules"
+	yaml "gopkg.in/yaml.v3"
+
+	"github.com/grafana/loki/v3/pkg/chunkenc"
+	"github.com/grafana/loki/v3/pkg/compression"
+	"github.com/grafana/loki/v3/pkg/iter"
+	"github.com/grafana/loki/v3/pkg/logproto"
+	"github.com/grafana/loki/v3/pkg/logql"
+	logql_log "github.com/grafana/loki/v3/pkg/logql/log"
+	"github.com/grafana/loki/v3/pkg/logql/syntax"
+	"github.com/grafana/loki/v3/pkg/ruler"
+)
+
+const (
+	defaultEvaluationInterval = time.Minute
+	// unitTestTenant is the tenant the rules are evaluated for.
+	unitTestTenant = "fake"
+)
+
+// UnitTestFile is a file of unit tests of LogQL rules, like the ones of promtool test rules but with log lines as
+// input instead of series.
+type UnitTestFile struct {
+	RuleFiles          []string       `yaml:"rule_files"`
+	EvaluationInterval model.Duration `yaml:"evaluation_interval,omitempty"`
+	Tests              []TestGroup    `yaml:"tests"`
+}
+
+// TestGroup is a set of input streams and the alerts and recorded samples expected from the rules over them.
+type TestGroup struct {
+	Name string `yaml:"name,omitempty"`
+	// Interval is the time between the repetitions of an input line, it defaults to the evaluation interval.
+	Interval           model.Duration      `yaml:"interval,omitempty"`
+	InputStreams       []InputStream       `yaml:"input_streams"`
+	AlertRuleTests     []AlertTestCase     `yaml:"alert_rule_test,omitempty"`
+	RecordingRuleTests []RecordingTestCase `yaml:"recording_rule_test,omitempty"`
+}
+
+// InputStream is a stream of log lines given to the rules.
+type InputStream struct {
+	Labels string      `yaml:"labels"`
+	Lines  []InputLine `yaml:"lines"`
+}
+
+// InputLine is a log line at a time from the start of the test, repeated every interval of the test group when
+// Repeat is set.
+type InputLine struct {
+	Timestamp          model.Duration    `yaml:"ts"`
+	Line               string            `yaml:"line"`
+	StructuredMetadata map[string]string `yaml:"structured_metadata,omitempty"`
+	Repeat             int               `yaml:"repeat,omitempty"`
+}
+
+// AlertTestCase are the alerts expected to fire for an alerting rule at a time from the start of the test.
+type AlertTestCase struct {
+	EvalTime  model.Duration `yaml:"eval_time"`
+	Alertname string         `yaml:"alertname"`
+	ExpAlerts []ExpAlert     `yaml:"exp_alerts"`
+}
+
+// ExpAlert is an expected alert, its labels don't need to include the alertname.
+type ExpAlert struct {
+	ExpLabels      map[string]string `yaml:"exp_labels"`
+	ExpAnnotations map[string]string `yaml:"exp_annotations"`
+}
+
+// RecordingTestCase are the samples expected to be recorded by a recording rule at a time from the start of the
+// test.
+type RecordingTestCase struct {
+	EvalTime   model.Duration `yaml:"eval_time"`
+	Record     string         `yaml:"record"`
+	ExpSamples []ExpSample    `yaml:"exp_samples"`
+}
+
+// ExpSample is an expected sample, its labels don't need to include the metric name.
+type ExpSample struct {
+	Labels string  `yaml:"labels"`
+	Value  float64 `yaml:"value"`
+}
+
+// RunUnitTests runs the rule unit tests of the given file and returns the failed expectations.
+func RunUnitTests(f string) []error {
+	content, err := loadFile(f)
+	if err != nil {
+		return []error{err}
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	var utf UnitTestFile
+	if err := decoder.Decode(&utf); err != nil {
+		return []error{err}
+	}
+
+	// rule files are relative to the test file, like in promtool.
+	files := make([]string, 0, len(utf.RuleFiles))
+	for _, rf := range utf.RuleFiles {
+		if !filepath.IsAbs(rf) {
+			rf = filepath.Join(filepath.Dir(f), rf)
+		}
+		files = append(files, rf)
+	}
+	namespaces, err := ParseFiles(files)
+	if err != nil {
+		return []error{err}
+	}
+
+	evalInterval := time.Duration(utf.EvaluationInterval)
+	if evalInterval == 0 {
+		evalInterval = defaultEvaluationInterval
+	}
+
+	var errs []error
+	for i, tg := range utf.Tests {
+		name := tg.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i)
+		}
+		for _, err := range tg.run(namespaces, evalInterval) {
+			errs = append(errs, fmt.Errorf("test %s: %w", name, err))
+		}
+	}
+	return errs
+}
+
+// unitTestGroup is a rule group evaluated by the unit tests.
+type unitTestGroup struct {
+	interval, queryOffset time.Duration
+	limit                 int
+	rules                 []rules.Rule
+}
+
+func (tg TestGroup) run(namespaces map[string]RuleNamespace, evalInterval time.Duration) []error {
+	groups, err := unitTestGroups(namespaces, evalInterval)
+	if err != nil {
+		return []error{err}
+	}
+
+	interval := time.Duration(tg.Interval)
+	if interval == 0 {
+		interval = evalInterval
+	}
+	q, err := newLocalQuerier(tg.InputStreams, interval)
+	if err != nil {
+		return []error{err}
+	}
+	engine := logql.NewEngine(logql.EngineOpts{}, q, logql.NoLimits, log.NewNopLogger())
+	queryFn := unitTestQueryFunc(engine)
+
+	alertTests := append([]AlertTestCase(nil), tg.AlertRuleTests...)
+	sort.SliceStable(alertTests, func(i, j int) bool { return alertTests[i].EvalTime < alertTests[j].EvalTime })
+	recordingTests := append([]RecordingTestCase(nil), tg.RecordingRuleTests...)
+	sort.SliceStable(recordingTests, func(i, j int) bool { return recordingTests[i].EvalTime < recordingTests[j].EvalTime })
+
+	var maxEvalTime time.Duration
+	if len(alertTests) > 0 {
+		maxEvalTime = time.Duration(alertTests[len(alertTests)-1].EvalTime)
+	}
+	if len(recordingTests) > 0 {
+		maxEvalTime = max(maxEvalTime, time.Duration(recordingTests[len(recordingTests)-1].EvalTime))
+	}
+
+	var (
+		errs []error
+		// recorded holds the samples of the last evaluation of every recording rule.
+		recorded = map[string]promql.Vector{}
+		ctx      = user.InjectOrgID(context.Background(), unitTestTenant)
+		start    = time.Unix(0, 0).UTC()
+	)
+	for ts := time.Duration(0); ts <= maxEvalTime; ts += evalInterval {
+		evaluated := map[string]promql.Vector{}
+		for _, g := range groups {
+			if ts%g.interval != 0 {
+				continue
+			}
+			for _, rule := range g.rules {
+				vec, err := rule.Eval(ctx, g.queryOffset, start.Add(ts), queryFn, nil, g.limit)
+				if err != nil {
+					errs = append(errs, fmt.Errorf("rule %s at %s: %w", rule.Name(), model.Duration(ts), err))
+					continue
+				}
+				if _, ok := rule.(*rules.RecordingRule); ok {
+					evaluated[rule.Name()] = append(evaluated[rule.Name()], vec...)
+				}
+			}
+		}
+		for name, vec := range evaluated {
+			recorded[name] = vec
+		}
+
+		// expectations are checked against the last evaluation at or before their time.
+		for len(alertTests) > 0 && time.Duration(alertTests[0].EvalTime) < ts+evalInterval {
+			if err := alertTests[0].check(groups); err != nil {
+				errs = append(errs, err)
+			}
+			alertTests = alertTests[1:]
+		}
+		for len(recordingTests) > 0 && time.Duration(recordingTests[0].EvalTime) < ts+evalInterval {
+			if err := recordingTests[0].check(recorded[recordingTests[0].Record]); err != nil {
+				errs = append(errs, err)
+			}
+			recordingTests = recordingTests[1:]
+		}
+	}
+	return errs
+}
+
+// unitTestGroups builds the rule groups of the namespaces, ordered by namespace name.
+func unitTestGroups(namespaces map[string]RuleNamespace, evalInterval time.Duration) ([]unitTestGroup, error) {
+	names := make([]string, 0, len(namespaces))
+	for name := range namespaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	logger := promslog.NewNopLogger()
+	var groups []unitTestGroup
+	for _, name := range names {
+		for _, rg := range namespaces[name].Groups {
+			g := unitTestGroup{interval: time.Duration(rg.Interval), limit: rg.Limit}
+			if g.interval == 0 {
+				g.interval = evalInterval
+			}
+			if rg.QueryOffset != nil {
+				g.queryOffset = time.Duration(*rg.QueryOffset)
+			}
+			for _, rn := range rg.Rules {
+				expr, err := ruler.GroupLoader{}.Parse(rn.Expr.Value)
+				if err != nil {
+					return nil, fmt.Errorf("group %s: %w", rg.Name, err)
+				}
+				lbs := labels.FromMap(rn.Labels)
+				if rn.Alert.Value != "" {
+					g.rules = append(g.rules, rules.NewAlertingRule(
+						rn.Alert.Value, expr, time.Duration(rn.For), time.Duration(rn.KeepFiringFor),
+						lbs, labels.FromMap(rn.Annotations), labels.EmptyLabels(), "", false, logger,
+					))
+					continue
+				}
+				g.rules = append(g.rules, rules.NewRecordingRule(rn.Record.Value, expr, lbs))
+			}
+			groups = append(groups, g)
+		}
+	}
+	return groups, nil
+}
+
+// unitTestQueryFunc evaluates the rule queries with the engine like the local evaluator of the ruler.
+func unitTestQueryFunc(engine *logql.Engine) rules.QueryFunc {
+	return func(ctx context.Context, qs string, t time.Time) (promql.Vector, error) {
+		params, err := logql.NewLiteralParams(qs, t, t, 0, 0, logproto.FORWARD, 0, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		res, err := engine.Query(params).Exec(ctx)
+		if err != nil {
+			return nil, err
+		}
+		switch v := res.Data.(type) {
+		case promql.Vector:
+			return v, nil
+		case promql.Scalar:
+			return promql.Vector{promql.Sample{T: v.T, F: v.V, Metric: labels.EmptyLabels()}}, nil
+		default:
+			return nil, fmt.Errorf("rule result is not a vector or scalar")
+		}
+	}
+}
+
+func (tc AlertTestCase) check(groups []unitTestGroup) error {
+	var got []string
+	for _, g := range groups {
+		for _, rule := range g.rules {
+			ar, ok := rule.(*rules.AlertingRule)
+			if !ok || ar.Name() != tc.Alertname {
+				continue
+			}
+			for _, a := range ar.ActiveAlerts() {
+				if a.State == rules.StateFiring {
+					got = append(got, formatAlert(a.Labels, a.Annotations))
+				}
+			}
+		}
+	}
+
+	exp := make([]string, 0, len(tc.ExpAlerts))
+	for _, a := range tc.ExpAlerts {
+		b := labels.NewBuilder(labels.FromMap(a.ExpLabels))
+		b.Set(labels.AlertName, tc.Alertname)
+		exp = append(exp, formatAlert(b.Labels(), labels.FromMap(a.ExpAnnotations)))
+	}
+
+	sort.Strings(got)
+	sort.Strings(exp)
+	if strings.Join(got, "\n") != strings.Join(exp, "\n") {
+		return fmt.Errorf("alertname: %s, time: %s, exp: %v, got: %v", tc.Alertname, tc.EvalTime, exp, got)
+	}
+	return nil
+}
+
+func formatAlert(lbs, annotations labels.Labels) string {
+	return fmt.Sprintf("Labels:%s Annotations:%s", lbs, annotations)
+}
+
+func (tc RecordingTestCase) check(vec promql.Vector) error {
+	exp := make([]promql.Sample, 0, len(tc.ExpSamples))
+	for _, s := range tc.ExpSamples {
+		lbs, err := parser.ParseMetric(s.Labels)
+		if err != nil {
+			return fmt.Errorf("record: %s, time: %s: invalid labels %q: %w", tc.Record, tc.EvalTime, s.Labels, err)
+		}
+		if !lbs.Has(labels.MetricName) {
+			lbs = labels.NewBuilder(lbs).Set(labels.MetricName, tc.Record).Labels()
+		}
+		exp = append(exp, promql.Sample{Metric: lbs, F: s.Value})
+	}
+	got := append(promql.Vector(nil), vec...)
+
+	for _, v := range []promql.Vector{exp, got} {
+		sort.Slice(v, func(i, j int) bool { return labels.Compare(v[i].Metric, v[j].Metric) < 0 })
+	}
+	equal := len(exp) == len(got)
+	for i := 0; equal && i < len(exp); i++ {
+		equal = labels.Equal(exp[i].Metric, got[i].Metric) && almostEqual(exp[i].F, got[i].F)
+	}
+	if !equal {
+		return fmt.Errorf("record: %s, time: %s, exp: %s, got: %s", tc.Record, tc.EvalTime, formatSamples(exp), formatSamples(got))
+	}
+	return nil
+}
+
+func formatSamples(v []promql.Sample) string {
+	s := make([]string, 0, len(v))
+	for _, smpl := range v {
+		s = append(s, fmt.Sprintf("%s %v", smpl.Metric, smpl.F))
+	}
+	return "[" + strings.Join(s, ", ") + "]"
+}
+
+// almostEqual compares the values with the tolerance promtool uses.
+func almostEqual(a, b float64) bool {
+	const epsilon = 1e-6
+	if math.IsNaN(a) && math.IsNaN(b) {
+		return true
+	}
+	if a == b {
+		return true
+	}
+	diff := math.Abs(a - b)
+	if a == 0 || b == 0 || diff < math.SmallestNonzeroFloat64 {
+		return diff < epsilon*math.SmallestNonzeroFloat64
+	}
+	return diff/(math.Abs(a)+math.Abs(b)) < epsilon
+}
+
+// localQuerier is a logql.Querier over the input streams of a test group, held in memory chunks so that the
+// queries process them like the ones of an ingester.
+type localQuerier struct {
+	streams []localStream
+}
+
+type localStream struct {
+	labels labels.Labels
+	chunk  *chunkenc.MemChunk
+}
+
+func newLocalQuerier(inputs []InputStream, interval time.Duration) (*localQuerier, error) {
+	q := &localQuerier{}
+	start := time.Unix(0, 0).UTC()
+	for _, in := range inputs {
+		lbs, err := syntax.ParseLabels(in.Labels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid input stream labels %q: %w", in.Labels, err)
+		}
+
+		var entries []logproto.Entry
+		for _, l := range in.Lines {
+			metadata := logproto.FromLabelsToLabelAdapters(labels.FromMap(l.StructuredMetadata))
+			for i := 0; i < max(l.Repeat, 1); i++ {
+				entries = append(entries, logproto.Entry{
+					Timestamp:          start.Add(time.Duration(l.Timestamp) + time.Duration(i)*interval),
+					Line:               l.Line,
+					StructuredMetadata: metadata,
+				})
+			}
+		}
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].Timestamp.Before(entries[j].Timestamp) })
+
+		chk := chunkenc.NewMemChunk(chunkenc.ChunkFormatV4, compression.None, chunkenc.UnorderedWithStructuredMetadataHeadBlockFmt, 256<<10, 0)
+		for i := range entries {
+			if _, err := chk.Append(&entries[i]); err != nil {
+				return nil, err
+			}
+		}
+		q.streams = append(q.streams, localStream{labels: lbs, chunk: chk})
+	}
+	return q, nil
+}
+
+func (q *localQuerier) matching(selector syntax.LogSelectorExpr) []localStream {
+	var matched []localStream
+outer:
+	for _, s := range q.streams {
+		for _, m := range selector.Matchers() {
+			if !m.Matches(s.labels.Get(m.Name)) {
+				continue outer
+			}
+		}
+		matched = append(matched, s)
+	}
+	return matched
+}
+
+func (q *localQuerier) SelectLogs(ctx context.Context, req logql.SelectLogParams) (iter.EntryIterator, error) {
+	selector, err := req.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	pipeline, err := selector.Pipeline()
+	if err != nil {
+		return nil, err
+	}
+
+	var its []iter.EntryIterator
+	for _, s := range q.matching(selector) {
+		it, err := s.chunk.Iterator(ctx, req.Start, req.End, req.Direction, pipeline.ForStream(s.labels))
+		if err != nil {
+			return nil, err
+		}
+		its = append(its, it)
+	}
+	return iter.NewSortEntryIterator(its, req.Direction), nil
+}
+
+func (q *localQuerier) SelectSamples(ctx context.Context, req logql.SelectSampleParams) (iter.SampleIterator, error) {
+	selector, err := req.LogSelector()
+	if err != nil {
+		return nil, err
+	}
+	expr, err := req.Expr()
+	if err != nil {
+		return nil, err
+	}
+	extractors, err := expr.Extractors()
+	if err != nil {
+		return nil, err
+	}
+
+	var its []iter.SampleIterator
+	for _, s := range q.matching(selector) {
+		streamExtractors := make([]logql_log.StreamSampleExtractor, 0, len(extractors))
+		for _, ex := range extractors {
+			streamExtractors = append(streamExtractors, ex.ForStream(s.labels))
+		}
+		its = append(its, s.chunk.SampleIterator(ctx, req.Start, req.End, streamExtractors...))
+	}
+	return iter.NewSortSampleIterator(its), nil
+}
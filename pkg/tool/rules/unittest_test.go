@@ -0,0 +1,20 @@
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunUnitTests(t *testing.T) {
+	require.Empty(t, RunUnitTests("testdata/unittest/test.yaml"))
+
+	errs := RunUnitTests("testdata/unittest/test_failure.yaml")
+	require.Len(t, errs, 2)
+	// the failures are reported in the order of their evaluation time, only one slow request is logged.
+	require.ErrorContains(t, errs[0], "test wrong expectations: record: app:slow_requests:count5m, time: 2m")
+	require.ErrorContains(t, errs[0], `got: [{__name__="app:slow_requests:count5m", app="api", route="/users"} 1]`)
+	// the alert is still pending at 3m.
+	require.ErrorContains(t, errs[1], "test wrong expectations: alertname: APIErrors, time: 3m")
+	require.ErrorContains(t, errs[1], "got: []")
+}
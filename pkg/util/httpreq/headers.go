@@ -14,6 +14,8 @@ var (
 	// LokiActorPathHeader is the name of the header e.g. used to enqueue requests in hierarchical queues.
 	LokiActorPathHeader               = "X-Loki-Actor-Path"
 	LokiDisablePipelineWrappersHeader = "X-Loki-Disable-Pipeline-Wrappers"
+	// LokiFederatedHeader is the name of the header marking queries sent by another cluster.
+	LokiFederatedHeader = "X-Loki-Federated"
 
 	// LokiActorPathDelimiter is the delimiter used to serialise the hierarchy of the actor.
 	LokiActorPathDelimiter = "|"
@@ -89,6 +89,7 @@ var queryTests = []struct {
 					"totalReached": 0
 				},
 				"querier": {
+					"queryMemoryBytes": 0,
 					"store": {
 						"chunksDownloadTime": 0,
 						"congestionControlLatency": 0,
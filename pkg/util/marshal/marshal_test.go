@@ -58,6 +58,7 @@ const emptyStats = `{
 		"totalReached": 0
 	},
 	"querier": {
+		"queryMemoryBytes": 0,
 		"store": {
 			"chunksDownloadTime": 0,
 			"congestionControlLatency": 0,
@@ -103,6 +103,7 @@ type Limits struct {
 	// Querier enforced limits.
 	MaxChunksPerQuery          int              `yaml:"max_chunks_per_query" json:"max_chunks_per_query"`
 	MaxQuerySeries             int              `yaml:"max_query_series" json:"max_query_series"`
+	MaxQueryMemory             flagext.ByteSize `yaml:"max_query_memory" json:"max_query_memory"`
 	MaxQueryLookback           model.Duration   `yaml:"max_query_lookback" json:"max_query_lookback"`
 	MaxQueryLength             model.Duration   `yaml:"max_query_length" json:"max_query_length"`
 	MaxQueryRange              model.Duration   `yaml:"max_query_range" json:"max_query_range"`
@@ -183,11 +184,16 @@ type Limits struct {
 	RulerRemoteEvaluationMaxResponseSize int64         `yaml:"ruler_remote_evaluation_max_response_size" json:"ruler_remote_evaluation_max_response_size" doc:"description=Maximum size (in bytes) of the allowable response size from a remote rule evaluation. Set to 0 to allow any response size (default)."`
 
 	// Global and per tenant deletion mode
-	DeletionMode string `yaml:"deletion_mode" json:"deletion_mode"`
+	DeletionMode             string `yaml:"deletion_mode" json:"deletion_mode"`
+	DeletionRequiresApproval bool   `yaml:"deletion_requires_approval" json:"deletion_requires_approval"`
 
 	// Global and per tenant retention
 	RetentionPeriod model.Duration    `yaml:"retention_period" json:"retention_period"`
 	StreamRetention []StreamRetention `yaml:"retention_stream,omitempty" json:"retention_stream,omitempty" doc:"description=Per-stream retention to apply, if the retention is enabled on the compactor side.\nExample:\n retention_stream:\n - selector: '{namespace=\"dev\"}'\n priority: 1\n period: 24h\n- selector: '{container=\"nginx\"}'\n priority: 1\n period: 744h\nSelector is a Prometheus labels matchers that will apply the 'period' retention only if the stream is matching. In case multiple streams are matching, the highest priority will be picked. If no rule is matched the 'retention_period' is used."`
+	RetentionRules  []RetentionRule   `yaml:"retention_rules,omitempty" json:"retention_rules,omitempty" doc:"description=Per-line retention to apply, if the retention is enabled on the compactor side. The compactor deletes the log lines matching the LogQL query of a rule once they are older than its period, rewriting the chunks like delete requests with line filters do. The query must have line filters, label filters or parsers, streams are matched with 'retention_stream' instead. If both a stream retention and a rule apply to a log line, the shortest period wins."`
+
+	// Per tenant storage tier delays
+	StorageTierAfter map[string]model.Duration `yaml:"storage_tier_after" json:"storage_tier_after" category:"experimental" doc:"description=Per-tenant delay after which the compactor moves chunks to a storage tier, keyed by the object_store of the tier. Overrides the 'after' of the storage_tiers of the schema config.\nExample:\n storage_tier_after:\n  cold-bucket: 2160h"`
 
 	// Config for overrides, convenient if it goes here.
 	PerTenantOverrideConfig string         `yaml:"per_tenant_override_config" json:"per_tenant_override_config"`
@@ -221,6 +227,10 @@ type Limits struct {
 	BloomMaxBlockSize flagext.ByteSize `yaml:"bloom_max_block_size" json:"bloom_max_block_size" category:"experimental"`
 	BloomMaxBloomSize flagext.ByteSize `yaml:"bloom_max_bloom_size" json:"bloom_max_bloom_size" category:"experimental"`
 
+	BloomLineTokenizer string `yaml:"bloom_line_tokenizer" json:"bloom_line_tokenizer" category:"experimental"`
+	BloomNGramLength   int    `yaml:"bloom_ngram_length" json:"bloom_ngram_length" category:"experimental"`
+	BloomNGramSkip     int    `yaml:"bloom_ngram_skip" json:"bloom_ngram_skip" category:"experimental"`
+
 	AllowStructuredMetadata           bool                  `yaml:"allow_structured_metadata,omitempty" json:"allow_structured_metadata,omitempty" doc:"description=Allow user to send structured metadata in push payload."`
 	MaxStructuredMetadataSize         flagext.ByteSize      `yaml:"max_structured_metadata_size" json:"max_structured_metadata_size" doc:"description=Maximum size accepted for structured metadata per log line."`
 	MaxStructuredMetadataEntriesCount int                   `yaml:"max_structured_metadata_entries_count" json:"max_structured_metadata_entries_count" doc:"description=Maximum number of structured metadata entries per log line."`
@@ -261,6 +271,14 @@ type StreamRetention struct {
 	Matchers []*labels.Matcher `yaml:"-" json:"-"` // populated during validation.
 }
 
+type RetentionRule struct {
+	Name   string         `yaml:"name" json:"name" doc:"description:Name of the rule, used in the metrics."`
+	Period model.Duration `yaml:"period" json:"period" doc:"description:Retention period applied to the log lines matching the query."`
+	Query  string         `yaml:"query" json:"query" doc:"description:LogQL log query selecting the log lines."`
+
+	Expr syntax.LogSelectorExpr `yaml:"-" json:"-"` // populated during validation.
+}
+
 // LimitError are errors that do not comply with the limits specified.
 type LimitError string
 
@@ -326,6 +344,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	_ = l.MaxQueryLength.Set("721h")
 	f.Var(&l.MaxQueryLength, "store.max-query-length", "The limit to length of chunk store queries. 0 to disable.")
 	f.IntVar(&l.MaxQuerySeries, "querier.max-query-series", 500, "Limit the maximum of unique series that is returned by a metric query. When the limit is reached an error is returned.")
+	f.Var(&l.MaxQueryMemory, "querier.max-query-memory", "Maximum amount of memory a single query can hold in a querier while it is evaluated, such as chunks fetched from the store, responses from ingesters, buffered results and series of metric queries. Split and sharded queries are limited per subquery executed by a querier, the query-frontend does not enforce the limit when merging their results. When the limit is reached the query is cancelled and an error is returned. The default value of 0 disables this limit.")
 	_ = l.MaxQueryRange.Set("0s")
 	f.Var(&l.MaxQueryRange, "querier.max-query-range", "Limit the length of the [range] inside a range query. Default is 0 or unlimited")
 	_ = l.QueryTimeout.Set(DefaultPerTenantQueryTimeout)
@@ -400,6 +419,7 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 	f.Var(&l.IngesterQuerySplitDuration, "querier.split-ingester-queries-by-interval", "Interval to use for time-based splitting when a request is within the `query_ingesters_within` window; defaults to `split-queries-by-interval` by setting to 0.")
 
 	f.StringVar(&l.DeletionMode, "compactor.deletion-mode", "filter-and-delete", "Deletion mode. Can be one of 'disabled', 'filter-only', or 'filter-and-delete'. When set to 'filter-only' or 'filter-and-delete', and if retention_enabled is true, then the log entry deletion API endpoints are available.")
+	f.BoolVar(&l.DeletionRequiresApproval, "compactor.deletion-requires-approval", false, "Require delete requests to be previewed with the dry_run parameter and the preview to be approved by another user before the delete request is added. Requires the delete request previews to be enabled on the compactor.")
 
 	// Deprecated
 	dskit_flagext.DeprecatedFlag(f, "compactor.allow-deletes", "Deprecated. Instead, see compactor.deletion-mode which is another per tenant configuration", util_log.Logger)
@@ -435,6 +455,9 @@ func (l *Limits) RegisterFlags(f *flag.FlagSet) {
 			defaultBloomBuildMaxBloomSize,
 		),
 	)
+	f.StringVar(&l.BloomLineTokenizer, "bloom-build.line-tokenizer", "none", "Experimental. How log lines are tokenized into blooms, allowing the bloom gateways to filter chunks for the literals of '|=' line filters. Can be one of: 'none', which only indexes structured metadata, 'ngram', which indexes the n-grams of log lines, or 'words', which indexes the words of log lines split on whitespace and punctuation. Only the blooms built after changing it use the new tokenizer.")
+	f.IntVar(&l.BloomNGramLength, "bloom-build.ngram-length", 4, "Experimental. Only if `bloom-build.line-tokenizer` is 'ngram'. Number of characters of the n-grams of log lines. Line filters shorter than this are not filtered by blooms.")
+	f.IntVar(&l.BloomNGramSkip, "bloom-build.ngram-skip", 1, "Experimental. Only if `bloom-build.line-tokenizer` is 'ngram'. Number of n-grams skipped after each n-gram indexed into blooms. Higher values build smaller blooms, at the cost of requiring longer line filters.")
 
 	l.ShardStreams.RegisterFlagsWithPrefix("shard-streams", f)
 	f.IntVar(&l.VolumeMaxSeries, "limits.volume-max-series", 1000, "The default number of aggregated series or labels that can be returned from a log-volume endpoint")
@@ -515,6 +538,30 @@ func (l *Limits) Validate() error {
 		}
 	}
 
+	names := make(map[string]struct{}, len(l.RetentionRules))
+	for i, rule := range l.RetentionRules {
+		if rule.Name == "" {
+			return errors.New("retention rule name must be set")
+		}
+		if _, ok := names[rule.Name]; ok {
+			return fmt.Errorf("duplicate retention rule name %s", rule.Name)
+		}
+		names[rule.Name] = struct{}{}
+
+		expr, err := syntax.ParseLogSelector(rule.Query, true)
+		if err != nil {
+			return fmt.Errorf("invalid query of retention rule %s: %w", rule.Name, err)
+		}
+		if !expr.HasFilter() {
+			return fmt.Errorf("query of retention rule %s has no filter, use retention_stream to apply a retention period to whole streams", rule.Name)
+		}
+		if time.Duration(rule.Period) < 24*time.Hour {
+			return fmt.Errorf("retention period of rule %s must be >= 24h was %s", rule.Name, rule.Period)
+		}
+		// populate the expression during validation
+		l.RetentionRules[i].Expr = expr
+	}
+
 	if l.PolicyStreamMapping != nil {
 		if err := l.PolicyStreamMapping.Validate(); err != nil {
 			return err
@@ -551,6 +598,19 @@ func (l *Limits) Validate() error {
 		return err
 	}
 
+	switch l.BloomLineTokenizer {
+	case "", "none", "words":
+	case "ngram":
+		if l.BloomNGramLength < 1 || l.BloomNGramLength > 255 {
+			return errors.New("bloom-build.ngram-length must be between 1 and 255")
+		}
+		if l.BloomNGramSkip < 0 || l.BloomNGramSkip > 255 {
+			return errors.New("bloom-build.ngram-skip must be between 0 and 255")
+		}
+	default:
+		return fmt.Errorf("invalid bloom-build.line-tokenizer %q, expected one of none, ngram or words", l.BloomLineTokenizer)
+	}
+
 	if l.TSDBMaxBytesPerShard <= 0 {
 		return errors.New("querier.tsdb-max-bytes-per-shard must be greater than 0")
 	}
@@ -687,6 +747,11 @@ func (o *Overrides) MaxQuerySeries(_ context.Context, userID string) int {
 	return o.getOverridesForUser(userID).MaxQuerySeries
 }
 
+// MaxQueryMemory returns the maximum bytes a single query can hold in a querier.
+func (o *Overrides) MaxQueryMemory(_ context.Context, userID string) int {
+	return o.getOverridesForUser(userID).MaxQueryMemory.Val()
+}
+
 // MaxQueryRange returns the limit for the max [range] value that can be in a range query
 func (o *Overrides) MaxQueryRange(_ context.Context, userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).MaxQueryRange)
@@ -966,11 +1031,22 @@ func (o *Overrides) RetentionPeriod(userID string) time.Duration {
 	return time.Duration(o.getOverridesForUser(userID).RetentionPeriod)
 }
 
+// StorageTierAfter returns the delay after which chunks of a given user are moved
+// to the storage tier using the given object store, or zero if it is not overridden.
+func (o *Overrides) StorageTierAfter(userID, objectStore string) time.Duration {
+	return time.Duration(o.getOverridesForUser(userID).StorageTierAfter[objectStore])
+}
+
 // StreamRetention returns the retention period for a given user.
 func (o *Overrides) StreamRetention(userID string) []StreamRetention {
 	return o.getOverridesForUser(userID).StreamRetention
 }
 
+// RetentionRules returns the per-line retention rules for a given user.
+func (o *Overrides) RetentionRules(userID string) []RetentionRule {
+	return o.getOverridesForUser(userID).RetentionRules
+}
+
 func (o *Overrides) UnorderedWrites(userID string) bool {
 	return o.getOverridesForUser(userID).UnorderedWrites
 }
@@ -979,6 +1055,10 @@ func (o *Overrides) DeletionMode(userID string) string {
 	return o.getOverridesForUser(userID).DeletionMode
 }
 
+func (o *Overrides) DeletionRequiresApproval(userID string) bool {
+	return o.getOverridesForUser(userID).DeletionRequiresApproval
+}
+
 func (o *Overrides) ShardStreams(userID string) shardstreams.Config {
 	return o.getOverridesForUser(userID).ShardStreams
 }
@@ -1093,6 +1173,18 @@ func (o *Overrides) BloomBlockEncoding(userID string) string {
 	return o.getOverridesForUser(userID).BloomBlockEncoding
 }
 
+func (o *Overrides) BloomLineTokenizer(userID string) string {
+	return o.getOverridesForUser(userID).BloomLineTokenizer
+}
+
+func (o *Overrides) BloomNGramLength(userID string) int {
+	return o.getOverridesForUser(userID).BloomNGramLength
+}
+
+func (o *Overrides) BloomNGramSkip(userID string) int {
+	return o.getOverridesForUser(userID).BloomNGramSkip
+}
+
 func (o *Overrides) AllowStructuredMetadata(userID string) bool {
 	return o.getOverridesForUser(userID).AllowStructuredMetadata
 }
@@ -232,6 +232,7 @@ ruler_remote_write_headers:
 				PolicyEnforcedLabels:      map[string][]string{},
 				PolicyStreamMapping:       PolicyStreamMapping{},
 				BlockIngestionPolicyUntil: map[string]dskit_flagext.Time{},
+				StorageTierAfter:          map[string]model.Duration{},
 			},
 		},
 		{
@@ -255,6 +256,7 @@ ruler_remote_write_headers:
 				PolicyEnforcedLabels:      map[string][]string{},
 				PolicyStreamMapping:       PolicyStreamMapping{},
 				BlockIngestionPolicyUntil: map[string]dskit_flagext.Time{},
+				StorageTierAfter:          map[string]model.Duration{},
 			},
 		},
 		{
@@ -282,6 +284,7 @@ retention_stream:
 				PolicyEnforcedLabels:      map[string][]string{},
 				PolicyStreamMapping:       PolicyStreamMapping{},
 				BlockIngestionPolicyUntil: map[string]dskit_flagext.Time{},
+				StorageTierAfter:          map[string]model.Duration{},
 			},
 		},
 		{
@@ -308,6 +311,7 @@ reject_old_samples: true
 				PolicyEnforcedLabels:      map[string][]string{},
 				PolicyStreamMapping:       PolicyStreamMapping{},
 				BlockIngestionPolicyUntil: map[string]dskit_flagext.Time{},
+				StorageTierAfter:          map[string]model.Duration{},
 			},
 		},
 		{
@@ -335,6 +339,7 @@ query_timeout: 5m
 				PolicyEnforcedLabels:      map[string][]string{},
 				PolicyStreamMapping:       PolicyStreamMapping{},
 				BlockIngestionPolicyUntil: map[string]dskit_flagext.Time{},
+				StorageTierAfter:          map[string]model.Duration{},
 			},
 		},
 	} {
@@ -386,6 +391,60 @@ func TestLimitsValidation(t *testing.T) {
 	}
 }
 
+func TestLimitsValidation_RetentionRules(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		rules    []RetentionRule
+		expected string
+	}{
+		{
+			name:  "valid",
+			rules: []RetentionRule{{Name: "debug", Query: `{app="foo"} | level="debug"`, Period: model.Duration(7 * 24 * time.Hour)}},
+		},
+		{
+			name:     "missing name",
+			rules:    []RetentionRule{{Query: `{app="foo"} |= "debug"`, Period: model.Duration(7 * 24 * time.Hour)}},
+			expected: "retention rule name must be set",
+		},
+		{
+			name: "duplicate name",
+			rules: []RetentionRule{
+				{Name: "debug", Query: `{app="foo"} |= "debug"`, Period: model.Duration(7 * 24 * time.Hour)},
+				{Name: "debug", Query: `{app="bar"} |= "debug"`, Period: model.Duration(7 * 24 * time.Hour)},
+			},
+			expected: "duplicate retention rule name debug",
+		},
+		{
+			name:     "invalid query",
+			rules:    []RetentionRule{{Name: "debug", Query: `{app="foo"`, Period: model.Duration(7 * 24 * time.Hour)}},
+			expected: "invalid query of retention rule debug",
+		},
+		{
+			name:     "no filter",
+			rules:    []RetentionRule{{Name: "debug", Query: `{app="foo"}`, Period: model.Duration(7 * 24 * time.Hour)}},
+			expected: "query of retention rule debug has no filter",
+		},
+		{
+			name:     "period too short",
+			rules:    []RetentionRule{{Name: "debug", Query: `{app="foo"} |= "debug"`, Period: model.Duration(time.Hour)}},
+			expected: "retention period of rule debug must be >= 24h",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			limits := Limits{DeletionMode: "disabled", BloomBlockEncoding: "none", RetentionRules: tc.rules}
+			limits.TSDBShardingStrategy = logql.PowerOfTwoVersion.String()
+			limits.TSDBMaxBytesPerShard = DefaultTSDBMaxBytesPerShard
+			err := limits.Validate()
+			if tc.expected == "" {
+				require.NoError(t, err)
+				require.NotNil(t, limits.RetentionRules[0].Expr)
+				return
+			}
+			require.ErrorContains(t, err, tc.expected)
+		})
+	}
+}
+
 func Test_PatternIngesterTokenizableJSONFields(t *testing.T) {
 	for _, tc := range []struct {
 		name     string
@@ -32,6 +32,7 @@ import (
 	"github.com/grafana/loki/v3/pkg/ruler"
 	"github.com/grafana/loki/v3/pkg/runtime"
 	"github.com/grafana/loki/v3/pkg/scheduler"
+	"github.com/grafana/loki/v3/pkg/scrubber"
 	"github.com/grafana/loki/v3/pkg/storage"
 	"github.com/grafana/loki/v3/pkg/storage/bucket"
 	"github.com/grafana/loki/v3/pkg/storage/chunk/cache"
@@ -124,6 +125,11 @@ var (
 			StructType: []reflect.Type{reflect.TypeOf(compactor.Config{})},
 			Desc:       "The compactor block configures the compactor component, which compacts index shards for performance.",
 		},
+		{
+			Name:       "scrubber",
+			StructType: []reflect.Type{reflect.TypeOf(scrubber.Config{})},
+			Desc:       "Experimental: The scrubber block configures the scrubber component, which verifies the integrity of the chunks and indexes in the object stores.",
+		},
 		{
 			Name:       "bloom_gateway",
 			StructType: []reflect.Type{reflect.TypeOf(bloomgateway.Config{})},